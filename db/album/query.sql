@@ -3,21 +3,31 @@ INSERT INTO albums (id, user_id, name, description, created_at, updated_at)
 VALUES (?, ?, ?, ?, datetime('now'), datetime('now'));
 
 -- name: GetAlbumByID :one
-SELECT id, user_id, name, description, created_at, updated_at
+SELECT id, user_id, name, description, created_at, updated_at, position
 FROM albums
 WHERE id = ?;
 
 -- name: ListAlbumsByUserID :many
-SELECT id, user_id, name, description, created_at, updated_at
+SELECT id, user_id, name, description, created_at, updated_at, position
 FROM albums
 WHERE user_id = ?
-ORDER BY created_at DESC;
+ORDER BY position IS NULL, position ASC, created_at DESC;
 
 -- name: UpdateAlbum :exec
 UPDATE albums
 SET name = ?, description = ?, updated_at = datetime('now')
 WHERE id = ?;
 
+-- name: ClearAlbumPositionsByUserID :exec
+UPDATE albums
+SET position = NULL
+WHERE user_id = ?;
+
+-- name: SetAlbumPosition :exec
+UPDATE albums
+SET position = ?
+WHERE id = ? AND user_id = ?;
+
 -- name: DeleteAlbum :exec
 DELETE FROM albums
 WHERE id = ?;
@@ -37,13 +47,34 @@ WHERE album_id = ?
 ORDER BY added_at DESC;
 
 -- name: ListAlbumsByMediaID :many
-SELECT a.id, a.user_id, a.name, a.description, a.created_at, a.updated_at
+SELECT a.id, a.user_id, a.name, a.description, a.created_at, a.updated_at, a.position
 FROM albums a
 JOIN album_media am ON a.id = am.album_id
 WHERE am.media_id = ?
 ORDER BY a.created_at DESC;
 
 -- name: GetDefaultAlbumByUserID :one
-SELECT id, user_id, name, description, created_at, updated_at
+SELECT id, user_id, name, description, created_at, updated_at, position
 FROM albums
 WHERE user_id = ? AND name = 'All Media';
+
+-- name: EnqueueOutboxEvent :exec
+INSERT INTO event_outbox (aggregate_id, aggregate_type, event_type, data, user_id, correlation_id, causation_id, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'));
+
+-- name: ListPendingOutboxEvents :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, user_id, attempts, last_error, created_at, delivered_at, correlation_id, causation_id
+FROM event_outbox
+WHERE delivered_at IS NULL
+ORDER BY id ASC
+LIMIT ?;
+
+-- name: MarkOutboxEventDelivered :exec
+UPDATE event_outbox
+SET delivered_at = datetime('now')
+WHERE id = ?;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE event_outbox
+SET attempts = attempts + 1, last_error = ?
+WHERE id = ?;
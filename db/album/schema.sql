@@ -14,7 +14,9 @@ CREATE TABLE IF NOT EXISTS albums (
     -- 作成日時
     created_at DATETIME NOT NULL DEFAULT (datetime('now')),
     -- 更新日時
-    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    -- ユーザーが指定した一覧での表示順（0始まり）。未指定の場合はNULLで、指定済みのアルバムの後に作成日時順で並ぶ
+    position INTEGER
 );
 
 CREATE TABLE IF NOT EXISTS album_media (
@@ -32,6 +34,45 @@ CREATE TABLE IF NOT EXISTS album_media (
 CREATE INDEX IF NOT EXISTS idx_albums_user_id
     ON albums(user_id);
 
+-- ユーザーごとの表示順での一覧取得を高速化するインデックス。
+CREATE INDEX IF NOT EXISTS idx_albums_user_position
+    ON albums(user_id, position);
+
 -- メディアIDでの逆引き検索を高速化するインデックス。
 CREATE INDEX IF NOT EXISTS idx_album_media_media_id
     ON album_media(media_id);
+
+-- トランザクショナルアウトボックス。
+-- アルバムの変更と同一トランザクションでイベントを記録し、
+-- バックグラウンドのリレーがEvent Storeへ配送する。
+-- Event Storeが停止していてもイベントが失われないようにするためのテーブル。
+CREATE TABLE IF NOT EXISTS event_outbox (
+    -- 記録順の連番。配送はこの順序で行う。
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- 対象エンティティの識別子（例: album-xxx）
+    aggregate_id TEXT NOT NULL,
+    -- 対象エンティティの種類
+    aggregate_type TEXT NOT NULL,
+    -- イベントの種類
+    event_type TEXT NOT NULL,
+    -- イベント固有のデータ（JSON形式）
+    data TEXT NOT NULL,
+    -- イベントを発生させたユーザーのID（X-User-IDヘッダーとして伝搬する）
+    user_id TEXT NOT NULL DEFAULT '',
+    -- 配送試行回数
+    attempts INTEGER NOT NULL DEFAULT 0,
+    -- 最後の配送エラーメッセージ
+    last_error TEXT NOT NULL DEFAULT '',
+    -- 記録日時
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    -- 配送完了日時（未配送の場合はNULL）
+    delivered_at DATETIME,
+    -- イベントのmetadata.correlation_id（X-Correlation-IDヘッダーとして伝搬する）
+    correlation_id TEXT NOT NULL DEFAULT '',
+    -- イベントのmetadata.causation_id（X-Causation-IDヘッダーとして伝搬する。起点のイベントは空文字）
+    causation_id TEXT NOT NULL DEFAULT ''
+);
+
+-- 未配送イベントの取得を高速化する部分インデックス。
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending
+    ON event_outbox(id) WHERE delivered_at IS NULL;
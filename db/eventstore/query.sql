@@ -1,27 +1,54 @@
 -- name: AppendEvent :exec
-INSERT INTO events (id, aggregate_id, aggregate_type, event_type, data, version, created_at)
-VALUES (?, ?, ?, ?, ?, ?, ?);
+INSERT INTO events (id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetEventByID :one
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+WHERE id = ?;
+
+-- name: CountEventRetractions :one
+SELECT COUNT(*)
+FROM events
+WHERE aggregate_id = ?
+  AND event_type = 'EventRetracted'
+  AND json_extract(data, '$.retracted_event_id') = sqlc.arg(retracted_event_id);
 
 -- name: GetEventsByAggregateID :many
-SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
 FROM events
 WHERE aggregate_id = ?
 ORDER BY version ASC;
 
 -- name: GetEventsByType :many
-SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
 FROM events
 WHERE event_type = ?
 ORDER BY created_at ASC;
 
 -- name: GetEventsSince :many
-SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
 FROM events
 WHERE created_at > ?
-ORDER BY created_at ASC;
+ORDER BY created_at ASC, version ASC, rowid ASC
+LIMIT ?;
+
+-- name: GetEventsSinceByType :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+WHERE created_at > ? AND event_type = ?
+ORDER BY created_at ASC, version ASC, rowid ASC
+LIMIT ?;
+
+-- name: GetEventsSinceByTypes :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+WHERE created_at > ? AND event_type IN (sqlc.slice('event_types'))
+ORDER BY created_at ASC, version ASC, rowid ASC
+LIMIT ?;
 
 -- name: GetEventsByAggregateType :many
-SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
 FROM events
 WHERE aggregate_type = ?
 ORDER BY created_at ASC;
@@ -31,7 +58,116 @@ SELECT COALESCE(MAX(version), 0) AS latest_version
 FROM events
 WHERE aggregate_id = ?;
 
+-- name: GetLatestEventCreatedAt :one
+SELECT created_at
+FROM events
+WHERE aggregate_id = ?
+ORDER BY version DESC
+LIMIT 1;
+
 -- name: GetAllEvents :many
-SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+ORDER BY created_at ASC;
+
+-- name: ListEventsPaginated :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+ORDER BY created_at ASC, rowid ASC
+LIMIT ? OFFSET ?;
+
+-- name: CountEventsByFilter :one
+-- 空文字列を指定した条件は絞り込まない。
+SELECT COUNT(*)
+FROM events
+WHERE (sqlc.arg(aggregate_type) = '' OR aggregate_type = sqlc.arg(aggregate_type))
+  AND (sqlc.arg(event_type) = '' OR event_type = sqlc.arg(event_type));
+
+-- name: GetEventsByUserID :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+WHERE user_id = ?
+ORDER BY created_at ASC;
+
+-- name: GetEventsByFilename :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
 FROM events
+WHERE filename = ?
 ORDER BY created_at ASC;
+
+-- name: ListAggregateIDsByType :many
+SELECT DISTINCT aggregate_id
+FROM events
+WHERE aggregate_type = ?
+  AND aggregate_id > sqlc.arg(cursor)
+ORDER BY aggregate_id ASC
+LIMIT ?;
+
+-- name: SummarizeEvents :one
+SELECT CAST(COUNT(DISTINCT aggregate_id) AS INTEGER) AS aggregate_count,
+       CAST(COUNT(*) AS INTEGER) AS event_count
+FROM events;
+
+-- name: CountEventsByTypeAndDay :many
+SELECT event_type,
+       CAST(substr(created_at, 1, 10) AS TEXT) AS day,
+       CAST(COUNT(*) AS INTEGER) AS event_count
+FROM events
+WHERE created_at >= sqlc.arg(since)
+GROUP BY event_type, day
+ORDER BY day ASC, event_type ASC;
+
+-- name: ListVersionAnomalies :many
+SELECT aggregate_id,
+       CAST(COUNT(*) AS INTEGER) AS event_count,
+       CAST(MIN(version) AS INTEGER) AS min_version,
+       CAST(MAX(version) AS INTEGER) AS max_version
+FROM events
+GROUP BY aggregate_id
+HAVING MIN(version) <> 1
+    OR MAX(version) <> COUNT(*)
+    OR COUNT(DISTINCT version) <> COUNT(*)
+ORDER BY aggregate_id ASC;
+
+-- name: CreateVersionReservation :exec
+INSERT INTO version_reservations (id, aggregate_id, version, expires_at, created_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetVersionReservation :one
+SELECT id, aggregate_id, version, expires_at, created_at
+FROM version_reservations
+WHERE id = ?;
+
+-- name: GetMaxReservedVersion :one
+SELECT CAST(COALESCE(MAX(version), 0) AS INTEGER) AS max_version
+FROM version_reservations
+WHERE aggregate_id = ?
+  AND expires_at > sqlc.arg(now);
+
+-- name: CountActiveVersionReservations :one
+SELECT COUNT(*)
+FROM version_reservations
+WHERE aggregate_id = ?
+  AND expires_at > sqlc.arg(now);
+
+-- name: DeleteVersionReservation :exec
+DELETE FROM version_reservations
+WHERE id = ?;
+
+-- name: DeleteExpiredVersionReservations :execrows
+DELETE FROM version_reservations
+WHERE expires_at <= ?;
+
+-- name: SaveSnapshot :exec
+INSERT INTO snapshots (aggregate_id, version, state, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (aggregate_id, version) DO UPDATE
+SET state = excluded.state,
+    created_at = excluded.created_at;
+
+-- name: GetLatestSnapshot :one
+SELECT aggregate_id, version, state, created_at
+FROM snapshots
+WHERE aggregate_id = ?
+ORDER BY version DESC
+LIMIT 1;
@@ -16,7 +16,13 @@ CREATE TABLE IF NOT EXISTS events (
     -- Aggregate内でのイベント順序番号。楽観的排他制御に使用する。
     version INTEGER NOT NULL,
     -- イベント作成日時（UTC）
-    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    -- ペイロードから抽出したユーザーID（索引用。抽出対象外のイベントは空文字）
+    user_id TEXT NOT NULL DEFAULT '',
+    -- ペイロードから抽出したファイル名（索引用。抽出対象外のイベントは空文字）
+    filename TEXT NOT NULL DEFAULT '',
+    -- 分散トレーシングのための追跡情報（correlation_id、causation_id。JSON形式）
+    metadata TEXT NOT NULL DEFAULT '{}'
 );
 
 -- AggregateIDとVersionの組み合わせで一意制約を設ける。
@@ -33,3 +39,57 @@ CREATE INDEX IF NOT EXISTS idx_events_event_type
 -- イベントの時系列順での取得に使用する。
 CREATE INDEX IF NOT EXISTS idx_events_created_at
     ON events(created_at);
+
+-- ユーザーIDでの検索を高速化するインデックス。
+-- JSONペイロードを走査せずにユーザー単位のイベントを時系列順で取得するために使用する。
+CREATE INDEX IF NOT EXISTS idx_events_user_id
+    ON events(user_id, created_at);
+
+-- ファイル名での検索を高速化するインデックス。
+CREATE INDEX IF NOT EXISTS idx_events_filename
+    ON events(filename, created_at);
+
+-- Aggregateの種類ごとのID列挙を高速化するインデックス。
+-- Read Modelを一から構築する際に、種類ごとのAggregate IDを重複なくページングで取得するために使用する。
+CREATE INDEX IF NOT EXISTS idx_events_aggregate_type_id
+    ON events(aggregate_type, aggregate_id);
+
+-- バージョン予約テーブル
+-- イベントの準備に時間がかかる場合に、Aggregateの次のバージョンを先に確保して発行順序を保証する。
+-- 予約中はそのAggregateへの即時追記を受け付けず、予約したバージョンでの追記または期限切れで解放される。
+CREATE TABLE IF NOT EXISTS version_reservations (
+    -- 予約の一意識別子（UUID）。予約したバージョンで追記する際に指定する
+    id TEXT PRIMARY KEY,
+    -- 予約対象のAggregateの識別子
+    aggregate_id TEXT NOT NULL,
+    -- 予約したバージョン
+    version INTEGER NOT NULL,
+    -- 予約の有効期限（UTC）。期限切れの予約は無効となり、次の予約時に削除する
+    expires_at DATETIME NOT NULL,
+    -- 予約日時（UTC）
+    created_at DATETIME NOT NULL
+);
+
+-- 同じAggregateの同じバージョンが二重に予約されることを防ぐ。
+CREATE UNIQUE INDEX IF NOT EXISTS idx_version_reservations_aggregate_version
+    ON version_reservations(aggregate_id, version);
+
+-- 期限切れの予約の削除を高速化するインデックス。
+CREATE INDEX IF NOT EXISTS idx_version_reservations_expires_at
+    ON version_reservations(expires_at);
+
+-- スナップショットテーブル
+-- Aggregateの状態をあるバージョン時点で保存し、状態の再構築時に全イベントのリプレイを省く。
+-- 再構築はスナップショットのstateから始め、そのversionより後のイベントだけを適用する。
+CREATE TABLE IF NOT EXISTS snapshots (
+    -- 対象のAggregateの識別子
+    aggregate_id TEXT NOT NULL,
+    -- スナップショットが反映しているイベントの最新バージョン
+    version INTEGER NOT NULL,
+    -- Aggregateの状態（JSON形式）
+    state TEXT NOT NULL,
+    -- 保存日時（UTC）
+    created_at DATETIME NOT NULL,
+    -- 同じAggregateの同じバージョンは1つのみ保持する（再保存時は上書き）
+    PRIMARY KEY (aggregate_id, version)
+);
@@ -1,23 +1,46 @@
 -- name: CreateUser :exec
-INSERT INTO users (id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at)
-VALUES (?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'));
+INSERT INTO users (id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at, last_active_at)
+VALUES (?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'), datetime('now'));
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at <= ?;
 
 -- name: GetUserByID :one
-SELECT id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at
+SELECT id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at, unlinked_at, last_active_at
 FROM users
 WHERE id = ?;
 
 -- name: GetUserByProvider :one
-SELECT id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at
+SELECT id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at, unlinked_at, last_active_at
 FROM users
 WHERE provider = ? AND provider_user_id = ?;
 
+-- name: IsTokenRevoked :one
+SELECT COUNT(*) FROM revoked_tokens
+WHERE jti = ? AND expires_at > ?;
+
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+VALUES (?, ?, ?, datetime('now'))
+ON CONFLICT(jti) DO NOTHING;
+
+-- name: UnlinkUserByProvider :execrows
+UPDATE users
+SET unlinked_at = datetime('now')
+WHERE provider = ? AND provider_user_id = ? AND unlinked_at IS NULL;
+
+-- name: UpdateLastActive :exec
+UPDATE users
+SET last_active_at = datetime('now')
+WHERE id = ?;
+
 -- name: UpdateLastLogin :exec
 UPDATE users
-SET last_login_at = datetime('now')
+SET last_login_at = datetime('now'), last_active_at = datetime('now')
 WHERE id = ?;
 
 -- name: UpdateUserProfile :exec
 UPDATE users
-SET display_name = ?, avatar_url = ?, last_login_at = datetime('now')
+SET display_name = ?, avatar_url = ?, last_login_at = datetime('now'), last_active_at = datetime('now')
 WHERE id = ?;
@@ -17,7 +17,11 @@ CREATE TABLE IF NOT EXISTS users (
     -- 初回ログイン日時
     created_at DATETIME NOT NULL DEFAULT (datetime('now')),
     -- 最終ログイン日時
-    last_login_at DATETIME NOT NULL DEFAULT (datetime('now'))
+    last_login_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    -- OAuthプロバイダーからの通知（アクセス権の取り消し等）で連携が解除された日時。連携中はNULL
+    unlinked_at DATETIME,
+    -- 最終アクティビティ日時（ログインとAPI呼び出し時に一定間隔で更新する）。記録を始める前から存在し、その後アクティビティのないユーザーはNULL
+    last_active_at DATETIME
 );
 
 -- プロバイダーとプロバイダーユーザーIDの組み合わせで一意制約を設ける。
@@ -28,3 +32,21 @@ CREATE UNIQUE INDEX IF NOT EXISTS idx_users_provider
 -- メールアドレスでの検索を高速化するインデックス。
 CREATE INDEX IF NOT EXISTS idx_users_email
     ON users(email);
+
+-- 失効済みトークン。
+-- 漏洩の疑いなどでユーザーが失効させたアクセストークンを、トークンID（jtiクレーム）で記録する。
+-- トークン自体の有効期限を過ぎたエントリは検証に影響しないため削除する。
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    -- トークンID（jtiクレーム）
+    jti TEXT PRIMARY KEY,
+    -- トークンを失効させたユーザーのID
+    user_id TEXT NOT NULL,
+    -- トークン自体の有効期限。この日時を過ぎたエントリは削除してよい
+    expires_at DATETIME NOT NULL,
+    -- 失効日時
+    revoked_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+-- 期限切れエントリの削除を高速化するインデックス。
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at
+    ON revoked_tokens(expires_at);
@@ -0,0 +1,42 @@
+-- name: CreateUploadIdempotencyKey :exec
+INSERT INTO upload_idempotency_keys (user_id, idempotency_key, media_id, filename, content_type, size, storage_path, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'));
+
+-- name: EnqueueOutboxEvent :exec
+INSERT INTO event_outbox (aggregate_id, aggregate_type, event_type, data, user_id, correlation_id, causation_id, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'));
+
+-- name: GetPendingOutboxEventData :one
+SELECT data
+FROM event_outbox
+WHERE aggregate_id = ? AND event_type = ? AND delivered_at IS NULL
+ORDER BY id ASC
+LIMIT 1;
+
+-- name: GetUploadIdempotencyKey :one
+SELECT user_id, idempotency_key, media_id, filename, content_type, size, storage_path, created_at
+FROM upload_idempotency_keys
+WHERE user_id = ? AND idempotency_key = ?;
+
+-- name: ListPendingOutboxEvents :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, user_id, attempts, last_error, created_at, delivered_at, correlation_id, causation_id
+FROM event_outbox
+WHERE delivered_at IS NULL
+ORDER BY id ASC
+LIMIT ?;
+
+-- name: ListPendingOutboxEventsByAggregate :many
+SELECT event_type, data
+FROM event_outbox
+WHERE aggregate_id = ? AND delivered_at IS NULL
+ORDER BY id ASC;
+
+-- name: MarkOutboxEventDelivered :exec
+UPDATE event_outbox
+SET delivered_at = datetime('now')
+WHERE id = ?;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE event_outbox
+SET attempts = attempts + 1, last_error = ?
+WHERE id = ?;
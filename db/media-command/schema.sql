@@ -0,0 +1,61 @@
+-- Media Command スキーマ
+-- メディアコマンドサービスのデータベース。
+-- メディアファイル自体はディスクに保存し、ここではイベント配送用のアウトボックスのみを管理する。
+
+-- トランザクショナルアウトボックス。
+-- ファイル保存などの処理結果をイベントとしてローカルに記録し、
+-- バックグラウンドのリレーがEvent Storeへ配送する。
+-- Event Storeが停止していてもイベントが失われないようにするためのテーブル。
+CREATE TABLE IF NOT EXISTS event_outbox (
+    -- 記録順の連番。配送はこの順序で行う。
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- 対象エンティティの識別子（例: media-xxx）
+    aggregate_id TEXT NOT NULL,
+    -- 対象エンティティの種類
+    aggregate_type TEXT NOT NULL,
+    -- イベントの種類
+    event_type TEXT NOT NULL,
+    -- イベント固有のデータ（JSON形式）
+    data TEXT NOT NULL,
+    -- イベントを発生させたユーザーのID（X-User-IDヘッダーとして伝搬する。内部APIの場合は空文字）
+    user_id TEXT NOT NULL DEFAULT '',
+    -- 配送試行回数
+    attempts INTEGER NOT NULL DEFAULT 0,
+    -- 最後の配送エラーメッセージ
+    last_error TEXT NOT NULL DEFAULT '',
+    -- 記録日時
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    -- 配送完了日時（未配送の場合はNULL）
+    delivered_at DATETIME,
+    -- イベントのmetadata.correlation_id（X-Correlation-IDヘッダーとして伝搬する）
+    correlation_id TEXT NOT NULL DEFAULT '',
+    -- イベントのmetadata.causation_id（X-Causation-IDヘッダーとして伝搬する。起点のイベントは空文字）
+    causation_id TEXT NOT NULL DEFAULT ''
+);
+
+-- 未配送イベントの取得を高速化する部分インデックス。
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending
+    ON event_outbox(id) WHERE delivered_at IS NULL;
+
+-- Idempotency-Key付きアップロードの記録。
+-- 通信エラー後にクライアントが同じキーで再送した場合、新しいメディアを作らず記録済みのメディアを返すために使用する。
+-- メディアIDはユーザーIDとキーから決定的に導出するため、同じキーの再送は常に同じメディアIDになる。
+CREATE TABLE IF NOT EXISTS upload_idempotency_keys (
+    -- アップロードしたユーザーのID
+    user_id TEXT NOT NULL,
+    -- クライアントが指定したIdempotency-Keyヘッダーの値
+    idempotency_key TEXT NOT NULL,
+    -- アップロードで作成したメディアのID
+    media_id TEXT NOT NULL,
+    -- 保存したファイル名
+    filename TEXT NOT NULL,
+    -- ファイルのMIMEタイプ
+    content_type TEXT NOT NULL,
+    -- 保存したファイルのサイズ（バイト）
+    size INTEGER NOT NULL,
+    -- ファイルの保存パス
+    storage_path TEXT NOT NULL,
+    -- 記録日時
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (user_id, idempotency_key)
+);
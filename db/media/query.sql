@@ -1,7 +1,8 @@
 -- name: UpsertMediaReadModel :exec
-INSERT INTO media_read_models (id, user_id, filename, content_type, size, storage_path, status, last_event_version, uploaded_at, updated_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
+INSERT INTO media_read_models (id, user_id, filename, filename_normalized, content_type, size, storage_path, status, last_event_version, uploaded_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
 ON CONFLICT(id) DO UPDATE SET
+    filename_normalized = excluded.filename_normalized,
     status = excluded.status,
     last_event_version = excluded.last_event_version,
     updated_at = datetime('now');
@@ -27,37 +28,112 @@ WHERE id = ?;
 -- name: GetMediaByID :one
 SELECT id, user_id, filename, content_type, size, storage_path,
        thumbnail_path, width, height, duration_seconds,
-       status, last_event_version, uploaded_at, updated_at
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
 FROM media_read_models
 WHERE id = ?;
 
--- name: ListMediaByUserID :many
+-- name: GetMediaStatus :one
+SELECT user_id, status, updated_at
+FROM media_read_models
+WHERE id = ?;
+
+-- name: ListMediaByUserIDAndStatuses :many
 SELECT id, user_id, filename, content_type, size, storage_path,
        thumbnail_path, width, height, duration_seconds,
-       status, last_event_version, uploaded_at, updated_at
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
 FROM media_read_models
-WHERE user_id = ? AND status != 'deleted'
-ORDER BY uploaded_at DESC;
+WHERE user_id = sqlc.arg('user_id') AND status IN (sqlc.slice('statuses'))
+ORDER BY
+    CASE WHEN sqlc.arg('sort') = 'uploaded_at_asc' THEN uploaded_at END ASC,
+    CASE WHEN sqlc.arg('sort') = 'uploaded_at_desc' THEN uploaded_at END DESC,
+    CASE WHEN sqlc.arg('sort') = 'size_asc' THEN size END ASC,
+    CASE WHEN sqlc.arg('sort') = 'size_desc' THEN size END DESC,
+    CASE WHEN sqlc.arg('sort') = 'filename_asc' THEN filename END ASC,
+    CASE WHEN sqlc.arg('sort') = 'filename_desc' THEN filename END DESC,
+    uploaded_at DESC, id ASC;
+
+-- name: ListMediaPageByUserIDAndStatuses :many
+SELECT id, user_id, filename, content_type, size, storage_path,
+       thumbnail_path, width, height, duration_seconds,
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
+FROM media_read_models
+WHERE user_id = sqlc.arg('user_id') AND status IN (sqlc.slice('statuses'))
+  AND (sqlc.narg('cursor_uploaded_at') IS NULL
+    OR (uploaded_at = sqlc.narg('cursor_uploaded_at') AND id > sqlc.arg('cursor_id'))
+    OR (sqlc.arg('sort') = 'uploaded_at_asc' AND uploaded_at > sqlc.narg('cursor_uploaded_at'))
+    OR (sqlc.arg('sort') = 'uploaded_at_desc' AND uploaded_at < sqlc.narg('cursor_uploaded_at')))
+ORDER BY
+    CASE WHEN sqlc.arg('sort') = 'uploaded_at_asc' THEN uploaded_at END ASC,
+    uploaded_at DESC, id ASC
+LIMIT sqlc.arg('limit');
 
 -- name: ListAllMedia :many
 SELECT id, user_id, filename, content_type, size, storage_path,
        thumbnail_path, width, height, duration_seconds,
-       status, last_event_version, uploaded_at, updated_at
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
 FROM media_read_models
-WHERE status != 'deleted'
+WHERE status NOT IN ('deleted', 'flagged')
 ORDER BY uploaded_at DESC;
 
 -- name: SearchMedia :many
 SELECT id, user_id, filename, content_type, size, storage_path,
        thumbnail_path, width, height, duration_seconds,
-       status, last_event_version, uploaded_at, updated_at
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
 FROM media_read_models
-WHERE filename LIKE ? AND status != 'deleted'
+WHERE filename_normalized LIKE sqlc.arg('filename_normalized')
+  AND status NOT IN ('deleted', 'flagged')
+  AND content_type LIKE sqlc.arg('content_type')
+  AND (sqlc.narg('uploaded_from') IS NULL OR uploaded_at >= sqlc.narg('uploaded_from'))
+  AND (sqlc.narg('uploaded_to') IS NULL OR uploaded_at <= sqlc.narg('uploaded_to'))
 ORDER BY uploaded_at DESC;
 
+-- name: ListStaleUploadedMedia :many
+SELECT id, user_id, filename, content_type, size, storage_path,
+       thumbnail_path, width, height, duration_seconds,
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
+FROM media_read_models
+WHERE status = 'uploaded' AND uploaded_at < ?
+ORDER BY uploaded_at ASC
+LIMIT ?;
+
+-- name: ListStuckMedia :many
+SELECT id, user_id, filename, content_type, size, storage_path,
+       thumbnail_path, width, height, duration_seconds,
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
+FROM media_read_models
+WHERE status = 'uploaded' AND uploaded_at <= ?
+ORDER BY uploaded_at ASC;
+
+-- name: ListProcessedImageIDs :many
+SELECT id
+FROM media_read_models
+WHERE status = 'processed' AND content_type LIKE 'image/%' AND id > ?
+ORDER BY id ASC
+LIMIT ?;
+
+-- name: ListMediaWithoutNormalizedFilename :many
+SELECT id, filename
+FROM media_read_models
+WHERE filename_normalized = '' AND filename != '';
+
+-- name: UpdateMediaFilenameNormalized :exec
+UPDATE media_read_models
+SET filename_normalized = ?
+WHERE id = ?;
+
 -- name: DeleteAllMediaReadModels :exec
 DELETE FROM media_read_models;
 
+-- name: DeleteMediaReadModel :exec
+DELETE FROM media_read_models WHERE id = ?;
+
 -- name: GetProjectorOffset :one
 SELECT last_timestamp FROM projector_offsets WHERE id = 'default';
 
@@ -24,14 +24,17 @@ CREATE TABLE IF NOT EXISTS media_read_models (
     height INTEGER,
     -- 動画の長さ（秒、画像の場合はNULL）
     duration_seconds REAL,
-    -- メディアの状態（uploaded, processed, failed, deleted）
+    -- メディアの状態（uploaded, processed, failed, deleted, flagged）
+    -- flaggedはコンテンツ審査で要確認と判定されたメディアで、一覧・検索から除外する
     status TEXT NOT NULL DEFAULT 'uploaded',
     -- 最後に適用されたイベントのバージョン番号
     last_event_version INTEGER NOT NULL DEFAULT 0,
     -- アップロード日時
     uploaded_at DATETIME NOT NULL,
     -- Read Model更新日時
-    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    -- 検索用に正規化したファイル名（NFKC正規化・小文字化・ひらがなをカタカナに統一）
+    filename_normalized TEXT NOT NULL DEFAULT ''
 );
 
 -- ユーザーIDでの検索を高速化するインデックス。
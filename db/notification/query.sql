@@ -3,20 +3,35 @@ INSERT INTO notifications (id, user_id, title, message, created_at)
 VALUES (?, ?, ?, ?, datetime('now'));
 
 -- name: GetNotificationByID :one
-SELECT id, user_id, title, message, is_read, created_at
+SELECT id, user_id, title, message, is_read, created_at, snoozed_until
 FROM notifications
 WHERE id = ?;
 
 -- name: ListNotificationsByUserID :many
-SELECT id, user_id, title, message, is_read, created_at
+SELECT id, user_id, title, message, is_read, created_at, snoozed_until
 FROM notifications
 WHERE user_id = ?
 ORDER BY created_at DESC;
 
+-- name: ListNotificationsByUserIDWithLimit :many
+SELECT id, user_id, title, message, is_read, created_at, snoozed_until
+FROM notifications
+WHERE user_id = ?
+ORDER BY created_at DESC
+LIMIT ?;
+
+-- name: CountNotificationsByReadStatus :one
+SELECT
+    COUNT(*) AS total,
+    CAST(COALESCE(SUM(CASE WHEN is_read = 0 THEN 1 ELSE 0 END), 0) AS INTEGER) AS unread
+FROM notifications
+WHERE user_id = ?;
+
 -- name: ListUnreadNotifications :many
-SELECT id, user_id, title, message, is_read, created_at
+SELECT id, user_id, title, message, is_read, created_at, snoozed_until
 FROM notifications
-WHERE user_id = ? AND is_read = 0
+WHERE user_id = sqlc.arg('user_id') AND is_read = 0
+  AND (snoozed_until IS NULL OR snoozed_until <= sqlc.arg('now'))
 ORDER BY created_at DESC;
 
 -- name: MarkAsRead :exec
@@ -28,3 +43,42 @@ WHERE id = ?;
 UPDATE notifications
 SET is_read = 1
 WHERE user_id = ? AND is_read = 0;
+
+-- name: SnoozeNotification :exec
+UPDATE notifications
+SET snoozed_until = ?, is_read = 0
+WHERE id = ?;
+
+-- name: UnsnoozeNotification :exec
+UPDATE notifications
+SET snoozed_until = NULL
+WHERE id = ?;
+
+-- name: CreateNotificationDelivery :exec
+INSERT INTO notification_deliveries (id, notification_id, channel, status, error, attempted_at)
+VALUES (?, ?, ?, ?, ?, datetime('now'));
+
+-- name: ListDeliveriesByNotificationID :many
+SELECT id, notification_id, channel, status, error, attempted_at
+FROM notification_deliveries
+WHERE notification_id = ?
+ORDER BY attempted_at ASC, rowid ASC;
+
+-- name: UpsertPushSubscription :one
+INSERT INTO push_subscriptions (id, user_id, endpoint, p256dh, auth, created_at)
+VALUES (?, ?, ?, ?, ?, datetime('now'))
+ON CONFLICT(endpoint) DO UPDATE SET
+    user_id = excluded.user_id,
+    p256dh = excluded.p256dh,
+    auth = excluded.auth
+RETURNING id, user_id, endpoint, p256dh, auth, created_at;
+
+-- name: ListPushSubscriptionsByUserID :many
+SELECT id, user_id, endpoint, p256dh, auth, created_at
+FROM push_subscriptions
+WHERE user_id = ?
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: DeletePushSubscription :exec
+DELETE FROM push_subscriptions
+WHERE id = ?;
@@ -13,7 +13,9 @@ CREATE TABLE IF NOT EXISTS notifications (
     -- 通知の既読状態
     is_read INTEGER NOT NULL DEFAULT 0,
     -- 通知の作成日時
-    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    -- スヌーズの期限。この日時まで未読一覧に表示しない（NULLはスヌーズしていない）
+    snoozed_until DATETIME
 );
 
 -- ユーザーIDでの検索を高速化するインデックス。
@@ -23,3 +25,54 @@ CREATE INDEX IF NOT EXISTS idx_notifications_user_id
 -- 未読通知の検索を高速化するインデックス。
 CREATE INDEX IF NOT EXISTS idx_notifications_unread
     ON notifications(user_id, is_read) WHERE is_read = 0;
+
+-- 通知の配信チャネルごとの配信試行を記録するテーブル。
+-- 1つの通知に対して、チャネル（アプリ内・メール・Webhook）ごと・試行ごとに1行を記録する。
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    -- 配信試行の一意識別子（UUID）
+    id TEXT PRIMARY KEY,
+    -- 配信対象の通知ID
+    notification_id TEXT NOT NULL REFERENCES notifications(id),
+    -- 配信チャネル（in_app, email, webhook）
+    channel TEXT NOT NULL,
+    -- 配信結果（succeeded, failed）
+    status TEXT NOT NULL,
+    -- 配信失敗時のエラー内容（成功時はNULL）
+    error TEXT,
+    -- 配信を試行した日時
+    attempted_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+-- 通知IDでの配信状況の取得を高速化するインデックス。
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_notification_id
+    ON notification_deliveries(notification_id);
+
+-- 配信に失敗した試行の検索（運用者による特定・再送）を高速化するインデックス。
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_failed
+    ON notification_deliveries(channel, attempted_at) WHERE status = 'failed';
+
+-- ブラウザのWeb Pushサブスクリプションを保存するテーブル。
+-- 通知の送信時に、通知先ユーザーの全サブスクリプションへプッシュ配信する。
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    -- サブスクリプションの一意識別子（UUID）
+    id TEXT PRIMARY KEY,
+    -- サブスクリプションを登録したユーザーのID
+    user_id TEXT NOT NULL,
+    -- プッシュサービスのエンドポイントURL
+    endpoint TEXT NOT NULL,
+    -- ブラウザのP-256公開鍵（Base64URL形式）。ペイロードの暗号化に使用する
+    p256dh TEXT NOT NULL,
+    -- ブラウザの認証シークレット（Base64URL形式）。ペイロードの暗号化に使用する
+    auth TEXT NOT NULL,
+    -- 登録日時
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+-- 同じブラウザ（エンドポイント）が二重に登録されることを防ぐ。
+-- 再登録時は鍵と登録ユーザーを更新する。
+CREATE UNIQUE INDEX IF NOT EXISTS idx_push_subscriptions_endpoint
+    ON push_subscriptions(endpoint);
+
+-- ユーザー単位のサブスクリプションの取得を高速化するインデックス。
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user_id
+    ON push_subscriptions(user_id);
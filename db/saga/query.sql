@@ -3,7 +3,7 @@ INSERT INTO sagas (id, saga_type, current_step, status, payload, started_at, upd
 VALUES (?, ?, ?, 'started', ?, datetime('now'), datetime('now'));
 
 -- name: GetSagaByID :one
-SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at
+SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at, attempts
 FROM sagas
 WHERE id = ?;
 
@@ -23,14 +23,14 @@ SET status = 'failed', updated_at = datetime('now'), completed_at = datetime('no
 WHERE id = ?;
 
 -- name: ListActiveSagas :many
-SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at
+SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at, attempts
 FROM sagas
-WHERE status IN ('started', 'in_progress', 'compensating')
+WHERE status IN ('started', 'in_progress', 'compensating', 'partially_compensated')
 ORDER BY started_at ASC;
 
 -- name: CreateSagaStep :exec
-INSERT INTO saga_steps (id, saga_id, step_name, status, result, started_at)
-VALUES (?, ?, ?, ?, '{}', datetime('now'));
+INSERT INTO saga_steps (id, saga_id, step_name, status, result, started_at, kind)
+VALUES (?, ?, ?, ?, '{}', datetime('now'), ?);
 
 -- name: UpdateSagaStepStatus :exec
 UPDATE saga_steps
@@ -38,20 +38,27 @@ SET status = ?, result = ?, completed_at = datetime('now')
 WHERE id = ?;
 
 -- name: ListSagaSteps :many
-SELECT id, saga_id, step_name, status, result, started_at, completed_at, retry_count, last_error
+SELECT id, saga_id, step_name, status, result, started_at, completed_at, retry_count, last_error, kind
 FROM saga_steps
 WHERE saga_id = ?
 ORDER BY started_at ASC;
 
+-- name: ListSagaStepsByStatus :many
+SELECT id, saga_id, step_name, status, result, started_at, completed_at, retry_count, last_error, kind
+FROM saga_steps
+WHERE status = ?
+ORDER BY started_at ASC, id ASC
+LIMIT ? OFFSET ?;
+
 -- name: UpdateSagaStepRetry :exec
 UPDATE saga_steps
 SET retry_count = ?, last_error = ?, status = ?
 WHERE id = ?;
 
 -- name: ListStuckSagas :many
-SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at
+SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at, attempts
 FROM sagas
-WHERE status IN ('in_progress', 'compensating')
+WHERE status IN ('in_progress', 'compensating', 'partially_compensated')
   AND updated_at < ?
 ORDER BY updated_at ASC;
 
@@ -62,3 +69,23 @@ SELECT last_timestamp FROM projector_offsets WHERE id = 'default';
 INSERT INTO projector_offsets (id, last_timestamp, updated_at)
 VALUES ('default', ?, datetime('now'))
 ON CONFLICT(id) DO UPDATE SET last_timestamp = excluded.last_timestamp, updated_at = datetime('now');
+
+-- name: UpdateSagaPayload :exec
+UPDATE sagas
+SET payload = ?, updated_at = datetime('now')
+WHERE id = ?;
+
+-- name: IncrementSagaAttempts :exec
+UPDATE sagas
+SET attempts = attempts + 1, updated_at = datetime('now')
+WHERE id = ?;
+
+-- name: UpdateSagaStatus :exec
+UPDATE sagas
+SET current_step = ?, status = ?, updated_at = datetime('now')
+WHERE id = ?;
+
+-- name: DeadLetterSaga :exec
+UPDATE sagas
+SET status = 'dead_letter', updated_at = datetime('now'), completed_at = datetime('now')
+WHERE id = ?;
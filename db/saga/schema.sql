@@ -9,7 +9,9 @@ CREATE TABLE IF NOT EXISTS sagas (
     saga_type TEXT NOT NULL,
     -- 現在のステップ名
     current_step TEXT NOT NULL,
-    -- Sagaの状態（started, in_progress, completed, failed, compensating, compensated）
+    -- Sagaの状態（started, in_progress, completed, failed, compensating, partially_compensated, compensated, dead_letter）
+    -- partially_compensated は補償ステップの一部が失敗し、未完了の補償ステップが残っているSaga。
+    -- dead_letter は試行回数の上限に達し、自動での再試行を打ち切ったSaga。
     status TEXT NOT NULL DEFAULT 'started',
     -- Sagaに関連するデータ（JSON形式）。各ステップの結果を蓄積する。
     payload TEXT NOT NULL DEFAULT '{}',
@@ -18,7 +20,9 @@ CREATE TABLE IF NOT EXISTS sagas (
     -- 最終更新日時
     updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
     -- 完了日時（未完了の場合はNULL）
-    completed_at DATETIME
+    completed_at DATETIME,
+    -- スタック検出による再試行の回数。上限に達するとdead_letterに移す。
+    attempts INTEGER NOT NULL DEFAULT 0
 );
 
 -- Saga内の各ステップの実行履歴を記録するテーブル。
@@ -28,7 +32,7 @@ CREATE TABLE IF NOT EXISTS saga_steps (
     id TEXT PRIMARY KEY,
     -- 所属するSagaのID
     saga_id TEXT NOT NULL,
-    -- ステップ名（upload_file, generate_thumbnail, add_to_album, send_notification）
+    -- ステップ名（process_media, add_to_album, send_notification, compensate_album, compensate_upload）
     step_name TEXT NOT NULL,
     -- ステップの状態（pending, executing, completed, failed, compensating, compensated）
     status TEXT NOT NULL DEFAULT 'pending',
@@ -42,6 +46,8 @@ CREATE TABLE IF NOT EXISTS saga_steps (
     retry_count INTEGER NOT NULL DEFAULT 0,
     -- 最後のエラーメッセージ
     last_error TEXT NOT NULL DEFAULT '',
+    -- ステップの種類（action: 順方向のステップ, compensation: 補償ステップ）
+    kind TEXT NOT NULL DEFAULT 'action',
     FOREIGN KEY (saga_id) REFERENCES sagas(id) ON DELETE CASCADE
 );
 
@@ -57,6 +63,10 @@ CREATE INDEX IF NOT EXISTS idx_sagas_type
 CREATE INDEX IF NOT EXISTS idx_saga_steps_saga_id
     ON saga_steps(saga_id);
 
+-- 状態ごとのSagaステップ検索（実行中のステップ一覧など）を高速化するインデックス。
+CREATE INDEX IF NOT EXISTS idx_saga_steps_status_started_at
+    ON saga_steps(status, started_at);
+
 -- Orchestratorのオフセット（最後にポーリングしたイベントのタイムスタンプ）を永続化するテーブル。
 CREATE TABLE IF NOT EXISTS projector_offsets (
     id TEXT PRIMARY KEY DEFAULT 'default',
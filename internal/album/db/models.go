@@ -5,6 +5,7 @@
 package albumdb
 
 import (
+	"database/sql"
 	"time"
 )
 
@@ -15,6 +16,7 @@ type Album struct {
 	Description string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	Position    sql.NullInt64
 }
 
 type AlbumMedium struct {
@@ -22,3 +24,18 @@ type AlbumMedium struct {
 	MediaID string
 	AddedAt time.Time
 }
+
+type EventOutbox struct {
+	ID            int64
+	AggregateID   string
+	AggregateType string
+	EventType     string
+	Data          string
+	UserID        string
+	Attempts      int64
+	LastError     string
+	CreatedAt     time.Time
+	DeliveredAt   sql.NullTime
+	CorrelationID string
+	CausationID   string
+}
@@ -7,6 +7,7 @@ package albumdb
 
 import (
 	"context"
+	"database/sql"
 )
 
 const addMediaToAlbum = `-- name: AddMediaToAlbum :exec
@@ -24,6 +25,17 @@ func (q *Queries) AddMediaToAlbum(ctx context.Context, arg AddMediaToAlbumParams
 	return err
 }
 
+const clearAlbumPositionsByUserID = `-- name: ClearAlbumPositionsByUserID :exec
+UPDATE albums
+SET position = NULL
+WHERE user_id = ?
+`
+
+func (q *Queries) ClearAlbumPositionsByUserID(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, clearAlbumPositionsByUserID, userID)
+	return err
+}
+
 const createAlbum = `-- name: CreateAlbum :exec
 INSERT INTO albums (id, user_id, name, description, created_at, updated_at)
 VALUES (?, ?, ?, ?, datetime('now'), datetime('now'))
@@ -56,8 +68,36 @@ func (q *Queries) DeleteAlbum(ctx context.Context, id string) error {
 	return err
 }
 
+const enqueueOutboxEvent = `-- name: EnqueueOutboxEvent :exec
+INSERT INTO event_outbox (aggregate_id, aggregate_type, event_type, data, user_id, correlation_id, causation_id, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'))
+`
+
+type EnqueueOutboxEventParams struct {
+	AggregateID   string
+	AggregateType string
+	EventType     string
+	Data          string
+	UserID        string
+	CorrelationID string
+	CausationID   string
+}
+
+func (q *Queries) EnqueueOutboxEvent(ctx context.Context, arg EnqueueOutboxEventParams) error {
+	_, err := q.db.ExecContext(ctx, enqueueOutboxEvent,
+		arg.AggregateID,
+		arg.AggregateType,
+		arg.EventType,
+		arg.Data,
+		arg.UserID,
+		arg.CorrelationID,
+		arg.CausationID,
+	)
+	return err
+}
+
 const getAlbumByID = `-- name: GetAlbumByID :one
-SELECT id, user_id, name, description, created_at, updated_at
+SELECT id, user_id, name, description, created_at, updated_at, position
 FROM albums
 WHERE id = ?
 `
@@ -72,12 +112,13 @@ func (q *Queries) GetAlbumByID(ctx context.Context, id string) (Album, error) {
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Position,
 	)
 	return i, err
 }
 
 const getDefaultAlbumByUserID = `-- name: GetDefaultAlbumByUserID :one
-SELECT id, user_id, name, description, created_at, updated_at
+SELECT id, user_id, name, description, created_at, updated_at, position
 FROM albums
 WHERE user_id = ? AND name = 'All Media'
 `
@@ -92,12 +133,13 @@ func (q *Queries) GetDefaultAlbumByUserID(ctx context.Context, userID string) (A
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Position,
 	)
 	return i, err
 }
 
 const listAlbumsByMediaID = `-- name: ListAlbumsByMediaID :many
-SELECT a.id, a.user_id, a.name, a.description, a.created_at, a.updated_at
+SELECT a.id, a.user_id, a.name, a.description, a.created_at, a.updated_at, a.position
 FROM albums a
 JOIN album_media am ON a.id = am.album_id
 WHERE am.media_id = ?
@@ -120,6 +162,7 @@ func (q *Queries) ListAlbumsByMediaID(ctx context.Context, mediaID string) ([]Al
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Position,
 		); err != nil {
 			return nil, err
 		}
@@ -135,10 +178,10 @@ func (q *Queries) ListAlbumsByMediaID(ctx context.Context, mediaID string) ([]Al
 }
 
 const listAlbumsByUserID = `-- name: ListAlbumsByUserID :many
-SELECT id, user_id, name, description, created_at, updated_at
+SELECT id, user_id, name, description, created_at, updated_at, position
 FROM albums
 WHERE user_id = ?
-ORDER BY created_at DESC
+ORDER BY position IS NULL, position ASC, created_at DESC
 `
 
 func (q *Queries) ListAlbumsByUserID(ctx context.Context, userID string) ([]Album, error) {
@@ -157,6 +200,7 @@ func (q *Queries) ListAlbumsByUserID(ctx context.Context, userID string) ([]Albu
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Position,
 		); err != nil {
 			return nil, err
 		}
@@ -201,6 +245,77 @@ func (q *Queries) ListMediaInAlbum(ctx context.Context, albumID string) ([]Album
 	return items, nil
 }
 
+const listPendingOutboxEvents = `-- name: ListPendingOutboxEvents :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, user_id, attempts, last_error, created_at, delivered_at, correlation_id, causation_id
+FROM event_outbox
+WHERE delivered_at IS NULL
+ORDER BY id ASC
+LIMIT ?
+`
+
+func (q *Queries) ListPendingOutboxEvents(ctx context.Context, limit int64) ([]EventOutbox, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EventOutbox
+	for rows.Next() {
+		var i EventOutbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.AggregateType,
+			&i.EventType,
+			&i.Data,
+			&i.UserID,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+			&i.CorrelationID,
+			&i.CausationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxEventDelivered = `-- name: MarkOutboxEventDelivered :exec
+UPDATE event_outbox
+SET delivered_at = datetime('now')
+WHERE id = ?
+`
+
+func (q *Queries) MarkOutboxEventDelivered(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markOutboxEventDelivered, id)
+	return err
+}
+
+const recordOutboxEventFailure = `-- name: RecordOutboxEventFailure :exec
+UPDATE event_outbox
+SET attempts = attempts + 1, last_error = ?
+WHERE id = ?
+`
+
+type RecordOutboxEventFailureParams struct {
+	LastError string
+	ID        int64
+}
+
+func (q *Queries) RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordOutboxEventFailure, arg.LastError, arg.ID)
+	return err
+}
+
 const removeMediaFromAlbum = `-- name: RemoveMediaFromAlbum :exec
 DELETE FROM album_media
 WHERE album_id = ? AND media_id = ?
@@ -216,6 +331,23 @@ func (q *Queries) RemoveMediaFromAlbum(ctx context.Context, arg RemoveMediaFromA
 	return err
 }
 
+const setAlbumPosition = `-- name: SetAlbumPosition :exec
+UPDATE albums
+SET position = ?
+WHERE id = ? AND user_id = ?
+`
+
+type SetAlbumPositionParams struct {
+	Position sql.NullInt64
+	ID       string
+	UserID   string
+}
+
+func (q *Queries) SetAlbumPosition(ctx context.Context, arg SetAlbumPositionParams) error {
+	_, err := q.db.ExecContext(ctx, setAlbumPosition, arg.Position, arg.ID, arg.UserID)
+	return err
+}
+
 const updateAlbum = `-- name: UpdateAlbum :exec
 UPDATE albums
 SET name = ?, description = ?, updated_at = datetime('now')
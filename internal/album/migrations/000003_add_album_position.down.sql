@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_albums_user_position;
+ALTER TABLE albums DROP COLUMN position;
@@ -0,0 +1,4 @@
+ALTER TABLE albums ADD COLUMN position INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_albums_user_position
+    ON albums(user_id, position);
@@ -0,0 +1,98 @@
+package album
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	albumdb "github.com/nao1215/micro/internal/album/db"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// reorderAlbumsRequest はアルバム一覧の表示順変更リクエストのJSON構造。
+type reorderAlbumsRequest struct {
+	// AlbumIDs は表示したい順に並べたアルバムIDのリスト。
+	// 含まれないアルバムは表示順が未指定となり、指定したアルバムの後に作成日時の新しい順で並ぶ。
+	AlbumIDs []string `json:"album_ids" binding:"required"`
+}
+
+// handleReorder はユーザーのアルバム一覧の表示順変更を処理するハンドラを返す。
+// リクエストのアルバムIDの並び順を表示順として保存し、並び替え後のアルバム一覧を返す。
+// 既存の表示順はすべて置き換えるため、空のリストを指定すると表示順の指定を解除する。
+func (s *Server) handleReorder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+
+		var req reorderAlbumsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+			return
+		}
+
+		albums, err := s.queries.ListAlbumsByUserID(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "アルバム一覧の取得に失敗しました"})
+			log.Printf("アルバム一覧取得エラー: %v", err)
+			return
+		}
+		owned := make(map[string]struct{}, len(albums))
+		for _, a := range albums {
+			owned[a.ID] = struct{}{}
+		}
+
+		// 他ユーザーのアルバムや重複したIDは受け付けない
+		seen := make(map[string]struct{}, len(req.AlbumIDs))
+		for _, id := range req.AlbumIDs {
+			if _, ok := owned[id]; !ok {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("アルバムが見つかりません: %s", id)})
+				return
+			}
+			if _, ok := seen[id]; ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("アルバムIDが重複しています: %s", id)})
+				return
+			}
+			seen[id] = struct{}{}
+		}
+
+		err = s.withTx(c.Request.Context(), func(q *albumdb.Queries) error {
+			if err := q.ClearAlbumPositionsByUserID(c.Request.Context(), userID); err != nil {
+				return err
+			}
+			for i, id := range req.AlbumIDs {
+				if err := q.SetAlbumPosition(c.Request.Context(), albumdb.SetAlbumPositionParams{
+					Position: sql.NullInt64{Int64: int64(i), Valid: true},
+					ID:       id,
+					UserID:   userID,
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "アルバムの表示順の更新に失敗しました"})
+			log.Printf("アルバム表示順更新エラー: %v", err)
+			return
+		}
+
+		reordered, err := s.queries.ListAlbumsByUserID(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "アルバム一覧の取得に失敗しました"})
+			log.Printf("アルバム一覧取得エラー: %v", err)
+			return
+		}
+
+		responses := make([]albumResponse, 0, len(reordered))
+		for _, a := range reordered {
+			responses = append(responses, toAlbumResponse(a))
+		}
+
+		c.JSON(http.StatusOK, responses)
+	}
+}
@@ -0,0 +1,166 @@
+package album
+
+import (
+	"net/http"
+	"testing"
+)
+
+// albumIDs はアルバム一覧レスポンスからIDを順に取り出すヘルパー関数。
+func albumIDs(albums []map[string]any) []string {
+	ids := make([]string, 0, len(albums))
+	for _, a := range albums {
+		id, _ := a["id"].(string)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// TestHandleReorderAlbums はアルバム一覧の表示順変更ハンドラのテスト。
+func TestHandleReorderAlbums(t *testing.T) {
+	t.Parallel()
+
+	t.Run("指定した順にアルバム一覧が並び替えられる", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestAlbum(t, s, "album-1", "user-1", "アルバム1", "")
+		createTestAlbum(t, s, "album-2", "user-1", "アルバム2", "")
+		createTestAlbum(t, s, "album-3", "user-1", "アルバム3", "")
+
+		body := map[string]any{"album_ids": []string{"album-3", "album-1", "album-2"}}
+		w := doRequest(router, http.MethodPut, "/api/v1/albums/order", "user-1", body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		want := []string{"album-3", "album-1", "album-2"}
+		got := albumIDs(parseJSONArray(t, w))
+		if len(got) != len(want) {
+			t.Fatalf("配列の長さ: got %d, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("レスポンスの並び順[%d]: got %s, want %s", i, got[i], want[i])
+			}
+		}
+
+		// 一覧取得でも保存した表示順で返されることを確認する
+		w = doRequest(router, http.MethodGet, "/api/v1/albums", "user-1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+		listed := parseJSONArray(t, w)
+		got = albumIDs(listed)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("一覧の並び順[%d]: got %s, want %s", i, got[i], want[i])
+			}
+		}
+		if pos, ok := listed[0]["position"].(float64); !ok || pos != 0 {
+			t.Errorf("先頭アルバムのposition: got %v, want 0", listed[0]["position"])
+		}
+	})
+
+	t.Run("指定しなかったアルバムは指定したアルバムの後に並ぶ", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestAlbum(t, s, "album-1", "user-1", "アルバム1", "")
+		createTestAlbum(t, s, "album-2", "user-1", "アルバム2", "")
+		createTestAlbum(t, s, "album-3", "user-1", "アルバム3", "")
+
+		body := map[string]any{"album_ids": []string{"album-2"}}
+		w := doRequest(router, http.MethodPut, "/api/v1/albums/order", "user-1", body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		result := parseJSONArray(t, w)
+		if len(result) != 3 {
+			t.Fatalf("配列の長さ: got %d, want 3", len(result))
+		}
+		if result[0]["id"] != "album-2" {
+			t.Errorf("先頭のアルバム: got %v, want album-2", result[0]["id"])
+		}
+		for _, a := range result[1:] {
+			if a["position"] != nil {
+				t.Errorf("表示順未指定のアルバムのposition: got %v, want nil", a["position"])
+			}
+		}
+	})
+
+	t.Run("再度並び替えると以前の表示順は置き換えられる", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestAlbum(t, s, "album-1", "user-1", "アルバム1", "")
+		createTestAlbum(t, s, "album-2", "user-1", "アルバム2", "")
+
+		first := map[string]any{"album_ids": []string{"album-1", "album-2"}}
+		if w := doRequest(router, http.MethodPut, "/api/v1/albums/order", "user-1", first); w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+
+		second := map[string]any{"album_ids": []string{"album-2"}}
+		w := doRequest(router, http.MethodPut, "/api/v1/albums/order", "user-1", second)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+
+		result := parseJSONArray(t, w)
+		if result[0]["id"] != "album-2" {
+			t.Errorf("先頭のアルバム: got %v, want album-2", result[0]["id"])
+		}
+		if result[1]["position"] != nil {
+			t.Errorf("album-1のposition: got %v, want nil", result[1]["position"])
+		}
+	})
+
+	t.Run("他ユーザーのアルバムを含む場合はNotFound", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestAlbum(t, s, "album-1", "user-1", "アルバム1", "")
+		createTestAlbum(t, s, "album-2", "user-2", "他ユーザー", "")
+
+		body := map[string]any{"album_ids": []string{"album-1", "album-2"}}
+		w := doRequest(router, http.MethodPut, "/api/v1/albums/order", "user-1", body)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("IDが重複している場合はBadRequest", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestAlbum(t, s, "album-1", "user-1", "アルバム1", "")
+
+		body := map[string]any{"album_ids": []string{"album-1", "album-1"}}
+		w := doRequest(router, http.MethodPut, "/api/v1/albums/order", "user-1", body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("album_idsがない場合はBadRequest", func(t *testing.T) {
+		t.Parallel()
+		_, router := setupTestServer(t)
+
+		w := doRequest(router, http.MethodPut, "/api/v1/albums/order", "user-1", map[string]any{})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("ユーザーIDが未設定の場合はUnauthorized", func(t *testing.T) {
+		t.Parallel()
+		_, router := setupTestServer(t)
+
+		body := map[string]any{"album_ids": []string{}}
+		w := doRequest(router, http.MethodPut, "/api/v1/albums/order", "", body)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+}
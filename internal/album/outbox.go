@@ -0,0 +1,118 @@
+package album
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	albumdb "github.com/nao1215/micro/internal/album/db"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// outboxBatchSize は1回の配送で取得する未配送イベントの最大件数。
+const outboxBatchSize = 100
+
+// OutboxRelay はアウトボックスに記録された未配送イベントをEvent Storeへ配送するバックグラウンドプロセス。
+// 配送に失敗したイベントは次回のポーリングで再送する。
+// 配送後に配送済みの記録が失敗した場合は再送されるため、配送保証は at-least-once となる。
+type OutboxRelay struct {
+	// queries はsqlcが生成したクエリ実行オブジェクト。
+	queries *albumdb.Queries
+	// client はEvent Storeとの通信用HTTPクライアント。
+	client *httpclient.Client
+	// interval はポーリング（再送）間隔。
+	interval time.Duration
+	// notify は新しいイベントの記録を通知し、ポーリング間隔を待たずに配送させるチャネル。
+	notify chan struct{}
+	// cancel はバックグラウンドゴルーチンを停止するためのキャンセル関数。
+	cancel context.CancelFunc
+}
+
+// NewOutboxRelay は新しいOutboxRelayを生成する。
+func NewOutboxRelay(queries *albumdb.Queries, client *httpclient.Client) *OutboxRelay {
+	return &OutboxRelay{
+		queries:  queries,
+		client:   client,
+		interval: 2 * time.Second,
+		notify:   make(chan struct{}, 1),
+	}
+}
+
+// Start はバックグラウンドでアウトボックスの配送を開始する。
+func (r *OutboxRelay) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+
+	go func() {
+		log.Println("OutboxRelay: アウトボックスの配送を開始します")
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("OutboxRelay: 配送を停止しました")
+				return
+			case <-ticker.C:
+			case <-r.notify:
+			}
+			if err := r.relay(ctx); err != nil {
+				log.Printf("OutboxRelay: 配送エラー（次回再送します）: %v", err)
+			}
+		}
+	}()
+}
+
+// Stop はバックグラウンドの配送を停止する。
+func (r *OutboxRelay) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// Notify は新しいイベントが記録されたことをリレーに通知する。
+// 通知済みで未処理の場合は何もしない（ブロックしない）。
+func (r *OutboxRelay) Notify() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// relay は未配送イベントを記録順にEvent Storeへ配送する。
+// 同一Aggregate内のイベント順序を保つため、配送に失敗した時点で処理を中断する。
+func (r *OutboxRelay) relay(ctx context.Context) error {
+	pending, err := r.queries.ListPendingOutboxEvents(ctx, outboxBatchSize)
+	if err != nil {
+		return fmt.Errorf("未配送イベントの取得に失敗: %w", err)
+	}
+
+	for _, ev := range pending {
+		reqBody := map[string]any{
+			"aggregate_id":   ev.AggregateID,
+			"aggregate_type": ev.AggregateType,
+			"event_type":     ev.EventType,
+			"data":           json.RawMessage(ev.Data),
+		}
+
+		sendCtx := httpclient.WithUserID(ctx, ev.UserID)
+		sendCtx = httpclient.WithCausationID(httpclient.WithCorrelationID(sendCtx, ev.CorrelationID), ev.CausationID)
+		if err := r.client.PostJSON(sendCtx, "/api/v1/events", reqBody, nil); err != nil {
+			if recErr := r.queries.RecordOutboxEventFailure(ctx, albumdb.RecordOutboxEventFailureParams{
+				LastError: err.Error(),
+				ID:        ev.ID,
+			}); recErr != nil {
+				log.Printf("OutboxRelay: 配送失敗の記録に失敗: %v", recErr)
+			}
+			return fmt.Errorf("イベント %d（%s）の配送に失敗: %w", ev.ID, ev.EventType, err)
+		}
+
+		if err := r.queries.MarkOutboxEventDelivered(ctx, ev.ID); err != nil {
+			return fmt.Errorf("イベント %d の配送済み記録に失敗: %w", ev.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package album
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// setupOutboxTestServer はEvent Storeをモックに差し替えたテスト用サーバーを構築する。
+func setupOutboxTestServer(t *testing.T) (*Server, *eventstoretest.Server) {
+	t.Helper()
+
+	s, _ := setupTestServer(t)
+	store := eventstoretest.New(t)
+	s.relay = NewOutboxRelay(s.queries, httpclient.New(store.URL))
+	return s, store
+}
+
+// TestOutboxRelay はアウトボックス経由のイベント配送を検証する。
+func TestOutboxRelay(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Event Store停止中もイベントはアウトボックスに残り、復旧後に配送される", func(t *testing.T) {
+		t.Parallel()
+
+		s, store := setupOutboxTestServer(t)
+		store.SetDown(true)
+
+		w := doRequest(s.router, http.MethodPost, "/api/v1/albums", "user-1", map[string]string{"name": "旅行"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusCreated, w.Body.String())
+		}
+
+		ctx := context.Background()
+		pending, err := s.queries.ListPendingOutboxEvents(ctx, outboxBatchSize)
+		if err != nil {
+			t.Fatalf("未配送イベントの取得に失敗: %v", err)
+		}
+		if len(pending) != 1 || pending[0].EventType != "AlbumCreated" {
+			t.Fatalf("アウトボックスにAlbumCreatedが記録されていない: %+v", pending)
+		}
+
+		// 停止中の配送は失敗し、試行回数とエラーが記録される
+		if err := s.relay.relay(ctx); err == nil {
+			t.Fatal("Event Store停止中の配送でエラーが返されるべき")
+		}
+		pending, err = s.queries.ListPendingOutboxEvents(ctx, outboxBatchSize)
+		if err != nil {
+			t.Fatalf("未配送イベントの取得に失敗: %v", err)
+		}
+		if len(pending) != 1 {
+			t.Fatalf("未配送イベント数: got %d, want 1", len(pending))
+		}
+		if pending[0].Attempts != 1 || pending[0].LastError == "" {
+			t.Errorf("配送失敗が記録されていない: attempts=%d, last_error=%q", pending[0].Attempts, pending[0].LastError)
+		}
+		if len(store.Events()) != 0 {
+			t.Errorf("停止中にイベントが受信されている: %+v", store.Events())
+		}
+
+		// 復旧後の配送で届く
+		store.SetDown(false)
+		if err := s.relay.relay(ctx); err != nil {
+			t.Fatalf("復旧後の配送に失敗: %v", err)
+		}
+		received := store.Events()
+		if len(received) != 1 {
+			t.Fatalf("受信イベント数: got %d, want 1", len(received))
+		}
+		if received[0].EventType != "AlbumCreated" || received[0].UserID != "user-1" {
+			t.Errorf("受信イベントが不正: %+v", received[0])
+		}
+
+		pending, err = s.queries.ListPendingOutboxEvents(ctx, outboxBatchSize)
+		if err != nil {
+			t.Fatalf("未配送イベントの取得に失敗: %v", err)
+		}
+		if len(pending) != 0 {
+			t.Errorf("配送済みイベントが未配送のまま残っている: %+v", pending)
+		}
+
+		// 配送済みイベントは再送されない
+		if err := s.relay.relay(ctx); err != nil {
+			t.Fatalf("再配送に失敗: %v", err)
+		}
+		if len(store.Events()) != 1 {
+			t.Errorf("配送済みイベントが再送された: %+v", store.Events())
+		}
+	})
+
+	t.Run("記録順に配送される", func(t *testing.T) {
+		t.Parallel()
+
+		s, store := setupOutboxTestServer(t)
+		store.SetDown(true)
+		createTestAlbum(t, s, "album-1", "user-1", "旅行", "")
+
+		doRequest(s.router, http.MethodPost, "/api/v1/albums/album-1/media", "user-1", map[string]string{"media_id": "media-1"})
+		doRequest(s.router, http.MethodDelete, "/api/v1/albums/album-1/media/media-1", "user-1", nil)
+
+		store.SetDown(false)
+		if err := s.relay.relay(context.Background()); err != nil {
+			t.Fatalf("配送に失敗: %v", err)
+		}
+
+		var got []string
+		for _, ev := range store.Events() {
+			if ev.AggregateID == "album-album-1" {
+				got = append(got, ev.EventType)
+			}
+		}
+		want := []string{"MediaAddedToAlbum", "MediaRemovedFromAlbum"}
+		if len(got) != len(want) {
+			t.Fatalf("album-1のイベント: got %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%d番目のイベント: got %s, want %s", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("バックグラウンド配送で通知後に速やかに配送される", func(t *testing.T) {
+		t.Parallel()
+
+		s, store := setupOutboxTestServer(t)
+		s.relay.interval = time.Hour
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		s.relay.Start(ctx)
+		defer s.relay.Stop()
+
+		w := doRequest(s.router, http.MethodPost, "/api/v1/albums", "user-1", map[string]string{"name": "旅行"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusCreated)
+		}
+
+		deadline := time.Now().Add(3 * time.Second)
+		for len(store.Events()) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("イベントが配送されなかった")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
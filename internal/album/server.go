@@ -1,6 +1,7 @@
 package album
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -17,6 +18,10 @@ import (
 	"github.com/nao1215/micro/pkg/middleware"
 )
 
+// defaultAlbumAlias はユーザーのデフォルトの「All Media」アルバムを指すアルバムIDの別名。
+// Sagaなど、アルバムIDを知らない呼び出し元がデフォルトアルバムを指定するために使う。
+const defaultAlbumAlias = "default"
+
 // Server はアルバムサービスのHTTPサーバー。
 type Server struct {
 	// router はGinのHTTPルーター。
@@ -27,12 +32,12 @@ type Server struct {
 	queries *albumdb.Queries
 	// db はSQLiteデータベース接続。
 	db *sql.DB
-	// eventClient はEvent StoreへのHTTPクライアント。
-	eventClient *httpclient.Client
+	// relay はアウトボックスに記録したイベントをEvent Storeへ配送するバックグラウンドプロセス。
+	relay *OutboxRelay
 }
 
 // NewServer は新しいアルバムサーバーを生成する。
-// SQLiteデータベースの初期化とスキーマ作成を行う。
+// SQLiteデータベースの初期化、スキーマ作成、およびOutboxRelayのバックグラウンド起動を行う。
 func NewServer(port string) (*Server, error) {
 	sqlDB, err := sql.Open("sqlite", "/data/album.db?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=ON")
 	if err != nil {
@@ -49,18 +54,23 @@ func NewServer(port string) (*Server, error) {
 	}
 
 	router := gin.New()
-	router.Use(middleware.Recovery())
-	router.Use(gin.Logger())
+	router.Use(middleware.DefaultChain(middleware.ChainConfig{})...)
+
+	queries := albumdb.New(sqlDB)
+	relay := NewOutboxRelay(queries, httpclient.New(eventstoreURL))
 
 	s := &Server{
-		router:      router,
-		port:        port,
-		queries:     albumdb.New(sqlDB),
-		db:          sqlDB,
-		eventClient: httpclient.New(eventstoreURL),
+		router:  router,
+		port:    port,
+		queries: queries,
+		db:      sqlDB,
+		relay:   relay,
 	}
 	s.setupRoutes()
 
+	// バックグラウンドでアウトボックスの配送を開始する
+	relay.Start(context.Background())
+
 	return s, nil
 }
 
@@ -77,7 +87,11 @@ func (s *Server) setupRoutes() {
 	}
 
 	api := s.router.Group("/api/v1")
-	api.Use(middleware.JWTAuth(jwtSecret))
+	api.Use(middleware.JWTAuth(jwtSecret,
+		middleware.WithPreviousSecrets(middleware.SplitSecrets(os.Getenv("JWT_SECRET_PREVIOUS"))...),
+		middleware.WithIssuer(middleware.Issuer),
+		middleware.WithAudience(middleware.Audience),
+	))
 	{
 		albums := api.Group("/albums")
 		{
@@ -85,25 +99,42 @@ func (s *Server) setupRoutes() {
 			albums.POST("", s.handleCreate())
 			// アルバム一覧取得
 			albums.GET("", s.handleList())
+			// アルバム一覧の表示順変更
+			albums.PUT("/order", s.handleReorder())
+			// デフォルトの「All Media」アルバム取得（存在しない場合は作成する）
+			albums.GET("/"+defaultAlbumAlias, s.handleGetDefault())
 			// アルバム詳細取得
 			albums.GET("/:id", s.handleGetByID())
 			// アルバム更新
 			albums.PUT("/:id", s.handleUpdate())
 			// アルバム削除
 			albums.DELETE("/:id", s.handleDelete())
-			// アルバムにメディアを追加
+			// アルバムにメディアを追加（IDにdefaultを指定するとデフォルトアルバムに追加する）
 			albums.POST("/:id/media", s.handleAddMedia())
-			// アルバムからメディアを削除
+			// アルバムからメディアを削除（IDにdefaultを指定するとデフォルトアルバムから削除する）
 			albums.DELETE("/:id/media/:media_id", s.handleRemoveMedia())
 			// アルバム内メディア一覧取得
 			albums.GET("/:id/media", s.handleListMedia())
 		}
 	}
 
+	// 以下はSagaサービスから呼び出される内部API。
+	// JWTの代わりにX-User-IDヘッダーで伝播されたユーザーとして処理する。
+	internal := s.router.Group("/api/v1/internal/albums")
+	// INTERNAL_SIGNING_KEYが設定されている場合は、Sagaからのリクエストの署名を検証する
+	internal.Use(middleware.VerifyInternalSignature(os.Getenv("INTERNAL_SIGNING_KEY")))
+	internal.Use(middleware.PropagatedUserID())
+	{
+		// ユーザーのデフォルトアルバム取得（存在しない場合は作成する）
+		internal.GET("/"+defaultAlbumAlias, s.handleGetDefault())
+		// アルバムにメディアを追加
+		internal.POST("/:id/media", s.handleAddMedia())
+		// 補償アクション: アルバムからメディアを削除
+		internal.DELETE("/:id/media/:media_id", s.handleRemoveMedia())
+	}
+
 	// ヘルスチェック
-	s.router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "album"})
-	})
+	s.router.GET("/health", middleware.HealthCheck("album", s.db))
 }
 
 // createAlbumRequest はアルバム作成リクエストのJSON構造。
@@ -142,6 +173,8 @@ type albumResponse struct {
 	CreatedAt string `json:"created_at"`
 	// UpdatedAt は更新日時。
 	UpdatedAt string `json:"updated_at"`
+	// Position はユーザーが指定した一覧での表示順（0始まり）。未指定の場合はnull。
+	Position *int64 `json:"position"`
 }
 
 // mediaInAlbumResponse はアルバム内メディアのJSONレスポンス構造。
@@ -154,7 +187,7 @@ type mediaInAlbumResponse struct {
 
 // toAlbumResponse はDB行をJSONレスポンスに変換する。
 func toAlbumResponse(a albumdb.Album) albumResponse {
-	return albumResponse{
+	resp := albumResponse{
 		ID:          a.ID,
 		UserID:      a.UserID,
 		Name:        a.Name,
@@ -162,10 +195,14 @@ func toAlbumResponse(a albumdb.Album) albumResponse {
 		CreatedAt:   a.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedAt:   a.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
+	if a.Position.Valid {
+		resp.Position = &a.Position.Int64
+	}
+	return resp
 }
 
 // handleCreate はアルバム作成を処理するハンドラを返す。
-// 新しいアルバムを作成し、AlbumCreatedイベントをEvent Storeに送信する。
+// 新しいアルバムを作成し、同一トランザクションでAlbumCreatedイベントをアウトボックスに記録する。
 func (s *Server) handleCreate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
@@ -181,24 +218,29 @@ func (s *Server) handleCreate() gin.HandlerFunc {
 		}
 
 		albumID := uuid.New().String()
-		if err := s.queries.CreateAlbum(c.Request.Context(), albumdb.CreateAlbumParams{
-			ID:          albumID,
-			UserID:      userID,
-			Name:        req.Name,
-			Description: req.Description,
-		}); err != nil {
+		err := s.withTx(c.Request.Context(), func(q *albumdb.Queries) error {
+			if err := q.CreateAlbum(c.Request.Context(), albumdb.CreateAlbumParams{
+				ID:          albumID,
+				UserID:      userID,
+				Name:        req.Name,
+				Description: req.Description,
+			}); err != nil {
+				return err
+			}
+
+			// AlbumCreatedイベントをアウトボックスに記録する
+			return enqueueEvent(c.Request.Context(), q, userID, fmt.Sprintf("album-%s", albumID), event.AlbumCreatedData{
+				UserID:      userID,
+				Name:        req.Name,
+				Description: req.Description,
+			}, event.TypeAlbumCreated)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "アルバムの作成に失敗しました"})
 			log.Printf("アルバム作成エラー: %v", err)
 			return
 		}
 
-		// AlbumCreatedイベントをEvent Storeに送信する
-		s.emitEvent(c, fmt.Sprintf("album-%s", albumID), event.AlbumCreatedData{
-			UserID:      userID,
-			Name:        req.Name,
-			Description: req.Description,
-		}, event.TypeAlbumCreated)
-
 		// 作成したアルバムをDBから取得してレスポンスを返す
 		created, err := s.queries.GetAlbumByID(c.Request.Context(), albumID)
 		if err != nil {
@@ -212,6 +254,7 @@ func (s *Server) handleCreate() gin.HandlerFunc {
 }
 
 // handleList はユーザーのアルバム一覧取得を処理するハンドラを返す。
+// ユーザーが指定した表示順のアルバムを先に、表示順が未指定のアルバムをその後に作成日時の新しい順で返す。
 func (s *Server) handleList() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
@@ -232,7 +275,7 @@ func (s *Server) handleList() gin.HandlerFunc {
 			responses = append(responses, toAlbumResponse(a))
 		}
 
-		c.JSON(http.StatusOK, responses)
+		middleware.RespondList(c, http.StatusOK, responses, middleware.Envelope{Data: responses, Count: len(responses)})
 	}
 }
 
@@ -267,6 +310,34 @@ func (s *Server) handleGetByID() gin.HandlerFunc {
 	}
 }
 
+// handleGetDefault は現在のユーザーのデフォルトの「All Media」アルバムを返すハンドラを返す。
+// デフォルトアルバムが存在しない場合は作成してから返す。
+func (s *Server) handleGetDefault() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+
+		albumID, err := s.ensureDefaultAlbum(c, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "デフォルトアルバムの取得に失敗しました"})
+			log.Printf("デフォルトアルバムの確認/作成エラー: %v", err)
+			return
+		}
+
+		a, err := s.queries.GetAlbumByID(c.Request.Context(), albumID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "アルバムの取得に失敗しました"})
+			log.Printf("アルバム取得エラー: %v", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, toAlbumResponse(a))
+	}
+}
+
 // handleUpdate はアルバム更新を処理するハンドラを返す。
 // 指定されたIDのアルバムの名前と説明を更新する。
 func (s *Server) handleUpdate() gin.HandlerFunc {
@@ -325,7 +396,7 @@ func (s *Server) handleUpdate() gin.HandlerFunc {
 }
 
 // handleDelete はアルバム削除を処理するハンドラを返す。
-// 指定されたIDのアルバムを削除し、AlbumDeletedイベントをEvent Storeに送信する。
+// 指定されたIDのアルバムを削除し、同一トランザクションでAlbumDeletedイベントをアウトボックスに記録する。
 func (s *Server) handleDelete() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
@@ -353,24 +424,30 @@ func (s *Server) handleDelete() gin.HandlerFunc {
 			return
 		}
 
-		if err := s.queries.DeleteAlbum(c.Request.Context(), albumID); err != nil {
+		err = s.withTx(c.Request.Context(), func(q *albumdb.Queries) error {
+			if err := q.DeleteAlbum(c.Request.Context(), albumID); err != nil {
+				return err
+			}
+
+			// AlbumDeletedイベントをアウトボックスに記録する
+			return enqueueEvent(c.Request.Context(), q, userID, fmt.Sprintf("album-%s", albumID), event.AlbumDeletedData{
+				UserID: userID,
+			}, event.TypeAlbumDeleted)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "アルバムの削除に失敗しました"})
 			log.Printf("アルバム削除エラー: %v", err)
 			return
 		}
 
-		// AlbumDeletedイベントをEvent Storeに送信する
-		s.emitEvent(c, fmt.Sprintf("album-%s", albumID), event.AlbumDeletedData{
-			UserID: userID,
-		}, event.TypeAlbumDeleted)
-
 		c.JSON(http.StatusOK, gin.H{"message": "アルバムを削除しました"})
 	}
 }
 
 // handleAddMedia はアルバムへのメディア追加を処理するハンドラを返す。
-// メディアをアルバムに追加し、MediaAddedToAlbumイベントをEvent Storeに送信する。
+// メディアをアルバムに追加し、同一トランザクションでMediaAddedToAlbumイベントをアウトボックスに記録する。
 // ユーザーにデフォルトの「All Media」アルバムが存在しない場合は自動的に作成する。
+// アルバムIDにdefaultを指定した場合は、デフォルトアルバムにのみ追加する。
 func (s *Server) handleAddMedia() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
@@ -379,7 +456,12 @@ func (s *Server) handleAddMedia() gin.HandlerFunc {
 			return
 		}
 
-		albumID := c.Param("id")
+		albumID, err := s.resolveAlbumID(c, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "デフォルトアルバムの取得に失敗しました"})
+			log.Printf("デフォルトアルバムの確認/作成エラー: %v", err)
+			return
+		}
 
 		// アルバムの存在確認と所有者チェック
 		a, err := s.queries.GetAlbumByID(c.Request.Context(), albumID)
@@ -411,35 +493,43 @@ func (s *Server) handleAddMedia() gin.HandlerFunc {
 			// デフォルトアルバム作成に失敗しても、指定アルバムへの追加は続行する
 		}
 
-		// 指定されたアルバムにメディアを追加する
-		if err := s.queries.AddMediaToAlbum(c.Request.Context(), albumdb.AddMediaToAlbumParams{
-			AlbumID: albumID,
-			MediaID: req.MediaID,
-		}); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "メディアのアルバムへの追加に失敗しました"})
-			log.Printf("メディア追加エラー: %v", err)
-			return
-		}
-
-		// MediaAddedToAlbumイベントをEvent Storeに送信する
-		s.emitEvent(c, fmt.Sprintf("album-%s", albumID), event.MediaAddedToAlbumData{
-			MediaID: req.MediaID,
-		}, event.TypeMediaAddedToAlbum)
-
-		// デフォルトアルバムが指定アルバムと異なる場合は、デフォルトアルバムにも追加する
-		if defaultAlbumID != "" && defaultAlbumID != albumID {
-			if err := s.queries.AddMediaToAlbum(c.Request.Context(), albumdb.AddMediaToAlbumParams{
-				AlbumID: defaultAlbumID,
+		err = s.withTx(c.Request.Context(), func(q *albumdb.Queries) error {
+			// 指定されたアルバムにメディアを追加する
+			if err := q.AddMediaToAlbum(c.Request.Context(), albumdb.AddMediaToAlbumParams{
+				AlbumID: albumID,
 				MediaID: req.MediaID,
 			}); err != nil {
-				// デフォルトアルバムへの追加失敗はログに記録するが、エラーレスポンスは返さない
-				log.Printf("デフォルトアルバムへのメディア追加エラー: %v", err)
-			} else {
-				// デフォルトアルバムへの追加もイベントを送信する
-				s.emitEvent(c, fmt.Sprintf("album-%s", defaultAlbumID), event.MediaAddedToAlbumData{
+				return err
+			}
+
+			// MediaAddedToAlbumイベントをアウトボックスに記録する
+			if err := enqueueEvent(c.Request.Context(), q, userID, fmt.Sprintf("album-%s", albumID), event.MediaAddedToAlbumData{
+				MediaID: req.MediaID,
+			}, event.TypeMediaAddedToAlbum); err != nil {
+				return err
+			}
+
+			// デフォルトアルバムが指定アルバムと異なる場合は、デフォルトアルバムにも追加する
+			if defaultAlbumID != "" && defaultAlbumID != albumID {
+				if err := q.AddMediaToAlbum(c.Request.Context(), albumdb.AddMediaToAlbumParams{
+					AlbumID: defaultAlbumID,
+					MediaID: req.MediaID,
+				}); err != nil {
+					// デフォルトアルバムへの追加失敗はログに記録するが、エラーレスポンスは返さない
+					log.Printf("デフォルトアルバムへのメディア追加エラー: %v", err)
+					return nil
+				}
+				// デフォルトアルバムへの追加もイベントを記録する
+				return enqueueEvent(c.Request.Context(), q, userID, fmt.Sprintf("album-%s", defaultAlbumID), event.MediaAddedToAlbumData{
 					MediaID: req.MediaID,
 				}, event.TypeMediaAddedToAlbum)
 			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "メディアのアルバムへの追加に失敗しました"})
+			log.Printf("メディア追加エラー: %v", err)
+			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "メディアをアルバムに追加しました"})
@@ -447,7 +537,7 @@ func (s *Server) handleAddMedia() gin.HandlerFunc {
 }
 
 // handleRemoveMedia はアルバムからのメディア削除を処理するハンドラを返す。
-// メディアをアルバムから削除し、MediaRemovedFromAlbumイベントをEvent Storeに送信する。
+// メディアをアルバムから削除し、同一トランザクションでMediaRemovedFromAlbumイベントをアウトボックスに記録する。
 func (s *Server) handleRemoveMedia() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
@@ -456,7 +546,12 @@ func (s *Server) handleRemoveMedia() gin.HandlerFunc {
 			return
 		}
 
-		albumID := c.Param("id")
+		albumID, err := s.resolveAlbumID(c, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "デフォルトアルバムの取得に失敗しました"})
+			log.Printf("デフォルトアルバムの確認/作成エラー: %v", err)
+			return
+		}
 		mediaID := c.Param("media_id")
 
 		// アルバムの存在確認と所有者チェック
@@ -476,20 +571,25 @@ func (s *Server) handleRemoveMedia() gin.HandlerFunc {
 			return
 		}
 
-		if err := s.queries.RemoveMediaFromAlbum(c.Request.Context(), albumdb.RemoveMediaFromAlbumParams{
-			AlbumID: albumID,
-			MediaID: mediaID,
-		}); err != nil {
+		err = s.withTx(c.Request.Context(), func(q *albumdb.Queries) error {
+			if err := q.RemoveMediaFromAlbum(c.Request.Context(), albumdb.RemoveMediaFromAlbumParams{
+				AlbumID: albumID,
+				MediaID: mediaID,
+			}); err != nil {
+				return err
+			}
+
+			// MediaRemovedFromAlbumイベントをアウトボックスに記録する
+			return enqueueEvent(c.Request.Context(), q, userID, fmt.Sprintf("album-%s", albumID), event.MediaRemovedFromAlbumData{
+				MediaID: mediaID,
+			}, event.TypeMediaRemovedFromAlbum)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "メディアのアルバムからの削除に失敗しました"})
 			log.Printf("メディア削除エラー: %v", err)
 			return
 		}
 
-		// MediaRemovedFromAlbumイベントをEvent Storeに送信する
-		s.emitEvent(c, fmt.Sprintf("album-%s", albumID), event.MediaRemovedFromAlbumData{
-			MediaID: mediaID,
-		}, event.TypeMediaRemovedFromAlbum)
-
 		c.JSON(http.StatusOK, gin.H{"message": "メディアをアルバムから削除しました"})
 	}
 }
@@ -537,12 +637,22 @@ func (s *Server) handleListMedia() gin.HandlerFunc {
 			})
 		}
 
-		c.JSON(http.StatusOK, responses)
+		middleware.RespondList(c, http.StatusOK, responses, middleware.Envelope{Data: responses, Count: len(responses)})
 	}
 }
 
+// resolveAlbumID はパスパラメータのアルバムIDを返す。
+// 別名defaultが指定された場合はユーザーのデフォルトアルバムのIDに解決し、存在しなければ作成する。
+func (s *Server) resolveAlbumID(c *gin.Context, userID string) (string, error) {
+	albumID := c.Param("id")
+	if albumID != defaultAlbumAlias {
+		return albumID, nil
+	}
+	return s.ensureDefaultAlbum(c, userID)
+}
+
 // ensureDefaultAlbum はユーザーのデフォルト「All Media」アルバムが存在することを確認する。
-// 存在しない場合は新規作成し、AlbumCreatedイベントをEvent Storeに送信する。
+// 存在しない場合は新規作成し、同一トランザクションでAlbumCreatedイベントをアウトボックスに記録する。
 // デフォルトアルバムのIDを返す。
 func (s *Server) ensureDefaultAlbum(c *gin.Context, userID string) (string, error) {
 	// デフォルトアルバムの存在を確認する
@@ -556,44 +666,74 @@ func (s *Server) ensureDefaultAlbum(c *gin.Context, userID string) (string, erro
 
 	// デフォルトアルバムが存在しないので作成する
 	defaultAlbumID := uuid.New().String()
-	if err := s.queries.CreateAlbum(c.Request.Context(), albumdb.CreateAlbumParams{
-		ID:          defaultAlbumID,
-		UserID:      userID,
-		Name:        "All Media",
-		Description: "すべてのメディアを含むデフォルトアルバム",
-	}); err != nil {
+	err = s.withTx(c.Request.Context(), func(q *albumdb.Queries) error {
+		if err := q.CreateAlbum(c.Request.Context(), albumdb.CreateAlbumParams{
+			ID:          defaultAlbumID,
+			UserID:      userID,
+			Name:        "All Media",
+			Description: "すべてのメディアを含むデフォルトアルバム",
+		}); err != nil {
+			return err
+		}
+
+		// AlbumCreatedイベントをアウトボックスに記録する
+		return enqueueEvent(c.Request.Context(), q, userID, fmt.Sprintf("album-%s", defaultAlbumID), event.AlbumCreatedData{
+			UserID:      userID,
+			Name:        "All Media",
+			Description: "すべてのメディアを含むデフォルトアルバム",
+		}, event.TypeAlbumCreated)
+	})
+	if err != nil {
 		return "", fmt.Errorf("デフォルトアルバムの作成に失敗: %w", err)
 	}
 
-	// AlbumCreatedイベントをEvent Storeに送信する
-	s.emitEvent(c, fmt.Sprintf("album-%s", defaultAlbumID), event.AlbumCreatedData{
-		UserID:      userID,
-		Name:        "All Media",
-		Description: "すべてのメディアを含むデフォルトアルバム",
-	}, event.TypeAlbumCreated)
-
 	log.Printf("ユーザー %s のデフォルトアルバムを作成しました: %s", userID, defaultAlbumID)
 	return defaultAlbumID, nil
 }
 
-// emitEvent はEvent Storeにイベントを送信する。
-// 送信に失敗した場合はログに記録するが、呼び出し元にはエラーを返さない。
-func (s *Server) emitEvent(c *gin.Context, aggregateID string, data any, eventType event.Type) {
-	jsonData, err := json.Marshal(data)
+// withTx はトランザクション内でfnを実行し、成功した場合はコミットする。
+// コミット後はOutboxRelayに通知し、記録したイベントを速やかに配送させる。
+func (s *Server) withTx(ctx context.Context, fn func(q *albumdb.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("イベントデータのシリアライズに失敗: %v", err)
-		return
+		return fmt.Errorf("トランザクション開始に失敗: %w", err)
 	}
+	defer tx.Rollback() //nolint:errcheck
 
-	reqBody := map[string]any{
-		"aggregate_id":   aggregateID,
-		"aggregate_type": string(event.AggregateTypeAlbum),
-		"event_type":     string(eventType),
-		"data":           json.RawMessage(jsonData),
+	if err := fn(s.queries.WithTx(tx)); err != nil {
+		return err
 	}
 
-	ctx := httpclient.WithUserID(c.Request.Context(), middleware.GetUserID(c))
-	if err := s.eventClient.PostJSON(ctx, "/api/v1/events", reqBody, nil); err != nil {
-		log.Printf("Event Storeへのイベント送信に失敗: %v", err)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("トランザクションのコミットに失敗: %w", err)
+	}
+
+	if s.relay != nil {
+		s.relay.Notify()
+	}
+	return nil
+}
+
+// enqueueEvent はイベントをアウトボックスに記録する。
+// アルバムの変更と同一トランザクションのクエリオブジェクトを渡すことで、
+// Event Storeが停止していてもイベントが失われないようにする。
+// ctxの追跡情報（X-Correlation-ID・X-Causation-ID）も記録し、配送時にEvent Storeへ伝播する。
+func enqueueEvent(ctx context.Context, q *albumdb.Queries, userID, aggregateID string, data any, eventType event.Type) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("イベントデータのシリアライズに失敗: %w", err)
+	}
+
+	if err := q.EnqueueOutboxEvent(ctx, albumdb.EnqueueOutboxEventParams{
+		AggregateID:   aggregateID,
+		AggregateType: string(event.AggregateTypeAlbum),
+		EventType:     string(eventType),
+		Data:          string(jsonData),
+		UserID:        userID,
+		CorrelationID: httpclient.CorrelationID(ctx),
+		CausationID:   httpclient.CausationID(ctx),
+	}); err != nil {
+		return fmt.Errorf("アウトボックスへのイベント記録に失敗: %w", err)
 	}
+	return nil
 }
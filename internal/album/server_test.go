@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,7 +11,9 @@ import (
 	"github.com/gin-gonic/gin"
 	_ "modernc.org/sqlite"
 	albumdb "github.com/nao1215/micro/internal/album/db"
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
 	"github.com/nao1215/micro/pkg/httpclient"
+	"github.com/nao1215/micro/pkg/middleware"
 )
 
 func init() {
@@ -28,6 +29,8 @@ func setupTestServer(t *testing.T) (*Server, *gin.Engine) {
 	if err != nil {
 		t.Fatalf("インメモリDBの作成に失敗: %v", err)
 	}
+	// インメモリDBは接続ごとに別のDBになるため、トランザクション中も同じ接続を使うよう1本に制限する
+	sqlDB.SetMaxOpenConns(1)
 	t.Cleanup(func() { sqlDB.Close() })
 
 	if err := initSchema(sqlDB); err != nil {
@@ -35,20 +38,16 @@ func setupTestServer(t *testing.T) (*Server, *gin.Engine) {
 	}
 
 	// Event Storeのモックサーバーを作成する
-	eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		fmt.Fprint(w, `{"id":"mock-event-id"}`)
-	}))
-	t.Cleanup(func() { eventStore.Close() })
+	eventStore := eventstoretest.New(t)
 
 	router := gin.New()
+	queries := albumdb.New(sqlDB)
 	s := &Server{
-		router:      router,
-		port:        "0",
-		queries:     albumdb.New(sqlDB),
-		db:          sqlDB,
-		eventClient: httpclient.New(eventStore.URL),
+		router:  router,
+		port:    "0",
+		queries: queries,
+		db:      sqlDB,
+		relay:   NewOutboxRelay(queries, httpclient.New(eventStore.URL)),
 	}
 
 	// JWTミドルウェアの代わりにテスト用のユーザーID設定ミドルウェアを使用する
@@ -65,6 +64,8 @@ func setupTestServer(t *testing.T) (*Server, *gin.Engine) {
 		{
 			albums.POST("", s.handleCreate())
 			albums.GET("", s.handleList())
+			albums.PUT("/order", s.handleReorder())
+			albums.GET("/"+defaultAlbumAlias, s.handleGetDefault())
 			albums.GET("/:id", s.handleGetByID())
 			albums.PUT("/:id", s.handleUpdate())
 			albums.DELETE("/:id", s.handleDelete())
@@ -73,6 +74,13 @@ func setupTestServer(t *testing.T) (*Server, *gin.Engine) {
 			albums.GET("/:id/media", s.handleListMedia())
 		}
 	}
+	internal := router.Group("/api/v1/internal/albums")
+	internal.Use(middleware.PropagatedUserID())
+	{
+		internal.GET("/"+defaultAlbumAlias, s.handleGetDefault())
+		internal.POST("/:id/media", s.handleAddMedia())
+		internal.DELETE("/:id/media/:media_id", s.handleRemoveMedia())
+	}
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "album"})
 	})
@@ -481,6 +489,168 @@ func TestHandleAddMedia(t *testing.T) {
 	})
 }
 
+// TestDefaultAlbumAlias はアルバムIDの別名defaultがユーザーのデフォルトアルバムに解決されることのテスト。
+func TestDefaultAlbumAlias(t *testing.T) {
+	t.Parallel()
+
+	// mediaIDsInAlbum はアルバム内のメディアIDを返す。
+	mediaIDsInAlbum := func(t *testing.T, router *gin.Engine, albumID, userID string) []string {
+		t.Helper()
+		w := doRequest(router, http.MethodGet, "/api/v1/albums/"+albumID+"/media", userID, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+		}
+		var ids []string
+		for _, m := range parseJSONArray(t, w) {
+			ids = append(ids, m["media_id"].(string))
+		}
+		return ids
+	}
+
+	t.Run("defaultへの追加はデフォルトアルバムを作成してそこに追加される", func(t *testing.T) {
+		t.Parallel()
+		_, router := setupTestServer(t)
+
+		w := doRequest(router, http.MethodPost, "/api/v1/albums/default/media", "user-1", map[string]string{"media_id": "media-1"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		w = doRequest(router, http.MethodGet, "/api/v1/albums/default", "user-1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+		}
+		album := parseJSON(t, w)
+		if album["name"] != "All Media" || album["user_id"] != "user-1" {
+			t.Errorf("デフォルトアルバムが不正: %v", album)
+		}
+
+		albumID := album["id"].(string)
+		if ids := mediaIDsInAlbum(t, router, albumID, "user-1"); len(ids) != 1 || ids[0] != "media-1" {
+			t.Errorf("デフォルトアルバム内のメディア: got %v, want [media-1]", ids)
+		}
+	})
+
+	t.Run("既存のデフォルトアルバムに追加され、他ユーザーのデフォルトアルバムには追加されない", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestAlbum(t, s, "default-user-1", "user-1", "All Media", "デフォルト")
+		createTestAlbum(t, s, "default-user-2", "user-2", "All Media", "デフォルト")
+
+		w := doRequest(router, http.MethodPost, "/api/v1/albums/default/media", "user-1", map[string]string{"media_id": "media-1"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		if ids := mediaIDsInAlbum(t, router, "default-user-1", "user-1"); len(ids) != 1 || ids[0] != "media-1" {
+			t.Errorf("user-1のデフォルトアルバム内のメディア: got %v, want [media-1]", ids)
+		}
+		if ids := mediaIDsInAlbum(t, router, "default-user-2", "user-2"); len(ids) != 0 {
+			t.Errorf("user-2のデフォルトアルバム内のメディア: got %v, want []", ids)
+		}
+
+		w = doRequest(router, http.MethodGet, "/api/v1/albums", "user-1", nil)
+		if albums := parseJSONArray(t, w); len(albums) != 1 {
+			t.Errorf("user-1のアルバム数: got %d, want 1", len(albums))
+		}
+	})
+
+	t.Run("defaultからメディアを削除できる", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestAlbum(t, s, "default-user-1", "user-1", "All Media", "デフォルト")
+		doRequest(router, http.MethodPost, "/api/v1/albums/default/media", "user-1", map[string]string{"media_id": "media-1"})
+
+		w := doRequest(router, http.MethodDelete, "/api/v1/albums/default/media/media-1", "user-1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+		}
+		if ids := mediaIDsInAlbum(t, router, "default-user-1", "user-1"); len(ids) != 0 {
+			t.Errorf("デフォルトアルバム内のメディア: got %v, want []", ids)
+		}
+	})
+
+	t.Run("ユーザーIDが未設定の場合はUnauthorized", func(t *testing.T) {
+		t.Parallel()
+		_, router := setupTestServer(t)
+
+		w := doRequest(router, http.MethodGet, "/api/v1/albums/default", "", nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+}
+
+// TestInternalAlbumAPI はSagaと同じ手順で内部APIを呼び出し、
+// 処理済みメディアがアップロードしたユーザーのデフォルトアルバムに追加されることを検証する。
+func TestInternalAlbumAPI(t *testing.T) {
+	t.Parallel()
+
+	s, router := setupTestServer(t)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	client := httpclient.New(srv.URL)
+
+	createTestAlbum(t, s, "default-user-1", "user-1", "All Media", "デフォルト")
+	createTestAlbum(t, s, "default-user-2", "user-2", "All Media", "デフォルト")
+
+	// mediaIDsInAlbum はアルバム内のメディアIDを返す。
+	mediaIDsInAlbum := func(t *testing.T, albumID string) []string {
+		t.Helper()
+		media, err := s.queries.ListMediaInAlbum(t.Context(), albumID)
+		if err != nil {
+			t.Fatalf("アルバム内メディアの取得に失敗: %v", err)
+		}
+		var ids []string
+		for _, m := range media {
+			ids = append(ids, m.MediaID)
+		}
+		return ids
+	}
+
+	ctx := httpclient.WithUserID(t.Context(), "user-2")
+
+	var album albumResponse
+	if err := client.GetJSON(ctx, "/api/v1/internal/albums/default", &album); err != nil {
+		t.Fatalf("デフォルトアルバムの取得に失敗: %v", err)
+	}
+	if album.ID != "default-user-2" {
+		t.Fatalf("デフォルトアルバムのID: got %q, want %q", album.ID, "default-user-2")
+	}
+
+	if err := client.PostJSON(ctx, "/api/v1/internal/albums/"+album.ID+"/media", map[string]string{"media_id": "media-1"}, nil); err != nil {
+		t.Fatalf("メディアの追加に失敗: %v", err)
+	}
+	if ids := mediaIDsInAlbum(t, "default-user-2"); len(ids) != 1 || ids[0] != "media-1" {
+		t.Errorf("user-2のデフォルトアルバム内のメディア: got %v, want [media-1]", ids)
+	}
+	if ids := mediaIDsInAlbum(t, "default-user-1"); len(ids) != 0 {
+		t.Errorf("user-1のデフォルトアルバム内のメディア: got %v, want []", ids)
+	}
+
+	// 他ユーザーとして呼び出しても、そのユーザーのアルバムは操作できない
+	otherCtx := httpclient.WithUserID(t.Context(), "user-1")
+	if err := client.DeleteJSON(otherCtx, "/api/v1/internal/albums/"+album.ID+"/media/media-1", nil); err == nil {
+		t.Error("他ユーザーのアルバムからの削除はエラーになるべき")
+	}
+
+	// 補償: 追加したアルバムからメディアを取り除く
+	if err := client.DeleteJSON(ctx, "/api/v1/internal/albums/"+album.ID+"/media/media-1", nil); err != nil {
+		t.Fatalf("メディアの削除に失敗: %v", err)
+	}
+	if ids := mediaIDsInAlbum(t, "default-user-2"); len(ids) != 0 {
+		t.Errorf("user-2のデフォルトアルバム内のメディア: got %v, want []", ids)
+	}
+
+	// X-User-IDヘッダーのない呼び出しは拒否する
+	w := doRequest(router, http.MethodGet, "/api/v1/internal/albums/default", "", nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
 // TestHandleListAlbumMedia はアルバム内メディア一覧取得ハンドラのテスト。
 func TestHandleListAlbumMedia(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,111 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// aggregateLimitWarningAggregateID はAggregateEventLimitExceededイベントを追記するSystem AggregateのID。
+const aggregateLimitWarningAggregateID = "system-aggregate-limits"
+
+// Aggregateあたりのイベント数が上限を超えた場合の動作。
+const (
+	// aggregateLimitModeWarn は追記を受け付け、警告のログとイベントを発行する。
+	aggregateLimitModeWarn = "warn"
+	// aggregateLimitModeReject は上限を超える追記を409で拒否する。
+	aggregateLimitModeReject = "reject"
+)
+
+// aggregateLimit はAggregateあたりのイベント数の上限の設定。
+// 同じメディアの再処理の繰り返しなどで無制限にイベントが増え続けるAggregateは不具合の兆候であり、
+// 状態の再構築も遅くなるため、運用者が気付けるようにする。
+type aggregateLimit struct {
+	// max はAggregateあたりのイベント数の上限。0の場合は上限を設けない。
+	max int64
+	// reject は上限を超える追記を拒否する場合にtrueとなる。falseの場合は警告のみ。
+	reject bool
+}
+
+// parseAggregateLimit は環境変数EVENTSTORE_AGGREGATE_EVENT_LIMITとEVENTSTORE_AGGREGATE_EVENT_LIMIT_MODEの値から上限の設定を生成する。
+// limitが空の場合は上限を設けない。modeが空の場合はwarnとする。
+func parseAggregateLimit(limit, mode string) (aggregateLimit, error) {
+	var l aggregateLimit
+	if limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n < 0 {
+			return aggregateLimit{}, fmt.Errorf("EVENTSTORE_AGGREGATE_EVENT_LIMITは0以上の整数で指定してください: %q", limit)
+		}
+		l.max = n
+	}
+	switch mode {
+	case "", aggregateLimitModeWarn:
+	case aggregateLimitModeReject:
+		l.reject = true
+	default:
+		return aggregateLimit{}, fmt.Errorf("EVENTSTORE_AGGREGATE_EVENT_LIMIT_MODEは%sまたは%sで指定してください: %q", aggregateLimitModeWarn, aggregateLimitModeReject, mode)
+	}
+	return l, nil
+}
+
+// exceeded は追記するイベントが上限を超えるかを返す。
+// 警告イベント自体が上限の対象とならないよう、System Aggregateは対象外とする。
+// 上限に達したAggregateでも誤ったイベントを撤回できるよう、EventRetractedも対象外とする。
+func (l aggregateLimit) exceeded(ev *event.Event) bool {
+	if l.max <= 0 || ev.AggregateType == event.AggregateTypeSystem || ev.EventType == event.TypeEventRetracted {
+		return false
+	}
+	return ev.Version > l.max
+}
+
+// checkAggregateLimit は追記するイベントがAggregateのイベント数の上限を超える場合に、
+// rejectモードであれば409のエラーレスポンスを書き込んでfalseを返す。warnモードでは常にtrueを返す。
+func (s *Server) checkAggregateLimit(c *gin.Context, ev *event.Event) bool {
+	if !s.aggregateLimit.reject || !s.aggregateLimit.exceeded(ev) {
+		return true
+	}
+	log.Printf("警告: Aggregateのイベント数が上限（%d件）に達したため追記を拒否しました: aggregate_id=%s", s.aggregateLimit.max, ev.AggregateID)
+	c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Aggregateのイベント数が上限（%d件）に達しています", s.aggregateLimit.max)})
+	return false
+}
+
+// warnAggregateLimit は追記したイベントがAggregateのイベント数の上限を超えた場合に警告をログに出す。
+// 上限を初めて超えたときだけ、運用者が検知できるようSystem AggregateにAggregateEventLimitExceededイベントを追記する。
+// 警告イベントの追記に失敗しても、元のイベントの追記は成功として扱う。呼び出し側でversionMuを保持すること。
+func (s *Server) warnAggregateLimit(ctx context.Context, ev *event.Event) {
+	if !s.aggregateLimit.exceeded(ev) {
+		return
+	}
+	log.Printf("警告: Aggregateのイベント数が上限（%d件）を超えています: aggregate_id=%s, event_count=%d", s.aggregateLimit.max, ev.AggregateID, ev.Version)
+	if ev.Version != s.aggregateLimit.max+1 {
+		return
+	}
+
+	latest, err := latestVersion(ctx, s.queries, aggregateLimitWarningAggregateID)
+	if err != nil {
+		log.Printf("警告イベントのバージョン取得エラー: %v", err)
+		return
+	}
+	warning, err := event.New(aggregateLimitWarningAggregateID, event.AggregateTypeSystem, event.TypeAggregateEventLimitExceeded, latest+1, event.AggregateEventLimitExceededData{
+		AggregateID:   ev.AggregateID,
+		AggregateType: string(ev.AggregateType),
+		EventCount:    ev.Version,
+		Limit:         s.aggregateLimit.max,
+	})
+	if err != nil {
+		log.Printf("警告イベントの生成エラー: %v", err)
+		return
+	}
+	// 上限を超えたイベントと同じ一連の処理として追跡できるよう、そのイベントを原因として記録する
+	warning.Metadata = event.Metadata{CorrelationID: ev.Metadata.CorrelationID, CausationID: ev.ID}
+	if err := appendEvent(ctx, s.queries, warning); err != nil {
+		log.Printf("警告イベントの追記エラー: %v", err)
+		return
+	}
+	s.broadcaster.publish(warning)
+}
@@ -0,0 +1,108 @@
+package eventstore
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestAggregateEventLimit はAggregateあたりのイベント数の上限を超えた追記の動作を検証する。
+func TestAggregateEventLimit(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]interface{}{"user_id": "user-1"}
+
+	t.Run("正常系_warnモードでは上限を超えても追記でき警告イベントを一度だけ発行する", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.aggregateLimit = aggregateLimit{max: 3}
+		for i := 0; i < 5; i++ {
+			if w := appendTestEvent(t, s, "agg-1", "Media", "MediaUploaded", data); w.Code != http.StatusCreated {
+				t.Fatalf("%d件目の追記のステータスコード = %d; 期待値 = %d", i+1, w.Code, http.StatusCreated)
+			}
+		}
+
+		warnings := getAggregateEvents(t, s, aggregateLimitWarningAggregateID, "")
+		if len(warnings) != 1 {
+			t.Fatalf("警告イベント数 = %d; 期待値 = 1", len(warnings))
+		}
+		if warnings[0].EventType != "AggregateEventLimitExceeded" {
+			t.Errorf("警告イベントの種類 = %q; 期待値 = %q", warnings[0].EventType, "AggregateEventLimitExceeded")
+		}
+		if warnings[0].AggregateType != "System" {
+			t.Errorf("警告イベントのAggregate種類 = %q; 期待値 = %q", warnings[0].AggregateType, "System")
+		}
+		if got := len(getAggregateEvents(t, s, "agg-1", "")); got != 5 {
+			t.Errorf("Aggregateのイベント数 = %d; 期待値 = 5", got)
+		}
+	})
+
+	t.Run("異常系_rejectモードでは上限を超える追記を409で拒否する", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.aggregateLimit = aggregateLimit{max: 3, reject: true}
+		for i := 0; i < 3; i++ {
+			if w := appendTestEvent(t, s, "agg-1", "Media", "MediaUploaded", data); w.Code != http.StatusCreated {
+				t.Fatalf("%d件目の追記のステータスコード = %d; 期待値 = %d", i+1, w.Code, http.StatusCreated)
+			}
+		}
+		if w := appendTestEvent(t, s, "agg-1", "Media", "MediaUploaded", data); w.Code != http.StatusConflict {
+			t.Errorf("上限を超える追記のステータスコード = %d; 期待値 = %d", w.Code, http.StatusConflict)
+		}
+		if got := len(getAggregateEvents(t, s, "agg-1", "")); got != 3 {
+			t.Errorf("Aggregateのイベント数 = %d; 期待値 = 3", got)
+		}
+		// 別のAggregateへの追記は影響を受けない
+		if w := appendTestEvent(t, s, "agg-2", "Media", "MediaUploaded", data); w.Code != http.StatusCreated {
+			t.Errorf("別Aggregateへの追記のステータスコード = %d; 期待値 = %d", w.Code, http.StatusCreated)
+		}
+	})
+
+	t.Run("異常系_rejectモードでは予約したバージョンでも上限を超える追記を拒否する", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.aggregateLimit = aggregateLimit{max: 1, reject: true}
+		if w := appendTestEvent(t, s, "agg-1", "Media", "MediaUploaded", data); w.Code != http.StatusCreated {
+			t.Fatalf("追記のステータスコード = %d; 期待値 = %d", w.Code, http.StatusCreated)
+		}
+		reservation := mustReserveVersion(t, s, "agg-1")
+		if w := appendReservedTestEvent(t, s, "agg-1", reservation.ReservationID); w.Code != http.StatusConflict {
+			t.Errorf("上限を超える予約した追記のステータスコード = %d; 期待値 = %d", w.Code, http.StatusConflict)
+		}
+	})
+}
+
+// TestParseAggregateLimit は上限の設定の解析を検証する。
+func TestParseAggregateLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		limit   string
+		mode    string
+		want    aggregateLimit
+		wantErr bool
+	}{
+		{name: "正常系_未設定の場合は上限なし", want: aggregateLimit{}},
+		{name: "正常系_上限のみ指定した場合はwarnモード", limit: "100", want: aggregateLimit{max: 100}},
+		{name: "正常系_rejectモード", limit: "100", mode: "reject", want: aggregateLimit{max: 100, reject: true}},
+		{name: "異常系_負の上限", limit: "-1", wantErr: true},
+		{name: "異常系_数値でない上限", limit: "abc", wantErr: true},
+		{name: "異常系_不明なモード", limit: "100", mode: "block", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseAggregateLimit(tt.limit, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAggregateLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAggregateLimit() = %+v; 期待値 = %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+const (
+	// defaultBackupDir はバックアップファイルの既定の保存先ディレクトリ。
+	defaultBackupDir = "/data/backups"
+	// maxBackupJobs はポーリング用に保持するバックアップジョブの最大件数。超えた場合は古い完了済みジョブから破棄する。
+	maxBackupJobs = 20
+)
+
+// バックアップジョブの状態。
+const (
+	backupStatusRunning   = "running"
+	backupStatusCompleted = "completed"
+	backupStatusFailed    = "failed"
+)
+
+// errBackupRunning はバックアップジョブの実行中に新しいジョブを開始しようとした場合のエラー。
+var errBackupRunning = errors.New("バックアップは実行中です")
+
+// parseAdminUserIDs は環境変数ADMIN_USER_IDSの値（カンマ区切りのユーザーID）を管理者の集合に変換する。
+// 前後の空白を除き、空の要素は含めない。
+func parseAdminUserIDs(v string) map[string]struct{} {
+	ids := make(map[string]struct{})
+	for _, id := range strings.Split(v, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// requireAdmin は管理者以外のリクエストを403で拒否するミドルウェアを返す。
+// JWTAuthミドルウェアの後に適用する。
+func (s *Server) requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := s.adminUserIDs[middleware.GetUserID(c)]; !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "管理者のみ実行できます"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// backupJob はバックアップジョブの状態。ポーリング用のレスポンスとしてそのまま返す。
+type backupJob struct {
+	// ID はジョブの一意識別子。
+	ID string `json:"id"`
+	// Status はジョブの状態（running, completed, failed）。
+	Status string `json:"status"`
+	// Path はバックアップファイルの保存先。
+	Path string `json:"path"`
+	// SourceBytes は開始時点のデータベースのサイズ（バイト）。進捗の目安に使用する。
+	SourceBytes int64 `json:"source_bytes"`
+	// BytesWritten は書き込み済みのバックアップのサイズ（バイト）。
+	BytesWritten int64 `json:"bytes_written"`
+	// StartedAt はジョブの開始日時。
+	StartedAt time.Time `json:"started_at"`
+	// CompletedAt はジョブの終了日時。実行中はnull。
+	CompletedAt *time.Time `json:"completed_at"`
+	// Error は失敗の理由。
+	Error string `json:"error,omitempty"`
+}
+
+// backupManager はEvent Storeのデータベースのバックアップジョブを管理する。
+// バックアップはVACUUM INTOで作成する。読み取りトランザクション内で一貫したスナップショットを書き出すため、
+// WALモードではバックアップ中も通常の読み書きを継続できる。
+type backupManager struct {
+	// db はバックアップ元のデータベース接続。
+	db *sql.DB
+	// dir はバックアップファイルの保存先ディレクトリ。
+	dir string
+	// running はジョブの実行中にtrueとなる。同時に1つのジョブだけを実行するために使用する。
+	running atomic.Bool
+	// mu はjobsへの並行アクセスを保護するミューテックス。
+	mu sync.Mutex
+	// jobs はジョブIDごとのジョブの状態。
+	jobs map[string]*backupJob
+}
+
+// newBackupManager は保存先dirにバックアップを作成するbackupManagerを生成する。
+func newBackupManager(db *sql.DB, dir string) *backupManager {
+	return &backupManager{
+		db:   db,
+		dir:  dir,
+		jobs: make(map[string]*backupJob),
+	}
+}
+
+// start はバックアップジョブをバックグラウンドで開始し、開始時点のジョブの状態を返す。
+// filenameが空の場合は日時とジョブIDからファイル名を決める。
+// 実行中のジョブがある場合はerrBackupRunningを返す。
+func (m *backupManager) start(ctx context.Context, filename string) (backupJob, error) {
+	if !m.running.CompareAndSwap(false, true) {
+		return backupJob{}, errBackupRunning
+	}
+
+	job, err := m.prepare(ctx, filename)
+	if err != nil {
+		m.running.Store(false)
+		return backupJob{}, err
+	}
+
+	go func() {
+		defer m.running.Store(false)
+		m.run(job)
+	}()
+	return m.snapshot(job), nil
+}
+
+// prepare は保存先を確認し、実行中のジョブとして登録する。
+func (m *backupManager) prepare(ctx context.Context, filename string) (*backupJob, error) {
+	id := uuid.New().String()
+	now := time.Now().UTC()
+	if filename == "" {
+		filename = fmt.Sprintf("eventstore-%s-%s.db", now.Format("20060102T150405Z"), id[:8])
+	}
+	if filename != filepath.Base(filename) || strings.HasPrefix(filename, ".") {
+		return nil, fmt.Errorf("ファイル名にディレクトリを含めることはできません: %q", filename)
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("バックアップ保存先の作成に失敗: %w", err)
+	}
+	path := filepath.Join(m.dir, filename)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("同じ名前のバックアップが既に存在します: %s", filename)
+	}
+
+	var sourceBytes int64
+	if err := m.db.QueryRowContext(ctx, "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()").Scan(&sourceBytes); err != nil {
+		return nil, fmt.Errorf("データベースサイズの取得に失敗: %w", err)
+	}
+
+	job := &backupJob{
+		ID:          id,
+		Status:      backupStatusRunning,
+		Path:        path,
+		SourceBytes: sourceBytes,
+		StartedAt:   now,
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+	m.jobs[id] = job
+	return job, nil
+}
+
+// run はVACUUM INTOでバックアップを作成し、ジョブの状態を更新する。
+// 書き込み途中のファイルを完成したバックアップと取り違えないよう、一時ファイルに書き出してから名前を変更する。
+// リクエストの終了後も継続するため、リクエストのコンテキストは使用しない。
+func (m *backupManager) run(job *backupJob) {
+	tmpPath := job.Path + ".tmp"
+	err := func() error {
+		if _, err := m.db.ExecContext(context.Background(), "VACUUM INTO ?", tmpPath); err != nil {
+			return fmt.Errorf("バックアップの作成に失敗: %w", err)
+		}
+		if err := os.Rename(tmpPath, job.Path); err != nil {
+			return fmt.Errorf("バックアップファイルの配置に失敗: %w", err)
+		}
+		return nil
+	}()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	job.CompletedAt = &now
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		job.Status = backupStatusFailed
+		job.Error = err.Error()
+		log.Printf("[Backup] バックアップに失敗しました: id=%s, error=%v", job.ID, err)
+		return
+	}
+	job.Status = backupStatusCompleted
+	if info, err := os.Stat(job.Path); err == nil {
+		job.BytesWritten = info.Size()
+	}
+	log.Printf("[Backup] バックアップが完了しました: id=%s, path=%s, size=%d", job.ID, job.Path, job.BytesWritten)
+}
+
+// get はジョブの現在の状態を返す。実行中のジョブは書き込み済みのサイズを含める。
+func (m *backupManager) get(id string) (backupJob, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return backupJob{}, false
+	}
+	return m.snapshotLocked(job), true
+}
+
+// snapshot はジョブの状態のコピーを返す。
+func (m *backupManager) snapshot(job *backupJob) backupJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshotLocked(job)
+}
+
+// snapshotLocked はジョブの状態のコピーを返す。呼び出し側でmuを保持すること。
+func (m *backupManager) snapshotLocked(job *backupJob) backupJob {
+	snap := *job
+	if snap.Status == backupStatusRunning {
+		if info, err := os.Stat(job.Path + ".tmp"); err == nil {
+			snap.BytesWritten = info.Size()
+		}
+	}
+	return snap
+}
+
+// pruneLocked は保持するジョブがmaxBackupJobs件未満になるまで、古い完了済みジョブを破棄する。
+// 呼び出し側でmuを保持すること。
+func (m *backupManager) pruneLocked() {
+	if len(m.jobs) < maxBackupJobs {
+		return
+	}
+	finished := make([]*backupJob, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		if job.Status != backupStatusRunning {
+			finished = append(finished, job)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].StartedAt.Before(finished[j].StartedAt) })
+	for _, job := range finished {
+		if len(m.jobs) < maxBackupJobs {
+			return
+		}
+		delete(m.jobs, job.ID)
+	}
+}
+
+// backupRequest はバックアップ開始リクエストのJSON構造。
+type backupRequest struct {
+	// Filename はバックアップ保存先ディレクトリ内のファイル名。省略した場合は日時から決める。
+	Filename string `json:"filename"`
+}
+
+// handleStartBackup はバックアップジョブを開始するハンドラを返す。
+// バックアップはバックグラウンドで作成し、ジョブの状態を202で返す。
+// 完了はGET /api/v1/admin/backup/:id をポーリングして確認する。実行中のジョブがある場合は409を返す。
+func (s *Server) handleStartBackup() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req backupRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+				return
+			}
+		}
+
+		job, err := s.backups.start(c.Request.Context(), strings.TrimSpace(req.Filename))
+		if errors.Is(err, errBackupRunning) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		log.Printf("[Backup] バックアップを開始しました: id=%s, path=%s, user_id=%s", job.ID, job.Path, middleware.GetUserID(c))
+		c.JSON(http.StatusAccepted, job)
+	}
+}
+
+// handleGetBackup はバックアップジョブの状態を返すハンドラを返す。
+func (s *Server) handleGetBackup() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, ok := s.backups.get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "バックアップジョブが見つかりません"})
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
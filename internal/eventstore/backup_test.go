@@ -0,0 +1,193 @@
+package eventstore
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "modernc.org/sqlite"
+	eventstoredb "github.com/nao1215/micro/internal/eventstore/db"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// setupAdminTestServer はWALモードのファイルDBで管理API用のテストサーバーを構築するヘルパー関数。
+// インメモリDBは接続ごとに別のデータベースとなり、VACUUM INTOの検証に使えないためファイルDBを使用する。
+func setupAdminTestServer(t *testing.T, adminUserIDs ...string) *Server {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	sqlDB, err := sql.Open("sqlite", filepath.Join(dir, "eventstore.db")+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("SQLiteの接続に失敗: %v", err)
+	}
+	t.Cleanup(func() {
+		sqlDB.Close()
+	})
+	if err := initSchema(sqlDB); err != nil {
+		t.Fatalf("スキーマ初期化に失敗: %v", err)
+	}
+
+	admins := make(map[string]struct{}, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = struct{}{}
+	}
+
+	s := &Server{
+		router:       gin.New(),
+		port:         "0",
+		queries:      eventstoredb.New(sqlDB),
+		db:           sqlDB,
+		adminUserIDs: admins,
+		backups:      newBackupManager(sqlDB, filepath.Join(dir, "backups")),
+		broadcaster:  newBroadcaster(),
+	}
+	s.setupRoutes()
+	return s
+}
+
+// doAdminRequest は指定ユーザーのJWTを付与して管理APIへリクエストするヘルパー関数。
+func doAdminRequest(t *testing.T, s *Server, method, path, userID string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	token, err := middleware.GenerateJWT("dev-secret-key", userID, userID+"@example.com")
+	if err != nil {
+		t.Fatalf("JWT生成に失敗: %v", err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// waitBackup はバックアップジョブが終了するまでポーリングし、最終状態を返すヘルパー関数。
+func waitBackup(t *testing.T, s *Server, userID, id string) backupJob {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		w := doAdminRequest(t, s, http.MethodGet, "/api/v1/admin/backup/"+id, userID, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		var job backupJob
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+		}
+		if job.Status != backupStatusRunning {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("バックアップが時間内に終了しませんでした: id=%s", id)
+	return backupJob{}
+}
+
+// TestHandleBackup はバックアップAPIの各パターンを検証する。
+func TestHandleBackup(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_バックアップが完了しイベントを含む", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		for _, id := range []string{"agg-1", "agg-2"} {
+			if w := appendTestEvent(t, s, id, "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"}); w.Code != http.StatusCreated {
+				t.Fatalf("イベントの追記に失敗: status = %d", w.Code)
+			}
+		}
+
+		w := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/backup", "admin-1", []byte(`{"filename":"snapshot.db"}`))
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusAccepted, w.Body.String())
+		}
+		var started backupJob
+		if err := json.Unmarshal(w.Body.Bytes(), &started); err != nil {
+			t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+		}
+		if started.Status != backupStatusRunning {
+			t.Errorf("status = %q; 期待値 = %q", started.Status, backupStatusRunning)
+		}
+
+		// バックアップ中も書き込みを継続できる
+		if w := appendTestEvent(t, s, "agg-3", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"}); w.Code != http.StatusCreated {
+			t.Fatalf("バックアップ中のイベント追記に失敗: status = %d", w.Code)
+		}
+
+		job := waitBackup(t, s, "admin-1", started.ID)
+		if job.Status != backupStatusCompleted {
+			t.Fatalf("status = %q; 期待値 = %q, error = %s", job.Status, backupStatusCompleted, job.Error)
+		}
+		if filepath.Base(job.Path) != "snapshot.db" {
+			t.Errorf("path = %q; 期待値のファイル名 = snapshot.db", job.Path)
+		}
+		if job.BytesWritten <= 0 || job.CompletedAt == nil {
+			t.Errorf("bytes_written = %d, completed_at = %v; 完了情報が設定されていない", job.BytesWritten, job.CompletedAt)
+		}
+
+		backupDB, err := sql.Open("sqlite", job.Path)
+		if err != nil {
+			t.Fatalf("バックアップの接続に失敗: %v", err)
+		}
+		defer backupDB.Close()
+		var count int
+		if err := backupDB.QueryRow("SELECT COUNT(*) FROM events WHERE aggregate_id IN ('agg-1', 'agg-2')").Scan(&count); err != nil {
+			t.Fatalf("バックアップの読み取りに失敗: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("バックアップ内のイベント数 = %d; 期待値 = 2", count)
+		}
+	})
+
+	t.Run("異常系_ディレクトリを含むファイル名は400", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		w := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/backup", "admin-1", []byte(`{"filename":"../evil.db"}`))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("異常系_管理者以外は403", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		w := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/backup", "user-1", nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusForbidden)
+		}
+	})
+
+	t.Run("異常系_存在しないジョブは404", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		w := doAdminRequest(t, s, http.MethodGet, "/api/v1/admin/backup/unknown", "admin-1", nil)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("異常系_実行中は409", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		s.backups.running.Store(true)
+		w := doAdminRequest(t, s, http.MethodPost, "/api/v1/admin/backup", "admin-1", nil)
+		if w.Code != http.StatusConflict {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusConflict)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package eventstore
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	eventstoredb "github.com/nao1215/micro/internal/eventstore/db"
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// maxBatchEvents はバッチ追記で1リクエストに含められるイベントの最大件数。
+const maxBatchEvents = 500
+
+// handleAppendEventBatch は複数イベントの原子的な追記を処理するハンドラを返す。
+// リクエストボディはappendEventRequestの配列で、単一トランザクション内で配列の順に追記する。
+// バージョンはAggregateごとに最新バージョン+1から採番し、同じAggregateIDを複数含む場合も連番になる。
+// expected_versionは、そのイベントより前にバッチ内で追記した分を含めた最新バージョンと比較する。
+// 1件でも追記できなければ全件をロールバックし、エラーレスポンスのみを返す。
+// 空配列と上限（maxBatchEvents件）を超える配列、reservation_id・created_atの指定は400を返す。
+func (s *Server) handleAppendEventBatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqs []appendEventRequest
+		if err := c.ShouldBindJSON(&reqs); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+			return
+		}
+		if len(reqs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "追記するイベントを1件以上指定してください"})
+			return
+		}
+		if len(reqs) > maxBatchEvents {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("一度に追記できるイベントは%d件までです", maxBatchEvents)})
+			return
+		}
+		for i, req := range reqs {
+			switch {
+			case req.ReservationID != "":
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("events[%d]: バッチ追記ではreservation_idを指定できません", i)})
+				return
+			case req.CreatedAt != "":
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("events[%d]: バッチ追記ではcreated_atを指定できません", i)})
+				return
+			case req.ExpectedVersion != nil && *req.ExpectedVersion < 0:
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("events[%d]: expected_versionは0以上で指定してください", i)})
+				return
+			case !event.IsValidType(event.Type(req.EventType)):
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("events[%d]: 未登録のイベントタイプです: %s", i, req.EventType)})
+				return
+			}
+			if err := s.validateEventData(event.Type(req.EventType), req.Data); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("events[%d]: %v", i, err)})
+				return
+			}
+		}
+
+		events, ok := s.appendBatch(c, reqs)
+		if !ok {
+			return
+		}
+
+		responses := make([]eventResponse, 0, len(events))
+		for _, ev := range events {
+			responses = append(responses, toEventResponse(ev.ID, ev.AggregateID, string(ev.AggregateType), string(ev.EventType), string(ev.Data), ev.Version, ev.CreatedAt, ev.Metadata))
+		}
+		c.JSON(http.StatusCreated, responses)
+	}
+}
+
+// appendBatch はイベントを単一トランザクション内で順に追記する。
+// 失敗した場合はロールバックしてエラーレスポンスを書き込み、falseを返す。
+// コミット後に購読者へ配信し、Aggregateのイベント数の上限を確認する。
+func (s *Server) appendBatch(c *gin.Context, reqs []appendEventRequest) ([]*event.Event, bool) {
+	ctx := c.Request.Context()
+
+	// 予約の確認からバージョンの採番・追記までの間に予約や他の追記が割り込まないよう直列化する
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+
+	checked := make(map[string]struct{})
+	for _, req := range reqs {
+		if _, ok := checked[req.AggregateID]; ok {
+			continue
+		}
+		checked[req.AggregateID] = struct{}{}
+
+		reserved, err := s.queries.CountActiveVersionReservations(ctx, eventstoredb.CountActiveVersionReservationsParams{
+			AggregateID: req.AggregateID,
+			Now:         time.Now().UTC(),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン予約の確認に失敗しました"})
+			log.Printf("バージョン予約の確認エラー: %v", err)
+			return nil, false
+		}
+		if reserved > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("予約中のバージョンがあるため追記できません: aggregate_id=%s", req.AggregateID)})
+			return nil, false
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "トランザクションの開始に失敗しました"})
+		log.Printf("トランザクション開始エラー: %v", err)
+		return nil, false
+	}
+	defer tx.Rollback()
+
+	q := s.queries.WithTx(tx)
+	// versions はAggregateIDごとのバッチ内で最後に採番したバージョン
+	versions := make(map[string]int64)
+	events := make([]*event.Event, 0, len(reqs))
+	for i, req := range reqs {
+		latest, ok := versions[req.AggregateID]
+		if !ok {
+			latest, err = latestVersion(ctx, q, req.AggregateID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン取得に失敗しました"})
+				log.Printf("バージョン取得エラー: %v", err)
+				return nil, false
+			}
+		}
+		if req.ExpectedVersion != nil && *req.ExpectedVersion != latest {
+			c.JSON(http.StatusConflict, versionConflictResponse{
+				Error:           fmt.Sprintf("events[%d]: Aggregateのバージョンが期待と一致しません（期待: %d, 最新: %d）。最新の状態を取得し直してください", i, *req.ExpectedVersion, latest),
+				ExpectedVersion: *req.ExpectedVersion,
+				CurrentVersion:  latest,
+			})
+			return nil, false
+		}
+
+		ev, err := event.New(req.AggregateID, event.AggregateType(req.AggregateType), event.Type(req.EventType), latest+1, req.Data)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント生成に失敗しました"})
+			log.Printf("イベント生成エラー: %v", err)
+			return nil, false
+		}
+		ev.Metadata = metadataFrom(withMetadata(ctx, req.Metadata))
+		if !s.checkAggregateLimit(c, ev) {
+			return nil, false
+		}
+		if err := appendEvent(ctx, q, ev); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("events[%d]: イベントの追記に失敗しました（バージョン競合の可能性）", i)})
+			log.Printf("イベント追記エラー: %v", err)
+			return nil, false
+		}
+		versions[req.AggregateID] = ev.Version
+		events = append(events, ev)
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの追記に失敗しました"})
+		log.Printf("トランザクションのコミットエラー: %v", err)
+		return nil, false
+	}
+	for _, ev := range events {
+		s.broadcaster.publish(ev)
+		s.warnAggregateLimit(ctx, ev)
+	}
+	return events, true
+}
@@ -0,0 +1,260 @@
+package eventstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// appendBatchTestEvents はテスト用にイベントのバッチ追記をPOSTするヘルパー関数。
+func appendBatchTestEvents(t *testing.T, s *Server, reqs any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(reqs)
+	if err != nil {
+		t.Fatalf("リクエストボディのJSON変換に失敗: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events/batch", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// batchTestEvent はバッチ追記する1件分のリクエストを生成する。
+func batchTestEvent(aggregateID, eventType string) appendEventRequest {
+	return appendEventRequest{
+		AggregateID:   aggregateID,
+		AggregateType: "Media",
+		EventType:     eventType,
+		Data:          json.RawMessage(`{"user_id":"user-batch"}`),
+	}
+}
+
+// countTestEvents はAggregateのイベント件数を返す。
+func countTestEvents(t *testing.T, s *Server, aggregateID string) int {
+	t.Helper()
+
+	events, err := s.queries.GetEventsByAggregateID(t.Context(), aggregateID)
+	if err != nil {
+		t.Fatalf("イベントの取得に失敗: %v", err)
+	}
+	return len(events)
+}
+
+func TestHandleAppendEventBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_複数Aggregateのイベントを追記しAggregateごとに連番で採番する", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		if w := appendTestEvent(t, s, "media-batch-1", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-batch"}); w.Code != http.StatusCreated {
+			t.Fatalf("事前の追記に失敗: %d", w.Code)
+		}
+
+		w := appendBatchTestEvents(t, s, []appendEventRequest{
+			batchTestEvent("media-batch-1", "MediaProcessed"),
+			batchTestEvent("media-batch-2", "MediaUploaded"),
+			batchTestEvent("media-batch-1", "MediaDeleted"),
+			batchTestEvent("media-batch-2", "MediaProcessed"),
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var resp []eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		want := []struct {
+			aggregateID string
+			eventType   string
+			version     int64
+		}{
+			{aggregateID: "media-batch-1", eventType: "MediaProcessed", version: 2},
+			{aggregateID: "media-batch-2", eventType: "MediaUploaded", version: 1},
+			{aggregateID: "media-batch-1", eventType: "MediaDeleted", version: 3},
+			{aggregateID: "media-batch-2", eventType: "MediaProcessed", version: 2},
+		}
+		if len(resp) != len(want) {
+			t.Fatalf("期待するイベント数 %d, 実際のイベント数 %d", len(want), len(resp))
+		}
+		for i, ev := range resp {
+			if ev.AggregateID != want[i].aggregateID || ev.EventType != want[i].eventType || ev.Version != want[i].version {
+				t.Errorf("%d件目: 期待する %s/%s/v%d, 実際の %s/%s/v%d", i, want[i].aggregateID, want[i].eventType, want[i].version, ev.AggregateID, ev.EventType, ev.Version)
+			}
+			if ev.ID == "" {
+				t.Errorf("%d件目: IDが空", i)
+			}
+		}
+		if got := countTestEvents(t, s, "media-batch-1"); got != 3 {
+			t.Errorf("media-batch-1: 期待するイベント数 3, 実際のイベント数 %d", got)
+		}
+	})
+
+	t.Run("異常系_途中のイベントが失敗した場合は全件をロールバックする", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		expected := int64(5)
+		reqs := []appendEventRequest{
+			batchTestEvent("media-rollback-1", "MediaUploaded"),
+			batchTestEvent("media-rollback-2", "MediaUploaded"),
+			batchTestEvent("media-rollback-1", "MediaProcessed"),
+		}
+		reqs[2].ExpectedVersion = &expected
+
+		w := appendBatchTestEvents(t, s, reqs)
+		if w.Code != http.StatusConflict {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+		var conflict versionConflictResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &conflict); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		// バッチ内で先に追記したイベントを含めた最新バージョンと比較する
+		if conflict.CurrentVersion != 1 {
+			t.Errorf("期待するcurrent_version 1, 実際のcurrent_version %d", conflict.CurrentVersion)
+		}
+		for _, id := range []string{"media-rollback-1", "media-rollback-2"} {
+			if got := countTestEvents(t, s, id); got != 0 {
+				t.Errorf("%s: 期待するイベント数 0, 実際のイベント数 %d", id, got)
+			}
+		}
+	})
+
+	t.Run("正常系_expected_versionはバッチ内で先に追記した分を含めて比較する", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		zero, one := int64(0), int64(1)
+		reqs := []appendEventRequest{
+			batchTestEvent("media-expected", "MediaUploaded"),
+			batchTestEvent("media-expected", "MediaProcessed"),
+		}
+		reqs[0].ExpectedVersion = &zero
+		reqs[1].ExpectedVersion = &one
+
+		if w := appendBatchTestEvents(t, s, reqs); w.Code != http.StatusCreated {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("異常系_不正なリクエストは400を返し何も追記しない", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		tooMany := make([]appendEventRequest, maxBatchEvents+1)
+		for i := range tooMany {
+			tooMany[i] = batchTestEvent(fmt.Sprintf("media-many-%d", i), "MediaUploaded")
+		}
+		withReservation := batchTestEvent("media-invalid", "MediaUploaded")
+		withReservation.ReservationID = "reservation-1"
+		withCreatedAt := batchTestEvent("media-invalid", "MediaUploaded")
+		withCreatedAt.CreatedAt = "2020-01-01T00:00:00Z"
+
+		tests := []struct {
+			name string
+			body any
+		}{
+			{name: "空配列", body: []appendEventRequest{}},
+			{name: "上限超過", body: tooMany},
+			{name: "配列でない", body: batchTestEvent("media-invalid", "MediaUploaded")},
+			{name: "必須項目の欠落", body: []map[string]any{{"aggregate_id": "media-invalid", "aggregate_type": "Media", "data": map[string]any{}}}},
+			{name: "未登録のイベントタイプ", body: []appendEventRequest{batchTestEvent("media-invalid", "MediaUploaded"), batchTestEvent("media-invalid", "MediaUplaoded")}},
+			{name: "reservation_idの指定", body: []appendEventRequest{withReservation}},
+			{name: "created_atの指定", body: []appendEventRequest{withCreatedAt}},
+		}
+		for _, tt := range tests {
+			w := appendBatchTestEvents(t, s, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", tt.name, http.StatusBadRequest, w.Code, w.Body.String())
+			}
+		}
+		if got := countTestEvents(t, s, "media-invalid"); got != 0 {
+			t.Errorf("期待するイベント数 0, 実際のイベント数 %d", got)
+		}
+		if got := countTestEvents(t, s, "media-many-0"); got != 0 {
+			t.Errorf("上限超過: 期待するイベント数 0, 実際のイベント数 %d", got)
+		}
+	})
+
+	t.Run("異常系_Aggregateのイベント数の上限を超える場合は全件をロールバックする", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.aggregateLimit = aggregateLimit{max: 1, reject: true}
+
+		w := appendBatchTestEvents(t, s, []appendEventRequest{
+			batchTestEvent("media-limit-other", "MediaUploaded"),
+			batchTestEvent("media-limit", "MediaUploaded"),
+			batchTestEvent("media-limit", "MediaProcessed"),
+		})
+		if w.Code != http.StatusConflict {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+		if got := countTestEvents(t, s, "media-limit-other"); got != 0 {
+			t.Errorf("期待するイベント数 0, 実際のイベント数 %d", got)
+		}
+	})
+}
+
+func TestHandleAppendEventBatchBroadcast(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_コミット後に配列の順で購読者へ配信する", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		sub := s.broadcaster.subscribe(func(*event.Event) bool { return true })
+		defer s.broadcaster.unsubscribe(sub)
+
+		w := appendBatchTestEvents(t, s, []appendEventRequest{
+			batchTestEvent("album-multi", "MediaUploaded"),
+			batchTestEvent("media-multi", "MediaUploaded"),
+			batchTestEvent("album-multi", "MediaProcessed"),
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		want := []string{"album-multi/1", "media-multi/1", "album-multi/2"}
+		if got := len(sub.events); got != len(want) {
+			t.Fatalf("期待する配信数 %d, 実際の配信数 %d", len(want), got)
+		}
+		for i := range want {
+			ev := <-sub.events
+			if got := fmt.Sprintf("%s/%d", ev.AggregateID, ev.Version); got != want[i] {
+				t.Errorf("%d件目: 期待する %s, 実際の %s", i, want[i], got)
+			}
+		}
+	})
+
+	t.Run("異常系_ロールバックした場合は1件も配信しない", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		sub := s.broadcaster.subscribe(func(*event.Event) bool { return true })
+		defer s.broadcaster.unsubscribe(sub)
+
+		expected := int64(3)
+		reqs := []appendEventRequest{
+			batchTestEvent("media-multi-ok", "MediaUploaded"),
+			batchTestEvent("media-multi-ng", "MediaUploaded"),
+		}
+		reqs[1].ExpectedVersion = &expected
+		if w := appendBatchTestEvents(t, s, reqs); w.Code != http.StatusConflict {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+		if got := len(sub.events); got != 0 {
+			t.Errorf("期待する配信数 0, 実際の配信数 %d", got)
+		}
+	})
+}
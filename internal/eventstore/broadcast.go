@@ -0,0 +1,82 @@
+package eventstore
+
+import (
+	"sync"
+
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// subscriberBufferSize は購読者ごとに溜めておける未送信イベントの件数。
+// 送信が追いつかず溢れた購読者は切断し、再接続後に取得APIで差分を取り直してもらう。
+const subscriberBufferSize = 64
+
+// subscriber は追記されたイベントの購読者。
+type subscriber struct {
+	// match は購読者に配信するイベントかを判定する。
+	match func(*event.Event) bool
+	// events は配信するイベントのチャネル。配信が追いつかず切断した場合はクローズされる。
+	events chan *event.Event
+}
+
+// broadcaster は追記されたイベントを購読者に配信する。
+// 追記の処理を購読者の受信速度に左右されないよう、配信はブロックしない。
+type broadcaster struct {
+	// mu はsubscribersへの並行アクセスを保護するミューテックス。
+	mu sync.Mutex
+	// subscribers は現在の購読者の集合。
+	subscribers map[*subscriber]struct{}
+}
+
+// newBroadcaster は購読者のいないbroadcasterを生成する。
+func newBroadcaster() *broadcaster {
+	return &broadcaster{subscribers: make(map[*subscriber]struct{})}
+}
+
+// subscribe はmatchがtrueを返すイベントを受け取る購読者を登録する。
+// 購読をやめる際は必ずunsubscribeを呼び出すこと。
+func (b *broadcaster) subscribe(match func(*event.Event) bool) *subscriber {
+	sub := &subscriber{
+		match:  match,
+		events: make(chan *event.Event, subscriberBufferSize),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// unsubscribe は購読者の登録を解除する。配信が追いつかず切断済みの購読者に対して呼び出してもよい。
+func (b *broadcaster) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// publish は追記されたイベントを該当する購読者に配信する。
+// 未送信のイベントが溜まって受け取れない購読者は、イベントの取りこぼしを気付かせるため切断する。
+func (b *broadcaster) publish(ev *event.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		if !sub.match(ev) {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// count は現在の購読者数を返す。
+func (b *broadcaster) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
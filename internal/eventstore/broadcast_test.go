@@ -0,0 +1,64 @@
+package eventstore
+
+import (
+	"testing"
+
+	"github.com/nao1215/micro/pkg/event"
+)
+
+func TestBroadcaster(t *testing.T) {
+	t.Parallel()
+
+	newEvent := func(t *testing.T, aggregateID string) *event.Event {
+		t.Helper()
+		ev, err := event.New(aggregateID, event.AggregateTypeMedia, event.TypeMediaUploaded, 1, event.MediaUploadedData{})
+		if err != nil {
+			t.Fatalf("イベントの生成に失敗: %v", err)
+		}
+		return ev
+	}
+	forAggregate := func(aggregateID string) func(*event.Event) bool {
+		return func(ev *event.Event) bool { return ev.AggregateID == aggregateID }
+	}
+
+	t.Run("正常系_条件に一致する購読者にのみ配信する", func(t *testing.T) {
+		t.Parallel()
+
+		b := newBroadcaster()
+		target := b.subscribe(forAggregate("agg-1"))
+		other := b.subscribe(forAggregate("agg-2"))
+		defer b.unsubscribe(target)
+		defer b.unsubscribe(other)
+
+		b.publish(newEvent(t, "agg-1"))
+		if got := len(target.events); got != 1 {
+			t.Errorf("購読対象のイベント数 = %d; 期待値 = 1", got)
+		}
+		if got := len(other.events); got != 0 {
+			t.Errorf("購読対象外のイベント数 = %d; 期待値 = 0", got)
+		}
+	})
+
+	t.Run("異常系_配信が追いつかない購読者は切断する", func(t *testing.T) {
+		t.Parallel()
+
+		b := newBroadcaster()
+		sub := b.subscribe(forAggregate("agg-1"))
+		for range subscriberBufferSize + 1 {
+			b.publish(newEvent(t, "agg-1"))
+		}
+		if got := b.count(); got != 0 {
+			t.Fatalf("購読者数 = %d; 期待値 = 0", got)
+		}
+
+		// 溜まっていたイベントを受け取った後にチャネルがクローズされている
+		for range subscriberBufferSize {
+			<-sub.events
+		}
+		if _, ok := <-sub.events; ok {
+			t.Error("切断した購読者のチャネルがクローズされていません")
+		}
+		// 切断済みの購読者の登録解除は何もしない
+		b.unsubscribe(sub)
+	})
+}
@@ -16,4 +16,22 @@ type Event struct {
 	Data          string
 	Version       int64
 	CreatedAt     time.Time
+	UserID        string
+	Filename      string
+	Metadata      string
+}
+
+type Snapshot struct {
+	AggregateID string
+	Version     int64
+	State       string
+	CreatedAt   time.Time
+}
+
+type VersionReservation struct {
+	ID          string
+	AggregateID string
+	Version     int64
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
 }
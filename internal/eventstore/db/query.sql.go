@@ -7,12 +7,13 @@ package eventstoredb
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
 const appendEvent = `-- name: AppendEvent :exec
-INSERT INTO events (id, aggregate_id, aggregate_type, event_type, data, version, created_at)
-VALUES (?, ?, ?, ?, ?, ?, ?)
+INSERT INTO events (id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type AppendEventParams struct {
@@ -23,6 +24,9 @@ type AppendEventParams struct {
 	Data          string
 	Version       int64
 	CreatedAt     time.Time
+	UserID        string
+	Filename      string
+	Metadata      string
 }
 
 func (q *Queries) AppendEvent(ctx context.Context, arg AppendEventParams) error {
@@ -34,12 +38,164 @@ func (q *Queries) AppendEvent(ctx context.Context, arg AppendEventParams) error
 		arg.Data,
 		arg.Version,
 		arg.CreatedAt,
+		arg.UserID,
+		arg.Filename,
+		arg.Metadata,
 	)
 	return err
 }
 
+const countActiveVersionReservations = `-- name: CountActiveVersionReservations :one
+SELECT COUNT(*)
+FROM version_reservations
+WHERE aggregate_id = ?
+  AND expires_at > ?
+`
+
+type CountActiveVersionReservationsParams struct {
+	AggregateID string
+	Now         time.Time
+}
+
+func (q *Queries) CountActiveVersionReservations(ctx context.Context, arg CountActiveVersionReservationsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countActiveVersionReservations, arg.AggregateID, arg.Now)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countEventRetractions = `-- name: CountEventRetractions :one
+SELECT COUNT(*)
+FROM events
+WHERE aggregate_id = ?
+  AND event_type = 'EventRetracted'
+  AND json_extract(data, '$.retracted_event_id') = ?
+`
+
+type CountEventRetractionsParams struct {
+	AggregateID      string
+	RetractedEventID interface{}
+}
+
+func (q *Queries) CountEventRetractions(ctx context.Context, arg CountEventRetractionsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countEventRetractions, arg.AggregateID, arg.RetractedEventID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countEventsByFilter = `-- name: CountEventsByFilter :one
+SELECT COUNT(*)
+FROM events
+WHERE (?1 = '' OR aggregate_type = ?1)
+  AND (?2 = '' OR event_type = ?2)
+`
+
+type CountEventsByFilterParams struct {
+	AggregateType interface{}
+	EventType     interface{}
+}
+
+// 空文字列を指定した条件は絞り込まない。
+func (q *Queries) CountEventsByFilter(ctx context.Context, arg CountEventsByFilterParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countEventsByFilter, arg.AggregateType, arg.EventType)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countEventsByTypeAndDay = `-- name: CountEventsByTypeAndDay :many
+SELECT event_type,
+       CAST(substr(created_at, 1, 10) AS TEXT) AS day,
+       CAST(COUNT(*) AS INTEGER) AS event_count
+FROM events
+WHERE created_at >= ?
+GROUP BY event_type, day
+ORDER BY day ASC, event_type ASC
+`
+
+type CountEventsByTypeAndDayRow struct {
+	EventType  string
+	Day        string
+	EventCount int64
+}
+
+func (q *Queries) CountEventsByTypeAndDay(ctx context.Context, since time.Time) ([]CountEventsByTypeAndDayRow, error) {
+	rows, err := q.db.QueryContext(ctx, countEventsByTypeAndDay, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountEventsByTypeAndDayRow
+	for rows.Next() {
+		var i CountEventsByTypeAndDayRow
+		if err := rows.Scan(
+			&i.EventType,
+			&i.Day,
+			&i.EventCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createVersionReservation = `-- name: CreateVersionReservation :exec
+INSERT INTO version_reservations (id, aggregate_id, version, expires_at, created_at)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateVersionReservationParams struct {
+	ID          string
+	AggregateID string
+	Version     int64
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+func (q *Queries) CreateVersionReservation(ctx context.Context, arg CreateVersionReservationParams) error {
+	_, err := q.db.ExecContext(ctx, createVersionReservation,
+		arg.ID,
+		arg.AggregateID,
+		arg.Version,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const deleteExpiredVersionReservations = `-- name: DeleteExpiredVersionReservations :execrows
+DELETE FROM version_reservations
+WHERE expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredVersionReservations(ctx context.Context, expiresAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredVersionReservations, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteVersionReservation = `-- name: DeleteVersionReservation :exec
+DELETE FROM version_reservations
+WHERE id = ?
+`
+
+func (q *Queries) DeleteVersionReservation(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteVersionReservation, id)
+	return err
+}
+
 const getAllEvents = `-- name: GetAllEvents :many
-SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
 FROM events
 ORDER BY created_at ASC
 `
@@ -61,6 +217,9 @@ func (q *Queries) GetAllEvents(ctx context.Context) ([]Event, error) {
 			&i.Data,
 			&i.Version,
 			&i.CreatedAt,
+			&i.UserID,
+			&i.Filename,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -75,8 +234,32 @@ func (q *Queries) GetAllEvents(ctx context.Context) ([]Event, error) {
 	return items, nil
 }
 
+const getEventByID = `-- name: GetEventByID :one
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+WHERE id = ?
+`
+
+func (q *Queries) GetEventByID(ctx context.Context, id string) (Event, error) {
+	row := q.db.QueryRowContext(ctx, getEventByID, id)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.AggregateID,
+		&i.AggregateType,
+		&i.EventType,
+		&i.Data,
+		&i.Version,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.Filename,
+		&i.Metadata,
+	)
+	return i, err
+}
+
 const getEventsByAggregateID = `-- name: GetEventsByAggregateID :many
-SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
 FROM events
 WHERE aggregate_id = ?
 ORDER BY version ASC
@@ -99,6 +282,9 @@ func (q *Queries) GetEventsByAggregateID(ctx context.Context, aggregateID string
 			&i.Data,
 			&i.Version,
 			&i.CreatedAt,
+			&i.UserID,
+			&i.Filename,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -114,7 +300,7 @@ func (q *Queries) GetEventsByAggregateID(ctx context.Context, aggregateID string
 }
 
 const getEventsByAggregateType = `-- name: GetEventsByAggregateType :many
-SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
 FROM events
 WHERE aggregate_type = ?
 ORDER BY created_at ASC
@@ -137,6 +323,50 @@ func (q *Queries) GetEventsByAggregateType(ctx context.Context, aggregateType st
 			&i.Data,
 			&i.Version,
 			&i.CreatedAt,
+			&i.UserID,
+			&i.Filename,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsByFilename = `-- name: GetEventsByFilename :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+WHERE filename = ?
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetEventsByFilename(ctx context.Context, filename string) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, getEventsByFilename, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.AggregateType,
+			&i.EventType,
+			&i.Data,
+			&i.Version,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.Filename,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -152,7 +382,7 @@ func (q *Queries) GetEventsByAggregateType(ctx context.Context, aggregateType st
 }
 
 const getEventsByType = `-- name: GetEventsByType :many
-SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
 FROM events
 WHERE event_type = ?
 ORDER BY created_at ASC
@@ -175,6 +405,50 @@ func (q *Queries) GetEventsByType(ctx context.Context, eventType string) ([]Even
 			&i.Data,
 			&i.Version,
 			&i.CreatedAt,
+			&i.UserID,
+			&i.Filename,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsByUserID = `-- name: GetEventsByUserID :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+WHERE user_id = ?
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetEventsByUserID(ctx context.Context, userID string) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, getEventsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.AggregateType,
+			&i.EventType,
+			&i.Data,
+			&i.Version,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.Filename,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -190,14 +464,128 @@ func (q *Queries) GetEventsByType(ctx context.Context, eventType string) ([]Even
 }
 
 const getEventsSince = `-- name: GetEventsSince :many
-SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
 FROM events
 WHERE created_at > ?
-ORDER BY created_at ASC
+ORDER BY created_at ASC, version ASC, rowid ASC
+LIMIT ?
+`
+
+type GetEventsSinceParams struct {
+	CreatedAt time.Time
+	Limit     int64
+}
+
+func (q *Queries) GetEventsSince(ctx context.Context, arg GetEventsSinceParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, getEventsSince, arg.CreatedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.AggregateType,
+			&i.EventType,
+			&i.Data,
+			&i.Version,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.Filename,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsSinceByType = `-- name: GetEventsSinceByType :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+WHERE created_at > ? AND event_type = ?
+ORDER BY created_at ASC, version ASC, rowid ASC
+LIMIT ?
 `
 
-func (q *Queries) GetEventsSince(ctx context.Context, createdAt time.Time) ([]Event, error) {
-	rows, err := q.db.QueryContext(ctx, getEventsSince, createdAt)
+type GetEventsSinceByTypeParams struct {
+	CreatedAt time.Time
+	EventType string
+	Limit     int64
+}
+
+func (q *Queries) GetEventsSinceByType(ctx context.Context, arg GetEventsSinceByTypeParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, getEventsSinceByType, arg.CreatedAt, arg.EventType, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.AggregateType,
+			&i.EventType,
+			&i.Data,
+			&i.Version,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.Filename,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsSinceByTypes = `-- name: GetEventsSinceByTypes :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+WHERE created_at > ? AND event_type IN (/*SLICE:event_types*/?)
+ORDER BY created_at ASC, version ASC, rowid ASC
+LIMIT ?
+`
+
+type GetEventsSinceByTypesParams struct {
+	CreatedAt  time.Time
+	EventTypes []string
+	Limit      int64
+}
+
+func (q *Queries) GetEventsSinceByTypes(ctx context.Context, arg GetEventsSinceByTypesParams) ([]Event, error) {
+	query := getEventsSinceByTypes
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.CreatedAt)
+	if len(arg.EventTypes) > 0 {
+		for _, v := range arg.EventTypes {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:event_types*/?", strings.Repeat(",?", len(arg.EventTypes))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:event_types*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
@@ -213,6 +601,9 @@ func (q *Queries) GetEventsSince(ctx context.Context, createdAt time.Time) ([]Ev
 			&i.Data,
 			&i.Version,
 			&i.CreatedAt,
+			&i.UserID,
+			&i.Filename,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -227,6 +618,41 @@ func (q *Queries) GetEventsSince(ctx context.Context, createdAt time.Time) ([]Ev
 	return items, nil
 }
 
+const getLatestEventCreatedAt = `-- name: GetLatestEventCreatedAt :one
+SELECT created_at
+FROM events
+WHERE aggregate_id = ?
+ORDER BY version DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestEventCreatedAt(ctx context.Context, aggregateID string) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getLatestEventCreatedAt, aggregateID)
+	var created_at time.Time
+	err := row.Scan(&created_at)
+	return created_at, err
+}
+
+const getLatestSnapshot = `-- name: GetLatestSnapshot :one
+SELECT aggregate_id, version, state, created_at
+FROM snapshots
+WHERE aggregate_id = ?
+ORDER BY version DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestSnapshot(ctx context.Context, aggregateID string) (Snapshot, error) {
+	row := q.db.QueryRowContext(ctx, getLatestSnapshot, aggregateID)
+	var i Snapshot
+	err := row.Scan(
+		&i.AggregateID,
+		&i.Version,
+		&i.State,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getLatestVersion = `-- name: GetLatestVersion :one
 SELECT COALESCE(MAX(version), 0) AS latest_version
 FROM events
@@ -239,3 +665,216 @@ func (q *Queries) GetLatestVersion(ctx context.Context, aggregateID string) (int
 	err := row.Scan(&latest_version)
 	return latest_version, err
 }
+
+const getMaxReservedVersion = `-- name: GetMaxReservedVersion :one
+SELECT CAST(COALESCE(MAX(version), 0) AS INTEGER) AS max_version
+FROM version_reservations
+WHERE aggregate_id = ?
+  AND expires_at > ?
+`
+
+type GetMaxReservedVersionParams struct {
+	AggregateID string
+	Now         time.Time
+}
+
+func (q *Queries) GetMaxReservedVersion(ctx context.Context, arg GetMaxReservedVersionParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getMaxReservedVersion, arg.AggregateID, arg.Now)
+	var max_version int64
+	err := row.Scan(&max_version)
+	return max_version, err
+}
+
+const getVersionReservation = `-- name: GetVersionReservation :one
+SELECT id, aggregate_id, version, expires_at, created_at
+FROM version_reservations
+WHERE id = ?
+`
+
+func (q *Queries) GetVersionReservation(ctx context.Context, id string) (VersionReservation, error) {
+	row := q.db.QueryRowContext(ctx, getVersionReservation, id)
+	var i VersionReservation
+	err := row.Scan(
+		&i.ID,
+		&i.AggregateID,
+		&i.Version,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAggregateIDsByType = `-- name: ListAggregateIDsByType :many
+SELECT DISTINCT aggregate_id
+FROM events
+WHERE aggregate_type = ?
+  AND aggregate_id > ?
+ORDER BY aggregate_id ASC
+LIMIT ?
+`
+
+type ListAggregateIDsByTypeParams struct {
+	AggregateType string
+	Cursor        string
+	Limit         int64
+}
+
+func (q *Queries) ListAggregateIDsByType(ctx context.Context, arg ListAggregateIDsByTypeParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listAggregateIDsByType, arg.AggregateType, arg.Cursor, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var aggregate_id string
+		if err := rows.Scan(&aggregate_id); err != nil {
+			return nil, err
+		}
+		items = append(items, aggregate_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsPaginated = `-- name: ListEventsPaginated :many
+SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, user_id, filename, metadata
+FROM events
+ORDER BY created_at ASC, rowid ASC
+LIMIT ? OFFSET ?
+`
+
+type ListEventsPaginatedParams struct {
+	Limit  int64
+	Offset int64
+}
+
+func (q *Queries) ListEventsPaginated(ctx context.Context, arg ListEventsPaginatedParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, listEventsPaginated, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.AggregateType,
+			&i.EventType,
+			&i.Data,
+			&i.Version,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.Filename,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVersionAnomalies = `-- name: ListVersionAnomalies :many
+SELECT aggregate_id,
+       CAST(COUNT(*) AS INTEGER) AS event_count,
+       CAST(MIN(version) AS INTEGER) AS min_version,
+       CAST(MAX(version) AS INTEGER) AS max_version
+FROM events
+GROUP BY aggregate_id
+HAVING MIN(version) <> 1
+    OR MAX(version) <> COUNT(*)
+    OR COUNT(DISTINCT version) <> COUNT(*)
+ORDER BY aggregate_id ASC
+`
+
+type ListVersionAnomaliesRow struct {
+	AggregateID string
+	EventCount  int64
+	MinVersion  int64
+	MaxVersion  int64
+}
+
+func (q *Queries) ListVersionAnomalies(ctx context.Context) ([]ListVersionAnomaliesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVersionAnomalies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVersionAnomaliesRow
+	for rows.Next() {
+		var i ListVersionAnomaliesRow
+		if err := rows.Scan(
+			&i.AggregateID,
+			&i.EventCount,
+			&i.MinVersion,
+			&i.MaxVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const saveSnapshot = `-- name: SaveSnapshot :exec
+INSERT INTO snapshots (aggregate_id, version, state, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (aggregate_id, version) DO UPDATE
+SET state = excluded.state,
+    created_at = excluded.created_at
+`
+
+type SaveSnapshotParams struct {
+	AggregateID string
+	Version     int64
+	State       string
+	CreatedAt   time.Time
+}
+
+func (q *Queries) SaveSnapshot(ctx context.Context, arg SaveSnapshotParams) error {
+	_, err := q.db.ExecContext(ctx, saveSnapshot,
+		arg.AggregateID,
+		arg.Version,
+		arg.State,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const summarizeEvents = `-- name: SummarizeEvents :one
+SELECT CAST(COUNT(DISTINCT aggregate_id) AS INTEGER) AS aggregate_count,
+       CAST(COUNT(*) AS INTEGER) AS event_count
+FROM events
+`
+
+type SummarizeEventsRow struct {
+	AggregateCount int64
+	EventCount     int64
+}
+
+func (q *Queries) SummarizeEvents(ctx context.Context) (SummarizeEventsRow, error) {
+	row := q.db.QueryRowContext(ctx, summarizeEvents)
+	var i SummarizeEventsRow
+	err := row.Scan(&i.AggregateCount, &i.EventCount)
+	return i, err
+}
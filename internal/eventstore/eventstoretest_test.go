@@ -0,0 +1,133 @@
+package eventstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// TestEventStoreTestServerFidelity はeventstoretestのモックが実際のEvent Storeと同じ形式で応答することを検証する。
+// 同じリクエストの列を両方に送り、ステータスコード・レスポンスのキー・採番したバージョン・エラーの内容を比較する。
+// 続けて、追記したイベントをAggregateのイベント取得APIで取得した結果を比較する。
+func TestEventStoreTestServerFidelity(t *testing.T) {
+	t.Parallel()
+
+	store := httptest.NewServer(setupTestServer(t).router)
+	t.Cleanup(store.Close)
+	mock := eventstoretest.New(t)
+
+	// 比較しない値（サーバーごとに異なるIDと作成日時）
+	ignored := map[string]bool{"id": true, "created_at": true}
+
+	requests := []struct {
+		name string
+		body string
+	}{
+		{name: "最初の追記", body: `{"aggregate_id":"media-fidelity","aggregate_type":"Media","event_type":"MediaUploaded","data":{"user_id":"user-1", "filename":"a.jpg"}}`},
+		{name: "expected_versionが一致する追記", body: `{"aggregate_id":"media-fidelity","aggregate_type":"Media","event_type":"MediaProcessed","data":{"width":10},"expected_version":1}`},
+		{name: "expected_versionが古い追記", body: `{"aggregate_id":"media-fidelity","aggregate_type":"Media","event_type":"MediaDeleted","data":{},"expected_version":1}`},
+		{name: "metadataを指定した追記", body: `{"aggregate_id":"media-fidelity","aggregate_type":"Media","event_type":"MediaProcessed","data":{"width":20},"metadata":{"correlation_id":"correlation-body","causation_id":"event-1"}}`},
+		{name: "別のAggregateへの追記", body: `{"aggregate_id":"album-fidelity","aggregate_type":"Album","event_type":"AlbumCreated","data":{"name":"旅行"}}`},
+		{name: "未登録のイベントタイプ", body: `{"aggregate_id":"media-fidelity","aggregate_type":"Media","event_type":"MediaUplaoded","data":{}}`},
+		{name: "負のexpected_version", body: `{"aggregate_id":"media-fidelity","aggregate_type":"Media","event_type":"MediaDeleted","data":{},"expected_version":-1}`},
+		{name: "必須項目の欠落", body: `{"aggregate_id":"media-fidelity","aggregate_type":"Media","data":{}}`},
+	}
+	for _, tt := range requests {
+		realStatus, realBody := postFidelityRequest(t, store.URL, tt.body)
+		mockStatus, mockBody := postFidelityRequest(t, mock.URL, tt.body)
+
+		if realStatus != mockStatus {
+			t.Errorf("%s: ステータスコードが一致しない（Event Store: %d, モック: %d）", tt.name, realStatus, mockStatus)
+			continue
+		}
+		realKeys := slices.Sorted(maps.Keys(realBody))
+		mockKeys := slices.Sorted(maps.Keys(mockBody))
+		if !slices.Equal(realKeys, mockKeys) {
+			t.Errorf("%s: レスポンスのキーが一致しない（Event Store: %v, モック: %v）", tt.name, realKeys, mockKeys)
+			continue
+		}
+		for _, key := range realKeys {
+			if ignored[key] {
+				continue
+			}
+			// 必須項目の欠落はバリデーションライブラリのメッセージをそのまま返すため、エラーの文言は比較しない
+			if key == "error" && tt.name == "必須項目の欠落" {
+				continue
+			}
+			if !reflect.DeepEqual(realBody[key], mockBody[key]) {
+				t.Errorf("%s: %sが一致しない（Event Store: %v, モック: %v）", tt.name, key, realBody[key], mockBody[key])
+			}
+		}
+	}
+
+	// 追記したイベントをAggregateのイベント取得APIで同じ形式・順序で返す
+	for _, aggregateID := range []string{"media-fidelity", "album-fidelity", "media-none"} {
+		realEvents := getFidelityEvents(t, store.URL, aggregateID)
+		mockEvents := getFidelityEvents(t, mock.URL, aggregateID)
+		if len(realEvents) != len(mockEvents) {
+			t.Errorf("%s: イベント数が一致しない（Event Store: %d, モック: %d）", aggregateID, len(realEvents), len(mockEvents))
+			continue
+		}
+		for i := range realEvents {
+			for key := range ignored {
+				delete(realEvents[i], key)
+				delete(mockEvents[i], key)
+			}
+			if !reflect.DeepEqual(realEvents[i], mockEvents[i]) {
+				t.Errorf("%s: %d件目のイベントが一致しない（Event Store: %v, モック: %v）", aggregateID, i, realEvents[i], mockEvents[i])
+			}
+		}
+	}
+}
+
+// getFidelityEvents はAggregateのイベント取得APIを呼び出し、JSONレスポンスのイベントを返す。
+func getFidelityEvents(t *testing.T, baseURL, aggregateID string) []map[string]any {
+	t.Helper()
+
+	resp, err := http.Get(baseURL + eventstoretest.AggregatePathPrefix + aggregateID)
+	if err != nil {
+		t.Fatalf("リクエストの送信に失敗: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("%s: 期待するステータスコード %d, 実際のステータスコード %d", baseURL, http.StatusOK, resp.StatusCode)
+	}
+
+	var events []map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+	}
+	return events
+}
+
+// postFidelityRequest はイベント追記APIにbodyをPOSTし、ステータスコードとJSONレスポンスを返す。
+// metadataを比較できるよう、両方に同じX-Correlation-IDヘッダーを付与する。
+func postFidelityRequest(t *testing.T, baseURL, body string) (int, map[string]any) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, baseURL+eventstoretest.AppendPath, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("リクエストの作成に失敗: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(httpclient.CorrelationIDHeader, "correlation-fidelity")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("リクエストの送信に失敗: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+	}
+	return resp.StatusCode, decoded
+}
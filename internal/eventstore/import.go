@@ -0,0 +1,96 @@
+package eventstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// importEventRequest はイベントインポートリクエストのJSON構造。
+// 通常の追記と異なり、イベントの本来の作成日時を指定する。
+type importEventRequest struct {
+	AggregateID   string          `json:"aggregate_id" binding:"required"`
+	AggregateType string          `json:"aggregate_type" binding:"required"`
+	EventType     string          `json:"event_type" binding:"required"`
+	Data          json.RawMessage `json:"data" binding:"required"`
+	// CreatedAt はイベントの本来の作成日時（RFC3339形式）。
+	CreatedAt string `json:"created_at" binding:"required"`
+	// Metadata はエクスポートしたイベントの追跡情報。省略した場合は通常の追記と同様に決める。
+	Metadata *event.Metadata `json:"metadata"`
+}
+
+// handleImportEvent は他システムからのデータ移行用に、作成日時を指定したイベントの追記を処理するハンドラを返す。
+// 作成日時を任意に指定できるため管理者のJWTを必須とし（ルーティングで検証）、
+// 通常運用での誤用を防ぐため、環境変数EVENTSTORE_IMPORT_ENABLED=trueの場合のみ受け付け、それ以外は403を返す。
+// 作成日時が未来の場合は400を返す。同じAggregateの最新イベントより前の作成日時はイベントの順序を壊すため409を返す。
+// 作成日時が過去のイベントは、日時指定でポーリングしているProjector等が取得済みの範囲に追記される可能性があるため、
+// インポート後はRead Modelを再構築すること。
+func (s *Server) handleImportEvent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.importEnabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": "イベントのインポートは無効です（EVENTSTORE_IMPORT_ENABLED=trueで有効化してください）"})
+			return
+		}
+
+		var req importEventRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+			return
+		}
+		if err := s.validateEventData(event.Type(req.EventType), req.Data); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		createdAt, ok := s.parseImportCreatedAt(c, req.AggregateID, req.CreatedAt)
+		if !ok {
+			return
+		}
+
+		c.Request = c.Request.WithContext(withMetadata(c.Request.Context(), req.Metadata))
+		ev, ok := s.appendNextVersionAt(c, req.AggregateID, event.AggregateType(req.AggregateType), event.Type(req.EventType), req.Data, createdAt, nil)
+		if !ok {
+			return
+		}
+		log.Printf("イベントをインポートしました: id=%s, aggregate_id=%s, created_at=%s", ev.ID, ev.AggregateID, ev.CreatedAt.Format(time.RFC3339))
+
+		c.JSON(http.StatusCreated, toEventResponse(ev.ID, ev.AggregateID, string(ev.AggregateType), string(ev.EventType), string(ev.Data), ev.Version, ev.CreatedAt, ev.Metadata))
+	}
+}
+
+// parseImportCreatedAt はインポートするイベントの作成日時（RFC3339形式）を解析し、追記できるかを検証する。
+// 作成日時が未来の場合は400、同じAggregateの最新イベントより前の場合はイベントの順序を壊すため409を返す。
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す。
+func (s *Server) parseImportCreatedAt(c *gin.Context, aggregateID, raw string) (time.Time, bool) {
+	createdAt, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "created_atはRFC3339形式で指定してください"})
+		return time.Time{}, false
+	}
+	createdAt = createdAt.UTC()
+	if createdAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "created_atに未来の日時は指定できません"})
+		return time.Time{}, false
+	}
+
+	latest, err := s.queries.GetLatestEventCreatedAt(c.Request.Context(), aggregateID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// 最初のイベントのため順序の制約はない
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "最新イベントの取得に失敗しました"})
+		log.Printf("最新イベント取得エラー: %v", err)
+		return time.Time{}, false
+	case createdAt.Before(latest):
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("created_atは同じAggregateの最新イベントの日時（%s）以降を指定してください", latest.UTC().Format(time.RFC3339))})
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
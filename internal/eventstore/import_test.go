@@ -0,0 +1,243 @@
+package eventstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// importAdminUserID はインポートのテストで管理者として登録するユーザーID。
+const importAdminUserID = "admin-import"
+
+// importTestEvent はテスト用に管理者のJWTを付与してイベントインポートをPOSTするヘルパー関数。
+// 呼び出し元でimportAdminUserIDを管理者として登録すること。
+func importTestEvent(t *testing.T, s *Server, aggregateID, createdAt string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{
+		"aggregate_id":   aggregateID,
+		"aggregate_type": "Media",
+		"event_type":     "MediaUploaded",
+		"data":           map[string]string{"user_id": "user-import", "filename": "legacy.jpg"},
+		"created_at":     createdAt,
+	})
+	if err != nil {
+		t.Fatalf("リクエストボディのJSON変換に失敗: %v", err)
+	}
+	return doAdminRequest(t, s, http.MethodPost, "/api/v1/events/import", importAdminUserID, body)
+}
+
+func TestHandleImportEvent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_指定した作成日時でイベントを追記する", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.adminUserIDs = parseAdminUserIDs(importAdminUserID)
+		s.importEnabled = true
+
+		w := importTestEvent(t, s, "media-legacy-1", "2020-01-02T03:04:05Z")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var resp eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		if resp.CreatedAt != "2020-01-02T03:04:05Z" {
+			t.Errorf("期待するcreated_at %q, 実際のcreated_at %q", "2020-01-02T03:04:05Z", resp.CreatedAt)
+		}
+		if resp.Version != 1 {
+			t.Errorf("期待するversion 1, 実際のversion %d", resp.Version)
+		}
+
+		// 永続化された作成日時も指定どおりであること
+		stored, err := s.queries.GetEventByID(t.Context(), resp.ID)
+		if err != nil {
+			t.Fatalf("イベントの取得に失敗: %v", err)
+		}
+		if want := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC); !stored.CreatedAt.Equal(want) {
+			t.Errorf("期待する永続化済みcreated_at %v, 実際 %v", want, stored.CreatedAt)
+		}
+
+		// 同じAggregateの最新イベント以降であれば続けて追記できる
+		w = importTestEvent(t, s, "media-legacy-1", "2020-01-02T03:04:05Z")
+		if w.Code != http.StatusCreated {
+			t.Errorf("同時刻: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		w = importTestEvent(t, s, "media-legacy-1", "2021-06-01T00:00:00+09:00")
+		if w.Code != http.StatusCreated {
+			t.Errorf("後の時刻: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("異常系_Aggregateの最新イベントより前の日時は409を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.adminUserIDs = parseAdminUserIDs(importAdminUserID)
+		s.importEnabled = true
+
+		if w := importTestEvent(t, s, "media-legacy-2", "2020-01-02T00:00:00Z"); w.Code != http.StatusCreated {
+			t.Fatalf("1件目のインポートに失敗: %d, body: %s", w.Code, w.Body.String())
+		}
+		w := importTestEvent(t, s, "media-legacy-2", "2020-01-01T00:00:00Z")
+		if w.Code != http.StatusConflict {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+
+		// 通常の追記で記録されたイベント（サーバー時刻）より前も拒否する
+		appendTestEvent(t, s, "media-live-1", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"})
+		w = importTestEvent(t, s, "media-live-1", "2020-01-01T00:00:00Z")
+		if w.Code != http.StatusConflict {
+			t.Errorf("通常追記後: 期待するステータスコード %d, 実際のステータスコード %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("異常系_不正な作成日時は400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.adminUserIDs = parseAdminUserIDs(importAdminUserID)
+		s.importEnabled = true
+
+		tests := []struct {
+			name      string
+			createdAt string
+		}{
+			{name: "未来の日時", createdAt: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)},
+			{name: "RFC3339形式でない", createdAt: "2020/01/02 03:04:05"},
+			{name: "未指定", createdAt: ""},
+		}
+		for _, tt := range tests {
+			w := importTestEvent(t, s, "media-invalid", tt.createdAt)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: 期待するステータスコード %d, 実際のステータスコード %d", tt.name, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+
+	t.Run("異常系_インポートが無効の場合は403を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.adminUserIDs = parseAdminUserIDs(importAdminUserID)
+
+		w := importTestEvent(t, s, "media-legacy-3", "2020-01-02T03:04:05Z")
+		if w.Code != http.StatusForbidden {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("異常系_インポートが有効でも認証なしは401を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.adminUserIDs = parseAdminUserIDs(importAdminUserID)
+		s.importEnabled = true
+
+		body, err := json.Marshal(map[string]any{
+			"aggregate_id":   "media-legacy-4",
+			"aggregate_type": "Media",
+			"event_type":     "MediaUploaded",
+			"data":           map[string]string{"user_id": "user-import", "filename": "legacy.jpg"},
+			"created_at":     "2020-01-02T03:04:05Z",
+		})
+		if err != nil {
+			t.Fatalf("リクエストボディのJSON変換に失敗: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/events/import", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("異常系_インポートが有効でも管理者以外は403を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-other")
+		s.importEnabled = true
+
+		w := importTestEvent(t, s, "media-legacy-5", "2020-01-02T03:04:05Z")
+		if w.Code != http.StatusForbidden {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusForbidden, w.Code)
+		}
+	})
+}
+
+// appendWithCreatedAtTestEvent はテスト用にcreated_atを指定したイベント追記を認証なしでPOSTするヘルパー関数。
+func appendWithCreatedAtTestEvent(t *testing.T, s *Server, aggregateID, createdAt string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{
+		"aggregate_id":   aggregateID,
+		"aggregate_type": "Media",
+		"event_type":     "MediaUploaded",
+		"data":           map[string]string{"user_id": "user-import", "filename": "legacy.jpg"},
+		"created_at":     createdAt,
+	})
+	if err != nil {
+		t.Fatalf("リクエストボディのJSON変換に失敗: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleAppendEventCreatedAt(t *testing.T) {
+	t.Parallel()
+
+	rejectTests := []struct {
+		name          string
+		importEnabled bool
+	}{
+		{name: "異常系_インポートが有効でもcreated_atを指定した認証なしの追記は400を返す", importEnabled: true},
+		{name: "異常系_インポートが無効の場合もcreated_atを指定した追記は400を返す", importEnabled: false},
+	}
+	for _, tt := range rejectTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := setupTestServer(t)
+			s.importEnabled = tt.importEnabled
+
+			w := appendWithCreatedAtTestEvent(t, s, "media-backdated", "2020-01-02T03:04:05Z")
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+			}
+			// 作成日時を偽装したイベントが記録されていないこと
+			if _, err := s.queries.GetLatestEventCreatedAt(t.Context(), "media-backdated"); err == nil {
+				t.Error("created_atを指定したイベントが記録されています")
+			}
+		})
+	}
+
+	t.Run("正常系_created_atを省略した場合はサーバー時刻を使う", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+
+		before := time.Now().UTC().Add(-time.Second)
+		w := appendTestEvent(t, s, "media-live", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"})
+		var resp eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		createdAt, err := time.Parse(time.RFC3339, resp.CreatedAt)
+		if err != nil || createdAt.Before(before.Truncate(time.Second)) {
+			t.Errorf("サーバー時刻が使われていない: created_at %q", resp.CreatedAt)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package eventstore
+
+import (
+	"encoding/json"
+
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// indexFields はペイロードから索引カラムへ抽出するJSONフィールド名の設定。
+// 空文字のフィールドは抽出しない。
+type indexFields struct {
+	// UserID はuser_idカラムに格納するペイロードのフィールド名。
+	UserID string
+	// Filename はfilenameカラムに格納するペイロードのフィールド名。
+	Filename string
+}
+
+// indexFieldsByType はイベントタイプごとの索引抽出設定。
+// ここに登録されていないイベントタイプは索引カラムが空文字のまま保存される。
+var indexFieldsByType = map[event.Type]indexFields{
+	event.TypeMediaUploaded:    {UserID: "user_id", Filename: "filename"},
+	event.TypeMediaDeleted:     {UserID: "user_id"},
+	event.TypeAlbumCreated:     {UserID: "user_id"},
+	event.TypeAlbumDeleted:     {UserID: "user_id"},
+	event.TypeNotificationSent: {UserID: "user_id"},
+}
+
+// eventIndex はイベント追記時にペイロードから導出した索引カラムの値。
+type eventIndex struct {
+	// UserID はuser_idカラムの値。
+	UserID string
+	// Filename はfilenameカラムの値。
+	Filename string
+}
+
+// extractIndex はイベントタイプの設定に従ってペイロードから索引カラムの値を抽出する。
+// 設定がない場合、ペイロードがJSONオブジェクトでない場合、値が文字列でない場合は空文字とする。
+func extractIndex(eventType event.Type, data json.RawMessage) eventIndex {
+	fields, ok := indexFieldsByType[eventType]
+	if !ok {
+		return eventIndex{}
+	}
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return eventIndex{}
+	}
+
+	return eventIndex{
+		UserID:   stringField(payload, fields.UserID),
+		Filename: stringField(payload, fields.Filename),
+	}
+}
+
+// stringField はペイロードから文字列フィールドを取り出す。
+func stringField(payload map[string]json.RawMessage, name string) string {
+	if name == "" {
+		return ""
+	}
+	raw, ok := payload[name]
+	if !ok {
+		return ""
+	}
+	var v string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return ""
+	}
+	return v
+}
@@ -0,0 +1,62 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// TestExtractIndex はイベントタイプごとの索引カラム抽出を検証する。
+func TestExtractIndex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		eventType event.Type
+		data      string
+		want      eventIndex
+	}{
+		{
+			name:      "MediaUploadedはuser_idとfilenameを抽出する",
+			eventType: event.TypeMediaUploaded,
+			data:      `{"user_id":"user-1","filename":"photo.jpg","size":100}`,
+			want:      eventIndex{UserID: "user-1", Filename: "photo.jpg"},
+		},
+		{
+			name:      "AlbumCreatedはuser_idのみ抽出する",
+			eventType: event.TypeAlbumCreated,
+			data:      `{"user_id":"user-2","name":"旅行","filename":"ignored"}`,
+			want:      eventIndex{UserID: "user-2"},
+		},
+		{
+			name:      "設定のないイベントタイプは抽出しない",
+			eventType: event.TypeMediaProcessed,
+			data:      `{"user_id":"user-3","thumbnail_path":"/t.jpg"}`,
+			want:      eventIndex{},
+		},
+		{
+			name:      "文字列でない値は空文字になる",
+			eventType: event.TypeMediaUploaded,
+			data:      `{"user_id":123,"filename":null}`,
+			want:      eventIndex{},
+		},
+		{
+			name:      "JSONオブジェクトでないペイロードは空文字になる",
+			eventType: event.TypeMediaUploaded,
+			data:      `["user-1"]`,
+			want:      eventIndex{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := extractIndex(tt.eventType, json.RawMessage(tt.data))
+			if got != tt.want {
+				t.Errorf("extractIndex() = %+v; 期待値 = %+v", got, tt.want)
+			}
+		})
+	}
+}
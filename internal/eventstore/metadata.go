@@ -0,0 +1,50 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// withMetadata はリクエストボディのmetadataで指定された追跡情報をctxに設定する。
+// 指定されていない項目は、middleware.CorrelationがX-Correlation-ID・X-Causation-IDヘッダーから設定した値を残す。
+func withMetadata(ctx context.Context, m *event.Metadata) context.Context {
+	if m == nil {
+		return ctx
+	}
+	ctx = httpclient.WithCorrelationID(ctx, m.CorrelationID)
+	return httpclient.WithCausationID(ctx, m.CausationID)
+}
+
+// metadataFrom はctxの追跡情報から追記するイベントのメタデータを作る。
+// Correlation IDがない場合（HTTPリクエストを経由しない追記など）は、新しい一連の処理の起点として生成する。
+func metadataFrom(ctx context.Context) event.Metadata {
+	m := event.Metadata{
+		CorrelationID: httpclient.CorrelationID(ctx),
+		CausationID:   httpclient.CausationID(ctx),
+	}
+	if m.CorrelationID == "" {
+		m.CorrelationID = uuid.New().String()
+	}
+	return m
+}
+
+// encodeMetadata はメタデータをmetadataカラムに格納するJSON文字列に変換する。
+// 文字列のフィールドのみのためシリアライズは失敗しない。
+func encodeMetadata(m event.Metadata) string {
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// decodeMetadata はmetadataカラムのJSON文字列をメタデータに変換する。
+// 不正な値の場合は追跡情報のないイベントとして扱い、空のメタデータを返す。
+func decodeMetadata(raw string) event.Metadata {
+	var m event.Metadata
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		return event.Metadata{}
+	}
+	return m
+}
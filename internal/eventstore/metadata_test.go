@@ -0,0 +1,98 @@
+package eventstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// TestAppendEventMetadata はイベントの追記時に記録する追跡情報（metadata）を検証する。
+func TestAppendEventMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		correlationID string
+		causationID   string
+		metadata      *event.Metadata
+		want          event.Metadata
+		wantGenerated bool
+	}{
+		{
+			name:          "X-Correlation-IDとX-Causation-IDヘッダーを記録すること",
+			correlationID: "correlation-header",
+			causationID:   "event-header",
+			want:          event.Metadata{CorrelationID: "correlation-header", CausationID: "event-header"},
+		},
+		{
+			name:          "リクエストボディのmetadataをヘッダーより優先すること",
+			correlationID: "correlation-header",
+			causationID:   "event-header",
+			metadata:      &event.Metadata{CorrelationID: "correlation-body"},
+			want:          event.Metadata{CorrelationID: "correlation-body", CausationID: "event-header"},
+		},
+		{
+			name:          "Correlation IDがない場合は新しく生成すること",
+			wantGenerated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := setupTestServer(t)
+			body, err := json.Marshal(appendEventRequest{
+				AggregateID:   "media-metadata",
+				AggregateType: string(event.AggregateTypeMedia),
+				EventType:     string(event.TypeMediaProcessed),
+				Data:          json.RawMessage(`{}`),
+				Metadata:      tt.metadata,
+			})
+			if err != nil {
+				t.Fatalf("リクエストボディのJSON変換に失敗: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.correlationID != "" {
+				req.Header.Set(httpclient.CorrelationIDHeader, tt.correlationID)
+			}
+			if tt.causationID != "" {
+				req.Header.Set(httpclient.CausationIDHeader, tt.causationID)
+			}
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+			}
+
+			var created eventResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+			if tt.wantGenerated {
+				if created.Metadata.CorrelationID == "" || created.Metadata.CausationID != "" {
+					t.Errorf("Metadata = %+v, want 生成したCorrelation IDのみ", created.Metadata)
+				}
+			} else if created.Metadata != tt.want {
+				t.Errorf("Metadata = %+v, want %+v", created.Metadata, tt.want)
+			}
+
+			// 取得APIでも同じ追跡情報を返す
+			w = httptest.NewRecorder()
+			s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/events/aggregate/media-metadata", nil))
+			var got []eventResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+			if len(got) != 1 || got[0].Metadata != created.Metadata {
+				t.Errorf("取得したイベント = %+v, want Metadata %+v", got, created.Metadata)
+			}
+		})
+	}
+}
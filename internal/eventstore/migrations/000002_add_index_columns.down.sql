@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_events_filename;
+DROP INDEX IF EXISTS idx_events_user_id;
+ALTER TABLE events DROP COLUMN filename;
+ALTER TABLE events DROP COLUMN user_id;
@@ -0,0 +1,18 @@
+ALTER TABLE events ADD COLUMN user_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN filename TEXT NOT NULL DEFAULT '';
+
+UPDATE events
+SET user_id = COALESCE(json_extract(data, '$.user_id'), '')
+WHERE event_type IN ('MediaUploaded', 'MediaDeleted', 'AlbumCreated', 'AlbumDeleted', 'NotificationSent')
+  AND json_valid(data);
+
+UPDATE events
+SET filename = COALESCE(json_extract(data, '$.filename'), '')
+WHERE event_type = 'MediaUploaded'
+  AND json_valid(data);
+
+CREATE INDEX IF NOT EXISTS idx_events_user_id
+    ON events(user_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_events_filename
+    ON events(filename, created_at);
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_events_aggregate_type_id
+    ON events(aggregate_type, aggregate_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_version_reservations_expires_at;
+DROP INDEX IF EXISTS idx_version_reservations_aggregate_version;
+DROP TABLE IF EXISTS version_reservations;
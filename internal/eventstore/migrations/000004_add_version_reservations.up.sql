@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS version_reservations (
+    id TEXT PRIMARY KEY,
+    aggregate_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_version_reservations_aggregate_version
+    ON version_reservations(aggregate_id, version);
+
+CREATE INDEX IF NOT EXISTS idx_version_reservations_expires_at
+    ON version_reservations(expires_at);
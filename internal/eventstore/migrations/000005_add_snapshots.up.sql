@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS snapshots (
+    aggregate_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    state TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (aggregate_id, version)
+);
@@ -0,0 +1,230 @@
+package eventstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	eventstoredb "github.com/nao1215/micro/internal/eventstore/db"
+	"github.com/nao1215/micro/pkg/event"
+)
+
+const (
+	// defaultReservationTTL はバージョン予約の既定の有効期間。
+	defaultReservationTTL = 30 * time.Second
+	// maxReservationTTL はバージョン予約に指定できる有効期間の上限。
+	// 予約中はAggregateへの即時追記を受け付けないため、長時間の予約を防ぐ。
+	maxReservationTTL = 5 * time.Minute
+)
+
+// reserveVersionRequest はバージョン予約リクエストのJSON構造。
+type reserveVersionRequest struct {
+	// TTLSeconds は予約の有効期間（秒）。省略した場合はdefaultReservationTTL。
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+// reservationResponse はバージョン予約のJSONレスポンス構造。
+type reservationResponse struct {
+	// ReservationID は予約ID。予約したバージョンで追記する際にreservation_idとして指定する。
+	ReservationID string `json:"reservation_id"`
+	// AggregateID は予約対象のAggregateの識別子。
+	AggregateID string `json:"aggregate_id"`
+	// Version は予約したバージョン。
+	Version int64 `json:"version"`
+	// ExpiresAt は予約の有効期限（RFC3339形式）。
+	ExpiresAt string `json:"expires_at"`
+}
+
+// handleReserveVersion はAggregateの次のバージョンを予約するハンドラを返す。
+// イベントの準備に時間がかかる場合でも発行順序を確保するため、先にバージョンを確保し、
+// 準備ができたらPOST /api/v1/eventsにreservation_idを指定して予約したバージョンで追記する。
+// 予約中はそのAggregateへの即時追記を409で拒否する。有効期限を過ぎた予約は無効となり、次の予約時に削除する。
+// 同じAggregateに予約が残っている場合は、その次のバージョンを予約する。
+func (s *Server) handleReserveVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req reserveVersionRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+				return
+			}
+		}
+		ttl := defaultReservationTTL
+		if req.TTLSeconds != 0 {
+			ttl = time.Duration(req.TTLSeconds) * time.Second
+		}
+		if ttl <= 0 || ttl > maxReservationTTL {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ttl_secondsは1以上%d以下で指定してください", int(maxReservationTTL.Seconds()))})
+			return
+		}
+
+		aggregateID := c.Param("aggregate_id")
+		ctx := c.Request.Context()
+		now := time.Now().UTC()
+
+		s.versionMu.Lock()
+		defer s.versionMu.Unlock()
+
+		if purged, err := s.queries.DeleteExpiredVersionReservations(ctx, now); err != nil {
+			log.Printf("期限切れのバージョン予約の削除エラー: %v", err)
+		} else if purged > 0 {
+			log.Printf("期限切れのバージョン予約を%d件削除しました", purged)
+		}
+
+		latest, err := latestVersion(ctx, s.queries, aggregateID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン取得に失敗しました"})
+			log.Printf("バージョン取得エラー: %v", err)
+			return
+		}
+		reserved, err := s.queries.GetMaxReservedVersion(ctx, eventstoredb.GetMaxReservedVersionParams{
+			AggregateID: aggregateID,
+			Now:         now,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン予約の確認に失敗しました"})
+			log.Printf("バージョン予約の確認エラー: %v", err)
+			return
+		}
+
+		reservation := eventstoredb.CreateVersionReservationParams{
+			ID:          uuid.New().String(),
+			AggregateID: aggregateID,
+			Version:     max(latest, reserved) + 1,
+			ExpiresAt:   now.Add(ttl),
+			CreatedAt:   now,
+		}
+		if err := s.queries.CreateVersionReservation(ctx, reservation); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "バージョンの予約に失敗しました（予約の競合の可能性）"})
+			log.Printf("バージョン予約エラー: %v", err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, reservationResponse{
+			ReservationID: reservation.ID,
+			AggregateID:   reservation.AggregateID,
+			Version:       reservation.Version,
+			ExpiresAt:     reservation.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+}
+
+// handleCancelReservation は未使用のバージョン予約を取り消すハンドラを返す。
+// イベントの準備を中止した場合に、有効期限を待たずにAggregateへの追記を再開できるようにする。
+func (s *Server) handleCancelReservation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		id := c.Param("reservation_id")
+
+		s.versionMu.Lock()
+		defer s.versionMu.Unlock()
+
+		if _, err := s.queries.GetVersionReservation(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "バージョン予約が見つかりません"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン予約の取得に失敗しました"})
+			log.Printf("バージョン予約の取得エラー: %v", err)
+			return
+		}
+		if err := s.queries.DeleteVersionReservation(ctx, id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン予約の取り消しに失敗しました"})
+			log.Printf("バージョン予約の取り消しエラー: %v", err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// appendReserved は予約したバージョンでイベントを追記し、予約を消費する。
+// 予約が見つからない場合は404、期限切れの場合は410、Aggregateが予約と異なる場合は400を返す。
+// 発行順序を崩さないよう、直前のバージョンまで追記済みでなければ（先行する予約が未使用であれば）409を返す。
+// 追記と予約の削除は同一トランザクションで行う。失敗した場合はエラーレスポンスを書き込み、falseを返す。
+func (s *Server) appendReserved(c *gin.Context, reservationID, aggregateID string, aggregateType event.AggregateType, eventType event.Type, data any) (*event.Event, bool) {
+	if !event.IsValidType(eventType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("未登録のイベントタイプです: %s", eventType)})
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+
+	reservation, err := s.queries.GetVersionReservation(ctx, reservationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "バージョン予約が見つかりません（使用済みまたは期限切れで削除済み）"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン予約の取得に失敗しました"})
+		log.Printf("バージョン予約の取得エラー: %v", err)
+		return nil, false
+	}
+	if reservation.AggregateID != aggregateID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("予約したAggregateと異なります: %s", reservation.AggregateID)})
+		return nil, false
+	}
+	if !reservation.ExpiresAt.After(time.Now().UTC()) {
+		if err := s.queries.DeleteVersionReservation(ctx, reservation.ID); err != nil {
+			log.Printf("期限切れのバージョン予約の削除エラー: %v", err)
+		}
+		c.JSON(http.StatusGone, gin.H{"error": "バージョン予約の有効期限が切れています。予約し直してください"})
+		return nil, false
+	}
+
+	latest, err := latestVersion(ctx, s.queries, aggregateID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン取得に失敗しました"})
+		log.Printf("バージョン取得エラー: %v", err)
+		return nil, false
+	}
+	if latest != reservation.Version-1 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("バージョン%dより前の予約が未使用のため追記できません（最新バージョン: %d）", reservation.Version, latest)})
+		return nil, false
+	}
+
+	ev, err := event.New(aggregateID, aggregateType, eventType, reservation.Version, data)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント生成に失敗しました"})
+		log.Printf("イベント生成エラー: %v", err)
+		return nil, false
+	}
+	ev.Metadata = metadataFrom(ctx)
+	if !s.checkAggregateLimit(c, ev) {
+		return nil, false
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "トランザクションの開始に失敗しました"})
+		log.Printf("トランザクション開始エラー: %v", err)
+		return nil, false
+	}
+	defer tx.Rollback()
+
+	q := s.queries.WithTx(tx)
+	if err := appendEvent(ctx, q, ev); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "イベントの追記に失敗しました（バージョン競合の可能性）"})
+		log.Printf("イベント追記エラー: %v", err)
+		return nil, false
+	}
+	if err := q.DeleteVersionReservation(ctx, reservation.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン予約の消費に失敗しました"})
+		log.Printf("バージョン予約の消費エラー: %v", err)
+		return nil, false
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの追記に失敗しました"})
+		log.Printf("トランザクションのコミットエラー: %v", err)
+		return nil, false
+	}
+	s.broadcaster.publish(ev)
+	s.warnAggregateLimit(ctx, ev)
+	return ev, true
+}
@@ -0,0 +1,208 @@
+package eventstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reserveTestVersion はテスト用にバージョンを予約するヘルパー関数。
+func reserveTestVersion(t *testing.T, s *Server, aggregateID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events/aggregate/"+aggregateID+"/reserve-version", strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// mustReserveVersion はバージョンを予約し、予約結果を返すヘルパー関数。
+func mustReserveVersion(t *testing.T, s *Server, aggregateID string) reservationResponse {
+	t.Helper()
+
+	w := reserveTestVersion(t, s, aggregateID, "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("予約のステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var resp reservationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+	}
+	return resp
+}
+
+// appendReservedTestEvent は予約IDを指定してイベントを追記するヘルパー関数。
+func appendReservedTestEvent(t *testing.T, s *Server, aggregateID, reservationID string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(appendEventRequest{
+		AggregateID:   aggregateID,
+		AggregateType: "Media",
+		EventType:     "MediaUploaded",
+		Data:          json.RawMessage(`{"user_id":"user-1"}`),
+		ReservationID: reservationID,
+	})
+	if err != nil {
+		t.Fatalf("リクエストボディのJSON変換に失敗: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// eventVersion は追記レスポンスのバージョンを返すヘルパー関数。
+func eventVersion(t *testing.T, w *httptest.ResponseRecorder) int64 {
+	t.Helper()
+
+	var resp eventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+	}
+	return resp.Version
+}
+
+// TestVersionReservation はバージョン予約と予約したバージョンでの追記を検証する。
+func TestVersionReservation(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]interface{}{"user_id": "user-1"}
+
+	t.Run("正常系_予約したバージョンで追記でき予約中の即時追記は拒否される", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		if w := appendTestEvent(t, s, "agg-1", "Media", "MediaUploaded", data); w.Code != http.StatusCreated {
+			t.Fatalf("イベントの追記に失敗: status = %d", w.Code)
+		}
+
+		reservation := mustReserveVersion(t, s, "agg-1")
+		if reservation.Version != 2 {
+			t.Errorf("予約したバージョン = %d; 期待値 = 2", reservation.Version)
+		}
+
+		if w := appendTestEvent(t, s, "agg-1", "Media", "MediaUploaded", data); w.Code != http.StatusConflict {
+			t.Errorf("予約中の即時追記のステータスコード = %d; 期待値 = %d", w.Code, http.StatusConflict)
+		}
+		// 別のAggregateへの即時追記は影響を受けない
+		if w := appendTestEvent(t, s, "agg-other", "Media", "MediaUploaded", data); w.Code != http.StatusCreated {
+			t.Errorf("別Aggregateへの即時追記のステータスコード = %d; 期待値 = %d", w.Code, http.StatusCreated)
+		}
+
+		w := appendReservedTestEvent(t, s, "agg-1", reservation.ReservationID)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("予約した追記のステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		if got := eventVersion(t, w); got != 2 {
+			t.Errorf("追記したバージョン = %d; 期待値 = 2", got)
+		}
+
+		// 予約は消費済みのため再利用できない
+		if w := appendReservedTestEvent(t, s, "agg-1", reservation.ReservationID); w.Code != http.StatusNotFound {
+			t.Errorf("消費済み予約のステータスコード = %d; 期待値 = %d", w.Code, http.StatusNotFound)
+		}
+		w = appendTestEvent(t, s, "agg-1", "Media", "MediaUploaded", data)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("予約消費後の即時追記のステータスコード = %d; 期待値 = %d", w.Code, http.StatusCreated)
+		}
+		if got := eventVersion(t, w); got != 3 {
+			t.Errorf("予約消費後のバージョン = %d; 期待値 = 3", got)
+		}
+	})
+
+	t.Run("正常系_複数の予約は予約した順にしか追記できない", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		first := mustReserveVersion(t, s, "agg-1")
+		second := mustReserveVersion(t, s, "agg-1")
+		if first.Version != 1 || second.Version != 2 {
+			t.Fatalf("予約したバージョン = %d, %d; 期待値 = 1, 2", first.Version, second.Version)
+		}
+
+		if w := appendReservedTestEvent(t, s, "agg-1", second.ReservationID); w.Code != http.StatusConflict {
+			t.Errorf("先行する予約が未使用の場合のステータスコード = %d; 期待値 = %d", w.Code, http.StatusConflict)
+		}
+		if w := appendReservedTestEvent(t, s, "agg-1", first.ReservationID); w.Code != http.StatusCreated {
+			t.Fatalf("1番目の予約の追記のステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		w := appendReservedTestEvent(t, s, "agg-1", second.ReservationID)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("2番目の予約の追記のステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		if got := eventVersion(t, w); got != 2 {
+			t.Errorf("追記したバージョン = %d; 期待値 = 2", got)
+		}
+	})
+
+	t.Run("異常系_期限切れの予約は410を返し即時追記が再開できる", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		reservation := mustReserveVersion(t, s, "agg-1")
+		if _, err := s.db.Exec("UPDATE version_reservations SET expires_at = ? WHERE id = ?", time.Now().UTC().Add(-time.Second), reservation.ReservationID); err != nil {
+			t.Fatalf("予約の有効期限の更新に失敗: %v", err)
+		}
+
+		w := appendTestEvent(t, s, "agg-1", "Media", "MediaUploaded", data)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("期限切れ後の即時追記のステータスコード = %d; 期待値 = %d", w.Code, http.StatusCreated)
+		}
+		if got := eventVersion(t, w); got != 1 {
+			t.Errorf("期限切れ後のバージョン = %d; 期待値 = 1", got)
+		}
+		if w := appendReservedTestEvent(t, s, "agg-1", reservation.ReservationID); w.Code != http.StatusGone {
+			t.Errorf("期限切れ予約のステータスコード = %d; 期待値 = %d", w.Code, http.StatusGone)
+		}
+	})
+
+	t.Run("正常系_取り消した予約は解放される", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		reservation := mustReserveVersion(t, s, "agg-1")
+
+		cancel := func() int {
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/events/reservations/"+reservation.ReservationID, nil)
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+			return w.Code
+		}
+		if got := cancel(); got != http.StatusNoContent {
+			t.Fatalf("取り消しのステータスコード = %d; 期待値 = %d", got, http.StatusNoContent)
+		}
+		if w := appendTestEvent(t, s, "agg-1", "Media", "MediaUploaded", data); w.Code != http.StatusCreated {
+			t.Errorf("取り消し後の即時追記のステータスコード = %d; 期待値 = %d", w.Code, http.StatusCreated)
+		}
+		if got := cancel(); got != http.StatusNotFound {
+			t.Errorf("取り消し済み予約のステータスコード = %d; 期待値 = %d", got, http.StatusNotFound)
+		}
+	})
+
+	t.Run("異常系_予約と異なるAggregateへの追記は400", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		reservation := mustReserveVersion(t, s, "agg-1")
+		if w := appendReservedTestEvent(t, s, "agg-2", reservation.ReservationID); w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("異常系_有効期間が上限を超える場合は400", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		if w := reserveTestVersion(t, s, "agg-1", `{"ttl_seconds":3600}`); w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusBadRequest)
+		}
+	})
+}
@@ -1,11 +1,16 @@
 package eventstore
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,6 +18,7 @@ import (
 	eventstoredb "github.com/nao1215/micro/internal/eventstore/db"
 	"github.com/nao1215/micro/pkg/event"
 	"github.com/nao1215/micro/pkg/middleware"
+	"github.com/nao1215/micro/pkg/query"
 )
 
 // Server はイベントストアサービスのHTTPサーバー。
@@ -25,12 +31,31 @@ type Server struct {
 	queries *eventstoredb.Queries
 	// db はSQLiteデータベース接続。
 	db *sql.DB
+	// importEnabled はデータ移行用のイベントインポートを受け付ける場合にtrueとなる。
+	importEnabled bool
+	// adminUserIDs は管理者として扱うユーザーIDの集合（環境変数ADMIN_USER_IDS）。
+	adminUserIDs map[string]struct{}
+	// backups はデータベースのバックアップジョブを管理する。
+	backups *backupManager
+	// aggregateLimit はAggregateあたりのイベント数の上限の設定。
+	aggregateLimit aggregateLimit
+	// validateData はイベントタイプごとのスキーマでdataを検証する場合にtrueとなる（環境変数EVENTSTORE_VALIDATE_DATA）。
+	validateData bool
+	// broadcaster は追記されたイベントをストリーミングの購読者に配信する。
+	broadcaster *broadcaster
+	// statsCache はイベント統計の集計結果のキャッシュ。
+	statsCache eventStatsCache
+	// versionMu はバージョンの採番・予約・予約したバージョンでの追記を直列化するミューテックス。
+	// 予約の確認と追記の間に別の予約や追記が割り込み、発行順序が崩れることを防ぐ。
+	versionMu sync.Mutex
 }
 
 // NewServer は新しいイベントストアサーバーを生成する。
 // SQLiteデータベースの初期化とスキーマ作成を行う。
 func NewServer(port string) (*Server, error) {
-	sqlDB, err := sql.Open("sqlite", "/data/eventstore.db?_journal_mode=WAL&_busy_timeout=5000")
+	// modernc.org/sqliteは_journal_mode等のパラメータを解釈しないため、_pragmaで指定する。
+	// WALモードでなければバックアップ（VACUUM INTO）中の書き込みがSQLITE_BUSYとなる。
+	sqlDB, err := sql.Open("sqlite", "/data/eventstore.db?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("データベース接続に失敗: %w", err)
 	}
@@ -39,15 +64,49 @@ func NewServer(port string) (*Server, error) {
 		return nil, fmt.Errorf("スキーマ初期化に失敗: %w", err)
 	}
 
+	importEnabled := false
+	if v := os.Getenv("EVENTSTORE_IMPORT_ENABLED"); v != "" {
+		importEnabled, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("EVENTSTORE_IMPORT_ENABLEDの設定が不正です: %w", err)
+		}
+	}
+	if importEnabled {
+		log.Println("警告: イベントのインポートが有効です。データ移行が完了したら無効にしてください")
+	}
+
+	validateData := true
+	if v := os.Getenv("EVENTSTORE_VALIDATE_DATA"); v != "" {
+		validateData, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("EVENTSTORE_VALIDATE_DATAの設定が不正です: %w", err)
+		}
+	}
+
+	aggLimit, err := parseAggregateLimit(os.Getenv("EVENTSTORE_AGGREGATE_EVENT_LIMIT"), os.Getenv("EVENTSTORE_AGGREGATE_EVENT_LIMIT_MODE"))
+	if err != nil {
+		return nil, err
+	}
+
+	backupDir := os.Getenv("EVENTSTORE_BACKUP_DIR")
+	if backupDir == "" {
+		backupDir = defaultBackupDir
+	}
+
 	router := gin.New()
-	router.Use(middleware.Recovery())
-	router.Use(gin.Logger())
+	router.Use(middleware.DefaultChain(middleware.ChainConfig{})...)
 
 	s := &Server{
-		router:  router,
-		port:    port,
-		queries: eventstoredb.New(sqlDB),
-		db:      sqlDB,
+		router:         router,
+		port:           port,
+		queries:        eventstoredb.New(sqlDB),
+		db:             sqlDB,
+		importEnabled:  importEnabled,
+		adminUserIDs:   parseAdminUserIDs(os.Getenv("ADMIN_USER_IDS")),
+		backups:        newBackupManager(sqlDB, backupDir),
+		aggregateLimit: aggLimit,
+		validateData:   validateData,
+		broadcaster:    newBroadcaster(),
 	}
 	s.setupRoutes()
 
@@ -61,29 +120,88 @@ func (s *Server) Run() error {
 
 // setupRoutes はAPIルーティングを設定する。
 func (s *Server) setupRoutes() {
+	// 管理者向けAPIの認証（JWT認証かつADMIN_USER_IDSに含まれるユーザーのみ）
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		jwtSecret = "dev-secret-key"
+	}
+	adminAuth := []gin.HandlerFunc{
+		middleware.JWTAuth(jwtSecret,
+			middleware.WithPreviousSecrets(middleware.SplitSecrets(os.Getenv("JWT_SECRET_PREVIOUS"))...),
+			middleware.WithIssuer(middleware.Issuer),
+			middleware.WithAudience(middleware.Audience),
+		),
+		s.requireAdmin(),
+	}
+
 	api := s.router.Group("/api/v1")
 	{
 		events := api.Group("/events")
 		{
 			// イベントの追記
 			events.POST("", s.handleAppendEvent())
-			// AggregateIDによるイベント取得
+			// データ移行用のイベントインポート（作成日時を指定して追記。管理者のみ、かつEVENTSTORE_IMPORT_ENABLED=trueの場合のみ）
+			events.POST("/import", append(adminAuth, s.handleImportEvent())...)
+			// 複数イベントの原子的な追記（単一トランザクション。1件でも失敗したら全件ロールバック）
+			events.POST("/batch", s.handleAppendEventBatch())
+			// イベントの撤回（EventRetractedメタイベントの追記）
+			events.POST("/:event_id/retract", s.handleRetractEvent())
+			// 次のバージョンの予約（予約したバージョンではPOST /api/v1/eventsにreservation_idを指定して追記する）
+			events.POST("/aggregate/:aggregate_id/reserve-version", s.handleReserveVersion())
+			// 未使用のバージョン予約の取り消し
+			events.DELETE("/reservations/:reservation_id", s.handleCancelReservation())
+			// AggregateIDによるイベント取得（クエリパラメータ: include_retracted=trueで撤回済みイベントも含める、limit・offset）
 			events.GET("/aggregate/:aggregate_id", s.handleGetEventsByAggregateID())
+			// AggregateIDの新しいイベントをServer-Sent Eventsで配信（接続後に追記されたイベントのみ）
+			events.GET("/aggregate/:aggregate_id/stream", s.handleSubscribeAggregate())
 			// イベントタイプによるイベント取得
 			events.GET("/type/:event_type", s.handleGetEventsByType())
-			// 日時指定によるイベント取得（クエリパラメータ: since）
+			// ユーザーIDによるイベント取得（索引カラムを使用）
+			events.GET("/user/:user_id", s.handleGetEventsByUserID())
+			// ファイル名によるイベント取得（索引カラムを使用）
+			events.GET("/filename/:filename", s.handleGetEventsByFilename())
+			// 日時指定によるイベント取得（クエリパラメータ: since、任意でtypes=カンマ区切りのイベントタイプ（typeまたはevent_typeも可）・limit・offset）
 			events.GET("/since", s.handleGetEventsSince())
+			// 新しいイベントをServer-Sent Eventsで配信（クエリパラメータ: 任意でsince=追記済みイベントの配信起点、types=カンマ区切りのイベントタイプ）
+			events.GET("/stream", s.handleSubscribeEvents())
 			// AggregateIDの最新バージョン取得
 			events.GET("/aggregate/:aggregate_id/version", s.handleGetLatestVersion())
-			// 全イベント取得（Read Model再構築用）
+			// 全イベント取得（Read Model再構築用。クエリパラメータ: limit・offset、envelope=trueで全件数と次ページのoffsetを付与）
 			events.GET("", s.handleGetAllEvents())
+			// イベント件数の取得（クエリパラメータ: aggregate_type, event_type）
+			events.GET("/count", s.handleCountEvents())
+			// イベントタイプ別・日別のイベント数の集計（管理者のみ。クエリパラメータ: group_by=day、days）
+			events.GET("/stats", append(adminAuth, s.handleEventStats())...)
+			// イベントタイプごとのdataのスキーマ（JSON Schema形式）の取得（クエリパラメータ: 任意でtype）
+			events.GET("/schema", s.handleGetEventSchemas())
 		}
+
+		// Aggregate種類ごとのID一覧取得（クエリパラメータ: type、任意でcursor・limit）
+		api.GET("/aggregates", s.handleListAggregateIDs())
+
+		snapshots := api.Group("/snapshots")
+		{
+			// Aggregateのスナップショットの保存（aggregate_id・version・state）
+			snapshots.POST("", s.handleSaveSnapshot())
+			// Aggregateの最新のスナップショットの取得（以降のイベントはversionより後を取得する）
+			snapshots.GET("/:aggregate_id", s.handleGetLatestSnapshot())
+		}
+	}
+
+	// 管理API（JWT認証かつADMIN_USER_IDSに含まれるユーザーのみ）
+	admin := s.router.Group("/api/v1/admin")
+	admin.Use(adminAuth...)
+	{
+		// オンラインバックアップの開始（VACUUM INTO。稼働中の読み書きは継続できる）
+		admin.POST("/backup", s.handleStartBackup())
+		// バックアップジョブの進捗・完了の確認
+		admin.GET("/backup/:id", s.handleGetBackup())
+		// Aggregateごとのバージョンの連続性（1..N、欠番・重複なし）の検査
+		admin.GET("/verify", s.handleVerify())
 	}
 
 	// ヘルスチェック
-	s.router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "eventstore"})
-	})
+	s.router.GET("/health", middleware.HealthCheck("eventstore", s.db))
 }
 
 // appendEventRequest はイベント追記リクエストのJSON構造。
@@ -92,21 +210,47 @@ type appendEventRequest struct {
 	AggregateType string          `json:"aggregate_type" binding:"required"`
 	EventType     string          `json:"event_type" binding:"required"`
 	Data          json.RawMessage `json:"data" binding:"required"`
+	// ReservationID は予約したバージョンで追記する場合に指定する予約ID。省略した場合は即時追記となる。
+	ReservationID string `json:"reservation_id"`
+	// ExpectedVersion は追記前のAggregateの最新バージョンとして期待する値。
+	// 指定した場合は最新バージョンが一致するときのみ追記し、一致しなければ409を返す。イベントがないAggregateは0。
+	// 省略した場合は競合を検出せず、最新バージョン+1で追記する。
+	ExpectedVersion *int64 `json:"expected_version"`
+	// CreatedAt は通常の追記では指定できない。作成日時を指定して再投入する場合は、
+	// 管理者のみ実行できるPOST /api/v1/events/importを使用する。誤用を検出して400を返すためにのみ受け取る。
+	CreatedAt string `json:"created_at"`
+	// Metadata は分散トレーシングのための追跡情報。省略した項目はX-Correlation-ID・X-Causation-IDヘッダーの値とし、
+	// Correlation IDがどちらにもない場合は新しく生成する。
+	Metadata *event.Metadata `json:"metadata"`
+}
+
+// versionConflictResponse はexpected_versionが最新バージョンと一致しない場合のJSONレスポンス構造。
+type versionConflictResponse struct {
+	// Error はエラーメッセージ。
+	Error string `json:"error"`
+	// ExpectedVersion はリクエストで指定された期待するバージョン。
+	ExpectedVersion int64 `json:"expected_version"`
+	// CurrentVersion はAggregateの現在の最新バージョン。
+	CurrentVersion int64 `json:"current_version"`
 }
 
 // eventResponse はイベントのJSONレスポンス構造。
 type eventResponse struct {
-	ID            string `json:"id"`
-	AggregateID   string `json:"aggregate_id"`
-	AggregateType string `json:"aggregate_type"`
-	EventType     string `json:"event_type"`
-	Data          string `json:"data"`
-	Version       int64  `json:"version"`
-	CreatedAt     string `json:"created_at"`
+	ID            string         `json:"id"`
+	AggregateID   string         `json:"aggregate_id"`
+	AggregateType string         `json:"aggregate_type"`
+	EventType     string         `json:"event_type"`
+	Data          string         `json:"data"`
+	Version       int64          `json:"version"`
+	CreatedAt     string         `json:"created_at"`
+	Metadata      event.Metadata `json:"metadata"`
 }
 
 // handleAppendEvent はイベントの追記を処理するハンドラを返す。
 // 楽観的排他制御: 現在の最新バージョン+1を新しいバージョンとして設定する。
+// expected_versionを指定した場合は、最新バージョンが一致しなければ409を返して並行書き込みの競合を検出する。
+// reservation_idを指定した場合は、予約したバージョンで追記する（appendReserved）。
+// 作成日時は常にサーバー時刻とし、created_atを指定した場合はEVENTSTORE_IMPORT_ENABLEDの設定に関わらず400を返す。
 func (s *Server) handleAppendEvent() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req appendEventRequest
@@ -114,72 +258,240 @@ func (s *Server) handleAppendEvent() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
 			return
 		}
+		if req.ExpectedVersion != nil {
+			if req.ReservationID != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "reservation_idとexpected_versionは同時に指定できません"})
+				return
+			}
+			if *req.ExpectedVersion < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "expected_versionは0以上で指定してください"})
+				return
+			}
+		}
+		if err := s.validateEventData(event.Type(req.EventType), req.Data); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.CreatedAt != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "created_atは通常の追記では指定できません。作成日時を指定する場合はPOST /api/v1/events/importを使用してください"})
+			return
+		}
+
+		c.Request = c.Request.WithContext(withMetadata(c.Request.Context(), req.Metadata))
+
+		var ev *event.Event
+		var ok bool
+		if req.ReservationID != "" {
+			ev, ok = s.appendReserved(c, req.ReservationID, req.AggregateID, event.AggregateType(req.AggregateType), event.Type(req.EventType), req.Data)
+		} else {
+			ev, ok = s.appendNextVersionAt(c, req.AggregateID, event.AggregateType(req.AggregateType), event.Type(req.EventType), req.Data, time.Time{}, req.ExpectedVersion)
+		}
+		if !ok {
+			return
+		}
 
-		// 楽観的排他制御: 最新バージョンを取得して+1する
-		latestVersionRaw, err := s.queries.GetLatestVersion(c.Request.Context(), req.AggregateID)
+		c.JSON(http.StatusCreated, toEventResponse(ev.ID, ev.AggregateID, string(ev.AggregateType), string(ev.EventType), string(ev.Data), ev.Version, ev.CreatedAt, ev.Metadata))
+	}
+}
+
+// validateEventData はvalidateDataが有効な場合に、dataがイベントタイプのスキーマを満たすかを検証する。
+// スキーマを満たさないイベントを保存すると、後からProjectorが処理に失敗するため追記前に拒否する。
+func (s *Server) validateEventData(eventType event.Type, data json.RawMessage) error {
+	if !s.validateData {
+		return nil
+	}
+	return event.ValidateData(eventType, data)
+}
+
+// appendNextVersion は最新バージョン+1のイベントを生成してEvent Storeに追記する。
+// 失敗した場合はエラーレスポンスを書き込み、falseを返す。
+func (s *Server) appendNextVersion(c *gin.Context, aggregateID string, aggregateType event.AggregateType, eventType event.Type, data any) (*event.Event, bool) {
+	return s.appendNextVersionAt(c, aggregateID, aggregateType, eventType, data, time.Time{}, nil)
+}
+
+// appendNextVersionAt は作成日時を指定してappendNextVersionと同様に追記する。
+// createdAtがゼロ値の場合はサーバー時刻を作成日時とする。
+// expectedVersionを指定した場合は、最新バージョンが一致しなければ409を返す。nilの場合は競合を検出しない。
+// レジストリに登録されていないイベントタイプはタイプミスとみなして400を返す。
+// 発行順序を保証するため、有効なバージョン予約があるAggregateへの追記は409で拒否する。
+func (s *Server) appendNextVersionAt(c *gin.Context, aggregateID string, aggregateType event.AggregateType, eventType event.Type, data any, createdAt time.Time, expectedVersion *int64) (*event.Event, bool) {
+	if !event.IsValidType(eventType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("未登録のイベントタイプです: %s", eventType)})
+		return nil, false
+	}
+
+	// 予約の確認からバージョンの採番・追記までの間に予約が割り込まないよう直列化する
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+
+	reserved, err := s.queries.CountActiveVersionReservations(c.Request.Context(), eventstoredb.CountActiveVersionReservationsParams{
+		AggregateID: aggregateID,
+		Now:         time.Now().UTC(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン予約の確認に失敗しました"})
+		log.Printf("バージョン予約の確認エラー: %v", err)
+		return nil, false
+	}
+	if reserved > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "予約中のバージョンがあるため追記できません。予約の使用または期限切れを待ってください"})
+		return nil, false
+	}
+
+	// 楽観的排他制御: 最新バージョンを取得して+1する
+	latest, err := latestVersion(c.Request.Context(), s.queries, aggregateID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン取得に失敗しました"})
+		log.Printf("バージョン取得エラー: %v", err)
+		return nil, false
+	}
+	if expectedVersion != nil && *expectedVersion != latest {
+		c.JSON(http.StatusConflict, versionConflictResponse{
+			Error:           fmt.Sprintf("Aggregateのバージョンが期待と一致しません（期待: %d, 最新: %d）。最新の状態を取得し直してください", *expectedVersion, latest),
+			ExpectedVersion: *expectedVersion,
+			CurrentVersion:  latest,
+		})
+		return nil, false
+	}
+
+	// イベントを生成
+	ev, err := event.New(aggregateID, aggregateType, eventType, latest+1, data)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント生成に失敗しました"})
+		log.Printf("イベント生成エラー: %v", err)
+		return nil, false
+	}
+	if !createdAt.IsZero() {
+		ev.CreatedAt = createdAt
+	}
+	ev.Metadata = metadataFrom(c.Request.Context())
+	if !s.checkAggregateLimit(c, ev) {
+		return nil, false
+	}
+
+	if err := appendEvent(c.Request.Context(), s.queries, ev); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "イベントの追記に失敗しました（バージョン競合の可能性）"})
+		log.Printf("イベント追記エラー: %v", err)
+		return nil, false
+	}
+	s.broadcaster.publish(ev)
+	s.warnAggregateLimit(c.Request.Context(), ev)
+	return ev, true
+}
+
+// appendEvent はイベントをEvent Storeに追記する（append-only）。
+// 検索用の索引カラムはペイロードから導出する。
+func appendEvent(ctx context.Context, q *eventstoredb.Queries, ev *event.Event) error {
+	index := extractIndex(ev.EventType, ev.Data)
+	return q.AppendEvent(ctx, eventstoredb.AppendEventParams{
+		ID:            ev.ID,
+		AggregateID:   ev.AggregateID,
+		AggregateType: string(ev.AggregateType),
+		EventType:     string(ev.EventType),
+		Data:          string(ev.Data),
+		Version:       ev.Version,
+		CreatedAt:     ev.CreatedAt,
+		UserID:        index.UserID,
+		Filename:      index.Filename,
+		Metadata:      encodeMetadata(ev.Metadata),
+	})
+}
+
+// latestVersion はAggregateIDの最新バージョンを返す。イベントがない場合は0を返す。
+func latestVersion(ctx context.Context, q *eventstoredb.Queries, aggregateID string) (int64, error) {
+	latestVersionRaw, err := q.GetLatestVersion(ctx, aggregateID)
+	if err != nil {
+		return 0, err
+	}
+
+	switch v := latestVersionRaw.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, nil
+	}
+}
+
+// retractEventRequest はイベント撤回リクエストのJSON構造。
+type retractEventRequest struct {
+	// Reason は撤回の理由。監査のためEventRetractedイベントに記録する。
+	Reason string `json:"reason" binding:"required"`
+}
+
+// handleRetractEvent はイベントの撤回を処理するハンドラを返す。
+// 元のイベントは削除せず、元のイベントと同じAggregateにEventRetractedメタイベントを追記する。
+// 撤回済みのイベントやEventRetractedイベント自体は撤回できない。
+func (s *Server) handleRetractEvent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req retractEventRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+			return
+		}
+
+		eventID := c.Param("event_id")
+		target, err := s.queries.GetEventByID(c.Request.Context(), eventID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン取得に失敗しました"})
-			log.Printf("バージョン取得エラー: %v", err)
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "撤回対象のイベントが見つかりません"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント取得に失敗しました"})
+			log.Printf("イベント取得エラー: %v", err)
+			return
+		}
+		if event.Type(target.EventType) == event.TypeEventRetracted {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "撤回イベントは撤回できません"})
 			return
 		}
 
-		var latestVersion int64
-		switch v := latestVersionRaw.(type) {
-		case int64:
-			latestVersion = v
-		case float64:
-			latestVersion = int64(v)
-		default:
-			latestVersion = 0
-		}
-		newVersion := latestVersion + 1
-
-		// イベントを生成
-		ev, err := event.New(
-			req.AggregateID,
-			event.AggregateType(req.AggregateType),
-			event.Type(req.EventType),
-			newVersion,
-			req.Data,
-		)
+		count, err := s.queries.CountEventRetractions(c.Request.Context(), eventstoredb.CountEventRetractionsParams{
+			AggregateID:      target.AggregateID,
+			RetractedEventID: target.ID,
+		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント生成に失敗しました"})
-			log.Printf("イベント生成エラー: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "撤回状況の取得に失敗しました"})
+			log.Printf("撤回状況の取得エラー: %v", err)
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "このイベントは既に撤回されています"})
 			return
 		}
 
-		// Event Storeに追記（append-only）
-		if err := s.queries.AppendEvent(c.Request.Context(), eventstoredb.AppendEventParams{
-			ID:            ev.ID,
-			AggregateID:   ev.AggregateID,
-			AggregateType: string(ev.AggregateType),
-			EventType:     string(ev.EventType),
-			Data:          string(ev.Data),
-			Version:       ev.Version,
-			CreatedAt:     ev.CreatedAt,
-		}); err != nil {
-			c.JSON(http.StatusConflict, gin.H{"error": "イベントの追記に失敗しました（バージョン競合の可能性）"})
-			log.Printf("イベント追記エラー: %v", err)
+		ev, ok := s.appendNextVersion(c, target.AggregateID, event.AggregateType(target.AggregateType), event.TypeEventRetracted, event.EventRetractedData{
+			RetractedEventID: target.ID,
+			Reason:           req.Reason,
+		})
+		if !ok {
 			return
 		}
 
-		c.JSON(http.StatusCreated, toEventResponse(ev.ID, ev.AggregateID, string(ev.AggregateType), string(ev.EventType), string(ev.Data), ev.Version, ev.CreatedAt))
+		c.JSON(http.StatusCreated, toEventResponse(ev.ID, ev.AggregateID, string(ev.AggregateType), string(ev.EventType), string(ev.Data), ev.Version, ev.CreatedAt, ev.Metadata))
 	}
 }
 
 // handleGetEventsByAggregateID はAggregateIDによるイベント取得を処理するハンドラを返す。
+// 既定では撤回されたイベントとEventRetractedイベントを除外する。
+// include_retracted=trueを指定すると、監査用に撤回を含む全イベントを返す。
+// limit/offsetでページングし（既定100件、最大1000件）、全件数をX-Total-Countヘッダーで返す。
 func (s *Server) handleGetEventsByAggregateID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		aggregateID := c.Param("aggregate_id")
 
-		rows, err := s.queries.GetEventsByAggregateID(c.Request.Context(), aggregateID)
+		includeRetracted, err := strconv.ParseBool(c.DefaultQuery("include_retracted", "false"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント取得に失敗しました"})
-			log.Printf("イベント取得エラー: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "include_retracted はtrueまたはfalseで指定してください"})
 			return
 		}
-
-		c.JSON(http.StatusOK, toEventResponses(rows))
+		if includeRetracted {
+			s.streamEventsPage(c, eventsPageSpec, streamEventsByAggregateIDQuery, aggregateID)
+			return
+		}
+		s.streamEventsPage(c, eventsPageSpec, streamEventsByAggregateIDExcludingRetractedQuery, aggregateID, aggregateID)
 	}
 }
 
@@ -188,20 +500,40 @@ func (s *Server) handleGetEventsByType() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		eventType := c.Param("event_type")
 
-		rows, err := s.queries.GetEventsByType(c.Request.Context(), eventType)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント取得に失敗しました"})
-			log.Printf("イベント取得エラー: %v", err)
-			return
-		}
+		s.streamEvents(c, streamEventsByTypeQuery, eventType)
+	}
+}
+
+// handleGetEventsByUserID はユーザーIDによるイベント取得を処理するハンドラを返す。
+func (s *Server) handleGetEventsByUserID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("user_id")
 
-		c.JSON(http.StatusOK, toEventResponses(rows))
+		s.streamEvents(c, streamEventsByUserIDQuery, userID)
+	}
+}
+
+// handleGetEventsByFilename はファイル名によるイベント取得を処理するハンドラを返す。
+func (s *Server) handleGetEventsByFilename() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filename := c.Param("filename")
+
+		s.streamEvents(c, streamEventsByFilenameQuery, filename)
 	}
 }
 
 // handleGetEventsSince は日時指定によるイベント取得を処理するハンドラを返す。
+// typesクエリパラメータ（typeまたはevent_typeも可）にカンマ区切りでイベントタイプを指定すると、いずれかに一致するイベントのみを返す。
+// 省略した場合は全タイプのイベントを返す。sinceは必須で、形式が不正な場合は400を返す。
+// limit（最大1000件）を指定すると作成日時・バージョンの昇順で最大件数まで返し、offsetで続きを取得できる。
+// 長時間停止していたProjectorやSagaが再開時に一度に大量のイベントを取得しないようにするために使用する。
 func (s *Server) handleGetEventsSince() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		page, ok := query.ParseRequest(c, eventsSinceQuerySpec)
+		if !ok {
+			return
+		}
+
 		sinceStr := c.Query("since")
 		if sinceStr == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "sinceクエリパラメータが必要です"})
@@ -214,15 +546,40 @@ func (s *Server) handleGetEventsSince() gin.HandlerFunc {
 			return
 		}
 
-		rows, err := s.queries.GetEventsSince(c.Request.Context(), since)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント取得に失敗しました"})
-			log.Printf("イベント取得エラー: %v", err)
+		// types・type・event_typeを併用した場合は、いずれかに一致するイベントを返す
+		eventTypes := parseEventTypes(strings.Join([]string{c.Query("types"), c.Query("type"), c.Query("event_type")}, ","))
+		if len(eventTypes) == 0 {
+			listQuery, args := withLimitOffset(streamEventsSinceQuery, []any{since}, page)
+			s.streamEvents(c, listQuery, args...)
 			return
 		}
 
-		c.JSON(http.StatusOK, toEventResponses(rows))
+		args := make([]any, 0, len(eventTypes)+3)
+		args = append(args, since)
+		for _, eventType := range eventTypes {
+			args = append(args, eventType)
+		}
+		listQuery, args := withLimitOffset(streamEventsSinceByTypesQuery(len(eventTypes)), args, page)
+		s.streamEvents(c, listQuery, args...)
+	}
+}
+
+// parseEventTypes はカンマ区切りのイベントタイプを分割する。空要素と重複は除く。
+func parseEventTypes(raw string) []string {
+	var eventTypes []string
+	seen := make(map[string]struct{})
+	for _, eventType := range strings.Split(raw, ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "" {
+			continue
+		}
+		if _, ok := seen[eventType]; ok {
+			continue
+		}
+		seen[eventType] = struct{}{}
+		eventTypes = append(eventTypes, eventType)
 	}
+	return eventTypes
 }
 
 // handleGetLatestVersion はAggregateIDの最新バージョン取得を処理するハンドラを返す。
@@ -230,21 +587,13 @@ func (s *Server) handleGetLatestVersion() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		aggregateID := c.Param("aggregate_id")
 
-		latestVersionRaw, err := s.queries.GetLatestVersion(c.Request.Context(), aggregateID)
+		version, err := latestVersion(c.Request.Context(), s.queries, aggregateID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン取得に失敗しました"})
 			log.Printf("バージョン取得エラー: %v", err)
 			return
 		}
 
-		var version int64
-		switch v := latestVersionRaw.(type) {
-		case int64:
-			version = v
-		case float64:
-			version = int64(v)
-		}
-
 		c.JSON(http.StatusOK, gin.H{
 			"aggregate_id":   aggregateID,
 			"latest_version": version,
@@ -252,22 +601,154 @@ func (s *Server) handleGetLatestVersion() gin.HandlerFunc {
 	}
 }
 
+// allEventsPageSpec は全イベント取得が受け付けるクエリパラメータ（limit、offset）。
+// 最大件数を超えるlimitはエラーにせず最大件数に丸める。
+var allEventsPageSpec = query.Spec{
+	DefaultLimit: defaultEventsPageLimit,
+	MaxLimit:     maxEventsPageLimit,
+	Offset:       true,
+	ClampLimit:   true,
+}
+
 // handleGetAllEvents は全イベント取得を処理するハンドラを返す。
+// 件数が多くなるため、limit/offsetでページングし（既定100件、最大1000件に丸める）、
+// DBカーソルから1件ずつJSON配列としてストリーミングで返す。全件数はX-Total-Countヘッダーで返す。
+// エンベロープ形式を要求された場合（middleware.WantsEnvelope）は、全件数と次ページのoffsetをメタ情報として付与する。
 func (s *Server) handleGetAllEvents() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		rows, err := s.queries.GetAllEvents(c.Request.Context())
+		c.Header("Vary", "Accept")
+		if middleware.WantsEnvelope(c) {
+			s.streamEventsPageEnvelope(c, allEventsPageSpec, streamAllEventsQuery)
+			return
+		}
+		s.streamEventsPage(c, allEventsPageSpec, streamAllEventsQuery)
+	}
+}
+
+// eventCountResponse はイベント件数のJSONレスポンス構造。
+type eventCountResponse struct {
+	// Count は条件に一致するイベントの件数。
+	Count int64 `json:"count"`
+}
+
+// eventSchemasResponse はイベントのスキーマ取得APIのレスポンス。
+type eventSchemasResponse struct {
+	// Schemas はイベントタイプごとのdataのスキーマ。
+	Schemas map[string]event.JSONSchema `json:"schemas"`
+}
+
+// handleGetEventSchemas はイベントタイプごとのdataのスキーマ（JSON Schema形式）の取得を処理するハンドラを返す。
+// クエリパラメータtypeを指定した場合はそのイベントタイプのスキーマのみを返し、未登録のイベントタイプは400を返す。
+// スキーマは追記時のdataの検証に使うものと同じため、公開した定義と実際に受け付けるデータ構造は一致する。
+func (s *Server) handleGetEventSchemas() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		types := event.AllTypes()
+		if t := c.Query("type"); t != "" {
+			if !event.IsValidType(event.Type(t)) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("未登録のイベントタイプです: %s", t)})
+				return
+			}
+			types = []event.Type{event.Type(t)}
+		}
+
+		schemas := make(map[string]event.JSONSchema, len(types))
+		for _, t := range types {
+			if schema, ok := event.JSONSchemaFor(t); ok {
+				schemas[string(t)] = schema
+			}
+		}
+		c.JSON(http.StatusOK, eventSchemasResponse{Schemas: schemas})
+	}
+}
+
+// handleCountEvents はイベント件数の取得を処理するハンドラを返す。
+// クエリパラメータaggregate_type・event_typeで絞り込み、両方を指定した場合は両方に一致するイベントを数える。
+// 未指定の場合は全イベントの件数を返す。イベントを取得せずに件数だけを返すため、監視ダッシュボードでの定期的な取得に向く。
+func (s *Server) handleCountEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count, err := s.queries.CountEventsByFilter(c.Request.Context(), eventstoredb.CountEventsByFilterParams{
+			AggregateType: c.Query("aggregate_type"),
+			EventType:     c.Query("event_type"),
+		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント取得に失敗しました"})
-			log.Printf("イベント取得エラー: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント件数の取得に失敗しました"})
+			log.Printf("イベント件数の取得エラー: %v", err)
 			return
 		}
+		c.JSON(http.StatusOK, eventCountResponse{Count: count})
+	}
+}
 
-		c.JSON(http.StatusOK, toEventResponses(rows))
+const (
+	// defaultAggregateIDsLimit はAggregate ID一覧の1ページあたりの既定件数。
+	defaultAggregateIDsLimit = 100
+	// maxAggregateIDsLimit はAggregate ID一覧の1ページあたりの最大件数。
+	maxAggregateIDsLimit = 1000
+)
+
+// aggregateIDsQuerySpec はAggregate ID一覧が受け付けるページングのクエリパラメータ。
+var aggregateIDsQuerySpec = query.Spec{
+	DefaultLimit: defaultAggregateIDsLimit,
+	MaxLimit:     maxAggregateIDsLimit,
+	Cursor:       true,
+}
+
+// aggregateIDsResponse はAggregate ID一覧のJSONレスポンス構造。
+type aggregateIDsResponse struct {
+	// AggregateType は一覧対象のAggregateの種類。
+	AggregateType string `json:"aggregate_type"`
+	// AggregateIDs はAggregate IDの昇順に並べた重複のないID一覧。
+	AggregateIDs []string `json:"aggregate_ids"`
+	// NextCursor は次のページを取得するためのcursor。最終ページの場合は空文字。
+	NextCursor string `json:"next_cursor"`
+}
+
+// handleListAggregateIDs は指定した種類のAggregate IDを重複なく返すハンドラを返す。
+// Read Modelを一から構築する際にAggregateを列挙するために使用する。
+// IDの昇順で並べ、cursorに前ページのnext_cursorを指定するとそのIDより後から返す。
+func (s *Server) handleListAggregateIDs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		aggregateType := c.Query("type")
+		if aggregateType == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "typeクエリパラメータが必要です"})
+			return
+		}
+
+		page, ok := query.ParseRequest(c, aggregateIDsQuerySpec)
+		if !ok {
+			return
+		}
+		limit := page.Limit
+
+		// 次のページの有無を判定するため1件多く取得する
+		ids, err := s.queries.ListAggregateIDsByType(c.Request.Context(), eventstoredb.ListAggregateIDsByTypeParams{
+			AggregateType: aggregateType,
+			Cursor:        page.Cursor,
+			Limit:         int64(limit + 1),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Aggregate ID一覧の取得に失敗しました"})
+			log.Printf("Aggregate ID一覧の取得エラー: %v", err)
+			return
+		}
+
+		resp := aggregateIDsResponse{
+			AggregateType: aggregateType,
+			AggregateIDs:  ids,
+		}
+		if len(ids) > limit {
+			resp.AggregateIDs = ids[:limit]
+			resp.NextCursor = ids[limit-1]
+		}
+		if resp.AggregateIDs == nil {
+			resp.AggregateIDs = []string{}
+		}
+		c.JSON(http.StatusOK, resp)
 	}
 }
 
 // toEventResponse はDB行をJSONレスポンスに変換する。
-func toEventResponse(id, aggregateID, aggregateType, eventType, data string, version int64, createdAt time.Time) eventResponse {
+func toEventResponse(id, aggregateID, aggregateType, eventType, data string, version int64, createdAt time.Time, metadata event.Metadata) eventResponse {
 	return eventResponse{
 		ID:            id,
 		AggregateID:   aggregateID,
@@ -276,6 +757,7 @@ func toEventResponse(id, aggregateID, aggregateType, eventType, data string, ver
 		Data:          data,
 		Version:       version,
 		CreatedAt:     createdAt.Format(time.RFC3339),
+		Metadata:      metadata,
 	}
 }
 
@@ -285,7 +767,7 @@ func toEventResponses(rows []eventstoredb.Event) []eventResponse {
 	for _, row := range rows {
 		responses = append(responses, toEventResponse(
 			row.ID, row.AggregateID, row.AggregateType,
-			row.EventType, row.Data, row.Version, row.CreatedAt,
+			row.EventType, row.Data, row.Version, row.CreatedAt, decodeMetadata(row.Metadata),
 		))
 	}
 	return responses
@@ -2,17 +2,24 @@ package eventstore
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "modernc.org/sqlite"
 	eventstoredb "github.com/nao1215/micro/internal/eventstore/db"
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/middleware"
 )
 
 // setupTestServer はテスト用のサーバーをインメモリSQLiteで構築するヘルパー関数。
@@ -36,12 +43,14 @@ func setupTestServer(t *testing.T) *Server {
 	})
 
 	router := gin.New()
+	router.Use(middleware.Correlation())
 
 	s := &Server{
-		router:  router,
-		port:    "0",
-		queries: eventstoredb.New(sqlDB),
-		db:      sqlDB,
+		router:      router,
+		port:        "0",
+		queries:     eventstoredb.New(sqlDB),
+		db:          sqlDB,
+		broadcaster: newBroadcaster(),
 	}
 	s.setupRoutes()
 
@@ -290,6 +299,20 @@ func TestHandleAppendEvent(t *testing.T) {
 		}
 	})
 
+	t.Run("未登録のイベントタイプの場合は400エラーを返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+
+		w := appendTestEvent(t, s, "agg-1", "Media", "MediaUplaoded", map[string]interface{}{
+			"user_id": "user-1",
+		})
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
 	t.Run("バージョンの自動インクリメントが正しく動作する", func(t *testing.T) {
 		t.Parallel()
 
@@ -351,6 +374,99 @@ func TestHandleAppendEvent(t *testing.T) {
 	})
 }
 
+// TestHandleAppendEventExpectedVersion はexpected_versionによる楽観的並行性制御を検証する。
+func TestHandleAppendEventExpectedVersion(t *testing.T) {
+	t.Parallel()
+
+	// appendWithBody はJSON文字列をそのままボディとしてイベントをPOSTする。
+	appendWithBody := func(t *testing.T, s *Server, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+	// expectedBody はexpected_versionを指定したMediaUploadedイベントのリクエストボディを返す。
+	expectedBody := func(aggregateID string, expected int64) string {
+		return fmt.Sprintf(`{"aggregate_id":%q,"aggregate_type":"Media","event_type":"MediaUploaded","data":{"user_id":"user-1"},"expected_version":%d}`, aggregateID, expected)
+	}
+
+	t.Run("正常系_expected_versionが最新バージョンと一致する場合は追記できる", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		w := appendWithBody(t, s, expectedBody("agg-ev", 0))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("新規Aggregate: ステータスコード = %d; 期待値 = %d, body=%s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		w = appendWithBody(t, s, expectedBody("agg-ev", 1))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("2件目: ステータスコード = %d; 期待値 = %d, body=%s", w.Code, http.StatusCreated, w.Body.String())
+		}
+
+		var resp eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+		}
+		if resp.Version != 2 {
+			t.Errorf("version = %d; 期待値 = 2", resp.Version)
+		}
+	})
+
+	t.Run("異常系_expected_versionが最新バージョンと一致しない場合は409と現在のバージョンを返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		for range 2 {
+			if w := appendTestEvent(t, s, "agg-conflict", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"}); w.Code != http.StatusCreated {
+				t.Fatalf("事前のイベント追記に失敗: %d", w.Code)
+			}
+		}
+
+		// 同じバージョンを読んだ2つの書き込みのうち、後の書き込みは競合として拒否される
+		if w := appendWithBody(t, s, expectedBody("agg-conflict", 2)); w.Code != http.StatusCreated {
+			t.Fatalf("先の書き込み: ステータスコード = %d; 期待値 = %d", w.Code, http.StatusCreated)
+		}
+		w := appendWithBody(t, s, expectedBody("agg-conflict", 2))
+		if w.Code != http.StatusConflict {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusConflict)
+		}
+
+		var resp versionConflictResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+		}
+		if resp.ExpectedVersion != 2 || resp.CurrentVersion != 3 || resp.Error == "" {
+			t.Errorf("レスポンス = %+v; 期待値 = expected_version 2, current_version 3, エラーメッセージあり", resp)
+		}
+
+		// 競合したイベントは追記されていない
+		latest, err := latestVersion(t.Context(), s.queries, "agg-conflict")
+		if err != nil {
+			t.Fatalf("最新バージョンの取得に失敗: %v", err)
+		}
+		if latest != 3 {
+			t.Errorf("最新バージョン = %d; 期待値 = 3", latest)
+		}
+	})
+
+	t.Run("異常系_不正なexpected_versionは400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		bodies := map[string]string{
+			"負の値":                  expectedBody("agg-invalid", -1),
+			"reservation_idとの同時指定": `{"aggregate_id":"agg-invalid","aggregate_type":"Media","event_type":"MediaUploaded","data":{},"expected_version":0,"reservation_id":"r-1"}`,
+		}
+		for name, body := range bodies {
+			if w := appendWithBody(t, s, body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: ステータスコード = %d; 期待値 = %d", name, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
+
 // TestHandleGetEventsByAggregateID はAggregateIDによるイベント取得ハンドラを検証する。
 func TestHandleGetEventsByAggregateID(t *testing.T) {
 	t.Parallel()
@@ -419,6 +535,127 @@ func TestHandleGetEventsByAggregateID(t *testing.T) {
 }
 
 // TestHandleGetEventsByType はイベントタイプによるイベント取得ハンドラを検証する。
+// retractTestEvent はテスト用にイベント撤回をPOSTするヘルパー関数。
+func retractTestEvent(t *testing.T, s *Server, eventID, reason string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(retractEventRequest{Reason: reason})
+	if err != nil {
+		t.Fatalf("リクエストボディのJSON変換に失敗: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events/"+eventID+"/retract", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// getAggregateEvents はAggregateIDのイベントを取得するヘルパー関数。
+func getAggregateEvents(t *testing.T, s *Server, aggregateID, rawQuery string) []eventResponse {
+	t.Helper()
+
+	target := "/api/v1/events/aggregate/" + aggregateID
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("イベント取得に失敗: status=%d, body=%s", w.Code, w.Body.String())
+	}
+
+	var events []eventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatalf("レスポンスのJSONパースに失敗: %v", err)
+	}
+	return events
+}
+
+func TestHandleRetractEvent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_撤回したイベントは既定で除外され、include_retracted=trueで撤回の事実も取得できる", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		appendTestEvent(t, s, "media-001", "Media", "MediaUploaded", map[string]interface{}{"filename": "a.jpg"})
+		w := appendTestEvent(t, s, "media-001", "Media", "MediaProcessingFailed", map[string]interface{}{"reason": "誤記録"})
+		var wrong eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &wrong); err != nil {
+			t.Fatalf("レスポンスのJSONパースに失敗: %v", err)
+		}
+
+		w = retractTestEvent(t, s, wrong.ID, "誤って追記したため")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var retraction eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &retraction); err != nil {
+			t.Fatalf("レスポンスのJSONパースに失敗: %v", err)
+		}
+		if retraction.EventType != "EventRetracted" || retraction.AggregateID != "media-001" || retraction.Version != 3 {
+			t.Errorf("撤回イベントの内容が不正です: %+v", retraction)
+		}
+		if !strings.Contains(retraction.Data, wrong.ID) {
+			t.Errorf("撤回イベントが元のイベントIDを参照していません: %s", retraction.Data)
+		}
+
+		for _, rawQuery := range []string{"", "include_retracted=false"} {
+			events := getAggregateEvents(t, s, "media-001", rawQuery)
+			if len(events) != 1 || events[0].EventType != "MediaUploaded" {
+				t.Errorf("query=%q: 撤回済みイベントが除外されていません: %+v", rawQuery, events)
+			}
+		}
+
+		events := getAggregateEvents(t, s, "media-001", "include_retracted=true")
+		if len(events) != 3 {
+			t.Fatalf("期待するイベント数 3, 実際のイベント数 %d", len(events))
+		}
+		if events[1].ID != wrong.ID || events[2].ID != retraction.ID {
+			t.Errorf("撤回されたイベントと撤回イベントが残っていません: %+v", events)
+		}
+	})
+
+	t.Run("異常系_撤回の失敗ケース", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		w := appendTestEvent(t, s, "media-002", "Media", "MediaUploaded", map[string]interface{}{"filename": "b.jpg"})
+		var uploaded eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &uploaded); err != nil {
+			t.Fatalf("レスポンスのJSONパースに失敗: %v", err)
+		}
+		w = retractTestEvent(t, s, uploaded.ID, "誤って追記したため")
+		var retraction eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &retraction); err != nil {
+			t.Fatalf("レスポンスのJSONパースに失敗: %v", err)
+		}
+
+		tests := []struct {
+			name       string
+			eventID    string
+			wantStatus int
+		}{
+			{name: "撤回済みのイベントは409", eventID: uploaded.ID, wantStatus: http.StatusConflict},
+			{name: "撤回イベント自体は400", eventID: retraction.ID, wantStatus: http.StatusBadRequest},
+			{name: "存在しないイベントは404", eventID: "missing", wantStatus: http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			if w := retractTestEvent(t, s, tt.eventID, "再撤回"); w.Code != tt.wantStatus {
+				t.Errorf("%s: 期待するステータスコード %d, 実際のステータスコード %d", tt.name, tt.wantStatus, w.Code)
+			}
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events/aggregate/media-002?include_retracted=maybe", nil)
+		w = httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("include_retractedが不正な場合: 期待するステータスコード %d, 実際のステータスコード %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
 func TestHandleGetEventsByType(t *testing.T) {
 	t.Parallel()
 
@@ -542,6 +779,224 @@ func TestHandleGetEventsSince(t *testing.T) {
 		}
 	})
 
+	t.Run("types・type・event_typeにカンマ区切りで指定したイベントタイプのみを取得できる", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+
+		past := time.Now().UTC().Add(-1 * time.Hour)
+
+		appendTestEvent(t, s, "agg-types-1", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"})
+		appendTestEvent(t, s, "agg-types-2", "Notification", "NotificationSent", map[string]interface{}{"user_id": "user-1"})
+		appendTestEvent(t, s, "agg-types-1", "Media", "MediaProcessed", map[string]interface{}{"user_id": "user-1"})
+		appendTestEvent(t, s, "agg-types-3", "Album", "AlbumCreated", map[string]interface{}{"user_id": "user-1"})
+
+		tests := []struct {
+			name           string
+			typesParam     string
+			typeParam      string
+			eventTypeParam string
+			want           []string
+		}{
+			{name: "typesで複数タイプ", typesParam: "MediaUploaded,MediaProcessed", want: []string{"MediaUploaded", "MediaProcessed"}},
+			{name: "typesとtypeの併用", typesParam: "MediaUploaded", typeParam: "AlbumCreated", want: []string{"MediaUploaded", "AlbumCreated"}},
+			{name: "event_typeで単一タイプ", eventTypeParam: "MediaUploaded", want: []string{"MediaUploaded"}},
+			{name: "複数タイプ", typeParam: "MediaUploaded,MediaProcessed", want: []string{"MediaUploaded", "MediaProcessed"}},
+			{name: "単一タイプ", typeParam: "AlbumCreated", want: []string{"AlbumCreated"}},
+			{name: "空要素と重複は無視される", typeParam: "MediaProcessed,,MediaProcessed, ", want: []string{"MediaProcessed"}},
+			{name: "一致しないタイプ", typeParam: "Unknown", want: []string{}},
+			{name: "空の場合は全タイプ", typeParam: "", want: []string{"MediaUploaded", "NotificationSent", "MediaProcessed", "AlbumCreated"}},
+		}
+
+		for _, tt := range tests {
+			params := url.Values{}
+			params.Set("since", past.Format(time.RFC3339))
+			params.Set("types", tt.typesParam)
+			params.Set("type", tt.typeParam)
+			params.Set("event_type", tt.eventTypeParam)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/events/since?"+params.Encode(), nil)
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: ステータスコード = %d; 期待値 = %d", tt.name, w.Code, http.StatusOK)
+			}
+
+			var resp []eventResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("%s: レスポンスのJSONデコードに失敗: %v", tt.name, err)
+			}
+
+			got := make([]string, 0, len(resp))
+			for _, ev := range resp {
+				got = append(got, ev.EventType)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("%s: イベントタイプ = %v; 期待値 = %v", tt.name, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("event_typeを指定してもsinceが未指定または不正な場合は400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+
+		for _, rawQuery := range []string{"event_type=MediaUploaded", "since=yesterday&event_type=MediaUploaded"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/events/since?"+rawQuery, nil)
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: ステータスコード = %d; 期待値 = %d", rawQuery, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("GetEventsSinceByTypesとGetEventsSinceByTypeは指定したイベントタイプのみを返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+
+		past := time.Now().UTC().Add(-1 * time.Hour)
+
+		appendTestEvent(t, s, "agg-sqlc-1", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"})
+		appendTestEvent(t, s, "agg-sqlc-2", "Album", "AlbumCreated", map[string]interface{}{"user_id": "user-1"})
+		appendTestEvent(t, s, "agg-sqlc-1", "Media", "MediaProcessed", map[string]interface{}{"user_id": "user-1"})
+
+		events, err := s.queries.GetEventsSinceByTypes(t.Context(), eventstoredb.GetEventsSinceByTypesParams{
+			CreatedAt:  past,
+			EventTypes: []string{"MediaProcessed", "MediaUploaded"},
+			Limit:      10,
+		})
+		if err != nil {
+			t.Fatalf("イベント取得に失敗: %v", err)
+		}
+		got := make([]string, 0, len(events))
+		for _, ev := range events {
+			got = append(got, ev.EventType)
+		}
+		if want := "MediaUploaded,MediaProcessed"; strings.Join(got, ",") != want {
+			t.Errorf("イベントタイプ = %v; 期待値 = %s", got, want)
+		}
+
+		single, err := s.queries.GetEventsSinceByType(t.Context(), eventstoredb.GetEventsSinceByTypeParams{
+			CreatedAt: past,
+			EventType: "AlbumCreated",
+			Limit:     10,
+		})
+		if err != nil {
+			t.Fatalf("イベント取得に失敗: %v", err)
+		}
+		if len(single) != 1 || single[0].AggregateID != "agg-sqlc-2" {
+			t.Errorf("GetEventsSinceByTypeの結果 = %+v; 期待値 = agg-sqlc-2のAlbumCreatedのみ", single)
+		}
+	})
+
+	t.Run("limitとoffsetで同じ作成日時のイベントをバージョン順に取りこぼさず取得できる", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.adminUserIDs = parseAdminUserIDs(importAdminUserID)
+		s.importEnabled = true
+
+		for i := 1; i <= 5; i++ {
+			if w := importTestEvent(t, s, "media-same-time", "2020-01-02T03:04:05Z"); w.Code != http.StatusCreated {
+				t.Fatalf("イベント%d: インポートに失敗: %d, body: %s", i, w.Code, w.Body.String())
+			}
+		}
+
+		var versions []int64
+		for offset := 0; ; offset += 2 {
+			target := fmt.Sprintf("/api/v1/events/since?since=2020-01-02T03:04:04Z&limit=2&offset=%d", offset)
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: ステータスコード = %d; 期待値 = %d", target, w.Code, http.StatusOK)
+			}
+
+			var resp []eventResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("%s: レスポンスのJSONデコードに失敗: %v", target, err)
+			}
+			if len(resp) > 2 {
+				t.Fatalf("%s: イベント数 = %d; 期待値 = 2以下", target, len(resp))
+			}
+			for _, ev := range resp {
+				versions = append(versions, ev.Version)
+			}
+			if len(resp) < 2 {
+				break
+			}
+		}
+		if fmt.Sprint(versions) != "[1 2 3 4 5]" {
+			t.Errorf("取得したバージョン = %v; 期待値 = [1 2 3 4 5]", versions)
+		}
+	})
+
+	t.Run("作成日時とバージョンが同じ別Aggregateのイベントもページの境界で重複・欠落せず追記順に取得できる", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.adminUserIDs = parseAdminUserIDs(importAdminUserID)
+		s.importEnabled = true
+
+		// 2つのAggregateのイベントを交互に同じ作成日時でインポートし、作成日時とバージョンが同じ組を作る
+		var want []string
+		for version := 1; version <= 3; version++ {
+			for _, aggregateID := range []string{"media-tie-a", "media-tie-b"} {
+				if w := importTestEvent(t, s, aggregateID, "2020-01-02T03:04:05Z"); w.Code != http.StatusCreated {
+					t.Fatalf("%s v%d: インポートに失敗: %d, body: %s", aggregateID, version, w.Code, w.Body.String())
+				}
+				want = append(want, fmt.Sprintf("%s/%d", aggregateID, version))
+			}
+		}
+
+		// limit=3ではバージョン2の組がページの境界をまたぐ
+		for _, filter := range []string{"", "&types=MediaUploaded"} {
+			var got []string
+			for offset := 0; ; offset += 3 {
+				target := fmt.Sprintf("/api/v1/events/since?since=2020-01-02T03:04:04Z&limit=3&offset=%d%s", offset, filter)
+				req := httptest.NewRequest(http.MethodGet, target, nil)
+				w := httptest.NewRecorder()
+				s.router.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					t.Fatalf("%s: ステータスコード = %d; 期待値 = %d", target, w.Code, http.StatusOK)
+				}
+
+				var resp []eventResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("%s: レスポンスのJSONデコードに失敗: %v", target, err)
+				}
+				for _, ev := range resp {
+					got = append(got, fmt.Sprintf("%s/%d", ev.AggregateID, ev.Version))
+				}
+				if len(resp) < 3 {
+					break
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("filter=%q: 取得したイベント = %v; 期待値 = %v", filter, got, want)
+			}
+		}
+	})
+
+	t.Run("不正なlimitは400エラーを返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+
+		for _, limit := range []string{"0", "1001", "abc"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/events/since?since=2020-01-02T03:04:05Z&limit="+limit, nil)
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("limit=%s: ステータスコード = %d; 期待値 = %d", limit, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
 	t.Run("未来の日時を指定すると空配列を返す", func(t *testing.T) {
 		t.Parallel()
 
@@ -770,8 +1225,11 @@ func TestHandleGetAllEvents(t *testing.T) {
 			t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
 		}
 
-		if len(resp) != 0 {
-			t.Errorf("イベント数 = %d; 期待値 = 0", len(resp))
+		if resp == nil || len(resp) != 0 {
+			t.Errorf("レスポンス = %s; 期待値 = 空配列", w.Body.String())
+		}
+		if total := w.Header().Get("X-Total-Count"); total != "0" {
+			t.Errorf("X-Total-Count = %q; 期待値 = %q", total, "0")
 		}
 	})
 
@@ -814,12 +1272,318 @@ func TestHandleGetAllEvents(t *testing.T) {
 	})
 }
 
+func TestHandleCountEvents(t *testing.T) {
+	t.Parallel()
+
+	s := setupTestServer(t)
+	appendTestEvent(t, s, "agg-count-1", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"})
+	appendTestEvent(t, s, "agg-count-1", "Media", "MediaProcessed", map[string]interface{}{"thumbnail_path": "/thumb.jpg"})
+	appendTestEvent(t, s, "agg-count-2", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-2"})
+	appendTestEvent(t, s, "agg-count-3", "Album", "AlbumCreated", map[string]interface{}{"user_id": "user-1"})
+
+	tests := []struct {
+		name  string
+		query string
+		want  int64
+	}{
+		{name: "絞り込みなしの場合は全イベント数を返す", query: "", want: 4},
+		{name: "aggregate_typeで絞り込む", query: "?aggregate_type=Media", want: 3},
+		{name: "event_typeで絞り込む", query: "?event_type=MediaUploaded", want: 2},
+		{name: "両方を指定した場合は両方に一致するイベントを数える", query: "?aggregate_type=Album&event_type=MediaUploaded", want: 0},
+		{name: "一致するイベントがない場合は0を返す", query: "?event_type=NotificationSent", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/events/count"+tt.query, nil)
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+			}
+			var resp eventCountResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+			if resp.Count != tt.want {
+				t.Errorf("期待する件数 %d, 実際の件数 %d", tt.want, resp.Count)
+			}
+		})
+	}
+}
+
+// TestEventsPagination は全イベント取得とAggregateIDによる取得のlimit/offsetページングを検証する。
+func TestEventsPagination(t *testing.T) {
+	t.Parallel()
+
+	// get はイベントを取得してレスポンスを返す。
+	get := func(t *testing.T, s *Server, target string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: ステータスコード = %d; 期待値 = %d, body = %s", target, w.Code, http.StatusOK, w.Body.String())
+		}
+		return w
+	}
+	// allEventsEnvelope はエンベロープ形式の全イベントのページ。
+	type allEventsEnvelope struct {
+		Data       []eventResponse `json:"data"`
+		Count      int             `json:"count"`
+		Total      int64           `json:"total"`
+		NextOffset *int            `json:"next_offset"`
+	}
+	// getAllEventsEnvelope は全イベントのページをエンベロープ形式で取得する。
+	getAllEventsEnvelope := func(t *testing.T, s *Server, target string) allEventsEnvelope {
+		t.Helper()
+		var page allEventsEnvelope
+		if err := json.Unmarshal(get(t, s, target).Body.Bytes(), &page); err != nil {
+			t.Fatalf("%s: レスポンスのJSONデコードに失敗: %v", target, err)
+		}
+		return page
+	}
+	// getPage はイベントのページとX-Total-Countヘッダーを取得する。
+	getPage := func(t *testing.T, s *Server, target string) ([]eventResponse, string) {
+		t.Helper()
+		w := get(t, s, target)
+		var resp []eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: レスポンスのJSONデコードに失敗: %v", target, err)
+		}
+		return resp, w.Header().Get("X-Total-Count")
+	}
+
+	s := setupTestServer(t)
+	for i := 1; i <= 5; i++ {
+		if w := appendTestEvent(t, s, "agg-page", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"}); w.Code != http.StatusCreated {
+			t.Fatalf("イベント%d: 追記に失敗: %d", i, w.Code)
+		}
+	}
+	appendTestEvent(t, s, "agg-page-other", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-2"})
+
+	t.Run("正常系_AggregateIDのイベントをlimitとoffsetで取得し、全件数をヘッダーで返す", func(t *testing.T) {
+		t.Parallel()
+
+		resp, total := getPage(t, s, "/api/v1/events/aggregate/agg-page?limit=2&offset=2")
+		if total != "5" {
+			t.Errorf("X-Total-Count = %q; 期待値 = %q", total, "5")
+		}
+		if len(resp) != 2 || resp[0].Version != 3 || resp[1].Version != 4 {
+			t.Errorf("取得したイベント = %+v; 期待値 = バージョン3, 4", resp)
+		}
+	})
+
+	t.Run("正常系_全イベントの最終ページは残りの件数だけ返す", func(t *testing.T) {
+		t.Parallel()
+
+		first, total := getPage(t, s, "/api/v1/events?limit=4")
+		last, _ := getPage(t, s, "/api/v1/events?limit=4&offset=4")
+		if total != "6" {
+			t.Errorf("X-Total-Count = %q; 期待値 = %q", total, "6")
+		}
+		if len(first) != 4 || len(last) != 2 {
+			t.Fatalf("ページごとのイベント数 = %d, %d; 期待値 = 4, 2", len(first), len(last))
+		}
+		seen := make(map[string]bool)
+		for _, ev := range append(first, last...) {
+			if seen[ev.ID] {
+				t.Errorf("ページ間でイベントが重複しています: %s", ev.ID)
+			}
+			seen[ev.ID] = true
+		}
+	})
+
+	t.Run("正常系_エンベロープ形式を要求すると全イベントをnext_offsetで最終ページまで辿れる", func(t *testing.T) {
+		t.Parallel()
+
+		first := getAllEventsEnvelope(t, s, "/api/v1/events?limit=4&envelope=true")
+		if first.Total != 6 || first.Count != 4 || len(first.Data) != 4 {
+			t.Fatalf("total = %d, count = %d, イベント数 = %d; 期待値 = 6, 4, 4", first.Total, first.Count, len(first.Data))
+		}
+		if first.NextOffset == nil || *first.NextOffset != 4 {
+			t.Fatalf("next_offset = %v; 期待値 = 4", first.NextOffset)
+		}
+
+		last := getAllEventsEnvelope(t, s, fmt.Sprintf("/api/v1/events?limit=4&offset=%d&envelope=true", *first.NextOffset))
+		if len(last.Data) != 2 || last.Count != 2 || last.NextOffset != nil {
+			t.Fatalf("イベント数 = %d, count = %d, next_offset = %v; 期待値 = 2, 2, nil", len(last.Data), last.Count, last.NextOffset)
+		}
+		seen := make(map[string]bool)
+		for _, ev := range append(first.Data, last.Data...) {
+			if seen[ev.ID] {
+				t.Errorf("ページ間でイベントが重複しています: %s", ev.ID)
+			}
+			seen[ev.ID] = true
+		}
+	})
+
+	t.Run("正常系_Acceptヘッダーでエンベロープ形式を要求できる", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events?limit=2", nil)
+		req.Header.Set("Accept", middleware.EnvelopeMediaType)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		var page allEventsEnvelope
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("レスポンスのJSONデコードに失敗: %v, body = %s", err, w.Body.String())
+		}
+		if page.Count != 2 || page.Total != 6 || page.NextOffset == nil || *page.NextOffset != 2 {
+			t.Errorf("count = %d, total = %d, next_offset = %v; 期待値 = 2, 6, 2", page.Count, page.Total, page.NextOffset)
+		}
+	})
+
+	t.Run("正常系_最大件数を超えるlimitは最大件数に丸める", func(t *testing.T) {
+		t.Parallel()
+
+		resp, total := getPage(t, s, "/api/v1/events?limit=5000")
+		if len(resp) != 6 || total != "6" {
+			t.Errorf("イベント数 = %d, X-Total-Count = %q; 期待値 = 6, %q", len(resp), total, "6")
+		}
+	})
+
+	t.Run("正常系_limit省略時は既定件数まで返し、範囲外のoffsetは空配列を返す", func(t *testing.T) {
+		t.Parallel()
+
+		if resp, _ := getPage(t, s, "/api/v1/events"); len(resp) != 6 {
+			t.Errorf("イベント数 = %d; 期待値 = 6", len(resp))
+		}
+		if page := getAllEventsEnvelope(t, s, "/api/v1/events?offset=10&envelope=true"); page.Data == nil || len(page.Data) != 0 || page.Count != 0 || page.Total != 6 || page.NextOffset != nil {
+			t.Errorf("全イベントの範囲外のページ = %+v; 期待値 = 空配列, total 6, next_offset nil", page)
+		}
+		resp, total := getPage(t, s, "/api/v1/events/aggregate/agg-page?offset=10")
+		if len(resp) != 0 || total != "5" {
+			t.Errorf("イベント数 = %d, X-Total-Count = %q; 期待値 = 0, %q", len(resp), total, "5")
+		}
+	})
+
+	t.Run("異常系_不正なlimitとoffsetは400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		for _, target := range []string{
+			"/api/v1/events?limit=0",
+			"/api/v1/events?limit=-1",
+			"/api/v1/events?limit=abc",
+			"/api/v1/events?offset=-1",
+			"/api/v1/events/aggregate/agg-page?limit=1001",
+			"/api/v1/events/aggregate/agg-page?limit=abc",
+		} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: ステータスコード = %d; 期待値 = %d", target, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
+
 // TestToEventResponse はtoEventResponse変換関数の動作を検証する。
+func TestHandleListAggregateIDs(t *testing.T) {
+	t.Parallel()
+
+	s := setupTestServer(t)
+
+	// 同じAggregateに複数イベントを追記し、別の種類のAggregateも混在させる
+	for _, id := range []string{"media-003", "media-001", "media-005", "media-002", "media-004"} {
+		appendTestEvent(t, s, id, "Media", "MediaUploaded", map[string]interface{}{"filename": id + ".jpg"})
+		appendTestEvent(t, s, id, "Media", "MediaProcessed", map[string]interface{}{"width": 10})
+	}
+	appendTestEvent(t, s, "album-001", "Album", "AlbumCreated", map[string]interface{}{"name": "旅行"})
+
+	listPage := func(t *testing.T, rawQuery string) (int, aggregateIDsResponse) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/aggregates?"+rawQuery, nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		var resp aggregateIDsResponse
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのJSONパースに失敗: %v", err)
+			}
+		}
+		return w.Code, resp
+	}
+
+	t.Run("正常系_cursorで重複のないIDを順にページングできる", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			got    []string
+			cursor string
+			pages  int
+		)
+		for {
+			code, resp := listPage(t, url.Values{"type": {"Media"}, "limit": {"2"}, "cursor": {cursor}}.Encode())
+			if code != http.StatusOK {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+			}
+			if len(resp.AggregateIDs) > 2 {
+				t.Fatalf("limitを超える件数が返されました: %v", resp.AggregateIDs)
+			}
+			got = append(got, resp.AggregateIDs...)
+			pages++
+			if resp.NextCursor == "" {
+				break
+			}
+			cursor = resp.NextCursor
+		}
+
+		want := []string{"media-001", "media-002", "media-003", "media-004", "media-005"}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("期待するID %v, 実際のID %v", want, got)
+		}
+		if pages != 3 {
+			t.Errorf("期待するページ数 3, 実際のページ数 %d", pages)
+		}
+	})
+
+	t.Run("正常系_limit省略時は既定件数で全件返し、次のcursorは空", func(t *testing.T) {
+		t.Parallel()
+
+		code, resp := listPage(t, "type=Album")
+		if code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		if len(resp.AggregateIDs) != 1 || resp.AggregateIDs[0] != "album-001" || resp.NextCursor != "" {
+			t.Errorf("期待しないレスポンス: %+v", resp)
+		}
+	})
+
+	t.Run("正常系_該当なしの場合は空配列を返す", func(t *testing.T) {
+		t.Parallel()
+
+		code, resp := listPage(t, "type=User")
+		if code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		if resp.AggregateIDs == nil || len(resp.AggregateIDs) != 0 {
+			t.Errorf("空配列が返されるべきです: %+v", resp.AggregateIDs)
+		}
+	})
+
+	t.Run("異常系_パラメータが不正な場合は400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		for _, rawQuery := range []string{"", "type=Media&limit=0", "type=Media&limit=1001", "type=Media&limit=abc"} {
+			if code, _ := listPage(t, rawQuery); code != http.StatusBadRequest {
+				t.Errorf("query=%q: 期待するステータスコード %d, 実際のステータスコード %d", rawQuery, http.StatusBadRequest, code)
+			}
+		}
+	})
+}
+
 func TestToEventResponse(t *testing.T) {
 	t.Parallel()
 
 	now := time.Now().UTC()
-	resp := toEventResponse("id-1", "agg-1", "Media", "MediaUploaded", `{"key":"value"}`, 5, now)
+	metadata := event.Metadata{CorrelationID: "correlation-1", CausationID: "event-0"}
+	resp := toEventResponse("id-1", "agg-1", "Media", "MediaUploaded", `{"key":"value"}`, 5, now, metadata)
 
 	if resp.ID != "id-1" {
 		t.Errorf("ID = %q; 期待値 = %q", resp.ID, "id-1")
@@ -844,6 +1608,9 @@ func TestToEventResponse(t *testing.T) {
 	if resp.CreatedAt != expectedTime {
 		t.Errorf("CreatedAt = %q; 期待値 = %q", resp.CreatedAt, expectedTime)
 	}
+	if resp.Metadata != metadata {
+		t.Errorf("Metadata = %+v; 期待値 = %+v", resp.Metadata, metadata)
+	}
 }
 
 // TestToEventResponses はtoEventResponsesスライス変換関数の動作を検証する。
@@ -1046,6 +1813,134 @@ func TestAppendAndRetrieveIntegration(t *testing.T) {
 	})
 }
 
+// TestAppendEventIndexColumns はイベント追記時に索引カラムが導出・保存されることを検証する。
+func TestAppendEventIndexColumns(t *testing.T) {
+	t.Parallel()
+
+	s := setupTestServer(t)
+
+	appendTestEvent(t, s, "media-001", "Media", "MediaUploaded", map[string]interface{}{
+		"user_id":  "user-001",
+		"filename": "photo.jpg",
+	})
+	appendTestEvent(t, s, "album-001", "Album", "AlbumCreated", map[string]interface{}{
+		"user_id": "user-001",
+		"name":    "旅行",
+	})
+	appendTestEvent(t, s, "media-001", "Media", "MediaProcessed", map[string]interface{}{
+		"thumbnail_path": "/data/thumbnails/media-001.jpg",
+	})
+	appendTestEvent(t, s, "media-002", "Media", "MediaUploaded", map[string]interface{}{
+		"user_id":  "user-002",
+		"filename": "photo.jpg",
+	})
+
+	t.Run("索引カラムにペイロードの値が保存される", func(t *testing.T) {
+		tests := []struct {
+			aggregateID  string
+			eventType    string
+			wantUserID   string
+			wantFilename string
+		}{
+			{aggregateID: "media-001", eventType: "MediaUploaded", wantUserID: "user-001", wantFilename: "photo.jpg"},
+			{aggregateID: "album-001", eventType: "AlbumCreated", wantUserID: "user-001", wantFilename: ""},
+			{aggregateID: "media-001", eventType: "MediaProcessed", wantUserID: "", wantFilename: ""},
+		}
+
+		for _, tt := range tests {
+			var userID, filename string
+			err := s.db.QueryRow(
+				"SELECT user_id, filename FROM events WHERE aggregate_id = ? AND event_type = ?",
+				tt.aggregateID, tt.eventType,
+			).Scan(&userID, &filename)
+			if err != nil {
+				t.Fatalf("索引カラムの取得に失敗 (%s): %v", tt.eventType, err)
+			}
+			if userID != tt.wantUserID {
+				t.Errorf("%s の user_id = %q; 期待値 = %q", tt.eventType, userID, tt.wantUserID)
+			}
+			if filename != tt.wantFilename {
+				t.Errorf("%s の filename = %q; 期待値 = %q", tt.eventType, filename, tt.wantFilename)
+			}
+		}
+	})
+
+	t.Run("ユーザーIDでイベントを取得できる", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events/user/user-001", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusOK)
+		}
+
+		var events []eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+			t.Fatalf("レスポンスのJSON解析に失敗: %v", err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("イベント数 = %d; 期待値 = 2", len(events))
+		}
+		for _, ev := range events {
+			if ev.AggregateID != "media-001" && ev.AggregateID != "album-001" {
+				t.Errorf("他ユーザーのイベントが含まれている: %s", ev.AggregateID)
+			}
+		}
+	})
+
+	t.Run("ファイル名でイベントを取得できる", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events/filename/photo.jpg", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusOK)
+		}
+
+		var events []eventResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+			t.Fatalf("レスポンスのJSON解析に失敗: %v", err)
+		}
+		if len(events) != 2 {
+			t.Errorf("イベント数 = %d; 期待値 = 2", len(events))
+		}
+	})
+
+	t.Run("該当するイベントがない場合は空配列を返す", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events/user/unknown-user", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusOK)
+		}
+		if w.Body.String() != "[]" {
+			t.Errorf("レスポンスボディ = %s; 期待値 = []", w.Body.String())
+		}
+	})
+
+	t.Run("ユーザーID検索はインデックスを使用する", func(t *testing.T) {
+		rows, err := s.db.Query("EXPLAIN QUERY PLAN SELECT id FROM events WHERE user_id = ? ORDER BY created_at ASC", "user-001")
+		if err != nil {
+			t.Fatalf("クエリプランの取得に失敗: %v", err)
+		}
+		defer rows.Close()
+
+		var plan string
+		for rows.Next() {
+			var id, parent, notUsed int
+			var detail string
+			if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+				t.Fatalf("クエリプランの読み取りに失敗: %v", err)
+			}
+			plan += detail + "\n"
+		}
+		if !strings.Contains(plan, "idx_events_user_id") {
+			t.Errorf("idx_events_user_id が使用されていない: %s", plan)
+		}
+	})
+}
+
 // TestInitSchema はスキーマ初期化関数の動作を検証する。
 func TestInitSchema(t *testing.T) {
 	t.Parallel()
@@ -1064,13 +1959,15 @@ func TestInitSchema(t *testing.T) {
 		}
 
 		// テーブルが作成されたことを確認する
-		var tableName string
-		err = sqlDB.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='events'").Scan(&tableName)
-		if err != nil {
-			t.Fatalf("eventsテーブルの確認に失敗: %v", err)
-		}
-		if tableName != "events" {
-			t.Errorf("テーブル名 = %q; 期待値 = %q", tableName, "events")
+		for _, want := range []string{"events", "snapshots"} {
+			var tableName string
+			err = sqlDB.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name=?", want).Scan(&tableName)
+			if err != nil {
+				t.Fatalf("%sテーブルの確認に失敗: %v", want, err)
+			}
+			if tableName != want {
+				t.Errorf("テーブル名 = %q; 期待値 = %q", tableName, want)
+			}
 		}
 	})
 
@@ -1091,3 +1988,124 @@ func TestInitSchema(t *testing.T) {
 		}
 	})
 }
+
+// TestAppendEventDataValidation はイベントタイプのスキーマによるdataの検証を検証する。
+func TestAppendEventDataValidation(t *testing.T) {
+	t.Parallel()
+
+	valid := map[string]interface{}{
+		"user_id":      "user-1",
+		"filename":     "photo.jpg",
+		"content_type": "image/jpeg",
+		"size":         1024,
+		"storage_path": "/data/media/photo.jpg",
+	}
+
+	t.Run("スキーマを満たすdataを受け付けること", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.validateData = true
+
+		if w := appendTestEvent(t, s, "agg-valid", "Media", "MediaUploaded", valid); w.Code != http.StatusCreated {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("必須フィールドのないdataを400で拒否し保存しないこと", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		s.validateData = true
+
+		data := maps.Clone(valid)
+		delete(data, "filename")
+		w := appendTestEvent(t, s, "agg-invalid", "Media", "MediaUploaded", data)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusBadRequest, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "filename") {
+			t.Errorf("エラーメッセージにfilenameが含まれません: %s", w.Body.String())
+		}
+
+		events, err := s.queries.GetEventsByAggregateID(context.Background(), "agg-invalid")
+		if err != nil {
+			t.Fatalf("イベントの取得に失敗: %v", err)
+		}
+		if len(events) != 0 {
+			t.Errorf("保存されたイベント数 = %d, want 0", len(events))
+		}
+	})
+
+	t.Run("検証が無効の場合はスキーマを満たさないdataも受け付けること", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+
+		if w := appendTestEvent(t, s, "agg-disabled", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"}); w.Code != http.StatusCreated {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusCreated, w.Code)
+		}
+	})
+}
+
+// TestGetEventSchemas はイベントのスキーマ取得APIを検証する。
+func TestGetEventSchemas(t *testing.T) {
+	t.Parallel()
+
+	getSchemas := func(t *testing.T, query string) (*httptest.ResponseRecorder, eventSchemasResponse) {
+		t.Helper()
+
+		s := setupTestServer(t)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/events/schema"+query, nil))
+
+		var resp eventSchemasResponse
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+		}
+		return w, resp
+	}
+
+	t.Run("全イベントタイプのスキーマを返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		w, resp := getSchemas(t, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+		for _, typ := range event.AllTypes() {
+			if _, ok := resp.Schemas[string(typ)]; !ok {
+				t.Errorf("%s のスキーマがありません", typ)
+			}
+		}
+		if len(resp.Schemas) != len(event.AllTypes()) {
+			t.Errorf("スキーマ数 = %d, want %d", len(resp.Schemas), len(event.AllTypes()))
+		}
+	})
+
+	t.Run("typeを指定した場合はそのタイプのみをJSON Schema形式で返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		w, resp := getSchemas(t, "?type=MediaUploaded")
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+		want, _ := event.JSONSchemaFor(event.TypeMediaUploaded)
+		if len(resp.Schemas) != 1 || !reflect.DeepEqual(resp.Schemas["MediaUploaded"], want) {
+			t.Errorf("Schemas = %+v, want MediaUploadedの %+v のみ", resp.Schemas, want)
+		}
+		if !strings.Contains(w.Body.String(), `"$schema":"https://json-schema.org/draft/2020-12/schema"`) {
+			t.Errorf("$schemaが含まれません: %s", w.Body.String())
+		}
+	})
+
+	t.Run("未登録のイベントタイプは400を返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		if w, _ := getSchemas(t, "?type=MediaUplaoded"); w.Code != http.StatusBadRequest {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
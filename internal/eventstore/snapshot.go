@@ -0,0 +1,113 @@
+package eventstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	eventstoredb "github.com/nao1215/micro/internal/eventstore/db"
+)
+
+// saveSnapshotRequest はスナップショット保存リクエストのJSON構造。
+type saveSnapshotRequest struct {
+	// AggregateID は対象のAggregateの識別子。
+	AggregateID string `json:"aggregate_id" binding:"required"`
+	// Version はスナップショットが反映しているイベントの最新バージョン。1以上かつAggregateの最新バージョン以下。
+	Version int64 `json:"version"`
+	// State はAggregateの状態（JSON）。
+	State json.RawMessage `json:"state" binding:"required"`
+}
+
+// snapshotResponse はスナップショットのJSONレスポンス構造。
+type snapshotResponse struct {
+	// AggregateID は対象のAggregateの識別子。
+	AggregateID string `json:"aggregate_id"`
+	// Version はスナップショットが反映しているイベントの最新バージョン。
+	// 状態の再構築時は、このバージョンより後のイベントだけを取得して適用する。
+	Version int64 `json:"version"`
+	// State はAggregateの状態（JSON）。
+	State json.RawMessage `json:"state"`
+	// CreatedAt はスナップショットの保存日時（RFC3339形式）。
+	CreatedAt string `json:"created_at"`
+}
+
+// toSnapshotResponse はDBのスナップショットをJSONレスポンスに変換する。
+func toSnapshotResponse(snapshot eventstoredb.Snapshot) snapshotResponse {
+	return snapshotResponse{
+		AggregateID: snapshot.AggregateID,
+		Version:     snapshot.Version,
+		State:       json.RawMessage(snapshot.State),
+		CreatedAt:   snapshot.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// handleSaveSnapshot はAggregateのスナップショットを保存するハンドラを返す。
+// まだ追記されていないバージョンのスナップショットは、以降のイベントと整合しないため409を返す。
+// 同じAggregateの同じバージョンを再保存した場合は上書きする。
+func (s *Server) handleSaveSnapshot() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req saveSnapshotRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+			return
+		}
+		if req.Version < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "versionは1以上で指定してください"})
+			return
+		}
+		if !json.Valid(req.State) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "stateはJSONで指定してください"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		latest, err := latestVersion(ctx, s.queries, req.AggregateID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "バージョン取得に失敗しました"})
+			log.Printf("バージョン取得エラー: %v", err)
+			return
+		}
+		if req.Version > latest {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("versionがAggregateの最新バージョン（%d）を超えています", latest)})
+			return
+		}
+
+		snapshot := eventstoredb.SaveSnapshotParams{
+			AggregateID: req.AggregateID,
+			Version:     req.Version,
+			State:       string(req.State),
+			CreatedAt:   time.Now().UTC(),
+		}
+		if err := s.queries.SaveSnapshot(ctx, snapshot); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "スナップショットの保存に失敗しました"})
+			log.Printf("スナップショット保存エラー: %v", err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, toSnapshotResponse(eventstoredb.Snapshot(snapshot)))
+	}
+}
+
+// handleGetLatestSnapshot はAggregateの最新のスナップショットを取得するハンドラを返す。
+// スナップショットがない場合は404を返し、クライアントは全イベントから状態を再構築する。
+func (s *Server) handleGetLatestSnapshot() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		snapshot, err := s.queries.GetLatestSnapshot(c.Request.Context(), c.Param("aggregate_id"))
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "スナップショットが見つかりません"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "スナップショットの取得に失敗しました"})
+			log.Printf("スナップショット取得エラー: %v", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, toSnapshotResponse(snapshot))
+	}
+}
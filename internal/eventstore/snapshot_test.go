@@ -0,0 +1,136 @@
+package eventstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// saveTestSnapshot はテスト用にスナップショット保存をPOSTするヘルパー関数。
+func saveTestSnapshot(t *testing.T, s *Server, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/snapshots", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// getTestSnapshot はテスト用に最新のスナップショットを取得するヘルパー関数。
+func getTestSnapshot(t *testing.T, s *Server, aggregateID string) (*httptest.ResponseRecorder, snapshotResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/snapshots/"+aggregateID, nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	var resp snapshotResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+		}
+	}
+	return w, resp
+}
+
+func TestSnapshots(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_最新バージョンのスナップショットを取得し、以降のイベントだけを取得できる", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		for range 4 {
+			appendTestEvent(t, s, "media-snap-1", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"})
+		}
+
+		if w := saveTestSnapshot(t, s, `{"aggregate_id":"media-snap-1","version":1,"state":{"status":"uploaded"}}`); w.Code != http.StatusCreated {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		if w := saveTestSnapshot(t, s, `{"aggregate_id":"media-snap-1","version":3,"state":{"status":"processed","width":640}}`); w.Code != http.StatusCreated {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+
+		w, snapshot := getTestSnapshot(t, s, "media-snap-1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusOK)
+		}
+		if snapshot.AggregateID != "media-snap-1" || snapshot.Version != 3 || snapshot.CreatedAt == "" {
+			t.Errorf("スナップショット = %+v; 期待値 = media-snap-1のバージョン3", snapshot)
+		}
+		var state map[string]any
+		if err := json.Unmarshal(snapshot.State, &state); err != nil || state["status"] != "processed" || state["width"] != float64(640) {
+			t.Errorf("state = %s; 期待値 = {\"status\":\"processed\",\"width\":640}", snapshot.State)
+		}
+
+		// スナップショットのバージョンより後のイベントだけを取得する（バージョンは1から連番のためoffsetに使える）
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events/aggregate/media-snap-1?include_retracted=true&offset=3", nil)
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, req)
+		var events []eventResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &events); err != nil {
+			t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+		}
+		if len(events) != 1 || events[0].Version != 4 {
+			t.Errorf("スナップショット以降のイベント = %+v; 期待値 = バージョン4のみ", events)
+		}
+	})
+
+	t.Run("正常系_同じバージョンの再保存は上書きする", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		appendTestEvent(t, s, "media-snap-2", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"})
+
+		for _, body := range []string{
+			`{"aggregate_id":"media-snap-2","version":1,"state":{"n":1}}`,
+			`{"aggregate_id":"media-snap-2","version":1,"state":{"n":2}}`,
+		} {
+			if w := saveTestSnapshot(t, s, body); w.Code != http.StatusCreated {
+				t.Fatalf("ステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusCreated, w.Body.String())
+			}
+		}
+
+		if _, snapshot := getTestSnapshot(t, s, "media-snap-2"); string(snapshot.State) != `{"n":2}` {
+			t.Errorf("state = %s; 期待値 = %s", snapshot.State, `{"n":2}`)
+		}
+	})
+
+	t.Run("異常系_スナップショットがない場合は404を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		if w, _ := getTestSnapshot(t, s, "media-none"); w.Code != http.StatusNotFound {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("異常系_不正なリクエストは保存しない", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		appendTestEvent(t, s, "media-snap-3", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"})
+
+		tests := []struct {
+			name string
+			body string
+			want int
+		}{
+			{name: "aggregate_idなし", body: `{"version":1,"state":{}}`, want: http.StatusBadRequest},
+			{name: "stateなし", body: `{"aggregate_id":"media-snap-3","version":1}`, want: http.StatusBadRequest},
+			{name: "versionが0", body: `{"aggregate_id":"media-snap-3","version":0,"state":{}}`, want: http.StatusBadRequest},
+			{name: "未追記のバージョン", body: `{"aggregate_id":"media-snap-3","version":2,"state":{}}`, want: http.StatusConflict},
+			{name: "イベントのないAggregate", body: `{"aggregate_id":"media-unknown","version":1,"state":{}}`, want: http.StatusConflict},
+		}
+		for _, tt := range tests {
+			if w := saveTestSnapshot(t, s, tt.body); w.Code != tt.want {
+				t.Errorf("%s: ステータスコード = %d; 期待値 = %d, body = %s", tt.name, w.Code, tt.want, w.Body.String())
+			}
+		}
+
+		if w, _ := getTestSnapshot(t, s, "media-snap-3"); w.Code != http.StatusNotFound {
+			t.Errorf("不正なリクエストでスナップショットが保存されています: ステータスコード = %d", w.Code)
+		}
+	})
+}
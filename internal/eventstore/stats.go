@@ -0,0 +1,170 @@
+package eventstore
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultEventStatsDays はイベント統計の既定の集計日数。
+	defaultEventStatsDays = 30
+	// maxEventStatsDays はイベント統計で指定できる最大の集計日数。
+	maxEventStatsDays = 365
+	// eventStatsCacheTTL はイベント統計の集計結果をキャッシュする時間。
+	// 全イベントを走査する重い集計のため、ダッシュボードの再読み込みのたびに集計し直さないようにする。
+	eventStatsCacheTTL = time.Minute
+	// eventStatsDateLayout はイベント統計の日付の形式。
+	eventStatsDateLayout = "2006-01-02"
+)
+
+// eventStatsResponse はイベント統計のJSONレスポンス構造。
+type eventStatsResponse struct {
+	// GroupBy は集計の単位（day）。
+	GroupBy string `json:"group_by"`
+	// Days は集計した日数。
+	Days int `json:"days"`
+	// From は集計期間の初日（UTC基準、YYYY-MM-DD）。
+	From string `json:"from"`
+	// To は集計期間の最終日（UTC基準、YYYY-MM-DD）。当日を含む。
+	To string `json:"to"`
+	// Timezone は日の境界のタイムゾーン。常にUTC。
+	Timezone string `json:"timezone"`
+	// GeneratedAt は集計した日時。キャッシュした結果を返す場合は集計時点の日時となる。
+	GeneratedAt string `json:"generated_at"`
+	// Stats はイベントタイプごとの日別のイベント数（イベントタイプ名順）。期間内にイベントがないタイプは含まない。
+	Stats []eventTypeStats `json:"stats"`
+}
+
+// eventTypeStats は1つのイベントタイプの日別のイベント数。
+type eventTypeStats struct {
+	// EventType はイベントタイプ。
+	EventType string `json:"event_type"`
+	// Total は集計期間内のイベント数の合計。
+	Total int64 `json:"total"`
+	// Counts は集計期間の初日から日付順に並べた日別のイベント数。イベントがない日も0として含める。
+	Counts []dailyEventCount `json:"counts"`
+}
+
+// dailyEventCount は1日分のイベント数。
+type dailyEventCount struct {
+	// Date は日付（UTC基準、YYYY-MM-DD）。
+	Date string `json:"date"`
+	// Count はイベント数。
+	Count int64 `json:"count"`
+}
+
+// eventStatsCache は集計日数ごとにイベント統計の集計結果をキャッシュする。ゼロ値で使用できる。
+type eventStatsCache struct {
+	// mu はentriesへの並行アクセスを保護するミューテックス。
+	mu sync.Mutex
+	// entries は集計日数ごとのキャッシュ。
+	entries map[int]eventStatsCacheEntry
+}
+
+// eventStatsCacheEntry はキャッシュした集計結果と有効期限。
+type eventStatsCacheEntry struct {
+	// resp は集計結果。
+	resp eventStatsResponse
+	// expiresAt はキャッシュの有効期限。
+	expiresAt time.Time
+}
+
+// get は有効期限内のキャッシュがあれば返す。
+func (c *eventStatsCache) get(days int, now time.Time) (eventStatsResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[days]
+	if !ok || !now.Before(entry.expiresAt) {
+		return eventStatsResponse{}, false
+	}
+	return entry.resp, true
+}
+
+// set は集計結果をキャッシュする。
+func (c *eventStatsCache) set(days int, resp eventStatsResponse, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[int]eventStatsCacheEntry)
+	}
+	c.entries[days] = eventStatsCacheEntry{resp: resp, expiresAt: now.Add(eventStatsCacheTTL)}
+}
+
+// handleEventStats は直近の日数のイベント数をイベントタイプ別・日別に集計して返すハンドラを返す。
+// クエリパラメータ group_by で集計の単位（dayのみ、既定day）、days で当日を含む集計日数（既定30、最大365）を指定する。
+// 日の境界はUTC基準とする。集計結果はeventStatsCacheTTLの間キャッシュする。
+func (s *Server) handleEventStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if groupBy := c.DefaultQuery("group_by", "day"); groupBy != "day" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("group_byはdayで指定してください: %q", groupBy)})
+			return
+		}
+		days := defaultEventStatsDays
+		if v := c.Query("days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxEventStatsDays {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("daysは1から%dまでの整数で指定してください", maxEventStatsDays)})
+				return
+			}
+			days = n
+		}
+
+		now := time.Now().UTC()
+		if resp, ok := s.statsCache.get(days, now); ok {
+			c.JSON(http.StatusOK, resp)
+			return
+		}
+
+		from := now.Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+		rows, err := s.queries.CountEventsByTypeAndDay(c.Request.Context(), from)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント統計の集計に失敗しました"})
+			log.Printf("イベント統計の集計エラー: %v", err)
+			return
+		}
+
+		// created_atはUTCで保存しているため、集計クエリの日付はUTC基準の日付となる
+		counts := make(map[string]map[string]int64)
+		for _, row := range rows {
+			if counts[row.EventType] == nil {
+				counts[row.EventType] = make(map[string]int64)
+			}
+			counts[row.EventType][row.Day] = row.EventCount
+		}
+
+		resp := eventStatsResponse{
+			GroupBy:     "day",
+			Days:        days,
+			From:        from.Format(eventStatsDateLayout),
+			To:          now.Format(eventStatsDateLayout),
+			Timezone:    "UTC",
+			GeneratedAt: now.Format(time.RFC3339),
+			Stats:       make([]eventTypeStats, 0, len(counts)),
+		}
+		eventTypes := make([]string, 0, len(counts))
+		for eventType := range counts {
+			eventTypes = append(eventTypes, eventType)
+		}
+		slices.Sort(eventTypes)
+		for _, eventType := range eventTypes {
+			stats := eventTypeStats{EventType: eventType, Counts: make([]dailyEventCount, 0, days)}
+			for i := range days {
+				date := from.AddDate(0, 0, i).Format(eventStatsDateLayout)
+				n := counts[eventType][date]
+				stats.Total += n
+				stats.Counts = append(stats.Counts, dailyEventCount{Date: date, Count: n})
+			}
+			resp.Stats = append(resp.Stats, stats)
+		}
+
+		s.statsCache.set(days, resp, now)
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,136 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	eventstoredb "github.com/nao1215/micro/internal/eventstore/db"
+)
+
+// TestHandleEventStats はイベントタイプ別・日別のイベント統計ハンドラを検証する。
+func TestHandleEventStats(t *testing.T) {
+	t.Parallel()
+
+	// appendAt は作成日時を指定してイベントを書き込むヘルパー関数。
+	appendAt := func(t *testing.T, s *Server, eventType string, createdAt time.Time) {
+		t.Helper()
+		if err := s.queries.AppendEvent(t.Context(), eventstoredb.AppendEventParams{
+			ID:            fmt.Sprintf("%s-%d", eventType, createdAt.UnixNano()),
+			AggregateID:   fmt.Sprintf("agg-%s-%d", eventType, createdAt.UnixNano()),
+			AggregateType: "Media",
+			EventType:     eventType,
+			Data:          "{}",
+			Version:       1,
+			CreatedAt:     createdAt,
+		}); err != nil {
+			t.Fatalf("イベントの書き込みに失敗: %v", err)
+		}
+	}
+	stats := func(t *testing.T, s *Server, query string) eventStatsResponse {
+		t.Helper()
+		w := doAdminRequest(t, s, http.MethodGet, "/api/v1/events/stats"+query, "admin-1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		var resp eventStatsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+		}
+		return resp
+	}
+	// countOn は集計結果から指定したイベントタイプ・日付のイベント数を返す。
+	countOn := func(t *testing.T, resp eventStatsResponse, eventType string, day time.Time) int64 {
+		t.Helper()
+		date := day.Format(eventStatsDateLayout)
+		for _, st := range resp.Stats {
+			if st.EventType != eventType {
+				continue
+			}
+			for _, c := range st.Counts {
+				if c.Date == date {
+					return c.Count
+				}
+			}
+			t.Fatalf("%sの集計に%sが含まれていません", eventType, date)
+		}
+		t.Fatalf("%sの集計がありません", eventType)
+		return 0
+	}
+
+	t.Run("正常系_直近の日数のイベント数をタイプ別・UTC基準の日別に集計する", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		now := time.Now().UTC()
+		today := now.Truncate(24 * time.Hour)
+		yesterday := today.AddDate(0, 0, -1)
+		appendAt(t, s, "MediaUploaded", now)
+		appendAt(t, s, "MediaUploaded", now.Add(-time.Millisecond))
+		appendAt(t, s, "MediaProcessingFailed", now)
+		// UTCの日の境界の直前・直後
+		appendAt(t, s, "MediaUploaded", today.Add(-time.Second))
+		appendAt(t, s, "MediaUploaded", yesterday)
+		// 集計期間外
+		appendAt(t, s, "MediaUploaded", yesterday.Add(-time.Second))
+
+		resp := stats(t, s, "?group_by=day&days=2")
+		if resp.Days != 2 || resp.Timezone != "UTC" || resp.From != yesterday.Format(eventStatsDateLayout) {
+			t.Errorf("集計期間 = %+v; 期待値 = 2日間（%s から、UTC）", resp, yesterday.Format(eventStatsDateLayout))
+		}
+		if len(resp.Stats) != 2 || resp.Stats[0].EventType != "MediaProcessingFailed" || resp.Stats[1].EventType != "MediaUploaded" {
+			t.Fatalf("イベントタイプ = %+v; 期待値 = MediaProcessingFailed, MediaUploadedの順", resp.Stats)
+		}
+		if got := countOn(t, resp, "MediaUploaded", today); got != 2 {
+			t.Errorf("当日のMediaUploaded = %d; 期待値 = 2", got)
+		}
+		if got := countOn(t, resp, "MediaUploaded", yesterday); got != 2 {
+			t.Errorf("前日のMediaUploaded = %d; 期待値 = 2", got)
+		}
+		if got := countOn(t, resp, "MediaProcessingFailed", yesterday); got != 0 {
+			t.Errorf("前日のMediaProcessingFailed = %d; 期待値 = 0", got)
+		}
+		if got := resp.Stats[1].Total; got != 4 {
+			t.Errorf("MediaUploadedの合計 = %d; 期待値 = 4", got)
+		}
+	})
+
+	t.Run("正常系_集計結果を短時間キャッシュする", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		appendAt(t, s, "MediaUploaded", time.Now().UTC())
+		first := stats(t, s, "?days=1")
+
+		appendAt(t, s, "MediaUploaded", time.Now().UTC())
+		if cached := stats(t, s, "?days=1"); cached.Stats[0].Total != first.Stats[0].Total || cached.GeneratedAt != first.GeneratedAt {
+			t.Errorf("キャッシュが使われていません: 1回目 = %+v, 2回目 = %+v", first, cached)
+		}
+		// 集計日数が異なる場合は集計し直す
+		if fresh := stats(t, s, "?days=7"); fresh.Stats[0].Total != 2 {
+			t.Errorf("集計日数を変えた場合の合計 = %d; 期待値 = 2", fresh.Stats[0].Total)
+		}
+	})
+
+	t.Run("異常系_不正なパラメータは400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		for _, query := range []string{"?group_by=week", "?days=0", "?days=366", "?days=abc"} {
+			if w := doAdminRequest(t, s, http.MethodGet, "/api/v1/events/stats"+query, "admin-1", nil); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: ステータスコード = %d; 期待値 = %d", query, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("異常系_管理者以外は403を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		if w := doAdminRequest(t, s, http.MethodGet, "/api/v1/events/stats", "user-1", nil); w.Code != http.StatusForbidden {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusForbidden)
+		}
+	})
+}
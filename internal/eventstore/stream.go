@@ -0,0 +1,246 @@
+package eventstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/query"
+)
+
+// 一覧取得APIのストリーミング用クエリ。
+// sqlcの生成コードは全件をスライスに読み込むため、ストリーミングではDBカーソルを直接扱う。
+// 取得カラムはscanEventResponseと一致させること。
+const (
+	// streamEventsColumns はストリーミング時に取得するカラム。
+	streamEventsColumns = `SELECT id, aggregate_id, aggregate_type, event_type, data, version, created_at, metadata FROM events`
+	// streamAllEventsQuery は全イベントを作成日時の昇順で取得する。
+	// ページングの境界でイベントが重複・欠落しないよう、作成日時が同じイベントは追記順（rowid）に並べる。
+	streamAllEventsQuery = streamEventsColumns + ` ORDER BY created_at ASC, rowid ASC`
+	// streamEventsByAggregateIDQuery はAggregateIDのイベントをバージョンの昇順で取得する。
+	streamEventsByAggregateIDQuery = streamEventsColumns + ` WHERE aggregate_id = ? ORDER BY version ASC`
+	// streamEventsByAggregateIDExcludingRetractedQuery はAggregateIDのイベントのうち、撤回されたイベントと
+	// EventRetractedイベント自体を除いたものをバージョンの昇順で取得する。プレースホルダはAggregateIDを2回並べる。
+	streamEventsByAggregateIDExcludingRetractedQuery = streamEventsColumns + ` WHERE aggregate_id = ? AND event_type != '` + string(event.TypeEventRetracted) + `'` +
+		` AND id NOT IN (SELECT json_extract(data, '$.retracted_event_id') FROM events WHERE aggregate_id = ? AND event_type = '` + string(event.TypeEventRetracted) + `')` +
+		` ORDER BY version ASC`
+	// streamEventsByTypeQuery はイベントタイプのイベントを作成日時の昇順で取得する。
+	streamEventsByTypeQuery = streamEventsColumns + ` WHERE event_type = ? ORDER BY created_at ASC`
+	// streamEventsByUserIDQuery はユーザーIDのイベントを作成日時の昇順で取得する。
+	streamEventsByUserIDQuery = streamEventsColumns + ` WHERE user_id = ? ORDER BY created_at ASC`
+	// streamEventsByFilenameQuery はファイル名のイベントを作成日時の昇順で取得する。
+	streamEventsByFilenameQuery = streamEventsColumns + ` WHERE filename = ? ORDER BY created_at ASC`
+	// streamEventsSinceQuery は指定日時より後のイベントを作成日時の昇順で取得する。
+	// limit/offsetでページングしても同じ作成日時のイベントが重複・欠落しないよう、作成日時が同じイベントはバージョンの昇順、
+	// さらに別のAggregateでバージョンも同じ場合は追記順（rowid）に並べて順序を一意に定める。
+	streamEventsSinceQuery = streamEventsColumns + ` WHERE created_at > ? ORDER BY created_at ASC, version ASC, rowid ASC`
+)
+
+const (
+	// defaultEventsPageLimit はページングする一覧取得APIの1ページあたりの既定件数。
+	defaultEventsPageLimit = 100
+	// maxEventsPageLimit はページングする一覧取得APIの1ページあたりの最大件数。
+	maxEventsPageLimit = 1000
+)
+
+// eventsPageSpec はページングする一覧取得APIが受け付けるクエリパラメータ（limit、offset）。
+var eventsPageSpec = query.Spec{
+	DefaultLimit: defaultEventsPageLimit,
+	MaxLimit:     maxEventsPageLimit,
+	Offset:       true,
+}
+
+// eventsSinceQuerySpec は日時指定によるイベント取得が受け付けるクエリパラメータ（limit、offset）。
+// 既存のクライアントとの互換性のため、limitを省略した場合は件数を制限しない。
+var eventsSinceQuerySpec = query.Spec{
+	MaxLimit: maxEventsPageLimit,
+	Offset:   true,
+}
+
+// streamEventsSinceByTypesQuery は指定日時より後の、いずれかのイベントタイプに一致するイベントを
+// 作成日時・バージョン・追記順（rowid）の昇順で取得するクエリを組み立てる。プレースホルダはsinceの後にイベントタイプの件数分並ぶ。
+func streamEventsSinceByTypesQuery(typeCount int) string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", typeCount), ",")
+	return streamEventsColumns + ` WHERE created_at > ? AND event_type IN (` + placeholders + `) ORDER BY created_at ASC, version ASC, rowid ASC`
+}
+
+// withLimitOffset はLIMIT句を含まない一覧取得クエリにpageのlimit/offsetを付与する。
+// limitが0の場合は件数を制限せず、offsetのみを適用する（SQLiteのLIMIT -1は無制限を表す）。
+func withLimitOffset(listQuery string, args []any, page query.Params) (string, []any) {
+	switch {
+	case page.Limit > 0:
+		return listQuery + ` LIMIT ? OFFSET ?`, append(args, page.Limit, page.Offset)
+	case page.Offset > 0:
+		return listQuery + ` LIMIT -1 OFFSET ?`, append(args, page.Offset)
+	default:
+		return listQuery, args
+	}
+}
+
+// streamEvents はクエリ結果のイベントをJSON配列としてレスポンスにストリーミングで書き出す。
+// 全件をメモリに読み込まないため、件数に関わらずメモリ使用量はほぼ一定となる。
+// 書き出し開始後にエラーが発生した場合はステータスコードを変更できないため、
+// ログに記録して書き出しを中断する（クライアントは不完全なJSONとして検知する）。
+func (s *Server) streamEvents(c *gin.Context, query string, args ...any) {
+	rows, err := s.db.QueryContext(c.Request.Context(), query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント取得に失敗しました"})
+		log.Printf("イベント取得エラー: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	if err := writeJSONArray(c.Writer, rows, scanEventResponse); err != nil {
+		log.Printf("イベントのストリーミングエラー: %v", err)
+	}
+}
+
+// eventsPage はページングする一覧取得APIのページを取得するための情報。
+type eventsPage struct {
+	// params はリクエストのlimit/offset。
+	params query.Params
+	// total は条件に一致する全件数。
+	total int64
+	// query はlimit/offsetを付与したページの取得クエリ。
+	query string
+	// args はqueryのプレースホルダに渡す値。
+	args []any
+}
+
+// prepareEventsPage はspecに従ってlimit/offsetを解析し、条件に一致する全件数とページの取得クエリを求める。
+// listQueryはLIMIT句を含まない一覧取得クエリとする。全件数はX-Total-Countヘッダーに設定する。
+// 不正なlimit/offsetの場合は400を、全件数の取得に失敗した場合は500を返してfalseを返す。
+func (s *Server) prepareEventsPage(c *gin.Context, spec query.Spec, listQuery string, args []any) (eventsPage, bool) {
+	params, ok := query.ParseRequest(c, spec)
+	if !ok {
+		return eventsPage{}, false
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(c.Request.Context(), `SELECT COUNT(*) FROM (`+listQuery+`)`, args...).Scan(&total); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント件数の取得に失敗しました"})
+		log.Printf("イベント件数の取得エラー: %v", err)
+		return eventsPage{}, false
+	}
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+
+	pageQuery, pageArgs := withLimitOffset(listQuery, args, params)
+	return eventsPage{params: params, total: total, query: pageQuery, args: pageArgs}, true
+}
+
+// streamEventsPage はspecに従ってlimit/offsetで指定したページのイベントをstreamEventsと同様に書き出す。
+// listQueryはLIMIT句を含まない一覧取得クエリとし、条件に一致する全件数をX-Total-Countヘッダーで返す。
+// 不正なlimit/offsetの場合は400を返す。
+func (s *Server) streamEventsPage(c *gin.Context, spec query.Spec, listQuery string, args ...any) {
+	page, ok := s.prepareEventsPage(c, spec, listQuery, args)
+	if !ok {
+		return
+	}
+	s.streamEvents(c, page.query, page.args...)
+}
+
+// streamEventsPageEnvelope はstreamEventsPageと同様にページのイベントを書き出すが、
+// エンベロープ形式（middleware.Envelope）で全件数と次ページのoffsetを付与する。
+// countとnext_offsetは実際に書き出した件数から求めるため、dataの後に書き出す。
+// 次ページがない場合はnext_offsetを省略する。
+func (s *Server) streamEventsPageEnvelope(c *gin.Context, spec query.Spec, listQuery string, args ...any) {
+	page, ok := s.prepareEventsPage(c, spec, listQuery, args)
+	if !ok {
+		return
+	}
+
+	rows, err := s.db.QueryContext(c.Request.Context(), page.query, page.args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "イベント取得に失敗しました"})
+		log.Printf("イベント取得エラー: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	if _, err := io.WriteString(c.Writer, `{"data":`); err != nil {
+		log.Printf("イベントのストリーミングエラー: %v", err)
+		return
+	}
+	count := 0
+	countingScan := func(rows *sql.Rows) (eventResponse, error) {
+		count++
+		return scanEventResponse(rows)
+	}
+	if err := writeJSONArray(c.Writer, rows, countingScan); err != nil {
+		log.Printf("イベントのストリーミングエラー: %v", err)
+		return
+	}
+
+	trailer := fmt.Sprintf(`,"count":%d,"total":%d`, count, page.total)
+	if next := page.params.Offset + count; count > 0 && int64(next) < page.total {
+		trailer += fmt.Sprintf(`,"next_offset":%d`, next)
+	}
+	if _, err := io.WriteString(c.Writer, trailer+"}"); err != nil {
+		log.Printf("イベントのストリーミングエラー: %v", err)
+	}
+}
+
+// writeJSONArray はDBカーソルから1件ずつ読み出した行をJSON配列としてwに書き出す。
+// 配列の開始 `[`、要素間のカンマ、終了 `]` を手動で書き出し、各要素はjson.Encoderでエンコードする。
+// 行の変換処理をscanで差し替えることで、イベント以外の一覧APIにも利用できる。
+func writeJSONArray[T any](w io.Writer, rows *sql.Rows, scan func(*sql.Rows) (T, error)) error {
+	enc := json.NewEncoder(w)
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return fmt.Errorf("配列開始の書き出しに失敗: %w", err)
+	}
+
+	first := true
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return fmt.Errorf("行の読み取りに失敗: %w", err)
+		}
+
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return fmt.Errorf("区切り文字の書き出しに失敗: %w", err)
+			}
+		}
+		first = false
+
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("要素のエンコードに失敗: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("カーソルの走査に失敗: %w", err)
+	}
+
+	if _, err := io.WriteString(w, "]"); err != nil {
+		return fmt.Errorf("配列終了の書き出しに失敗: %w", err)
+	}
+	return nil
+}
+
+// scanEventResponse はstreamEventsColumnsの1行をJSONレスポンスに変換する。
+func scanEventResponse(rows *sql.Rows) (eventResponse, error) {
+	var (
+		id, aggregateID, aggregateType, eventType, data, metadata string
+		version                                                   int64
+		createdAt                                                 time.Time
+	)
+	if err := rows.Scan(&id, &aggregateID, &aggregateType, &eventType, &data, &version, &createdAt, &metadata); err != nil {
+		return eventResponse{}, err
+	}
+	return toEventResponse(id, aggregateID, aggregateType, eventType, data, version, createdAt, decodeMetadata(metadata)), nil
+}
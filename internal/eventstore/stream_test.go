@@ -0,0 +1,253 @@
+package eventstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "modernc.org/sqlite"
+	eventstoredb "github.com/nao1215/micro/internal/eventstore/db"
+)
+
+// TestWriteJSONArray はJSON配列のストリーミング書き出しを検証する。
+func TestWriteJSONArray(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{name: "0件の場合は空配列を書き出す", query: "SELECT 1 WHERE 0", want: []int{}},
+		{name: "1件の場合はカンマなしで書き出す", query: "SELECT 1", want: []int{1}},
+		{name: "複数件の場合はカンマ区切りで書き出す", query: "SELECT 1 UNION ALL SELECT 2 UNION ALL SELECT 3", want: []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlDB, err := sql.Open("sqlite", ":memory:")
+			if err != nil {
+				t.Fatalf("SQLite接続に失敗: %v", err)
+			}
+			defer sqlDB.Close()
+
+			rows, err := sqlDB.Query(tt.query)
+			if err != nil {
+				t.Fatalf("クエリ実行に失敗: %v", err)
+			}
+			defer rows.Close()
+
+			var sb strings.Builder
+			err = writeJSONArray(&sb, rows, func(r *sql.Rows) (int, error) {
+				var v int
+				err := r.Scan(&v)
+				return v, err
+			})
+			if err != nil {
+				t.Fatalf("writeJSONArray() エラー: %v", err)
+			}
+
+			var got []int
+			if err := json.Unmarshal([]byte(sb.String()), &got); err != nil {
+				t.Fatalf("出力が有効なJSON配列ではない: %v (%q)", err, sb.String())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("要素数 = %d; 期待値 = %d (%q)", len(got), len(tt.want), sb.String())
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("[%d] = %d; 期待値 = %d", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestStreamEventsResponse はストリーミングのレスポンスがクライアントから従来通りパースできることを検証する。
+func TestStreamEventsResponse(t *testing.T) {
+	t.Parallel()
+
+	s := setupTestServer(t)
+	for i := range 3 {
+		appendTestEvent(t, s, fmt.Sprintf("media-%03d", i), "Media", "MediaUploaded", map[string]interface{}{
+			"user_id":  "user-001",
+			"filename": fmt.Sprintf("photo-%d.jpg", i),
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q; 期待値 = application/json", ct)
+	}
+
+	var events []eventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatalf("レスポンスのJSON解析に失敗: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("イベント数 = %d; 期待値 = 3", len(events))
+	}
+	if events[0].AggregateID != "media-000" || events[0].Version != 1 || events[0].CreatedAt == "" {
+		t.Errorf("イベントの内容が不正: %+v", events[0])
+	}
+}
+
+// TestStreamEventsSinceQueryOrder は日時指定によるイベント取得の並び順が一意に定まることを検証する。
+// 作成日時とバージョンが同じ別Aggregateのイベントがlimit/offsetのページ境界で重複・欠落しないよう、
+// 最後のソートキーは追記順（rowid）とする。
+func TestStreamEventsSinceQueryOrder(t *testing.T) {
+	t.Parallel()
+
+	for name, q := range map[string]string{
+		"イベントタイプ指定なし": streamEventsSinceQuery,
+		"イベントタイプ指定あり": streamEventsSinceByTypesQuery(2),
+	} {
+		if !strings.HasSuffix(q, "ORDER BY created_at ASC, version ASC, rowid ASC") {
+			t.Errorf("%s: クエリの並び順が一意ではない: %s", name, q)
+		}
+	}
+}
+
+// heapSamplingWriter はレスポンスを破棄しつつ、書き込み中のヒープ使用量の最大値を記録するResponseWriter。
+// httptest.ResponseRecorderはボディ全体をメモリに保持するため、メモリ計測には使用しない。
+type heapSamplingWriter struct {
+	header http.Header
+	writes int
+	peak   uint64
+}
+
+func (w *heapSamplingWriter) Header() http.Header { return w.header }
+
+func (w *heapSamplingWriter) WriteHeader(int) {}
+
+func (w *heapSamplingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes%500 == 1 {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		if ms.HeapAlloc > w.peak {
+			w.peak = ms.HeapAlloc
+		}
+	}
+	return len(p), nil
+}
+
+// setupBenchServer は指定件数のイベントを投入したベンチマーク用サーバーを構築する。
+func setupBenchServer(b *testing.B, n int) *Server {
+	b.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		b.Fatalf("インメモリSQLiteの接続に失敗: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	b.Cleanup(func() { sqlDB.Close() })
+
+	if err := initSchema(sqlDB); err != nil {
+		b.Fatalf("スキーマ初期化に失敗: %v", err)
+	}
+
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		b.Fatalf("トランザクション開始に失敗: %v", err)
+	}
+	q := eventstoredb.New(tx)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range n {
+		if err := q.AppendEvent(b.Context(), eventstoredb.AppendEventParams{
+			ID:            fmt.Sprintf("event-%06d", i),
+			AggregateID:   fmt.Sprintf("media-%06d", i),
+			AggregateType: "Media",
+			EventType:     "MediaUploaded",
+			Data:          `{"user_id":"user-001","filename":"photo.jpg","content_type":"image/jpeg","size":1048576}`,
+			Version:       1,
+			CreatedAt:     base.Add(time.Duration(i) * time.Second),
+		}); err != nil {
+			b.Fatalf("イベント投入に失敗: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatalf("コミットに失敗: %v", err)
+	}
+
+	s := &Server{
+		router:      gin.New(),
+		port:        "0",
+		queries:     eventstoredb.New(sqlDB),
+		db:          sqlDB,
+		broadcaster: newBroadcaster(),
+	}
+	s.setupRoutes()
+	return s
+}
+
+// BenchmarkGetAllEvents は全イベント（すべてMediaUploaded）取得のメモリ使用量を件数ごとに計測する。
+// 全イベント取得APIはページングするため、ページングしないイベントタイプ別の取得APIで全件をストリーミングする。
+// peak-heap-KB はレスポンス書き出し中のヒープ使用量の最大値（リクエスト開始時点からの増分）。
+// streaming は件数に関わらずほぼ一定、buffered（全件をスライスに読み込む従来方式）は件数に比例して増える。
+//
+//	go test -run '^$' -bench BenchmarkGetAllEvents ./internal/eventstore/
+func BenchmarkGetAllEvents(b *testing.B) {
+	for _, n := range []int{1000, 10000, 50000} {
+		s := setupBenchServer(b, n)
+
+		// 従来方式: sqlcで全件をスライスに読み込んでからエンコードする
+		buffered := func(c *gin.Context) {
+			rows, err := s.queries.GetAllEvents(c.Request.Context())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, toEventResponses(rows))
+		}
+		s.router.GET("/bench/buffered", buffered)
+
+		for _, mode := range []struct {
+			name string
+			path string
+		}{
+			{name: "streaming", path: "/api/v1/events/type/MediaUploaded"},
+			{name: "buffered", path: "/bench/buffered"},
+		} {
+			b.Run(fmt.Sprintf("%s/events=%d", mode.name, n), func(b *testing.B) {
+				b.ReportAllocs()
+
+				var peak uint64
+				for b.Loop() {
+					b.StopTimer()
+					runtime.GC()
+					var ms runtime.MemStats
+					runtime.ReadMemStats(&ms)
+					baseline := ms.HeapAlloc
+					b.StartTimer()
+
+					w := &heapSamplingWriter{header: http.Header{}}
+					req := httptest.NewRequest(http.MethodGet, mode.path, nil)
+					s.router.ServeHTTP(w, req)
+
+					if w.peak > baseline && w.peak-baseline > peak {
+						peak = w.peak - baseline
+					}
+				}
+				b.ReportMetric(float64(peak)/1024, "peak-heap-KB")
+			})
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// sseKeepAliveInterval はServer-Sent Eventsの接続を維持するためにコメント行を送る間隔。
+// イベントが追記されない間もプロキシやロードバランサーにアイドル接続として切断されないようにする。
+const sseKeepAliveInterval = 15 * time.Second
+
+// handleSubscribeAggregate は指定したAggregateに追記されたイベントをServer-Sent Eventsで配信するハンドラを返す。
+// 接続後に追記されたイベントのみを配信するため、それ以前のイベントはGET /api/v1/events/aggregate/:aggregate_idで取得する。
+// クライアントが切断すると購読を解除する。配信が追いつかず購読が切断された場合はレスポンスを終了し、
+// クライアントには再接続と取得APIによる差分の取り直しを求める。
+func (s *Server) handleSubscribeAggregate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		aggregateID := c.Param("aggregate_id")
+		sub := s.broadcaster.subscribe(func(ev *event.Event) bool {
+			return ev.AggregateID == aggregateID
+		})
+		defer s.broadcaster.unsubscribe(sub)
+
+		startServerSentEvents(c)
+		serveServerSentEvents(c, sub, nil, fmt.Sprintf("aggregate_id=%s", aggregateID))
+	}
+}
+
+// handleSubscribeEvents は追記されたイベントをServer-Sent Eventsで配信するハンドラを返す。
+// ProjectorやSagaが/api/v1/events/sinceをポーリングする代わりに使い、追記されたイベントを遅延なく受け取れる。
+// types（従来のtypeも可）にカンマ区切りでイベントタイプを指定すると、いずれかに一致するイベントのみを配信する。
+// sinceを指定した場合は、その日時より後に追記済みのイベントを先に配信してから、接続後に追記されたイベントを配信する。
+// 購読を開始してから追記済みのイベントを読み出すため、その間に追記されたイベントも取りこぼさず、重複して配信もしない。
+func (s *Server) handleSubscribeEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var since time.Time
+		if sinceStr := c.Query("since"); sinceStr != "" {
+			t, err := time.Parse(time.RFC3339, sinceStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "since の形式が不正です（RFC3339形式: 2006-01-02T15:04:05Z）"})
+				return
+			}
+			since = t
+		}
+
+		eventTypes := parseEventTypes(c.Query("types") + "," + c.Query("type"))
+		sub := s.broadcaster.subscribe(func(ev *event.Event) bool {
+			return len(eventTypes) == 0 || slices.Contains(eventTypes, string(ev.EventType))
+		})
+		defer s.broadcaster.unsubscribe(sub)
+
+		startServerSentEvents(c)
+
+		target := fmt.Sprintf("types=%s", strings.Join(eventTypes, ","))
+		var delivered map[string]struct{}
+		if !since.IsZero() {
+			var err error
+			delivered, err = s.replayEventsSince(c, since, eventTypes)
+			if err != nil {
+				log.Printf("追記済みイベントの配信エラー: %s, error=%v", target, err)
+				return
+			}
+		}
+		serveServerSentEvents(c, sub, delivered, target)
+	}
+}
+
+// replayEventsSince はsinceより後に追記済みのイベントを作成日時・バージョンの昇順でServer-Sent Eventsとして書き出し、
+// 書き出したイベントのIDを返す。eventTypesが空の場合は全タイプのイベントを書き出す。
+func (s *Server) replayEventsSince(c *gin.Context, since time.Time, eventTypes []string) (map[string]struct{}, error) {
+	listQuery, args := streamEventsSinceQuery, []any{since}
+	if len(eventTypes) > 0 {
+		listQuery = streamEventsSinceByTypesQuery(len(eventTypes))
+		for _, eventType := range eventTypes {
+			args = append(args, eventType)
+		}
+	}
+
+	rows, err := s.db.QueryContext(c.Request.Context(), listQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("イベントの取得に失敗: %w", err)
+	}
+	defer rows.Close()
+
+	delivered := make(map[string]struct{})
+	for rows.Next() {
+		resp, err := scanEventResponse(rows)
+		if err != nil {
+			return nil, fmt.Errorf("行の読み取りに失敗: %w", err)
+		}
+		if err := writeServerSentEventResponse(c.Writer, resp); err != nil {
+			return nil, err
+		}
+		delivered[resp.ID] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("カーソルの走査に失敗: %w", err)
+	}
+	c.Writer.Flush()
+	return delivered, nil
+}
+
+// startServerSentEvents はServer-Sent Eventsのレスポンスヘッダーを書き出し、クライアントに接続の確立を通知する。
+func startServerSentEvents(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// serveServerSentEvents は購読者に届いたイベントを、クライアントが切断するまで逐次flushしながら配信する。
+// イベントがない間はsseKeepAliveIntervalごとにコメント行を送り、接続を維持する。
+// deliveredに含まれるIDのイベントは配信済みのため送らない。targetはログに出力する購読対象。
+func serveServerSentEvents(c *gin.Context, sub *subscriber, delivered map[string]struct{}, target string) {
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, ok := <-sub.events:
+			if !ok {
+				log.Printf("イベント配信が追いつかないため購読を切断しました: %s", target)
+				return
+			}
+			if _, ok := delivered[ev.ID]; ok {
+				delete(delivered, ev.ID)
+				continue
+			}
+			if err := writeServerSentEvent(c.Writer, ev); err != nil {
+				log.Printf("イベントの配信エラー: %s, error=%v", target, err)
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeServerSentEvent はイベントをServer-Sent Eventsの1件として書き出す。
+func writeServerSentEvent(w io.Writer, ev *event.Event) error {
+	return writeServerSentEventResponse(w, toEventResponse(ev.ID, ev.AggregateID, string(ev.AggregateType), string(ev.EventType), string(ev.Data), ev.Version, ev.CreatedAt, ev.Metadata))
+}
+
+// writeServerSentEventResponse はイベントのJSONレスポンスをServer-Sent Eventsの1件として書き出す。
+// idにイベントID、eventにイベントタイプ、dataに取得APIと同じ形式のJSONを設定する。
+func writeServerSentEventResponse(w io.Writer, resp eventResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("イベントのエンコードに失敗: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", resp.ID, resp.EventType, data); err != nil {
+		return fmt.Errorf("イベントの書き出しに失敗: %w", err)
+	}
+	return nil
+}
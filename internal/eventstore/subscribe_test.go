@@ -0,0 +1,213 @@
+package eventstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// readServerSentEvent はServer-Sent Eventsのストリームから次のイベントを読み取り、イベントタイプとデータを返す。
+// keep-aliveのコメント行は読み飛ばす。
+func readServerSentEvent(t *testing.T, r *bufio.Reader) (string, eventResponse) {
+	t.Helper()
+
+	var (
+		eventType string
+		data      string
+	)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ストリームの読み取りに失敗: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && data != "":
+			var resp eventResponse
+			if err := json.Unmarshal([]byte(data), &resp); err != nil {
+				t.Fatalf("イベントデータのデコードに失敗: %v", err)
+			}
+			return eventType, resp
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// waitSubscribers は購読者数がwantになるまで待つ。
+func waitSubscribers(t *testing.T, b *broadcaster, want int) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for b.count() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("購読者数 = %d; 期待値 = %d", b.count(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHandleSubscribeAggregate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_購読したAggregateのイベントのみを配信し、切断すると購読を解除する", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		ts := httptest.NewServer(s.router)
+		t.Cleanup(ts.Close)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/v1/events/aggregate/agg-target/stream", nil)
+		if err != nil {
+			t.Fatalf("リクエストの作成に失敗: %v", err)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatalf("ストリームへの接続に失敗: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d", resp.StatusCode, http.StatusOK)
+		}
+		if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+			t.Errorf("Content-Type = %q; 期待値 = %q", got, "text/event-stream")
+		}
+		waitSubscribers(t, s.broadcaster, 1)
+
+		// 購読対象のイベントの間に別のAggregateのイベントを追記する
+		for _, aggregateID := range []string{"agg-target", "agg-other", "agg-target"} {
+			if w := appendTestEvent(t, s, aggregateID, "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"}); w.Code != http.StatusCreated {
+				t.Fatalf("イベント追記に失敗: aggregate_id=%s, status=%d", aggregateID, w.Code)
+			}
+		}
+
+		r := bufio.NewReader(resp.Body)
+		for want := int64(1); want <= 2; want++ {
+			eventType, ev := readServerSentEvent(t, r)
+			if ev.AggregateID != "agg-target" {
+				t.Fatalf("購読していないAggregateのイベントが配信されました: %s", ev.AggregateID)
+			}
+			if ev.Version != want {
+				t.Errorf("version = %d; 期待値 = %d", ev.Version, want)
+			}
+			if eventType != "MediaUploaded" {
+				t.Errorf("event = %q; 期待値 = %q", eventType, "MediaUploaded")
+			}
+		}
+
+		cancel()
+		waitSubscribers(t, s.broadcaster, 0)
+	})
+}
+
+func TestHandleSubscribeEvents(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_sinceより後の追記済みイベントに続けて新しいイベントを配信し、typesで絞り込む", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		ts := httptest.NewServer(s.router)
+		t.Cleanup(ts.Close)
+
+		since := time.Now().UTC().Add(-1 * time.Hour).Format(time.RFC3339)
+		appendTestEvent(t, s, "agg-stream-1", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"})
+		appendTestEvent(t, s, "agg-stream-2", "Album", "AlbumCreated", map[string]interface{}{"user_id": "user-1"})
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/v1/events/stream?since="+since+"&types=MediaUploaded,MediaProcessed", nil)
+		if err != nil {
+			t.Fatalf("リクエストの作成に失敗: %v", err)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatalf("ストリームへの接続に失敗: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d", resp.StatusCode, http.StatusOK)
+		}
+		if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+			t.Errorf("Content-Type = %q; 期待値 = %q", got, "text/event-stream")
+		}
+
+		r := bufio.NewReader(resp.Body)
+		if eventType, ev := readServerSentEvent(t, r); eventType != "MediaUploaded" || ev.AggregateID != "agg-stream-1" {
+			t.Fatalf("追記済みイベント = %s %+v; 期待値 = agg-stream-1のMediaUploaded", eventType, ev)
+		}
+		waitSubscribers(t, s.broadcaster, 1)
+
+		appendTestEvent(t, s, "agg-stream-1", "Media", "MediaProcessed", map[string]interface{}{"user_id": "user-1"})
+		appendTestEvent(t, s, "agg-stream-3", "Album", "AlbumCreated", map[string]interface{}{"user_id": "user-1"})
+		appendTestEvent(t, s, "agg-stream-4", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-2"})
+
+		for _, want := range []struct {
+			eventType   string
+			aggregateID string
+		}{
+			{eventType: "MediaProcessed", aggregateID: "agg-stream-1"},
+			{eventType: "MediaUploaded", aggregateID: "agg-stream-4"},
+		} {
+			eventType, ev := readServerSentEvent(t, r)
+			if eventType != want.eventType || ev.AggregateID != want.aggregateID {
+				t.Errorf("新しいイベント = %s %s; 期待値 = %s %s", eventType, ev.AggregateID, want.eventType, want.aggregateID)
+			}
+		}
+
+		cancel()
+		waitSubscribers(t, s.broadcaster, 0)
+	})
+
+	t.Run("正常系_購読開始後に届いた配信済みのイベントは重複して配信しない", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		sub := s.broadcaster.subscribe(func(*event.Event) bool { return true })
+
+		// 追記済みとして配信したイベントが、購読からも届いた状況を再現する
+		sub.events <- &event.Event{ID: "ev-replayed", AggregateID: "agg-1", EventType: "MediaUploaded", Data: []byte(`{}`), Version: 1}
+		sub.events <- &event.Event{ID: "ev-new", AggregateID: "agg-1", EventType: "MediaProcessed", Data: []byte(`{}`), Version: 2}
+		s.broadcaster.unsubscribe(sub)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/events/stream", nil)
+		serveServerSentEvents(c, sub, map[string]struct{}{"ev-replayed": {}}, "types=")
+
+		body := w.Body.String()
+		if strings.Contains(body, "ev-replayed") {
+			t.Errorf("配信済みのイベントが重複して配信されました: %s", body)
+		}
+		if !strings.Contains(body, "id: ev-new\n") {
+			t.Errorf("新しいイベントが配信されていません: %s", body)
+		}
+	})
+
+	t.Run("異常系_sinceの形式が不正な場合は400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events/stream?since=yesterday", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusBadRequest)
+		}
+		if s.broadcaster.count() != 0 {
+			t.Errorf("購読者数 = %d; 期待値 = 0", s.broadcaster.count())
+		}
+	})
+}
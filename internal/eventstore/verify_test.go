@@ -0,0 +1,111 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	eventstoredb "github.com/nao1215/micro/internal/eventstore/db"
+)
+
+// TestHandleVerify は整合性検査ハンドラを検証する。
+func TestHandleVerify(t *testing.T) {
+	t.Parallel()
+
+	// appendRaw は採番を経由せず指定したバージョンでイベントを書き込むヘルパー関数。
+	appendRaw := func(t *testing.T, s *Server, aggregateID string, versions ...int64) {
+		t.Helper()
+		for _, v := range versions {
+			if err := s.queries.AppendEvent(t.Context(), eventstoredb.AppendEventParams{
+				ID:            fmt.Sprintf("%s-v%d", aggregateID, v),
+				AggregateID:   aggregateID,
+				AggregateType: "Media",
+				EventType:     "MediaUploaded",
+				Data:          "{}",
+				Version:       v,
+				CreatedAt:     time.Now().UTC(),
+			}); err != nil {
+				t.Fatalf("イベントの書き込みに失敗: %v", err)
+			}
+		}
+	}
+
+	verify := func(t *testing.T, s *Server) verifyResponse {
+		t.Helper()
+		w := doAdminRequest(t, s, http.MethodGet, "/api/v1/admin/verify", "admin-1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード = %d; 期待値 = %d, body = %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		var resp verifyResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("正常系_連続したバージョンのみの場合は異常なし", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		appendRaw(t, s, "agg-ok", 1, 2, 3)
+		for range 2 {
+			if w := appendTestEvent(t, s, "agg-api", "Media", "MediaUploaded", map[string]interface{}{"user_id": "user-1"}); w.Code != http.StatusCreated {
+				t.Fatalf("イベントの追記に失敗: status = %d", w.Code)
+			}
+		}
+
+		resp := verify(t, s)
+		if !resp.OK || len(resp.Anomalies) != 0 {
+			t.Errorf("ok = %v, anomalies = %+v; 異常なしを期待", resp.OK, resp.Anomalies)
+		}
+		if resp.CheckedAggregates != 2 || resp.CheckedEvents != 5 {
+			t.Errorf("checked_aggregates = %d, checked_events = %d; 期待値 = 2, 5", resp.CheckedAggregates, resp.CheckedEvents)
+		}
+	})
+
+	t.Run("異常系_欠番のあるAggregateを報告する", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		appendRaw(t, s, "agg-ok", 1, 2)
+		appendRaw(t, s, "agg-gap", 1, 2, 4, 6)
+		appendRaw(t, s, "agg-late", 2, 3)
+
+		resp := verify(t, s)
+		if resp.OK {
+			t.Error("ok = true; 異常ありを期待")
+		}
+		if len(resp.Anomalies) != 2 {
+			t.Fatalf("anomalies = %+v; 期待値 = 2件", resp.Anomalies)
+		}
+
+		gap := resp.Anomalies[0]
+		if gap.AggregateID != "agg-gap" || gap.EventCount != 4 || gap.MinVersion != 1 || gap.MaxVersion != 6 {
+			t.Errorf("agg-gapの検査結果 = %+v", gap)
+		}
+		if !slices.Equal(gap.MissingVersions, []int64{3, 5}) {
+			t.Errorf("missing_versions = %v; 期待値 = [3 5]", gap.MissingVersions)
+		}
+		if len(gap.DuplicateVersions) != 0 {
+			t.Errorf("duplicate_versions = %v; 期待値 = []", gap.DuplicateVersions)
+		}
+
+		late := resp.Anomalies[1]
+		if late.AggregateID != "agg-late" || late.MinVersion != 2 || !slices.Equal(late.MissingVersions, []int64{1}) {
+			t.Errorf("agg-lateの検査結果 = %+v", late)
+		}
+	})
+
+	t.Run("異常系_管理者以外は403", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupAdminTestServer(t, "admin-1")
+		w := doAdminRequest(t, s, http.MethodGet, "/api/v1/admin/verify", "user-1", nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("ステータスコード = %d; 期待値 = %d", w.Code, http.StatusForbidden)
+		}
+	})
+}
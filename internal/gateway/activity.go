@@ -0,0 +1,83 @@
+package gateway
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// defaultActivityInterval はユーザーの最終アクティビティ日時を更新する既定の間隔。
+const defaultActivityInterval = 5 * time.Minute
+
+// parseActivityInterval は環境変数USER_ACTIVITY_INTERVAL（例: 5m）を更新間隔に変換する。
+// 空の場合は既定値を返す。
+func parseActivityInterval(v string) (time.Duration, error) {
+	if v == "" {
+		return defaultActivityInterval, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("USER_ACTIVITY_INTERVALは正の期間（例: 5m）で指定してください: %q", v)
+	}
+	return d, nil
+}
+
+// activityThrottle はユーザーごとに最終アクティビティ日時の更新を間引く。
+// API呼び出しのたびにDBを更新すると書き込みが集中するため、前回の更新から一定間隔が経過した場合のみ更新する。
+type activityThrottle struct {
+	// interval は同じユーザーの更新間隔。
+	interval time.Duration
+	// now は現在時刻を返す関数。テスト時に差し替える。
+	now func() time.Time
+
+	mu sync.Mutex
+	// lastUpdated はユーザーIDごとの最終更新時刻。
+	lastUpdated map[string]time.Time
+}
+
+// newActivityThrottle は更新間隔intervalのactivityThrottleを生成する。
+func newActivityThrottle(interval time.Duration) *activityThrottle {
+	return &activityThrottle{
+		interval:    interval,
+		now:         time.Now,
+		lastUpdated: make(map[string]time.Time),
+	}
+}
+
+// shouldUpdate はユーザーの最終アクティビティ日時を更新すべきかを返す。
+// trueを返した場合は更新したものとして記録し、以降interval以内の呼び出しにはfalseを返す。
+func (t *activityThrottle) shouldUpdate(userID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if last, ok := t.lastUpdated[userID]; ok && now.Sub(last) < t.interval {
+		return false
+	}
+	// 間隔を過ぎた記録は不要なため、更新のついでに掃除してマップの肥大化を防ぐ
+	for id, last := range t.lastUpdated {
+		if now.Sub(last) >= t.interval {
+			delete(t.lastUpdated, id)
+		}
+	}
+	t.lastUpdated[userID] = now
+	return true
+}
+
+// trackActivity は認証済みユーザーの最終アクティビティ日時を記録するミドルウェアを返す。
+// 更新はactivityThrottleで間引き、失敗してもリクエストの処理は継続する。
+func (s *Server) trackActivity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID != "" && s.activity.shouldUpdate(userID) {
+			if err := s.queries.UpdateLastActive(c.Request.Context(), userID); err != nil {
+				log.Printf("最終アクティビティ日時の更新に失敗: user_id=%s, error=%v", userID, err)
+			}
+		}
+		c.Next()
+	}
+}
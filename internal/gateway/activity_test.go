@@ -0,0 +1,113 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseActivityInterval(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "", want: defaultActivityInterval},
+		{input: "1m", want: time.Minute},
+		{input: "0s", wantErr: true},
+		{input: "5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseActivityInterval(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期待するエラー有無 %v, 実際のエラー %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("期待する値 %v, 実際の値 %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestActivityThrottle(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	throttle := newActivityThrottle(5 * time.Minute)
+	throttle.now = func() time.Time { return now }
+
+	if !throttle.shouldUpdate("user-1") {
+		t.Error("初回は更新すべき")
+	}
+	if throttle.shouldUpdate("user-1") {
+		t.Error("間隔内の2回目は更新すべきでない")
+	}
+	if !throttle.shouldUpdate("user-2") {
+		t.Error("別ユーザーは独立して更新すべき")
+	}
+
+	now = now.Add(5 * time.Minute)
+	if !throttle.shouldUpdate("user-1") {
+		t.Error("間隔経過後は再び更新すべき")
+	}
+	// user-2の記録は間隔を過ぎたため掃除される
+	if _, ok := throttle.lastUpdated["user-2"]; ok {
+		t.Error("間隔を過ぎた記録が残っている")
+	}
+}
+
+func TestTrackActivity(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+	seedUser(t, s, "user-active", "github", "gh-active", "active@example.com", "アクティブユーザー")
+	token := generateTestJWT(t, "user-active", "active@example.com")
+
+	// lastActiveAt は最終アクティビティ日時を取得する。
+	lastActiveAt := func(t *testing.T) time.Time {
+		t.Helper()
+		user, err := s.queries.GetUserByID(context.Background(), "user-active")
+		if err != nil {
+			t.Fatalf("ユーザーの取得に失敗: %v", err)
+		}
+		return user.LastActiveAt.Time
+	}
+	// setLastActiveAt は最終アクティビティ日時を過去の値に書き換える。
+	setLastActiveAt := func(t *testing.T, v string) {
+		t.Helper()
+		if _, err := s.db.ExecContext(context.Background(), `UPDATE users SET last_active_at = ? WHERE id = ?`, v, "user-active"); err != nil {
+			t.Fatalf("最終アクティビティ日時の更新に失敗: %v", err)
+		}
+	}
+	request := func(t *testing.T) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+	}
+
+	const past = "2000-01-01 00:00:00"
+	pastTime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	setLastActiveAt(t, past)
+	request(t)
+	if got := lastActiveAt(t); got.Equal(pastTime) {
+		t.Fatal("API呼び出しで最終アクティビティ日時が更新されていない")
+	}
+
+	// 間隔内の呼び出しではDBを更新しない
+	setLastActiveAt(t, past)
+	request(t)
+	if got := lastActiveAt(t); !got.Equal(pastTime) {
+		t.Errorf("間隔内の呼び出しで最終アクティビティ日時が更新された: %s", got)
+	}
+}
@@ -0,0 +1,102 @@
+package gateway
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// breakerFailureThreshold はインスタンスを候補から外すまでの連続失敗回数。
+	breakerFailureThreshold = 3
+	// breakerCooldown は候補から外したインスタンスを再び試行するまでの待機時間。
+	breakerCooldown = 30 * time.Second
+)
+
+// circuitBreaker はバックエンドインスタンスごとの連続失敗回数を記録するサーキットブレーカー。
+// 連続失敗が閾値に達したインスタンスはクールダウン期間中プロキシ候補から外す。
+type circuitBreaker struct {
+	// mu はstatesへの並行アクセスを保護するミューテックス。
+	mu sync.Mutex
+	// threshold は回路を開くまでの連続失敗回数。
+	threshold int
+	// cooldown は回路を開いてから再試行を許可するまでの時間。
+	cooldown time.Duration
+	// states はインスタンスURLごとの状態。
+	states map[string]*breakerState
+}
+
+// breakerState は1インスタンスのサーキットブレーカー状態。
+type breakerState struct {
+	// failures は連続失敗回数。
+	failures int
+	// openUntil はこの日時まで回路を開いたままにする。
+	openUntil time.Time
+}
+
+// newCircuitBreaker は新しいサーキットブレーカーを生成する。
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		states:    make(map[string]*breakerState),
+	}
+}
+
+// available はinstancesのうち回路が閉じている（リクエストを送ってよい）インスタンスを順序を保って返す。
+// すべての回路が開いている場合は完全に遮断しないよう、instancesをそのまま返す。
+func (b *circuitBreaker) available(instances []string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	result := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		if st, ok := b.states[instance]; ok && now.Before(st.openUntil) {
+			continue
+		}
+		result = append(result, instance)
+	}
+	if len(result) == 0 {
+		return instances
+	}
+	return result
+}
+
+// recordSuccess はインスタンスへのリクエスト成功を記録し、連続失敗回数をリセットする。
+func (b *circuitBreaker) recordSuccess(instance string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.states, instance)
+}
+
+// recordFailure はインスタンスへのリクエスト失敗を記録する。
+// 連続失敗回数が閾値に達した場合は回路を開く。
+func (b *circuitBreaker) recordFailure(instance string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st, ok := b.states[instance]
+	if !ok {
+		st = &breakerState{}
+		b.states[instance] = st
+	}
+	st.failures++
+	if st.failures >= b.threshold {
+		st.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// splitServiceURLs はカンマ区切りで複数指定されたサービスURLをインスタンスごとに分割する。
+// 例: "http://media-query-1:8082,http://media-query-2:8082"
+func splitServiceURLs(baseURL string) []string {
+	parts := strings.Split(baseURL, ",")
+	urls := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			urls = append(urls, p)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,107 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker はサーキットブレーカーの状態遷移を検証する。
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("連続失敗が閾値未満の場合は候補に残る", func(t *testing.T) {
+		t.Parallel()
+
+		b := newCircuitBreaker(2, time.Minute)
+		b.recordFailure("http://a")
+
+		got := b.available([]string{"http://a", "http://b"})
+		if len(got) != 2 {
+			t.Errorf("候補数: got %d, want 2", len(got))
+		}
+	})
+
+	t.Run("連続失敗が閾値に達したインスタンスは候補から外れる", func(t *testing.T) {
+		t.Parallel()
+
+		b := newCircuitBreaker(2, time.Minute)
+		b.recordFailure("http://a")
+		b.recordFailure("http://a")
+
+		got := b.available([]string{"http://a", "http://b"})
+		if len(got) != 1 || got[0] != "http://b" {
+			t.Errorf("候補: got %v, want [http://b]", got)
+		}
+	})
+
+	t.Run("成功すると連続失敗回数がリセットされる", func(t *testing.T) {
+		t.Parallel()
+
+		b := newCircuitBreaker(2, time.Minute)
+		b.recordFailure("http://a")
+		b.recordSuccess("http://a")
+		b.recordFailure("http://a")
+
+		got := b.available([]string{"http://a"})
+		if len(got) != 1 {
+			t.Errorf("候補数: got %d, want 1", len(got))
+		}
+	})
+
+	t.Run("全インスタンスの回路が開いている場合は全候補を返す", func(t *testing.T) {
+		t.Parallel()
+
+		b := newCircuitBreaker(1, time.Minute)
+		b.recordFailure("http://a")
+		b.recordFailure("http://b")
+
+		got := b.available([]string{"http://a", "http://b"})
+		if len(got) != 2 {
+			t.Errorf("候補数: got %d, want 2", len(got))
+		}
+	})
+
+	t.Run("クールダウン経過後は再び候補に戻る", func(t *testing.T) {
+		t.Parallel()
+
+		b := newCircuitBreaker(1, time.Millisecond)
+		b.recordFailure("http://a")
+		time.Sleep(5 * time.Millisecond)
+
+		got := b.available([]string{"http://a", "http://b"})
+		if len(got) != 2 {
+			t.Errorf("候補数: got %d, want 2", len(got))
+		}
+	})
+}
+
+// TestSplitServiceURLs はカンマ区切りのサービスURL分割を検証する。
+func TestSplitServiceURLs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "単一URLはそのまま1件になる", input: "http://a:8082", want: []string{"http://a:8082"}},
+		{name: "カンマ区切りは複数件に分割される", input: "http://a:8082,http://b:8082", want: []string{"http://a:8082", "http://b:8082"}},
+		{name: "前後の空白と空要素は除去される", input: " http://a:8082 , ,http://b:8082", want: []string{"http://a:8082", "http://b:8082"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := splitServiceURLs(tt.input)
+			if len(got) != len(tt.want) {
+				t.Fatalf("件数: got %d, want %d (%v)", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("[%d]: got %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
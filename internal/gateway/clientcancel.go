@@ -0,0 +1,27 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusClientClosedRequest はクライアントがレスポンスを待たずに切断したことを表すステータスコード。
+// 標準のステータスコードではないが、nginxの慣習に倣ってアクセスログで切断を識別できるようにする。
+const statusClientClosedRequest = 499
+
+// isClientCanceled はプロキシ先との通信エラーがクライアントの切断（リクエストのコンテキストのキャンセル）によるものかを返す。
+// クライアントの切断はプロキシ先の障害ではないため、サーキットブレーカーの失敗として記録せず、フェイルオーバーもしない。
+func isClientCanceled(ctx context.Context, err error) bool {
+	return err != nil && errors.Is(ctx.Err(), context.Canceled)
+}
+
+// abortClientCanceled はクライアントの切断でプロキシを中断したことをログに残し、499を記録してハンドラを打ち切る。
+// クライアントは既に切断しているため、エラーのレスポンスボディは書き込まない。
+func abortClientCanceled(c *gin.Context, target string) {
+	log.Printf("プロキシ中断: クライアントが切断したためバックエンドへのリクエストをキャンセルしました (499): method=%s, path=%s, target=%s",
+		c.Request.Method, c.Request.URL.Path, target)
+	c.AbortWithStatus(statusClientClosedRequest)
+}
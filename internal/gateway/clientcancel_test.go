@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProxyClientCancel(t *testing.T) {
+	t.Parallel()
+
+	// blockingBackend はクライアントの切断でリクエストのコンテキストがキャンセルされるまで応答しないバックエンドを起動する。
+	// リクエストを受け付けるとreceivedに通知し、キャンセルを検知するとcanceledに通知する。
+	blockingBackend := func(t *testing.T, received, canceled chan<- struct{}) string {
+		t.Helper()
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received <- struct{}{}
+			select {
+			case <-r.Context().Done():
+				canceled <- struct{}{}
+			case <-time.After(5 * time.Second):
+				w.WriteHeader(http.StatusOK)
+			}
+		}))
+		t.Cleanup(backend.Close)
+		return backend.URL
+	}
+	// serveCanceled はバックエンドがリクエストを受け付けた時点でクライアント側のコンテキストをキャンセルし、Gatewayのステータスコードを返す。
+	serveCanceled := func(t *testing.T, s *Server, req *http.Request, received <-chan struct{}) int {
+		t.Helper()
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		go func() {
+			<-received
+			cancel()
+		}()
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req.WithContext(ctx))
+		return w.Code
+	}
+	// waitCanceled はバックエンドがリクエストのキャンセルを検知したことを確認する。
+	waitCanceled := func(t *testing.T, canceled <-chan struct{}) {
+		t.Helper()
+		select {
+		case <-canceled:
+		case <-time.After(3 * time.Second):
+			t.Fatal("バックエンドへのリクエストがキャンセルされていません")
+		}
+	}
+
+	t.Run("異常系_プロキシ中にクライアントが切断した場合はバックエンドへのリクエストもキャンセルしフェイルオーバーしない", func(t *testing.T) {
+		t.Parallel()
+
+		received := make(chan struct{}, 1)
+		canceled := make(chan struct{}, 1)
+		first := blockingBackend(t, received, canceled)
+		var secondHits atomic.Int32
+		second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			secondHits.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(second.Close)
+
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaQuery: first + "," + second.URL})
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+
+		if code := serveCanceled(t, s, req, received); code != statusClientClosedRequest {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", statusClientClosedRequest, code)
+		}
+		waitCanceled(t, canceled)
+		if got := secondHits.Load(); got != 0 {
+			t.Errorf("クライアントの切断後に次のインスタンスへフェイルオーバーしています: %d回", got)
+		}
+		if _, ok := s.breaker.states[first]; ok {
+			t.Error("クライアントの切断がサーキットブレーカーの失敗として記録されています")
+		}
+	})
+
+	t.Run("異常系_署名付きURLのダウンロード中にクライアントが切断した場合はバックエンドへのリクエストもキャンセルする", func(t *testing.T) {
+		t.Parallel()
+
+		received := make(chan struct{}, 1)
+		canceled := make(chan struct{}, 1)
+		backend := blockingBackend(t, received, canceled)
+
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaCommand: backend})
+		signed, err := url.Parse(buildSignedDownloadURL(s.signedURLSecret, "media-1", time.Now().Add(time.Minute).Unix()))
+		if err != nil {
+			t.Fatalf("署名付きURLの解析に失敗: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodGet, signed.RequestURI(), nil)
+
+		if code := serveCanceled(t, s, req, received); code != statusClientClosedRequest {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", statusClientClosedRequest, code)
+		}
+		waitCanceled(t, canceled)
+		if _, ok := s.breaker.states[backend]; ok {
+			t.Error("クライアントの切断がサーキットブレーカーの失敗として記録されています")
+		}
+	})
+}
@@ -5,9 +5,17 @@
 package gatewaydb
 
 import (
+	"database/sql"
 	"time"
 )
 
+type RevokedToken struct {
+	Jti       string
+	UserID    string
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
 type User struct {
 	ID             string
 	Provider       string
@@ -17,4 +25,6 @@ type User struct {
 	AvatarUrl      string
 	CreatedAt      time.Time
 	LastLoginAt    time.Time
+	UnlinkedAt     sql.NullTime
+	LastActiveAt   sql.NullTime
 }
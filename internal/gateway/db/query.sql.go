@@ -7,11 +7,12 @@ package gatewaydb
 
 import (
 	"context"
+	"time"
 )
 
 const createUser = `-- name: CreateUser :exec
-INSERT INTO users (id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at)
-VALUES (?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
+INSERT INTO users (id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at, last_active_at)
+VALUES (?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'), datetime('now'))
 `
 
 type CreateUserParams struct {
@@ -35,8 +36,21 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
 	return err
 }
 
+const deleteExpiredRevokedTokens = `-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredRevokedTokens(ctx context.Context, expiresAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredRevokedTokens, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at
+SELECT id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at, unlinked_at, last_active_at
 FROM users
 WHERE id = ?
 `
@@ -53,12 +67,14 @@ func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
 		&i.AvatarUrl,
 		&i.CreatedAt,
 		&i.LastLoginAt,
+		&i.UnlinkedAt,
+		&i.LastActiveAt,
 	)
 	return i, err
 }
 
 const getUserByProvider = `-- name: GetUserByProvider :one
-SELECT id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at
+SELECT id, provider, provider_user_id, email, display_name, avatar_url, created_at, last_login_at, unlinked_at, last_active_at
 FROM users
 WHERE provider = ? AND provider_user_id = ?
 `
@@ -80,13 +96,79 @@ func (q *Queries) GetUserByProvider(ctx context.Context, arg GetUserByProviderPa
 		&i.AvatarUrl,
 		&i.CreatedAt,
 		&i.LastLoginAt,
+		&i.UnlinkedAt,
+		&i.LastActiveAt,
 	)
 	return i, err
 }
 
+const isTokenRevoked = `-- name: IsTokenRevoked :one
+SELECT COUNT(*) FROM revoked_tokens
+WHERE jti = ? AND expires_at > ?
+`
+
+type IsTokenRevokedParams struct {
+	Jti       string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, isTokenRevoked, arg.Jti, arg.ExpiresAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const revokeToken = `-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+VALUES (?, ?, ?, datetime('now'))
+ON CONFLICT(jti) DO NOTHING
+`
+
+type RevokeTokenParams struct {
+	Jti       string
+	UserID    string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) RevokeToken(ctx context.Context, arg RevokeTokenParams) error {
+	_, err := q.db.ExecContext(ctx, revokeToken, arg.Jti, arg.UserID, arg.ExpiresAt)
+	return err
+}
+
+const unlinkUserByProvider = `-- name: UnlinkUserByProvider :execrows
+UPDATE users
+SET unlinked_at = datetime('now')
+WHERE provider = ? AND provider_user_id = ? AND unlinked_at IS NULL
+`
+
+type UnlinkUserByProviderParams struct {
+	Provider       string
+	ProviderUserID string
+}
+
+func (q *Queries) UnlinkUserByProvider(ctx context.Context, arg UnlinkUserByProviderParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unlinkUserByProvider, arg.Provider, arg.ProviderUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateLastActive = `-- name: UpdateLastActive :exec
+UPDATE users
+SET last_active_at = datetime('now')
+WHERE id = ?
+`
+
+func (q *Queries) UpdateLastActive(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, updateLastActive, id)
+	return err
+}
+
 const updateLastLogin = `-- name: UpdateLastLogin :exec
 UPDATE users
-SET last_login_at = datetime('now')
+SET last_login_at = datetime('now'), last_active_at = datetime('now')
 WHERE id = ?
 `
 
@@ -97,7 +179,7 @@ func (q *Queries) UpdateLastLogin(ctx context.Context, id string) error {
 
 const updateUserProfile = `-- name: UpdateUserProfile :exec
 UPDATE users
-SET display_name = ?, avatar_url = ?, last_login_at = datetime('now')
+SET display_name = ?, avatar_url = ?, last_login_at = datetime('now'), last_active_at = datetime('now')
 WHERE id = ?
 `
 
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN unlinked_at DATETIME;
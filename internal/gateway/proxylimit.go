@@ -0,0 +1,207 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultProxyMaxConcurrent はプロキシ先サービスごとに同時に転送するリクエストの既定の上限。
+	defaultProxyMaxConcurrent = 100
+	// defaultProxyMaxQueued は同時転送数の上限に達したときに空きを待たせるリクエストの既定の上限。
+	defaultProxyMaxQueued = 50
+	// defaultProxyQueueTimeout は空きを待つリクエストの既定の最大待ち時間。
+	// 待たせすぎるとクライアント側のタイムアウトと重なるため、短時間で諦めて503を返す。
+	defaultProxyQueueTimeout = time.Second
+	// proxySaturatedRetryAfter は混雑時の503に付けるRetry-Afterヘッダーの値（秒）。
+	proxySaturatedRetryAfter = "1"
+)
+
+// errProxySaturated はプロキシ先サービスの同時転送数と待ち行列がいずれも上限に達していることを表す。
+var errProxySaturated = errors.New("プロキシ先サービスの同時転送数が上限に達しています")
+
+// proxyLimitConfig は1サービス分の同時転送数の制限設定。
+type proxyLimitConfig struct {
+	// maxConcurrent は同時に転送するリクエストの上限。0の場合は制限しない。
+	maxConcurrent int
+	// maxQueued は空きを待たせるリクエストの上限。0の場合は待たせずに503を返す。
+	maxQueued int
+	// queueTimeout は空きを待つ最大時間。
+	queueTimeout time.Duration
+}
+
+// proxyLimiter は1サービス分の同時転送数を制限するセマフォと待ち行列。
+// 急激なアクセス増加でバックエンドへの接続が際限なく増えないよう、上限を超えたリクエストは短時間だけ待たせ、
+// 待ち行列もあふれた場合や待ち時間を過ぎた場合は503を返す。
+type proxyLimiter struct {
+	// slots は転送中のリクエスト数を表すセマフォ。容量が同時転送数の上限。
+	slots chan struct{}
+	// queued は空きを待っているリクエスト数。
+	queued atomic.Int64
+	// maxQueued は空きを待たせるリクエストの上限。
+	maxQueued int64
+	// queueTimeout は空きを待つ最大時間。
+	queueTimeout time.Duration
+}
+
+// newProxyLimiter は新しいproxyLimiterを生成する。同時転送数の上限が0以下の場合はnilを返し、制限しない。
+func newProxyLimiter(cfg proxyLimitConfig) *proxyLimiter {
+	if cfg.maxConcurrent <= 0 {
+		return nil
+	}
+	return &proxyLimiter{
+		slots:        make(chan struct{}, cfg.maxConcurrent),
+		maxQueued:    int64(cfg.maxQueued),
+		queueTimeout: cfg.queueTimeout,
+	}
+}
+
+// acquire は転送枠を1つ確保し、解放する関数を返す。
+// 空きがない場合は待ち行列に入ってqueueTimeoutまで待ち、待ち行列があふれている場合や待ち時間を過ぎた場合はerrProxySaturatedを返す。
+// 待っている間にctxがキャンセルされた場合はctxのエラーを返す。
+// nilのproxyLimiterは制限せずに常に成功する。
+func (l *proxyLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	default:
+	}
+
+	if l.queued.Add(1) > l.maxQueued {
+		l.queued.Add(-1)
+		return nil, errProxySaturated
+	}
+	defer l.queued.Add(-1)
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	case <-timer.C:
+		return nil, errProxySaturated
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release は確保した転送枠を解放する。
+func (l *proxyLimiter) release() {
+	<-l.slots
+}
+
+// proxyLimiters はプロキシ先サービスのURLごとのproxyLimiter。
+// 同じURLを複数のサービスに設定した場合は同じバックエンドとみなし、最初に登録した設定を共有する。
+type proxyLimiters map[string]*proxyLimiter
+
+// newProxyLimiters はサービスのURLと制限設定の組からproxyLimitersを生成する。
+func newProxyLimiters(urls serviceURLConfig, limits map[string]proxyLimitConfig) proxyLimiters {
+	limiters := make(proxyLimiters)
+	for _, svc := range urls.named() {
+		if _, ok := limiters[svc.url]; ok {
+			continue
+		}
+		limiters[svc.url] = newProxyLimiter(limits[svc.name])
+	}
+	return limiters
+}
+
+// forService はbaseURLのサービスのproxyLimiterを返す。制限しない場合はnilを返す。
+func (ls proxyLimiters) forService(baseURL string) *proxyLimiter {
+	return ls[baseURL]
+}
+
+// namedServiceURL は環境変数名の接尾辞（MEDIA_COMMAND等）とサービスURLの組。
+type namedServiceURL struct {
+	// name は環境変数名の接尾辞。
+	name string
+	// url はサービスのURL。
+	url string
+}
+
+// named はサービスURLを環境変数名の接尾辞と組にして、定義順に返す。
+func (u serviceURLConfig) named() []namedServiceURL {
+	return []namedServiceURL{
+		{name: "MEDIA_COMMAND", url: u.MediaCommand},
+		{name: "MEDIA_QUERY", url: u.MediaQuery},
+		{name: "ALBUM", url: u.Album},
+		{name: "NOTIFICATION", url: u.Notification},
+		{name: "EVENTSTORE", url: u.EventStore},
+		{name: "SAGA", url: u.Saga},
+	}
+}
+
+// parseProxyLimits は環境変数からサービスごとの同時転送数の制限設定を読み込む。
+// PROXY_MAX_CONCURRENT・PROXY_MAX_QUEUEDで全サービスの既定値を、
+// PROXY_MAX_CONCURRENT_<サービス>・PROXY_MAX_QUEUED_<サービス>（例: PROXY_MAX_CONCURRENT_MEDIA_QUERY）でサービスごとの値を指定する。
+// 待ち時間はPROXY_QUEUE_TIMEOUTで全サービス共通に指定する。
+func parseProxyLimits(getenv func(string) string) (map[string]proxyLimitConfig, error) {
+	maxConcurrent, err := parseProxyLimitCount("PROXY_MAX_CONCURRENT", getenv("PROXY_MAX_CONCURRENT"), defaultProxyMaxConcurrent)
+	if err != nil {
+		return nil, err
+	}
+	maxQueued, err := parseProxyLimitCount("PROXY_MAX_QUEUED", getenv("PROXY_MAX_QUEUED"), defaultProxyMaxQueued)
+	if err != nil {
+		return nil, err
+	}
+	queueTimeout, err := parseProxyTimeout("PROXY_QUEUE_TIMEOUT", getenv("PROXY_QUEUE_TIMEOUT"), defaultProxyQueueTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make(map[string]proxyLimitConfig)
+	for _, svc := range (serviceURLConfig{}).named() {
+		cfg := proxyLimitConfig{queueTimeout: queueTimeout}
+		key := "PROXY_MAX_CONCURRENT_" + svc.name
+		if cfg.maxConcurrent, err = parseProxyLimitCount(key, getenv(key), maxConcurrent); err != nil {
+			return nil, err
+		}
+		key = "PROXY_MAX_QUEUED_" + svc.name
+		if cfg.maxQueued, err = parseProxyLimitCount(key, getenv(key), maxQueued); err != nil {
+			return nil, err
+		}
+		limits[svc.name] = cfg
+	}
+	return limits, nil
+}
+
+// parseProxyLimitCount は環境変数name（値v）を0以上の件数に変換する。空の場合は既定値を返す。
+func parseProxyLimitCount(name, v string, def int) (int, error) {
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%sは0以上の整数で指定してください: %q", name, v)
+	}
+	return n, nil
+}
+
+// acquireProxySlot はbaseURLのサービスへの転送枠を確保し、解放する関数を返す。
+// 混雑で確保できない場合は503（Retry-After付き）を、待っている間にクライアントが切断した場合は499を書き込み、falseを返す。
+func (s *Server) acquireProxySlot(c *gin.Context, baseURL string) (func(), bool) {
+	release, err := s.proxyLimits.forService(baseURL).acquire(c.Request.Context())
+	if err == nil {
+		return release, true
+	}
+	if isClientCanceled(c.Request.Context(), err) {
+		abortClientCanceled(c, baseURL)
+		return nil, false
+	}
+	log.Printf("プロキシ制限: 同時転送数の上限に達したため503を返します: method=%s, path=%s, target=%s",
+		c.Request.Method, c.Request.URL.Path, baseURL)
+	c.Header("Retry-After", proxySaturatedRetryAfter)
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "内部サービスが混雑しています。しばらくしてから再試行してください"})
+	return nil, false
+}
@@ -0,0 +1,264 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseProxyLimits(t *testing.T) {
+	t.Parallel()
+
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	t.Run("未指定の場合は全サービスに既定値を使う", func(t *testing.T) {
+		t.Parallel()
+
+		limits, err := parseProxyLimits(env(nil))
+		if err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+		want := proxyLimitConfig{maxConcurrent: defaultProxyMaxConcurrent, maxQueued: defaultProxyMaxQueued, queueTimeout: defaultProxyQueueTimeout}
+		for _, svc := range (serviceURLConfig{}).named() {
+			if got := limits[svc.name]; got != want {
+				t.Errorf("%s: 期待する設定 %+v, 実際の設定 %+v", svc.name, want, got)
+			}
+		}
+	})
+
+	t.Run("サービスごとの指定は全体の既定値より優先する", func(t *testing.T) {
+		t.Parallel()
+
+		limits, err := parseProxyLimits(env(map[string]string{
+			"PROXY_MAX_CONCURRENT":             "20",
+			"PROXY_MAX_QUEUED":                 "5",
+			"PROXY_QUEUE_TIMEOUT":              "200ms",
+			"PROXY_MAX_CONCURRENT_MEDIA_QUERY": "50",
+			"PROXY_MAX_QUEUED_ALBUM":           "0",
+		}))
+		if err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+		tests := []struct {
+			name string
+			want proxyLimitConfig
+		}{
+			{name: "MEDIA_QUERY", want: proxyLimitConfig{maxConcurrent: 50, maxQueued: 5, queueTimeout: 200 * time.Millisecond}},
+			{name: "ALBUM", want: proxyLimitConfig{maxConcurrent: 20, maxQueued: 0, queueTimeout: 200 * time.Millisecond}},
+			{name: "SAGA", want: proxyLimitConfig{maxConcurrent: 20, maxQueued: 5, queueTimeout: 200 * time.Millisecond}},
+		}
+		for _, tt := range tests {
+			if got := limits[tt.name]; got != tt.want {
+				t.Errorf("%s: 期待する設定 %+v, 実際の設定 %+v", tt.name, tt.want, got)
+			}
+		}
+	})
+
+	t.Run("不正な値はエラーを返す", func(t *testing.T) {
+		t.Parallel()
+
+		for _, vars := range []map[string]string{
+			{"PROXY_MAX_CONCURRENT": "-1"},
+			{"PROXY_MAX_QUEUED_EVENTSTORE": "many"},
+			{"PROXY_QUEUE_TIMEOUT": "0s"},
+		} {
+			if _, err := parseProxyLimits(env(vars)); err == nil {
+				t.Errorf("%v: エラーが返されなかった", vars)
+			}
+		}
+	})
+}
+
+// blockingBackend はunblockが閉じられるまでレスポンスを返さないバックエンドを起動する。
+// 処理中のリクエスト数と、その最大値を記録する。
+type blockingBackend struct {
+	server   *httptest.Server
+	unblock  chan struct{}
+	inFlight atomic.Int32
+	peak     atomic.Int32
+	hits     atomic.Int32
+}
+
+// newBlockingBackend は新しいblockingBackendを起動する。
+func newBlockingBackend(t *testing.T) *blockingBackend {
+	t.Helper()
+
+	b := &blockingBackend{unblock: make(chan struct{})}
+	b.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.hits.Add(1)
+		n := b.inFlight.Add(1)
+		defer b.inFlight.Add(-1)
+		for {
+			peak := b.peak.Load()
+			if n <= peak || b.peak.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		select {
+		case <-b.unblock:
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"media":[]}`))
+	}))
+	t.Cleanup(b.server.Close)
+	t.Cleanup(b.release)
+	return b
+}
+
+// release はブロックしているリクエストにレスポンスを返させる。
+func (b *blockingBackend) release() {
+	select {
+	case <-b.unblock:
+	default:
+		close(b.unblock)
+	}
+}
+
+// waitFor はcondが満たされるまで待つ。一定時間内に満たされなければテストを失敗させる。
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%sを待機中にタイムアウトしました", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestProxyConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	// serveAsync はメディア一覧の取得をゴルーチンで実行し、ステータスコードをcodesへ送る。
+	serveAsync := func(t *testing.T, s *Server, wg *sync.WaitGroup, codes chan<- int) {
+		token := generateTestJWT(t, "user-123", "test@example.com")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+			codes <- w.Code
+		}()
+	}
+
+	t.Run("上限と待ち行列を超えたリクエストはバックエンドへ送らずに503を返す", func(t *testing.T) {
+		t.Parallel()
+
+		backend := newBlockingBackend(t)
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaQuery: backend.server.URL})
+		s.proxyLimits = newProxyLimiters(s.serviceURLs, map[string]proxyLimitConfig{
+			"MEDIA_QUERY": {maxConcurrent: 2, maxQueued: 1, queueTimeout: 5 * time.Second},
+		})
+		limiter := s.proxyLimits.forService(backend.server.URL)
+
+		var wg sync.WaitGroup
+		codes := make(chan int, 6)
+		// 上限の2件を転送中にする
+		for range 2 {
+			serveAsync(t, s, &wg, codes)
+		}
+		waitFor(t, "2件の転送", func() bool { return backend.inFlight.Load() == 2 })
+		// 1件は空きを待つ
+		serveAsync(t, s, &wg, codes)
+		waitFor(t, "1件の待機", func() bool { return limiter.queued.Load() == 1 })
+		// 待ち行列もあふれたリクエストは即座に503を返す
+		for range 3 {
+			serveAsync(t, s, &wg, codes)
+		}
+		for range 3 {
+			if code := <-codes; code != http.StatusServiceUnavailable {
+				t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusServiceUnavailable, code)
+			}
+		}
+
+		backend.release()
+		wg.Wait()
+		close(codes)
+		for code := range codes {
+			if code != http.StatusOK {
+				t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+			}
+		}
+		if got := backend.hits.Load(); got != 3 {
+			t.Errorf("期待するバックエンドへの転送数 3, 実際の転送数 %d", got)
+		}
+		if got := backend.peak.Load(); got != 2 {
+			t.Errorf("期待する最大同時転送数 2, 実際の最大同時転送数 %d", got)
+		}
+	})
+
+	t.Run("待ち時間を過ぎても空かない場合はRetry-After付きの503を返す", func(t *testing.T) {
+		t.Parallel()
+
+		backend := newBlockingBackend(t)
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaQuery: backend.server.URL})
+		s.proxyLimits = newProxyLimiters(s.serviceURLs, map[string]proxyLimitConfig{
+			"MEDIA_QUERY": {maxConcurrent: 1, maxQueued: 1, queueTimeout: 50 * time.Millisecond},
+		})
+
+		var wg sync.WaitGroup
+		codes := make(chan int, 1)
+		serveAsync(t, s, &wg, codes)
+		waitFor(t, "1件の転送", func() bool { return backend.inFlight.Load() == 1 })
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusServiceUnavailable, w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != proxySaturatedRetryAfter {
+			t.Errorf("期待するRetry-After %q, 実際のRetry-After %q", proxySaturatedRetryAfter, got)
+		}
+
+		backend.release()
+		wg.Wait()
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+	})
+
+	t.Run("上限に達したサービス以外への転送は制限しない", func(t *testing.T) {
+		t.Parallel()
+
+		saturated := newBlockingBackend(t)
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"notifications":[]}`))
+		}))
+		t.Cleanup(other.Close)
+
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaQuery: saturated.server.URL, Notification: other.URL})
+		s.proxyLimits = newProxyLimiters(s.serviceURLs, map[string]proxyLimitConfig{
+			"MEDIA_QUERY":  {maxConcurrent: 1, maxQueued: 0, queueTimeout: time.Second},
+			"NOTIFICATION": {maxConcurrent: 1, maxQueued: 0, queueTimeout: time.Second},
+		})
+
+		var wg sync.WaitGroup
+		codes := make(chan int, 1)
+		serveAsync(t, s, &wg, codes)
+		waitFor(t, "1件の転送", func() bool { return saturated.inFlight.Load() == 1 })
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+
+		saturated.release()
+		wg.Wait()
+	})
+}
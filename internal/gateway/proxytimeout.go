@@ -0,0 +1,82 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+const (
+	// defaultProxyDialTimeout はプロキシ先への接続確立の既定のタイムアウト。
+	// 存在しないホストや停止したインスタンスへの接続を早く諦め、フェイルオーバーや502を返すために短くする。
+	defaultProxyDialTimeout = 5 * time.Second
+	// defaultProxyResponseHeaderTimeout はリクエスト送信後、プロキシ先のレスポンスヘッダーを待つ既定のタイムアウト。
+	// 処理に時間のかかる正当なリクエストを打ち切らないよう、接続タイムアウトより長くする。
+	defaultProxyResponseHeaderTimeout = 30 * time.Second
+)
+
+// parseProxyTimeout は環境変数name（値v、例: 5s, 1m）をタイムアウトに変換する。
+// 空の場合は既定値を返す。
+func parseProxyTimeout(name, v string, def time.Duration) (time.Duration, error) {
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%sは正の期間（例: 5s）で指定してください: %q", name, v)
+	}
+	return d, nil
+}
+
+// newProxyClient はプロキシ先との通信に使用するHTTPクライアントを生成する。
+// 接続確立とレスポンスヘッダー待ちのタイムアウトを個別に設定する。
+// 署名付きURLのダウンロードのようにボディを長時間ストリーミングする場合があるため、全体のタイムアウトは設定しない。
+func newProxyClient(dialTimeout, responseHeaderTimeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.ResponseHeaderTimeout = responseHeaderTimeout
+	return &http.Client{Transport: transport}
+}
+
+// isResponseTimeout はプロキシ先への接続後、レスポンスを待つ間にタイムアウトしたかを返す。
+// 接続の確立に失敗した場合（接続拒否や接続タイムアウト）はfalseを返す。
+func isResponseTimeout(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// proxyUnavailableMessage はプロキシ先と通信できない場合（502・504）にクライアントへ返すメッセージ。
+// どのサービスで何が起きたかは内部の構成の詳細にあたるため、クライアントには返さずログにのみ記録する。
+const proxyUnavailableMessage = "サービスが一時的に利用できません。しばらくしてから再度お試しください"
+
+// proxyErrorStatus はプロキシ先との通信エラーをクライアントへ返すステータスコードに変換する。
+// レスポンス待ちのタイムアウトは504、接続失敗などそれ以外のエラーは502とする。
+func proxyErrorStatus(err error) int {
+	if isResponseTimeout(err) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+// respondProxyError はプロキシ先serviceとの通信エラーを502または504のレスポンスとして返す。
+// バックエンドが返した4xx・5xxはそのまま転送し、この関数はバックエンドからレスポンスを得られなかった場合にのみ使用する。
+// エラーの詳細はCorrelation ID付きでログに残し、クライアントにはサービスが一時的に利用できないことだけを伝える。
+func respondProxyError(c *gin.Context, service string, err error) {
+	status := proxyErrorStatus(err)
+	log.Printf("プロキシエラー: 内部サービスからレスポンスを得られませんでした (%d): service=%s, method=%s, path=%s, correlation_id=%s, error=%v",
+		status, service, c.Request.Method, c.Request.URL.Path, httpclient.CorrelationID(c.Request.Context()), err)
+	c.JSON(status, gin.H{"error": proxyUnavailableMessage})
+}
@@ -0,0 +1,130 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProxyTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "", want: defaultProxyDialTimeout},
+		{input: "2s", want: 2 * time.Second},
+		{input: "0s", wantErr: true},
+		{input: "-1s", wantErr: true},
+		{input: "5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseProxyTimeout("PROXY_DIAL_TIMEOUT", tt.input, defaultProxyDialTimeout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期待するエラー有無 %v, 実際のエラー %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("期待する値 %v, 実際の値 %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestProxyTimeouts(t *testing.T) {
+	t.Parallel()
+
+	const headerTimeout = 200 * time.Millisecond
+
+	// slowBackend はdelayだけ待ってからレスポンスヘッダーを返すバックエンドを起動する。
+	slowBackend := func(t *testing.T, delay time.Duration) string {
+		t.Helper()
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"media":[]}`))
+		}))
+		t.Cleanup(backend.Close)
+		return backend.URL
+	}
+	// proxy はヘッダータイムアウトを設定したGateway経由でメディア一覧を取得し、ステータスコードと所要時間を返す。
+	// 502・504の場合はクライアントに内部の詳細を返していないことも検証する。
+	proxy := func(t *testing.T, backendURL string) (int, time.Duration) {
+		t.Helper()
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaQuery: backendURL})
+		s.proxyClient = newProxyClient(defaultProxyDialTimeout, headerTimeout)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		start := time.Now()
+		s.router.ServeHTTP(w, req)
+		elapsed := time.Since(start)
+
+		if w.Code == http.StatusBadGateway || w.Code == http.StatusGatewayTimeout {
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("レスポンスのパースに失敗: %v", err)
+			}
+			if body["error"] != proxyUnavailableMessage {
+				t.Errorf("error: got %q, want %q", body["error"], proxyUnavailableMessage)
+			}
+			if host := strings.TrimPrefix(backendURL, "http://"); strings.Contains(w.Body.String(), host) {
+				t.Errorf("レスポンスにプロキシ先のアドレスが含まれています: %s", w.Body.String())
+			}
+		}
+		return w.Code, elapsed
+	}
+
+	t.Run("異常系_接続を拒否された場合はタイムアウトを待たずに502を返す", func(t *testing.T) {
+		t.Parallel()
+
+		// リッスンを終了したポートへ接続させる
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("リッスンに失敗: %v", err)
+		}
+		addr := ln.Addr().String()
+		ln.Close()
+
+		code, elapsed := proxy(t, "http://"+addr)
+		if code != http.StatusBadGateway {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusBadGateway, code)
+		}
+		if elapsed >= headerTimeout {
+			t.Errorf("接続拒否がヘッダータイムアウト（%v）以上かかりました: %v", headerTimeout, elapsed)
+		}
+	})
+
+	t.Run("異常系_レスポンスヘッダーがタイムアウトした場合は504を返す", func(t *testing.T) {
+		t.Parallel()
+
+		code, elapsed := proxy(t, slowBackend(t, 5*time.Second))
+		if code != http.StatusGatewayTimeout {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusGatewayTimeout, code)
+		}
+		if elapsed < headerTimeout || elapsed > 2*time.Second {
+			t.Errorf("ヘッダータイムアウト（%v）で打ち切られていません: %v", headerTimeout, elapsed)
+		}
+	})
+
+	t.Run("正常系_ヘッダータイムアウト以内の遅いレスポンスはそのまま返す", func(t *testing.T) {
+		t.Parallel()
+
+		code, _ := proxy(t, slowBackend(t, headerTimeout/4))
+		if code != http.StatusOK {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+	})
+}
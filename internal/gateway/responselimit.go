@@ -0,0 +1,88 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxProxyResponseBytes はプロキシするバックエンドレスポンスの既定の最大サイズ（64MB）。
+// 元ファイルのダウンロード（アップロード上限50MB）も収まるように設定する。
+const defaultMaxProxyResponseBytes int64 = 64 << 20
+
+// errProxyResponseTooLarge はバックエンドのレスポンスが上限サイズを超えたことを示す。
+var errProxyResponseTooLarge = errors.New("内部サービスのレスポンスが上限サイズを超えました")
+
+// parseMaxProxyResponseBytes は環境変数MAX_PROXY_RESPONSE_BYTESの値（バイト数）を上限サイズに変換する。
+// 空の場合は既定値を返す。
+func parseMaxProxyResponseBytes(v string) (int64, error) {
+	if v == "" {
+		return defaultMaxProxyResponseBytes, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("MAX_PROXY_RESPONSE_BYTESは正の整数（バイト数）で指定してください: %q", v)
+	}
+	return n, nil
+}
+
+// readLimitedBody はレスポンスボディを最大limitバイトまで読み取る。
+// limitを超える場合は上限+1バイトまでで読み取りを打ち切り、errProxyResponseTooLargeを返す。
+func readLimitedBody(r io.Reader, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, errProxyResponseTooLarge
+	}
+	return body, nil
+}
+
+// limitedReader はlimitバイトを超えて読み取ろうとした時点でerrProxyResponseTooLargeを返すReader。
+// ストリーミングプロキシで、上限までクライアントへ流してから切断するために使用する。
+type limitedReader struct {
+	// r は読み取り元のReader。
+	r io.Reader
+	// remaining は残りの読み取り可能なバイト数。
+	remaining int64
+}
+
+// Read はio.Readerを実装する。
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// 上限ちょうどで終わっている場合は正常終了とするため、1バイト読めるかを確認する
+		var one [1]byte
+		n, err := l.r.Read(one[:])
+		if n > 0 {
+			return 0, errProxyResponseTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// abortConnection はレスポンスの送信途中でクライアントとの接続を切断する。
+// GinのResponseWriterは書き込み後のHijackを拒否するため、net/httpのResponseWriterを取り出して切断する。
+func abortConnection(c *gin.Context) {
+	w, ok := c.Writer.(interface{ Unwrap() http.ResponseWriter })
+	if !ok {
+		return
+	}
+	conn, _, err := http.NewResponseController(w.Unwrap()).Hijack()
+	if err != nil {
+		log.Printf("接続の切断に失敗: %v", err)
+		return
+	}
+	conn.Close()
+}
@@ -0,0 +1,178 @@
+package gateway
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMaxProxyResponseBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "", want: defaultMaxProxyResponseBytes},
+		{input: "1048576", want: 1 << 20},
+		{input: "0", wantErr: true},
+		{input: "-1", wantErr: true},
+		{input: "10MB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseMaxProxyResponseBytes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期待するエラー有無 %v, 実際のエラー %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("期待する値 %d, 実際の値 %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_上限ちょうどのデータは最後まで読み取れる", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := io.ReadAll(&limitedReader{r: strings.NewReader("0123456789"), remaining: 10})
+		if err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+		if string(got) != "0123456789" {
+			t.Errorf("期待する内容 %q, 実際の内容 %q", "0123456789", got)
+		}
+	})
+
+	t.Run("異常系_上限を超えると上限まで読み取った後にエラーを返す", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := io.ReadAll(&limitedReader{r: strings.NewReader("0123456789"), remaining: 4})
+		if err != errProxyResponseTooLarge {
+			t.Fatalf("期待するエラー %v, 実際のエラー %v", errProxyResponseTooLarge, err)
+		}
+		if string(got) != "0123" {
+			t.Errorf("期待する内容 %q, 実際の内容 %q", "0123", got)
+		}
+	})
+}
+
+func TestProxyResponseLimit(t *testing.T) {
+	t.Parallel()
+
+	body := bytes.Repeat([]byte("a"), 100)
+	newServer := func(t *testing.T, limit int64) *Server {
+		t.Helper()
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body)
+		}))
+		t.Cleanup(backend.Close)
+
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaQuery: backend.URL})
+		s.maxProxyResponseBytes = limit
+		return s
+	}
+
+	t.Run("正常系_上限以内のレスポンスはそのまま返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newServer(t, int64(len(body)))
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+		if w.Body.Len() != len(body) {
+			t.Errorf("期待するボディ長 %d, 実際のボディ長 %d", len(body), w.Body.Len())
+		}
+	})
+
+	t.Run("異常系_上限を超えるレスポンスは502を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newServer(t, int64(len(body))-1)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadGateway {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusBadGateway, w.Code)
+		}
+	})
+}
+
+func TestSignedDownloadResponseLimit(t *testing.T) {
+	t.Parallel()
+
+	file := bytes.Repeat([]byte("x"), 64<<10)
+	newServer := func(t *testing.T, chunked bool) *Server {
+		t.Helper()
+		mediaCommand := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			if !chunked {
+				w.Header().Set("Content-Length", strconv.Itoa(len(file)))
+			}
+			for i := 0; i < len(file); i += 1 << 10 {
+				_, _ = w.Write(file[i : i+1<<10])
+				w.(http.Flusher).Flush()
+			}
+		}))
+		t.Cleanup(mediaCommand.Close)
+
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaCommand: mediaCommand.URL})
+		s.maxProxyResponseBytes = int64(len(file)) / 2
+		return s
+	}
+	downloadURL := func(s *Server) string {
+		return buildSignedDownloadURL(s.signedURLSecret, "media-1", time.Now().Add(time.Hour).Unix())
+	}
+
+	t.Run("異常系_Content-Lengthが上限を超える場合は502を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newServer(t, false)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, downloadURL(s), nil))
+
+		if w.Code != http.StatusBadGateway {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusBadGateway, w.Code)
+		}
+	})
+
+	t.Run("異常系_サイズ不明のレスポンスは上限まで流した後に切断する", func(t *testing.T) {
+		t.Parallel()
+
+		s := newServer(t, true)
+		gateway := httptest.NewServer(s.router)
+		t.Cleanup(gateway.Close)
+
+		resp, err := http.Get(gateway.URL + downloadURL(s))
+		if err != nil {
+			t.Fatalf("リクエストに失敗: %v", err)
+		}
+		defer resp.Body.Close()
+
+		got, err := io.ReadAll(resp.Body)
+		if err == nil {
+			t.Fatal("上限を超えたレスポンスが切断されずに正常終了しました")
+		}
+		if int64(len(got)) > s.maxProxyResponseBytes {
+			t.Errorf("上限（%dバイト）を超えて転送されました: %dバイト", s.maxProxyResponseBytes, len(got))
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	gatewaydb "github.com/nao1215/micro/internal/gateway/db"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// revokedTokenStore はgatewayのDBに記録した失効済みトークンを参照する。
+// middleware.RevocationCheckerとしてJWTAuthに渡す。
+type revokedTokenStore struct {
+	// queries はsqlcが生成したクエリ実行オブジェクト。
+	queries *gatewaydb.Queries
+}
+
+// IsRevoked はトークンID（jti）が失効済みの場合にtrueを返す。
+// トークン自体の有効期限を過ぎたエントリは削除前でも対象外とする。
+func (r *revokedTokenStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	count, err := r.queries.IsTokenRevoked(ctx, gatewaydb.IsTokenRevokedParams{
+		Jti:       jti,
+		ExpiresAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// handleRevokeToken はリクエストに使用したアクセストークンを失効させるハンドラを返す。
+// 漏洩の疑いがあるトークンを有効期限前に無効化するために使用する。
+// 失効の記録はトークン自体の有効期限まで保持し、期限を過ぎた記録は失効のたびに削除する。
+func (s *Server) handleRevokeToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := middleware.GetClaims(c)
+		if claims == nil || claims.ID == "" || claims.ExpiresAt == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "トークンIDを持たないトークンは失効できません。トークンを再発行してください"})
+			return
+		}
+
+		if err := s.queries.RevokeToken(c.Request.Context(), gatewaydb.RevokeTokenParams{
+			Jti:       claims.ID,
+			UserID:    claims.UserID,
+			ExpiresAt: claims.ExpiresAt.UTC(),
+		}); err != nil {
+			log.Printf("トークンの失効に失敗: user_id=%s, error=%v", claims.UserID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンの失効に失敗しました"})
+			return
+		}
+
+		// 期限切れのトークンは署名の検証で拒否されるため、失効の記録は不要になる
+		if n, err := s.queries.DeleteExpiredRevokedTokens(c.Request.Context(), time.Now().UTC()); err != nil {
+			log.Printf("期限切れの失効記録の削除に失敗: %v", err)
+		} else if n > 0 {
+			log.Printf("期限切れの失効記録を削除しました: %d件", n)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "トークンを失効させました",
+			"jti":     claims.ID,
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gatewaydb "github.com/nao1215/micro/internal/gateway/db"
+)
+
+func TestHandleRevokeToken(t *testing.T) {
+	t.Parallel()
+
+	// request はトークンを付けてリクエストを送り、ステータスコードを返す。
+	request := func(t *testing.T, s *Server, method, path, token string) int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		s.router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("正常系_失効させたトークンは以降のリクエストで401を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		seedUser(t, s, "user-revoke", "github", "gh-revoke", "revoke@example.com", "失効ユーザー")
+		leaked := generateTestJWT(t, "user-revoke", "revoke@example.com")
+		other := generateTestJWT(t, "user-revoke", "revoke@example.com")
+
+		if code := request(t, s, http.MethodGet, "/api/v1/me", leaked); code != http.StatusOK {
+			t.Fatalf("失効前のステータスコード: got %d, want %d", code, http.StatusOK)
+		}
+		if code := request(t, s, http.MethodPost, "/auth/revoke", leaked); code != http.StatusOK {
+			t.Fatalf("失効のステータスコード: got %d, want %d", code, http.StatusOK)
+		}
+
+		if code := request(t, s, http.MethodGet, "/api/v1/me", leaked); code != http.StatusUnauthorized {
+			t.Errorf("失効後のステータスコード: got %d, want %d", code, http.StatusUnauthorized)
+		}
+		if code := request(t, s, http.MethodPost, "/auth/revoke", leaked); code != http.StatusUnauthorized {
+			t.Errorf("失効済みトークンでの再失効のステータスコード: got %d, want %d", code, http.StatusUnauthorized)
+		}
+		// 同じユーザーの別のトークンは失効しない
+		if code := request(t, s, http.MethodGet, "/api/v1/me", other); code != http.StatusOK {
+			t.Errorf("別のトークンのステータスコード: got %d, want %d", code, http.StatusOK)
+		}
+	})
+
+	t.Run("正常系_失効時に有効期限を過ぎた失効記録を削除する", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		ctx := context.Background()
+		if err := s.queries.RevokeToken(ctx, gatewaydb.RevokeTokenParams{
+			Jti:       "expired-jti",
+			UserID:    "user-old",
+			ExpiresAt: time.Now().UTC().Add(-time.Hour),
+		}); err != nil {
+			t.Fatalf("失効記録の挿入に失敗: %v", err)
+		}
+
+		token := generateTestJWT(t, "user-new", "new@example.com")
+		if code := request(t, s, http.MethodPost, "/auth/revoke", token); code != http.StatusOK {
+			t.Fatalf("失効のステータスコード: got %d, want %d", code, http.StatusOK)
+		}
+
+		var jtis []string
+		rows, err := s.db.QueryContext(ctx, `SELECT jti FROM revoked_tokens`)
+		if err != nil {
+			t.Fatalf("失効記録の取得に失敗: %v", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var jti string
+			if err := rows.Scan(&jti); err != nil {
+				t.Fatalf("失効記録の読み込みに失敗: %v", err)
+			}
+			jtis = append(jtis, jti)
+		}
+		if len(jtis) != 1 || jtis[0] == "expired-jti" {
+			t.Errorf("失効記録: got %v, want 新しいトークンの記録のみ", jtis)
+		}
+	})
+
+	t.Run("異常系_認証なしの場合は401を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/auth/revoke", nil)
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+}
@@ -3,16 +3,19 @@ package gateway
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	_ "modernc.org/sqlite"
 	gatewaydb "github.com/nao1215/micro/internal/gateway/db"
+	"github.com/nao1215/micro/pkg/httpclient"
 	"github.com/nao1215/micro/pkg/middleware"
 )
 
@@ -28,11 +31,40 @@ type Server struct {
 	db *sql.DB
 	// jwtSecret はJWT署名用の秘密鍵。
 	jwtSecret string
+	// previousJWTSecrets はローテーション前の秘密鍵。発行済みトークンの検証にのみ使用する。
+	previousJWTSecrets []string
 	// serviceURLs は内部サービスのURL。
 	serviceURLs serviceURLConfig
+	// breaker はプロキシ先インスタンスごとのサーキットブレーカー。
+	breaker *circuitBreaker
+	// signedURLTTL は署名付きダウンロードURLの有効期間。
+	signedURLTTL time.Duration
+	// signedURLSecret は署名付きダウンロードURLの署名用の秘密鍵（環境変数SIGNED_URL_SECRET）。JWTの秘密鍵とは共有しない。
+	signedURLSecret string
+	// previousSignedURLSecrets はローテーション前の署名付きURLの秘密鍵。発行済みURLの検証にのみ使用する。
+	previousSignedURLSecrets []string
+	// internalSigningKey はmedia-commandなど内部サービスへのリクエストの署名用の鍵（環境変数INTERNAL_SIGNING_KEY）。
+	// 空の場合は署名しない。
+	internalSigningKey string
+	// maxProxyResponseBytes はプロキシするバックエンドレスポンスの最大サイズ（バイト）。
+	maxProxyResponseBytes int64
+	// webhooks はOAuthプロバイダーからのWebhookの検証設定。
+	webhooks webhookConfig
+	// proxyClient はプロキシ先との通信に使用するHTTPクライアント。
+	// 接続確立とレスポンスヘッダー待ちのタイムアウトを個別に設定する。
+	proxyClient *http.Client
+	// activity はユーザーの最終アクティビティ日時の更新を間引く。
+	activity *activityThrottle
+	// tokenExpiringThreshold はレスポンスにトークンの有効期限が近いことを示すヘッダーを付ける残り有効時間の閾値。
+	// 0の場合はヘッダーを付けない。
+	tokenExpiringThreshold time.Duration
+	// proxyLimits はプロキシ先サービスごとの同時転送数の制限。
+	// 登録されていないサービスへの転送は制限しない。
+	proxyLimits proxyLimiters
 }
 
 // serviceURLConfig は内部サービスのURL設定。
+// 各URLはカンマ区切りで複数インスタンスを指定でき、GETリクエストは5xxや接続エラー時に次のインスタンスへフェイルオーバーする。
 type serviceURLConfig struct {
 	MediaCommand string
 	MediaQuery   string
@@ -42,6 +74,27 @@ type serviceURLConfig struct {
 	Saga         string
 }
 
+// serviceName はbaseURLに対応する内部サービスの名前を返す。ログでプロキシ先を識別するために使用する。
+// 設定にないURLの場合はbaseURLをそのまま返す。
+func (u serviceURLConfig) serviceName(baseURL string) string {
+	switch baseURL {
+	case u.MediaCommand:
+		return "media-command"
+	case u.MediaQuery:
+		return "media-query"
+	case u.Album:
+		return "album"
+	case u.Notification:
+		return "notification"
+	case u.EventStore:
+		return "eventstore"
+	case u.Saga:
+		return "saga"
+	default:
+		return baseURL
+	}
+}
+
 // NewServer は新しいGatewayサーバーを生成する。
 func NewServer(port string) (*Server, error) {
 	sqlDB, err := sql.Open("sqlite", "/data/gateway.db?_journal_mode=WAL&_busy_timeout=5000")
@@ -69,18 +122,77 @@ func NewServer(port string) (*Server, error) {
 
 	frontendURL := getEnvOr("FRONTEND_URL", "http://localhost:3000")
 
+	signedURLTTL, err := parseSignedURLTTL(os.Getenv("SIGNED_URL_TTL"))
+	if err != nil {
+		return nil, err
+	}
+
+	signedURLSecret, err := resolveSignedURLSecret(os.Getenv("SIGNED_URL_SECRET"))
+	if err != nil {
+		return nil, err
+	}
+
+	maxProxyResponseBytes, err := parseMaxProxyResponseBytes(os.Getenv("MAX_PROXY_RESPONSE_BYTES"))
+	if err != nil {
+		return nil, err
+	}
+
+	proxyDialTimeout, err := parseProxyTimeout("PROXY_DIAL_TIMEOUT", os.Getenv("PROXY_DIAL_TIMEOUT"), defaultProxyDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyResponseHeaderTimeout, err := parseProxyTimeout("PROXY_RESPONSE_HEADER_TIMEOUT", os.Getenv("PROXY_RESPONSE_HEADER_TIMEOUT"), defaultProxyResponseHeaderTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	activityInterval, err := parseActivityInterval(os.Getenv("USER_ACTIVITY_INTERVAL"))
+	if err != nil {
+		return nil, err
+	}
+
+	tokenExpiringThreshold, err := parseTokenExpiringThreshold(os.Getenv("TOKEN_EXPIRING_THRESHOLD"))
+	if err != nil {
+		return nil, err
+	}
+
+	proxyLimits, err := parseProxyLimits(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	googleWebhookKey, err := parseGooglePublicKey(os.Getenv("GOOGLE_WEBHOOK_PUBLIC_KEY"))
+	if err != nil {
+		return nil, err
+	}
+
 	router := gin.New()
-	router.Use(middleware.Recovery())
-	router.Use(gin.Logger())
-	router.Use(middleware.CORS([]string{frontendURL}))
+	router.Use(middleware.DefaultChain(middleware.ChainConfig{AllowedOrigins: []string{frontendURL}})...)
 
 	s := &Server{
-		router:      router,
-		port:        port,
-		queries:     gatewaydb.New(sqlDB),
-		db:          sqlDB,
-		jwtSecret:   jwtSecret,
-		serviceURLs: urls,
+		router:                   router,
+		port:                     port,
+		queries:                  gatewaydb.New(sqlDB),
+		db:                       sqlDB,
+		jwtSecret:                jwtSecret,
+		previousJWTSecrets:       middleware.SplitSecrets(os.Getenv("JWT_SECRET_PREVIOUS")),
+		serviceURLs:              urls,
+		breaker:                  newCircuitBreaker(breakerFailureThreshold, breakerCooldown),
+		signedURLTTL:             signedURLTTL,
+		signedURLSecret:          signedURLSecret,
+		previousSignedURLSecrets: middleware.SplitSecrets(os.Getenv("SIGNED_URL_SECRET_PREVIOUS")),
+		internalSigningKey:       os.Getenv("INTERNAL_SIGNING_KEY"),
+		maxProxyResponseBytes:    maxProxyResponseBytes,
+		webhooks: webhookConfig{
+			githubSecret:    os.Getenv("GITHUB_WEBHOOK_SECRET"),
+			googlePublicKey: googleWebhookKey,
+			googleAudience:  os.Getenv("GOOGLE_CLIENT_ID"),
+		},
+		proxyClient:            newProxyClient(proxyDialTimeout, proxyResponseHeaderTimeout),
+		activity:               newActivityThrottle(activityInterval),
+		tokenExpiringThreshold: tokenExpiringThreshold,
+		proxyLimits:            newProxyLimiters(urls, proxyLimits),
 	}
 	s.setupRoutes()
 
@@ -94,6 +206,13 @@ func (s *Server) Run() error {
 
 // setupRoutes はAPIルーティングを設定する。
 func (s *Server) setupRoutes() {
+	jwtAuth := middleware.JWTAuth(s.jwtSecret,
+		middleware.WithPreviousSecrets(s.previousJWTSecrets...),
+		middleware.WithIssuer(middleware.Issuer),
+		middleware.WithAudience(middleware.Audience),
+		middleware.WithRevocationChecker(&revokedTokenStore{queries: s.queries}),
+	)
+
 	// OAuth2認証エンドポイント（認証不要）
 	auth := s.router.Group("/auth")
 	{
@@ -103,24 +222,38 @@ func (s *Server) setupRoutes() {
 		auth.GET("/google/callback", s.handleGoogleCallback())
 		// 開発用トークン発行
 		auth.POST("/dev-token", s.handleDevToken())
+		// 使用中のアクセストークンの失効（認証必須）
+		auth.POST("/revoke", jwtAuth, s.handleRevokeToken())
 	}
 
+	// OAuthプロバイダーからのアカウント変更通知（認証不要 - プロバイダーの署名で検証するため）
+	s.router.POST("/webhooks/:provider", s.handleWebhook())
+
 	// 認証必須のAPIエンドポイント
 	api := s.router.Group("/api/v1")
-	api.Use(middleware.JWTAuth(s.jwtSecret))
+	api.Use(jwtAuth)
+	// トークンの有効期限が近い場合は、クライアントが先回りして更新できるようヘッダーで知らせる
+	api.Use(middleware.TokenExpiryNotice(s.tokenExpiringThreshold))
+	api.Use(s.trackActivity())
 	{
 		// ユーザー情報
 		api.GET("/me", s.handleGetCurrentUser())
+		api.GET("/me/activity", s.handleGetMyActivity())
 
 		// メディア（プロキシ）
 		api.POST("/media", s.handleProxy(s.serviceURLs.MediaCommand, "/api/v1/media"))
 		api.GET("/media", s.handleProxy(s.serviceURLs.MediaQuery, "/api/v1/media"))
 		api.GET("/media/:id", s.handleProxyWithParam(s.serviceURLs.MediaQuery, "/api/v1/media/", "id"))
+		api.GET("/media/:id/srcset", s.handleProxyWithParam(s.serviceURLs.MediaQuery, "/api/v1/media/", "id", "/srcset"))
+		api.GET("/media/:id/status", s.handleProxyWithParam(s.serviceURLs.MediaQuery, "/api/v1/media/", "id", "/status"))
 		api.DELETE("/media/:id", s.handleProxyWithParam(s.serviceURLs.MediaCommand, "/api/v1/media/", "id"))
+		api.POST("/media/:id/signed-url", s.handleIssueSignedURL())
+		api.POST("/media/:id/regenerate-thumbnail", s.handleProxyWithParam(s.serviceURLs.MediaCommand, "/api/v1/media/", "id", "/regenerate-thumbnail"))
 
 		// アルバム（プロキシ）
 		api.POST("/albums", s.handleProxy(s.serviceURLs.Album, "/api/v1/albums"))
 		api.GET("/albums", s.handleProxy(s.serviceURLs.Album, "/api/v1/albums"))
+		api.PUT("/albums/order", s.handleProxy(s.serviceURLs.Album, "/api/v1/albums/order"))
 		api.GET("/albums/:id", s.handleProxyWithParam(s.serviceURLs.Album, "/api/v1/albums/", "id"))
 		api.DELETE("/albums/:id", s.handleProxyWithParam(s.serviceURLs.Album, "/api/v1/albums/", "id"))
 		api.POST("/albums/:id/media", s.handleProxyAlbumMedia())
@@ -128,7 +261,13 @@ func (s *Server) setupRoutes() {
 
 		// 通知
 		api.GET("/notifications", s.handleProxy(s.serviceURLs.Notification, "/api/v1/notifications"))
+		api.GET("/notifications/summary", s.handleProxy(s.serviceURLs.Notification, "/api/v1/notifications/summary"))
+		api.GET("/notifications/:id/deliveries", s.handleProxyWithParam(s.serviceURLs.Notification, "/api/v1/notifications/", "id", "/deliveries"))
 		api.PUT("/notifications/:id/read", s.handleProxyWithParam(s.serviceURLs.Notification, "/api/v1/notifications/", "id", "/read"))
+		api.POST("/notifications/:id/snooze", s.handleProxyWithParam(s.serviceURLs.Notification, "/api/v1/notifications/", "id", "/snooze"))
+		api.DELETE("/notifications/:id/snooze", s.handleProxyWithParam(s.serviceURLs.Notification, "/api/v1/notifications/", "id", "/snooze"))
+		api.POST("/notifications/push-subscription", s.handleProxy(s.serviceURLs.Notification, "/api/v1/notifications/push-subscription"))
+		api.GET("/notifications/push-subscription/vapid-public-key", s.handleProxy(s.serviceURLs.Notification, "/api/v1/notifications/push-subscription/vapid-public-key"))
 
 		// Saga監視
 		api.GET("/sagas", s.handleProxy(s.serviceURLs.Saga, "/api/v1/sagas"))
@@ -140,10 +279,11 @@ func (s *Server) setupRoutes() {
 	// サムネイル画像の取得（認証不要 - img要素から直接参照されるため）
 	s.router.GET("/api/v1/media/:id/thumbnail", s.handleProxyWithParam(s.serviceURLs.MediaCommand, "/api/v1/media/", "id", "/thumbnail"))
 
+	// 署名付きURLによるメディアファイルのダウンロード（認証不要 - 署名と有効期限で検証するため）
+	s.router.GET("/download", s.handleSignedDownload())
+
 	// ヘルスチェック
-	s.router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "gateway"})
-	})
+	s.router.GET("/health", middleware.HealthCheck("gateway", s.db))
 }
 
 // handleDevToken は開発用JWTトークンを発行するハンドラを返す。
@@ -243,6 +383,7 @@ func (s *Server) handleGoogleCallback() gin.HandlerFunc {
 }
 
 // handleGetCurrentUser は認証済みユーザーの情報を返すハンドラを返す。
+// 最終ログイン日時と最終アクティビティ日時（未記録の場合はnull）を含める。
 func (s *Server) handleGetCurrentUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
@@ -256,39 +397,64 @@ func (s *Server) handleGetCurrentUser() gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
 			return
 		}
+		if user.UnlinkedAt.Valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "OAuthプロバイダーとの連携が解除されています。再度ログインしてください"})
+			return
+		}
+
+		var lastActiveAt *string
+		if user.LastActiveAt.Valid {
+			t := user.LastActiveAt.Time.Format(time.RFC3339)
+			lastActiveAt = &t
+		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"id":           user.ID,
-			"email":        user.Email,
-			"display_name": user.DisplayName,
-			"avatar_url":   user.AvatarUrl,
-			"provider":     user.Provider,
+			"id":             user.ID,
+			"email":          user.Email,
+			"display_name":   user.DisplayName,
+			"avatar_url":     user.AvatarUrl,
+			"provider":       user.Provider,
+			"last_login_at":  user.LastLoginAt.Format(time.RFC3339),
+			"last_active_at": lastActiveAt,
 		})
 	}
 }
 
+// handleGetMyActivity は認証済みユーザー自身のアクティビティログ（Event Storeのユーザー別イベント）を返すハンドラを返す。
+// ユーザーIDはJWTのクレームからサーバー側で埋め込み、クエリパラメータやX-User-IDヘッダーでは他のユーザーを指定できない。
+func (s *Server) handleGetMyActivity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+		s.doProxy(c, http.MethodGet, s.serviceURLs.EventStore, "/api/v1/events/user/"+url.PathEscape(userID))
+	}
+}
+
 // handleProxy は指定されたサービスにリクエストをプロキシするハンドラを返す。
 func (s *Server) handleProxy(baseURL, path string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		proxyURL := baseURL + path
+		proxyPath := path
 		if c.Request.URL.RawQuery != "" {
-			proxyURL += "?" + c.Request.URL.RawQuery
+			proxyPath += "?" + c.Request.URL.RawQuery
 		}
-		s.doProxy(c, c.Request.Method, proxyURL)
+		s.doProxy(c, c.Request.Method, baseURL, proxyPath)
 	}
 }
 
 // handleProxyWithParam はURLパラメータを含むプロキシハンドラを返す。
 func (s *Server) handleProxyWithParam(baseURL, pathPrefix, paramName string, pathSuffix ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		proxyURL := baseURL + pathPrefix + c.Param(paramName)
+		proxyPath := pathPrefix + c.Param(paramName)
 		for _, suffix := range pathSuffix {
-			proxyURL += suffix
+			proxyPath += suffix
 		}
 		if c.Request.URL.RawQuery != "" {
-			proxyURL += "?" + c.Request.URL.RawQuery
+			proxyPath += "?" + c.Request.URL.RawQuery
 		}
-		s.doProxy(c, c.Request.Method, proxyURL)
+		s.doProxy(c, c.Request.Method, baseURL, proxyPath)
 	}
 }
 
@@ -296,8 +462,7 @@ func (s *Server) handleProxyWithParam(baseURL, pathPrefix, paramName string, pat
 func (s *Server) handleProxyAlbumMedia() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		albumID := c.Param("id")
-		proxyURL := s.serviceURLs.Album + "/api/v1/albums/" + albumID + "/media"
-		s.doProxy(c, http.MethodPost, proxyURL)
+		s.doProxy(c, http.MethodPost, s.serviceURLs.Album, "/api/v1/albums/"+albumID+"/media")
 	}
 }
 
@@ -306,40 +471,125 @@ func (s *Server) handleProxyAlbumRemoveMedia() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		albumID := c.Param("id")
 		mediaID := c.Param("media_id")
-		proxyURL := s.serviceURLs.Album + "/api/v1/albums/" + albumID + "/media/" + mediaID
-		s.doProxy(c, http.MethodDelete, proxyURL)
+		s.doProxy(c, http.MethodDelete, s.serviceURLs.Album, "/api/v1/albums/"+albumID+"/media/"+mediaID)
 	}
 }
 
 // doProxy はリクエストを内部サービスにプロキシする共通処理。
-// JWTトークンとユーザーIDヘッダーを転送する。
-func (s *Server) doProxy(c *gin.Context, method, url string) {
-	req, err := http.NewRequestWithContext(c.Request.Context(), method, url, c.Request.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "プロキシリクエストの作成に失敗しました"})
+// JWTトークンとユーザーIDヘッダー、指定された場合はIdempotency-Keyヘッダーを転送する。
+// baseURLに複数インスタンスが指定されている場合、冪等なGETに限り5xxや接続エラー時に次の候補へフェイルオーバーする。
+// POST/DELETE等は副作用の重複を避けるため最初の候補にのみ送信する。
+// 全候補が失敗した場合は最後のエラーを返す。
+// レスポンスボディがmaxProxyResponseBytesを超える場合は、メモリ枯渇を防ぐため読み取りを打ち切って502を返す。
+// プロキシ先がレスポンスヘッダー待ちのタイムアウトを超えた場合は504を、接続できない場合は502を返し（respondProxyError）、
+// プロキシ先が返した4xx・5xxはそのステータスで転送する。
+// クライアントが切断した場合はバックエンドへのリクエストもキャンセルし、フェイルオーバーせずに499を記録して打ち切る。
+// プロキシ先サービスの同時転送数が上限に達している場合は短時間だけ空きを待ち、空かなければ503を返す。
+func (s *Server) doProxy(c *gin.Context, method, baseURL, path string) {
+	release, ok := s.acquireProxySlot(c, baseURL)
+	if !ok {
 		return
 	}
+	defer release()
 
-	// 元のリクエストヘッダーを転送
-	req.Header.Set("Content-Type", c.GetHeader("Content-Type"))
-	req.Header.Set("Authorization", c.GetHeader("Authorization"))
-	req.Header.Set("X-User-ID", middleware.GetUserID(c))
+	candidates := s.breaker.available(splitServiceURLs(baseURL))
+	if method != http.MethodGet && len(candidates) > 1 {
+		candidates = candidates[:1]
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": "内部サービスとの通信に失敗しました"})
-		log.Printf("プロキシエラー: url=%s, error=%v", url, err)
+	var (
+		resp    *http.Response
+		lastErr error
+	)
+	for i, instance := range candidates {
+		url := instance + path
+		if i > 0 {
+			log.Printf("プロキシフェイルオーバー: 次のインスタンスで再試行します (%d/%d): url=%s", i+1, len(candidates), url)
+		}
+
+		req, err := http.NewRequestWithContext(c.Request.Context(), method, url, c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "プロキシリクエストの作成に失敗しました"})
+			return
+		}
+
+		// 元のリクエストヘッダーを転送
+		req.Header.Set("Content-Type", c.GetHeader("Content-Type"))
+		req.Header.Set("Authorization", c.GetHeader("Authorization"))
+		req.Header.Set("X-User-ID", middleware.GetUserID(c))
+		// クライアントが指定した、またはmiddleware.Correlationが生成したCorrelation IDを後続のサービスに伝播する
+		req.Header.Set(httpclient.CorrelationIDHeader, httpclient.CorrelationID(c.Request.Context()))
+		// レスポンス形式（JSON/MessagePack）をバックエンドに選ばせるため、Acceptは指定された場合のみ転送
+		if accept := c.GetHeader("Accept"); accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		// 再送時の重複作成を防ぐIdempotency-Keyは指定された場合のみ転送
+		if key := c.GetHeader("Idempotency-Key"); key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+
+		resp, lastErr = s.proxyClient.Do(req)
+		if isClientCanceled(c.Request.Context(), lastErr) {
+			abortClientCanceled(c, url)
+			return
+		}
+		if lastErr == nil && resp.StatusCode < http.StatusInternalServerError {
+			s.breaker.recordSuccess(instance)
+			if i > 0 {
+				log.Printf("プロキシフェイルオーバー成功: url=%s, status=%d", url, resp.StatusCode)
+			}
+			break
+		}
+
+		s.breaker.recordFailure(instance)
+		if lastErr != nil {
+			log.Printf("プロキシエラー: url=%s, error=%v", url, lastErr)
+		} else {
+			log.Printf("プロキシエラー: url=%s, status=%d", url, resp.StatusCode)
+		}
+		// 次の候補がある場合は失敗したレスポンスを破棄する
+		if i < len(candidates)-1 && resp != nil {
+			resp.Body.Close()
+			resp = nil
+		}
+	}
+
+	if lastErr != nil {
+		if len(candidates) > 1 {
+			log.Printf("プロキシフェイルオーバー失敗: 全%dインスタンスで失敗しました", len(candidates))
+		}
+		respondProxyError(c, s.serviceURLs.serviceName(baseURL), lastErr)
 		return
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError && len(candidates) > 1 {
+		log.Printf("プロキシフェイルオーバー失敗: 全%dインスタンスで失敗しました", len(candidates))
+	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readLimitedBody(resp.Body, s.maxProxyResponseBytes)
+	if errors.Is(err, errProxyResponseTooLarge) {
+		log.Printf("プロキシエラー: レスポンスが上限サイズ（%dバイト）を超えました: path=%s", s.maxProxyResponseBytes, path)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "内部サービスのレスポンスが大きすぎます"})
+		return
+	}
+	if isClientCanceled(c.Request.Context(), err) {
+		abortClientCanceled(c, baseURL+path)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "レスポンスの読み取りに失敗しました"})
 		return
 	}
 
+	// Idempotency-Keyによる再送の応答であることをクライアントに伝える
+	if v := resp.Header.Get("Idempotent-Replayed"); v != "" {
+		c.Header("Idempotent-Replayed", v)
+	}
+	// ページングした一覧の全件数をクライアントに伝える
+	if v := resp.Header.Get("X-Total-Count"); v != "" {
+		c.Header("X-Total-Count", v)
+	}
+
 	// レスポンスのContent-Typeに応じてそのまま転送
 	contentType := resp.Header.Get("Content-Type")
 	if contentType == "" {
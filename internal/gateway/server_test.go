@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -8,7 +9,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -24,6 +27,9 @@ func init() {
 // testJWTSecret はテスト用のJWT署名秘密鍵。
 const testJWTSecret = "test-secret-key"
 
+// testSignedURLSecret はテスト用の署名付きURLの秘密鍵。
+const testSignedURLSecret = "test-signed-url-secret"
+
 // newTestServer はテスト用のGatewayサーバーを生成する。
 // インメモリSQLiteを使用し、内部サービスURLはダミー値を設定する。
 func newTestServer(t *testing.T) *Server {
@@ -53,6 +59,13 @@ func newTestServer(t *testing.T) *Server {
 			Notification: "http://localhost:19004",
 			EventStore:   "http://localhost:19005",
 		},
+		breaker:                newCircuitBreaker(breakerFailureThreshold, breakerCooldown),
+		signedURLTTL:           defaultSignedURLTTL,
+		signedURLSecret:        testSignedURLSecret,
+		maxProxyResponseBytes:  defaultMaxProxyResponseBytes,
+		proxyClient:            newProxyClient(defaultProxyDialTimeout, defaultProxyResponseHeaderTimeout),
+		activity:               newActivityThrottle(defaultActivityInterval),
+		tokenExpiringThreshold: defaultTokenExpiringThreshold,
 	}
 	s.setupRoutes()
 
@@ -91,6 +104,13 @@ func newTestServerWithBackend(t *testing.T, backendHandler http.HandlerFunc) (*S
 			Notification: backend.URL,
 			EventStore:   backend.URL,
 		},
+		breaker:                newCircuitBreaker(breakerFailureThreshold, breakerCooldown),
+		signedURLTTL:           defaultSignedURLTTL,
+		signedURLSecret:        testSignedURLSecret,
+		maxProxyResponseBytes:  defaultMaxProxyResponseBytes,
+		proxyClient:            newProxyClient(defaultProxyDialTimeout, defaultProxyResponseHeaderTimeout),
+		activity:               newActivityThrottle(defaultActivityInterval),
+		tokenExpiringThreshold: defaultTokenExpiringThreshold,
 	}
 	s.setupRoutes()
 
@@ -235,6 +255,11 @@ func TestHandleGetCurrentUser(t *testing.T) {
 		if result["provider"] != "github" {
 			t.Errorf("provider: got %q, want %q", result["provider"], "github")
 		}
+		for _, key := range []string{"last_login_at", "last_active_at"} {
+			if v, ok := result[key].(string); !ok || v == "" {
+				t.Errorf("%s: got %v, want 日時", key, result[key])
+			}
+		}
 	})
 
 	t.Run("認証ヘッダーが無い場合は401を返す", func(t *testing.T) {
@@ -293,6 +318,93 @@ func TestHandleGetCurrentUser(t *testing.T) {
 }
 
 // TestHandleGitHubLogin はGitHub OAuth2ログインハンドラのテスト。
+// TestHandleGetMyActivity は自分のアクティビティログの取得を検証する。
+func TestHandleGetMyActivity(t *testing.T) {
+	t.Parallel()
+
+	// eventStoreHandler はユーザーIDごとのイベントを返すEvent Storeのモック。
+	eventStoreHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := strings.CutPrefix(r.URL.Path, "/api/v1/events/user/")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		events := map[string]string{
+			"user-1": `[{"id":"ev-1","aggregate_id":"media-1","event_type":"MediaUploaded"}]`,
+			"user-2": `[{"id":"ev-2","aggregate_id":"media-2","event_type":"MediaUploaded"}]`,
+		}[userID]
+		if events == "" {
+			events = "[]"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(events))
+	})
+
+	getActivity := func(t *testing.T, s *Server, target string, header http.Header) (int, []string) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			return w.Code, nil
+		}
+
+		var events []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+		ids := make([]string, 0, len(events))
+		for _, ev := range events {
+			ids = append(ids, ev.ID)
+		}
+		return w.Code, ids
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+	}{
+		{name: "正常系_自分のイベントだけを返す", target: "/api/v1/me/activity"},
+		{name: "正常系_クエリパラメータでは他のユーザーを指定できない", target: "/api/v1/me/activity?user_id=user-2"},
+		{name: "正常系_X-User-IDヘッダーでは他のユーザーを指定できない", target: "/api/v1/me/activity", header: http.Header{"X-User-Id": {"user-2"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, _ := newTestServerWithBackend(t, eventStoreHandler)
+			header := http.Header{"Authorization": {"Bearer " + generateTestJWT(t, "user-1", "user1@example.com")}}
+			for k, v := range tt.header {
+				header[k] = v
+			}
+
+			code, ids := getActivity(t, s, tt.target, header)
+			if code != http.StatusOK {
+				t.Fatalf("ステータスコード: got %d, want %d", code, http.StatusOK)
+			}
+			if !slices.Equal(ids, []string{"ev-1"}) {
+				t.Errorf("イベント: got %v, want %v", ids, []string{"ev-1"})
+			}
+		})
+	}
+
+	t.Run("異常系_未認証の場合は401を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := newTestServerWithBackend(t, eventStoreHandler)
+		if code, _ := getActivity(t, s, "/api/v1/me/activity", nil); code != http.StatusUnauthorized {
+			t.Errorf("ステータスコード: got %d, want %d", code, http.StatusUnauthorized)
+		}
+	})
+}
+
 func TestHandleGitHubLogin(t *testing.T) {
 	t.Parallel()
 
@@ -505,6 +617,31 @@ func TestHandleProxy(t *testing.T) {
 		}
 	})
 
+	t.Run("バックエンドが5xxを返した場合はそのステータスとボディを転送する", func(t *testing.T) {
+		t.Parallel()
+
+		backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"メンテナンス中です"}`))
+		})
+
+		s, _ := newTestServerWithBackend(t, backendHandler)
+		token := generateTestJWT(t, "err5xx-user", "err5xx@example.com")
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusServiceUnavailable)
+		}
+		if got := w.Body.String(); got != `{"error":"メンテナンス中です"}` {
+			t.Errorf("ボディ: got %s", got)
+		}
+	})
+
 	t.Run("POSTリクエストのボディが転送される", func(t *testing.T) {
 		t.Parallel()
 
@@ -538,6 +675,104 @@ func TestHandleProxy(t *testing.T) {
 		}
 	})
 
+	t.Run("通知のスヌーズと解除がnotificationにプロキシされる", func(t *testing.T) {
+		t.Parallel()
+
+		backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			resp, _ := json.Marshal(map[string]string{"method": r.Method, "path": r.URL.Path, "body": string(body)})
+			_, _ = w.Write(resp)
+		})
+
+		s, _ := newTestServerWithBackend(t, backendHandler)
+		token := generateTestJWT(t, "snooze-user", "snooze@example.com")
+
+		tests := []struct {
+			method string
+			body   string
+		}{
+			{method: http.MethodPost, body: `{"until":"2026-01-01T09:00:00+09:00"}`},
+			{method: http.MethodDelete},
+		}
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/api/v1/notifications/notif-1/snooze", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: ステータスコード: got %d, want %d", tt.method, w.Code, http.StatusOK)
+			}
+			var result map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("レスポンスのパースに失敗: %v", err)
+			}
+			if result["method"] != tt.method || result["path"] != "/api/v1/notifications/notif-1/snooze" || result["body"] != tt.body {
+				t.Errorf("%s: 転送されたリクエスト: got %v", tt.method, result)
+			}
+		}
+	})
+
+	t.Run("Idempotency-Keyと再送の応答ヘッダーが転送される", func(t *testing.T) {
+		t.Parallel()
+
+		backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"key":"%s"}`, r.Header.Get("Idempotency-Key"))))
+		})
+
+		s, _ := newTestServerWithBackend(t, backendHandler)
+		token := generateTestJWT(t, "idem-user", "idem@example.com")
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media", strings.NewReader(`{}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Idempotency-Key", "upload-1")
+		s.router.ServeHTTP(w, req)
+
+		if !strings.Contains(w.Body.String(), `"key":"upload-1"`) {
+			t.Errorf("Idempotency-Keyが転送されていない: got %s", w.Body.String())
+		}
+		if got := w.Header().Get("Idempotent-Replayed"); got != "true" {
+			t.Errorf("Idempotent-Replayed: got %q, want %q", got, "true")
+		}
+	})
+
+	t.Run("AcceptとMessagePackのレスポンスがそのまま転送される", func(t *testing.T) {
+		t.Parallel()
+
+		msgpackBody := []byte{0x81, 0xa2, 'i', 'd', 0xa1, '1'} // {"id":"1"}
+		backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got := r.Header.Get("Accept"); got != "application/msgpack" {
+				t.Errorf("Accept: got %q, want %q", got, "application/msgpack")
+			}
+			w.Header().Set("Content-Type", "application/msgpack; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(msgpackBody)
+		})
+
+		s, _ := newTestServerWithBackend(t, backendHandler)
+		token := generateTestJWT(t, "msgpack-user", "msgpack@example.com")
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/msgpack")
+		s.router.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Content-Type"); got != "application/msgpack; charset=utf-8" {
+			t.Errorf("Content-Type: got %q, want %q", got, "application/msgpack; charset=utf-8")
+		}
+		if !bytes.Equal(w.Body.Bytes(), msgpackBody) {
+			t.Errorf("ボディ: got %x, want %x", w.Body.Bytes(), msgpackBody)
+		}
+	})
+
 	t.Run("認証なしのプロキシリクエストは401を返す", func(t *testing.T) {
 		t.Parallel()
 
@@ -557,6 +792,175 @@ func TestHandleProxy(t *testing.T) {
 	})
 }
 
+// newTestServerWithURLs は指定したサービスURL設定でルーティングし直したテスト用Gatewayサーバーを生成する。
+func newTestServerWithURLs(t *testing.T, urls serviceURLConfig) *Server {
+	t.Helper()
+
+	s := newTestServer(t)
+	s.router = gin.New()
+	s.serviceURLs = urls
+	s.setupRoutes()
+	return s
+}
+
+// TestProxyFailover は複数インスタンス指定時のフェイルオーバーのテスト。
+func TestProxyFailover(t *testing.T) {
+	t.Parallel()
+
+	t.Run("GETで最初のインスタンスが5xxを返した場合は次のインスタンスの結果を返す", func(t *testing.T) {
+		t.Parallel()
+
+		var firstHits atomic.Int32
+		first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			firstHits.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		t.Cleanup(first.Close)
+		second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"instance":"second"}`))
+		}))
+		t.Cleanup(second.Close)
+
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaQuery: first.URL + "," + second.URL})
+		token := generateTestJWT(t, "failover-user", "failover@example.com")
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+		if !strings.Contains(w.Body.String(), "second") {
+			t.Errorf("2番目のインスタンスの結果が返されていない: %s", w.Body.String())
+		}
+		if firstHits.Load() != 1 {
+			t.Errorf("最初のインスタンスへのリクエスト回数: got %d, want 1", firstHits.Load())
+		}
+	})
+
+	t.Run("GETで最初のインスタンスに接続できない場合は次のインスタンスにフェイルオーバーする", func(t *testing.T) {
+		t.Parallel()
+
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+		downURL := down.URL
+		down.Close()
+
+		up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"instance":"up"}`))
+		}))
+		t.Cleanup(up.Close)
+
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaQuery: downURL + ", " + up.URL})
+		token := generateTestJWT(t, "failover-user", "failover@example.com")
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("GETで全インスタンスが5xxを返した場合は最後のエラーを返す", func(t *testing.T) {
+		t.Parallel()
+
+		first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		t.Cleanup(first.Close)
+		second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"unavailable"}`))
+		}))
+		t.Cleanup(second.Close)
+
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaQuery: first.URL + "," + second.URL})
+		token := generateTestJWT(t, "failover-user", "failover@example.com")
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusServiceUnavailable)
+		}
+	})
+
+	t.Run("POSTは5xxでもフェイルオーバーしない", func(t *testing.T) {
+		t.Parallel()
+
+		first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		t.Cleanup(first.Close)
+		var secondHits atomic.Int32
+		second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			secondHits.Add(1)
+			w.WriteHeader(http.StatusCreated)
+		}))
+		t.Cleanup(second.Close)
+
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaCommand: first.URL + "," + second.URL})
+		token := generateTestJWT(t, "failover-user", "failover@example.com")
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media", strings.NewReader(`{}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusInternalServerError)
+		}
+		if secondHits.Load() != 0 {
+			t.Errorf("2番目のインスタンスにリクエストが送られた: %d回", secondHits.Load())
+		}
+	})
+
+	t.Run("連続失敗で回路が開いたインスタンスは候補から外れる", func(t *testing.T) {
+		t.Parallel()
+
+		var firstHits atomic.Int32
+		first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			firstHits.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		t.Cleanup(first.Close)
+		second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(second.Close)
+
+		s := newTestServerWithURLs(t, serviceURLConfig{MediaQuery: first.URL + "," + second.URL})
+		token := generateTestJWT(t, "failover-user", "failover@example.com")
+
+		for i := 0; i < breakerFailureThreshold+2; i++ {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("%d回目のステータスコード: got %d, want %d", i+1, w.Code, http.StatusOK)
+			}
+		}
+
+		if firstHits.Load() != breakerFailureThreshold {
+			t.Errorf("最初のインスタンスへのリクエスト回数: got %d, want %d", firstHits.Load(), breakerFailureThreshold)
+		}
+	})
+}
+
 // TestGatewayHealthCheck はヘルスチェックエンドポイントのテスト。
 func TestGatewayHealthCheck(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,279 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/httpclient"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// defaultSignedURLTTL は署名付きダウンロードURLの既定の有効期間。
+// メールのリンク等から後日ダウンロードされることを想定し、長めに設定する。
+const defaultSignedURLTTL = 24 * time.Hour
+
+var (
+	// errSignedURLExpired は署名付きURLの有効期限が切れていることを示す。
+	errSignedURLExpired = errors.New("署名付きURLの有効期限が切れています")
+	// errSignedURLInvalid は署名付きURLの署名またはパラメータが不正であることを示す。
+	errSignedURLInvalid = errors.New("署名付きURLの署名が不正です")
+)
+
+// parseSignedURLTTL は環境変数SIGNED_URL_TTLの値（例: 24h, 30m）を有効期間に変換する。
+// 空の場合は既定値を返す。
+func parseSignedURLTTL(v string) (time.Duration, error) {
+	if v == "" {
+		return defaultSignedURLTTL, nil
+	}
+	ttl, err := time.ParseDuration(v)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("SIGNED_URL_TTLは正の期間（例: 24h）で指定してください: %q", v)
+	}
+	return ttl, nil
+}
+
+// resolveSignedURLSecret は環境変数SIGNED_URL_SECRETの値を署名付きURLの秘密鍵として返す。
+// 空の場合はJWTの秘密鍵と共有しないよう、起動ごとに乱数の秘密鍵を生成する。
+// その場合は再起動後や別のインスタンスでは発行済みのURLを検証できないため、本番環境では設定すること。
+func resolveSignedURLSecret(v string) (string, error) {
+	if v != "" {
+		return v, nil
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("署名付きURLの秘密鍵の生成に失敗: %w", err)
+	}
+	log.Printf("警告: SIGNED_URL_SECRETが未設定のため、起動ごとの秘密鍵で署名付きURLを発行します。再起動後や別のインスタンスでは発行済みのURLを使用できません")
+	return hex.EncodeToString(secret), nil
+}
+
+// signDownload はメディアIDと有効期限（Unix秒）に対するHMAC-SHA256署名を16進文字列で返す。
+func signDownload(secret, mediaID string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%d", mediaID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// buildSignedDownloadURL は署名付きダウンロードURL（/download?media=...&expires=...&sig=...）を組み立てる。
+func buildSignedDownloadURL(secret, mediaID string, expires int64) string {
+	q := url.Values{}
+	q.Set("media", mediaID)
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("sig", signDownload(secret, mediaID, expires))
+	return "/download?" + q.Encode()
+}
+
+// verifySignedDownload は署名付きダウンロードURLのクエリパラメータを検証する。
+// secretsのいずれかによる署名と一致し、有効期限がnowより後であればnilを返す。
+// 秘密鍵のローテーション後も発行済みのURLを使えるよう、ローテーション前の秘密鍵も渡す。
+func verifySignedDownload(secrets []string, mediaID, expiresStr, sig string, now time.Time) error {
+	if mediaID == "" || expiresStr == "" || sig == "" {
+		return errSignedURLInvalid
+	}
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return errSignedURLInvalid
+	}
+	if !slices.ContainsFunc(secrets, func(secret string) bool {
+		return hmac.Equal([]byte(sig), []byte(signDownload(secret, mediaID, expires)))
+	}) {
+		return errSignedURLInvalid
+	}
+	if !now.Before(time.Unix(expires, 0)) {
+		return errSignedURLExpired
+	}
+	return nil
+}
+
+// signedURLResponse は署名付きURL発行のJSONレスポンス構造。
+type signedURLResponse struct {
+	// URL は認証なしでダウンロードできる署名付きURL。
+	URL string `json:"url"`
+	// ExpiresAt はURLの有効期限。
+	ExpiresAt string `json:"expires_at"`
+}
+
+// mediaOwner は所有者確認のためにmedia-queryから取得するメディア情報。
+type mediaOwner struct {
+	// UserID はメディアをアップロードしたユーザーのID。
+	UserID string `json:"user_id"`
+	// Status はメディアの状態。
+	Status string `json:"status"`
+}
+
+// handleIssueSignedURL はメディアの署名付きダウンロードURLを発行するハンドラを返す。
+// media-queryのRead Modelで所有者を確認し、所有者以外には403を返す。
+func (s *Server) handleIssueSignedURL() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+		mediaID := c.Param("id")
+
+		resp, err := s.getFromService(c.Request.Context(), s.serviceURLs.MediaQuery, "/api/v1/media/"+url.PathEscape(mediaID), c.GetHeader("Authorization"))
+		if err != nil {
+			if isClientCanceled(c.Request.Context(), err) {
+				abortClientCanceled(c, s.serviceURLs.MediaQuery)
+				return
+			}
+			respondProxyError(c, "media-query", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+		case http.StatusNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "メディアが見つかりません"})
+			return
+		default:
+			log.Printf("メディア情報の取得エラー: status=%d", resp.StatusCode)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "メディア情報の取得に失敗しました"})
+			return
+		}
+
+		var owner mediaOwner
+		if err := json.NewDecoder(resp.Body).Decode(&owner); err != nil {
+			log.Printf("メディア情報のデコードエラー: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "メディア情報の取得に失敗しました"})
+			return
+		}
+		if owner.Status == "deleted" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "メディアが見つかりません"})
+			return
+		}
+		if owner.UserID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "このメディアへのアクセス権がありません"})
+			return
+		}
+
+		expiresAt := time.Now().Add(s.signedURLTTL).Truncate(time.Second)
+		c.JSON(http.StatusCreated, signedURLResponse{
+			URL:       buildSignedDownloadURL(s.signedURLSecret, mediaID, expiresAt.Unix()),
+			ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+		})
+	}
+}
+
+// handleSignedDownload は署名付きURLを検証し、media-commandの元ファイルをストリーミングで返すハンドラを返す。
+// 認証は不要で、署名不正・期限切れの場合は403を返す。
+// media-commandの元ファイル取得APIは内部APIのため、INTERNAL_SIGNING_KEYで署名して呼び出す（getFromService）。
+func (s *Server) handleSignedDownload() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mediaID := c.Query("media")
+		secrets := append([]string{s.signedURLSecret}, s.previousSignedURLSecrets...)
+		if err := verifySignedDownload(secrets, mediaID, c.Query("expires"), c.Query("sig"), time.Now()); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		release, ok := s.acquireProxySlot(c, s.serviceURLs.MediaCommand)
+		if !ok {
+			return
+		}
+		defer release()
+
+		resp, err := s.getFromService(c.Request.Context(), s.serviceURLs.MediaCommand, "/api/v1/media/"+url.PathEscape(mediaID)+"/file", "")
+		if err != nil {
+			if isClientCanceled(c.Request.Context(), err) {
+				abortClientCanceled(c, s.serviceURLs.MediaCommand)
+				return
+			}
+			respondProxyError(c, "media-command", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			if resp.StatusCode == http.StatusNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "メディアファイルが見つかりません"})
+				return
+			}
+			log.Printf("ダウンロードプロキシエラー: status=%d", resp.StatusCode)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "メディアファイルの取得に失敗しました"})
+			return
+		}
+
+		if resp.ContentLength > s.maxProxyResponseBytes {
+			log.Printf("ダウンロードプロキシエラー: ファイルが上限サイズ（%dバイト）を超えています: size=%d", s.maxProxyResponseBytes, resp.ContentLength)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "内部サービスのレスポンスが大きすぎます"})
+			return
+		}
+
+		// ファイル全体をメモリに載せないよう、レスポンスボディをそのままクライアントへ流す
+		extraHeaders := map[string]string{}
+		if v := resp.Header.Get("Content-Disposition"); v != "" {
+			extraHeaders["Content-Disposition"] = v
+		}
+		body := &limitedReader{r: resp.Body, remaining: s.maxProxyResponseBytes}
+		c.DataFromReader(http.StatusOK, resp.ContentLength, resp.Header.Get("Content-Type"), body, extraHeaders)
+
+		// サイズ不明のレスポンスが上限を超えた場合、送信済みのヘッダーは取り消せないため接続を切断し、
+		// クライアントに不完全なファイルを正常終了として受け取らせない
+		if err := c.Errors.Last(); err != nil && errors.Is(err.Err, errProxyResponseTooLarge) {
+			log.Printf("ダウンロードプロキシエラー: レスポンスが上限サイズ（%dバイト）を超えたため切断します: media_id=%s", s.maxProxyResponseBytes, mediaID)
+			abortConnection(c)
+		}
+	}
+}
+
+// getFromService は内部サービスにGETリクエストを送信する。
+// doProxyと同様にサーキットブレーカーで候補を絞り、5xxや接続エラー時は次のインスタンスへフェイルオーバーする。
+// ctxがキャンセルされた場合（クライアントの切断）はブレーカーに失敗を記録せず、フェイルオーバーせずにエラーを返す。
+// INTERNAL_SIGNING_KEYが設定されている場合はサービス間リクエストの署名を付与する。
+// 成功時のレスポンスボディのクローズは呼び出し元が行う。
+func (s *Server) getFromService(ctx context.Context, baseURL, path, authorization string) (*http.Response, error) {
+	candidates := s.breaker.available(splitServiceURLs(baseURL))
+
+	var lastErr error
+	for _, instance := range candidates {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, instance+path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("リクエストの作成に失敗: %w", err)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		if s.internalSigningKey != "" {
+			if err := httpclient.SignRequest(req, s.internalSigningKey, nil); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := s.proxyClient.Do(req)
+		if isClientCanceled(ctx, err) {
+			return nil, fmt.Errorf("%s%sへのリクエストがキャンセルされました: %w", instance, path, ctx.Err())
+		}
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			s.breaker.recordSuccess(instance)
+			return resp, nil
+		}
+
+		s.breaker.recordFailure(instance)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		lastErr = fmt.Errorf("status=%d", resp.StatusCode)
+	}
+	if lastErr == nil {
+		lastErr = errors.New("利用可能なインスタンスがありません")
+	}
+	return nil, fmt.Errorf("%s%sへのリクエストに失敗: %w", baseURL, path, lastErr)
+}
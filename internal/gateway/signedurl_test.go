@@ -0,0 +1,266 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// newSignedURLTestServer はmedia-queryとmedia-commandのモックを持つテスト用Gatewayサーバーを生成する。
+// media-queryは"media-1"をuser-123の所有として返し、media-commandは"media-1"の元ファイルを返す。
+func newSignedURLTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	mediaQuery := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/media/media-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"id": "media-1", "user_id": "user-123", "status": "processed"})
+	}))
+	t.Cleanup(mediaQuery.Close)
+
+	mediaCommand := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/media/media-1/file" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Disposition", `attachment; filename="photo.png"`)
+		w.Write([]byte("original-bytes"))
+	}))
+	t.Cleanup(mediaCommand.Close)
+
+	return newTestServerWithURLs(t, serviceURLConfig{
+		MediaCommand: mediaCommand.URL,
+		MediaQuery:   mediaQuery.URL,
+	})
+}
+
+// issueSignedURL は署名付きURL発行APIを呼び出し、レスポンスを返す。
+func issueSignedURL(t *testing.T, s *Server, mediaID, userID string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/media/"+mediaID+"/signed-url", nil)
+	req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, userID, "test@example.com"))
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSignedURL(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_所有者が発行したURLで認証なしにダウンロードできる", func(t *testing.T) {
+		t.Parallel()
+
+		s := newSignedURLTestServer(t)
+		w := issueSignedURL(t, s, "media-1", "user-123")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var resp signedURLResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		if !strings.HasPrefix(resp.URL, "/download?") {
+			t.Fatalf("署名付きURLの形式が不正です: %q", resp.URL)
+		}
+		expiresAt, err := time.Parse(time.RFC3339, resp.ExpiresAt)
+		if err != nil {
+			t.Fatalf("expires_atの形式が不正です: %v", err)
+		}
+		if d := time.Until(expiresAt); d < defaultSignedURLTTL-time.Minute || d > defaultSignedURLTTL {
+			t.Errorf("有効期限が設定値と一致しません: %v", d)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, resp.URL, nil)
+		dw := httptest.NewRecorder()
+		s.router.ServeHTTP(dw, req)
+
+		if dw.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, dw.Code, dw.Body.String())
+		}
+		if dw.Body.String() != "original-bytes" {
+			t.Errorf("期待するボディ %q, 実際のボディ %q", "original-bytes", dw.Body.String())
+		}
+		if got := dw.Header().Get("Content-Disposition"); got != `attachment; filename="photo.png"` {
+			t.Errorf("Content-Dispositionが転送されていません: %q", got)
+		}
+	})
+
+	t.Run("異常系_所有者以外は発行できない", func(t *testing.T) {
+		t.Parallel()
+
+		s := newSignedURLTestServer(t)
+		if w := issueSignedURL(t, s, "media-1", "user-999"); w.Code != http.StatusForbidden {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("異常系_存在しないメディアは404を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newSignedURLTestServer(t)
+		if w := issueSignedURL(t, s, "media-missing", "user-123"); w.Code != http.StatusNotFound {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("異常系_期限切れや署名不正のURLは403を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newSignedURLTestServer(t)
+		future := time.Now().Add(time.Hour).Unix()
+		valid := buildSignedDownloadURL(testSignedURLSecret, "media-1", future)
+		parsed, err := url.Parse(valid)
+		if err != nil {
+			t.Fatalf("URLの解析に失敗: %v", err)
+		}
+		sig := parsed.Query().Get("sig")
+
+		tests := []struct {
+			name string
+			url  string
+		}{
+			{name: "期限切れ", url: buildSignedDownloadURL(testSignedURLSecret, "media-1", time.Now().Add(-time.Minute).Unix())},
+			{name: "別の鍵で署名", url: buildSignedDownloadURL("other-secret", "media-1", future)},
+			{name: "メディアIDの改ざん", url: "/download?" + url.Values{"media": {"media-2"}, "expires": {parsed.Query().Get("expires")}, "sig": {sig}}.Encode()},
+			{name: "有効期限の延長", url: "/download?" + url.Values{"media": {"media-1"}, "expires": {"9999999999"}, "sig": {sig}}.Encode()},
+			{name: "署名なし", url: "/download?media=media-1"},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("%s: 期待するステータスコード %d, 実際のステータスコード %d", tt.name, http.StatusForbidden, w.Code)
+			}
+		}
+	})
+}
+
+// TestSignedDownloadInternalSignature は元ファイル取得APIへのリクエストに内部APIの署名を付与することを検証する。
+func TestSignedDownloadInternalSignature(t *testing.T) {
+	t.Parallel()
+
+	const signingKey = "internal-signing-key"
+
+	router := gin.New()
+	router.GET("/api/v1/media/:id/file", middleware.VerifyInternalSignature(signingKey), func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte("original-bytes"))
+	})
+	mediaCommand := httptest.NewServer(router)
+	t.Cleanup(mediaCommand.Close)
+
+	tests := []struct {
+		name       string
+		signingKey string
+		wantCode   int
+	}{
+		{name: "正常系_INTERNAL_SIGNING_KEYで署名して元ファイルを取得する", signingKey: signingKey, wantCode: http.StatusOK},
+		{name: "異常系_署名しない場合はmedia-commandに拒否され502を返す", signingKey: "", wantCode: http.StatusBadGateway},
+		{name: "異常系_別の鍵で署名した場合はmedia-commandに拒否され502を返す", signingKey: "another-key", wantCode: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newTestServerWithURLs(t, serviceURLConfig{MediaCommand: mediaCommand.URL})
+			s.internalSigningKey = tt.signingKey
+
+			req := httptest.NewRequest(http.MethodGet, buildSignedDownloadURL(s.signedURLSecret, "media-1", time.Now().Add(time.Hour).Unix()), nil)
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSignedURLSecretRotation(t *testing.T) {
+	t.Parallel()
+
+	s := newSignedURLTestServer(t)
+	s.signedURLSecret = "current-secret"
+	s.previousSignedURLSecrets = []string{"previous-secret"}
+	future := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name     string
+		secret   string
+		wantCode int
+	}{
+		{name: "現在の秘密鍵で署名したURLはダウンロードできる", secret: "current-secret", wantCode: http.StatusOK},
+		{name: "ローテーション前の秘密鍵で署名したURLもダウンロードできる", secret: "previous-secret", wantCode: http.StatusOK},
+		{name: "指定していない秘密鍵で署名したURLは403", secret: "older-secret", wantCode: http.StatusForbidden},
+		{name: "JWTの秘密鍵で署名したURLは403", secret: testJWTSecret, wantCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, buildSignedDownloadURL(tt.secret, "media-1", future), nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: 期待するステータスコード %d, 実際のステータスコード %d", tt.name, tt.wantCode, w.Code)
+		}
+	}
+}
+
+func TestParseSignedURLTTL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "", want: defaultSignedURLTTL},
+		{input: "72h", want: 72 * time.Hour},
+		{input: "0s", wantErr: true},
+		{input: "-1h", wantErr: true},
+		{input: "invalid", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSignedURLTTL(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSignedURLTTL(%q) のエラー: %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSignedURLTTL(%q) = %v, 期待値 %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestResolveSignedURLSecret(t *testing.T) {
+	t.Parallel()
+
+	if got, err := resolveSignedURLSecret("configured-secret"); err != nil || got != "configured-secret" {
+		t.Errorf("resolveSignedURLSecret(%q) = %q, %v, 期待値 %q", "configured-secret", got, err, "configured-secret")
+	}
+
+	first, err := resolveSignedURLSecret("")
+	if err != nil {
+		t.Fatalf("秘密鍵の生成に失敗: %v", err)
+	}
+	second, err := resolveSignedURLSecret("")
+	if err != nil {
+		t.Fatalf("秘密鍵の生成に失敗: %v", err)
+	}
+	if first == "" || first == second {
+		t.Errorf("未設定の場合は起動ごとに異なる秘密鍵を生成すること: %q, %q", first, second)
+	}
+}
@@ -0,0 +1,22 @@
+package gateway
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultTokenExpiringThreshold はトークンの有効期限が近いとみなす既定の残り有効時間。
+const defaultTokenExpiringThreshold = 5 * time.Minute
+
+// parseTokenExpiringThreshold は環境変数TOKEN_EXPIRING_THRESHOLD（例: 5m）を、
+// レスポンスにX-Token-Expiringヘッダーを付ける残り有効時間の閾値に変換する。空の場合は既定値を返す。
+func parseTokenExpiringThreshold(v string) (time.Duration, error) {
+	if v == "" {
+		return defaultTokenExpiringThreshold, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("TOKEN_EXPIRING_THRESHOLDは正の期間（例: 5m）で指定してください: %q", v)
+	}
+	return d, nil
+}
@@ -0,0 +1,97 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+func TestParseTokenExpiringThreshold(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "", want: defaultTokenExpiringThreshold},
+		{input: "10m", want: 10 * time.Minute},
+		{input: "0s", wantErr: true},
+		{input: "5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseTokenExpiringThreshold(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期待するエラー有無 %v, 実際のエラー %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("期待する値 %v, 実際の値 %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTokenExpiryHeaders(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+	seedUser(t, s, "user-expiring", "github", "gh-expiring", "expiring@example.com", "期限間近ユーザー")
+
+	// request はトークンを付けて/api/v1/meを呼び出す。
+	request := func(t *testing.T, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+		return w
+	}
+
+	t.Run("残り有効時間が閾値未満のトークンには期限間近のヘッダーを返す", func(t *testing.T) {
+		t.Parallel()
+
+		expiresAt := time.Now().Add(2 * time.Minute).Truncate(time.Second)
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, middleware.JWTClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+				IssuedAt:  jwt.NewNumericDate(expiresAt.Add(-24 * time.Hour)),
+				Issuer:    middleware.Issuer,
+				Audience:  jwt.ClaimStrings{middleware.Audience},
+			},
+			UserID: "user-expiring",
+			Email:  "expiring@example.com",
+		}).SignedString([]byte(testJWTSecret))
+		if err != nil {
+			t.Fatalf("トークンの署名に失敗: %v", err)
+		}
+
+		w := request(t, token)
+		if got := w.Header().Get(middleware.HeaderTokenExpiring); got != "true" {
+			t.Errorf("期待する%s %q, 実際の値 %q", middleware.HeaderTokenExpiring, "true", got)
+		}
+		if got, want := w.Header().Get(middleware.HeaderTokenExpiresAt), expiresAt.UTC().Format(time.RFC3339); got != want {
+			t.Errorf("期待する%s %q, 実際の値 %q", middleware.HeaderTokenExpiresAt, want, got)
+		}
+	})
+
+	t.Run("残り有効時間が十分なトークンにはヘッダーを返さない", func(t *testing.T) {
+		t.Parallel()
+
+		w := request(t, generateTestJWT(t, "user-expiring", "expiring@example.com"))
+		if got := w.Header().Get(middleware.HeaderTokenExpiring); got != "" {
+			t.Errorf("期待する%s なし, 実際の値 %q", middleware.HeaderTokenExpiring, got)
+		}
+		if got := w.Header().Get(middleware.HeaderTokenExpiresAt); got != "" {
+			t.Errorf("期待する%s なし, 実際の値 %q", middleware.HeaderTokenExpiresAt, got)
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	gatewaydb "github.com/nao1215/micro/internal/gateway/db"
+)
+
+// maxWebhookBodyBytes はWebhookリクエストボディの最大サイズ（1MB）。
+const maxWebhookBodyBytes = 1 << 20
+
+// googleIssuer はGoogleが発行するセキュリティイベントトークンのiss。
+const googleIssuer = "https://accounts.google.com/"
+
+// googleUnlinkEventTypes は連携解除の対象となるGoogle Cross-Account Protection（RISC）のイベントタイプ。
+var googleUnlinkEventTypes = map[string]struct{}{
+	"https://schemas.openid.net/secevent/risc/event-type/account-disabled": {},
+	"https://schemas.openid.net/secevent/risc/event-type/account-purged":   {},
+	"https://schemas.openid.net/secevent/oauth/event-type/tokens-revoked":  {},
+	"https://schemas.openid.net/secevent/oauth/event-type/token-revoked":   {},
+}
+
+// errWebhookSignature はWebhookの署名がない、または検証に失敗したことを示す。
+var errWebhookSignature = errors.New("Webhookの署名が不正です")
+
+// webhookConfig はOAuthプロバイダーからのWebhookの検証設定。
+type webhookConfig struct {
+	// githubSecret はGitHub Webhookの署名（X-Hub-Signature-256）の検証に使用する共有シークレット。
+	// 空の場合はGitHubからのWebhookを受け付けない。
+	githubSecret string
+	// googlePublicKey はGoogleのセキュリティイベントトークン（RS256）の検証に使用する公開鍵。
+	// nilの場合はGoogleからのWebhookを受け付けない。
+	googlePublicKey *rsa.PublicKey
+	// googleAudience はGoogleのセキュリティイベントトークンのaud（OAuthクライアントID）。
+	googleAudience string
+}
+
+// parseGooglePublicKey は環境変数GOOGLE_WEBHOOK_PUBLIC_KEYのPEM形式の公開鍵を読み込む。
+// 空の場合はnilを返す。
+func parseGooglePublicKey(v string) (*rsa.PublicKey, error) {
+	if v == "" {
+		return nil, nil
+	}
+	key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(v))
+	if err != nil {
+		return nil, fmt.Errorf("GOOGLE_WEBHOOK_PUBLIC_KEYの読み込みに失敗: %w", err)
+	}
+	return key, nil
+}
+
+// verifyGitHubSignature はX-Hub-Signature-256ヘッダー（sha256=<HMAC-SHA256の16進文字列>）を検証する。
+func verifyGitHubSignature(secret string, body []byte, header string) error {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return errWebhookSignature
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return errWebhookSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return errWebhookSignature
+	}
+	return nil
+}
+
+// githubAuthorizationEvent はGitHubのgithub_app_authorizationイベントのペイロード。
+type githubAuthorizationEvent struct {
+	// Action はイベントの種類。ユーザーがアクセス権を取り消した場合は"revoked"。
+	Action string `json:"action"`
+	// Sender はアクセス権を取り消したユーザー。
+	Sender struct {
+		// ID はGitHubのユーザーID。
+		ID int64 `json:"id"`
+	} `json:"sender"`
+}
+
+// parseGitHubWebhook はGitHub Webhookの署名を検証し、連携を解除するユーザーのIDを返す。
+// 連携解除の対象はユーザーがアクセス権を取り消したgithub_app_authorizationイベントのみで、
+// それ以外のイベントの場合は空のスライスを返す。
+func parseGitHubWebhook(secret string, header http.Header, body []byte) ([]string, error) {
+	if err := verifyGitHubSignature(secret, body, header.Get("X-Hub-Signature-256")); err != nil {
+		return nil, err
+	}
+	if header.Get("X-GitHub-Event") != "github_app_authorization" {
+		return []string{}, nil
+	}
+
+	var ev githubAuthorizationEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, fmt.Errorf("ペイロードの解析に失敗: %w", err)
+	}
+	if ev.Action != "revoked" || ev.Sender.ID == 0 {
+		return []string{}, nil
+	}
+	return []string{strconv.FormatInt(ev.Sender.ID, 10)}, nil
+}
+
+// googleSecurityEventClaims はGoogleのセキュリティイベントトークンのクレーム。
+type googleSecurityEventClaims struct {
+	jwt.RegisteredClaims
+	// Events はイベントタイプのURIごとのイベント内容。
+	Events map[string]struct {
+		// Subject はイベントの対象アカウント。
+		Subject struct {
+			// SubjectType は対象の識別方法。Googleアカウントの場合は"iss-sub"。
+			SubjectType string `json:"subject_type"`
+			// Sub はGoogleのユーザーID。
+			Sub string `json:"sub"`
+		} `json:"subject"`
+	} `json:"events"`
+}
+
+// parseGoogleWebhook はGoogleのセキュリティイベントトークン（RS256署名のJWT）を検証し、連携を解除するユーザーのIDを返す。
+// 連携解除の対象はアカウントの無効化やトークンの取り消しなどのイベントのみで、
+// それ以外のイベントの場合は空のスライスを返す。
+func parseGoogleWebhook(cfg webhookConfig, body []byte) ([]string, error) {
+	var claims googleSecurityEventClaims
+	_, err := jwt.ParseWithClaims(strings.TrimSpace(string(body)), &claims,
+		func(_ *jwt.Token) (any, error) { return cfg.googlePublicKey, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(googleIssuer),
+		jwt.WithAudience(cfg.googleAudience),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errWebhookSignature, err)
+	}
+
+	ids := []string{}
+	for eventType, ev := range claims.Events {
+		if _, ok := googleUnlinkEventTypes[eventType]; !ok {
+			continue
+		}
+		if ev.Subject.SubjectType != "iss-sub" || ev.Subject.Sub == "" {
+			continue
+		}
+		ids = append(ids, ev.Subject.Sub)
+	}
+	return ids, nil
+}
+
+// handleWebhook はOAuthプロバイダーからのアカウント変更通知を受け付けるハンドラを返す。
+// パスパラメータ :provider（github, google）ごとにプロバイダーの署名を検証し、
+// アクセス権の取り消しやアカウントの無効化を通知されたユーザーの連携を解除する。
+// 署名がない、または不正な場合は401を返し、未設定のプロバイダーの場合は503を返す。
+func (s *Server) handleWebhook() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provider := c.Param("provider")
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodyBytes))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "リクエストボディの読み取りに失敗しました"})
+			return
+		}
+
+		var providerUserIDs []string
+		switch provider {
+		case "github":
+			if s.webhooks.githubSecret == "" {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "GitHub Webhookが設定されていません"})
+				return
+			}
+			providerUserIDs, err = parseGitHubWebhook(s.webhooks.githubSecret, c.Request.Header, body)
+		case "google":
+			if s.webhooks.googlePublicKey == nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Google Webhookが設定されていません"})
+				return
+			}
+			providerUserIDs, err = parseGoogleWebhook(s.webhooks, body)
+		default:
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("未対応のプロバイダーです: %s", provider)})
+			return
+		}
+		if errors.Is(err, errWebhookSignature) {
+			log.Printf("Webhookの署名検証に失敗: provider=%s, error=%v", provider, err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Webhookの署名が不正です"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Webhookの解析に失敗しました: %v", err)})
+			return
+		}
+
+		var unlinked int64
+		for _, id := range providerUserIDs {
+			n, err := s.queries.UnlinkUserByProvider(c.Request.Context(), gatewaydb.UnlinkUserByProviderParams{
+				Provider:       provider,
+				ProviderUserID: id,
+			})
+			if err != nil {
+				log.Printf("ユーザーの連携解除に失敗: provider=%s, provider_user_id=%s, error=%v", provider, id, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザーの連携解除に失敗しました"})
+				return
+			}
+			if n > 0 {
+				log.Printf("OAuthプロバイダーの通知によりユーザーの連携を解除しました: provider=%s, provider_user_id=%s", provider, id)
+			}
+			unlinked += n
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"provider": provider,
+			"unlinked": unlinked,
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package gateway
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	gatewaydb "github.com/nao1215/micro/internal/gateway/db"
+)
+
+// testGitHubWebhookSecret はテスト用のGitHub Webhookシークレット。
+const testGitHubWebhookSecret = "test-github-webhook-secret"
+
+// testGoogleClientID はテスト用のGoogle OAuthクライアントID。
+const testGoogleClientID = "test-google-client-id"
+
+// signGitHubPayload はGitHubと同じ形式（sha256=<16進文字列>）でペイロードに署名する。
+func signGitHubPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// signGoogleSecurityEvent はGoogleのセキュリティイベントトークンを模したJWTを生成する。
+func signGoogleSecurityEvent(t *testing.T, key *rsa.PrivateKey, audience, eventType, sub string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": googleIssuer,
+		"aud": audience,
+		"iat": time.Now().Unix(),
+		"jti": "test-jti",
+		"events": map[string]any{
+			eventType: map[string]any{
+				"subject": map[string]any{"subject_type": "iss-sub", "iss": googleIssuer, "sub": sub},
+			},
+		},
+	})
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("セキュリティイベントトークンの署名に失敗: %v", err)
+	}
+	return signed
+}
+
+// newWebhookTestServer はWebhookの検証設定とOAuthユーザーを持つテスト用Gatewayサーバーを生成する。
+func newWebhookTestServer(t *testing.T) (*Server, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("RSA鍵の生成に失敗: %v", err)
+	}
+
+	s := newTestServer(t)
+	s.webhooks = webhookConfig{
+		githubSecret:    testGitHubWebhookSecret,
+		googlePublicKey: &key.PublicKey,
+		googleAudience:  testGoogleClientID,
+	}
+	seedUser(t, s, "user-github", "github", "12345", "github@example.com", "GitHubユーザー")
+	seedUser(t, s, "user-google", "google", "google-sub-1", "google@example.com", "Googleユーザー")
+	return s, key
+}
+
+// postWebhook はWebhookエンドポイントを呼び出す。
+func postWebhook(s *Server, provider string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/"+provider, bytes.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// isUnlinked はユーザーの連携が解除されているかを返す。
+func isUnlinked(t *testing.T, s *Server, provider, providerUserID string) bool {
+	t.Helper()
+	user, err := s.queries.GetUserByProvider(t.Context(), gatewaydb.GetUserByProviderParams{
+		Provider:       provider,
+		ProviderUserID: providerUserID,
+	})
+	if err != nil {
+		t.Fatalf("ユーザーの取得に失敗: %v", err)
+	}
+	return user.UnlinkedAt.Valid
+}
+
+func TestHandleWebhookGitHub(t *testing.T) {
+	t.Parallel()
+
+	revoked, _ := json.Marshal(map[string]any{"action": "revoked", "sender": map[string]any{"id": 12345, "login": "octocat"}})
+
+	t.Run("正常系_正しい署名のアクセス権取り消しでユーザーの連携を解除する", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := newWebhookTestServer(t)
+		w := postWebhook(s, "github", revoked, map[string]string{
+			"X-GitHub-Event":      "github_app_authorization",
+			"X-Hub-Signature-256": signGitHubPayload(testGitHubWebhookSecret, revoked),
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		if resp["unlinked"] != float64(1) {
+			t.Errorf("期待するunlinked 1, 実際 %v", resp["unlinked"])
+		}
+		if !isUnlinked(t, s, "github", "12345") {
+			t.Error("ユーザーの連携が解除されていません")
+		}
+
+		// 連携解除されたユーザーは発行済みのトークンでもユーザー情報を取得できない
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-github", "github@example.com"))
+		mw := httptest.NewRecorder()
+		s.router.ServeHTTP(mw, req)
+		if mw.Code != http.StatusUnauthorized {
+			t.Errorf("/me: 期待するステータスコード %d, 実際のステータスコード %d", http.StatusUnauthorized, mw.Code)
+		}
+	})
+
+	t.Run("正常系_連携解除の対象外のイベントは何もしない", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := newWebhookTestServer(t)
+		body := []byte(`{"zen":"Keep it logically awesome."}`)
+		w := postWebhook(s, "github", body, map[string]string{
+			"X-GitHub-Event":      "ping",
+			"X-Hub-Signature-256": signGitHubPayload(testGitHubWebhookSecret, body),
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+		if isUnlinked(t, s, "github", "12345") {
+			t.Error("対象外のイベントでユーザーの連携が解除されました")
+		}
+	})
+
+	t.Run("異常系_署名が不正な場合は401を返し連携を解除しない", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := newWebhookTestServer(t)
+		w := postWebhook(s, "github", revoked, map[string]string{
+			"X-GitHub-Event":      "github_app_authorization",
+			"X-Hub-Signature-256": signGitHubPayload("wrong-secret", revoked),
+		})
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusUnauthorized, w.Code)
+		}
+		if isUnlinked(t, s, "github", "12345") {
+			t.Error("署名が不正なWebhookでユーザーの連携が解除されました")
+		}
+	})
+
+	t.Run("異常系_署名がない場合は401を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := newWebhookTestServer(t)
+		w := postWebhook(s, "github", revoked, map[string]string{"X-GitHub-Event": "github_app_authorization"})
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("異常系_シークレットが未設定の場合は503を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		w := postWebhook(s, "github", revoked, map[string]string{
+			"X-GitHub-Event":      "github_app_authorization",
+			"X-Hub-Signature-256": signGitHubPayload("", revoked),
+		})
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusServiceUnavailable, w.Code)
+		}
+	})
+}
+
+func TestHandleWebhookGoogle(t *testing.T) {
+	t.Parallel()
+
+	const accountDisabled = "https://schemas.openid.net/secevent/risc/event-type/account-disabled"
+
+	t.Run("正常系_正しい署名のアカウント無効化でユーザーの連携を解除する", func(t *testing.T) {
+		t.Parallel()
+
+		s, key := newWebhookTestServer(t)
+		token := signGoogleSecurityEvent(t, key, testGoogleClientID, accountDisabled, "google-sub-1")
+		w := postWebhook(s, "google", []byte(token), map[string]string{"Content-Type": "application/secevent+jwt"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if !isUnlinked(t, s, "google", "google-sub-1") {
+			t.Error("ユーザーの連携が解除されていません")
+		}
+		if isUnlinked(t, s, "github", "12345") {
+			t.Error("他プロバイダーのユーザーの連携が解除されました")
+		}
+	})
+
+	t.Run("正常系_連携解除の対象外のイベントは何もしない", func(t *testing.T) {
+		t.Parallel()
+
+		s, key := newWebhookTestServer(t)
+		token := signGoogleSecurityEvent(t, key, testGoogleClientID, "https://schemas.openid.net/secevent/risc/event-type/verification", "google-sub-1")
+		w := postWebhook(s, "google", []byte(token), nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+		if isUnlinked(t, s, "google", "google-sub-1") {
+			t.Error("対象外のイベントでユーザーの連携が解除されました")
+		}
+	})
+
+	t.Run("異常系_別の鍵で署名されたトークンは401を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := newWebhookTestServer(t)
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("RSA鍵の生成に失敗: %v", err)
+		}
+		token := signGoogleSecurityEvent(t, other, testGoogleClientID, accountDisabled, "google-sub-1")
+		w := postWebhook(s, "google", []byte(token), nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusUnauthorized, w.Code)
+		}
+		if isUnlinked(t, s, "google", "google-sub-1") {
+			t.Error("署名が不正なWebhookでユーザーの連携が解除されました")
+		}
+	})
+
+	t.Run("異常系_audienceが異なるトークンは401を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, key := newWebhookTestServer(t)
+		token := signGoogleSecurityEvent(t, key, "other-client-id", accountDisabled, "google-sub-1")
+		w := postWebhook(s, "google", []byte(token), nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+}
+
+func TestHandleWebhookUnknownProvider(t *testing.T) {
+	t.Parallel()
+
+	s, _ := newWebhookTestServer(t)
+	w := postWebhook(s, "gitlab", []byte(`{}`), nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,30 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// parseAdminUserIDs は環境変数ADMIN_USER_IDSの値（カンマ区切りのユーザーID）を管理者の集合に変換する。
+// 前後の空白を除き、空の要素は含めない。
+func parseAdminUserIDs(v string) map[string]struct{} {
+	ids := make(map[string]struct{})
+	for _, id := range strings.Split(v, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// isAdmin はリクエストのトークンが管理者ユーザーのものかを返す。
+func (s *Server) isAdmin(c *gin.Context) bool {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return false
+	}
+	_, ok := s.adminUserIDs[userID]
+	return ok
+}
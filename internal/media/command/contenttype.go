@@ -0,0 +1,193 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sniffLen はファイルの実体からContent-Typeを判定するために読み込むバイト数。
+// http.DetectContentTypeが参照する最大長に合わせる。
+const sniffLen = 512
+
+// contentTypeCheckMode はファイル名の拡張子・申告されたContent-Type・実体の不整合を検出した場合の動作。
+type contentTypeCheckMode string
+
+const (
+	// contentTypeCheckLenient は警告をログに出してアップロードを受け付ける。
+	// 実体から種類を判定できた場合は、申告されたContent-Typeではなく実体の種類を記録する。
+	contentTypeCheckLenient contentTypeCheckMode = "lenient"
+	// contentTypeCheckStrict は不整合のあるアップロードを400で拒否する。
+	contentTypeCheckStrict contentTypeCheckMode = "strict"
+)
+
+// parseContentTypeCheckMode は文字列を不整合を検出した場合の動作に変換する。
+// 空文字の場合は既定のlenientとする。
+func parseContentTypeCheckMode(v string) (contentTypeCheckMode, error) {
+	switch contentTypeCheckMode(strings.ToLower(strings.TrimSpace(v))) {
+	case "", contentTypeCheckLenient:
+		return contentTypeCheckLenient, nil
+	case contentTypeCheckStrict:
+		return contentTypeCheckStrict, nil
+	default:
+		return "", fmt.Errorf("不明なContent-Typeの検証モードです: %s（lenient または strict を指定してください）", v)
+	}
+}
+
+// parseFixExtension は環境変数UPLOAD_FIX_EXTENSIONの値を解釈する。
+// 空文字の場合は補正しない（false）。
+func parseFixExtension(v string) (bool, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("UPLOAD_FIX_EXTENSIONはtrueまたはfalseで指定してください: %q", v)
+	}
+	return enabled, nil
+}
+
+// extensionContentTypes は拡張子から期待されるContent-Type。
+// OSのMIMEデータベースに依存せず結果を一定にするため、扱うメディアの拡張子を列挙する。
+var extensionContentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".bmp":  "image/bmp",
+	".mp4":  "video/mp4",
+	".webm": "video/webm",
+	".avi":  "video/avi",
+}
+
+// contentTypeExtensions は実体の種類に対応する正しい拡張子。拡張子の補正に使用する。
+var contentTypeExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+	"video/mp4":  ".mp4",
+	"video/webm": ".webm",
+	"video/avi":  ".avi",
+}
+
+// contentTypeAliases は同じ種類を表す非標準のContent-Typeと標準の名前の対応。
+var contentTypeAliases = map[string]string{
+	"image/jpg":       "image/jpeg",
+	"image/pjpeg":     "image/jpeg",
+	"image/x-png":     "image/png",
+	"image/x-ms-bmp":  "image/bmp",
+	"video/x-msvideo": "video/avi",
+}
+
+// normalizeContentType はContent-Typeからパラメータを除き、小文字の標準の名前に揃える。
+func normalizeContentType(contentType string) string {
+	ct := strings.ToLower(strings.TrimSpace(contentType))
+	if mediaType, _, err := mime.ParseMediaType(ct); err == nil {
+		ct = mediaType
+	}
+	if alias, ok := contentTypeAliases[ct]; ok {
+		return alias
+	}
+	return ct
+}
+
+// contentTypeCheck はアップロードされたファイルのContent-Typeの整合性を検証した結果。
+type contentTypeCheck struct {
+	// declared はクライアントが申告したContent-Type（正規化後）。
+	declared string
+	// fromExtension はファイル名の拡張子から期待されるContent-Type。拡張子が未知の場合は空。
+	fromExtension string
+	// sniffed はファイルの実体から判定したContent-Type。判定できなかった場合は空。
+	sniffed string
+}
+
+// checkContentType はファイル名・申告されたContent-Type・実体の先頭バイトを照合する。
+func checkContentType(filename, declared string, head []byte) contentTypeCheck {
+	check := contentTypeCheck{
+		declared:      normalizeContentType(declared),
+		fromExtension: extensionContentTypes[strings.ToLower(filepath.Ext(filename))],
+	}
+	// 扱うメディアとして判定できた場合のみ実体の種類とみなす（不明なデータはapplication/octet-stream等になる）
+	if sniffed := normalizeContentType(http.DetectContentType(head)); contentTypeExtensions[sniffed] != "" {
+		check.sniffed = sniffed
+	}
+	return check
+}
+
+// mismatch は不整合の内容を返す。不整合がない場合は空文字を返す。
+func (c contentTypeCheck) mismatch() string {
+	var problems []string
+	if c.fromExtension != "" && c.fromExtension != c.declared {
+		problems = append(problems, fmt.Sprintf("拡張子から期待されるContent-Type %s と申告されたContent-Type %s が一致しません", c.fromExtension, c.declared))
+	}
+	if c.sniffed != "" && c.sniffed != c.declared {
+		problems = append(problems, fmt.Sprintf("ファイルの内容 %s と申告されたContent-Type %s が一致しません", c.sniffed, c.declared))
+	}
+	return strings.Join(problems, "、")
+}
+
+// fixExtension はファイル名の拡張子を実体の種類に合わせて補正したファイル名を返す。
+// 実体の種類を判定できない場合や、拡張子がすでに実体と一致する場合はそのまま返す。
+func (c contentTypeCheck) fixExtension(filename string) string {
+	if c.sniffed == "" || c.fromExtension == c.sniffed {
+		return filename
+	}
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + contentTypeExtensions[c.sniffed]
+}
+
+// videoSignature は動画コンテナの先頭に現れるマジックナンバー。
+type videoSignature struct {
+	// offset はマジックナンバーが現れる位置。
+	offset int
+	// magic はマジックナンバーのバイト列。
+	magic []byte
+}
+
+// videoSignatures はhttp.DetectContentTypeが判定できない動画コンテナも含めて受け付けるマジックナンバー。
+// コンテナの中身までは検証せず、先頭が動画コンテナの形式であることだけを確認する。
+var videoSignatures = []videoSignature{
+	{offset: 4, magic: []byte("ftyp")},             // ISO BMFF（MP4・MOV・3GPなど）
+	{offset: 0, magic: []byte("\x1A\x45\xDF\xA3")}, // EBML（WebM・Matroska）
+	{offset: 0, magic: []byte("\x00\x00\x01\xBA")}, // MPEG-PS
+	{offset: 0, magic: []byte("\x00\x00\x01\xB3")}, // MPEG-1/2 ビデオ
+}
+
+// hasVideoSignature は先頭バイトが動画コンテナのマジックナンバーで始まるかどうかを判定する。
+func hasVideoSignature(head []byte) bool {
+	for _, sig := range videoSignatures {
+		if len(head) >= sig.offset+len(sig.magic) && bytes.Equal(head[sig.offset:sig.offset+len(sig.magic)], sig.magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyContentType は申告されたContent-Typeとファイルの実体の種類（image/・video/）が一致するかを検証する。
+// 実体はhttp.DetectContentTypeで先頭バイトから判定し、動画はhasVideoSignatureのマジックナンバーでも判定する。
+// text/plainの内容をimage/pngと申告するなど、種類を詐称したアップロードを検出するために使う。
+// 拡張子や同じ種類内の細かな不一致はcheckContentTypeとUPLOAD_CONTENT_TYPE_CHECKの設定に委ね、ここでは検証しない。
+func verifyContentType(declared string, head []byte) error {
+	declared = normalizeContentType(declared)
+	sniffed := normalizeContentType(http.DetectContentType(head))
+	switch {
+	case strings.HasPrefix(declared, "image/"):
+		if strings.HasPrefix(sniffed, "image/") {
+			return nil
+		}
+	case strings.HasPrefix(declared, "video/"):
+		if strings.HasPrefix(sniffed, "video/") || hasVideoSignature(head) {
+			return nil
+		}
+	default:
+		return fmt.Errorf("許可されていないContent-Typeです: %s（image/*またはvideo/*のみ）", declared)
+	}
+	return fmt.Errorf("ファイルの内容（%s）が申告されたContent-Type %s と一致しません", sniffed, declared)
+}
@@ -0,0 +1,181 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// createTestPNG はテスト用の小さなPNG画像のバイト列を生成する。
+func createTestPNG(t *testing.T) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("テスト画像のエンコードに失敗: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// pngSignature はPNGファイルの先頭に現れるマジックナンバー。
+// 内容を問わないテストデータを画像として判定させるために先頭に書き込む。
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+func TestVerifyContentType(t *testing.T) {
+	t.Parallel()
+
+	pngData := createTestPNG(t)
+	tests := []struct {
+		name        string
+		contentType string
+		head        []byte
+		wantErr     bool
+	}{
+		{name: "正常系_画像の内容とimage/のContent-Typeが一致する", contentType: "image/png", head: pngData},
+		{name: "正常系_同じ画像の種類内の不一致は検証しない", contentType: "image/jpeg", head: pngData},
+		{name: "正常系_MP4の内容とvideo/のContent-Typeが一致する", contentType: "video/mp4", head: []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")},
+		{name: "正常系_DetectContentTypeが判定できないMOVもマジックナンバーで受け付ける", contentType: "video/quicktime", head: []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x00\x00qt  ")},
+		{name: "正常系_WebMの内容をマジックナンバーで判定する", contentType: "video/webm", head: []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01")},
+		{name: "異常系_テキストの内容をimage/pngと詐称する", contentType: "image/png", head: []byte("hello world"), wantErr: true},
+		{name: "異常系_画像の内容をvideo/と申告する", contentType: "video/mp4", head: pngData, wantErr: true},
+		{name: "異常系_動画の内容をimage/と申告する", contentType: "image/png", head: []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01"), wantErr: true},
+		{name: "異常系_空のファイル", contentType: "image/png", head: nil, wantErr: true},
+		{name: "異常系_image/・video/以外のContent-Type", contentType: "text/plain", head: []byte("hello world"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := verifyContentType(tt.contentType, tt.head)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyContentType(%q) error = %v, wantErr %v", tt.contentType, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckContentType(t *testing.T) {
+	t.Parallel()
+
+	pngData := createTestPNG(t)
+	tests := []struct {
+		name         string
+		filename     string
+		contentType  string
+		head         []byte
+		wantMismatch bool
+		wantSniffed  string
+		wantFixed    string
+	}{
+		{name: "正常系_拡張子・Content-Type・実体が一致する", filename: "photo.png", contentType: "image/png", head: pngData, wantSniffed: "image/png", wantFixed: "photo.png"},
+		{name: "正常系_非標準のContent-Typeは標準の名前として扱う", filename: "photo.jpg", contentType: "image/jpg", head: []byte("not an image"), wantFixed: "photo.jpg"},
+		{name: "正常系_未知の拡張子と判定できない実体は検証しない", filename: "clip.mov", contentType: "video/quicktime", head: []byte("not an image"), wantFixed: "clip.mov"},
+		{name: "異常系_拡張子とContent-Typeが一致しない", filename: "photo.jpg", contentType: "image/png", head: []byte("not an image"), wantMismatch: true, wantFixed: "photo.jpg"},
+		{name: "異常系_実体とContent-Typeが一致しない", filename: "photo.jpg", contentType: "image/jpeg", head: pngData, wantMismatch: true, wantSniffed: "image/png", wantFixed: "photo.png"},
+		{name: "正常系_拡張子がない場合は実体の拡張子を補う", filename: "photo", contentType: "image/png", head: pngData, wantSniffed: "image/png", wantFixed: "photo.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			check := checkContentType(tt.filename, tt.contentType, tt.head)
+			if got := check.mismatch() != ""; got != tt.wantMismatch {
+				t.Errorf("不整合の有無: 期待 %v, 実際 %v（%s）", tt.wantMismatch, got, check.mismatch())
+			}
+			if check.sniffed != tt.wantSniffed {
+				t.Errorf("実体の種類: 期待 %q, 実際 %q", tt.wantSniffed, check.sniffed)
+			}
+			if got := check.fixExtension(tt.filename); got != tt.wantFixed {
+				t.Errorf("補正後のファイル名: 期待 %q, 実際 %q", tt.wantFixed, got)
+			}
+		})
+	}
+}
+
+func TestParseContentTypeCheckMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    contentTypeCheckMode
+		wantErr bool
+	}{
+		{input: "", want: contentTypeCheckLenient},
+		{input: "lenient", want: contentTypeCheckLenient},
+		{input: " STRICT ", want: contentTypeCheckStrict},
+		{input: "reject", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseContentTypeCheckMode(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseContentTypeCheckMode(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseContentTypeCheckMode(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestHandleUpload_ContentTypeCheck(t *testing.T) {
+	// mediaBaseDirを差し替えるため、並列実行はしない
+	tests := []struct {
+		name            string
+		mode            contentTypeCheckMode
+		fixExtension    bool
+		wantStatus      int
+		wantContentType string
+		wantFilename    string
+	}{
+		{name: "正常系_lenientモードでは実体の種類を記録して受け付ける", mode: contentTypeCheckLenient, wantStatus: http.StatusCreated, wantContentType: "image/png", wantFilename: "photo.jpg"},
+		{name: "正常系_拡張子の補正を有効にすると実体の拡張子で保存する", mode: contentTypeCheckLenient, fixExtension: true, wantStatus: http.StatusCreated, wantContentType: "image/png", wantFilename: "photo.png"},
+		{name: "異常系_strictモードでは不整合のあるアップロードを400で拒否する", mode: contentTypeCheckStrict, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origBaseDir := mediaBaseDir
+			mediaBaseDir = t.TempDir()
+			t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+			s := setupTestServer(t, "http://localhost:0")
+			s.contentTypeCheck = tt.mode
+			s.fixExtension = tt.fixExtension
+
+			// 拡張子とContent-TypeはJPEGだが、実体はPNG
+			pngData := createTestPNG(t)
+			body, ct := createMultipartFile(t, "file", "photo.jpg", pngData, "image/jpeg")
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/media", body)
+			req.Header.Set("Content-Type", ct)
+			req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var resp uploadResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+			if resp.ContentType != tt.wantContentType {
+				t.Errorf("期待するContent-Type %q, 実際のContent-Type %q", tt.wantContentType, resp.ContentType)
+			}
+			if resp.Filename != tt.wantFilename || filepath.Base(resp.StoragePath) != tt.wantFilename {
+				t.Errorf("期待するファイル名 %q, 実際のファイル名 %q（保存先 %q）", tt.wantFilename, resp.Filename, resp.StoragePath)
+			}
+			// 判定に使用した先頭バイトも含め、ファイル全体が保存される
+			if resp.Size != int64(len(pngData)) {
+				t.Errorf("期待するサイズ %d, 実際のサイズ %d", len(pngData), resp.Size)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package command
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// exifHeader はEXIFを格納するAPP1セグメントの識別子。
+var exifHeader = []byte("Exif\x00\x00")
+
+// exifTagOrientation はEXIFのOrientationタグ。
+const exifTagOrientation = 0x0112
+
+// exifTypeShort はEXIFのSHORT型（符号なし16ビット整数）。
+const exifTypeShort = 3
+
+// parseStripEXIF は環境変数STRIP_EXIFの値を真偽値に変換する。
+// 空の場合は無効（false）とする。
+func parseStripEXIF(v string) (bool, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("STRIP_EXIFはtrueまたはfalseで指定してください: %q", v)
+	}
+	return enabled, nil
+}
+
+// stripUploadedEXIF はアップロードされたJPEGからEXIF等のメタデータを除去してwに書き出す。
+// EXIFのOrientationが回転・反転を示す場合は、除去すると向きが失われるため
+// 画素に向きを適用してから再エンコードする。それ以外の場合は再エンコードせずにセグメントのみ除去する。
+// JPEG以外のデータの場合はerrNotJPEGを返す。
+func stripUploadedEXIF(w io.Writer, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("ファイルの読み取りに失敗: %w", err)
+	}
+	if len(data) < 2 || data[0] != 0xFF || data[1] != markerSOI {
+		return errNotJPEG
+	}
+
+	orientation := jpegOrientation(data)
+	if orientation == 1 {
+		return stripJPEGMetadata(w, bytes.NewReader(data))
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("画像のデコードに失敗: %w", err)
+	}
+	if err := jpeg.Encode(w, applyOrientation(img, orientation), &jpeg.Options{Quality: 90}); err != nil {
+		return fmt.Errorf("画像のエンコードに失敗: %w", err)
+	}
+	return nil
+}
+
+// jpegOrientation はJPEGデータのEXIFからOrientation（1〜8）を返す。
+// EXIFがない、またはOrientationを読み取れない場合は1（回転なし）を返す。
+func jpegOrientation(data []byte) int {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// マーカー前の詰め物
+			i++
+			continue
+		case marker == markerSOS || marker == markerEOI:
+			return 1
+		case marker == markerTEM || (marker >= markerRST0 && marker <= markerRST7):
+			i += 2
+			continue
+		}
+
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			return 1
+		}
+		payload := data[i+4 : i+2+n]
+		if marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader) {
+			return exifOrientation(payload[len(exifHeader):])
+		}
+		i += 2 + n
+	}
+	return 1
+}
+
+// exifOrientation はEXIFのTIFF構造のIFD0からOrientationを読み取る。
+// 読み取れない場合は1（回転なし）を返す。
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for k := 0; k < count; k++ {
+		entry := ifd + 2 + 12*k
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != exifTagOrientation {
+			continue
+		}
+		if order.Uint16(tiff[entry+2:]) != exifTypeShort {
+			return 1
+		}
+		if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+			return v
+		}
+		return 1
+	}
+	return 1
+}
+
+// applyOrientation はEXIFのOrientationが示す回転・反転を画素に適用した画像を返す。
+// 5〜8（90度単位の回転を含む）の場合は幅と高さが入れ替わる。
+func applyOrientation(src image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 左右反転
+				sx, sy = w-1-x, y
+			case 3: // 180度回転
+				sx, sy = w-1-x, h-1-y
+			case 4: // 上下反転
+				sx, sy = x, h-1-y
+			case 5: // 左上と右下を結ぶ対角線で反転
+				sx, sy = y, x
+			case 6: // 時計回りに90度回転
+				sx, sy = y, h-1-x
+			case 7: // 右上と左下を結ぶ対角線で反転
+				sx, sy = w-1-y, h-1-x
+			case 8: // 反時計回りに90度回転
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, src.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// stripUploadedFileEXIF は保存済みのファイルをEXIF等のメタデータを除去したファイルに置き換え、置き換え後のサイズを返す。
+// JPEG以外のファイルの場合は何もせず-1を返す。
+func stripUploadedFileEXIF(path string) (int64, error) {
+	if err := rewriteFile(path, stripUploadedEXIF); err != nil {
+		if errors.Is(err, errNotJPEG) {
+			return -1, nil
+		}
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("ファイルの情報取得に失敗: %w", err)
+	}
+	return info.Size(), nil
+}
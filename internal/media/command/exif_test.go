@@ -0,0 +1,212 @@
+package command
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// exifWithOrientation はOrientationタグのみを持つEXIFセグメントの中身を生成する。
+func exifWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], exifTagOrientation)
+	order.PutUint16(tiff[12:], exifTypeShort)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+	payload := append([]byte{}, exifHeader...)
+	payload = append(payload, tiff...)
+	// 撮影位置を含む想定のダミーデータ
+	return append(payload, []byte("GPS 35.6812N 139.7671E")...)
+}
+
+// createTestJPEGWithOrientation は左上の画素だけ赤いJPEGにOrientation付きのEXIFを挿入する。
+func createTestJPEGWithOrientation(t *testing.T, width, height int, orientation uint16) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{B: 255, A: 255})
+		}
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("テスト用JPEGのエンコードに失敗: %v", err)
+	}
+	encoded := buf.Bytes()
+	data := append([]byte{}, encoded[:2]...)
+	data = append(data, jpegSegment(markerAPP1, exifWithOrientation(binary.BigEndian, orientation))...)
+	return append(data, encoded[2:]...)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{
+			name: "正常系_ビッグエンディアンのEXIFからOrientationを読み取る",
+			data: append([]byte{0xFF, markerSOI}, jpegSegment(markerAPP1, exifWithOrientation(binary.BigEndian, 6))...),
+			want: 6,
+		},
+		{
+			name: "正常系_リトルエンディアンのEXIFからOrientationを読み取る",
+			data: append([]byte{0xFF, markerSOI}, jpegSegment(markerAPP1, exifWithOrientation(binary.LittleEndian, 8))...),
+			want: 8,
+		},
+		{
+			name: "正常系_EXIFがない場合は1を返す",
+			data: append([]byte{0xFF, markerSOI}, jpegSegment(markerCOM, []byte("comment"))...),
+			want: 1,
+		},
+		{
+			name: "異常系_範囲外の値は1を返す",
+			data: append([]byte{0xFF, markerSOI}, jpegSegment(markerAPP1, exifWithOrientation(binary.BigEndian, 9))...),
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("期待するOrientation %d, 実際のOrientation %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	t.Parallel()
+
+	// 3x2の画像で、左上の画素だけ赤くする
+	red := color.RGBA{R: 255, A: 255}
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, red)
+
+	tests := []struct {
+		orientation  int
+		wantW, wantH int
+		wantRedX     int
+		wantRedY     int
+	}{
+		{orientation: 1, wantW: 3, wantH: 2, wantRedX: 0, wantRedY: 0},
+		{orientation: 2, wantW: 3, wantH: 2, wantRedX: 2, wantRedY: 0},
+		{orientation: 3, wantW: 3, wantH: 2, wantRedX: 2, wantRedY: 1},
+		{orientation: 4, wantW: 3, wantH: 2, wantRedX: 0, wantRedY: 1},
+		{orientation: 5, wantW: 2, wantH: 3, wantRedX: 0, wantRedY: 0},
+		{orientation: 6, wantW: 2, wantH: 3, wantRedX: 1, wantRedY: 0},
+		{orientation: 7, wantW: 2, wantH: 3, wantRedX: 1, wantRedY: 2},
+		{orientation: 8, wantW: 2, wantH: 3, wantRedX: 0, wantRedY: 2},
+	}
+	for _, tt := range tests {
+		got := applyOrientation(src, tt.orientation)
+		if b := got.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+			t.Errorf("orientation=%d: 期待するサイズ %dx%d, 実際 %dx%d", tt.orientation, tt.wantW, tt.wantH, b.Dx(), b.Dy())
+			continue
+		}
+		if got.At(tt.wantRedX, tt.wantRedY) != color.Color(red) {
+			t.Errorf("orientation=%d: (%d,%d)が赤ではありません", tt.orientation, tt.wantRedX, tt.wantRedY)
+		}
+	}
+}
+
+func TestHandleUpload_StripEXIF(t *testing.T) {
+	// mediaBaseDirを差し替えるため、並列実行はしない
+	tests := []struct {
+		name         string
+		stripEXIF    bool
+		wantEXIF     bool
+		wantW, wantH int
+	}{
+		{name: "正常系_STRIP_EXIFが無効の場合はEXIFを残す", stripEXIF: false, wantEXIF: true, wantW: 40, wantH: 20},
+		{name: "正常系_STRIP_EXIFが有効の場合は向きを適用してからEXIFを除去する", stripEXIF: true, wantEXIF: false, wantW: 20, wantH: 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origBaseDir := mediaBaseDir
+			mediaBaseDir = t.TempDir()
+			t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+			s := setupTestServer(t, "http://localhost:0")
+			s.stripEXIF = tt.stripEXIF
+
+			original := createTestJPEGWithOrientation(t, 40, 20, 6)
+			body, ct := createMultipartFile(t, "file", "photo.jpg", original, "image/jpeg")
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/media", body)
+			req.Header.Set("Content-Type", ct)
+			req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+			}
+			var resp uploadResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+
+			saved, err := os.ReadFile(resp.StoragePath)
+			if err != nil {
+				t.Fatalf("保存されたファイルの読み込みに失敗: %v", err)
+			}
+			if resp.Size != int64(len(saved)) {
+				t.Errorf("レスポンスのsize %d が保存されたファイルのサイズ %d と一致しません", resp.Size, len(saved))
+			}
+			if got := hasMarker(jpegMarkers(t, saved), markerAPP1); got != tt.wantEXIF {
+				t.Errorf("EXIFの有無: 期待 %v, 実際 %v", tt.wantEXIF, got)
+			}
+			if !tt.wantEXIF && bytes.Contains(saved, []byte("GPS")) {
+				t.Error("保存されたファイルにEXIFの内容が残っています")
+			}
+
+			img, err := jpeg.Decode(bytes.NewReader(saved))
+			if err != nil {
+				t.Fatalf("保存されたファイルのデコードに失敗: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("期待するサイズ %dx%d, 実際 %dx%d", tt.wantW, tt.wantH, b.Dx(), b.Dy())
+			}
+
+			// イベントのsizeも除去後のサイズで記録される
+			events, err := s.queries.ListPendingOutboxEvents(t.Context(), 10)
+			if err != nil {
+				t.Fatalf("アウトボックスの取得に失敗: %v", err)
+			}
+			if len(events) != 1 {
+				t.Fatalf("期待するイベント数 1, 実際のイベント数 %d", len(events))
+			}
+			var data struct {
+				Size int64 `json:"size"`
+			}
+			if err := json.Unmarshal([]byte(events[0].Data), &data); err != nil {
+				t.Fatalf("イベントデータのデシリアライズに失敗: %v", err)
+			}
+			if data.Size != int64(len(saved)) {
+				t.Errorf("イベントのsize %d が保存されたファイルのサイズ %d と一致しません", data.Size, len(saved))
+			}
+		})
+	}
+}
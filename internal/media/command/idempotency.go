@@ -0,0 +1,44 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	mediacommanddb "github.com/nao1215/micro/internal/media/command/db"
+)
+
+// maxIdempotencyKeyLength はIdempotency-Keyヘッダーの最大長。
+const maxIdempotencyKeyLength = 255
+
+// uploadIdempotencyNamespace はIdempotency-KeyからメディアIDを導出する際のUUIDv5の名前空間。
+var uploadIdempotencyNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/nao1215/micro/media-upload"))
+
+// idempotentMediaID はユーザーIDとIdempotency-KeyからメディアIDを決定的に導出する。
+// 同じユーザーが同じキーで再送した場合は常に同じメディアIDになり、別ユーザーのキーとは衝突しない。
+func idempotentMediaID(userID, key string) string {
+	return uuid.NewSHA1(uploadIdempotencyNamespace, []byte(userID+"\x00"+key)).String()
+}
+
+// withTx はトランザクション内でfnを実行し、成功した場合はコミットする。
+// コミット後はOutboxRelayに通知し、記録したイベントを速やかに配送させる。
+func (s *Server) withTx(ctx context.Context, fn func(q *mediacommanddb.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("トランザクション開始に失敗: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := fn(s.queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("トランザクションのコミットに失敗: %w", err)
+	}
+
+	if s.relay != nil {
+		s.relay.Notify()
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package command
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIdempotentMediaID(t *testing.T) {
+	t.Parallel()
+
+	id := idempotentMediaID("user-1", "key-1")
+	if got := idempotentMediaID("user-1", "key-1"); got != id {
+		t.Errorf("同じユーザーとキーから異なるIDが導出された: %s, %s", id, got)
+	}
+	if got := idempotentMediaID("user-2", "key-1"); got == id {
+		t.Error("別ユーザーの同じキーから同じIDが導出された")
+	}
+	if got := idempotentMediaID("user-1", "key-2"); got == id {
+		t.Error("同じユーザーの別のキーから同じIDが導出された")
+	}
+}
+
+func TestHandleUpload_IdempotencyKey(t *testing.T) {
+	// mediaBaseDirを差し替えるため、並列実行はしない
+	origBaseDir := mediaBaseDir
+	mediaBaseDir = t.TempDir()
+	t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+	s := setupTestServer(t, "http://localhost:0")
+	token := generateTestJWT(t, "user-123", "test@example.com")
+
+	upload := func(t *testing.T, key string) (*httptest.ResponseRecorder, uploadResponse) {
+		t.Helper()
+		body, ct := createMultipartFile(t, "file", "photo.jpg", createTestJPEGWithMetadata(t, 10, 10), "image/jpeg")
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media", body)
+		req.Header.Set("Content-Type", ct)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		var resp uploadResponse
+		if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+		}
+		return w, resp
+	}
+	countEvents := func(t *testing.T) int {
+		t.Helper()
+		events, err := s.queries.ListPendingOutboxEvents(t.Context(), 100)
+		if err != nil {
+			t.Fatalf("アウトボックスの取得に失敗: %v", err)
+		}
+		return len(events)
+	}
+
+	t.Run("正常系_同じキーで再送した場合は記録済みのメディアを返しイベントを重複させない", func(t *testing.T) {
+		before := countEvents(t)
+
+		first, firstResp := upload(t, "retry-key")
+		if first.Code != http.StatusCreated {
+			t.Fatalf("1回目: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, first.Code, first.Body.String())
+		}
+		if firstResp.ID != idempotentMediaID("user-123", "retry-key") {
+			t.Errorf("メディアIDがキーから導出されていません: %s", firstResp.ID)
+		}
+
+		second, secondResp := upload(t, "retry-key")
+		if second.Code != http.StatusOK {
+			t.Fatalf("2回目: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, second.Code, second.Body.String())
+		}
+		if second.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("再送のレスポンスにIdempotent-Replayedヘッダーがありません")
+		}
+		if secondResp != firstResp {
+			t.Errorf("再送のレスポンスが1回目と異なります: got %+v, want %+v", secondResp, firstResp)
+		}
+
+		if got := countEvents(t) - before; got != 1 {
+			t.Errorf("期待するイベント数 1, 実際のイベント数 %d", got)
+		}
+		entries, err := os.ReadDir(mediaBaseDir)
+		if err != nil {
+			t.Fatalf("保存先ディレクトリの読み込みに失敗: %v", err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("期待するメディア数 1, 実際のメディア数 %d", len(entries))
+		}
+	})
+
+	t.Run("正常系_キーを指定しない場合は毎回新しいメディアを作成する", func(t *testing.T) {
+		before := countEvents(t)
+
+		_, first := upload(t, "")
+		_, second := upload(t, "")
+		if first.ID == second.ID {
+			t.Errorf("キーなしのアップロードで同じメディアIDが返された: %s", first.ID)
+		}
+		if got := countEvents(t) - before; got != 2 {
+			t.Errorf("期待するイベント数 2, 実際のイベント数 %d", got)
+		}
+	})
+
+	t.Run("異常系_処理中の同じキーのアップロードは409を返す", func(t *testing.T) {
+		// 記録前のアップロードが保存先を確保している状態を再現する
+		if err := os.Mkdir(filepath.Join(mediaBaseDir, idempotentMediaID("user-123", "in-flight")), 0o755); err != nil {
+			t.Fatalf("保存先ディレクトリの作成に失敗: %v", err)
+		}
+
+		w, _ := upload(t, "in-flight")
+		if w.Code != http.StatusConflict {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusConflict, w.Code)
+		}
+	})
+}
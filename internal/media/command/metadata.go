@@ -0,0 +1,208 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// metadataStripMode はメディア処理時にメタデータ（EXIF等）を除去する対象。
+type metadataStripMode string
+
+const (
+	// metadataStripThumbnail は生成するサムネイルのみからメタデータを除去する。
+	metadataStripThumbnail metadataStripMode = "thumbnail"
+	// metadataStripAll はサムネイルに加え、JPEGの元ファイルもメタデータを除去したファイルに置き換える。
+	// 撮影位置などのEXIF情報を元ファイルのダウンロードから漏らさないために使用する。
+	metadataStripAll metadataStripMode = "all"
+)
+
+// parseMetadataStripMode は文字列をメタデータ除去の対象に変換する。
+// 空文字の場合は既定のthumbnailとする。
+func parseMetadataStripMode(v string) (metadataStripMode, error) {
+	switch metadataStripMode(strings.ToLower(strings.TrimSpace(v))) {
+	case "", metadataStripThumbnail:
+		return metadataStripThumbnail, nil
+	case metadataStripAll:
+		return metadataStripAll, nil
+	default:
+		return "", fmt.Errorf("不明なメタデータ除去の対象です: %s（thumbnail または all を指定してください）", v)
+	}
+}
+
+// JPEGのマーカー。
+const (
+	markerSOI  = 0xD8
+	markerEOI  = 0xD9
+	markerSOS  = 0xDA
+	markerTEM  = 0x01
+	markerRST0 = 0xD0
+	markerRST7 = 0xD7
+	markerAPP0 = 0xE0
+	markerAPP1 = 0xE1
+	markerAPP2 = 0xE2
+	markerAPPE = 0xEE
+	markerAPPF = 0xEF
+	markerCOM  = 0xFE
+)
+
+// errNotJPEG はJPEG形式ではないデータを処理しようとした場合のエラー。
+var errNotJPEG = errors.New("JPEG形式ではありません")
+
+// iccProfileID はICCプロファイルを格納するAPP2セグメントの識別子。
+var iccProfileID = []byte("ICC_PROFILE\x00")
+
+// keepSegment はメタデータ除去時に残すセグメントかどうかを返す。
+// 画像の表示に必要なAPP0（JFIF）、APP2のICCプロファイル、APP14（Adobe、色変換方式）は残し、
+// EXIF・XMP（APP1）やIPTC（APP13）などのその他のAPPnセグメントとコメントは除去する。
+func keepSegment(marker byte, payload []byte) bool {
+	switch {
+	case marker == markerCOM:
+		return false
+	case marker == markerAPP0, marker == markerAPPE:
+		return true
+	case marker == markerAPP2:
+		return bytes.HasPrefix(payload, iccProfileID)
+	case marker >= markerAPP0 && marker <= markerAPPF:
+		return false
+	default:
+		return true
+	}
+}
+
+// stripJPEGMetadata はJPEGデータからメタデータのセグメントを除去してwに書き出す。
+// 画像データは再エンコードせずにそのままコピーするため、画質は劣化しない。
+func stripJPEGMetadata(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi[0] != 0xFF || soi[1] != markerSOI {
+		return errNotJPEG
+	}
+	if _, err := bw.Write(soi[:]); err != nil {
+		return err
+	}
+
+	for {
+		marker, err := readMarker(br)
+		if err != nil {
+			return fmt.Errorf("JPEGマーカーの読み取りに失敗: %w", err)
+		}
+
+		// 長さを持たないマーカーはそのまま書き出す
+		if marker == markerEOI || marker == markerTEM || (marker >= markerRST0 && marker <= markerRST7) {
+			if _, err := bw.Write([]byte{0xFF, marker}); err != nil {
+				return err
+			}
+			if marker == markerEOI {
+				return bw.Flush()
+			}
+			continue
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(br, length[:]); err != nil {
+			return fmt.Errorf("JPEGセグメント長の読み取りに失敗: %w", err)
+		}
+		n := int(binary.BigEndian.Uint16(length[:]))
+		if n < 2 {
+			return fmt.Errorf("JPEGセグメント長が不正です: %d", n)
+		}
+		payload := make([]byte, n-2)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return fmt.Errorf("JPEGセグメントの読み取りに失敗: %w", err)
+		}
+
+		if !keepSegment(marker, payload) {
+			continue
+		}
+		if _, err := bw.Write([]byte{0xFF, marker, length[0], length[1]}); err != nil {
+			return err
+		}
+		if _, err := bw.Write(payload); err != nil {
+			return err
+		}
+
+		// SOS以降はエントロピー符号化された画像データのため、残りをそのままコピーする
+		if marker == markerSOS {
+			if _, err := io.Copy(bw, br); err != nil {
+				return err
+			}
+			return bw.Flush()
+		}
+	}
+}
+
+// readMarker は次のJPEGマーカーを読み取る。マーカー前の詰め物の0xFFは読み飛ばす。
+func readMarker(br *bufio.Reader) (byte, error) {
+	b, err := br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xFF {
+		return 0, fmt.Errorf("マーカーの先頭が0xFFではありません: 0x%02X", b)
+	}
+	for b == 0xFF {
+		if b, err = br.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return b, nil
+}
+
+// stripOriginalMetadata はJPEGの元ファイルをメタデータを除去したファイルに置き換える。
+// JPEG以外のファイルの場合は何もせずfalseを返す。
+func stripOriginalMetadata(path string) (bool, error) {
+	if err := rewriteFile(path, stripJPEGMetadata); err != nil {
+		if errors.Is(err, errNotJPEG) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// rewriteFile はファイルの内容をrewriteで変換した内容に置き換える。
+// 同じディレクトリに一時ファイルを作成してからリネームするため、途中で失敗しても元ファイルは壊れない。
+// rewriteが返したエラーはそのまま返す。
+func rewriteFile(path string, rewrite func(w io.Writer, r io.Reader) error) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("元ファイルのオープンに失敗: %w", err)
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("元ファイルの情報取得に失敗: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sanitized-*")
+	if err != nil {
+		return fmt.Errorf("一時ファイルの作成に失敗: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := rewrite(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("一時ファイルの権限設定に失敗: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("一時ファイルのクローズに失敗: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("元ファイルの置き換えに失敗: %w", err)
+	}
+	return nil
+}
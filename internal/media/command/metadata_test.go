@@ -0,0 +1,207 @@
+package command
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// exifPayload はテスト用のEXIFセグメントの中身（撮影位置を含む想定のダミーデータ）。
+var exifPayload = append([]byte("Exif\x00\x00"), []byte("GPS 35.6812N 139.7671E")...)
+
+// jpegSegment はJPEGのセグメント（マーカーとペイロード）を組み立てる。
+func jpegSegment(marker byte, payload []byte) []byte {
+	seg := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// createTestJPEGWithMetadata はEXIF・コメント・ICCプロファイルを含むテスト用のJPEGデータを生成する。
+func createTestJPEGWithMetadata(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x % 256), G: uint8(y % 256), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("テスト用JPEGのエンコードに失敗: %v", err)
+	}
+
+	// SOIの直後にメタデータのセグメントを挿入する
+	encoded := buf.Bytes()
+	data := append([]byte{}, encoded[:2]...)
+	data = append(data, jpegSegment(0xE1, exifPayload)...)
+	data = append(data, jpegSegment(0xE2, append([]byte("ICC_PROFILE\x00"), 1, 1))...)
+	data = append(data, jpegSegment(0xFE, []byte("secret comment"))...)
+	return append(data, encoded[2:]...)
+}
+
+// jpegMarkers はJPEGデータのSOSまでに含まれるセグメントのマーカーを返す。
+func jpegMarkers(t *testing.T, data []byte) []byte {
+	t.Helper()
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		t.Fatal("JPEG形式ではありません")
+	}
+	var markers []byte
+	for i := 2; i+4 <= len(data); {
+		marker := data[i+1]
+		markers = append(markers, marker)
+		if marker == 0xDA {
+			break
+		}
+		i += 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+	}
+	return markers
+}
+
+// hasMarker はマーカーのスライスに指定のマーカーが含まれるかを返す。
+func hasMarker(markers []byte, marker byte) bool {
+	return bytes.IndexByte(markers, marker) >= 0
+}
+
+func TestStripJPEGMetadata(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_EXIFとコメントを除去しICCプロファイルを残す", func(t *testing.T) {
+		t.Parallel()
+
+		src := createTestJPEGWithMetadata(t, 64, 48)
+		var out bytes.Buffer
+		if err := stripJPEGMetadata(&out, bytes.NewReader(src)); err != nil {
+			t.Fatalf("メタデータの除去に失敗: %v", err)
+		}
+
+		markers := jpegMarkers(t, out.Bytes())
+		if hasMarker(markers, 0xE1) {
+			t.Error("EXIF（APP1）が除去されていません")
+		}
+		if hasMarker(markers, 0xFE) {
+			t.Error("コメントが除去されていません")
+		}
+		if !hasMarker(markers, 0xE2) {
+			t.Error("ICCプロファイル（APP2）が除去されています")
+		}
+		if bytes.Contains(out.Bytes(), []byte("GPS")) {
+			t.Error("出力にEXIFの内容が残っています")
+		}
+
+		img, err := jpeg.Decode(bytes.NewReader(out.Bytes()))
+		if err != nil {
+			t.Fatalf("除去後のJPEGのデコードに失敗: %v", err)
+		}
+		if b := img.Bounds(); b.Dx() != 64 || b.Dy() != 48 {
+			t.Errorf("期待するサイズ 64x48, 実際 %dx%d", b.Dx(), b.Dy())
+		}
+	})
+
+	t.Run("異常系_JPEG以外のデータはerrNotJPEGを返す", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		if err := stripJPEGMetadata(&out, bytes.NewReader([]byte("\x89PNG\r\n"))); err != errNotJPEG {
+			t.Errorf("期待するエラー %v, 実際 %v", errNotJPEG, err)
+		}
+	})
+}
+
+func TestParseMetadataStripMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    metadataStripMode
+		wantErr bool
+	}{
+		{input: "", want: metadataStripThumbnail},
+		{input: "thumbnail", want: metadataStripThumbnail},
+		{input: "ALL", want: metadataStripAll},
+		{input: "none", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseMetadataStripMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期待するエラー有無 %v, 実際のエラー %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("期待するモード %q, 実際のモード %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHandleProcess_StripMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		mode             metadataStripMode
+		wantOriginalExif bool
+	}{
+		{name: "正常系_既定ではサムネイルのみメタデータを除去する", mode: "", wantOriginalExif: true},
+		{name: "正常系_allの場合は元ファイルのメタデータも除去する", mode: metadataStripAll, wantOriginalExif: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			originalPath := filepath.Join(tmpDir, "photo.jpg")
+			if err := os.WriteFile(originalPath, createTestJPEGWithMetadata(t, 400, 300), 0o644); err != nil {
+				t.Fatalf("テスト画像の書き込みに失敗: %v", err)
+			}
+
+			s := setupTestServer(t, "http://localhost:0")
+			s.metadataStrip = tt.mode
+
+			reqBody, _ := json.Marshal(processRequest{StoragePath: originalPath, ContentType: "image/jpeg"})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/media/test-media-id/process", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+			if resp["original_stripped"] != !tt.wantOriginalExif {
+				t.Errorf("期待するoriginal_stripped %v, 実際 %v", !tt.wantOriginalExif, resp["original_stripped"])
+			}
+
+			thumbnail, err := os.ReadFile(filepath.Join(tmpDir, "thumbnail.jpg"))
+			if err != nil {
+				t.Fatalf("サムネイルの読み込みに失敗: %v", err)
+			}
+			if hasMarker(jpegMarkers(t, thumbnail), 0xE1) {
+				t.Error("サムネイルにEXIFが含まれています")
+			}
+
+			original, err := os.ReadFile(originalPath)
+			if err != nil {
+				t.Fatalf("元ファイルの読み込みに失敗: %v", err)
+			}
+			if got := hasMarker(jpegMarkers(t, original), 0xE1); got != tt.wantOriginalExif {
+				t.Errorf("元ファイルのEXIF有無: 期待 %v, 実際 %v", tt.wantOriginalExif, got)
+			}
+			if _, err := jpeg.Decode(bytes.NewReader(original)); err != nil {
+				t.Errorf("元ファイルのデコードに失敗: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS upload_idempotency_keys (
+    user_id TEXT NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    media_id TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    storage_path TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (user_id, idempotency_key)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE event_outbox DROP COLUMN causation_id;
+ALTER TABLE event_outbox DROP COLUMN correlation_id;
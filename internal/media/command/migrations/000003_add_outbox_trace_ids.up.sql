@@ -0,0 +1,2 @@
+ALTER TABLE event_outbox ADD COLUMN correlation_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE event_outbox ADD COLUMN causation_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"context"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// ModerationInput はコンテンツ審査の対象となるメディアの情報。
+type ModerationInput struct {
+	// MediaID は審査対象のメディアID。
+	MediaID string
+	// StoragePath は審査対象のメディアファイルの保存パス。
+	StoragePath string
+	// ContentType はファイルのMIMEタイプ。
+	ContentType string
+}
+
+// ModerationResult はコンテンツ審査の結果。
+type ModerationResult struct {
+	// Flagged は要確認と判定された場合にtrueとなる。
+	Flagged bool
+	// Reason は要確認と判定された理由。
+	Reason string
+}
+
+// Moderator はアップロードされたメディアに不適切なコンテンツが含まれていないかを審査する。
+// 外部の審査サービスと連携する場合はこのインターフェースを実装し、SetModeratorで登録する。
+type Moderator interface {
+	// Moderate はメディアを審査し、結果を返す。審査自体に失敗した場合はエラーを返す。
+	Moderate(ctx context.Context, in ModerationInput) (ModerationResult, error)
+}
+
+// NopModerator は何も審査せず、常に問題なしと判定するModerator。既定のModeratorとして使用する。
+type NopModerator struct{}
+
+// Moderate は常に要確認ではない結果を返す。
+func (NopModerator) Moderate(_ context.Context, _ ModerationInput) (ModerationResult, error) {
+	return ModerationResult{}, nil
+}
+
+// SetModerator はメディア処理時に使用するModeratorを設定する。
+func (s *Server) SetModerator(m Moderator) {
+	s.moderator = m
+}
+
+// moderate はメディアを審査し、要確認と判定された場合はMediaFlaggedイベントをアウトボックスに記録する。
+// 審査に失敗した場合は警告をログに出力し、要確認とはしない（審査サービスの障害でメディアの公開を止めない）。
+// 要確認として記録した場合はtrueを返す。
+func (s *Server) moderate(c *gin.Context, aggregateID string, in ModerationInput) bool {
+	if s.moderator == nil {
+		return false
+	}
+
+	result, err := s.moderator.Moderate(c.Request.Context(), in)
+	if err != nil {
+		log.Printf("警告: コンテンツ審査に失敗しました: media_id=%s, error=%v", in.MediaID, err)
+		return false
+	}
+	if !result.Flagged {
+		return false
+	}
+
+	if err := s.enqueueEvent(c, aggregateID, event.TypeMediaFlagged, event.MediaFlaggedData{
+		Reason: result.Reason,
+	}); err != nil {
+		log.Printf("MediaFlaggedイベントの記録に失敗: %v", err)
+		return false
+	}
+	log.Printf("メディアを要確認として記録しました: media_id=%s, reason=%s", in.MediaID, result.Reason)
+	return true
+}
@@ -0,0 +1,135 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// fakeModerator はテスト用のModerator。受け取った入力を記録し、指定した結果を返す。
+type fakeModerator struct {
+	result ModerationResult
+	err    error
+	// got は最後に審査したメディアの情報。
+	got ModerationInput
+}
+
+func (f *fakeModerator) Moderate(_ context.Context, in ModerationInput) (ModerationResult, error) {
+	f.got = in
+	return f.result, f.err
+}
+
+func TestHandleProcessModeration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		moderator   Moderator
+		contentType string
+		wantEvents  []event.Type
+		wantFlagged bool
+	}{
+		{
+			name:        "要確認と判定された画像はMediaProcessedの後にMediaFlaggedを記録する",
+			moderator:   &fakeModerator{result: ModerationResult{Flagged: true, Reason: "不適切なコンテンツの可能性"}},
+			contentType: "image/png",
+			wantEvents:  []event.Type{event.TypeMediaProcessed, event.TypeMediaFlagged},
+			wantFlagged: true,
+		},
+		{
+			name:        "要確認と判定された動画もMediaFlaggedを記録する",
+			moderator:   &fakeModerator{result: ModerationResult{Flagged: true, Reason: "不適切なコンテンツの可能性"}},
+			contentType: "video/mp4",
+			wantEvents:  []event.Type{event.TypeMediaProcessed, event.TypeMediaFlagged},
+			wantFlagged: true,
+		},
+		{
+			name:        "問題なしと判定された場合はMediaProcessedのみ記録する",
+			moderator:   &fakeModerator{},
+			contentType: "image/png",
+			wantEvents:  []event.Type{event.TypeMediaProcessed},
+		},
+		{
+			name:        "審査に失敗した場合は要確認にしない",
+			moderator:   &fakeModerator{err: errors.New("審査サービスに接続できません")},
+			contentType: "image/png",
+			wantEvents:  []event.Type{event.TypeMediaProcessed},
+		},
+		{
+			name:        "既定のNopModeratorは要確認にしない",
+			moderator:   NopModerator{},
+			contentType: "image/png",
+			wantEvents:  []event.Type{event.TypeMediaProcessed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			storagePath := filepath.Join(t.TempDir(), "test.png")
+			createTestImage(t, storagePath, 40, 30)
+
+			s := setupTestServer(t, "http://localhost:0")
+			s.SetModerator(tt.moderator)
+
+			reqBody, _ := json.Marshal(processRequest{StoragePath: storagePath, ContentType: tt.contentType})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/media/moderated-id/process", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+
+			var resp map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+			if resp["flagged"] != tt.wantFlagged {
+				t.Errorf("期待するflagged %v, 実際のflagged %v", tt.wantFlagged, resp["flagged"])
+			}
+
+			pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+			if err != nil {
+				t.Fatalf("未配送イベントの取得に失敗: %v", err)
+			}
+			if len(pending) != len(tt.wantEvents) {
+				t.Fatalf("期待するイベント数 %d, 実際のイベント数 %d: %+v", len(tt.wantEvents), len(pending), pending)
+			}
+			for i, want := range tt.wantEvents {
+				if pending[i].EventType != string(want) {
+					t.Errorf("イベント[%d]: 期待する種類 %s, 実際の種類 %s", i, want, pending[i].EventType)
+				}
+				if pending[i].AggregateID != "media-moderated-id" {
+					t.Errorf("イベント[%d]: 期待するaggregate_id media-moderated-id, 実際 %s", i, pending[i].AggregateID)
+				}
+			}
+
+			if f, ok := tt.moderator.(*fakeModerator); ok {
+				if f.got.MediaID != "moderated-id" || f.got.StoragePath != storagePath || f.got.ContentType != tt.contentType {
+					t.Errorf("Moderatorへの入力が不正: %+v", f.got)
+				}
+			}
+			if tt.wantFlagged {
+				var data event.MediaFlaggedData
+				if err := json.Unmarshal([]byte(pending[1].Data), &data); err != nil {
+					t.Fatalf("イベントデータのデシリアライズに失敗: %v", err)
+				}
+				if data.Reason != "不適切なコンテンツの可能性" {
+					t.Errorf("期待するreason %q, 実際のreason %q", "不適切なコンテンツの可能性", data.Reason)
+				}
+			}
+		})
+	}
+}
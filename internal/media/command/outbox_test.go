@@ -0,0 +1,174 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+func TestOutboxRelay(t *testing.T) {
+	// mediaBaseDirを差し替えるため、並列実行はしない
+	t.Run("正常系_Event Store停止中のイベントが復旧後に記録順で配送される", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = tmpDir
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		store := eventstoretest.New(t)
+		store.SetDown(true)
+
+		s := setupTestServer(t, store.URL)
+		token := generateTestJWT(t, "user-123", "test@example.com")
+
+		// アップロード
+		imgBuf := &bytes.Buffer{}
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		for y := 0; y < 10; y++ {
+			for x := 0; x < 10; x++ {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			}
+		}
+		if err := png.Encode(imgBuf, img); err != nil {
+			t.Fatalf("テスト画像のエンコードに失敗: %v", err)
+		}
+		body, ct := createMultipartFile(t, "file", "test.png", imgBuf.Bytes(), "image/png")
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media", body)
+		req.Header.Set("Content-Type", ct)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("アップロード: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var uploaded uploadResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &uploaded); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+
+		// サムネイル生成
+		reqBody, _ := json.Marshal(processRequest{StoragePath: uploaded.StoragePath, ContentType: "image/png"})
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/media/"+uploaded.ID+"/process", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w = httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("サムネイル生成: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		// 削除
+		req = httptest.NewRequest(http.MethodDelete, "/api/v1/media/"+uploaded.ID, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w = httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("削除: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		// 停止中は配送に失敗し、イベントはアウトボックスに残る
+		ctx := context.Background()
+		if err := s.relay.relay(ctx); err == nil {
+			t.Fatal("Event Store停止中の配送でエラーが返されるべき")
+		}
+		pending, err := s.queries.ListPendingOutboxEvents(ctx, outboxBatchSize)
+		if err != nil {
+			t.Fatalf("未配送イベントの取得に失敗: %v", err)
+		}
+		if len(pending) != 3 {
+			t.Fatalf("未配送イベント数: got %d, want 3", len(pending))
+		}
+		if pending[0].Attempts != 1 || pending[0].LastError == "" {
+			t.Errorf("配送失敗が記録されていない: attempts=%d, last_error=%q", pending[0].Attempts, pending[0].LastError)
+		}
+		if pending[1].Attempts != 0 {
+			t.Errorf("先頭の配送失敗後に後続イベントが送信されている: attempts=%d", pending[1].Attempts)
+		}
+
+		// 復旧後に記録順で配送される
+		store.SetDown(false)
+		if err := s.relay.relay(ctx); err != nil {
+			t.Fatalf("復旧後の配送に失敗: %v", err)
+		}
+		store.AssertEventTypes(t, "MediaUploaded", "MediaProcessed", "MediaDeleted")
+		received := store.Events()
+		if received[0].AggregateID != "media-"+uploaded.ID {
+			t.Errorf("aggregate_id: got %s, want %s", received[0].AggregateID, "media-"+uploaded.ID)
+		}
+		if received[0].UserID != "user-123" {
+			t.Errorf("X-User-ID: got %q, want %q", received[0].UserID, "user-123")
+		}
+		// 同じAggregateのイベントは記録順に連番で追記される
+		for i, ev := range received {
+			if ev.Version != int64(i+1) {
+				t.Errorf("%d番目のイベントのバージョン: got %d, want %d", i, ev.Version, i+1)
+			}
+		}
+
+		pending, err = s.queries.ListPendingOutboxEvents(ctx, outboxBatchSize)
+		if err != nil {
+			t.Fatalf("未配送イベントの取得に失敗: %v", err)
+		}
+		if len(pending) != 0 {
+			t.Errorf("配送済みイベントが未配送のまま残っている: %+v", pending)
+		}
+	})
+
+	t.Run("正常系_バックグラウンド配送で通知後に速やかに配送される", func(t *testing.T) {
+		store := eventstoretest.New(t)
+		seedUploadedMedia(t, store, "test-media-id", "user-123")
+
+		s := setupTestServer(t, store.URL)
+		s.relay.interval = time.Hour
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		s.relay.Start(ctx)
+		defer s.relay.Stop()
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/media/test-media-id", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+
+		store.WaitForEvents(t, 1)
+	})
+
+	t.Run("正常系_リクエストの追跡情報をEvent Storeへ伝播する", func(t *testing.T) {
+		store := eventstoretest.New(t)
+		seedUploadedMedia(t, store, "test-media-id", "user-123")
+
+		s := setupTestServer(t, store.URL)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/media/test-media-id", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		req.Header.Set(httpclient.CorrelationIDHeader, "correlation-1")
+		req.Header.Set(httpclient.CausationIDHeader, "event-1")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+
+		if err := s.relay.relay(context.Background()); err != nil {
+			t.Fatalf("配送に失敗: %v", err)
+		}
+		received := store.Events()
+		want := event.Metadata{CorrelationID: "correlation-1", CausationID: "event-1"}
+		if len(received) != 1 || received[0].Metadata != want {
+			t.Errorf("Event Storeが受信したイベント = %+v, want Metadata %+v", received, want)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	mediacommanddb "github.com/nao1215/micro/internal/media/command/db"
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// errMediaNotFound はメディアのMediaUploadedイベントが見つからない場合のエラー。
+var errMediaNotFound = errors.New("メディアが見つかりません")
+
+// eventStorePageLimit はEvent StoreのAggregateのイベント取得APIから1回に取得するイベント数（Event Storeが受け付ける最大件数）。
+const eventStorePageLimit = 1000
+
+// storedEvent はEvent StoreのAggregateのイベント取得APIが返すイベントのうち、所有者の確認に使う項目。
+type storedEvent struct {
+	// EventType はイベントの種類。
+	EventType string `json:"event_type"`
+	// Data はイベント固有のデータ（JSON文字列）。
+	Data string `json:"data"`
+}
+
+// mediaOwner はメディアをアップロードしたユーザーのIDを、最初のMediaUploadedイベントのuser_idから求める。
+// アップロード直後はMediaUploadedがまだEvent Storeに配送されていないことがあるため、
+// 先にアウトボックスの未配送のイベントを確認し、なければEvent Storeから該当Aggregateのイベントを取得する。
+// どちらにもない場合はerrMediaNotFoundを返す。
+func (s *Server) mediaOwner(ctx context.Context, aggregateID string) (string, error) {
+	data, err := s.queries.GetPendingOutboxEventData(ctx, mediacommanddb.GetPendingOutboxEventDataParams{
+		AggregateID: aggregateID,
+		EventType:   string(event.TypeMediaUploaded),
+	})
+	switch {
+	case err == nil:
+		return uploadedUserID(data)
+	case !errors.Is(err, sql.ErrNoRows):
+		return "", fmt.Errorf("アウトボックスの検索に失敗: %w", err)
+	}
+
+	var events []storedEvent
+	if err := s.eventClient.GetJSON(ctx, "/api/v1/events/aggregate/"+url.PathEscape(aggregateID), &events); err != nil {
+		return "", fmt.Errorf("Event Storeからのイベント取得に失敗: %w", err)
+	}
+	for _, ev := range events {
+		if ev.EventType == string(event.TypeMediaUploaded) {
+			return uploadedUserID(ev.Data)
+		}
+	}
+	return "", errMediaNotFound
+}
+
+// fetchAggregateEvents はEvent StoreのAggregateのイベント取得API（limit/offset）から全ページのイベントをバージョンの昇順で取得する。
+func (s *Server) fetchAggregateEvents(ctx context.Context, aggregateID string) ([]storedEvent, error) {
+	var events []storedEvent
+	for offset := 0; ; offset += eventStorePageLimit {
+		var page []storedEvent
+		path := fmt.Sprintf("/api/v1/events/aggregate/%s?limit=%d&offset=%d", url.PathEscape(aggregateID), eventStorePageLimit, offset)
+		if err := s.eventClient.GetJSON(ctx, path, &page); err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+		if len(page) < eventStorePageLimit {
+			return events, nil
+		}
+	}
+}
+
+// uploadedUserID はMediaUploadedイベントのデータからアップロードしたユーザーのIDを取り出す。
+func uploadedUserID(data string) (string, error) {
+	var uploaded event.MediaUploadedData
+	if err := json.Unmarshal([]byte(data), &uploaded); err != nil {
+		return "", fmt.Errorf("MediaUploadedイベントのデータのデシリアライズに失敗: %w", err)
+	}
+	return uploaded.UserID, nil
+}
+
+// authorizeMediaOwner はuserIDがメディアの所有者かを確認する。
+// MediaUploadedイベントがない場合は404、所有者でない場合は403、確認できない場合は503のレスポンスを書き込み、falseを返す。
+// userIDが空の場合は所有者とみなさず403を返す。呼び出し元はユーザーを確認してから呼び出すこと。
+func (s *Server) authorizeMediaOwner(c *gin.Context, aggregateID, userID string) bool {
+	owner, err := s.mediaOwner(c.Request.Context(), aggregateID)
+	switch {
+	case errors.Is(err, errMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "メディアが見つかりません"})
+		return false
+	case err != nil:
+		log.Printf("メディアの所有者の確認に失敗: aggregate_id=%s, error=%v", aggregateID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "メディアの所有者を確認できません"})
+		return false
+	case userID == "" || owner != userID:
+		c.JSON(http.StatusForbidden, gin.H{"error": "このメディアを操作する権限がありません"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// ffprobeTimeout はffprobeによる動画メタデータ抽出のタイムアウト。
+const ffprobeTimeout = 30 * time.Second
+
+// videoMetadata は動画ファイルから抽出したメタデータ。
+type videoMetadata struct {
+	// DurationSeconds は動画の長さ（秒）。
+	DurationSeconds float64
+	// Width は映像ストリームの幅（ピクセル）。
+	Width int
+	// Height は映像ストリームの高さ（ピクセル）。
+	Height int
+}
+
+// ffprobeOutput はffprobeの `-of json` 出力のうち、使用する項目のJSON構造。
+type ffprobeOutput struct {
+	// Streams は選択した映像ストリームの情報。
+	Streams []struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"streams"`
+	// Format はコンテナの情報。durationは秒数の文字列で出力される。
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+// probeVideo はffprobeを実行して動画の再生時間と解像度を取得する。
+// ffprobePathにはffprobeの実行ファイルのパスを指定する。
+func probeVideo(ctx context.Context, ffprobePath, storagePath string) (videoMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, ffprobeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, ffprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height:format=duration",
+		"-of", "json",
+		storagePath,
+	).Output()
+	if err != nil {
+		return videoMetadata{}, fmt.Errorf("ffprobeの実行に失敗: %w", err)
+	}
+	return parseFFprobeOutput(out)
+}
+
+// parseFFprobeOutput はffprobeのJSON出力から動画メタデータを取り出す。
+func parseFFprobeOutput(out []byte) (videoMetadata, error) {
+	var probed ffprobeOutput
+	if err := json.Unmarshal(out, &probed); err != nil {
+		return videoMetadata{}, fmt.Errorf("ffprobeの出力の解析に失敗: %w", err)
+	}
+	if len(probed.Streams) == 0 {
+		return videoMetadata{}, fmt.Errorf("映像ストリームが見つかりません")
+	}
+
+	meta := videoMetadata{
+		Width:  probed.Streams[0].Width,
+		Height: probed.Streams[0].Height,
+	}
+	if probed.Format.Duration != "" {
+		duration, err := strconv.ParseFloat(probed.Format.Duration, 64)
+		if err != nil {
+			return videoMetadata{}, fmt.Errorf("再生時間の解析に失敗: %w", err)
+		}
+		meta.DurationSeconds = duration
+	}
+	return meta, nil
+}
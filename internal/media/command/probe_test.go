@@ -0,0 +1,134 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/nao1215/micro/pkg/event"
+)
+
+func TestParseFFprobeOutput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		out     string
+		want    videoMetadata
+		wantErr bool
+	}{
+		{
+			name: "正常系_解像度と再生時間を取得できる",
+			out:  `{"programs":[],"streams":[{"width":1920,"height":1080}],"format":{"duration":"12.345000"}}`,
+			want: videoMetadata{DurationSeconds: 12.345, Width: 1920, Height: 1080},
+		},
+		{
+			name: "正常系_再生時間が出力されない場合は0",
+			out:  `{"streams":[{"width":640,"height":480}],"format":{}}`,
+			want: videoMetadata{Width: 640, Height: 480},
+		},
+		{name: "異常系_映像ストリームがない", out: `{"streams":[],"format":{"duration":"3.0"}}`, wantErr: true},
+		{name: "異常系_再生時間が数値でない", out: `{"streams":[{"width":1,"height":1}],"format":{"duration":"N/A"}}`, wantErr: true},
+		{name: "異常系_JSONでない", out: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseFFprobeOutput([]byte(tt.out))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFFprobeOutputのエラー: %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("期待する値 %+v, 実際の値 %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// writeFakeFFprobe は固定のJSONを出力する偽のffprobeを作成し、そのパスを返す。
+func writeFakeFFprobe(t *testing.T, output string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("シェルスクリプトによる偽のffprobeはWindowsでは実行できない")
+	}
+
+	path := filepath.Join(t.TempDir(), "ffprobe")
+	script := "#!/bin/sh\ncat <<'JSON'\n" + output + "\nJSON\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("偽のffprobeの作成に失敗: %v", err)
+	}
+	return path
+}
+
+func TestHandleProcessVideoMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		ffprobePath func(t *testing.T) string
+		want        event.MediaProcessedData
+	}{
+		{
+			name: "正常系_ffprobeで取得した再生時間と解像度を記録する",
+			ffprobePath: func(t *testing.T) string {
+				return writeFakeFFprobe(t, `{"streams":[{"width":1280,"height":720}],"format":{"duration":"8.500000"}}`)
+			},
+			want: event.MediaProcessedData{Width: 1280, Height: 720, DurationSeconds: 8.5},
+		},
+		{
+			name:        "正常系_ffprobeがない場合はメタデータなしで処理を完了する",
+			ffprobePath: func(*testing.T) string { return "" },
+			want:        event.MediaProcessedData{},
+		},
+		{
+			name: "正常系_抽出に失敗してもメタデータなしで処理を完了する",
+			ffprobePath: func(t *testing.T) string {
+				return writeFakeFFprobe(t, `broken`)
+			},
+			want: event.MediaProcessedData{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := setupTestServer(t, "http://localhost:0")
+			s.ffprobePath = tt.ffprobePath(t)
+
+			reqBody, _ := json.Marshal(processRequest{StoragePath: filepath.Join(t.TempDir(), "movie.mp4"), ContentType: "video/mp4"})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/media/video-id/process", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+
+			pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+			if err != nil {
+				t.Fatalf("未配送イベントの取得に失敗: %v", err)
+			}
+			if len(pending) != 1 || pending[0].EventType != string(event.TypeMediaProcessed) {
+				t.Fatalf("アウトボックスにMediaProcessedが記録されていない: %+v", pending)
+			}
+			var got event.MediaProcessedData
+			if err := json.Unmarshal([]byte(pending[0].Data), &got); err != nil {
+				t.Fatalf("イベントデータのデシリアライズに失敗: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("期待するイベントデータ %+v, 実際のイベントデータ %+v", tt.want, got)
+			}
+		})
+	}
+}
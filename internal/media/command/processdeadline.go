@@ -0,0 +1,117 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultProcessingTimeout は環境変数THUMBNAIL_PROCESSING_TIMEOUTを省略した場合の、
+// 1件のサムネイル生成（デコード・リサイズ・エンコード）にかけられる時間の上限。
+const defaultProcessingTimeout = 30 * time.Second
+
+// parseProcessingTimeout は環境変数THUMBNAIL_PROCESSING_TIMEOUTの値（例: 30s, 1m）を解釈する。
+// 空文字の場合は既定の30秒とする。
+func parseProcessingTimeout(v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return defaultProcessingTimeout, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("THUMBNAIL_PROCESSING_TIMEOUTは正の期間（例: 30s）で指定してください: %q", v)
+	}
+	return d, nil
+}
+
+// runWithDeadline はfnを別のゴルーチンで実行し、ctxが終了するまでにfnが終わらない場合は待たずにctxのエラーを返す。
+// 標準ライブラリのデコード・エンコードはctxを受け取らないため、巨大な画像などで終わらない処理があってもワーカー（ハンドラ）を解放する。
+// 打ち切った後もfnは実行を続けるため、fnはcontextReaderやcancelableImageを通じてctxの終了後に早く終わるようにすること。
+// 打ち切った場合、呼び出し元はfnが書き込む変数を参照してはならない。
+func runWithDeadline(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	select {
+	case err := <-done:
+		// ctxの終了で読み取りが失敗した場合などは、fnのエラーではなく打ち切りとして扱う
+		if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// contextReader はctxが終了した後の読み取りをctxのエラーで失敗させるio.Reader。
+// デコード中の元ファイルの読み取りを打ち切り、期限を過ぎたデコードを早く終わらせる。
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read はctxが終了していなければrから読み取る。
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// cancelableImage はdoneが閉じられた後のAtを元の画像にアクセスせずに透明色で返す画像。
+// リサイズはピクセルごとに元画像のAtを呼ぶため、期限を過ぎたリサイズのループを空回りさせて早く終わらせる。
+type cancelableImage struct {
+	image.Image
+	done <-chan struct{}
+}
+
+// At はdoneが閉じられていなければ元の画像の色を返す。
+func (img cancelableImage) At(x, y int) color.Color {
+	select {
+	case <-img.done:
+		return color.Transparent
+	default:
+		return img.Image.At(x, y)
+	}
+}
+
+// abortProcessing はサムネイル生成をctxの終了で打ち切った場合のレスポンスを返す。
+// 処理時間の上限（timeout）を超えた場合はタイムアウトの理由でMediaProcessingFailedイベントを記録して503を返す。
+// リクエストがキャンセルされた場合はイベントを記録できないため、ログに残して打ち切る。
+// errがctxの終了によるものでない場合は何もせずにfalseを返す。
+func (s *Server) abortProcessing(c *gin.Context, aggregateID string, timeout time.Duration, err error) bool {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		reason := fmt.Sprintf("サムネイル生成が処理時間の上限（%s）を超えたため中断しました", timeout)
+		log.Printf("サムネイル生成エラー: %s: aggregate_id=%s", reason, aggregateID)
+		s.emitProcessingFailed(c, aggregateID, reason)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": reason})
+		return true
+	case errors.Is(err, context.Canceled):
+		log.Printf("サムネイル生成を中断しました: リクエストがキャンセルされました: aggregate_id=%s", aggregateID)
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+		return true
+	default:
+		return false
+	}
+}
+
+// processingContext はサムネイル生成に処理時間の上限を設けたコンテキストと、適用した上限を返す。
+// 上限が設定されていない場合は既定の30秒とする。
+func (s *Server) processingContext(c *gin.Context) (context.Context, context.CancelFunc, time.Duration) {
+	timeout := s.processingTimeout
+	if timeout <= 0 {
+		timeout = defaultProcessingTimeout
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	return ctx, cancel, timeout
+}
@@ -0,0 +1,149 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
+)
+
+func TestParseProcessingTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "", want: defaultProcessingTimeout},
+		{input: "5s", want: 5 * time.Second},
+		{input: " 1m ", want: time.Minute},
+		{input: "0s", wantErr: true},
+		{input: "-1s", wantErr: true},
+		{input: "30", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseProcessingTimeout(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期待するエラー有無 %v, 実際のエラー %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("期待する値 %v, 実際の値 %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHandleProcessDeadline(t *testing.T) {
+	t.Parallel()
+
+	t.Run("異常系_リサイズが処理時間の上限を超えた場合はMediaProcessingFailedを記録して503を返す", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		testImagePath := filepath.Join(tmpDir, "test.png")
+		createTestImage(t, testImagePath, 400, 300)
+
+		eventStore := eventstoretest.New(t)
+		s := setupTestServer(t, eventStore.URL)
+		s.processingTimeout = 50 * time.Millisecond
+
+		// 元画像は不透明なため、Atが透明色を返すのは処理を打ち切った後だけ。
+		// 打ち切られるまで元画像を読み続ける遅いリサイズで、期限切れがリサイズまで伝わることを検証する。
+		stopped := make(chan struct{})
+		s.resize = func(src image.Image, width, height int, _ thumbnailFit, _ thumbnailAlgorithm, _ color.Color) *image.RGBA {
+			defer close(stopped)
+			for {
+				if _, _, _, a := src.At(0, 0).RGBA(); a == 0 {
+					return image.NewRGBA(image.Rect(0, 0, width, height))
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+
+		reqBody, _ := json.Marshal(processRequest{StoragePath: testImagePath})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/slow-media-id/process", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+
+		w := httptest.NewRecorder()
+		start := time.Now()
+		s.router.ServeHTTP(w, req)
+		elapsed := time.Since(start)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusServiceUnavailable, w.Code, w.Body.String())
+		}
+		if elapsed > 2*time.Second {
+			t.Errorf("処理時間の上限で打ち切られていません: %v", elapsed)
+		}
+
+		select {
+		case <-stopped:
+		case <-time.After(2 * time.Second):
+			t.Fatal("打ち切った後もリサイズが終了しません")
+		}
+
+		if _, err := os.Stat(filepath.Join(tmpDir, "thumbnail.jpg")); !os.IsNotExist(err) {
+			t.Errorf("打ち切った場合はサムネイルを保存しないこと: err=%v", err)
+		}
+
+		pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+		if err != nil {
+			t.Fatalf("アウトボックスの取得に失敗: %v", err)
+		}
+		if len(pending) != 1 || pending[0].EventType != string(event.TypeMediaProcessingFailed) {
+			t.Fatalf("MediaProcessingFailedイベントが記録されていません: %+v", pending)
+		}
+		var data event.MediaProcessingFailedData
+		if err := json.Unmarshal([]byte(pending[0].Data), &data); err != nil {
+			t.Fatalf("イベントデータのデシリアライズに失敗: %v", err)
+		}
+		if !strings.Contains(data.Reason, "処理時間の上限") {
+			t.Errorf("タイムアウトの理由が記録されていません: %q", data.Reason)
+		}
+	})
+
+	t.Run("正常系_処理時間の上限以内のリサイズはそのまま成功する", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		testImagePath := filepath.Join(tmpDir, "test.png")
+		createTestImage(t, testImagePath, 400, 300)
+
+		eventStore := eventstoretest.New(t)
+		s := setupTestServer(t, eventStore.URL)
+		s.processingTimeout = 5 * time.Second
+		s.resize = func(src image.Image, width, height int, fit thumbnailFit, algorithm thumbnailAlgorithm, background color.Color) *image.RGBA {
+			time.Sleep(10 * time.Millisecond)
+			return resizeThumbnail(src, width, height, fit, algorithm, background)
+		}
+
+		reqBody, _ := json.Marshal(processRequest{StoragePath: testImagePath})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/fast-media-id/process", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "thumbnail.jpg")); err != nil {
+			t.Errorf("サムネイルファイルが生成されていません: %v", err)
+		}
+	})
+}
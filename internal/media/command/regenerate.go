@@ -0,0 +1,233 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// mediaStatus はイベントから求めたメディアの状態。media-queryのRead Modelのstatusと同じ値を使う。
+type mediaStatus string
+
+const (
+	// mediaStatusUploaded はアップロード済みでサムネイル生成が完了していない状態。
+	mediaStatusUploaded mediaStatus = "uploaded"
+	// mediaStatusProcessed はサムネイル生成が完了した状態。
+	mediaStatusProcessed mediaStatus = "processed"
+	// mediaStatusFailed は処理の失敗または管理者の操作でfailedになった状態。
+	mediaStatusFailed mediaStatus = "failed"
+	// mediaStatusFlagged はコンテンツ審査で要確認と判定された状態。
+	mediaStatusFlagged mediaStatus = "flagged"
+	// mediaStatusDeleted は削除または補償アクションで無効化された状態。
+	mediaStatusDeleted mediaStatus = "deleted"
+)
+
+// mediaState はメディアのイベントを順に適用して求めた現在の状態。
+type mediaState struct {
+	// owner はアップロードしたユーザーのID。
+	owner string
+	// contentType はアップロード時のMIMEタイプ。
+	contentType string
+	// status はメディアの状態。
+	status mediaStatus
+}
+
+// apply はイベントをmediaStateに適用する。ProjectorがRead Modelのstatusを更新する規則と同じ遷移にする。
+func (st *mediaState) apply(eventType, data string) error {
+	switch event.Type(eventType) {
+	case event.TypeMediaUploaded:
+		var uploaded event.MediaUploadedData
+		if err := json.Unmarshal([]byte(data), &uploaded); err != nil {
+			return fmt.Errorf("MediaUploadedイベントのデータのデシリアライズに失敗: %w", err)
+		}
+		st.owner = uploaded.UserID
+		st.contentType = uploaded.ContentType
+		st.status = mediaStatusUploaded
+	case event.TypeMediaProcessed:
+		st.status = mediaStatusProcessed
+	case event.TypeMediaProcessingFailed, event.TypeMediaMarkedFailed:
+		st.status = mediaStatusFailed
+	case event.TypeMediaFlagged:
+		st.status = mediaStatusFlagged
+	case event.TypeMediaRestored:
+		st.status = mediaStatusUploaded
+	case event.TypeMediaDeleted, event.TypeMediaUploadCompensated:
+		st.status = mediaStatusDeleted
+	}
+	return nil
+}
+
+// currentMediaState はEvent Storeに記録済みの全イベント（全ページ）と、アウトボックスの未配送のイベントを順に適用してメディアの状態を求める。
+// 未配送のイベントはEvent Storeのイベントより後に記録されたものなので、その後に適用する。
+// MediaUploadedイベントがどちらにもない場合はerrMediaNotFoundを返す。
+func (s *Server) currentMediaState(ctx context.Context, aggregateID string) (mediaState, error) {
+	events, err := s.fetchAggregateEvents(ctx, aggregateID)
+	if err != nil {
+		return mediaState{}, fmt.Errorf("Event Storeからのイベント取得に失敗: %w", err)
+	}
+	pending, err := s.queries.ListPendingOutboxEventsByAggregate(ctx, aggregateID)
+	if err != nil {
+		return mediaState{}, fmt.Errorf("アウトボックスの検索に失敗: %w", err)
+	}
+	for _, ev := range pending {
+		events = append(events, storedEvent{EventType: ev.EventType, Data: ev.Data})
+	}
+
+	var st mediaState
+	for _, ev := range events {
+		if err := st.apply(ev.EventType, ev.Data); err != nil {
+			return mediaState{}, err
+		}
+	}
+	if st.status == "" {
+		return mediaState{}, errMediaNotFound
+	}
+	return st, nil
+}
+
+// regenerateThumbnailRequest はサムネイル再生成リクエストのJSON構造。省略した項目はサーバーの既定値を使用する。
+type regenerateThumbnailRequest struct {
+	// Fit はサムネイルのフィットモード（contain または cover）。
+	Fit string `json:"fit"`
+	// Format はサムネイルの出力形式（auto、jpeg または png）。
+	Format string `json:"format"`
+	// Algorithm はリサイズの補間方法（nearest または bilinear）。
+	Algorithm string `json:"algorithm"`
+	// ThumbnailSize はサムネイル画像の幅・高さ（ピクセル、16〜1024）。
+	ThumbnailSize *int `json:"thumbnail_size"`
+}
+
+// handleRegenerateThumbnail はメディアのサムネイルを元画像から再生成するハンドラを返す。
+// メディアの所有者またはADMIN_USER_IDSに含まれる管理者のみ実行でき、それ以外は403を返す。
+func (s *Server) handleRegenerateThumbnail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+		if s.isAdmin(c) {
+			userID = ""
+		}
+		s.regenerateThumbnail(c, userID)
+	}
+}
+
+// handleInternalRegenerateThumbnail はmedia-queryの一括再生成ジョブから呼び出される、サムネイル再生成の内部APIのハンドラを返す。
+// 呼び出し元の管理者の確認はmedia-queryで行うため、所有者は確認しない。
+func (s *Server) handleInternalRegenerateThumbnail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.regenerateThumbnail(c, "")
+	}
+}
+
+// regenerateThumbnail は元画像からサムネイルを再生成して上書きし、MediaProcessedイベントを記録する。
+// userIDが空でない場合はそのユーザーがメディアの所有者かを確認する。
+// サムネイル生成が完了した（processed）画像のみを対象とし、削除済み・要確認・failedのメディアや、
+// 元画像が既に削除されている場合は409を返す。要確認のメディアは再生成すると審査を経ずにprocessedへ戻るため対象外とする。
+// 再生成に失敗しても既存のサムネイルは残るため、MediaProcessingFailedイベントは記録しない。
+func (s *Server) regenerateThumbnail(c *gin.Context, userID string) {
+	mediaID := c.Param("id")
+	if mediaID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "メディアIDが指定されていません"})
+		return
+	}
+
+	var req regenerateThumbnailRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+			return
+		}
+	}
+	opts, err := s.resolveThumbnailOptions(req.Fit, req.Format, req.Algorithm, req.ThumbnailSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	aggregateID := fmt.Sprintf("media-%s", mediaID)
+	st, err := s.currentMediaState(c.Request.Context(), aggregateID)
+	switch {
+	case errors.Is(err, errMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "メディアが見つかりません"})
+		return
+	case err != nil:
+		log.Printf("メディアの状態の確認に失敗: aggregate_id=%s, error=%v", aggregateID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "メディアの状態を確認できません"})
+		return
+	case userID != "" && st.owner != userID:
+		c.JSON(http.StatusForbidden, gin.H{"error": "このメディアを操作する権限がありません"})
+		return
+	}
+
+	if strings.HasPrefix(strings.ToLower(st.contentType), "video/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "動画ファイルのサムネイルは再生成できません"})
+		return
+	}
+	switch st.status {
+	case mediaStatusProcessed:
+	case mediaStatusDeleted:
+		c.JSON(http.StatusConflict, gin.H{"error": "メディアは既に削除されています"})
+		return
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("サムネイルを再生成できる状態ではありません（status=%s）", st.status)})
+		return
+	}
+
+	var storagePath string
+	if mediaDir, err := s.findMediaDir(filepath.Base(mediaID)); err == nil {
+		storagePath, _ = findOriginalFile(mediaDir)
+	}
+	if storagePath == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "元画像が既に削除されています"})
+		return
+	}
+
+	ctx, cancel, timeout := s.processingContext(c)
+	defer cancel()
+
+	thumbnail, status, err := s.generateThumbnail(ctx, storagePath, opts)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		reason := fmt.Sprintf("サムネイルの再生成が処理時間の上限（%s）を超えたため中断しました", timeout)
+		log.Printf("サムネイル再生成エラー: %s: aggregate_id=%s", reason, aggregateID)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": reason})
+		return
+	case errors.Is(err, context.Canceled):
+		log.Printf("サムネイルの再生成を中断しました: リクエストがキャンセルされました: aggregate_id=%s", aggregateID)
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		log.Printf("サムネイル再生成エラー: %v: aggregate_id=%s", err, aggregateID)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.enqueueEvent(c, aggregateID, event.TypeMediaProcessed, thumbnail.eventData()); err != nil {
+		log.Printf("MediaProcessedイベントの記録に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの記録に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "サムネイルを再生成しました",
+		"media_id":       mediaID,
+		"thumbnail_path": thumbnail.path,
+		"format":         thumbnail.format,
+		"width":          thumbnail.width,
+		"height":         thumbnail.height,
+		"fit":            opts.fit,
+		"algorithm":      opts.algorithm,
+		"thumbnail_size": opts.size,
+	})
+}
@@ -0,0 +1,243 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	mediacommanddb "github.com/nao1215/micro/internal/media/command/db"
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
+)
+
+// seedProcessedImage はmediaBaseDirに元画像を保存し、Event Storeのモックにサムネイル生成済みのメディアのイベントを登録する。
+// 元画像の保存ディレクトリを返す。
+func seedProcessedImage(t *testing.T, store *eventstoretest.Server, mediaID, userID string) string {
+	t.Helper()
+	mediaDir := filepath.Join(mediaBaseDir, mediaID)
+	if err := os.MkdirAll(mediaDir, 0o755); err != nil {
+		t.Fatalf("メディアディレクトリの作成に失敗: %v", err)
+	}
+	createTestImage(t, filepath.Join(mediaDir, "photo.png"), 400, 300)
+	seedUploadedMedia(t, store, mediaID, userID)
+	store.Seed(t, "media-"+mediaID, event.AggregateTypeMedia, event.TypeMediaProcessed, event.MediaProcessedData{
+		ThumbnailPath:   filepath.Join(mediaDir, "thumbnail.jpg"),
+		ThumbnailFormat: "jpeg",
+		Width:           400,
+		Height:          300,
+	})
+	return mediaDir
+}
+
+func TestHandleRegenerateThumbnail(t *testing.T) {
+	// mediaBaseDirを差し替えるため、並列実行はしない
+
+	t.Run("正常系_所有者は指定の形式でサムネイルを再生成しMediaProcessedを記録する", func(t *testing.T) {
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = t.TempDir()
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		eventStore := eventstoretest.New(t)
+		mediaDir := seedProcessedImage(t, eventStore, "regen-media-id", "user-123")
+		s := setupTestServer(t, eventStore.URL)
+
+		reqBody, _ := json.Marshal(regenerateThumbnailRequest{Format: "png"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/regen-media-id/regenerate-thumbnail", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if _, err := os.Stat(filepath.Join(mediaDir, "thumbnail.png")); err != nil {
+			t.Errorf("サムネイルファイルが生成されていません: %v", err)
+		}
+
+		pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+		if err != nil {
+			t.Fatalf("アウトボックスの取得に失敗: %v", err)
+		}
+		if len(pending) != 1 || pending[0].EventType != string(event.TypeMediaProcessed) {
+			t.Fatalf("MediaProcessedイベントが記録されていません: %+v", pending)
+		}
+		var data event.MediaProcessedData
+		if err := json.Unmarshal([]byte(pending[0].Data), &data); err != nil {
+			t.Fatalf("イベントデータのデシリアライズに失敗: %v", err)
+		}
+		if data.ThumbnailFormat != "png" || data.Width != 400 || data.Height != 300 {
+			t.Errorf("イベントデータが期待と異なります: %+v", data)
+		}
+	})
+
+	t.Run("正常系_管理者は他のユーザーのメディアも再生成できる", func(t *testing.T) {
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = t.TempDir()
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		eventStore := eventstoretest.New(t)
+		seedProcessedImage(t, eventStore, "regen-media-id", "user-123")
+		s := setupTestServer(t, eventStore.URL)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/regen-media-id/regenerate-thumbnail", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "admin-1", "admin@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("正常系_内部APIからは所有者を確認せずに再生成できる", func(t *testing.T) {
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = t.TempDir()
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		eventStore := eventstoretest.New(t)
+		seedProcessedImage(t, eventStore, "regen-media-id", "user-123")
+		s := setupTestServer(t, eventStore.URL)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/regen-media-id/thumbnail/regenerate", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("異常系_所有者でも管理者でもないユーザーは403を返す", func(t *testing.T) {
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = t.TempDir()
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		eventStore := eventstoretest.New(t)
+		seedProcessedImage(t, eventStore, "regen-media-id", "user-123")
+		s := setupTestServer(t, eventStore.URL)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/regen-media-id/regenerate-thumbnail", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-999", "other@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusForbidden, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("異常系_存在しないメディアは404を返す", func(t *testing.T) {
+		eventStore := eventstoretest.New(t)
+		s := setupTestServer(t, eventStore.URL)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/missing-id/regenerate-thumbnail", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusNotFound, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("異常系_元画像が既に削除されている場合は409を返しサムネイルを変更しない", func(t *testing.T) {
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = t.TempDir()
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		eventStore := eventstoretest.New(t)
+		mediaDir := seedProcessedImage(t, eventStore, "regen-media-id", "user-123")
+		if err := os.Remove(filepath.Join(mediaDir, "photo.png")); err != nil {
+			t.Fatalf("元画像の削除に失敗: %v", err)
+		}
+		s := setupTestServer(t, eventStore.URL)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/regen-media-id/regenerate-thumbnail", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+		pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+		if err != nil {
+			t.Fatalf("アウトボックスの取得に失敗: %v", err)
+		}
+		if len(pending) != 0 {
+			t.Errorf("イベントを記録しないこと: %+v", pending)
+		}
+	})
+
+	t.Run("異常系_100件を超えるイベントの末尾で削除されたメディアは409を返す", func(t *testing.T) {
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = t.TempDir()
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		// Event Storeの既定の件数（100件）を超える履歴の末尾で削除し、全ページから状態を求めることを確認する
+		eventStore := eventstoretest.New(t)
+		seedProcessedImage(t, eventStore, "long-media-id", "user-123")
+		for range 150 {
+			eventStore.Seed(t, "media-long-media-id", event.AggregateTypeMedia, event.TypeMediaProcessed, event.MediaProcessedData{})
+		}
+		eventStore.Seed(t, "media-long-media-id", event.AggregateTypeMedia, event.TypeMediaDeleted, event.MediaDeletedData{UserID: "user-123"})
+		s := setupTestServer(t, eventStore.URL)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/long-media-id/regenerate-thumbnail", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	})
+
+	stateTests := []struct {
+		name      string
+		eventType event.Type
+		pending   bool
+	}{
+		{name: "異常系_削除済みのメディアは409を返す", eventType: event.TypeMediaDeleted},
+		{name: "異常系_要確認のメディアは409を返す", eventType: event.TypeMediaFlagged},
+		{name: "異常系_未配送の削除イベントがあるメディアは409を返す", eventType: event.TypeMediaDeleted, pending: true},
+	}
+	for _, tt := range stateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			origBaseDir := mediaBaseDir
+			mediaBaseDir = t.TempDir()
+			t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+			eventStore := eventstoretest.New(t)
+			seedProcessedImage(t, eventStore, "regen-media-id", "user-123")
+			s := setupTestServer(t, eventStore.URL)
+			if tt.pending {
+				if err := s.queries.EnqueueOutboxEvent(t.Context(), mediacommanddb.EnqueueOutboxEventParams{
+					AggregateID:   "media-regen-media-id",
+					AggregateType: string(event.AggregateTypeMedia),
+					EventType:     string(tt.eventType),
+					Data:          "{}",
+				}); err != nil {
+					t.Fatalf("アウトボックスへの記録に失敗: %v", err)
+				}
+			} else {
+				eventStore.Seed(t, "media-regen-media-id", event.AggregateTypeMedia, tt.eventType, map[string]string{})
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/media/regen-media-id/regenerate-thumbnail", nil)
+			req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusConflict {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusConflict, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+// thumbnailAlgorithm はサムネイル生成時のリサイズの補間方法。
+type thumbnailAlgorithm string
+
+const (
+	// thumbnailAlgorithmNearest は最近傍補間法。高速だが縮小時に輪郭がジャギーになる。
+	thumbnailAlgorithmNearest thumbnailAlgorithm = "nearest"
+	// thumbnailAlgorithmBilinear はバイリニア補間法。周囲4ピクセルを距離で重み付けして滑らかに縮小する。
+	thumbnailAlgorithmBilinear thumbnailAlgorithm = "bilinear"
+)
+
+// parseThumbnailAlgorithm は文字列をリサイズの補間方法に変換する。
+// 空文字の場合は既定のbilinearとする。
+func parseThumbnailAlgorithm(v string) (thumbnailAlgorithm, error) {
+	switch thumbnailAlgorithm(strings.ToLower(strings.TrimSpace(v))) {
+	case "", thumbnailAlgorithmBilinear:
+		return thumbnailAlgorithmBilinear, nil
+	case thumbnailAlgorithmNearest:
+		return thumbnailAlgorithmNearest, nil
+	default:
+		return "", fmt.Errorf("不明なリサイズの補間方法です: %s（nearest または bilinear を指定してください）", v)
+	}
+}
+
+// resizeBilinear はバイリニア補間法で画像をリサイズする。
+// 出力サイズ・アスペクト比の維持・中央配置・余白をbackgroundで埋める挙動はresizeNearestNeighborと同じ。
+func resizeBilinear(src image.Image, width, height int, background color.Color) *image.RGBA {
+	srcBounds := src.Bounds()
+	srcW := srcBounds.Dx()
+	srcH := srcBounds.Dy()
+
+	// アスペクト比を維持したスケーリング係数を算出する。
+	scaleX := float64(width) / float64(srcW)
+	scaleY := float64(height) / float64(srcH)
+	scale := math.Min(scaleX, scaleY)
+
+	// リサイズ後の実際のサイズを算出する。
+	newW := int(float64(srcW) * scale)
+	newH := int(float64(srcH) * scale)
+
+	// 出力画像を背景色で初期化する。
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+	// 中央に配置するためのオフセットを算出する。
+	offsetX := (width - newW) / 2
+	offsetY := (height - newH) / 2
+
+	// 出力ピクセルの中心に対応する元画像上の座標を補間する。
+	for y := 0; y < newH; y++ {
+		srcY := (float64(y)+0.5)/scale - 0.5
+		for x := 0; x < newW; x++ {
+			srcX := (float64(x)+0.5)/scale - 0.5
+			dst.Set(offsetX+x, offsetY+y, bilinearAt(src, srcX, srcY))
+		}
+	}
+
+	return dst
+}
+
+// resizeCoverBilinear はバイリニア補間法で画像をリサイズし、指定サイズを余白なしで埋める。
+// スケーリングと中央クロップの挙動はresizeCoverと同じ。
+func resizeCoverBilinear(src image.Image, width, height int) *image.RGBA {
+	srcBounds := src.Bounds()
+	srcW := srcBounds.Dx()
+	srcH := srcBounds.Dy()
+
+	// 出力サイズを完全に覆うスケーリング係数を算出する。
+	scaleX := float64(width) / float64(srcW)
+	scaleY := float64(height) / float64(srcH)
+	scale := math.Max(scaleX, scaleY)
+
+	// 元画像上で切り出す領域の左上座標を算出する（中央クロップ）。
+	cropX := (float64(srcW) - float64(width)/scale) / 2
+	cropY := (float64(srcH) - float64(height)/scale) / 2
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		srcY := cropY + (float64(y)+0.5)/scale - 0.5
+		for x := 0; x < width; x++ {
+			srcX := cropX + (float64(x)+0.5)/scale - 0.5
+			dst.Set(x, y, bilinearAt(src, srcX, srcY))
+		}
+	}
+
+	return dst
+}
+
+// bilinearAt は元画像の左上を原点とする座標(fx, fy)の色を周囲4ピクセルから補間して返す。
+// 画像の外側を指す座標は端のピクセルに丸める。アルファ乗算済みの値で補間するため、
+// 透過部分の色が不透明部分ににじまない。
+func bilinearAt(src image.Image, fx, fy float64) color.RGBA64 {
+	bounds := src.Bounds()
+	fx = math.Max(0, math.Min(fx, float64(bounds.Dx()-1)))
+	fy = math.Max(0, math.Min(fy, float64(bounds.Dy()-1)))
+
+	x0 := int(fx)
+	y0 := int(fy)
+	x1 := min(x0+1, bounds.Dx()-1)
+	y1 := min(y0+1, bounds.Dy()-1)
+	wx := fx - float64(x0)
+	wy := fy - float64(y0)
+
+	var sum [4]float64
+	for _, p := range []struct {
+		x, y   int
+		weight float64
+	}{
+		{x: x0, y: y0, weight: (1 - wx) * (1 - wy)},
+		{x: x1, y: y0, weight: wx * (1 - wy)},
+		{x: x0, y: y1, weight: (1 - wx) * wy},
+		{x: x1, y: y1, weight: wx * wy},
+	} {
+		r, g, b, a := src.At(bounds.Min.X+p.x, bounds.Min.Y+p.y).RGBA()
+		sum[0] += float64(r) * p.weight
+		sum[1] += float64(g) * p.weight
+		sum[2] += float64(b) * p.weight
+		sum[3] += float64(a) * p.weight
+	}
+
+	return color.RGBA64{
+		R: uint16(math.Round(sum[0])),
+		G: uint16(math.Round(sum[1])),
+		B: uint16(math.Round(sum[2])),
+		A: uint16(math.Round(sum[3])),
+	}
+}
@@ -0,0 +1,170 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseThumbnailAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    thumbnailAlgorithm
+		wantErr bool
+	}{
+		{input: "", want: thumbnailAlgorithmBilinear},
+		{input: "bilinear", want: thumbnailAlgorithmBilinear},
+		{input: " NEAREST ", want: thumbnailAlgorithmNearest},
+		{input: "bicubic", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseThumbnailAlgorithm(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseThumbnailAlgorithm(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseThumbnailAlgorithm(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResizeBilinear(t *testing.T) {
+	t.Parallel()
+
+	// 左半分が黒、右半分が白の横長画像を作成する。
+	newSplitImage := func(w, h int) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				if x < w/2 {
+					img.Set(x, y, color.RGBA{A: 255})
+				} else {
+					img.Set(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+				}
+			}
+		}
+		return img
+	}
+
+	t.Run("正常系_最近傍補間と同じサイズ・配置・余白で出力する", func(t *testing.T) {
+		t.Parallel()
+
+		src := newSplitImage(800, 400)
+		background := color.RGBA{R: 10, G: 20, B: 30, A: 255}
+		bilinear := resizeBilinear(src, 200, 200, background)
+		nearest := resizeNearestNeighbor(src, 200, 200, background)
+
+		if bilinear.Bounds() != nearest.Bounds() {
+			t.Fatalf("期待するサイズ %v, 実際のサイズ %v", nearest.Bounds(), bilinear.Bounds())
+		}
+		// 200x100に縮小して上下50ピクセルずつ余白になる。
+		for _, y := range []int{0, 49, 150, 199} {
+			if got := bilinear.RGBAAt(100, y); got != background {
+				t.Errorf("y=%d: 余白の色が背景色ではない: %v", y, got)
+			}
+		}
+		if got := bilinear.RGBAAt(10, 100); got != (color.RGBA{A: 255}) {
+			t.Errorf("左側の色が黒ではない: %v", got)
+		}
+		if got := bilinear.RGBAAt(190, 100); got != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+			t.Errorf("右側の色が白ではない: %v", got)
+		}
+	})
+
+	t.Run("正常系_境界の色を中間色で滑らかに補間する", func(t *testing.T) {
+		t.Parallel()
+
+		// 1ピクセル幅の白黒の縞を半分に縮小すると、最近傍補間では縞の片方だけが残るが、
+		// バイリニア補間では隣り合う2ピクセルの平均の灰色になる。
+		src := image.NewRGBA(image.Rect(0, 0, 400, 400))
+		for y := 0; y < 400; y++ {
+			for x := 0; x < 400; x++ {
+				if x%2 == 1 {
+					src.Set(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+				} else {
+					src.Set(x, y, color.RGBA{A: 255})
+				}
+			}
+		}
+
+		result := resizeBilinear(src, 200, 200, color.White)
+		for _, x := range []int{0, 57, 199} {
+			if got := result.RGBAAt(x, 100); got.R < 120 || got.R > 135 {
+				t.Errorf("x=%d: 期待する中間色（R≒128）, 実際の色 %v", x, got)
+			}
+		}
+	})
+
+	t.Run("正常系_coverは余白なしで中央をクロップする", func(t *testing.T) {
+		t.Parallel()
+
+		src := newSplitImage(800, 400)
+		result := resizeThumbnail(src, 200, 200, thumbnailFitCover, thumbnailAlgorithmBilinear, color.White)
+
+		if got := result.RGBAAt(0, 0); got != (color.RGBA{A: 255}) {
+			t.Errorf("左上の色が黒ではない: %v", got)
+		}
+		if got := result.RGBAAt(199, 199); got != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+			t.Errorf("右下の色が白ではない: %v", got)
+		}
+	})
+}
+
+func TestHandleProcessThumbnailAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_省略時はbilinearで生成する", func(t *testing.T) {
+		t.Parallel()
+
+		testImagePath := filepath.Join(t.TempDir(), "test.png")
+		createTestImage(t, testImagePath, 400, 300)
+		s := setupTestServer(t, "http://localhost:0")
+
+		reqBody, _ := json.Marshal(processRequest{StoragePath: testImagePath})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/test-media-id/process", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		if resp["algorithm"] != string(thumbnailAlgorithmBilinear) {
+			t.Errorf("期待するalgorithm %q, 実際のalgorithm %v", thumbnailAlgorithmBilinear, resp["algorithm"])
+		}
+	})
+
+	t.Run("異常系_不明な補間方法の場合400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t, "http://localhost:0")
+
+		reqBody, _ := json.Marshal(processRequest{StoragePath: "/tmp/test.png", Algorithm: "bicubic"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/test-media-id/process", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+}
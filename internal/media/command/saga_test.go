@@ -0,0 +1,121 @@
+package command
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "modernc.org/sqlite"
+	mediacommanddb "github.com/nao1215/micro/internal/media/command/db"
+	"github.com/nao1215/micro/internal/saga"
+	sagadb "github.com/nao1215/micro/internal/saga/db"
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
+	"github.com/nao1215/micro/pkg/httpclient"
+	"github.com/nao1215/micro/pkg/middleware"
+	"github.com/nao1215/micro/pkg/migration"
+)
+
+// setupTestSagaQueries はSagaのマイグレーションを適用したインメモリSQLiteのクエリを返す。
+func setupTestSagaQueries(t *testing.T) *sagadb.Queries {
+	t.Helper()
+
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("インメモリSQLiteの接続に失敗: %v", err)
+	}
+	// インメモリDBは接続ごとに別のDBになるため、接続を1本に制限する
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := migration.Run(sqlDB, os.DirFS(filepath.Join("..", "..", "saga")), "migrations"); err != nil {
+		t.Fatalf("Sagaのスキーマ初期化に失敗: %v", err)
+	}
+	return sagadb.New(sqlDB)
+}
+
+// TestSagaCompensation はSagaの補償アクションを実際の補償ハンドラに対して実行できることを検証する。
+func TestSagaCompensation(t *testing.T) {
+	// mediaBaseDirを差し替えるため、並列実行はしない
+	t.Run("正常系_メディア処理の失敗時にSagaがアップロードを補償して失敗として完了する", func(t *testing.T) {
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = t.TempDir()
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		const (
+			signingKey  = "internal-signing-key"
+			mediaID     = "saga-media-id"
+			aggregateID = "media-" + mediaID
+		)
+		mediaDir := filepath.Join(mediaBaseDir, mediaID)
+		if err := os.MkdirAll(mediaDir, 0o755); err != nil {
+			t.Fatalf("テスト用メディアディレクトリの作成に失敗: %v", err)
+		}
+
+		eventStore := eventstoretest.New(t)
+		seedUploadedMedia(t, eventStore, mediaID, "user-123")
+		s := setupTestServer(t, eventStore.URL)
+
+		// 本番と同じく内部APIの署名を検証する。サムネイル生成は本テストの対象外のため成功を返す。
+		router := gin.New()
+		internal := router.Group("/api/v1/media", middleware.VerifyInternalSignature(signingKey))
+		internal.POST("/:id/process", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+		internal.POST("/:id/compensate", s.handleCompensate())
+		mediaCommand := httptest.NewServer(router)
+		t.Cleanup(mediaCommand.Close)
+
+		sagaQueries := setupTestSagaQueries(t)
+		orchestrator := saga.NewOrchestrator(
+			sagaQueries,
+			httpclient.New(eventStore.URL, httpclient.WithSigningKey(signingKey)),
+			httpclient.New(mediaCommand.URL, httpclient.WithSigningKey(signingKey)),
+			httpclient.New("http://localhost:19003", httpclient.WithSigningKey(signingKey)),
+			httpclient.New("http://localhost:19004", httpclient.WithSigningKey(signingKey)),
+		)
+
+		uploaded, err := json.Marshal(event.MediaUploadedData{
+			UserID:      "user-123",
+			Filename:    "photo.png",
+			ContentType: "image/png",
+			Size:        10,
+			StoragePath: filepath.Join(mediaDir, "photo.png"),
+		})
+		if err != nil {
+			t.Fatalf("イベントデータのシリアライズに失敗: %v", err)
+		}
+		ctx := t.Context()
+		orchestrator.HandleEvent(ctx, string(event.TypeMediaUploaded), aggregateID, string(uploaded))
+
+		active, err := sagaQueries.ListActiveSagas(ctx)
+		if err != nil {
+			t.Fatalf("Sagaの取得に失敗: %v", err)
+		}
+		if len(active) != 1 {
+			t.Fatalf("実行中のSaga数 = %d; 期待値 = 1", len(active))
+		}
+
+		orchestrator.HandleEvent(ctx, string(event.TypeMediaProcessingFailed), aggregateID, `{"reason":"テスト用の処理失敗"}`)
+
+		got, err := sagaQueries.GetSagaByID(ctx, active[0].ID)
+		if err != nil {
+			t.Fatalf("Sagaの取得に失敗: %v", err)
+		}
+		if got.Status != "failed" {
+			t.Errorf("Sagaのステータス = %q; 期待値 = %q（補償が完了していない）", got.Status, "failed")
+		}
+		if _, err := os.Stat(mediaDir); !os.IsNotExist(err) {
+			t.Error("メディアディレクトリが削除されていません")
+		}
+		if _, err := s.queries.GetPendingOutboxEventData(ctx, mediacommanddb.GetPendingOutboxEventDataParams{
+			AggregateID: aggregateID,
+			EventType:   string(event.TypeMediaUploadCompensated),
+		}); err != nil {
+			t.Errorf("%sのMediaUploadCompensatedイベントが記録されていません: %v", aggregateID, err)
+		}
+	})
+}
@@ -1,10 +1,17 @@
 package command
 
 import (
+	"database/sql"
+	"embed"
 	"fmt"
 	"os"
+
+	"github.com/nao1215/micro/pkg/migration"
 )
 
+//go:embed migrations
+var migrationsFS embed.FS
+
 // mediaBaseDir はメディアファイルの保存先ベースディレクトリ。
 // テスト時に差し替え可能にするためvarとして宣言する。
 var mediaBaseDir = "/data/media"
@@ -17,3 +24,8 @@ func initStorage() error {
 	}
 	return nil
 }
+
+// initSchema はマイグレーションを実行してSQLiteデータベースにスキーマを適用する。
+func initSchema(db *sql.DB) error {
+	return migration.Run(db, migrationsFS, "migrations")
+}
@@ -1,12 +1,15 @@
 package command
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"image/jpeg"
 	// image/png、image/gif、webp はデコード用に副作用インポートする。
 	_ "image/gif"
 	_ "image/png"
@@ -17,11 +20,15 @@ import (
 	"math"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+	mediacommanddb "github.com/nao1215/micro/internal/media/command/db"
 	"github.com/nao1215/micro/pkg/event"
 	"github.com/nao1215/micro/pkg/httpclient"
 	"github.com/nao1215/micro/pkg/middleware"
@@ -31,45 +38,188 @@ import (
 // テスト時に差し替え可能にするためvarとして宣言する。
 var maxUploadSize int64 = 50 << 20
 
-// thumbnailSize はサムネイル画像の幅・高さ（ピクセル）。
+// thumbnailSize はリクエストでサイズの指定がない場合のサムネイル画像の幅・高さ（ピクセル）。
 const thumbnailSize = 200
 
+const (
+	// minThumbnailSize はリクエストで指定できるサムネイル画像の幅・高さの最小値（ピクセル）。
+	minThumbnailSize = 16
+	// maxThumbnailSize はリクエストで指定できるサムネイル画像の幅・高さの最大値（ピクセル）。
+	maxThumbnailSize = 1024
+)
+
+// thumbnailFit はサムネイル生成時に元画像を出力サイズへ当てはめる方法。
+type thumbnailFit string
+
+const (
+	// thumbnailFitContain は元画像全体が収まるように縮小し、余白を白で埋める。
+	thumbnailFitContain thumbnailFit = "contain"
+	// thumbnailFitCover は出力サイズを余白なしで埋めるように拡大縮小し、はみ出す部分を中央基準でクロップする。
+	thumbnailFitCover thumbnailFit = "cover"
+)
+
+// parseThumbnailFit は文字列をフィットモードに変換する。
+// 空文字の場合は既定のcontainとする。
+func parseThumbnailFit(v string) (thumbnailFit, error) {
+	switch thumbnailFit(strings.ToLower(strings.TrimSpace(v))) {
+	case "", thumbnailFitContain:
+		return thumbnailFitContain, nil
+	case thumbnailFitCover:
+		return thumbnailFitCover, nil
+	default:
+		return "", fmt.Errorf("不明なサムネイルのフィットモードです: %s（contain または cover を指定してください）", v)
+	}
+}
+
 // Server はメディアコマンドサービスのHTTPサーバー。
 type Server struct {
 	// router はGinのHTTPルーター。
 	router *gin.Engine
 	// port はサーバーのリッスンポート。
 	port string
-	// eventClient はEvent StoreへのHTTPクライアント。
+	// queries はsqlcが生成したクエリ実行オブジェクト。
+	queries *mediacommanddb.Queries
+	// db はSQLiteデータベース接続。イベント配送用のアウトボックスを保持する。
+	db *sql.DB
+	// relay はアウトボックスに記録したイベントをEvent Storeへ配送するバックグラウンドプロセス。
+	relay *OutboxRelay
+	// eventClient はEvent StoreのAPIを呼び出すHTTPクライアント。メディアの所有者の確認に使用する。
 	eventClient *httpclient.Client
+	// thumbnailFit はリクエストで指定がない場合に使用するサムネイルのフィットモード。
+	// ゼロ値の場合はcontainとして扱う。
+	thumbnailFit thumbnailFit
+	// thumbnailFormat はリクエストで指定がない場合に使用するサムネイルの出力形式（環境変数THUMBNAIL_FORMAT）。
+	// ゼロ値の場合はautoとして扱う。
+	thumbnailFormat thumbnailFormat
+	// ffprobePath は動画メタデータ抽出に使用するffprobeのパス。空の場合は抽出をスキップする。
+	ffprobePath string
+	// metadataStrip はメディア処理時にメタデータを除去する対象。
+	// ゼロ値の場合はthumbnailとして扱う。
+	metadataStrip metadataStripMode
+	// stripEXIF はアップロード時にJPEGからEXIF等のメタデータを除去するかどうか（環境変数STRIP_EXIF）。
+	// 除去したファイルを保存するため、MediaUploadedイベントのsizeは除去後のサイズになる。
+	stripEXIF bool
+	// contentTypeCheck は拡張子・Content-Type・実体の不整合を検出した場合の動作（環境変数UPLOAD_CONTENT_TYPE_CHECK）。
+	// ゼロ値の場合はlenientとして扱う。
+	contentTypeCheck contentTypeCheckMode
+	// fixExtension はアップロード時にファイル名の拡張子を実体の種類に合わせて補正するかどうか（環境変数UPLOAD_FIX_EXTENSION）。
+	fixExtension bool
+	// storageShardDepth はメディアの保存ディレクトリを分散する階層の深さ（環境変数MEDIA_STORAGE_SHARD_DEPTH）。
+	// ゼロ値の場合は分散せずmediaBaseDir直下に保存する。
+	storageShardDepth int
+	// moderator はメディア処理後にコンテンツを審査するModerator。nilの場合は審査しない。
+	moderator Moderator
+	// processingTimeout は1件のサムネイル生成にかけられる時間の上限（環境変数THUMBNAIL_PROCESSING_TIMEOUT）。
+	// ゼロ値の場合はdefaultProcessingTimeoutとして扱う。
+	processingTimeout time.Duration
+	// adminUserIDs は管理者として扱うユーザーIDの集合（環境変数ADMIN_USER_IDS）。
+	// 管理者は他のユーザーのメディアのサムネイルも再生成できる。
+	adminUserIDs map[string]struct{}
+	// resize はサムネイルのリサイズに使用する関数。nilの場合はresizeThumbnailを使用する。
+	resize func(src image.Image, width, height int, fit thumbnailFit, algorithm thumbnailAlgorithm, background color.Color) *image.RGBA
 }
 
 // NewServer は新しいメディアコマンドサーバーを生成する。
-// ファイル保存ディレクトリの初期化も行う。
+// ファイル保存ディレクトリとSQLiteデータベースの初期化、およびOutboxRelayのバックグラウンド起動を行う。
 func NewServer(port string) (*Server, error) {
 	if err := initStorage(); err != nil {
 		return nil, fmt.Errorf("ストレージ初期化に失敗: %w", err)
 	}
 
+	sqlDB, err := sql.Open("sqlite", "/data/media-command.db?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("データベース接続に失敗: %w", err)
+	}
+
+	if err := initSchema(sqlDB); err != nil {
+		return nil, fmt.Errorf("スキーマ初期化に失敗: %w", err)
+	}
+
 	eventstoreURL := os.Getenv("EVENTSTORE_URL")
 	if eventstoreURL == "" {
 		eventstoreURL = "http://localhost:8084"
 	}
 
+	fit, err := parseThumbnailFit(os.Getenv("THUMBNAIL_FIT"))
+	if err != nil {
+		return nil, fmt.Errorf("THUMBNAIL_FITの設定が不正です: %w", err)
+	}
+
+	format, err := parseThumbnailFormat(os.Getenv("THUMBNAIL_FORMAT"))
+	if err != nil {
+		return nil, fmt.Errorf("THUMBNAIL_FORMATの設定が不正です: %w", err)
+	}
+
+	metadataStrip, err := parseMetadataStripMode(os.Getenv("STRIP_METADATA"))
+	if err != nil {
+		return nil, fmt.Errorf("STRIP_METADATAの設定が不正です: %w", err)
+	}
+
+	stripEXIF, err := parseStripEXIF(os.Getenv("STRIP_EXIF"))
+	if err != nil {
+		return nil, err
+	}
+
+	contentTypeCheck, err := parseContentTypeCheckMode(os.Getenv("UPLOAD_CONTENT_TYPE_CHECK"))
+	if err != nil {
+		return nil, fmt.Errorf("UPLOAD_CONTENT_TYPE_CHECKの設定が不正です: %w", err)
+	}
+
+	fixExtension, err := parseFixExtension(os.Getenv("UPLOAD_FIX_EXTENSION"))
+	if err != nil {
+		return nil, err
+	}
+
+	storageShardDepth, err := parseStorageShardDepth(os.Getenv("MEDIA_STORAGE_SHARD_DEPTH"))
+	if err != nil {
+		return nil, err
+	}
+
+	processingTimeout, err := parseProcessingTimeout(os.Getenv("THUMBNAIL_PROCESSING_TIMEOUT"))
+	if err != nil {
+		return nil, err
+	}
+
+	ffprobePath, err := exec.LookPath("ffprobe")
+	if err != nil {
+		log.Printf("警告: ffprobeが見つからないため、動画メタデータの抽出をスキップします: %v", err)
+		ffprobePath = ""
+	}
+
 	router := gin.New()
-	router.Use(middleware.Recovery())
-	router.Use(gin.Logger())
+	router.Use(middleware.DefaultChain(middleware.ChainConfig{})...)
 
 	// マルチパートフォームの最大メモリを設定する。
 	router.MaxMultipartMemory = maxUploadSize
 
+	queries := mediacommanddb.New(sqlDB)
+	eventClient := httpclient.New(eventstoreURL)
+	relay := NewOutboxRelay(queries, eventClient)
+
 	s := &Server{
-		router:      router,
-		port:        port,
-		eventClient: httpclient.New(eventstoreURL),
+		router:            router,
+		port:              port,
+		queries:           queries,
+		db:                sqlDB,
+		relay:             relay,
+		eventClient:       eventClient,
+		thumbnailFit:      fit,
+		thumbnailFormat:   format,
+		ffprobePath:       ffprobePath,
+		metadataStrip:     metadataStrip,
+		stripEXIF:         stripEXIF,
+		contentTypeCheck:  contentTypeCheck,
+		fixExtension:      fixExtension,
+		storageShardDepth: storageShardDepth,
+		moderator:         NopModerator{},
+		processingTimeout: processingTimeout,
+		adminUserIDs:      parseAdminUserIDs(os.Getenv("ADMIN_USER_IDS")),
 	}
 	s.setupRoutes()
 
+	// バックグラウンドでアウトボックスの配送を開始する
+	relay.Start(context.Background())
+
 	return s, nil
 }
 
@@ -86,7 +236,11 @@ func (s *Server) setupRoutes() {
 	}
 
 	api := s.router.Group("/api/v1")
-	api.Use(middleware.JWTAuth(jwtSecret))
+	api.Use(middleware.JWTAuth(jwtSecret,
+		middleware.WithPreviousSecrets(middleware.SplitSecrets(os.Getenv("JWT_SECRET_PREVIOUS"))...),
+		middleware.WithIssuer(middleware.Issuer),
+		middleware.WithAudience(middleware.Audience),
+	))
 	{
 		media := api.Group("/media")
 		{
@@ -94,24 +248,30 @@ func (s *Server) setupRoutes() {
 			media.POST("", s.handleUpload())
 			// メディアの削除
 			media.DELETE("/:id", s.handleDelete())
+			// サムネイルの再生成（所有者または管理者）
+			media.POST("/:id/regenerate-thumbnail", s.handleRegenerateThumbnail())
 		}
 	}
 
 	// 以下は認証不要の内部API（Sagaサービスやブラウザから直接呼ばれるため）
+	// サービスからのみ呼ばれるAPIは、INTERNAL_SIGNING_KEYが設定されている場合にリクエストの署名を検証する
+	verifySignature := middleware.VerifyInternalSignature(os.Getenv("INTERNAL_SIGNING_KEY"))
 	internal := s.router.Group("/api/v1/media")
 	{
 		// サムネイル画像の取得（img要素から直接参照される）
 		internal.GET("/:id/thumbnail", s.handleThumbnail())
+		// 元ファイルの取得（Gatewayの署名付きURLダウンロードから呼び出される内部API）
+		internal.GET("/:id/file", verifySignature, s.handleFile())
 		// サムネイル生成（Sagaから呼び出される内部API）
-		internal.POST("/:id/process", s.handleProcess())
+		internal.POST("/:id/process", verifySignature, s.handleProcess())
+		// サムネイルの再生成（media-queryの一括再生成ジョブから呼び出される内部API）
+		internal.POST("/:id/thumbnail/regenerate", verifySignature, s.handleInternalRegenerateThumbnail())
 		// 補償アクション: アップロード済みメディアの無効化（Sagaから呼び出される内部API）
-		internal.POST("/:id/compensate", s.handleCompensate())
+		internal.POST("/:id/compensate", verifySignature, s.handleCompensate())
 	}
 
 	// ヘルスチェック
-	s.router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "media-command"})
-	})
+	s.router.GET("/health", middleware.HealthCheck("media-command", s.db))
 }
 
 // appendEventRequest はEvent Storeへのイベント追記リクエスト。
@@ -126,24 +286,37 @@ type appendEventRequest struct {
 	Data json.RawMessage `json:"data"`
 }
 
-// emitEvent はEvent Storeにイベントを送信する。
-// dataにはイベント固有のデータ構造体を渡す。JSON形式にシリアライズしてから送信する。
-func (s *Server) emitEvent(c *gin.Context, aggregateID string, eventType event.Type, data any) error {
+// enqueueEvent はイベントをアウトボックスに記録し、OutboxRelayに配送を通知する。
+// dataにはイベント固有のデータ構造体を渡す。JSON形式にシリアライズしてから記録する。
+// Event Storeへの配送はバックグラウンドで行うため、Event Storeが停止していても記録は成功する。
+func (s *Server) enqueueEvent(c *gin.Context, aggregateID string, eventType event.Type, data any) error {
+	if err := enqueueOutboxEvent(c, s.queries, aggregateID, eventType, data); err != nil {
+		return err
+	}
+
+	s.relay.Notify()
+	return nil
+}
+
+// enqueueOutboxEvent はqを使用してイベントをアウトボックスに記録する。
+// トランザクション内で他のレコードと一緒に記録する場合に使用する。
+// リクエストの追跡情報（X-Correlation-ID・X-Causation-ID）も記録し、配送時にEvent Storeへ伝播する。
+func enqueueOutboxEvent(c *gin.Context, q *mediacommanddb.Queries, aggregateID string, eventType event.Type, data any) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("イベントデータのシリアライズに失敗: %w", err)
 	}
 
-	req := appendEventRequest{
+	if err := q.EnqueueOutboxEvent(c.Request.Context(), mediacommanddb.EnqueueOutboxEventParams{
 		AggregateID:   aggregateID,
 		AggregateType: string(event.AggregateTypeMedia),
 		EventType:     string(eventType),
-		Data:          jsonData,
-	}
-
-	var resp map[string]any
-	if err := s.eventClient.PostJSON(c.Request.Context(), "/api/v1/events", req, &resp); err != nil {
-		return fmt.Errorf("Event Storeへのイベント送信に失敗: %w", err)
+		Data:          string(jsonData),
+		UserID:        middleware.GetUserID(c),
+		CorrelationID: httpclient.CorrelationID(c.Request.Context()),
+		CausationID:   httpclient.CausationID(c.Request.Context()),
+	}); err != nil {
+		return fmt.Errorf("アウトボックスへのイベント記録に失敗: %w", err)
 	}
 	return nil
 }
@@ -164,7 +337,10 @@ type uploadResponse struct {
 
 // handleUpload はメディアファイルのアップロードを処理するハンドラを返す。
 // マルチパートフォームからファイルを受け取り、ディスクに保存し、
-// MediaUploadedイベントをEvent Storeに発行する。
+// MediaUploadedイベントをアウトボックスに記録する。
+// stripEXIFが有効な場合は、保存したJPEGからEXIF等のメタデータを除去してからイベントを記録する。
+// Idempotency-Keyヘッダーが指定された場合はメディアIDをキーから決定的に導出し、
+// 同じキーでの再送には新しいメディアを作らず、記録済みのメディアを200で返す。
 func (s *Server) handleUpload() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
@@ -194,17 +370,87 @@ func (s *Server) handleUpload() gin.HandlerFunc {
 			return
 		}
 
-		// 保存先ディレクトリを作成する。
+		// 拡張子・申告されたContent-Type・実体の整合性を検証する。
+		// 先頭バイトは判定に使用した後、保存時に残りのデータと連結して書き込む。
+		head := make([]byte, sniffLen)
+		n, err := io.ReadFull(file, head)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			log.Printf("ファイルの読み込みに失敗: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ファイルの読み込みに失敗しました"})
+			return
+		}
+		head = head[:n]
+		// 申告されたContent-Typeと実体の種類（画像・動画）が異なる場合は、検証モードに関わらず拒否する
+		if err := verifyContentType(contentType, head); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filename := filepath.Base(header.Filename)
+		check := checkContentType(filename, contentType, head)
+		if problem := check.mismatch(); problem != "" {
+			if s.contentTypeCheck == contentTypeCheckStrict {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ファイルの種類が一致しません: %s", problem)})
+				return
+			}
+			log.Printf("警告: ファイルの種類が一致しません（%s）: filename=%s", problem, filename)
+			if check.sniffed != "" {
+				contentType = check.sniffed
+			}
+		}
+		if s.fixExtension {
+			filename = check.fixExtension(filename)
+		}
+
+		// Idempotency-Keyが指定された場合、同じキーで記録済みのアップロードがあればそれを返す。
+		idempotencyKey := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Idempotency-Keyが長すぎます（最大%d文字）", maxIdempotencyKeyLength)})
+			return
+		}
 		mediaID := uuid.New().String()
-		mediaDir := filepath.Join(mediaBaseDir, mediaID)
-		if err := os.MkdirAll(mediaDir, 0o755); err != nil {
+		if idempotencyKey != "" {
+			existing, err := s.queries.GetUploadIdempotencyKey(c.Request.Context(), mediacommanddb.GetUploadIdempotencyKeyParams{
+				UserID:         userID,
+				IdempotencyKey: idempotencyKey,
+			})
+			if err == nil {
+				c.Header("Idempotent-Replayed", "true")
+				c.JSON(http.StatusOK, uploadResponse{
+					ID:          existing.MediaID,
+					Filename:    existing.Filename,
+					ContentType: existing.ContentType,
+					Size:        existing.Size,
+					StoragePath: existing.StoragePath,
+				})
+				return
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("Idempotency-Keyの確認に失敗: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "アップロード履歴の確認に失敗しました"})
+				return
+			}
+			mediaID = idempotentMediaID(userID, idempotencyKey)
+		}
+
+		// 保存先ディレクトリを作成する。
+		// Idempotency-Key付きの場合はディレクトリの作成で処理を排他し、同じキーの同時アップロードを拒否する。
+		mediaDir := s.mediaDir(mediaID)
+		if err := os.MkdirAll(filepath.Dir(mediaDir), 0o755); err != nil {
+			log.Printf("メディアディレクトリの作成に失敗: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ファイル保存先の作成に失敗しました"})
+			return
+		}
+		if err := os.Mkdir(mediaDir, 0o755); err != nil {
+			if errors.Is(err, os.ErrExist) && idempotencyKey != "" {
+				c.JSON(http.StatusConflict, gin.H{"error": "同じIdempotency-Keyのアップロードを処理中です"})
+				return
+			}
 			log.Printf("メディアディレクトリの作成に失敗: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "ファイル保存先の作成に失敗しました"})
 			return
 		}
 
 		// ファイルをディスクに保存する。
-		filename := filepath.Base(header.Filename)
 		storagePath := filepath.Join(mediaDir, filename)
 		dst, err := os.Create(storagePath)
 		if err != nil {
@@ -214,14 +460,48 @@ func (s *Server) handleUpload() gin.HandlerFunc {
 		}
 		defer dst.Close()
 
-		written, err := io.Copy(dst, file)
+		// 実際に書き込んだバイト数で上限とheader.Size（申告サイズ）との一致を確認する。
+		// 上限超過や途中で終了したアップロードは、保存途中のファイルを残さないようディレクトリごと削除する。
+		written, err := copyUpload(dst, io.MultiReader(bytes.NewReader(head), file), header.Size)
 		if err != nil {
-			log.Printf("ファイルの書き込みに失敗: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "ファイルの書き込みに失敗しました"})
+			dst.Close()
+			if removeErr := os.RemoveAll(mediaDir); removeErr != nil {
+				log.Printf("クリーンアップ失敗: %v", removeErr)
+			}
+			switch {
+			case errors.Is(err, errUploadTooLarge):
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("ファイルサイズが上限を超えています（最大%dMB）", maxUploadSize/(1<<20))})
+			case errors.Is(err, errIncompleteUpload):
+				log.Printf("警告: 不完全なアップロードを破棄しました: filename=%s, error=%v", filename, err)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "アップロードが完了していません。ファイルを再送してください"})
+			default:
+				log.Printf("ファイルの書き込みに失敗: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "ファイルの書き込みに失敗しました"})
+			}
 			return
 		}
 
-		// MediaUploadedイベントをEvent Storeに発行する。
+		// 位置情報などのプライバシー情報を保存しないよう、メタデータを除去したファイルに置き換える。
+		// 再エンコードによりサイズが変わるため、イベントには除去後のサイズを記録する。
+		if s.stripEXIF {
+			// 置き換え前に書き込み中のファイルを閉じる（deferのCloseは二重クローズのエラーを無視する）
+			dst.Close()
+			stripped, err := stripUploadedFileEXIF(storagePath)
+			if err != nil {
+				log.Printf("EXIFの除去に失敗: %v", err)
+				if removeErr := os.RemoveAll(mediaDir); removeErr != nil {
+					log.Printf("クリーンアップ失敗: %v", removeErr)
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "画像のメタデータ除去に失敗しました"})
+				return
+			}
+			if stripped >= 0 {
+				written = stripped
+			}
+		}
+
+		// MediaUploadedイベントをアウトボックスに記録する。
+		// Sizeには申告サイズではなく、実際に保存したファイルのバイト数を記録する。
 		aggregateID := fmt.Sprintf("media-%s", mediaID)
 		eventData := event.MediaUploadedData{
 			UserID:      userID,
@@ -231,13 +511,29 @@ func (s *Server) handleUpload() gin.HandlerFunc {
 			StoragePath: storagePath,
 		}
 
-		if err := s.emitEvent(c, aggregateID, event.TypeMediaUploaded, eventData); err != nil {
-			log.Printf("MediaUploadedイベントの送信に失敗: %v", err)
-			// ファイルは保存済みだがイベント送信に失敗した場合、ファイルをクリーンアップする。
+		// Idempotency-Key付きの場合は、再送時に返すアップロード結果をイベントと同じトランザクションで記録する。
+		if err := s.withTx(c.Request.Context(), func(q *mediacommanddb.Queries) error {
+			if idempotencyKey != "" {
+				if err := q.CreateUploadIdempotencyKey(c.Request.Context(), mediacommanddb.CreateUploadIdempotencyKeyParams{
+					UserID:         userID,
+					IdempotencyKey: idempotencyKey,
+					MediaID:        mediaID,
+					Filename:       filename,
+					ContentType:    contentType,
+					Size:           written,
+					StoragePath:    storagePath,
+				}); err != nil {
+					return fmt.Errorf("Idempotency-Keyの記録に失敗: %w", err)
+				}
+			}
+			return enqueueOutboxEvent(c, q, aggregateID, event.TypeMediaUploaded, eventData)
+		}); err != nil {
+			log.Printf("MediaUploadedイベントの記録に失敗: %v", err)
+			// ファイルは保存済みだがイベント記録に失敗した場合、ファイルをクリーンアップする。
 			if removeErr := os.RemoveAll(mediaDir); removeErr != nil {
 				log.Printf("クリーンアップ失敗: %v", removeErr)
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの送信に失敗しました"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの記録に失敗しました"})
 			return
 		}
 
@@ -251,9 +547,57 @@ func (s *Server) handleUpload() gin.HandlerFunc {
 	}
 }
 
+var (
+	// errUploadTooLarge はアップロードされたファイルがmaxUploadSizeを超えたことを表す。
+	errUploadTooLarge = errors.New("ファイルサイズが上限を超えています")
+	// errIncompleteUpload はアップロードが申告サイズに達する前に終了したことを表す。
+	errIncompleteUpload = errors.New("アップロードが途中で終了しました")
+)
+
+// readErrorRecorder は読み込み元で発生したエラー（io.EOFを除く）を記録するio.Reader。
+// io.Copyのエラーが読み込み側（クライアントの切断など）と書き込み側（ディスク障害など）のどちらで
+// 発生したかを区別するために使用する。
+type readErrorRecorder struct {
+	r   io.Reader
+	err error
+}
+
+// Read は読み込み元から読み込み、発生したエラーを記録する。
+func (r *readErrorRecorder) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+	return n, err
+}
+
+// copyUpload はアップロードされたファイルをdstに書き込み、実際に書き込んだバイト数を返す。
+// header.Sizeはクライアントの申告に基づくため、上限の確認には書き込んだバイト数を使い、
+// 上限を1バイトでも超えたことを検出できるようmaxUploadSize+1バイトまで読み込む。
+// 上限を超えた場合はerrUploadTooLarge、読み込み中のエラーや書き込んだバイト数が申告サイズdeclaredと
+// 一致しない場合（ネットワーク切断による途中終了など）はerrIncompleteUploadを返す。
+func copyUpload(dst io.Writer, src io.Reader, declared int64) (int64, error) {
+	rec := &readErrorRecorder{r: src}
+	written, err := io.Copy(dst, io.LimitReader(rec, maxUploadSize+1))
+	if rec.err != nil {
+		return written, fmt.Errorf("%w（受信: %dバイト, 申告: %dバイト）: %v", errIncompleteUpload, written, declared, rec.err)
+	}
+	if err != nil {
+		return written, fmt.Errorf("ファイルの書き込みに失敗: %w", err)
+	}
+	if written > maxUploadSize {
+		return written, errUploadTooLarge
+	}
+	if written != declared {
+		return written, fmt.Errorf("%w（受信: %dバイト, 申告: %dバイト）", errIncompleteUpload, written, declared)
+	}
+	return written, nil
+}
+
 // handleDelete はメディアの削除を処理するハンドラを返す。
-// MediaDeletedイベントをEvent Storeに発行する。
+// MediaDeletedイベントをアウトボックスに記録する。
 // 実際のファイル削除は行わず、イベントとして削除を記録する（論理削除）。
+// Event StoreのMediaUploadedイベントで所有者を確認し、イベントがない場合は404、JWTのユーザーがアップロードしたユーザーでない場合は403を返す。
 func (s *Server) handleDelete() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
@@ -268,15 +612,18 @@ func (s *Server) handleDelete() gin.HandlerFunc {
 			return
 		}
 
-		// MediaDeletedイベントをEvent Storeに発行する。
+		// アップロードしたユーザー本人の場合のみ、MediaDeletedイベントをアウトボックスに記録する。
 		aggregateID := fmt.Sprintf("media-%s", mediaID)
+		if !s.authorizeMediaOwner(c, aggregateID, userID) {
+			return
+		}
 		eventData := event.MediaDeletedData{
 			UserID: userID,
 		}
 
-		if err := s.emitEvent(c, aggregateID, event.TypeMediaDeleted, eventData); err != nil {
-			log.Printf("MediaDeletedイベントの送信に失敗: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの送信に失敗しました"})
+		if err := s.enqueueEvent(c, aggregateID, event.TypeMediaDeleted, eventData); err != nil {
+			log.Printf("MediaDeletedイベントの記録に失敗: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの記録に失敗しました"})
 			return
 		}
 
@@ -288,7 +635,7 @@ func (s *Server) handleDelete() gin.HandlerFunc {
 }
 
 // handleThumbnail はサムネイル画像を返すハンドラを返す。
-// メディアIDからサムネイルファイルのパスを特定し、JPEG画像として返す。
+// メディアIDからサムネイルファイルのパスを特定し、保存された形式（JPEGまたはPNG）の画像として返す。
 // URLパスのIDはaggregate ID（"media-{uuid}"形式）だが、
 // ファイル保存ディレクトリはUUID部分のみのため、プレフィックスを除去する。
 func (s *Server) handleThumbnail() gin.HandlerFunc {
@@ -300,9 +647,11 @@ func (s *Server) handleThumbnail() gin.HandlerFunc {
 		}
 
 		// aggregate IDの"media-"プレフィックスを除去してディレクトリ名にする
-		dirName := strings.TrimPrefix(mediaID, "media-")
-		thumbnailPath := filepath.Join(mediaBaseDir, dirName, "thumbnail.jpg")
-		if _, err := os.Stat(thumbnailPath); os.IsNotExist(err) {
+		var thumbnailPath string
+		if mediaDir, err := s.findMediaDir(filepath.Base(strings.TrimPrefix(mediaID, "media-"))); err == nil {
+			thumbnailPath = findThumbnail(mediaDir)
+		}
+		if thumbnailPath == "" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "サムネイルが見つかりません"})
 			return
 		}
@@ -311,17 +660,72 @@ func (s *Server) handleThumbnail() gin.HandlerFunc {
 	}
 }
 
+// handleFile はアップロードされた元ファイルを添付ファイルとして返すハンドラを返す。
+// 保存ディレクトリにはサムネイルと元ファイルが置かれるため、サムネイル以外の通常ファイルを元ファイルとみなす。
+func (s *Server) handleFile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mediaID := c.Param("id")
+		if mediaID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "メディアIDが指定されていません"})
+			return
+		}
+
+		// aggregate IDの"media-"プレフィックスを除去してディレクトリ名にする
+		mediaDir, err := s.findMediaDir(filepath.Base(strings.TrimPrefix(mediaID, "media-")))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "メディアファイルが見つかりません"})
+			return
+		}
+		storagePath, err := findOriginalFile(mediaDir)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "メディアファイルが見つかりません"})
+			return
+		}
+
+		c.FileAttachment(storagePath, filepath.Base(storagePath))
+	}
+}
+
+// findOriginalFile はメディアの保存ディレクトリからサムネイル以外の元ファイルのパスを返す。
+func findOriginalFile(mediaDir string) (string, error) {
+	entries, err := os.ReadDir(mediaDir)
+	if err != nil {
+		return "", fmt.Errorf("メディアディレクトリの読み取りに失敗: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || isThumbnailFileName(entry.Name()) {
+			continue
+		}
+		return filepath.Join(mediaDir, entry.Name()), nil
+	}
+	return "", fmt.Errorf("元ファイルが存在しません: %s", mediaDir)
+}
+
 // processRequest はサムネイル生成リクエストのJSON構造。
 type processRequest struct {
 	// StoragePath は処理対象のメディアファイルの保存パス。
 	StoragePath string `json:"storage_path" binding:"required"`
 	// ContentType はファイルのMIMEタイプ。動画の場合サムネイル生成をスキップする。
 	ContentType string `json:"content_type"`
+	// Fit はサムネイルのフィットモード（contain または cover）。
+	// 省略時はサーバーの既定値（環境変数THUMBNAIL_FIT、未設定ならcontain）を使用する。
+	Fit string `json:"fit"`
+	// Format はサムネイルの出力形式（auto、jpeg または png）。
+	// 省略時はサーバーの既定値（環境変数THUMBNAIL_FORMAT、未設定ならauto）を使用する。
+	Format string `json:"format"`
+	// Algorithm はリサイズの補間方法（nearest または bilinear）。省略時はbilinearを使用する。
+	Algorithm string `json:"algorithm"`
+	// ThumbnailSize はサムネイル画像の幅・高さ（ピクセル、16〜1024）。省略時は200を使用する。
+	ThumbnailSize *int `json:"thumbnail_size"`
 }
 
 // handleProcess はサムネイル生成を処理するハンドラを返す。
-// 画像ファイルの場合は200x200のサムネイルを生成し、
-// MediaProcessedイベントまたはMediaProcessingFailedイベントをEvent Storeに発行する。
+// 画像ファイルの場合は指定のフィットモードと補間方法（既定はバイリニア補間）で指定サイズ（既定は200x200）のサムネイルを生成し、
+// 出力形式がautoの場合は透過のある画像をPNG、不透明な画像をJPEGで保存する。
+// MediaProcessedイベントまたはMediaProcessingFailedイベントをアウトボックスに記録する。
+// 処理が完了したメディアはModeratorで審査し、要確認の場合はMediaFlaggedイベントも記録する。
+// 画像のデコード・リサイズ・エンコードが処理時間の上限（processingTimeout）を超えた場合は打ち切り、
+// タイムアウトの理由でMediaProcessingFailedイベントを記録して503を返す。
 func (s *Server) handleProcess() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		mediaID := c.Param("id")
@@ -336,105 +740,115 @@ func (s *Server) handleProcess() gin.HandlerFunc {
 			return
 		}
 
+		opts, err := s.resolveThumbnailOptions(req.Fit, req.Format, req.Algorithm, req.ThumbnailSize)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		aggregateID := fmt.Sprintf("media-%s", mediaID)
 
 		// 動画ファイルの場合はサムネイル生成をスキップし、
-		// MediaProcessedイベントのみ発行して処理完了とする。
+		// 再生時間と解像度を抽出してMediaProcessedイベントを発行し処理完了とする。
+		// メタデータの抽出に失敗しても処理は失敗扱いにせず、取得できた項目だけを記録する。
 		if strings.HasPrefix(strings.ToLower(req.ContentType), "video/") {
 			eventData := event.MediaProcessedData{}
-			if err := s.emitEvent(c, aggregateID, event.TypeMediaProcessed, eventData); err != nil {
-				log.Printf("MediaProcessedイベントの送信に失敗: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの送信に失敗しました"})
+			if s.ffprobePath == "" {
+				log.Printf("警告: ffprobeが利用できないため、動画メタデータの抽出をスキップします: media_id=%s", mediaID)
+			} else if meta, err := probeVideo(c.Request.Context(), s.ffprobePath, req.StoragePath); err != nil {
+				log.Printf("警告: 動画メタデータの抽出に失敗しました: media_id=%s, error=%v", mediaID, err)
+			} else {
+				eventData.Width = meta.Width
+				eventData.Height = meta.Height
+				eventData.DurationSeconds = meta.DurationSeconds
+			}
+
+			if err := s.enqueueEvent(c, aggregateID, event.TypeMediaProcessed, eventData); err != nil {
+				log.Printf("MediaProcessedイベントの記録に失敗: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの記録に失敗しました"})
 				return
 			}
+			flagged := s.moderate(c, aggregateID, ModerationInput{
+				MediaID:     mediaID,
+				StoragePath: req.StoragePath,
+				ContentType: req.ContentType,
+			})
 			c.JSON(http.StatusOK, gin.H{
-				"message":  "動画ファイルのため、サムネイル生成をスキップしました",
-				"media_id": mediaID,
+				"message":          "動画ファイルのため、サムネイル生成をスキップしました",
+				"media_id":         mediaID,
+				"width":            eventData.Width,
+				"height":           eventData.Height,
+				"duration_seconds": eventData.DurationSeconds,
+				"flagged":          flagged,
 			})
 			return
 		}
 
-		// 元ファイルを開く。
-		srcFile, err := os.Open(req.StoragePath)
-		if err != nil {
-			reason := fmt.Sprintf("元ファイルのオープンに失敗: %v", err)
-			log.Printf("サムネイル生成エラー: %s", reason)
-			s.emitProcessingFailed(c, aggregateID, reason)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": reason})
-			return
-		}
-		defer srcFile.Close()
+		// 画像のデコード・リサイズ・エンコードは処理時間の上限を設け、超えた場合は打ち切ってワーカーを解放する。
+		ctx, cancel, timeout := s.processingContext(c)
+		defer cancel()
 
-		// 画像をデコードする。
-		srcImg, _, err := image.Decode(srcFile)
-		if err != nil {
-			reason := fmt.Sprintf("画像のデコードに失敗: %v", err)
-			log.Printf("サムネイル生成エラー: %s", reason)
-			s.emitProcessingFailed(c, aggregateID, reason)
-			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": reason})
+		thumbnail, status, err := s.generateThumbnail(ctx, req.StoragePath, opts)
+		if s.abortProcessing(c, aggregateID, timeout, err) {
 			return
 		}
-
-		// 元画像のサイズを取得する。
-		bounds := srcImg.Bounds()
-		srcWidth := bounds.Dx()
-		srcHeight := bounds.Dy()
-
-		// 200x200のサムネイル画像を最近傍補間法でリサイズして生成する。
-		thumbnailImg := resizeNearestNeighbor(srcImg, thumbnailSize, thumbnailSize)
-
-		// サムネイルをJPEG形式で保存する。
-		thumbnailDir := filepath.Dir(req.StoragePath)
-		thumbnailPath := filepath.Join(thumbnailDir, "thumbnail.jpg")
-
-		thumbFile, err := os.Create(thumbnailPath)
 		if err != nil {
-			reason := fmt.Sprintf("サムネイルファイルの作成に失敗: %v", err)
-			log.Printf("サムネイル生成エラー: %s", reason)
-			s.emitProcessingFailed(c, aggregateID, reason)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": reason})
-			return
-		}
-		defer thumbFile.Close()
-
-		if err := jpeg.Encode(thumbFile, thumbnailImg, &jpeg.Options{Quality: 85}); err != nil {
-			reason := fmt.Sprintf("サムネイルのエンコードに失敗: %v", err)
+			reason := err.Error()
 			log.Printf("サムネイル生成エラー: %s", reason)
 			s.emitProcessingFailed(c, aggregateID, reason)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": reason})
+			c.JSON(status, gin.H{"error": reason})
 			return
 		}
 
-		// MediaProcessedイベントをEvent Storeに発行する。
-		eventData := event.MediaProcessedData{
-			ThumbnailPath: thumbnailPath,
-			Width:         srcWidth,
-			Height:        srcHeight,
+		// 設定されている場合はJPEGの元ファイルもメタデータを除去したファイルに置き換える。
+		// 置き換えに失敗してもサムネイル生成は成功として扱う。
+		originalStripped := false
+		if s.metadataStrip == metadataStripAll {
+			stripped, err := stripOriginalMetadata(req.StoragePath)
+			if err != nil {
+				log.Printf("警告: 元ファイルのメタデータ除去に失敗しました: media_id=%s, error=%v", mediaID, err)
+			}
+			originalStripped = stripped
 		}
 
-		if err := s.emitEvent(c, aggregateID, event.TypeMediaProcessed, eventData); err != nil {
-			log.Printf("MediaProcessedイベントの送信に失敗: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの送信に失敗しました"})
+		// MediaProcessedイベントをアウトボックスに記録する。
+		if err := s.enqueueEvent(c, aggregateID, event.TypeMediaProcessed, thumbnail.eventData()); err != nil {
+			log.Printf("MediaProcessedイベントの記録に失敗: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの記録に失敗しました"})
 			return
 		}
 
+		// 処理完了後にコンテンツを審査する。要確認の場合はMediaProcessedの後にMediaFlaggedを記録し、
+		// Read Modelでは一覧から除外される。
+		flagged := s.moderate(c, aggregateID, ModerationInput{
+			MediaID:     mediaID,
+			StoragePath: req.StoragePath,
+			ContentType: req.ContentType,
+		})
+
 		c.JSON(http.StatusOK, gin.H{
-			"message":        "サムネイルを生成しました",
-			"media_id":       mediaID,
-			"thumbnail_path": thumbnailPath,
-			"width":          srcWidth,
-			"height":         srcHeight,
+			"message":           "サムネイルを生成しました",
+			"media_id":          mediaID,
+			"thumbnail_path":    thumbnail.path,
+			"format":            thumbnail.format,
+			"width":             thumbnail.width,
+			"height":            thumbnail.height,
+			"fit":               opts.fit,
+			"algorithm":         opts.algorithm,
+			"thumbnail_size":    opts.size,
+			"original_stripped": originalStripped,
+			"flagged":           flagged,
 		})
 	}
 }
 
-// emitProcessingFailed はMediaProcessingFailedイベントをEvent Storeに発行する。
+// emitProcessingFailed はMediaProcessingFailedイベントをアウトボックスに記録する。
 func (s *Server) emitProcessingFailed(c *gin.Context, aggregateID, reason string) {
 	eventData := event.MediaProcessingFailedData{
 		Reason: reason,
 	}
-	if err := s.emitEvent(c, aggregateID, event.TypeMediaProcessingFailed, eventData); err != nil {
-		log.Printf("MediaProcessingFailedイベントの送信に失敗: %v", err)
+	if err := s.enqueueEvent(c, aggregateID, event.TypeMediaProcessingFailed, eventData); err != nil {
+		log.Printf("MediaProcessingFailedイベントの記録に失敗: %v", err)
 	}
 }
 
@@ -448,7 +862,9 @@ type compensateRequest struct {
 
 // handleCompensate はアップロード済みメディアの補償アクションを処理するハンドラを返す。
 // Sagaのロールバック時に呼び出され、ディスクからファイルを削除し、
-// MediaUploadCompensatedイベントをEvent Storeに発行する。
+// MediaUploadCompensatedイベントをアウトボックスに記録する。
+// 照合するユーザー（JWTのユーザー、または署名を検証したリクエストのX-User-ID）を確認できない場合は401、
+// MediaUploadedイベントがない場合は404、伝播されたユーザーがアップロードしたユーザーでない場合は403を返す。
 func (s *Server) handleCompensate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		mediaID := c.Param("id")
@@ -463,23 +879,40 @@ func (s *Server) handleCompensate() gin.HandlerFunc {
 			return
 		}
 
-		// ディスクからメディアファイルを削除する。
-		mediaDir := filepath.Join(mediaBaseDir, mediaID)
-		if err := os.RemoveAll(mediaDir); err != nil {
+		// Sagaはaggregate ID（"media-{uuid}"形式）を指定するため、"media-"プレフィックスを除去してから組み立てる
+		mediaID = strings.TrimPrefix(mediaID, "media-")
+		aggregateID := fmt.Sprintf("media-%s", mediaID)
+
+		// アップロードしたユーザーの場合のみ補償する。内部APIのため、SagaがX-User-IDヘッダーで伝播したユーザーと照合する。
+		// X-User-IDヘッダーは送信元が自由に指定できるため、署名を検証したリクエストの場合のみ信頼する。
+		// 照合するユーザーを確認できない場合（署名の検証が無効、またはX-User-IDがない）は補償しない。
+		userID := middleware.GetUserID(c)
+		if userID == "" && middleware.InternalSignatureVerified(c) {
+			userID = c.GetHeader("X-User-ID")
+		}
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+		if !s.authorizeMediaOwner(c, aggregateID, userID) {
+			return
+		}
+
+		// ディスクからメディアファイルを削除する。分散の導入前のフラット配置に保存したファイルも削除する。
+		if err := s.removeMediaDirs(filepath.Base(mediaID)); err != nil {
 			log.Printf("メディアディレクトリの削除に失敗: %v", err)
 			// ディレクトリ削除に失敗しても、イベントは発行する。
 		}
 
-		// MediaUploadCompensatedイベントをEvent Storeに発行する。
-		aggregateID := fmt.Sprintf("media-%s", mediaID)
+		// MediaUploadCompensatedイベントをアウトボックスに記録する。
 		eventData := event.MediaUploadCompensatedData{
 			Reason: req.Reason,
 			SagaID: req.SagaID,
 		}
 
-		if err := s.emitEvent(c, aggregateID, event.TypeMediaUploadCompensated, eventData); err != nil {
-			log.Printf("MediaUploadCompensatedイベントの送信に失敗: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの送信に失敗しました"})
+		if err := s.enqueueEvent(c, aggregateID, event.TypeMediaUploadCompensated, eventData); err != nil {
+			log.Printf("MediaUploadCompensatedイベントの記録に失敗: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントの記録に失敗しました"})
 			return
 		}
 
@@ -490,11 +923,26 @@ func (s *Server) handleCompensate() gin.HandlerFunc {
 	}
 }
 
+// resizeThumbnail はフィットモードと補間方法に応じて画像を指定サイズにリサイズする。
+// backgroundはcontainの余白を埋める色。
+func resizeThumbnail(src image.Image, width, height int, fit thumbnailFit, algorithm thumbnailAlgorithm, background color.Color) *image.RGBA {
+	switch {
+	case fit == thumbnailFitCover && algorithm == thumbnailAlgorithmNearest:
+		return resizeCover(src, width, height)
+	case fit == thumbnailFitCover:
+		return resizeCoverBilinear(src, width, height)
+	case algorithm == thumbnailAlgorithmNearest:
+		return resizeNearestNeighbor(src, width, height, background)
+	default:
+		return resizeBilinear(src, width, height, background)
+	}
+}
+
 // resizeNearestNeighbor は最近傍補間法で画像をリサイズする。
 // Go標準ライブラリのみを使用し、外部依存を排除する。
 // アスペクト比を維持しながら、指定サイズに収まるようにリサイズし、
-// 余白部分は白で埋める。
-func resizeNearestNeighbor(src image.Image, width, height int) *image.RGBA {
+// 余白部分はbackgroundで埋める。
+func resizeNearestNeighbor(src image.Image, width, height int, background color.Color) *image.RGBA {
 	srcBounds := src.Bounds()
 	srcW := srcBounds.Dx()
 	srcH := srcBounds.Dy()
@@ -508,9 +956,9 @@ func resizeNearestNeighbor(src image.Image, width, height int) *image.RGBA {
 	newW := int(float64(srcW) * scale)
 	newH := int(float64(srcH) * scale)
 
-	// 出力画像を白背景で初期化する。
+	// 出力画像を背景色で初期化する。
 	dst := image.NewRGBA(image.Rect(0, 0, width, height))
-	draw.Draw(dst, dst.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
 
 	// 中央に配置するためのオフセットを算出する。
 	offsetX := (width - newW) / 2
@@ -534,6 +982,43 @@ func resizeNearestNeighbor(src image.Image, width, height int) *image.RGBA {
 	return dst
 }
 
+// resizeCover は最近傍補間法で画像をリサイズし、指定サイズを余白なしで埋める。
+// アスペクト比を維持しながら短辺が指定サイズに一致するように拡大縮小し、
+// はみ出した部分は中央を基準にクロップする。
+func resizeCover(src image.Image, width, height int) *image.RGBA {
+	srcBounds := src.Bounds()
+	srcW := srcBounds.Dx()
+	srcH := srcBounds.Dy()
+
+	// 出力サイズを完全に覆うスケーリング係数を算出する。
+	scaleX := float64(width) / float64(srcW)
+	scaleY := float64(height) / float64(srcH)
+	scale := math.Max(scaleX, scaleY)
+
+	// 元画像上で切り出す領域の左上座標を算出する（中央クロップ）。
+	cropX := (float64(srcW) - float64(width)/scale) / 2
+	cropY := (float64(srcH) - float64(height)/scale) / 2
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// 最近傍補間法でリサイズする。
+	for y := 0; y < height; y++ {
+		srcY := srcBounds.Min.Y + int(cropY+float64(y)/scale)
+		if srcY >= srcBounds.Max.Y {
+			srcY = srcBounds.Max.Y - 1
+		}
+		for x := 0; x < width; x++ {
+			srcX := srcBounds.Min.X + int(cropX+float64(x)/scale)
+			if srcX >= srcBounds.Max.X {
+				srcX = srcBounds.Max.X - 1
+			}
+			dst.Set(x, y, src.At(srcX, srcY))
+		}
+	}
+
+	return dst
+}
+
 // isAllowedContentType は許可されたContent-Typeかどうかを判定する。
 // image/* または video/* のみ許可する。
 func isAllowedContentType(contentType string) bool {
@@ -2,44 +2,70 @@ package command
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"image/png"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/textproto"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	mediacommanddb "github.com/nao1215/micro/internal/media/command/db"
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
 	"github.com/nao1215/micro/pkg/httpclient"
 	"github.com/nao1215/micro/pkg/middleware"
+	_ "modernc.org/sqlite"
 )
 
 // jwtSecret はテスト用のJWT署名鍵。
 const jwtSecret = "test-secret-key"
 
 // setupTestServer はテスト用のServerインスタンスを作成する。
-// Event StoreのモックURLとファイル保存先のテンポラリディレクトリを設定する。
+// アウトボックス用のインメモリSQLiteとEvent StoreのモックURLを設定する。
+// OutboxRelayはバックグラウンド起動しないため、配送を検証する場合はrelayを直接呼び出す。
 func setupTestServer(t *testing.T, eventStoreURL string) *Server {
 	t.Helper()
 
 	gin.SetMode(gin.TestMode)
 
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("インメモリSQLiteの接続に失敗: %v", err)
+	}
+	// インメモリDBは接続ごとに別のDBになるため、接続を1本に制限する
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := initSchema(sqlDB); err != nil {
+		t.Fatalf("スキーマ初期化に失敗: %v", err)
+	}
+
 	router := gin.New()
+	router.Use(middleware.Correlation())
+	queries := mediacommanddb.New(sqlDB)
 	s := &Server{
 		router:      router,
 		port:        "0",
+		queries:     queries,
+		db:          sqlDB,
+		relay:       NewOutboxRelay(queries, httpclient.New(eventStoreURL)),
 		eventClient: httpclient.New(eventStoreURL),
 	}
 
 	// JWTミドルウェア付きのルーティングを設定する
 	api := router.Group("/api/v1")
-	api.Use(middleware.JWTAuth(jwtSecret))
+	api.Use(middleware.JWTAuth(jwtSecret, middleware.WithIssuer(middleware.Issuer), middleware.WithAudience(middleware.Audience)))
 	{
 		media := api.Group("/media")
 		{
@@ -47,8 +73,12 @@ func setupTestServer(t *testing.T, eventStoreURL string) *Server {
 			media.DELETE("/:id", s.handleDelete())
 			media.POST("/:id/process", s.handleProcess())
 			media.POST("/:id/compensate", s.handleCompensate())
+			media.POST("/:id/regenerate-thumbnail", s.handleRegenerateThumbnail())
 		}
 	}
+	router.POST("/api/v1/media/:id/thumbnail/regenerate", s.handleInternalRegenerateThumbnail())
+	router.GET("/api/v1/media/:id/thumbnail", s.handleThumbnail())
+	router.GET("/api/v1/media/:id/file", s.handleFile())
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "media-command"})
 	})
@@ -66,6 +96,18 @@ func generateTestJWT(t *testing.T, userID, email string) string {
 	return token
 }
 
+// seedUploadedMedia はEvent Storeのモックに、userIDがアップロードしたメディアのMediaUploadedイベントを登録する。
+func seedUploadedMedia(t *testing.T, store *eventstoretest.Server, mediaID, userID string) {
+	t.Helper()
+	store.Seed(t, "media-"+mediaID, event.AggregateTypeMedia, event.TypeMediaUploaded, event.MediaUploadedData{
+		UserID:      userID,
+		Filename:    "photo.png",
+		ContentType: "image/png",
+		Size:        10,
+		StoragePath: filepath.Join(mediaBaseDir, mediaID, "photo.png"),
+	})
+}
+
 // createTestImage はテスト用のPNG画像を指定パスに作成する。
 func createTestImage(t *testing.T, path string, width, height int) {
 	t.Helper()
@@ -134,12 +176,7 @@ func TestHandleUpload(t *testing.T) {
 		origBaseDir := mediaBaseDir
 
 		// Event StoreのモックHTTPサーバーを起動する
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusCreated)
-			json.NewEncoder(w).Encode(map[string]any{"id": "event-1", "version": 1})
-		}))
-		defer eventStore.Close()
+		eventStore := eventstoretest.New(t)
 
 		s := setupTestServer(t, eventStore.URL)
 
@@ -195,10 +232,7 @@ func TestHandleUpload(t *testing.T) {
 	})
 
 	t.Run("異常系_ファイルが指定されていない場合400を返す", func(t *testing.T) {
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-		defer eventStore.Close()
+		eventStore := eventstoretest.New(t)
 
 		s := setupTestServer(t, eventStore.URL)
 
@@ -230,10 +264,7 @@ func TestHandleUpload(t *testing.T) {
 		maxUploadSize = 1024 // 1KB
 		t.Cleanup(func() { maxUploadSize = origMaxUploadSize })
 
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-		defer eventStore.Close()
+		eventStore := eventstoretest.New(t)
 
 		s := setupTestServer(t, eventStore.URL)
 		s.router.MaxMultipartMemory = maxUploadSize
@@ -255,14 +286,110 @@ func TestHandleUpload(t *testing.T) {
 		}
 	})
 
+	t.Run("異常系_申告サイズより実際のデータが大きく上限を超える場合413を返しファイルを削除する", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		origBaseDir := mediaBaseDir
+		origMaxUploadSize := maxUploadSize
+		mediaBaseDir = tmpDir
+		maxUploadSize = 1024 // 1KB
+		t.Cleanup(func() {
+			mediaBaseDir = origBaseDir
+			maxUploadSize = origMaxUploadSize
+		})
+
+		eventStore := eventstoretest.New(t)
+
+		s := setupTestServer(t, eventStore.URL)
+
+		largeData := make([]byte, 4*maxUploadSize)
+		copy(largeData, pngSignature)
+		body, ct := createMultipartFile(t, "file", "large.png", largeData, "image/png")
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media", body)
+		req.Header.Set("Content-Type", ct)
+		token := generateTestJWT(t, "user-123", "test@example.com")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		// 事前にフォームを解析し、申告サイズだけを小さく偽装する
+		if err := req.ParseMultipartForm(1); err != nil {
+			t.Fatalf("マルチパートフォームの解析に失敗: %v", err)
+		}
+		t.Cleanup(func() { req.MultipartForm.RemoveAll() })
+		req.MultipartForm.File["file"][0].Size = 10
+
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+		}
+		entries, err := os.ReadDir(tmpDir)
+		if err != nil {
+			t.Fatalf("メディアディレクトリの読み込みに失敗: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("上限を超えたファイルが残っています: %v", entries)
+		}
+		pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+		if err != nil {
+			t.Fatalf("アウトボックスの取得に失敗: %v", err)
+		}
+		if len(pending) != 0 {
+			t.Errorf("上限を超えたアップロードのイベントが記録されています: %d件", len(pending))
+		}
+	})
+
+	t.Run("異常系_受信したデータが申告サイズに満たない場合400を返しファイルを削除する", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = tmpDir
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		eventStore := eventstoretest.New(t)
+
+		s := setupTestServer(t, eventStore.URL)
+
+		truncatedData := make([]byte, 64)
+		copy(truncatedData, pngSignature)
+		body, ct := createMultipartFile(t, "file", "truncated.png", truncatedData, "image/png")
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media", body)
+		req.Header.Set("Content-Type", ct)
+		token := generateTestJWT(t, "user-123", "test@example.com")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		// 事前にフォームを解析し、申告サイズだけを実際のデータより大きくして途中で終了したアップロードを再現する
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("マルチパートフォームの解析に失敗: %v", err)
+		}
+		t.Cleanup(func() { req.MultipartForm.RemoveAll() })
+		req.MultipartForm.File["file"][0].Size += 1024
+
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+		entries, err := os.ReadDir(tmpDir)
+		if err != nil {
+			t.Fatalf("メディアディレクトリの読み込みに失敗: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("不完全なファイルが残っています: %v", entries)
+		}
+		pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+		if err != nil {
+			t.Fatalf("アウトボックスの取得に失敗: %v", err)
+		}
+		if len(pending) != 0 {
+			t.Errorf("不完全なアップロードのイベントが記録されています: %d件", len(pending))
+		}
+	})
+
 	t.Run("異常系_許可されていないContent-Typeの場合400を返す", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		origBaseDir := mediaBaseDir
 
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-		defer eventStore.Close()
+		eventStore := eventstoretest.New(t)
 
 		s := setupTestServer(t, eventStore.URL)
 
@@ -294,6 +421,107 @@ func TestHandleUpload(t *testing.T) {
 			t.Errorf("エラーメッセージにContent-Typeが含まれていません: %s", errMsg)
 		}
 	})
+
+	t.Run("異常系_ファイルの内容が申告されたContent-Typeと異なる種類の場合400を返す", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = tmpDir
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		eventStore := eventstoretest.New(t)
+
+		s := setupTestServer(t, eventStore.URL)
+
+		// テキストの内容をPNG画像と詐称してアップロードする
+		body, ct := createMultipartFile(t, "file", "spoofed.png", []byte("hello world"), "image/png")
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media", body)
+		req.Header.Set("Content-Type", ct)
+		token := generateTestJWT(t, "user-123", "test@example.com")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+		entries, err := os.ReadDir(tmpDir)
+		if err != nil {
+			t.Fatalf("メディアディレクトリの読み込みに失敗: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("拒否したファイルが保存されています: %v", entries)
+		}
+		if got := eventStore.Events(); len(got) != 0 {
+			t.Errorf("拒否したアップロードのイベントが記録されています: %d件", len(got))
+		}
+	})
+}
+
+// failingReader はdataを返した後にerrを返すio.Reader。通信の途中切断を再現する。
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// failingWriter は常にerrを返すio.Writer。ディスク障害を再現する。
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+func TestCopyUpload(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("0123456789")
+	diskErr := errors.New("ディスクがいっぱいです")
+
+	tests := []struct {
+		name        string
+		dst         io.Writer
+		src         io.Reader
+		declared    int64
+		wantWritten int64
+		wantErr     error
+	}{
+		{name: "正常系_申告サイズと一致する", dst: io.Discard, src: bytes.NewReader(data), declared: 10, wantWritten: 10},
+		{name: "異常系_申告サイズに満たない", dst: io.Discard, src: bytes.NewReader(data), declared: 20, wantWritten: 10, wantErr: errIncompleteUpload},
+		{name: "異常系_申告サイズを超える", dst: io.Discard, src: bytes.NewReader(data), declared: 5, wantWritten: 10, wantErr: errIncompleteUpload},
+		{name: "異常系_受信中に切断された", dst: io.Discard, src: &failingReader{data: data, err: io.ErrUnexpectedEOF}, declared: 20, wantWritten: 10, wantErr: errIncompleteUpload},
+		{name: "異常系_書き込みに失敗した", dst: failingWriter{err: diskErr}, src: bytes.NewReader(data), declared: 10, wantErr: diskErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			written, err := copyUpload(tt.dst, tt.src, tt.declared)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("期待するエラー %v, 実際のエラー %v", tt.wantErr, err)
+			}
+			if errors.Is(err, diskErr) && errors.Is(err, errIncompleteUpload) {
+				t.Errorf("書き込み側の失敗が不完全なアップロードとして扱われています: %v", err)
+			}
+			if written != tt.wantWritten {
+				t.Errorf("期待する書き込みバイト数 %d, 実際の書き込みバイト数 %d", tt.wantWritten, written)
+			}
+		})
+	}
 }
 
 func TestHandleDelete(t *testing.T) {
@@ -302,12 +530,8 @@ func TestHandleDelete(t *testing.T) {
 	t.Run("正常系_メディアの削除が成功する", func(t *testing.T) {
 		t.Parallel()
 
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusCreated)
-			json.NewEncoder(w).Encode(map[string]any{"id": "event-1", "version": 1})
-		}))
-		defer eventStore.Close()
+		eventStore := eventstoretest.New(t)
+		seedUploadedMedia(t, eventStore, "test-media-id", "user-123")
 
 		s := setupTestServer(t, eventStore.URL)
 
@@ -331,18 +555,30 @@ func TestHandleDelete(t *testing.T) {
 		}
 	})
 
-	t.Run("異常系_Event Storeへの送信が失敗した場合500を返す", func(t *testing.T) {
+	t.Run("正常系_Event Storeがエラーを返してもアウトボックスに記録して成功する", func(t *testing.T) {
 		t.Parallel()
 
-		// Event Storeがエラーを返すモックサーバー
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(`{"error":"internal error"}`))
-		}))
-		defer eventStore.Close()
+		// Event Storeが停止しているモックサーバー
+		eventStore := eventstoretest.New(t)
+		eventStore.SetDown(true)
 
 		s := setupTestServer(t, eventStore.URL)
 
+		// 未配送のMediaUploadedはアウトボックスで所有者を確認する
+		data, err := json.Marshal(event.MediaUploadedData{UserID: "user-123", Filename: "photo.png"})
+		if err != nil {
+			t.Fatalf("イベントデータのJSON変換に失敗: %v", err)
+		}
+		if err := s.queries.EnqueueOutboxEvent(t.Context(), mediacommanddb.EnqueueOutboxEventParams{
+			AggregateID:   "media-test-media-id",
+			AggregateType: string(event.AggregateTypeMedia),
+			EventType:     string(event.TypeMediaUploaded),
+			Data:          string(data),
+			UserID:        "user-123",
+		}); err != nil {
+			t.Fatalf("アウトボックスへの記録に失敗: %v", err)
+		}
+
 		req := httptest.NewRequest(http.MethodDelete, "/api/v1/media/test-media-id", nil)
 		token := generateTestJWT(t, "user-123", "test@example.com")
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -350,8 +586,60 @@ func TestHandleDelete(t *testing.T) {
 		w := httptest.NewRecorder()
 		s.router.ServeHTTP(w, req)
 
-		if w.Code != http.StatusInternalServerError {
-			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+		if w.Code != http.StatusOK {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+		if err != nil {
+			t.Fatalf("未配送イベントの取得に失敗: %v", err)
+		}
+		if len(pending) != 2 || pending[1].EventType != "MediaDeleted" {
+			t.Errorf("アウトボックスにMediaDeletedが記録されていない: %+v", pending)
+		}
+	})
+
+	t.Run("異常系_所有者の確認に失敗した場合は削除しない", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name     string
+			ownerID  string
+			down     bool
+			wantCode int
+		}{
+			{name: "他のユーザーのメディアは403", ownerID: "user-other", wantCode: http.StatusForbidden},
+			{name: "MediaUploadedイベントがないメディアは404", wantCode: http.StatusNotFound},
+			{name: "Event Storeが停止中で確認できない場合は503", ownerID: "user-123", down: true, wantCode: http.StatusServiceUnavailable},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				eventStore := eventstoretest.New(t)
+				if tt.ownerID != "" {
+					seedUploadedMedia(t, eventStore, "test-media-id", tt.ownerID)
+				}
+				eventStore.SetDown(tt.down)
+
+				s := setupTestServer(t, eventStore.URL)
+
+				req := httptest.NewRequest(http.MethodDelete, "/api/v1/media/test-media-id", nil)
+				req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+				w := httptest.NewRecorder()
+				s.router.ServeHTTP(w, req)
+
+				if w.Code != tt.wantCode {
+					t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", tt.wantCode, w.Code, w.Body.String())
+				}
+				pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+				if err != nil {
+					t.Fatalf("未配送イベントの取得に失敗: %v", err)
+				}
+				if len(pending) != 0 {
+					t.Errorf("拒否した削除のイベントが記録されている: %+v", pending)
+				}
+			})
 		}
 	})
 }
@@ -368,12 +656,7 @@ func TestHandleProcess(t *testing.T) {
 		testImagePath := filepath.Join(tmpDir, "test.png")
 		createTestImage(t, testImagePath, 400, 300)
 
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusCreated)
-			json.NewEncoder(w).Encode(map[string]any{"id": "event-1", "version": 1})
-		}))
-		defer eventStore.Close()
+		eventStore := eventstoretest.New(t)
 
 		s := setupTestServer(t, eventStore.URL)
 
@@ -413,13 +696,174 @@ func TestHandleProcess(t *testing.T) {
 		}
 	})
 
+	t.Run("正常系_フィットモードごとに200x200のサムネイルが生成される", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name    string
+			reqFit  string
+			srvFit  thumbnailFit
+			wantFit string
+		}{
+			{name: "指定なしはcontain", reqFit: "", srvFit: "", wantFit: "contain"},
+			{name: "リクエストでcoverを指定", reqFit: "cover", srvFit: "", wantFit: "cover"},
+			{name: "サーバー既定値のcoverを使用", reqFit: "", srvFit: thumbnailFitCover, wantFit: "cover"},
+			{name: "リクエスト指定がサーバー既定値より優先される", reqFit: "contain", srvFit: thumbnailFitCover, wantFit: "contain"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				tmpDir := t.TempDir()
+				testImagePath := filepath.Join(tmpDir, "test.png")
+				createTestImage(t, testImagePath, 400, 300)
+
+				eventStore := eventstoretest.New(t)
+
+				s := setupTestServer(t, eventStore.URL)
+				s.thumbnailFit = tt.srvFit
+
+				reqBody, _ := json.Marshal(processRequest{StoragePath: testImagePath, Fit: tt.reqFit})
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/media/test-media-id/process", bytes.NewReader(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				token := generateTestJWT(t, "user-123", "test@example.com")
+				req.Header.Set("Authorization", "Bearer "+token)
+
+				w := httptest.NewRecorder()
+				s.router.ServeHTTP(w, req)
+
+				if w.Code != http.StatusOK {
+					t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+				}
+
+				var resp map[string]any
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+				}
+				if resp["fit"] != tt.wantFit {
+					t.Errorf("期待するfit %q, 実際のfit %v", tt.wantFit, resp["fit"])
+				}
+
+				thumbFile, err := os.Open(filepath.Join(tmpDir, "thumbnail.jpg"))
+				if err != nil {
+					t.Fatalf("サムネイルファイルのオープンに失敗: %v", err)
+				}
+				defer thumbFile.Close()
+
+				cfg, _, err := image.DecodeConfig(thumbFile)
+				if err != nil {
+					t.Fatalf("サムネイルのデコードに失敗: %v", err)
+				}
+				if cfg.Width != thumbnailSize || cfg.Height != thumbnailSize {
+					t.Errorf("期待するサイズ %dx%d, 実際のサイズ %dx%d", thumbnailSize, thumbnailSize, cfg.Width, cfg.Height)
+				}
+			})
+		}
+	})
+
+	t.Run("正常系_thumbnail_sizeを指定するとそのサイズで生成しイベントには元画像のサイズを記録する", func(t *testing.T) {
+		t.Parallel()
+
+		for _, size := range []int{minThumbnailSize, 64, maxThumbnailSize} {
+			t.Run(fmt.Sprintf("%dpx", size), func(t *testing.T) {
+				t.Parallel()
+
+				tmpDir := t.TempDir()
+				testImagePath := filepath.Join(tmpDir, "test.png")
+				createTestImage(t, testImagePath, 400, 300)
+
+				s := setupTestServer(t, "http://localhost:0")
+
+				reqBody, _ := json.Marshal(processRequest{StoragePath: testImagePath, ThumbnailSize: &size})
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/media/test-media-id/process", bytes.NewReader(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+
+				w := httptest.NewRecorder()
+				s.router.ServeHTTP(w, req)
+
+				if w.Code != http.StatusOK {
+					t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+				}
+
+				thumbFile, err := os.Open(filepath.Join(tmpDir, "thumbnail.jpg"))
+				if err != nil {
+					t.Fatalf("サムネイルファイルのオープンに失敗: %v", err)
+				}
+				defer thumbFile.Close()
+
+				cfg, _, err := image.DecodeConfig(thumbFile)
+				if err != nil {
+					t.Fatalf("サムネイルのデコードに失敗: %v", err)
+				}
+				if cfg.Width != size || cfg.Height != size {
+					t.Errorf("期待するサイズ %dx%d, 実際のサイズ %dx%d", size, size, cfg.Width, cfg.Height)
+				}
+
+				pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+				if err != nil {
+					t.Fatalf("アウトボックスの取得に失敗: %v", err)
+				}
+				if len(pending) != 1 || pending[0].EventType != string(event.TypeMediaProcessed) {
+					t.Fatalf("MediaProcessedイベントが記録されていません: %+v", pending)
+				}
+				var data event.MediaProcessedData
+				if err := json.Unmarshal([]byte(pending[0].Data), &data); err != nil {
+					t.Fatalf("イベントデータのデシリアライズに失敗: %v", err)
+				}
+				if data.Width != 400 || data.Height != 300 {
+					t.Errorf("期待するイベントのサイズ 400x300, 実際のサイズ %dx%d", data.Width, data.Height)
+				}
+			})
+		}
+	})
+
+	t.Run("異常系_thumbnail_sizeが範囲外の場合400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t, "http://localhost:0")
+
+		for _, size := range []int{0, minThumbnailSize - 1, maxThumbnailSize + 1} {
+			reqBody, _ := json.Marshal(processRequest{StoragePath: "/tmp/test.png", ThumbnailSize: &size})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/media/test-media-id/process", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("thumbnail_size=%d: 期待するステータスコード %d, 実際のステータスコード %d", size, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+
+	t.Run("異常系_不明なフィットモードの場合400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		eventStore := eventstoretest.New(t)
+
+		s := setupTestServer(t, eventStore.URL)
+
+		reqBody, _ := json.Marshal(processRequest{StoragePath: "/tmp/test.png", Fit: "stretch"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/test-media-id/process", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		token := generateTestJWT(t, "user-123", "test@example.com")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+
 	t.Run("異常系_storage_pathが指定されていない場合400を返す", func(t *testing.T) {
 		t.Parallel()
 
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-		defer eventStore.Close()
+		eventStore := eventstoretest.New(t)
 
 		s := setupTestServer(t, eventStore.URL)
 
@@ -441,12 +885,7 @@ func TestHandleProcess(t *testing.T) {
 	t.Run("異常系_存在しないファイルパスの場合エラーを返す", func(t *testing.T) {
 		t.Parallel()
 
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusCreated)
-			json.NewEncoder(w).Encode(map[string]any{"id": "event-1", "version": 1})
-		}))
-		defer eventStore.Close()
+		eventStore := eventstoretest.New(t)
 
 		s := setupTestServer(t, eventStore.URL)
 
@@ -482,12 +921,8 @@ func TestHandleCompensate(t *testing.T) {
 			t.Fatalf("テスト用ファイルの書き込みに失敗: %v", err)
 		}
 
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusCreated)
-			json.NewEncoder(w).Encode(map[string]any{"id": "event-1", "version": 1})
-		}))
-		defer eventStore.Close()
+		eventStore := eventstoretest.New(t)
+		seedUploadedMedia(t, eventStore, mediaID, "user-123")
 
 		s := setupTestServer(t, eventStore.URL)
 
@@ -528,12 +963,8 @@ func TestHandleCompensate(t *testing.T) {
 		tmpDir := t.TempDir()
 		origBaseDir := mediaBaseDir
 
-		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusCreated)
-			json.NewEncoder(w).Encode(map[string]any{"id": "event-1", "version": 1})
-		}))
-		defer eventStore.Close()
+		eventStore := eventstoretest.New(t)
+		seedUploadedMedia(t, eventStore, "nonexistent-id", "user-123")
 
 		s := setupTestServer(t, eventStore.URL)
 
@@ -556,6 +987,134 @@ func TestHandleCompensate(t *testing.T) {
 			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
 		}
 	})
+
+	t.Run("異常系_アップロードしたユーザーでなければ403を返しファイルを削除しない", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = tmpDir
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		mediaDir := filepath.Join(tmpDir, "others-media-id")
+		if err := os.MkdirAll(mediaDir, 0o755); err != nil {
+			t.Fatalf("テスト用メディアディレクトリの作成に失敗: %v", err)
+		}
+
+		eventStore := eventstoretest.New(t)
+		seedUploadedMedia(t, eventStore, "others-media-id", "user-other")
+
+		s := setupTestServer(t, eventStore.URL)
+
+		reqBody, _ := json.Marshal(compensateRequest{Reason: "テスト用補償", SagaID: "saga-789"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/others-media-id/compensate", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusForbidden, w.Code, w.Body.String())
+		}
+		if _, err := os.Stat(mediaDir); err != nil {
+			t.Errorf("他のユーザーのメディアディレクトリが削除されています: %v", err)
+		}
+	})
+
+	signedTests := []struct {
+		name       string
+		signingKey string
+		mediaID    string
+		userID     string
+		wantStatus int
+	}{
+		{name: "正常系_署名を検証したリクエストはX-User-IDのユーザーを所有者として照合する", signingKey: "internal-signing-key", mediaID: "signed-media-id", userID: "user-123", wantStatus: http.StatusOK},
+		{name: "正常系_Sagaが指定するaggregate IDでも補償できる", signingKey: "internal-signing-key", mediaID: "media-signed-media-id", userID: "user-123", wantStatus: http.StatusOK},
+		{name: "異常系_署名を検証したリクエストのX-User-IDが所有者でなければ403を返す", signingKey: "internal-signing-key", mediaID: "signed-media-id", userID: "user-other", wantStatus: http.StatusForbidden},
+		{name: "異常系_署名を検証したリクエストにX-User-IDがなければ401を返す", signingKey: "internal-signing-key", mediaID: "signed-media-id", wantStatus: http.StatusUnauthorized},
+		{name: "異常系_署名の検証が無効な場合はX-User-IDを信頼せず401を返す", mediaID: "signed-media-id", userID: "user-123", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range signedTests {
+		t.Run(tt.name, func(t *testing.T) {
+			origBaseDir := mediaBaseDir
+			mediaBaseDir = t.TempDir()
+			t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+			mediaDir := filepath.Join(mediaBaseDir, "signed-media-id")
+			if err := os.MkdirAll(mediaDir, 0o755); err != nil {
+				t.Fatalf("テスト用メディアディレクトリの作成に失敗: %v", err)
+			}
+
+			eventStore := eventstoretest.New(t)
+			seedUploadedMedia(t, eventStore, "signed-media-id", "user-123")
+			s := setupTestServer(t, eventStore.URL)
+
+			router := gin.New()
+			router.POST("/api/v1/media/:id/compensate", middleware.VerifyInternalSignature(tt.signingKey), s.handleCompensate())
+			ts := httptest.NewServer(router)
+			t.Cleanup(ts.Close)
+
+			client := httpclient.New(ts.URL, httpclient.WithSigningKey(tt.signingKey))
+			ctx := t.Context()
+			if tt.userID != "" {
+				ctx = httpclient.WithUserID(ctx, tt.userID)
+			}
+			status, _ := client.DoJSON(ctx, http.MethodPost, "/api/v1/media/"+tt.mediaID+"/compensate", compensateRequest{Reason: "テスト用補償", SagaID: "saga-1"}, nil)
+			if status != tt.wantStatus {
+				t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", tt.wantStatus, status)
+			}
+			// 補償した場合のみメディアディレクトリを削除する
+			_, err := os.Stat(mediaDir)
+			if removed := os.IsNotExist(err); removed != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("メディアディレクトリの削除 = %v; 期待値 = %v", removed, tt.wantStatus == http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestHandleFile(t *testing.T) {
+	// mediaBaseDirを差し替えるため、並列実行はしない
+	tmpDir := t.TempDir()
+	origBaseDir := mediaBaseDir
+	mediaBaseDir = tmpDir
+	t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+	mediaDir := filepath.Join(tmpDir, "file-test-id")
+	if err := os.MkdirAll(mediaDir, 0o755); err != nil {
+		t.Fatalf("テスト用メディアディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(mediaDir, "thumbnail.jpg"), []byte("thumbnail"), 0o644); err != nil {
+		t.Fatalf("テスト用サムネイルの書き込みに失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(mediaDir, "photo.png"), []byte("original"), 0o644); err != nil {
+		t.Fatalf("テスト用ファイルの書き込みに失敗: %v", err)
+	}
+
+	s := setupTestServer(t, "http://localhost:0")
+
+	t.Run("正常系_サムネイルではなく元ファイルを添付ファイルとして返す", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media/media-file-test-id/file", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w.Body.String() != "original" {
+			t.Errorf("期待するボディ %q, 実際のボディ %q", "original", w.Body.String())
+		}
+		if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "photo.png") {
+			t.Errorf("Content-Dispositionに元ファイル名が含まれていません: %q", got)
+		}
+	})
+
+	t.Run("異常系_存在しないメディアは404を返す", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media/media-missing/file", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusNotFound, w.Code)
+		}
+	})
 }
 
 func TestHealthCheck(t *testing.T) {
@@ -631,7 +1190,7 @@ func TestResizeNearestNeighbor(t *testing.T) {
 			}
 		}
 
-		result := resizeNearestNeighbor(src, 200, 200)
+		result := resizeNearestNeighbor(src, 200, 200, color.White)
 
 		bounds := result.Bounds()
 		if bounds.Dx() != 200 || bounds.Dy() != 200 {
@@ -643,7 +1202,7 @@ func TestResizeNearestNeighbor(t *testing.T) {
 		t.Parallel()
 
 		src := image.NewRGBA(image.Rect(0, 0, 100, 100))
-		result := resizeNearestNeighbor(src, 200, 200)
+		result := resizeNearestNeighbor(src, 200, 200, color.White)
 
 		bounds := result.Bounds()
 		if bounds.Dx() != 200 || bounds.Dy() != 200 {
@@ -651,3 +1210,110 @@ func TestResizeNearestNeighbor(t *testing.T) {
 		}
 	})
 }
+
+func TestResizeCover(t *testing.T) {
+	t.Parallel()
+
+	// 左半分が赤、右半分が青の横長画像を作成する。
+	newSplitImage := func(w, h int) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				if x < w/2 {
+					img.Set(x, y, color.RGBA{R: 255, A: 255})
+				} else {
+					img.Set(x, y, color.RGBA{B: 255, A: 255})
+				}
+			}
+		}
+		return img
+	}
+
+	t.Run("正常系_横長画像が余白なしで200x200になる", func(t *testing.T) {
+		t.Parallel()
+
+		src := newSplitImage(800, 400)
+		result := resizeCover(src, 200, 200)
+
+		bounds := result.Bounds()
+		if bounds.Dx() != 200 || bounds.Dy() != 200 {
+			t.Fatalf("期待するサイズ 200x200, 実際のサイズ %dx%d", bounds.Dx(), bounds.Dy())
+		}
+
+		// 上下左右の端にも白い余白が存在しないことを確認する。
+		white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+		for _, p := range []image.Point{{0, 0}, {199, 0}, {0, 199}, {199, 199}, {100, 0}, {100, 199}} {
+			if result.RGBAAt(p.X, p.Y) == white {
+				t.Errorf("座標 %v に余白が存在する", p)
+			}
+		}
+
+		// 中央クロップのため、左端は赤、右端は青になる。
+		if got := result.RGBAAt(0, 100); got.R != 255 || got.B != 0 {
+			t.Errorf("左端の色が赤ではない: %v", got)
+		}
+		if got := result.RGBAAt(199, 100); got.B != 255 || got.R != 0 {
+			t.Errorf("右端の色が青ではない: %v", got)
+		}
+	})
+
+	t.Run("正常系_縦長画像が余白なしで200x200になる", func(t *testing.T) {
+		t.Parallel()
+
+		src := image.NewRGBA(image.Rect(0, 0, 300, 900))
+		for y := 0; y < 900; y++ {
+			for x := 0; x < 300; x++ {
+				src.Set(x, y, color.RGBA{G: 200, A: 255})
+			}
+		}
+		result := resizeCover(src, 200, 200)
+
+		bounds := result.Bounds()
+		if bounds.Dx() != 200 || bounds.Dy() != 200 {
+			t.Fatalf("期待するサイズ 200x200, 実際のサイズ %dx%d", bounds.Dx(), bounds.Dy())
+		}
+		if got := result.RGBAAt(100, 0); got.G != 200 {
+			t.Errorf("上端に余白が存在する: %v", got)
+		}
+	})
+
+	t.Run("正常系_containは余白を白で埋める", func(t *testing.T) {
+		t.Parallel()
+
+		src := newSplitImage(800, 400)
+		result := resizeThumbnail(src, 200, 200, thumbnailFitContain, thumbnailAlgorithmBilinear, color.White)
+
+		if got := result.RGBAAt(100, 0); got != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+			t.Errorf("containの上端が白ではない: %v", got)
+		}
+	})
+}
+
+func TestParseThumbnailFit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    thumbnailFit
+		wantErr bool
+	}{
+		{input: "", want: thumbnailFitContain},
+		{input: "contain", want: thumbnailFitContain},
+		{input: "COVER", want: thumbnailFitCover},
+		{input: "stretch", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseThumbnailFit(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseThumbnailFit(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseThumbnailFit(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
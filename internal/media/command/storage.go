@@ -0,0 +1,91 @@
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultStorageShardDepth は環境変数MEDIA_STORAGE_SHARD_DEPTHを省略した場合の分散の深さ。
+	// mediaBaseDir/ab/cd/{mediaID} の2階層に分散する。
+	defaultStorageShardDepth = 2
+	// maxStorageShardDepth は分散の深さの上限。1階層あたり256通りのため、4階層で約43億ディレクトリに分散できる。
+	maxStorageShardDepth = 4
+	// storageShardWidth は1階層のディレクトリ名に使うメディアIDのハッシュの文字数（16進数）。
+	storageShardWidth = 2
+)
+
+// parseStorageShardDepth は環境変数MEDIA_STORAGE_SHARD_DEPTHの値を解釈する。
+// 空文字の場合は既定の2階層とし、0の場合は分散せずmediaBaseDir直下に保存する（従来のフラット配置）。
+func parseStorageShardDepth(v string) (int, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return defaultStorageShardDepth, nil
+	}
+	depth, err := strconv.Atoi(v)
+	if err != nil || depth < 0 || depth > maxStorageShardDepth {
+		return 0, fmt.Errorf("MEDIA_STORAGE_SHARD_DEPTHは0から%dまでの整数で指定してください: %q", maxStorageShardDepth, v)
+	}
+	return depth, nil
+}
+
+// shardedMediaDir はメディアIDの保存ディレクトリを、depth階層に分散した配置で返す。
+// 各階層のディレクトリ名はメディアIDのSHA-256ハッシュの先頭から2文字ずつ取り、
+// IDの形式に関わらずディレクトリエントリ数が均等に分散するようにする。depthが0の場合はフラット配置になる。
+func shardedMediaDir(mediaID string, depth int) string {
+	sum := sha256.Sum256([]byte(mediaID))
+	hash := hex.EncodeToString(sum[:])
+
+	elems := make([]string, 0, depth+2)
+	elems = append(elems, mediaBaseDir)
+	for i := range depth {
+		elems = append(elems, hash[i*storageShardWidth:(i+1)*storageShardWidth])
+	}
+	elems = append(elems, mediaID)
+	return filepath.Join(elems...)
+}
+
+// mediaDir は新しく保存するメディアの保存ディレクトリを返す。
+// 保存パスはこの関数でのみ組み立て、分散の深さはServerのstorageShardDepthに従う。
+func (s *Server) mediaDir(mediaID string) string {
+	return shardedMediaDir(mediaID, s.storageShardDepth)
+}
+
+// candidateMediaDirs はメディアIDの保存ディレクトリの候補を、現在の分散の深さを先頭に全ての深さについて返す。
+// 分散の導入前のフラット配置や、分散の深さを変更する前に保存したメディアも見つけられるようにする。
+func (s *Server) candidateMediaDirs(mediaID string) []string {
+	dirs := []string{s.mediaDir(mediaID)}
+	for depth := 0; depth <= maxStorageShardDepth; depth++ {
+		if depth != s.storageShardDepth {
+			dirs = append(dirs, shardedMediaDir(mediaID, depth))
+		}
+	}
+	return dirs
+}
+
+// findMediaDir は既存のメディアの保存ディレクトリを探して返す。見つからない場合はエラーを返す。
+func (s *Server) findMediaDir(mediaID string) (string, error) {
+	for _, dir := range s.candidateMediaDirs(mediaID) {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("メディアディレクトリが存在しません: %s", mediaID)
+}
+
+// removeMediaDirs はメディアIDの保存ディレクトリを全ての配置から削除する。存在しない配置は無視する。
+func (s *Server) removeMediaDirs(mediaID string) error {
+	var errs []error
+	for _, dir := range s.candidateMediaDirs(mediaID) {
+		if err := os.RemoveAll(dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,176 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
+)
+
+func TestParseStorageShardDepth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{input: "", want: defaultStorageShardDepth},
+		{input: "0", want: 0},
+		{input: " 3 ", want: 3},
+		{input: "4", want: maxStorageShardDepth},
+		{input: "5", wantErr: true},
+		{input: "-1", wantErr: true},
+		{input: "deep", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseStorageShardDepth(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStorageShardDepth(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseStorageShardDepth(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShardedMediaDir(t *testing.T) {
+	// mediaBaseDirを差し替えるため、並列実行はしない
+	origBaseDir := mediaBaseDir
+	mediaBaseDir = "/data/media"
+	t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+	// "abc"のSHA-256は"ba7816bf..."
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{depth: 0, want: "/data/media/abc"},
+		{depth: 1, want: "/data/media/ba/abc"},
+		{depth: 2, want: "/data/media/ba/78/abc"},
+		{depth: 4, want: "/data/media/ba/78/16/bf/abc"},
+	}
+	for _, tt := range tests {
+		if got := shardedMediaDir("abc", tt.depth); got != tt.want {
+			t.Errorf("shardedMediaDir(%q, %d) = %q, want %q", "abc", tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestShardedStorage(t *testing.T) {
+	// mediaBaseDirを差し替えるため、並列実行はしない
+	eventStore := eventstoretest.New(t)
+
+	// serve はリクエストを処理してレスポンスを返す。
+	serve := func(s *Server, method, path string, body []byte, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("正常系_アップロードしたファイルを分散した階層に保存し、取得・サムネイル生成・補償ができる", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = tmpDir
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		s := setupTestServer(t, eventStore.URL)
+		s.storageShardDepth = 2
+
+		var imgBuf bytes.Buffer
+		if err := png.Encode(&imgBuf, image.NewRGBA(image.Rect(0, 0, 10, 10))); err != nil {
+			t.Fatalf("テスト画像のエンコードに失敗: %v", err)
+		}
+		body, ct := createMultipartFile(t, "file", "photo.png", imgBuf.Bytes(), "image/png")
+		w := serve(s, http.MethodPost, "/api/v1/media", body.Bytes(), ct)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var uploaded uploadResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &uploaded); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+
+		wantPath := filepath.Join(shardedMediaDir(uploaded.ID, 2), "photo.png")
+		if uploaded.StoragePath != wantPath {
+			t.Fatalf("期待する保存パス %q, 実際の保存パス %q", wantPath, uploaded.StoragePath)
+		}
+		if rel, _ := filepath.Rel(tmpDir, uploaded.StoragePath); len(strings.Split(rel, string(filepath.Separator))) != 4 {
+			t.Errorf("保存パスが2階層に分散されていません: %q", rel)
+		}
+
+		reqBody, _ := json.Marshal(processRequest{StoragePath: uploaded.StoragePath, ContentType: "image/png"})
+		if w := serve(s, http.MethodPost, "/api/v1/media/"+uploaded.ID+"/process", reqBody, "application/json"); w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w := serve(s, http.MethodGet, "/api/v1/media/media-"+uploaded.ID+"/thumbnail", nil, ""); w.Code != http.StatusOK {
+			t.Errorf("サムネイル取得: 期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+		if w := serve(s, http.MethodGet, "/api/v1/media/media-"+uploaded.ID+"/file", nil, ""); w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), imgBuf.Bytes()) {
+			t.Errorf("元ファイル取得: ステータスコード %d, 元ファイルと一致しません", w.Code)
+		}
+
+		reqBody, _ = json.Marshal(compensateRequest{Reason: "テスト用補償", SagaID: "saga-1"})
+		if w := serve(s, http.MethodPost, "/api/v1/media/"+uploaded.ID+"/compensate", reqBody, "application/json"); w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if _, err := os.Stat(filepath.Dir(uploaded.StoragePath)); !os.IsNotExist(err) {
+			t.Error("メディアディレクトリが削除されていません")
+		}
+	})
+
+	t.Run("正常系_分散の導入前のフラット配置のファイルも取得・補償できる", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		origBaseDir := mediaBaseDir
+		mediaBaseDir = tmpDir
+		t.Cleanup(func() { mediaBaseDir = origBaseDir })
+
+		legacyDir := filepath.Join(tmpDir, "legacy-id")
+		if err := os.MkdirAll(legacyDir, 0o755); err != nil {
+			t.Fatalf("テスト用メディアディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(legacyDir, "photo.png"), []byte("original"), 0o644); err != nil {
+			t.Fatalf("テスト用ファイルの書き込みに失敗: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(legacyDir, "thumbnail.jpg"), []byte("thumbnail"), 0o644); err != nil {
+			t.Fatalf("テスト用サムネイルの書き込みに失敗: %v", err)
+		}
+
+		seedUploadedMedia(t, eventStore, "legacy-id", "user-123")
+		s := setupTestServer(t, eventStore.URL)
+		s.storageShardDepth = 2
+
+		if w := serve(s, http.MethodGet, "/api/v1/media/media-legacy-id/file", nil, ""); w.Code != http.StatusOK || w.Body.String() != "original" {
+			t.Errorf("元ファイル取得: ステータスコード %d, ボディ %q", w.Code, w.Body.String())
+		}
+		if w := serve(s, http.MethodGet, "/api/v1/media/media-legacy-id/thumbnail", nil, ""); w.Code != http.StatusOK || w.Body.String() != "thumbnail" {
+			t.Errorf("サムネイル取得: ステータスコード %d, ボディ %q", w.Code, w.Body.String())
+		}
+
+		reqBody, _ := json.Marshal(compensateRequest{Reason: "テスト用補償", SagaID: "saga-1"})
+		if w := serve(s, http.MethodPost, "/api/v1/media/legacy-id/compensate", reqBody, "application/json"); w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if _, err := os.Stat(legacyDir); !os.IsNotExist(err) {
+			t.Error("フラット配置のメディアディレクトリが削除されていません")
+		}
+	})
+}
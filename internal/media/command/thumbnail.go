@@ -0,0 +1,156 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// thumbnailOptions はサムネイル生成の設定。
+type thumbnailOptions struct {
+	// fit はサムネイルのフィットモード。
+	fit thumbnailFit
+	// format はサムネイルの出力形式。autoの場合は元画像から決定する。
+	format thumbnailFormat
+	// algorithm はリサイズの補間方法。
+	algorithm thumbnailAlgorithm
+	// size はサムネイル画像の幅・高さ（ピクセル）。
+	size int
+}
+
+// resolveThumbnailOptions はリクエストで指定されたフィットモード・出力形式・補間方法・サイズを検証し、
+// 省略された項目はサーバーの既定値で補ってサムネイル生成の設定を返す。
+func (s *Server) resolveThumbnailOptions(fit, format, algorithm string, size *int) (thumbnailOptions, error) {
+	opts := thumbnailOptions{
+		fit:    s.thumbnailFit,
+		format: s.thumbnailFormat,
+		size:   thumbnailSize,
+	}
+	if opts.fit == "" {
+		opts.fit = thumbnailFitContain
+	}
+	if fit != "" {
+		parsed, err := parseThumbnailFit(fit)
+		if err != nil {
+			return thumbnailOptions{}, err
+		}
+		opts.fit = parsed
+	}
+
+	if opts.format == "" {
+		opts.format = thumbnailFormatAuto
+	}
+	if format != "" {
+		parsed, err := parseThumbnailFormat(format)
+		if err != nil {
+			return thumbnailOptions{}, err
+		}
+		opts.format = parsed
+	}
+
+	parsed, err := parseThumbnailAlgorithm(algorithm)
+	if err != nil {
+		return thumbnailOptions{}, err
+	}
+	opts.algorithm = parsed
+
+	if size != nil {
+		if *size < minThumbnailSize || *size > maxThumbnailSize {
+			return thumbnailOptions{}, fmt.Errorf("thumbnail_sizeは%d〜%dの範囲で指定してください", minThumbnailSize, maxThumbnailSize)
+		}
+		opts.size = *size
+	}
+	return opts, nil
+}
+
+// thumbnailResult はgenerateThumbnailで生成したサムネイルの情報。
+type thumbnailResult struct {
+	// path は保存したサムネイルのパス。
+	path string
+	// format は実際に保存したサムネイルの形式。
+	format thumbnailFormat
+	// width は元画像の幅（ピクセル）。
+	width int
+	// height は元画像の高さ（ピクセル）。
+	height int
+}
+
+// eventData はサムネイルの情報をMediaProcessedイベントのデータに変換する。
+func (r thumbnailResult) eventData() event.MediaProcessedData {
+	return event.MediaProcessedData{
+		ThumbnailPath:   r.path,
+		ThumbnailFormat: string(r.format),
+		Width:           r.width,
+		Height:          r.height,
+	}
+}
+
+// generateThumbnail はstoragePathの元画像からoptsに従ってサムネイルを生成し、元画像と同じディレクトリに保存する。
+// 既存のサムネイルはエンコードが終わってから上書きするため、失敗した場合は以前のサムネイルが残る。
+// 出力形式が変わった場合は以前の形式のサムネイルを削除する。
+// デコード・リサイズ・エンコードはctxの期限で打ち切り、その場合はctxのエラーを返す。
+// 失敗した場合はクライアントへ返すステータスコード（デコードできない画像は422、それ以外は500）もあわせて返す。
+func (s *Server) generateThumbnail(ctx context.Context, storagePath string, opts thumbnailOptions) (thumbnailResult, int, error) {
+	// 元ファイルを開く。
+	srcFile, err := os.Open(storagePath)
+	if err != nil {
+		return thumbnailResult{}, http.StatusInternalServerError, fmt.Errorf("元ファイルのオープンに失敗: %w", err)
+	}
+	defer srcFile.Close()
+
+	// 画像をデコードする。
+	var srcImg image.Image
+	err = runWithDeadline(ctx, func() error {
+		var err error
+		srcImg, _, err = image.Decode(contextReader{ctx: ctx, r: srcFile})
+		return err
+	})
+	if err != nil {
+		return thumbnailResult{}, http.StatusUnprocessableEntity, fmt.Errorf("画像のデコードに失敗: %w", err)
+	}
+
+	// 元画像から出力形式を決定し、指定サイズの正方形のサムネイル画像を指定の補間方法でリサイズして生成し、出力形式でエンコードする。
+	// PNGの場合はcontainの余白も透明にして透過を保つ。
+	format := resolveThumbnailFormat(opts.format, srcImg)
+	resize := s.resize
+	if resize == nil {
+		resize = resizeThumbnail
+	}
+	var encoded bytes.Buffer
+	err = runWithDeadline(ctx, func() error {
+		thumbnailImg := resize(cancelableImage{Image: srcImg, done: ctx.Done()}, opts.size, opts.size, opts.fit, opts.algorithm, thumbnailBackground(format))
+		// サムネイルはmaxThumbnailSize以下のため、エンコードは標準の画像型の高速な経路を使うよう包まずに渡す
+		return writeThumbnail(&encoded, thumbnailImg, format)
+	})
+	if err != nil {
+		return thumbnailResult{}, http.StatusInternalServerError, fmt.Errorf("サムネイルの保存に失敗: %w", err)
+	}
+
+	// サムネイルを出力形式に応じたファイル名で保存する。
+	thumbnailDir := filepath.Dir(storagePath)
+	thumbnailPath := filepath.Join(thumbnailDir, thumbnailFileNames[format])
+	if err := os.WriteFile(thumbnailPath, encoded.Bytes(), 0o644); err != nil {
+		return thumbnailResult{}, http.StatusInternalServerError, fmt.Errorf("サムネイルの保存に失敗: %w", err)
+	}
+
+	// 再処理で出力形式が変わった場合は以前の形式のサムネイルを削除する。
+	// 削除に失敗しても新しいサムネイルは保存できているため、処理は成功として扱う。
+	if err := removeStaleThumbnails(thumbnailDir, format); err != nil {
+		log.Printf("警告: 以前のサムネイルの削除に失敗しました: path=%s, error=%v", thumbnailDir, err)
+	}
+
+	bounds := srcImg.Bounds()
+	return thumbnailResult{
+		path:   thumbnailPath,
+		format: format,
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+	}, 0, nil
+}
@@ -0,0 +1,139 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// thumbnailFormat はサムネイルの出力形式。
+type thumbnailFormat string
+
+const (
+	// thumbnailFormatAuto は元画像に透過（不透明でないピクセル）があればPNG、なければJPEGで出力する。
+	thumbnailFormatAuto thumbnailFormat = "auto"
+	// thumbnailFormatJPEG は元画像に関わらずJPEGで出力する。透過部分は白で埋める。
+	thumbnailFormatJPEG thumbnailFormat = "jpeg"
+	// thumbnailFormatPNG は元画像に関わらずPNGで出力する。
+	thumbnailFormatPNG thumbnailFormat = "png"
+)
+
+// thumbnailFileNames は出力形式ごとのサムネイルのファイル名。
+// メディアの保存ディレクトリにはいずれか1つのみが置かれる。
+var thumbnailFileNames = map[thumbnailFormat]string{
+	thumbnailFormatJPEG: "thumbnail.jpg",
+	thumbnailFormatPNG:  "thumbnail.png",
+}
+
+// parseThumbnailFormat は文字列をサムネイルの出力形式に変換する。
+// 空文字の場合は既定のautoとし、jpgはjpegとして扱う。
+func parseThumbnailFormat(v string) (thumbnailFormat, error) {
+	switch thumbnailFormat(strings.ToLower(strings.TrimSpace(v))) {
+	case "", thumbnailFormatAuto:
+		return thumbnailFormatAuto, nil
+	case thumbnailFormatJPEG, "jpg":
+		return thumbnailFormatJPEG, nil
+	case thumbnailFormatPNG:
+		return thumbnailFormatPNG, nil
+	default:
+		return "", fmt.Errorf("不明なサムネイルの出力形式です: %s（auto、jpeg または png を指定してください）", v)
+	}
+}
+
+// resolveThumbnailFormat は出力形式がautoの場合に元画像から出力形式を決定する。
+// 透過のある画像（アルファチャンネルを持つPNG・GIF・WebP）はPNG、不透明な写真はJPEGとする。
+func resolveThumbnailFormat(format thumbnailFormat, src image.Image) thumbnailFormat {
+	if format != thumbnailFormatAuto {
+		return format
+	}
+	if hasTransparency(src) {
+		return thumbnailFormatPNG
+	}
+	return thumbnailFormatJPEG
+}
+
+// hasTransparency は画像に不透明でないピクセルが含まれるかを返す。
+// 標準の画像型はOpaqueで判定し、それ以外は全ピクセルのアルファ値を調べる。
+func hasTransparency(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// thumbnailBackground は出力形式に応じてcontainの余白を埋める色を返す。
+// PNGは透過を保つため余白も透明にし、JPEGは白で埋める。
+func thumbnailBackground(format thumbnailFormat) color.Color {
+	if format == thumbnailFormatPNG {
+		return color.Transparent
+	}
+	return color.White
+}
+
+// writeThumbnail はサムネイルを出力形式でエンコードしてwに書き出す。
+// JPEGはエンコード結果からメタデータを除去してから書き出し、サムネイルにEXIF等が含まれないことを保証する。
+func writeThumbnail(w io.Writer, img image.Image, format thumbnailFormat) error {
+	if format == thumbnailFormatPNG {
+		if err := png.Encode(w, img); err != nil {
+			return fmt.Errorf("PNGのエンコードに失敗: %w", err)
+		}
+		return nil
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 85}); err != nil {
+		return fmt.Errorf("JPEGのエンコードに失敗: %w", err)
+	}
+	return stripJPEGMetadata(w, &encoded)
+}
+
+// removeStaleThumbnails はformat以外の形式で保存されている以前のサムネイルを削除する。
+// 再処理で出力形式が変わった場合に、古いサムネイルが残らないようにする。
+func removeStaleThumbnails(dir string, format thumbnailFormat) error {
+	var errs []error
+	for f, name := range thumbnailFileNames {
+		if f == format {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// findThumbnail はメディアの保存ディレクトリにあるサムネイルのパスを返す。見つからない場合は空文字を返す。
+func findThumbnail(mediaDir string) string {
+	for _, format := range []thumbnailFormat{thumbnailFormatJPEG, thumbnailFormatPNG} {
+		path := filepath.Join(mediaDir, thumbnailFileNames[format])
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// isThumbnailFileName はファイル名がサムネイルのものかを返す。
+func isThumbnailFileName(name string) bool {
+	for _, n := range thumbnailFileNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
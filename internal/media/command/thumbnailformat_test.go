@@ -0,0 +1,250 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nao1215/micro/pkg/event"
+)
+
+// createTransparentTestPNG は左半分が透明、右半分が不透明な赤のテスト用PNGを作成する。
+func createTransparentTestPNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := width / 2; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("テスト画像ファイルの作成に失敗: %v", err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("テスト画像のエンコードに失敗: %v", err)
+	}
+}
+
+func TestParseThumbnailFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    thumbnailFormat
+		wantErr bool
+	}{
+		{input: "", want: thumbnailFormatAuto},
+		{input: "auto", want: thumbnailFormatAuto},
+		{input: "JPEG", want: thumbnailFormatJPEG},
+		{input: "jpg", want: thumbnailFormatJPEG},
+		{input: " png ", want: thumbnailFormatPNG},
+		{input: "webp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseThumbnailFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseThumbnailFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseThumbnailFormat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveThumbnailFormat(t *testing.T) {
+	t.Parallel()
+
+	opaque := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			opaque.Set(x, y, color.RGBA{G: 255, A: 255})
+		}
+	}
+	translucent := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	translucent.Set(0, 0, color.NRGBA{R: 255, A: 128})
+	paletted := image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.Transparent, color.Black})
+
+	tests := []struct {
+		name   string
+		format thumbnailFormat
+		src    image.Image
+		want   thumbnailFormat
+	}{
+		{name: "正常系_autoで不透明な画像はJPEG", format: thumbnailFormatAuto, src: opaque, want: thumbnailFormatJPEG},
+		{name: "正常系_autoで半透明のピクセルを含む画像はPNG", format: thumbnailFormatAuto, src: translucent, want: thumbnailFormatPNG},
+		{name: "正常系_autoで透過色を使うGIFはPNG", format: thumbnailFormatAuto, src: paletted, want: thumbnailFormatPNG},
+		{name: "正常系_jpeg指定は透過があってもJPEG", format: thumbnailFormatJPEG, src: translucent, want: thumbnailFormatJPEG},
+		{name: "正常系_png指定は不透明でもPNG", format: thumbnailFormatPNG, src: opaque, want: thumbnailFormatPNG},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := resolveThumbnailFormat(tt.format, tt.src); got != tt.want {
+				t.Errorf("期待する出力形式 %q, 実際の出力形式 %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHandleProcessThumbnailFormat(t *testing.T) {
+	t.Parallel()
+
+	// process はサムネイル生成を実行し、レスポンスとアウトボックスに記録されたMediaProcessedイベントを返す。
+	process := func(t *testing.T, s *Server, req processRequest) (map[string]any, event.MediaProcessedData) {
+		t.Helper()
+		reqBody, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/media/test-media-id/process", bytes.NewReader(reqBody))
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httpReq)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+
+		pending, err := s.queries.ListPendingOutboxEvents(t.Context(), outboxBatchSize)
+		if err != nil {
+			t.Fatalf("アウトボックスの取得に失敗: %v", err)
+		}
+		if len(pending) != 1 || pending[0].EventType != string(event.TypeMediaProcessed) {
+			t.Fatalf("MediaProcessedイベントが記録されていません: %+v", pending)
+		}
+		var data event.MediaProcessedData
+		if err := json.Unmarshal([]byte(pending[0].Data), &data); err != nil {
+			t.Fatalf("イベントデータのデシリアライズに失敗: %v", err)
+		}
+		return resp, data
+	}
+
+	t.Run("正常系_透過PNGは透過を保ったPNGのサムネイルになる", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		srcPath := filepath.Join(tmpDir, "logo.png")
+		createTransparentTestPNG(t, srcPath, 400, 300)
+
+		s := setupTestServer(t, "http://localhost:0")
+		resp, data := process(t, s, processRequest{StoragePath: srcPath, ContentType: "image/png"})
+
+		wantPath := filepath.Join(tmpDir, "thumbnail.png")
+		if resp["format"] != "png" || resp["thumbnail_path"] != wantPath {
+			t.Errorf("期待するformat png, thumbnail_path %q, 実際のformat %v, thumbnail_path %v", wantPath, resp["format"], resp["thumbnail_path"])
+		}
+		if data.ThumbnailFormat != "png" || data.ThumbnailPath != wantPath {
+			t.Errorf("イベントの出力形式・パスが不正: %+v", data)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "thumbnail.jpg")); !os.IsNotExist(err) {
+			t.Error("JPEGのサムネイルが生成されています")
+		}
+
+		thumbFile, err := os.Open(wantPath)
+		if err != nil {
+			t.Fatalf("サムネイルファイルのオープンに失敗: %v", err)
+		}
+		defer thumbFile.Close()
+		thumb, err := png.Decode(thumbFile)
+		if err != nil {
+			t.Fatalf("サムネイルのデコードに失敗: %v", err)
+		}
+		if b := thumb.Bounds(); b.Dx() != thumbnailSize || b.Dy() != thumbnailSize {
+			t.Fatalf("期待するサイズ %dx%d, 実際のサイズ %dx%d", thumbnailSize, thumbnailSize, b.Dx(), b.Dy())
+		}
+
+		// 400x300を200x150に縮小し、上下25ピクセルずつがcontainの余白になる
+		for _, tc := range []struct {
+			name      string
+			p         image.Point
+			wantAlpha uint32
+		}{
+			{name: "元画像の透明部分", p: image.Pt(50, 100), wantAlpha: 0},
+			{name: "元画像の不透明部分", p: image.Pt(150, 100), wantAlpha: 0xffff},
+			{name: "containの余白", p: image.Pt(100, 5), wantAlpha: 0},
+		} {
+			if _, _, _, a := thumb.At(tc.p.X, tc.p.Y).RGBA(); a != tc.wantAlpha {
+				t.Errorf("%s %v のアルファ値 = %#x; 期待値 = %#x", tc.name, tc.p, a, tc.wantAlpha)
+			}
+		}
+	})
+
+	t.Run("正常系_jpeg指定は透過PNGでもJPEGにし、以前のPNGのサムネイルを削除する", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		srcPath := filepath.Join(tmpDir, "logo.png")
+		createTransparentTestPNG(t, srcPath, 400, 300)
+		stalePath := filepath.Join(tmpDir, "thumbnail.png")
+		if err := os.WriteFile(stalePath, []byte("stale"), 0o644); err != nil {
+			t.Fatalf("以前のサムネイルの書き込みに失敗: %v", err)
+		}
+
+		s := setupTestServer(t, "http://localhost:0")
+		s.thumbnailFormat = thumbnailFormatPNG
+		resp, data := process(t, s, processRequest{StoragePath: srcPath, Format: "jpeg"})
+
+		if resp["format"] != "jpeg" || data.ThumbnailFormat != "jpeg" {
+			t.Errorf("期待する出力形式 jpeg, 実際のレスポンス %v, イベント %q", resp["format"], data.ThumbnailFormat)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "thumbnail.jpg")); err != nil {
+			t.Errorf("JPEGのサムネイルが生成されていません: %v", err)
+		}
+		if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+			t.Error("以前のPNGのサムネイルが削除されていません")
+		}
+	})
+
+	t.Run("正常系_サーバー既定値のpngは不透明な画像にも適用される", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		srcPath := filepath.Join(tmpDir, "photo.png")
+		createTestImage(t, srcPath, 400, 300)
+
+		s := setupTestServer(t, "http://localhost:0")
+		s.thumbnailFormat = thumbnailFormatPNG
+		_, data := process(t, s, processRequest{StoragePath: srcPath})
+
+		if data.ThumbnailFormat != "png" || filepath.Base(data.ThumbnailPath) != "thumbnail.png" {
+			t.Errorf("イベントの出力形式・パスが不正: %+v", data)
+		}
+	})
+
+	t.Run("異常系_不明な出力形式の場合400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupTestServer(t, "http://localhost:0")
+
+		reqBody, _ := json.Marshal(processRequest{StoragePath: "/tmp/test.png", Format: "gif"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/media/test-media-id/process", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+}
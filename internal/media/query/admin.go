@@ -0,0 +1,48 @@
+package query
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// parseAdminUserIDs は環境変数ADMIN_USER_IDSの値（カンマ区切りのユーザーID）を管理者の集合に変換する。
+// 前後の空白を除き、空の要素は含めない。
+func parseAdminUserIDs(v string) map[string]struct{} {
+	ids := make(map[string]struct{})
+	for _, id := range strings.Split(v, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// isAdmin はリクエストのトークンが管理者ユーザーのものかを返す。
+func (s *Server) isAdmin(c *gin.Context) bool {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return false
+	}
+	_, ok := s.adminUserIDs[userID]
+	return ok
+}
+
+// requireAdmin は管理者以外のリクエストを403で拒否するミドルウェアを返す。
+func (s *Server) requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.isAdmin(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "管理者のみ実行できます"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// includeDeleted はクエリパラメータinclude_deleted=trueが指定され、かつ管理者のリクエストかを返す。
+// 管理者以外がinclude_deletedを指定した場合は無視し、削除済みメディアを含めない。
+func (s *Server) includeDeleted(c *gin.Context) bool {
+	return c.Query("include_deleted") == "true" && s.isAdmin(c)
+}
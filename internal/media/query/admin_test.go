@@ -0,0 +1,129 @@
+package query
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseAdminUserIDs(t *testing.T) {
+	t.Parallel()
+
+	got := parseAdminUserIDs(" admin-1, ,admin-2 ")
+	if len(got) != 2 {
+		t.Fatalf("期待する件数 2, 実際の件数 %d", len(got))
+	}
+	for _, id := range []string{"admin-1", "admin-2"} {
+		if _, ok := got[id]; !ok {
+			t.Errorf("%q が管理者に含まれていません", id)
+		}
+	}
+	if len(parseAdminUserIDs("")) != 0 {
+		t.Error("空文字列の場合は管理者を含めないこと")
+	}
+}
+
+// setupIncludeDeletedTestServer は管理者admin-1と削除済みを含むメディアを持つテスト用サーバーを作成する。
+func setupIncludeDeletedTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	s, db := setupTestQueryServer(t)
+	s.adminUserIDs = parseAdminUserIDs("admin-1")
+	for _, userID := range []string{"admin-1", "user-123"} {
+		insertTestMedia(t, db, userID+"-active", userID, "active.jpg", "image/jpeg", 1024, "/data/media/"+userID+"-active/active.jpg", "processed")
+		insertTestMedia(t, db, userID+"-deleted", userID, "deleted.jpg", "image/jpeg", 1024, "/data/media/"+userID+"-deleted/deleted.jpg", "deleted")
+	}
+	return s
+}
+
+func TestHandleListMedia_IncludeDeleted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		userID      string
+		query       string
+		wantDeleted bool
+	}{
+		{name: "正常系_管理者がinclude_deletedを指定すると削除済みメディアを含める", userID: "admin-1", query: "?include_deleted=true", wantDeleted: true},
+		{name: "正常系_管理者でもinclude_deletedを指定しなければ削除済みメディアを含めない", userID: "admin-1", query: "", wantDeleted: false},
+		{name: "異常系_一般ユーザーがinclude_deletedを指定しても削除済みメディアを含めない", userID: "user-123", query: "?include_deleted=true", wantDeleted: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := setupIncludeDeletedTestServer(t)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/media"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+generateTestToken(t, tt.userID, "test@example.com"))
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var resp struct {
+				Media []mediaResponse `json:"media"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+
+			statuses := make(map[string]string, len(resp.Media))
+			for _, m := range resp.Media {
+				statuses[m.ID] = m.Status
+			}
+			if statuses[tt.userID+"-active"] != "processed" {
+				t.Errorf("削除されていないメディアが含まれていません: %v", statuses)
+			}
+			status, ok := statuses[tt.userID+"-deleted"]
+			if ok != tt.wantDeleted {
+				t.Fatalf("削除済みメディアの有無: 期待 %v, 実際 %v", tt.wantDeleted, ok)
+			}
+			if ok && status != "deleted" {
+				t.Errorf("期待するStatus %q, 実際のStatus %q", "deleted", status)
+			}
+		})
+	}
+}
+
+func TestHandleGetMedia_IncludeDeleted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		userID     string
+		query      string
+		wantStatus int
+	}{
+		{name: "正常系_管理者がinclude_deletedを指定すると削除済みメディアを返す", userID: "admin-1", query: "?include_deleted=true", wantStatus: http.StatusOK},
+		{name: "異常系_管理者でもinclude_deletedを指定しなければ404を返す", userID: "admin-1", query: "", wantStatus: http.StatusNotFound},
+		{name: "異常系_一般ユーザーがinclude_deletedを指定しても404を返す", userID: "user-123", query: "?include_deleted=true", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := setupIncludeDeletedTestServer(t)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/media/user-123-deleted"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+generateTestToken(t, tt.userID, "test@example.com"))
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp mediaResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+			if resp.Status != "deleted" {
+				t.Errorf("期待するStatus %q, 実際のStatus %q", "deleted", resp.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package query
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// maxBulkStatusIDs は一括ステータス変更で1リクエストに指定できるメディアIDの最大件数。
+const maxBulkStatusIDs = 100
+
+// bulkStatusTransition は一括ステータス変更の変更先ごとに発行するイベントと変更元として許可するステータス。
+type bulkStatusTransition struct {
+	// eventType は発行するイベントの種類。
+	eventType event.Type
+	// from は変更元として許可するステータスの集合。
+	from map[string]struct{}
+}
+
+// bulkStatusTransitions は一括ステータス変更で指定できる変更先ステータスと許可する遷移。
+// deletedのメディアはどのステータスにも戻せない。
+var bulkStatusTransitions = map[string]bulkStatusTransition{
+	"failed": {
+		eventType: event.TypeMediaMarkedFailed,
+		from:      map[string]struct{}{"uploaded": {}, "processed": {}, "flagged": {}},
+	},
+	"uploaded": {
+		eventType: event.TypeMediaRestored,
+		from:      map[string]struct{}{"failed": {}, "flagged": {}},
+	},
+}
+
+// bulkStatusRequest は一括ステータス変更のリクエストボディ。
+type bulkStatusRequest struct {
+	// IDs は変更対象のメディアID。
+	IDs []string `json:"ids" binding:"required"`
+	// Status は変更先のステータス（failed, uploaded）。
+	Status string `json:"status" binding:"required"`
+}
+
+// bulkStatusAppendRequest はEvent Storeへのイベント追記リクエストのJSON構造。
+type bulkStatusAppendRequest struct {
+	// AggregateID は対象メディアのAggregate ID。
+	AggregateID string `json:"aggregate_id"`
+	// AggregateType は対象エンティティの種類。
+	AggregateType string `json:"aggregate_type"`
+	// EventType はイベントの種類。
+	EventType string `json:"event_type"`
+	// Data はイベント固有のデータ（JSON形式）。
+	Data json.RawMessage `json:"data"`
+	// ExpectedVersion はRead Modelが最後に反映したバージョン。
+	// Read Modelの反映後に別のイベントが追記されていれば競合として拒否される。
+	ExpectedVersion int64 `json:"expected_version"`
+}
+
+// bulkStatusRejection は遷移が許可されず変更しなかったメディアの情報。
+type bulkStatusRejection struct {
+	// MediaID は対象メディアのID。
+	MediaID string `json:"media_id"`
+	// CurrentStatus は変更前のステータス。
+	CurrentStatus string `json:"current_status"`
+	// Reason は拒否の理由。
+	Reason string `json:"reason"`
+}
+
+// bulkStatusFailure はイベントの追記に失敗したメディアの情報。
+type bulkStatusFailure struct {
+	// MediaID は対象メディアのID。
+	MediaID string `json:"media_id"`
+	// Error は失敗の理由。
+	Error string `json:"error"`
+}
+
+// bulkStatusResponse は一括ステータス変更の結果サマリーのレスポンス。
+type bulkStatusResponse struct {
+	// Status は変更先のステータス。
+	Status string `json:"status"`
+	// Updated はイベントを発行したメディアのID。
+	Updated []string `json:"updated"`
+	// Skipped は既に変更先のステータスのため何もしなかったメディアのID。
+	Skipped []string `json:"skipped"`
+	// Rejected は遷移が許可されず変更しなかったメディア。
+	Rejected []bulkStatusRejection `json:"rejected"`
+	// NotFound はRead Modelに存在しないメディアのID。
+	NotFound []string `json:"not_found"`
+	// Failed はイベントの追記に失敗したメディア。
+	Failed []bulkStatusFailure `json:"failed"`
+}
+
+// handleBulkStatus は管理者による複数メディアのステータス一括変更を処理するハンドラ。
+// Read Modelを直接書き換えず、メディアごとにイベント（MediaMarkedFailed, MediaRestored）を
+// Event Storeへ追記し、Projector経由でRead Modelへ反映する。
+// 変更先はfailedとuploadedのみで、許可されない遷移（deleted→uploaded等）はrejectedとして返す。
+// 1件ずつ追記するため一部のメディアだけが変更される場合があり、結果はメディアごとに分類して返す。
+func (s *Server) handleBulkStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req bulkStatusRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+			return
+		}
+		if len(req.IDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "変更するメディアIDを1件以上指定してください"})
+			return
+		}
+		if len(req.IDs) > maxBulkStatusIDs {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("一度に変更できるメディアは%d件までです", maxBulkStatusIDs)})
+			return
+		}
+		transition, ok := bulkStatusTransitions[req.Status]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "statusにはfailedまたはuploadedを指定してください"})
+			return
+		}
+
+		data, err := bulkStatusEventData(transition.eventType, middleware.GetUserID(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "イベントデータのシリアライズに失敗しました"})
+			log.Printf("イベントデータシリアライズエラー: %v", err)
+			return
+		}
+
+		ctx := c.Request.Context()
+		resp := bulkStatusResponse{
+			Status:   req.Status,
+			Updated:  []string{},
+			Skipped:  []string{},
+			Rejected: []bulkStatusRejection{},
+			NotFound: []string{},
+			Failed:   []bulkStatusFailure{},
+		}
+		seen := make(map[string]struct{}, len(req.IDs))
+		for _, id := range req.IDs {
+			if _, dup := seen[id]; dup {
+				continue
+			}
+			seen[id] = struct{}{}
+
+			m, err := s.queries.GetMediaByID(ctx, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				resp.NotFound = append(resp.NotFound, id)
+				continue
+			}
+			if err != nil {
+				log.Printf("メディアの取得エラー: media_id=%s, error=%v", id, err)
+				resp.Failed = append(resp.Failed, bulkStatusFailure{MediaID: id, Error: "メディアの取得に失敗しました"})
+				continue
+			}
+			if m.Status == req.Status {
+				resp.Skipped = append(resp.Skipped, id)
+				continue
+			}
+			if _, ok := transition.from[m.Status]; !ok {
+				resp.Rejected = append(resp.Rejected, bulkStatusRejection{
+					MediaID:       id,
+					CurrentStatus: m.Status,
+					Reason:        fmt.Sprintf("%sから%sへは変更できません", m.Status, req.Status),
+				})
+				continue
+			}
+
+			appendReq := bulkStatusAppendRequest{
+				AggregateID:     m.ID,
+				AggregateType:   string(event.AggregateTypeMedia),
+				EventType:       string(transition.eventType),
+				Data:            data,
+				ExpectedVersion: m.LastEventVersion,
+			}
+			if err := s.eventStoreClient.PostJSON(ctx, "/api/v1/events", appendReq, nil); err != nil {
+				log.Printf("%sイベントの追記に失敗: media_id=%s, error=%v", transition.eventType, id, err)
+				resp.Failed = append(resp.Failed, bulkStatusFailure{MediaID: id, Error: err.Error()})
+				continue
+			}
+			resp.Updated = append(resp.Updated, id)
+		}
+
+		log.Printf("メディアのステータスを一括変更しました: status=%s, 変更=%d件, スキップ=%d件, 拒否=%d件, 未検出=%d件, 失敗=%d件",
+			req.Status, len(resp.Updated), len(resp.Skipped), len(resp.Rejected), len(resp.NotFound), len(resp.Failed))
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// bulkStatusEventData は一括ステータス変更で発行するイベントのデータを生成する。
+func bulkStatusEventData(eventType event.Type, adminUserID string) (json.RawMessage, error) {
+	switch eventType {
+	case event.TypeMediaMarkedFailed:
+		return json.Marshal(event.MediaMarkedFailedData{AdminUserID: adminUserID})
+	case event.TypeMediaRestored:
+		return json.Marshal(event.MediaRestoredData{AdminUserID: adminUserID})
+	default:
+		return nil, fmt.Errorf("一括ステータス変更に対応していないイベントタイプです: %s", eventType)
+	}
+}
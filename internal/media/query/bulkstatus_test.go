@@ -0,0 +1,206 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// setupBulkStatusTestServer は管理者admin-1とEvent Storeのモックを持つテスト用サーバーを作成する。
+// Read Modelの各メディアはバージョン1まで反映済みとし、conflictIDのメディアだけはEvent Store側で
+// 別のイベントが追記済み（バージョン2）として、expected_versionの競合を再現する。
+func setupBulkStatusTestServer(t *testing.T, conflictID string) (*Server, *eventstoretest.Server) {
+	t.Helper()
+
+	s, db := setupTestQueryServer(t)
+	s.adminUserIDs = parseAdminUserIDs("admin-1")
+	store := eventstoretest.New(t)
+	for id, status := range map[string]string{
+		"media-uploaded":  "uploaded",
+		"media-processed": "processed",
+		"media-failed":    "failed",
+		"media-flagged":   "flagged",
+		"media-deleted":   "deleted",
+		"media-conflict":  "processed",
+	} {
+		insertTestMedia(t, db, id, "user-123", id+".jpg", "image/jpeg", 1024, "/data/media/"+id+"/"+id+".jpg", status)
+		store.SetVersion(id, 1)
+	}
+	if conflictID != "" {
+		store.SetVersion(conflictID, 2)
+	}
+	s.eventStoreClient = httpclient.New(store.URL)
+
+	return s, store
+}
+
+// postBulkStatus は一括ステータス変更のエンドポイントを呼び出す。
+func postBulkStatus(t *testing.T, s *Server, userID string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("リクエストボディのJSON変換に失敗: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/media/bulk-status", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+generateTestToken(t, userID, "test@example.com"))
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleBulkStatus(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_failedへの変更で許可された遷移だけイベントを発行し結果を分類して返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, store := setupBulkStatusTestServer(t, "media-conflict")
+		w := postBulkStatus(t, s, "admin-1", bulkStatusRequest{
+			IDs:    []string{"media-uploaded", "media-processed", "media-failed", "media-deleted", "media-missing", "media-conflict", "media-uploaded"},
+			Status: "failed",
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var resp bulkStatusResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		if !slices.Equal(resp.Updated, []string{"media-uploaded", "media-processed"}) {
+			t.Errorf("期待するupdated [media-uploaded media-processed], 実際 %v", resp.Updated)
+		}
+		if !slices.Equal(resp.Skipped, []string{"media-failed"}) {
+			t.Errorf("期待するskipped [media-failed], 実際 %v", resp.Skipped)
+		}
+		if len(resp.Rejected) != 1 || resp.Rejected[0].MediaID != "media-deleted" || resp.Rejected[0].CurrentStatus != "deleted" {
+			t.Errorf("期待するrejected [media-deleted(deleted)], 実際 %+v", resp.Rejected)
+		}
+		if !slices.Equal(resp.NotFound, []string{"media-missing"}) {
+			t.Errorf("期待するnot_found [media-missing], 実際 %v", resp.NotFound)
+		}
+		if len(resp.Failed) != 1 || resp.Failed[0].MediaID != "media-conflict" {
+			t.Errorf("期待するfailed [media-conflict], 実際 %+v", resp.Failed)
+		}
+
+		store.AssertEventTypes(t, "MediaMarkedFailed", "MediaMarkedFailed")
+		for _, ev := range store.Events() {
+			if ev.AggregateType != "Media" || ev.ExpectedVersion == nil || *ev.ExpectedVersion != 1 {
+				t.Errorf("期待するMedia/expected_version=1, 実際 %+v", ev)
+			}
+			if data := eventstoretest.DecodeData[event.MediaMarkedFailedData](t, ev); data.AdminUserID != "admin-1" {
+				t.Errorf("期待するadmin_user_id admin-1, 実際 %q", data.AdminUserID)
+			}
+		}
+
+		// イベント発行時点ではRead Modelを直接書き換えない
+		model, err := s.queries.GetMediaByID(context.Background(), "media-uploaded")
+		if err != nil {
+			t.Fatalf("GetMediaByIDが失敗: %v", err)
+		}
+		if model.Status != "uploaded" {
+			t.Errorf("期待するStatus %q, 実際のStatus %q", "uploaded", model.Status)
+		}
+	})
+
+	t.Run("正常系_uploadedへの変更はfailedとflaggedだけを戻しProjector経由で反映する", func(t *testing.T) {
+		t.Parallel()
+
+		s, store := setupBulkStatusTestServer(t, "")
+		w := postBulkStatus(t, s, "admin-1", bulkStatusRequest{
+			IDs:    []string{"media-failed", "media-flagged", "media-deleted", "media-processed"},
+			Status: "uploaded",
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var resp bulkStatusResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		if !slices.Equal(resp.Updated, []string{"media-failed", "media-flagged"}) {
+			t.Errorf("期待するupdated [media-failed media-flagged], 実際 %v", resp.Updated)
+		}
+		if len(resp.Rejected) != 2 {
+			t.Errorf("期待するrejected件数 2, 実際 %+v", resp.Rejected)
+		}
+
+		// 追記されたイベントをProjectorで反映するとRead Modelがuploadedに戻る
+		p := NewProjector(s.db, "http://localhost:9999")
+		store.AssertEventTypes(t, "MediaRestored", "MediaRestored")
+		for _, ev := range store.Events() {
+			err := p.processEvent(context.Background(), p.queries, eventStoreResponse{
+				ID:            ev.ID,
+				AggregateID:   ev.AggregateID,
+				AggregateType: ev.AggregateType,
+				EventType:     ev.EventType,
+				Data:          string(ev.Data),
+				Version:       ev.Version,
+				CreatedAt:     ev.CreatedAt.Format(time.RFC3339),
+			})
+			if err != nil {
+				t.Fatalf("%sの処理に失敗: %v", ev.EventType, err)
+			}
+		}
+		for _, id := range []string{"media-failed", "media-flagged"} {
+			model, err := s.queries.GetMediaByID(context.Background(), id)
+			if err != nil {
+				t.Fatalf("GetMediaByIDが失敗: %v", err)
+			}
+			if model.Status != "uploaded" || model.LastEventVersion != 2 {
+				t.Errorf("%s: 期待する uploaded/v2, 実際 %s/v%d", id, model.Status, model.LastEventVersion)
+			}
+		}
+	})
+
+	t.Run("異常系_管理者以外は403を返しイベントを発行しない", func(t *testing.T) {
+		t.Parallel()
+
+		s, store := setupBulkStatusTestServer(t, "")
+		w := postBulkStatus(t, s, "user-123", bulkStatusRequest{IDs: []string{"media-uploaded"}, Status: "failed"})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusForbidden, w.Code)
+		}
+		if got := len(store.Events()); got != 0 {
+			t.Errorf("期待する追記件数 0, 実際の追記件数 %d", got)
+		}
+	})
+
+	t.Run("異常系_不正なリクエストは400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, store := setupBulkStatusTestServer(t, "")
+		tooMany := make([]string, maxBulkStatusIDs+1)
+		for i := range tooMany {
+			tooMany[i] = "media-uploaded"
+		}
+		tests := []struct {
+			name string
+			body any
+		}{
+			{name: "idsが空", body: bulkStatusRequest{IDs: []string{}, Status: "failed"}},
+			{name: "idsの上限超過", body: bulkStatusRequest{IDs: tooMany, Status: "failed"}},
+			{name: "statusの欠落", body: map[string]any{"ids": []string{"media-uploaded"}}},
+			{name: "変更先に指定できないstatus", body: bulkStatusRequest{IDs: []string{"media-failed"}, Status: "deleted"}},
+		}
+		for _, tt := range tests {
+			if w := postBulkStatus(t, s, "admin-1", tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: 期待するステータスコード %d, 実際のステータスコード %d", tt.name, http.StatusBadRequest, w.Code)
+			}
+		}
+		if got := len(store.Events()); got != 0 {
+			t.Errorf("期待する追記件数 0, 実際の追記件数 %d", got)
+		}
+	})
+}
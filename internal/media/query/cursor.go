@@ -0,0 +1,42 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	mediadb "github.com/nao1215/micro/internal/media/query/db"
+)
+
+// errInvalidCursor はメディア一覧のcursorを解釈できない場合のエラー。
+var errInvalidCursor = errors.New("cursorが不正です")
+
+// mediaListCursor はメディア一覧のカーソルベースのページングで、前ページの最後のメディアの位置を表す。
+// 一覧はuploaded_at、同じ日時の中ではidの昇順に並べるため、この2つの組で次ページの開始位置が一意に決まる。
+type mediaListCursor struct {
+	// UploadedAt は前ページの最後のメディアのアップロード日時。
+	UploadedAt time.Time `json:"uploaded_at"`
+	// ID は前ページの最後のメディアのID。
+	ID string `json:"id"`
+}
+
+// encodeMediaListCursor はメディアの位置をcursorの文字列（JSONをURLセーフなbase64でエンコードしたもの）に変換する。
+// 日時はナノ秒まで保持し、Read Modelの値と完全に一致させる。
+func encodeMediaListCursor(m mediadb.MediaReadModel) string {
+	data, _ := json.Marshal(mediaListCursor{UploadedAt: m.UploadedAt, ID: m.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeMediaListCursor はcursorの文字列を解釈する。base64やJSONとして不正な場合、必須の項目がない場合はerrInvalidCursorを返す。
+func decodeMediaListCursor(v string) (mediaListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return mediaListCursor{}, errInvalidCursor
+	}
+	var cursor mediaListCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || cursor.UploadedAt.IsZero() {
+		return mediaListCursor{}, errInvalidCursor
+	}
+	return cursor, nil
+}
@@ -10,20 +10,21 @@ import (
 )
 
 type MediaReadModel struct {
-	ID               string
-	UserID           string
-	Filename         string
-	ContentType      string
-	Size             int64
-	StoragePath      string
-	ThumbnailPath    sql.NullString
-	Width            sql.NullInt64
-	Height           sql.NullInt64
-	DurationSeconds  sql.NullFloat64
-	Status           string
-	LastEventVersion int64
-	UploadedAt       time.Time
-	UpdatedAt        time.Time
+	ID                 string
+	UserID             string
+	Filename           string
+	ContentType        string
+	Size               int64
+	StoragePath        string
+	ThumbnailPath      sql.NullString
+	Width              sql.NullInt64
+	Height             sql.NullInt64
+	DurationSeconds    sql.NullFloat64
+	Status             string
+	LastEventVersion   int64
+	UploadedAt         time.Time
+	UpdatedAt          time.Time
+	FilenameNormalized string
 }
 
 type ProjectorOffset struct {
@@ -8,6 +8,7 @@ package mediadb
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 )
 
@@ -20,10 +21,20 @@ func (q *Queries) DeleteAllMediaReadModels(ctx context.Context) error {
 	return err
 }
 
+const deleteMediaReadModel = `-- name: DeleteMediaReadModel :exec
+DELETE FROM media_read_models WHERE id = ?
+`
+
+func (q *Queries) DeleteMediaReadModel(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteMediaReadModel, id)
+	return err
+}
+
 const getMediaByID = `-- name: GetMediaByID :one
 SELECT id, user_id, filename, content_type, size, storage_path,
        thumbnail_path, width, height, duration_seconds,
-       status, last_event_version, uploaded_at, updated_at
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
 FROM media_read_models
 WHERE id = ?
 `
@@ -46,10 +57,30 @@ func (q *Queries) GetMediaByID(ctx context.Context, id string) (MediaReadModel,
 		&i.LastEventVersion,
 		&i.UploadedAt,
 		&i.UpdatedAt,
+		&i.FilenameNormalized,
 	)
 	return i, err
 }
 
+const getMediaStatus = `-- name: GetMediaStatus :one
+SELECT user_id, status, updated_at
+FROM media_read_models
+WHERE id = ?
+`
+
+type GetMediaStatusRow struct {
+	UserID    string
+	Status    string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) GetMediaStatus(ctx context.Context, id string) (GetMediaStatusRow, error) {
+	row := q.db.QueryRowContext(ctx, getMediaStatus, id)
+	var i GetMediaStatusRow
+	err := row.Scan(&i.UserID, &i.Status, &i.UpdatedAt)
+	return i, err
+}
+
 const getProjectorOffset = `-- name: GetProjectorOffset :one
 SELECT last_timestamp FROM projector_offsets WHERE id = 'default'
 `
@@ -64,9 +95,10 @@ func (q *Queries) GetProjectorOffset(ctx context.Context) (time.Time, error) {
 const listAllMedia = `-- name: ListAllMedia :many
 SELECT id, user_id, filename, content_type, size, storage_path,
        thumbnail_path, width, height, duration_seconds,
-       status, last_event_version, uploaded_at, updated_at
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
 FROM media_read_models
-WHERE status != 'deleted'
+WHERE status NOT IN ('deleted', 'flagged')
 ORDER BY uploaded_at DESC
 `
 
@@ -94,6 +126,7 @@ func (q *Queries) ListAllMedia(ctx context.Context) ([]MediaReadModel, error) {
 			&i.LastEventVersion,
 			&i.UploadedAt,
 			&i.UpdatedAt,
+			&i.FilenameNormalized,
 		); err != nil {
 			return nil, err
 		}
@@ -108,17 +141,133 @@ func (q *Queries) ListAllMedia(ctx context.Context) ([]MediaReadModel, error) {
 	return items, nil
 }
 
-const listMediaByUserID = `-- name: ListMediaByUserID :many
+const listMediaByUserIDAndStatuses = `-- name: ListMediaByUserIDAndStatuses :many
 SELECT id, user_id, filename, content_type, size, storage_path,
        thumbnail_path, width, height, duration_seconds,
-       status, last_event_version, uploaded_at, updated_at
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
 FROM media_read_models
-WHERE user_id = ? AND status != 'deleted'
-ORDER BY uploaded_at DESC
+WHERE user_id = ? AND status IN (/*SLICE:statuses*/?)
+ORDER BY
+    CASE WHEN ? = 'uploaded_at_asc' THEN uploaded_at END ASC,
+    CASE WHEN ? = 'uploaded_at_desc' THEN uploaded_at END DESC,
+    CASE WHEN ? = 'size_asc' THEN size END ASC,
+    CASE WHEN ? = 'size_desc' THEN size END DESC,
+    CASE WHEN ? = 'filename_asc' THEN filename END ASC,
+    CASE WHEN ? = 'filename_desc' THEN filename END DESC,
+    uploaded_at DESC, id ASC
+`
+
+type ListMediaByUserIDAndStatusesParams struct {
+	UserID   string
+	Statuses []string
+	Sort     string
+}
+
+func (q *Queries) ListMediaByUserIDAndStatuses(ctx context.Context, arg ListMediaByUserIDAndStatusesParams) ([]MediaReadModel, error) {
+	query := listMediaByUserIDAndStatuses
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.UserID)
+	if len(arg.Statuses) > 0 {
+		for _, v := range arg.Statuses {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:statuses*/?", strings.Repeat(",?", len(arg.Statuses))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:statuses*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.Sort)
+	queryParams = append(queryParams, arg.Sort)
+	queryParams = append(queryParams, arg.Sort)
+	queryParams = append(queryParams, arg.Sort)
+	queryParams = append(queryParams, arg.Sort)
+	queryParams = append(queryParams, arg.Sort)
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MediaReadModel
+	for rows.Next() {
+		var i MediaReadModel
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.StoragePath,
+			&i.ThumbnailPath,
+			&i.Width,
+			&i.Height,
+			&i.DurationSeconds,
+			&i.Status,
+			&i.LastEventVersion,
+			&i.UploadedAt,
+			&i.UpdatedAt,
+			&i.FilenameNormalized,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMediaPageByUserIDAndStatuses = `-- name: ListMediaPageByUserIDAndStatuses :many
+SELECT id, user_id, filename, content_type, size, storage_path,
+       thumbnail_path, width, height, duration_seconds,
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
+FROM media_read_models
+WHERE user_id = ? AND status IN (/*SLICE:statuses*/?)
+  AND (? IS NULL
+    OR (uploaded_at = ? AND id > ?)
+    OR (? = 'uploaded_at_asc' AND uploaded_at > ?)
+    OR (? = 'uploaded_at_desc' AND uploaded_at < ?))
+ORDER BY
+    CASE WHEN ? = 'uploaded_at_asc' THEN uploaded_at END ASC,
+    uploaded_at DESC, id ASC
+LIMIT ?
 `
 
-func (q *Queries) ListMediaByUserID(ctx context.Context, userID string) ([]MediaReadModel, error) {
-	rows, err := q.db.QueryContext(ctx, listMediaByUserID, userID)
+type ListMediaPageByUserIDAndStatusesParams struct {
+	UserID           string
+	Statuses         []string
+	CursorUploadedAt sql.NullTime
+	CursorID         string
+	Sort             string
+	Limit            int64
+}
+
+func (q *Queries) ListMediaPageByUserIDAndStatuses(ctx context.Context, arg ListMediaPageByUserIDAndStatusesParams) ([]MediaReadModel, error) {
+	query := listMediaPageByUserIDAndStatuses
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.UserID)
+	if len(arg.Statuses) > 0 {
+		for _, v := range arg.Statuses {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:statuses*/?", strings.Repeat(",?", len(arg.Statuses))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:statuses*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.CursorUploadedAt)
+	queryParams = append(queryParams, arg.CursorUploadedAt)
+	queryParams = append(queryParams, arg.CursorID)
+	queryParams = append(queryParams, arg.Sort)
+	queryParams = append(queryParams, arg.CursorUploadedAt)
+	queryParams = append(queryParams, arg.Sort)
+	queryParams = append(queryParams, arg.CursorUploadedAt)
+	queryParams = append(queryParams, arg.Sort)
+	queryParams = append(queryParams, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
@@ -141,6 +290,181 @@ func (q *Queries) ListMediaByUserID(ctx context.Context, userID string) ([]Media
 			&i.LastEventVersion,
 			&i.UploadedAt,
 			&i.UpdatedAt,
+			&i.FilenameNormalized,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMediaWithoutNormalizedFilename = `-- name: ListMediaWithoutNormalizedFilename :many
+SELECT id, filename
+FROM media_read_models
+WHERE filename_normalized = '' AND filename != ''
+`
+
+type ListMediaWithoutNormalizedFilenameRow struct {
+	ID       string
+	Filename string
+}
+
+func (q *Queries) ListMediaWithoutNormalizedFilename(ctx context.Context) ([]ListMediaWithoutNormalizedFilenameRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMediaWithoutNormalizedFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMediaWithoutNormalizedFilenameRow
+	for rows.Next() {
+		var i ListMediaWithoutNormalizedFilenameRow
+		if err := rows.Scan(&i.ID, &i.Filename); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProcessedImageIDs = `-- name: ListProcessedImageIDs :many
+SELECT id
+FROM media_read_models
+WHERE status = 'processed' AND content_type LIKE 'image/%' AND id > ?
+ORDER BY id ASC
+LIMIT ?
+`
+
+type ListProcessedImageIDsParams struct {
+	ID    string
+	Limit int64
+}
+
+func (q *Queries) ListProcessedImageIDs(ctx context.Context, arg ListProcessedImageIDsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listProcessedImageIDs, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStaleUploadedMedia = `-- name: ListStaleUploadedMedia :many
+SELECT id, user_id, filename, content_type, size, storage_path,
+       thumbnail_path, width, height, duration_seconds,
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
+FROM media_read_models
+WHERE status = 'uploaded' AND uploaded_at < ?
+ORDER BY uploaded_at ASC
+LIMIT ?
+`
+
+type ListStaleUploadedMediaParams struct {
+	UploadedAt time.Time
+	Limit      int64
+}
+
+func (q *Queries) ListStaleUploadedMedia(ctx context.Context, arg ListStaleUploadedMediaParams) ([]MediaReadModel, error) {
+	rows, err := q.db.QueryContext(ctx, listStaleUploadedMedia, arg.UploadedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MediaReadModel
+	for rows.Next() {
+		var i MediaReadModel
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.StoragePath,
+			&i.ThumbnailPath,
+			&i.Width,
+			&i.Height,
+			&i.DurationSeconds,
+			&i.Status,
+			&i.LastEventVersion,
+			&i.UploadedAt,
+			&i.UpdatedAt,
+			&i.FilenameNormalized,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStuckMedia = `-- name: ListStuckMedia :many
+SELECT id, user_id, filename, content_type, size, storage_path,
+       thumbnail_path, width, height, duration_seconds,
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
+FROM media_read_models
+WHERE status = 'uploaded' AND uploaded_at <= ?
+ORDER BY uploaded_at ASC
+`
+
+func (q *Queries) ListStuckMedia(ctx context.Context, uploadedAt time.Time) ([]MediaReadModel, error) {
+	rows, err := q.db.QueryContext(ctx, listStuckMedia, uploadedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MediaReadModel
+	for rows.Next() {
+		var i MediaReadModel
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.StoragePath,
+			&i.ThumbnailPath,
+			&i.Width,
+			&i.Height,
+			&i.DurationSeconds,
+			&i.Status,
+			&i.LastEventVersion,
+			&i.UploadedAt,
+			&i.UpdatedAt,
+			&i.FilenameNormalized,
 		); err != nil {
 			return nil, err
 		}
@@ -158,14 +482,33 @@ func (q *Queries) ListMediaByUserID(ctx context.Context, userID string) ([]Media
 const searchMedia = `-- name: SearchMedia :many
 SELECT id, user_id, filename, content_type, size, storage_path,
        thumbnail_path, width, height, duration_seconds,
-       status, last_event_version, uploaded_at, updated_at
+       status, last_event_version, uploaded_at, updated_at,
+       filename_normalized
 FROM media_read_models
-WHERE filename LIKE ? AND status != 'deleted'
+WHERE filename_normalized LIKE ?
+  AND status NOT IN ('deleted', 'flagged')
+  AND content_type LIKE ?
+  AND (? IS NULL OR uploaded_at >= ?)
+  AND (? IS NULL OR uploaded_at <= ?)
 ORDER BY uploaded_at DESC
 `
 
-func (q *Queries) SearchMedia(ctx context.Context, filename string) ([]MediaReadModel, error) {
-	rows, err := q.db.QueryContext(ctx, searchMedia, filename)
+type SearchMediaParams struct {
+	FilenameNormalized string
+	ContentType        string
+	UploadedFrom       sql.NullTime
+	UploadedTo         sql.NullTime
+}
+
+func (q *Queries) SearchMedia(ctx context.Context, arg SearchMediaParams) ([]MediaReadModel, error) {
+	rows, err := q.db.QueryContext(ctx, searchMedia,
+		arg.FilenameNormalized,
+		arg.ContentType,
+		arg.UploadedFrom,
+		arg.UploadedFrom,
+		arg.UploadedTo,
+		arg.UploadedTo,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -188,6 +531,7 @@ func (q *Queries) SearchMedia(ctx context.Context, filename string) ([]MediaRead
 			&i.LastEventVersion,
 			&i.UploadedAt,
 			&i.UpdatedAt,
+			&i.FilenameNormalized,
 		); err != nil {
 			return nil, err
 		}
@@ -202,6 +546,22 @@ func (q *Queries) SearchMedia(ctx context.Context, filename string) ([]MediaRead
 	return items, nil
 }
 
+const updateMediaFilenameNormalized = `-- name: UpdateMediaFilenameNormalized :exec
+UPDATE media_read_models
+SET filename_normalized = ?
+WHERE id = ?
+`
+
+type UpdateMediaFilenameNormalizedParams struct {
+	FilenameNormalized string
+	ID                 string
+}
+
+func (q *Queries) UpdateMediaFilenameNormalized(ctx context.Context, arg UpdateMediaFilenameNormalizedParams) error {
+	_, err := q.db.ExecContext(ctx, updateMediaFilenameNormalized, arg.FilenameNormalized, arg.ID)
+	return err
+}
+
 const updateMediaProcessed = `-- name: UpdateMediaProcessed :exec
 UPDATE media_read_models
 SET thumbnail_path = ?,
@@ -255,24 +615,26 @@ func (q *Queries) UpdateMediaStatus(ctx context.Context, arg UpdateMediaStatusPa
 }
 
 const upsertMediaReadModel = `-- name: UpsertMediaReadModel :exec
-INSERT INTO media_read_models (id, user_id, filename, content_type, size, storage_path, status, last_event_version, uploaded_at, updated_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
+INSERT INTO media_read_models (id, user_id, filename, filename_normalized, content_type, size, storage_path, status, last_event_version, uploaded_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
 ON CONFLICT(id) DO UPDATE SET
+    filename_normalized = excluded.filename_normalized,
     status = excluded.status,
     last_event_version = excluded.last_event_version,
     updated_at = datetime('now')
 `
 
 type UpsertMediaReadModelParams struct {
-	ID               string
-	UserID           string
-	Filename         string
-	ContentType      string
-	Size             int64
-	StoragePath      string
-	Status           string
-	LastEventVersion int64
-	UploadedAt       time.Time
+	ID                 string
+	UserID             string
+	Filename           string
+	FilenameNormalized string
+	ContentType        string
+	Size               int64
+	StoragePath        string
+	Status             string
+	LastEventVersion   int64
+	UploadedAt         time.Time
 }
 
 func (q *Queries) UpsertMediaReadModel(ctx context.Context, arg UpsertMediaReadModelParams) error {
@@ -280,6 +642,7 @@ func (q *Queries) UpsertMediaReadModel(ctx context.Context, arg UpsertMediaReadM
 		arg.ID,
 		arg.UserID,
 		arg.Filename,
+		arg.FilenameNormalized,
 		arg.ContentType,
 		arg.Size,
 		arg.StoragePath,
@@ -0,0 +1 @@
+ALTER TABLE media_read_models DROP COLUMN filename_normalized;
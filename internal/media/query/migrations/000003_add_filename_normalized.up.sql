@@ -0,0 +1 @@
+ALTER TABLE media_read_models ADD COLUMN filename_normalized TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,62 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mediadb "github.com/nao1215/micro/internal/media/query/db"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// hiraganaStart は変換対象のひらがなの先頭（ぁ）。
+	hiraganaStart = 'ぁ'
+	// hiraganaEnd は変換対象のひらがなの末尾（ゖ）。
+	hiraganaEnd = 'ゖ'
+	// hiraganaToKatakanaOffset はひらがなと対応するカタカナのコードポイントの差。
+	hiraganaToKatakanaOffset = 'ァ' - 'ぁ'
+)
+
+// normalizeForSearch はファイル名と検索クエリを同じ基準で比較するための検索用文字列に変換する。
+// SQLiteのLIKEはASCII以外の大文字小文字や全角・半角を区別するため、次の順で正規化してから比較する。
+//   - NFKC正規化: 全角英数字を半角に、半角カタカナを全角に統一する（「ｻﾝｾｯﾄ」→「サンセット」）
+//   - 小文字化: 英字の大文字小文字を区別しない
+//   - ひらがなをカタカナに統一する（「さんせっと」→「サンセット」）
+func normalizeForSearch(s string) string {
+	s = norm.NFKC.String(s)
+	s = strings.ToLower(s)
+	return strings.Map(hiraganaToKatakana, s)
+}
+
+// hiraganaToKatakana はひらがなを対応するカタカナに変換する。ひらがな以外はそのまま返す。
+func hiraganaToKatakana(r rune) rune {
+	switch {
+	case r >= hiraganaStart && r <= hiraganaEnd:
+		return r + hiraganaToKatakanaOffset
+	case r == 'ゝ' || r == 'ゞ':
+		// 踊り字（ゝゞ）もカタカナの踊り字（ヽヾ）に揃える
+		return r + hiraganaToKatakanaOffset
+	default:
+		return r
+	}
+}
+
+// backfillNormalizedFilenames は検索用カラムが未設定のRead Modelにファイル名の正規化結果を設定する。
+// filename_normalizedカラム追加前に投影されたレコードを、Read Modelを再構築せずに検索対象にするために使用する。
+func backfillNormalizedFilenames(ctx context.Context, queries *mediadb.Queries) (int, error) {
+	rows, err := queries.ListMediaWithoutNormalizedFilename(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("未正規化のメディア取得に失敗: %w", err)
+	}
+
+	for _, row := range rows {
+		if err := queries.UpdateMediaFilenameNormalized(ctx, mediadb.UpdateMediaFilenameNormalizedParams{
+			FilenameNormalized: normalizeForSearch(row.Filename),
+			ID:                 row.ID,
+		}); err != nil {
+			return 0, fmt.Errorf("メディア %s の正規化ファイル名の更新に失敗: %w", row.ID, err)
+		}
+	}
+	return len(rows), nil
+}
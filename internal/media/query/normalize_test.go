@@ -0,0 +1,108 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mediadb "github.com/nao1215/micro/internal/media/query/db"
+)
+
+func TestNormalizeForSearch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "半角カタカナを全角カタカナにする", input: "ｻﾝｾｯﾄ", want: "サンセット"},
+		{name: "半角カタカナの濁点・半濁点を結合する", input: "ｶﾞｲﾄﾞﾌﾞｯｸ_ﾊﾟﾝﾌﾚｯﾄ", want: "ガイドブック_パンフレット"},
+		{name: "ひらがなをカタカナにする", input: "さんせっと", want: "サンセット"},
+		{name: "濁音・半濁音のひらがなをカタカナにする", input: "がぎぐげご ぱぴぷぺぽ", want: "ガギグゲゴ パピプペポ"},
+		{name: "小書き文字のひらがなをカタカナにする", input: "ぁぃぅぇぉっゃゅょゎゕゖ", want: "ァィゥェォッャュョヮヵヶ"},
+		{name: "ひらがなの踊り字をカタカナの踊り字にする", input: "ゝゞ", want: "ヽヾ"},
+		{name: "カタカナはそのまま", input: "サンセット", want: "サンセット"},
+		{name: "全角英数字を半角にする", input: "ＰＨＯＴＯ１２３", want: "photo123"},
+		{name: "英字を小文字にする", input: "Sunset_Beach.JPG", want: "sunset_beach.jpg"},
+		{name: "全角記号・空白を半角にする", input: "旅行（２０２６）　写真．ｊｐｇ", want: "旅行(2026) 写真.jpg"},
+		{name: "漢字はそのままで送り仮名はカタカナにする", input: "夕焼け海岸", want: "夕焼ケ海岸"},
+		{name: "長音記号はそのまま", input: "ﾋﾞｰﾁ", want: "ビーチ"},
+		{name: "空文字列", input: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := normalizeForSearch(tt.input); got != tt.want {
+				t.Errorf("normalizeForSearch(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("表記の異なる同じ語は同じ文字列になる", func(t *testing.T) {
+		t.Parallel()
+
+		variants := []string{"サンセット", "ｻﾝｾｯﾄ", "さんせっと"}
+		want := normalizeForSearch(variants[0])
+		for _, v := range variants[1:] {
+			if got := normalizeForSearch(v); got != want {
+				t.Errorf("normalizeForSearch(%q) = %q, want %q", v, got, want)
+			}
+		}
+	})
+
+	t.Run("正規化は冪等である", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tt := range tests {
+			once := normalizeForSearch(tt.input)
+			if twice := normalizeForSearch(once); twice != once {
+				t.Errorf("normalizeForSearch(normalizeForSearch(%q)) = %q, want %q", tt.input, twice, once)
+			}
+		}
+	})
+}
+
+func TestBackfillNormalizedFilenames(t *testing.T) {
+	t.Parallel()
+
+	s, db := setupTestQueryServer(t)
+	ctx := context.Background()
+
+	// 正規化カラム追加前に投影されたレコードを再現する
+	if _, err := db.Exec(
+		`INSERT INTO media_read_models (id, user_id, filename, content_type, size, storage_path, status, last_event_version, uploaded_at, updated_at)
+		 VALUES ('legacy-1', 'user-123', 'ｻﾝｾｯﾄ.jpg', 'image/jpeg', 1, '/data/media/legacy-1/a.jpg', 'uploaded', 1, ?, datetime('now'))`,
+		time.Now().UTC(),
+	); err != nil {
+		t.Fatalf("テスト用メディアレコードの挿入に失敗: %v", err)
+	}
+	insertTestMedia(t, db, "current-1", "user-123", "さくら.jpg", "image/jpeg", 1, "/data/media/current-1/b.jpg", "uploaded")
+
+	n, err := backfillNormalizedFilenames(ctx, s.queries)
+	if err != nil {
+		t.Fatalf("backfillNormalizedFilenames() エラー: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("補完件数: got %d, want 1", n)
+	}
+
+	models, err := s.queries.SearchMedia(ctx, mediadb.SearchMediaParams{FilenameNormalized: "%サンセット%", ContentType: "%"})
+	if err != nil {
+		t.Fatalf("検索に失敗: %v", err)
+	}
+	if len(models) != 1 || models[0].ID != "legacy-1" {
+		t.Errorf("補完したレコードが検索でヒットしない: %+v", models)
+	}
+
+	// 補完済みのため2回目は対象なし
+	n, err = backfillNormalizedFilenames(ctx, s.queries)
+	if err != nil {
+		t.Fatalf("backfillNormalizedFilenames() エラー: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("2回目の補完件数: got %d, want 0", n)
+	}
+}
@@ -16,15 +16,40 @@ import (
 	mediadb "github.com/nao1215/micro/internal/media/query/db"
 )
 
+// defaultProjectorMaxInterval は取得失敗時に広げるポーリング間隔の既定の上限。
+const defaultProjectorMaxInterval = 30 * time.Second
+
+// 起動時にEvent Storeの起動を待機する際の既定の試行回数と初回の待機時間。
+const (
+	defaultStartupAttempts = 10
+	defaultStartupBackoff  = 500 * time.Millisecond
+)
+
+// projectorBatchSize は1つのトランザクションでRead Modelに反映するイベントの最大件数。
+const projectorBatchSize = 100
+
+// eventStorePageLimit はEvent Storeのページングされた一覧取得APIから1回に取得するイベント数（Event Storeが受け付ける最大件数）。
+const eventStorePageLimit = 1000
+
 // Projector はEvent Storeのイベントをポーリングし、Read Modelを更新するバックグラウンドプロセス。
 // Event Sourcingにおける投影（Projection）を担当する。
 type Projector struct {
+	// db はRead ModelのSQLiteデータベース接続。バッチ単位のトランザクションに使用する。
+	db *sql.DB
 	// queries はsqlcが生成したクエリ実行オブジェクト。
 	queries *mediadb.Queries
 	// client はEvent Storeとの通信用HTTPクライアント。
 	client *httpclient.Client
 	// interval はポーリング間隔。
 	interval time.Duration
+	// maxInterval はEvent Storeからの取得失敗が続いた場合に広げるポーリング間隔の上限。
+	maxInterval time.Duration
+	// startupAttempts は起動時にEvent Storeへの接続を試行する最大回数。
+	startupAttempts int
+	// startupBackoff は起動時の接続失敗後に待機する初回の時間。失敗ごとに2倍に広げる。
+	startupBackoff time.Duration
+	// consecutiveFailures はEvent Storeからの取得が連続で失敗した回数。ポーリングのゴルーチンからのみ参照する。
+	consecutiveFailures int
 	// lastTimestamp は最後にポーリングしたイベントのタイムスタンプ。
 	lastTimestamp time.Time
 	// mu はlastTimestampへの並行アクセスを保護するミューテックス。
@@ -34,18 +59,25 @@ type Projector struct {
 }
 
 // NewProjector は新しいProjectorを生成する。
+// sqlDB はRead ModelのSQLiteデータベース接続。
 // eventstoreURL はEvent StoreのベースURL（例: "http://localhost:8084"）。
-func NewProjector(queries *mediadb.Queries, eventstoreURL string) *Projector {
+func NewProjector(sqlDB *sql.DB, eventstoreURL string) *Projector {
 	return &Projector{
-		queries:       queries,
-		client:        httpclient.New(eventstoreURL),
-		interval:      2 * time.Second,
-		lastTimestamp: time.Time{},
+		db:              sqlDB,
+		queries:         mediadb.New(sqlDB),
+		client:          httpclient.New(eventstoreURL),
+		interval:        2 * time.Second,
+		maxInterval:     defaultProjectorMaxInterval,
+		startupAttempts: defaultStartupAttempts,
+		startupBackoff:  defaultStartupBackoff,
+		lastTimestamp:   time.Time{},
 	}
 }
 
 // Start はバックグラウンドでEvent Storeのポーリングを開始する。
+// コンテナの起動順序によってはEvent Storeがまだ立ち上がっていないため、接続を確認してからポーリングを始める。
 // 定期的にEvent Storeから新しいイベントを取得してRead Modelに反映する。
+// 取得に失敗した場合は次回のポーリングまでの間隔をnextIntervalで広げる。
 func (p *Projector) Start(ctx context.Context) {
 	ctx, cancel := context.WithCancel(ctx)
 	p.cancel = cancel
@@ -54,24 +86,56 @@ func (p *Projector) Start(ctx context.Context) {
 	p.loadOffset(ctx)
 
 	go func() {
+		if err := p.client.WaitForHealthy(ctx, p.startupAttempts, p.startupBackoff); err != nil {
+			if ctx.Err() != nil {
+				log.Println("Projector: ポーリングを停止しました")
+				return
+			}
+			// 接続できなくてもポーリングは継続し、Event Storeの復旧を待つ
+			log.Printf("Projector: Event Storeに接続できないままポーリングを開始します: %v", err)
+		}
+
 		log.Println("Projector: Event Storeポーリングを開始します")
-		ticker := time.NewTicker(p.interval)
-		defer ticker.Stop()
+		timer := time.NewTimer(p.interval)
+		defer timer.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
 				log.Println("Projector: ポーリングを停止しました")
 				return
-			case <-ticker.C:
-				if err := p.poll(ctx); err != nil {
-					log.Printf("Projector: ポーリングエラー: %v", err)
+			case <-timer.C:
+				err := p.poll(ctx)
+				wait := p.nextInterval(err)
+				if err != nil {
+					log.Printf("Projector: ポーリングエラー（連続%d回、%v後に再試行）: %v", p.consecutiveFailures, wait, err)
 				}
+				timer.Reset(wait)
 			}
 		}
 	}()
 }
 
+// nextInterval は直前のポーリング結果から次回のポーリングまでの待機時間を返す。
+// 取得失敗が続くごとに間隔を2倍に広げてEvent Storeの停止中の負荷を抑え、maxIntervalで頭打ちにする。
+// 取得に成功した時点で通常の間隔に戻す。
+func (p *Projector) nextInterval(pollErr error) time.Duration {
+	if pollErr == nil {
+		if p.consecutiveFailures > 0 {
+			log.Printf("Projector: Event Storeへの接続が回復しました（連続失敗%d回）", p.consecutiveFailures)
+		}
+		p.consecutiveFailures = 0
+		return p.interval
+	}
+
+	p.consecutiveFailures++
+	wait := p.interval
+	for i := 0; i < p.consecutiveFailures && wait < p.maxInterval; i++ {
+		wait *= 2
+	}
+	return min(wait, p.maxInterval)
+}
+
 // loadOffset は永続化されたオフセットを読み込み、lastTimestampに設定する。
 func (p *Projector) loadOffset(ctx context.Context) {
 	offset, err := p.queries.GetProjectorOffset(ctx)
@@ -111,36 +175,44 @@ type eventStoreResponse struct {
 }
 
 // poll はEvent Storeから新しいイベントを取得してRead Modelに反映する。
+// 長時間停止した後でも一度に大量のイベントを取得しないよう、同じsinceのままeventStorePageLimit件ずつ
+// offsetを進めて取得・反映し、全ページを反映し終えてからオフセットを進める。
 func (p *Projector) poll(ctx context.Context) error {
 	p.mu.Lock()
 	since := p.lastTimestamp
 	p.mu.Unlock()
 
 	sinceStr := since.UTC().Format(time.RFC3339)
-	path := fmt.Sprintf("/api/v1/events/since?since=%s", url.QueryEscape(sinceStr))
-
-	var events []eventStoreResponse
-	if err := p.client.GetJSON(ctx, path, &events); err != nil {
-		return fmt.Errorf("Event Storeからのイベント取得に失敗: %w", err)
-	}
-
-	if len(events) == 0 {
-		return nil
-	}
+	var (
+		latestTimestamp time.Time
+		processedCount  int
+	)
+	for offset := 0; ; {
+		path := fmt.Sprintf("/api/v1/events/since?since=%s&limit=%d&offset=%d", url.QueryEscape(sinceStr), eventStorePageLimit, offset)
+
+		var events []eventStoreResponse
+		if err := p.client.GetJSON(ctx, path, &events); err != nil {
+			return fmt.Errorf("Event Storeからのイベント取得に失敗: %w", err)
+		}
 
-	var latestTimestamp time.Time
-	for _, ev := range events {
-		if err := p.processEvent(ctx, ev); err != nil {
-			log.Printf("Projector: イベント処理エラー (id=%s, type=%s): %v", ev.ID, ev.EventType, err)
-			continue
+		for _, ev := range p.applyPolledEvents(ctx, events) {
+			createdAt, err := time.Parse(time.RFC3339, ev.CreatedAt)
+			if err == nil && createdAt.After(latestTimestamp) {
+				latestTimestamp = createdAt
+			}
 		}
+		processedCount += len(events)
+		offset += len(events)
 
-		createdAt, err := time.Parse(time.RFC3339, ev.CreatedAt)
-		if err == nil && createdAt.After(latestTimestamp) {
-			latestTimestamp = createdAt
+		if len(events) < eventStorePageLimit {
+			break
 		}
 	}
 
+	if processedCount == 0 {
+		return nil
+	}
+
 	if !latestTimestamp.IsZero() {
 		newOffset := latestTimestamp.Add(1 * time.Nanosecond)
 		p.mu.Lock()
@@ -154,13 +226,154 @@ func (p *Projector) poll(ctx context.Context) error {
 		}
 	}
 
-	log.Printf("Projector: %d件のイベントを処理しました", len(events))
+	log.Printf("Projector: %d件のイベントを処理しました", processedCount)
+	return nil
+}
+
+// applyPolledEvents はポーリングで取得した1ページ分のイベントをRead Modelに反映し、反映できたイベントを返す。
+func (p *Projector) applyPolledEvents(ctx context.Context, events []eventStoreResponse) []eventStoreResponse {
+	// 同じページ内で撤回されたイベントはRead Modelに反映しない
+	retractions := observeRetractions(events)
+	batchIDs := make(map[string]struct{}, len(events))
+	for _, ev := range events {
+		batchIDs[ev.ID] = struct{}{}
+	}
+
+	return p.applyBatches(ctx, events, func(q *mediadb.Queries, ev eventStoreResponse) error {
+		switch {
+		case retractions.Contains(ev.ID):
+			log.Printf("Projector: 撤回されたイベントをスキップします (id=%s, type=%s)", ev.ID, ev.EventType)
+			return nil
+		case event.Type(ev.EventType) == event.TypeEventRetracted:
+			return p.handleEventRetracted(ctx, q, ev, batchIDs)
+		default:
+			return p.processEvent(ctx, q, ev)
+		}
+	})
+}
+
+// applyBatches はイベント列をprojectorBatchSize件ずつ1つのトランザクションでRead Modelに反映し、反映できたイベントを返す。
+// 未反映のイベントが溜まった場合に1件ずつコミットするとfsyncが頻発するため、バッチ単位でまとめてコミットする。
+// バッチ内のいずれかのイベントの反映に失敗した場合はバッチ全体をロールバックし、
+// 反映できないイベント（poison event）を特定するため、そのバッチを1件ずつ反映し直す。
+// 反映に失敗したイベントはログに記録して読み飛ばす。
+func (p *Projector) applyBatches(ctx context.Context, events []eventStoreResponse, apply func(q *mediadb.Queries, ev eventStoreResponse) error) []eventStoreResponse {
+	applied := make([]eventStoreResponse, 0, len(events))
+	for start := 0; start < len(events); start += projectorBatchSize {
+		batch := events[start:min(start+projectorBatchSize, len(events))]
+		if err := p.applyBatch(ctx, batch, apply); err != nil {
+			log.Printf("Projector: バッチの反映に失敗したため1件ずつ反映します (%d件): %v", len(batch), err)
+			applied = append(applied, p.applyEach(batch, apply)...)
+			continue
+		}
+		applied = append(applied, batch...)
+	}
+	return applied
+}
+
+// applyBatch はイベント列を1つのトランザクションでRead Modelに反映する。
+// いずれかのイベントの反映に失敗した場合は全体をロールバックしてエラーを返す。
+func (p *Projector) applyBatch(ctx context.Context, batch []eventStoreResponse, apply func(q *mediadb.Queries, ev eventStoreResponse) error) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("トランザクション開始に失敗: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	q := p.queries.WithTx(tx)
+	for _, ev := range batch {
+		if err := apply(q, ev); err != nil {
+			return fmt.Errorf("イベントの反映に失敗 (id=%s, type=%s): %w", ev.ID, ev.EventType, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("トランザクションのコミットに失敗: %w", err)
+	}
+	return nil
+}
+
+// applyEach はイベントを1件ずつRead Modelに反映し、反映できたイベントを返す。
+func (p *Projector) applyEach(batch []eventStoreResponse, apply func(q *mediadb.Queries, ev eventStoreResponse) error) []eventStoreResponse {
+	applied := make([]eventStoreResponse, 0, len(batch))
+	for _, ev := range batch {
+		if err := apply(p.queries, ev); err != nil {
+			log.Printf("Projector: イベント処理エラー (id=%s, type=%s): %v", ev.ID, ev.EventType, err)
+			continue
+		}
+		applied = append(applied, ev)
+	}
+	return applied
+}
+
+// observeRetractions はイベント列に含まれるEventRetractedイベントから撤回対象のイベントIDを集める。
+// 撤回情報が読み取れないEventRetractedイベントはログに記録して無視する。
+func observeRetractions(events []eventStoreResponse) event.RetractionSet {
+	retractions := event.NewRetractionSet()
+	for _, ev := range events {
+		if _, err := retractions.Observe(event.Type(ev.EventType), []byte(ev.Data)); err != nil {
+			log.Printf("Projector: 撤回イベントの読み取りエラー (id=%s): %v", ev.ID, err)
+		}
+	}
+	return retractions
+}
+
+// skipRetracted は撤回されたイベントとEventRetractedイベント自体を除いたイベント列を返す。
+// event.SkipRetractedと同じ扱いをEvent Store APIのレスポンス形式に適用する。
+func skipRetracted(events []eventStoreResponse) []eventStoreResponse {
+	retractions := observeRetractions(events)
+	applicable := make([]eventStoreResponse, 0, len(events))
+	for _, ev := range events {
+		if event.Type(ev.EventType) == event.TypeEventRetracted || retractions.Contains(ev.ID) {
+			continue
+		}
+		applicable = append(applicable, ev)
+	}
+	return applicable
+}
+
+// handleEventRetracted はEventRetractedイベントをRead Modelに反映する。
+// 撤回対象が同じバッチに含まれる場合は反映前にスキップ済みのため何もしない。
+// 既に反映済みのイベントが撤回された場合は、該当メディアのRead Modelを削除し、
+// 撤回済みイベントを除いたAggregateのイベント列から再投影する。
+func (p *Projector) handleEventRetracted(ctx context.Context, q *mediadb.Queries, ev eventStoreResponse, batchIDs map[string]struct{}) error {
+	if ev.AggregateType != string(event.AggregateTypeMedia) {
+		return nil
+	}
+
+	var data event.EventRetractedData
+	if err := json.Unmarshal([]byte(ev.Data), &data); err != nil {
+		return fmt.Errorf("EventRetractedDataのデシリアライズに失敗: %w", err)
+	}
+	if _, ok := batchIDs[data.RetractedEventID]; ok {
+		return nil
+	}
+	return p.reprojectAggregate(ctx, q, ev.AggregateID)
+}
+
+// reprojectAggregate は1つのメディアのRead Modelを、撤回済みイベントを除いたイベント列から作り直す。
+func (p *Projector) reprojectAggregate(ctx context.Context, q *mediadb.Queries, aggregateID string) error {
+	// Event Storeは既定で撤回済みイベントとEventRetractedイベントを除外して返す
+	events, err := p.fetchAllEvents(ctx, "/api/v1/events/aggregate/"+url.PathEscape(aggregateID))
+	if err != nil {
+		return fmt.Errorf("Aggregate %s のイベント取得に失敗: %w", aggregateID, err)
+	}
+
+	if err := q.DeleteMediaReadModel(ctx, aggregateID); err != nil {
+		return fmt.Errorf("Aggregate %s のRead Model削除に失敗: %w", aggregateID, err)
+	}
+	for _, ev := range events {
+		if err := p.processEvent(ctx, q, ev); err != nil {
+			return fmt.Errorf("Aggregate %s の再投影に失敗 (id=%s, type=%s): %w", aggregateID, ev.ID, ev.EventType, err)
+		}
+	}
+	log.Printf("Projector: 撤回を反映するためRead Modelを再投影しました (aggregate_id=%s, %d件)", aggregateID, len(events))
 	return nil
 }
 
-// processEvent は1つのイベントをRead Modelに反映する。
+// processEvent は1つのイベントをqを通じてRead Modelに反映する。
 // イベントタイプに応じて適切なRead Model更新処理を呼び出す。
-func (p *Projector) processEvent(ctx context.Context, ev eventStoreResponse) error {
+func (p *Projector) processEvent(ctx context.Context, q *mediadb.Queries, ev eventStoreResponse) error {
 	// メディア関連のイベントのみ処理する
 	if ev.AggregateType != string(event.AggregateTypeMedia) {
 		return nil
@@ -168,23 +381,32 @@ func (p *Projector) processEvent(ctx context.Context, ev eventStoreResponse) err
 
 	switch event.Type(ev.EventType) {
 	case event.TypeMediaUploaded:
-		return p.handleMediaUploaded(ctx, ev)
+		return p.handleMediaUploaded(ctx, q, ev)
 	case event.TypeMediaProcessed:
-		return p.handleMediaProcessed(ctx, ev)
+		return p.handleMediaProcessed(ctx, q, ev)
 	case event.TypeMediaProcessingFailed:
-		return p.handleMediaProcessingFailed(ctx, ev)
+		return p.handleMediaProcessingFailed(ctx, q, ev)
 	case event.TypeMediaDeleted:
-		return p.handleMediaDeleted(ctx, ev)
+		return p.handleMediaDeleted(ctx, q, ev)
 	case event.TypeMediaUploadCompensated:
-		return p.handleMediaUploadCompensated(ctx, ev)
+		return p.handleMediaUploadCompensated(ctx, q, ev)
+	case event.TypeMediaFlagged:
+		return p.handleMediaFlagged(ctx, q, ev)
+	case event.TypeMediaMarkedFailed:
+		return p.handleMediaMarkedFailed(ctx, q, ev)
+	case event.TypeMediaRestored:
+		return p.handleMediaRestored(ctx, q, ev)
 	default:
+		if !event.IsValidType(event.Type(ev.EventType)) {
+			log.Printf("Projector: 警告: 未登録のイベントタイプを無視します (id=%s, type=%s)", ev.ID, ev.EventType)
+		}
 		return nil
 	}
 }
 
 // handleMediaUploaded はMediaUploadedイベントをRead Modelに反映する。
 // 新しいメディアレコードをstatus=uploadedで挿入する。
-func (p *Projector) handleMediaUploaded(ctx context.Context, ev eventStoreResponse) error {
+func (p *Projector) handleMediaUploaded(ctx context.Context, q *mediadb.Queries, ev eventStoreResponse) error {
 	var data event.MediaUploadedData
 	if err := json.Unmarshal([]byte(ev.Data), &data); err != nil {
 		return fmt.Errorf("MediaUploadedDataのデシリアライズに失敗: %w", err)
@@ -195,28 +417,29 @@ func (p *Projector) handleMediaUploaded(ctx context.Context, ev eventStoreRespon
 		createdAt = time.Now().UTC()
 	}
 
-	return p.queries.UpsertMediaReadModel(ctx, mediadb.UpsertMediaReadModelParams{
-		ID:               ev.AggregateID,
-		UserID:           data.UserID,
-		Filename:         data.Filename,
-		ContentType:      data.ContentType,
-		Size:             data.Size,
-		StoragePath:      data.StoragePath,
-		Status:           "uploaded",
-		LastEventVersion: ev.Version,
-		UploadedAt:       createdAt,
+	return q.UpsertMediaReadModel(ctx, mediadb.UpsertMediaReadModelParams{
+		ID:                 ev.AggregateID,
+		UserID:             data.UserID,
+		Filename:           data.Filename,
+		FilenameNormalized: normalizeForSearch(data.Filename),
+		ContentType:        data.ContentType,
+		Size:               data.Size,
+		StoragePath:        data.StoragePath,
+		Status:             "uploaded",
+		LastEventVersion:   ev.Version,
+		UploadedAt:         createdAt,
 	})
 }
 
 // handleMediaProcessed はMediaProcessedイベントをRead Modelに反映する。
 // サムネイルパス、幅、高さを更新し、status=processedに変更する。
-func (p *Projector) handleMediaProcessed(ctx context.Context, ev eventStoreResponse) error {
+func (p *Projector) handleMediaProcessed(ctx context.Context, q *mediadb.Queries, ev eventStoreResponse) error {
 	var data event.MediaProcessedData
 	if err := json.Unmarshal([]byte(ev.Data), &data); err != nil {
 		return fmt.Errorf("MediaProcessedDataのデシリアライズに失敗: %w", err)
 	}
 
-	return p.queries.UpdateMediaProcessed(ctx, mediadb.UpdateMediaProcessedParams{
+	return q.UpdateMediaProcessed(ctx, mediadb.UpdateMediaProcessedParams{
 		ThumbnailPath: sql.NullString{
 			String: data.ThumbnailPath,
 			Valid:  data.ThumbnailPath != "",
@@ -240,8 +463,8 @@ func (p *Projector) handleMediaProcessed(ctx context.Context, ev eventStoreRespo
 
 // handleMediaProcessingFailed はMediaProcessingFailedイベントをRead Modelに反映する。
 // status=failedに変更する。
-func (p *Projector) handleMediaProcessingFailed(ctx context.Context, ev eventStoreResponse) error {
-	return p.queries.UpdateMediaStatus(ctx, mediadb.UpdateMediaStatusParams{
+func (p *Projector) handleMediaProcessingFailed(ctx context.Context, q *mediadb.Queries, ev eventStoreResponse) error {
+	return q.UpdateMediaStatus(ctx, mediadb.UpdateMediaStatusParams{
 		Status:           "failed",
 		LastEventVersion: ev.Version,
 		ID:               ev.AggregateID,
@@ -250,8 +473,8 @@ func (p *Projector) handleMediaProcessingFailed(ctx context.Context, ev eventSto
 
 // handleMediaDeleted はMediaDeletedイベントをRead Modelに反映する。
 // status=deletedに変更する。
-func (p *Projector) handleMediaDeleted(ctx context.Context, ev eventStoreResponse) error {
-	return p.queries.UpdateMediaStatus(ctx, mediadb.UpdateMediaStatusParams{
+func (p *Projector) handleMediaDeleted(ctx context.Context, q *mediadb.Queries, ev eventStoreResponse) error {
+	return q.UpdateMediaStatus(ctx, mediadb.UpdateMediaStatusParams{
 		Status:           "deleted",
 		LastEventVersion: ev.Version,
 		ID:               ev.AggregateID,
@@ -260,14 +483,59 @@ func (p *Projector) handleMediaDeleted(ctx context.Context, ev eventStoreRespons
 
 // handleMediaUploadCompensated はMediaUploadCompensatedイベントをRead Modelに反映する。
 // 補償アクションとしてstatus=deletedに変更する。
-func (p *Projector) handleMediaUploadCompensated(ctx context.Context, ev eventStoreResponse) error {
-	return p.queries.UpdateMediaStatus(ctx, mediadb.UpdateMediaStatusParams{
+func (p *Projector) handleMediaUploadCompensated(ctx context.Context, q *mediadb.Queries, ev eventStoreResponse) error {
+	return q.UpdateMediaStatus(ctx, mediadb.UpdateMediaStatusParams{
 		Status:           "deleted",
 		LastEventVersion: ev.Version,
 		ID:               ev.AggregateID,
 	})
 }
 
+// handleMediaFlagged はMediaFlaggedイベントをRead Modelに反映する。
+// status=flaggedに変更し、確認が終わるまで一覧・検索から除外する。
+func (p *Projector) handleMediaFlagged(ctx context.Context, q *mediadb.Queries, ev eventStoreResponse) error {
+	return q.UpdateMediaStatus(ctx, mediadb.UpdateMediaStatusParams{
+		Status:           "flagged",
+		LastEventVersion: ev.Version,
+		ID:               ev.AggregateID,
+	})
+}
+
+// handleMediaMarkedFailed はMediaMarkedFailedイベントをRead Modelに反映する。
+// 管理者による変更としてstatus=failedに変更する。
+func (p *Projector) handleMediaMarkedFailed(ctx context.Context, q *mediadb.Queries, ev eventStoreResponse) error {
+	return q.UpdateMediaStatus(ctx, mediadb.UpdateMediaStatusParams{
+		Status:           "failed",
+		LastEventVersion: ev.Version,
+		ID:               ev.AggregateID,
+	})
+}
+
+// handleMediaRestored はMediaRestoredイベントをRead Modelに反映する。
+// 管理者による変更としてstatus=uploadedに戻す。
+func (p *Projector) handleMediaRestored(ctx context.Context, q *mediadb.Queries, ev eventStoreResponse) error {
+	return q.UpdateMediaStatus(ctx, mediadb.UpdateMediaStatusParams{
+		Status:           "uploaded",
+		LastEventVersion: ev.Version,
+		ID:               ev.AggregateID,
+	})
+}
+
+// fetchAllEvents はEvent Storeのページングされた一覧取得API（limit/offset）から全ページのイベントを取得する。
+func (p *Projector) fetchAllEvents(ctx context.Context, path string) ([]eventStoreResponse, error) {
+	var events []eventStoreResponse
+	for offset := 0; ; offset += eventStorePageLimit {
+		var page []eventStoreResponse
+		if err := p.client.GetJSON(ctx, fmt.Sprintf("%s?limit=%d&offset=%d", path, eventStorePageLimit, offset), &page); err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+		if len(page) < eventStorePageLimit {
+			return events, nil
+		}
+	}
+}
+
 // RebuildFromEventStore はRead Modelを全削除し、Event Storeの全イベントから再構築する。
 // Read Modelが破損した場合や整合性を回復する必要がある場合に使用する。
 func (p *Projector) RebuildFromEventStore(ctx context.Context) error {
@@ -279,20 +547,15 @@ func (p *Projector) RebuildFromEventStore(ctx context.Context) error {
 	}
 
 	// Event Storeから全イベントを取得
-	var events []eventStoreResponse
-	if err := p.client.GetJSON(ctx, "/api/v1/events", &events); err != nil {
+	events, err := p.fetchAllEvents(ctx, "/api/v1/events")
+	if err != nil {
 		return fmt.Errorf("Event Storeからの全イベント取得に失敗: %w", err)
 	}
 
-	// 全イベントを順次処理してRead Modelを再構築
-	var processedCount int
-	for _, ev := range events {
-		if err := p.processEvent(ctx, ev); err != nil {
-			log.Printf("Projector: 再構築中のイベント処理エラー (id=%s, type=%s): %v", ev.ID, ev.EventType, err)
-			continue
-		}
-		processedCount++
-	}
+	// 撤回されたイベントを除いた全イベントをバッチ単位で処理してRead Modelを再構築
+	processedCount := len(p.applyBatches(ctx, skipRetracted(events), func(q *mediadb.Queries, ev eventStoreResponse) error {
+		return p.processEvent(ctx, q, ev)
+	}))
 
 	// lastTimestampをリセットして最新のイベント以降からポーリングを再開する
 	if len(events) > 0 {
@@ -4,12 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	_ "modernc.org/sqlite"
 	mediadb "github.com/nao1215/micro/internal/media/query/db"
 	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/httpclient"
 )
 
 // setupTestProjector はテスト用のProjectorとインメモリSQLiteを作成する。
@@ -26,8 +37,8 @@ func setupTestProjector(t *testing.T) (*Projector, *mediadb.Queries, *sql.DB) {
 		t.Fatalf("Read Modelスキーマの初期化に失敗: %v", err)
 	}
 
-	queries := mediadb.New(sqlDB)
-	projector := NewProjector(queries, "http://localhost:9999")
+	projector := NewProjector(sqlDB, "http://localhost:9999")
+	queries := projector.queries
 
 	t.Cleanup(func() {
 		sqlDB.Close()
@@ -49,6 +60,44 @@ func makeEventJSON(t *testing.T, data any) string {
 func TestProcessEvent_MediaUploaded(t *testing.T) {
 	t.Parallel()
 
+	t.Run("正常系_日本語のファイル名は正規化して検索用カラムに保存される", func(t *testing.T) {
+		t.Parallel()
+
+		p, queries, _ := setupTestProjector(t)
+		ctx := context.Background()
+
+		ev := eventStoreResponse{
+			ID:            "event-ja",
+			AggregateID:   "media-upload-ja",
+			AggregateType: string(event.AggregateTypeMedia),
+			EventType:     string(event.TypeMediaUploaded),
+			Data: makeEventJSON(t, event.MediaUploadedData{
+				UserID:      "user-123",
+				Filename:    "ｻﾝｾｯﾄ.JPG",
+				ContentType: "image/jpeg",
+				Size:        1024,
+				StoragePath: "/data/media/media-upload-ja/sunset.jpg",
+			}),
+			Version:   1,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		}
+
+		if err := p.processEvent(ctx, p.queries, ev); err != nil {
+			t.Fatalf("processEventが失敗: %v", err)
+		}
+
+		model, err := queries.GetMediaByID(ctx, "media-upload-ja")
+		if err != nil {
+			t.Fatalf("GetMediaByIDが失敗: %v", err)
+		}
+		if model.Filename != "ｻﾝｾｯﾄ.JPG" {
+			t.Errorf("元のファイル名は変更しない: got %q", model.Filename)
+		}
+		if model.FilenameNormalized != "サンセット.jpg" {
+			t.Errorf("期待するFilenameNormalized %q, 実際のFilenameNormalized %q", "サンセット.jpg", model.FilenameNormalized)
+		}
+	})
+
 	t.Run("正常系_MediaUploadedイベントでRead Modelにレコードが挿入される", func(t *testing.T) {
 		t.Parallel()
 
@@ -73,7 +122,7 @@ func TestProcessEvent_MediaUploaded(t *testing.T) {
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
 
-		if err := p.processEvent(ctx, ev); err != nil {
+		if err := p.processEvent(ctx, p.queries, ev); err != nil {
 			t.Fatalf("processEventが失敗: %v", err)
 		}
 
@@ -95,6 +144,9 @@ func TestProcessEvent_MediaUploaded(t *testing.T) {
 		if model.ContentType != "image/jpeg" {
 			t.Errorf("期待するContentType %q, 実際のContentType %q", "image/jpeg", model.ContentType)
 		}
+		if model.FilenameNormalized != "test_photo.jpg" {
+			t.Errorf("期待するFilenameNormalized %q, 実際のFilenameNormalized %q", "test_photo.jpg", model.FilenameNormalized)
+		}
 		if model.Size != 4096 {
 			t.Errorf("期待するSize %d, 実際のSize %d", 4096, model.Size)
 		}
@@ -136,7 +188,7 @@ func TestProcessEvent_MediaProcessed(t *testing.T) {
 			Version:       1,
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, uploadEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, uploadEv); err != nil {
 			t.Fatalf("MediaUploadedの処理に失敗: %v", err)
 		}
 
@@ -155,7 +207,7 @@ func TestProcessEvent_MediaProcessed(t *testing.T) {
 			Version:       2,
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, processEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, processEv); err != nil {
 			t.Fatalf("MediaProcessedの処理に失敗: %v", err)
 		}
 
@@ -209,7 +261,7 @@ func TestProcessEvent_MediaProcessingFailed(t *testing.T) {
 			Version:       1,
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, uploadEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, uploadEv); err != nil {
 			t.Fatalf("MediaUploadedの処理に失敗: %v", err)
 		}
 
@@ -226,7 +278,7 @@ func TestProcessEvent_MediaProcessingFailed(t *testing.T) {
 			Version:       2,
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, failEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, failEv); err != nil {
 			t.Fatalf("MediaProcessingFailedの処理に失敗: %v", err)
 		}
 
@@ -271,7 +323,7 @@ func TestProcessEvent_MediaDeleted(t *testing.T) {
 			Version:       1,
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, uploadEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, uploadEv); err != nil {
 			t.Fatalf("MediaUploadedの処理に失敗: %v", err)
 		}
 
@@ -288,7 +340,7 @@ func TestProcessEvent_MediaDeleted(t *testing.T) {
 			Version:       2,
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, deleteEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, deleteEv); err != nil {
 			t.Fatalf("MediaDeletedの処理に失敗: %v", err)
 		}
 
@@ -333,7 +385,7 @@ func TestProcessEvent_MediaUploadCompensated(t *testing.T) {
 			Version:       1,
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, uploadEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, uploadEv); err != nil {
 			t.Fatalf("MediaUploadedの処理に失敗: %v", err)
 		}
 
@@ -351,7 +403,7 @@ func TestProcessEvent_MediaUploadCompensated(t *testing.T) {
 			Version:       2,
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, compensateEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, compensateEv); err != nil {
 			t.Fatalf("MediaUploadCompensatedの処理に失敗: %v", err)
 		}
 
@@ -370,6 +422,92 @@ func TestProcessEvent_MediaUploadCompensated(t *testing.T) {
 	})
 }
 
+func TestProcessEvent_MediaFlagged(t *testing.T) {
+	t.Parallel()
+
+	s, _ := setupTestQueryServer(t)
+	p := NewProjector(s.db, "http://localhost:9999")
+	ctx := context.Background()
+
+	// 2件のメディアをアップロード・処理し、片方だけ要確認と判定されたとする
+	for _, id := range []string{"media-flagged-1", "media-clean-1"} {
+		events := []eventStoreResponse{
+			{
+				ID:            id + "-event-1",
+				AggregateID:   id,
+				AggregateType: string(event.AggregateTypeMedia),
+				EventType:     string(event.TypeMediaUploaded),
+				Data: makeEventJSON(t, event.MediaUploadedData{
+					UserID:      "user-123",
+					Filename:    id + ".jpg",
+					ContentType: "image/jpeg",
+					Size:        1024,
+					StoragePath: "/data/media/" + id + "/" + id + ".jpg",
+				}),
+				Version:   1,
+				CreatedAt: time.Now().UTC().Format(time.RFC3339),
+			},
+			{
+				ID:            id + "-event-2",
+				AggregateID:   id,
+				AggregateType: string(event.AggregateTypeMedia),
+				EventType:     string(event.TypeMediaProcessed),
+				Data:          makeEventJSON(t, event.MediaProcessedData{ThumbnailPath: "/data/media/" + id + "/thumbnail.jpg", Width: 10, Height: 10}),
+				Version:       2,
+				CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+			},
+		}
+		if id == "media-flagged-1" {
+			events = append(events, eventStoreResponse{
+				ID:            id + "-event-3",
+				AggregateID:   id,
+				AggregateType: string(event.AggregateTypeMedia),
+				EventType:     string(event.TypeMediaFlagged),
+				Data:          makeEventJSON(t, event.MediaFlaggedData{Reason: "不適切なコンテンツの可能性"}),
+				Version:       3,
+				CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+			})
+		}
+		for _, ev := range events {
+			if err := p.processEvent(ctx, p.queries, ev); err != nil {
+				t.Fatalf("%sの処理に失敗: %v", ev.EventType, err)
+			}
+		}
+	}
+
+	model, err := s.queries.GetMediaByID(ctx, "media-flagged-1")
+	if err != nil {
+		t.Fatalf("GetMediaByIDが失敗: %v", err)
+	}
+	if model.Status != "flagged" {
+		t.Errorf("期待するStatus %q, 実際のStatus %q", "flagged", model.Status)
+	}
+	if model.LastEventVersion != 3 {
+		t.Errorf("期待するLastEventVersion 3, 実際のLastEventVersion %d", model.LastEventVersion)
+	}
+
+	// 通常の一覧・検索からは要確認のメディアが除外される
+	for _, target := range []string{"/api/v1/media", "/api/v1/media/search?q=media"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: 期待するステータスコード %d, 実際のステータスコード %d", target, http.StatusOK, w.Code)
+		}
+		var resp struct {
+			Media []mediaResponse `json:"media"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		if len(resp.Media) != 1 || resp.Media[0].ID != "media-clean-1" {
+			t.Errorf("%s: 期待する一覧 [media-clean-1], 実際 %+v", target, resp.Media)
+		}
+	}
+}
+
 func TestProcessEvent_UnknownEventType(t *testing.T) {
 	t.Parallel()
 
@@ -390,7 +528,7 @@ func TestProcessEvent_UnknownEventType(t *testing.T) {
 		}
 
 		// エラーなく処理されることを確認する
-		if err := p.processEvent(ctx, ev); err != nil {
+		if err := p.processEvent(ctx, p.queries, ev); err != nil {
 			t.Errorf("未知のイベントタイプでエラーが発生: %v", err)
 		}
 	})
@@ -415,7 +553,7 @@ func TestProcessEvent_NonMediaAggregate(t *testing.T) {
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
 
-		if err := p.processEvent(ctx, ev); err != nil {
+		if err := p.processEvent(ctx, p.queries, ev); err != nil {
 			t.Errorf("メディア以外のAggregateTypeでエラーが発生: %v", err)
 		}
 	})
@@ -440,7 +578,7 @@ func TestProcessEvent_InvalidJSON(t *testing.T) {
 			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
 		}
 
-		err := p.processEvent(ctx, ev)
+		err := p.processEvent(ctx, p.queries, ev)
 		if err == nil {
 			t.Error("不正なJSONデータでエラーが返されるべきです")
 		}
@@ -475,7 +613,7 @@ func TestProcessEvent_FullLifecycle(t *testing.T) {
 			Version:       1,
 			CreatedAt:     baseTime.Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, uploadEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, uploadEv); err != nil {
 			t.Fatalf("ステップ1(Upload)で失敗: %v", err)
 		}
 
@@ -499,7 +637,7 @@ func TestProcessEvent_FullLifecycle(t *testing.T) {
 			Version:       2,
 			CreatedAt:     baseTime.Add(1 * time.Second).Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, processEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, processEv); err != nil {
 			t.Fatalf("ステップ2(Process)で失敗: %v", err)
 		}
 
@@ -524,7 +662,7 @@ func TestProcessEvent_FullLifecycle(t *testing.T) {
 			Version:       3,
 			CreatedAt:     baseTime.Add(2 * time.Second).Format(time.RFC3339),
 		}
-		if err := p.processEvent(ctx, deleteEv); err != nil {
+		if err := p.processEvent(ctx, p.queries, deleteEv); err != nil {
 			t.Fatalf("ステップ3(Delete)で失敗: %v", err)
 		}
 
@@ -550,11 +688,13 @@ func TestNewProjector(t *testing.T) {
 		}
 		defer sqlDB.Close()
 
-		queries := mediadb.New(sqlDB)
-		p := NewProjector(queries, "http://localhost:8084")
+		p := NewProjector(sqlDB, "http://localhost:8084")
 
-		if p.queries != queries {
-			t.Error("queriesが正しく設定されていません")
+		if p.db != sqlDB {
+			t.Error("dbが正しく設定されていません")
+		}
+		if p.queries == nil {
+			t.Error("queriesがnilです")
 		}
 		if p.client == nil {
 			t.Error("clientがnilです")
@@ -562,12 +702,289 @@ func TestNewProjector(t *testing.T) {
 		if p.interval != 2*time.Second {
 			t.Errorf("期待するinterval %v, 実際のinterval %v", 2*time.Second, p.interval)
 		}
+		if p.maxInterval != defaultProjectorMaxInterval {
+			t.Errorf("期待するmaxInterval %v, 実際のmaxInterval %v", defaultProjectorMaxInterval, p.maxInterval)
+		}
 		if !p.lastTimestamp.IsZero() {
 			t.Error("lastTimestampはゼロ値であるべきです")
 		}
 	})
 }
 
+func TestProjectorPollBackoff(t *testing.T) {
+	t.Parallel()
+
+	// Event Storeの停止を模擬する。downがtrueの間は503を返す
+	var down atomic.Bool
+	down.Store(true)
+	eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	t.Cleanup(eventStore.Close)
+
+	p, _, _ := setupTestProjector(t)
+	p.client = httpclient.New(eventStore.URL)
+	ctx := context.Background()
+
+	// 連続失敗のたびに間隔が2倍になり、上限で頭打ちになる
+	wantIntervals := []time.Duration{4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i, want := range wantIntervals {
+		err := p.poll(ctx)
+		if err == nil {
+			t.Fatalf("失敗%d回目: Event Store停止中にエラーが返りません", i+1)
+		}
+		if got := p.nextInterval(err); got != want {
+			t.Errorf("失敗%d回目: 期待する間隔 %v, 実際の間隔 %v", i+1, want, got)
+		}
+	}
+	if p.consecutiveFailures != len(wantIntervals) {
+		t.Errorf("期待する連続失敗回数 %d, 実際の連続失敗回数 %d", len(wantIntervals), p.consecutiveFailures)
+	}
+
+	// 回復後は通常の間隔に戻る
+	down.Store(false)
+	err := p.poll(ctx)
+	if err != nil {
+		t.Fatalf("回復後のポーリングに失敗: %v", err)
+	}
+	if got := p.nextInterval(err); got != p.interval {
+		t.Errorf("回復後: 期待する間隔 %v, 実際の間隔 %v", p.interval, got)
+	}
+	if p.consecutiveFailures != 0 {
+		t.Errorf("回復後: 連続失敗回数がリセットされていません: %d", p.consecutiveFailures)
+	}
+
+	// 再び失敗した場合は最初の段階から広げ直す
+	down.Store(true)
+	if got := p.nextInterval(p.poll(ctx)); got != 4*time.Second {
+		t.Errorf("再失敗時: 期待する間隔 %v, 実際の間隔 %v", 4*time.Second, got)
+	}
+}
+
+func TestProjectorRetraction(t *testing.T) {
+	t.Parallel()
+
+	newMediaEvent := func(t *testing.T, id string, eventType event.Type, version int64, data any) eventStoreResponse {
+		t.Helper()
+		return eventStoreResponse{
+			ID:            id,
+			AggregateID:   "media-retract-1",
+			AggregateType: string(event.AggregateTypeMedia),
+			EventType:     string(eventType),
+			Data:          makeEventJSON(t, data),
+			Version:       version,
+			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+		}
+	}
+	uploaded := newMediaEvent(t, "ev-uploaded", event.TypeMediaUploaded, 1, event.MediaUploadedData{
+		UserID: "user-123", Filename: "a.jpg", ContentType: "image/jpeg", Size: 1024, StoragePath: "/data/media/a.jpg",
+	})
+	wrong := newMediaEvent(t, "ev-wrong", event.TypeMediaProcessingFailed, 2, event.MediaProcessingFailedData{Reason: "誤記録"})
+	retraction := newMediaEvent(t, "ev-retraction", event.TypeEventRetracted, 3, event.EventRetractedData{RetractedEventID: "ev-wrong", Reason: "誤って追記"})
+
+	// newEventStore は/sinceで指定のバッチを順に返し、/aggregateで撤回済みを除いたイベント列を返すEvent Storeを起動する
+	newEventStore := func(t *testing.T, batches ...[]eventStoreResponse) *httptest.Server {
+		t.Helper()
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/api/v1/events/since":
+				i := int(calls.Add(1)) - 1
+				if i >= len(batches) {
+					json.NewEncoder(w).Encode([]eventStoreResponse{})
+					return
+				}
+				json.NewEncoder(w).Encode(batches[i])
+			case "/api/v1/events/aggregate/media-retract-1":
+				json.NewEncoder(w).Encode([]eventStoreResponse{uploaded})
+			case "/api/v1/events":
+				json.NewEncoder(w).Encode([]eventStoreResponse{uploaded, wrong, retraction})
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	assertStatus := func(t *testing.T, queries *mediadb.Queries, want string) {
+		t.Helper()
+		model, err := queries.GetMediaByID(context.Background(), "media-retract-1")
+		if err != nil {
+			t.Fatalf("GetMediaByIDが失敗: %v", err)
+		}
+		if model.Status != want {
+			t.Errorf("期待するStatus %q, 実際のStatus %q", want, model.Status)
+		}
+		if model.LastEventVersion != 1 {
+			t.Errorf("期待するLastEventVersion 1, 実際のLastEventVersion %d", model.LastEventVersion)
+		}
+	}
+
+	t.Run("正常系_同じバッチ内で撤回されたイベントは反映しない", func(t *testing.T) {
+		t.Parallel()
+
+		p, queries, _ := setupTestProjector(t)
+		p.client = httpclient.New(newEventStore(t, []eventStoreResponse{uploaded, wrong, retraction}).URL)
+
+		if err := p.poll(context.Background()); err != nil {
+			t.Fatalf("pollが失敗: %v", err)
+		}
+		assertStatus(t, queries, "uploaded")
+	})
+
+	t.Run("正常系_反映済みのイベントが撤回された場合は再投影する", func(t *testing.T) {
+		t.Parallel()
+
+		p, queries, _ := setupTestProjector(t)
+		p.client = httpclient.New(newEventStore(t, []eventStoreResponse{uploaded, wrong}, []eventStoreResponse{retraction}).URL)
+		ctx := context.Background()
+
+		if err := p.poll(ctx); err != nil {
+			t.Fatalf("1回目のpollが失敗: %v", err)
+		}
+		model, err := queries.GetMediaByID(ctx, "media-retract-1")
+		if err != nil {
+			t.Fatalf("GetMediaByIDが失敗: %v", err)
+		}
+		if model.Status != "failed" {
+			t.Fatalf("撤回前: 期待するStatus %q, 実際のStatus %q", "failed", model.Status)
+		}
+
+		if err := p.poll(ctx); err != nil {
+			t.Fatalf("2回目のpollが失敗: %v", err)
+		}
+		assertStatus(t, queries, "uploaded")
+	})
+
+	t.Run("正常系_再構築時は撤回されたイベントをスキップする", func(t *testing.T) {
+		t.Parallel()
+
+		p, queries, _ := setupTestProjector(t)
+		p.client = httpclient.New(newEventStore(t).URL)
+
+		if err := p.RebuildFromEventStore(context.Background()); err != nil {
+			t.Fatalf("RebuildFromEventStoreが失敗: %v", err)
+		}
+		assertStatus(t, queries, "uploaded")
+	})
+}
+
+func TestProjectorFetchAllEvents(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_Event Storeのページを最後まで順に取得する", func(t *testing.T) {
+		t.Parallel()
+
+		const total = eventStorePageLimit + 5
+		var requests atomic.Int32
+		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			page := []eventStoreResponse{}
+			for i := offset; i < min(offset+limit, total); i++ {
+				page = append(page, eventStoreResponse{ID: fmt.Sprintf("ev-%04d", i)})
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(page)
+		}))
+		t.Cleanup(eventStore.Close)
+
+		p, _, _ := setupTestProjector(t)
+		p.client = httpclient.New(eventStore.URL)
+
+		events, err := p.fetchAllEvents(context.Background(), "/api/v1/events")
+		if err != nil {
+			t.Fatalf("fetchAllEventsが失敗: %v", err)
+		}
+		if len(events) != total {
+			t.Fatalf("期待するイベント数 %d, 実際のイベント数 %d", total, len(events))
+		}
+		if events[0].ID != "ev-0000" || events[total-1].ID != fmt.Sprintf("ev-%04d", total-1) {
+			t.Errorf("イベントの順序が不正: 先頭 %s, 末尾 %s", events[0].ID, events[total-1].ID)
+		}
+		if got := requests.Load(); got != 2 {
+			t.Errorf("期待するリクエスト数 2, 実際のリクエスト数 %d", got)
+		}
+	})
+}
+
+func TestProjectorPollPaging(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_同じsinceのままoffsetを進めて全ページを反映する", func(t *testing.T) {
+		t.Parallel()
+
+		// 全イベントを同じ作成日時にし、ページの境界で取りこぼさないことを確認する
+		const total = eventStorePageLimit + 5
+		events := make([]eventStoreResponse, 0, total)
+		for i := range total {
+			events = append(events, eventStoreResponse{
+				ID:            fmt.Sprintf("ev-%04d", i),
+				AggregateID:   fmt.Sprintf("media-page-%04d", i),
+				AggregateType: string(event.AggregateTypeMedia),
+				EventType:     string(event.TypeMediaUploaded),
+				Data: makeEventJSON(t, event.MediaUploadedData{
+					UserID: "user-123", Filename: "a.jpg", ContentType: "image/jpeg", Size: 1024, StoragePath: "/data/media/a.jpg",
+				}),
+				Version:   1,
+				CreatedAt: "2026-01-01T00:00:00Z",
+			})
+		}
+
+		var (
+			mu      sync.Mutex
+			queries []url.Values
+		)
+		eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			mu.Lock()
+			queries = append(queries, q)
+			mu.Unlock()
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			offset, _ := strconv.Atoi(q.Get("offset"))
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(events[min(offset, total):min(offset+limit, total)])
+		}))
+		t.Cleanup(eventStore.Close)
+
+		p, mediaQueries, _ := setupTestProjector(t)
+		p.client = httpclient.New(eventStore.URL)
+
+		if err := p.poll(context.Background()); err != nil {
+			t.Fatalf("pollが失敗: %v", err)
+		}
+
+		models, err := mediaQueries.ListAllMedia(context.Background())
+		if err != nil {
+			t.Fatalf("ListAllMediaが失敗: %v", err)
+		}
+		if len(models) != total {
+			t.Errorf("期待するRead Modelの件数 %d, 実際の件数 %d", total, len(models))
+		}
+		if len(queries) != 2 {
+			t.Fatalf("期待するリクエスト数 2, 実際のリクエスト数 %d", len(queries))
+		}
+		if queries[0].Get("since") != queries[1].Get("since") {
+			t.Errorf("ページごとにsinceが変わっています: %q, %q", queries[0].Get("since"), queries[1].Get("since"))
+		}
+		if got := []string{queries[0].Get("offset"), queries[1].Get("offset")}; !slices.Equal(got, []string{"0", strconv.Itoa(eventStorePageLimit)}) {
+			t.Errorf("期待するoffset [0 %d], 実際のoffset %v", eventStorePageLimit, got)
+		}
+		want := time.Date(2026, 1, 1, 0, 0, 0, 1, time.UTC)
+		if !p.lastTimestamp.Equal(want) {
+			t.Errorf("期待するlastTimestamp %v, 実際のlastTimestamp %v", want, p.lastTimestamp)
+		}
+	})
+}
+
 func TestProjectorStartStop(t *testing.T) {
 	t.Parallel()
 
@@ -580,8 +997,7 @@ func TestProjectorStartStop(t *testing.T) {
 		}
 		defer sqlDB.Close()
 
-		queries := mediadb.New(sqlDB)
-		p := NewProjector(queries, "http://localhost:9999")
+		p := NewProjector(sqlDB, "http://localhost:9999")
 
 		ctx := context.Background()
 		p.Start(ctx)
@@ -604,10 +1020,208 @@ func TestProjectorStartStop(t *testing.T) {
 		}
 		defer sqlDB.Close()
 
-		queries := mediadb.New(sqlDB)
-		p := NewProjector(queries, "http://localhost:9999")
+		p := NewProjector(sqlDB, "http://localhost:9999")
 
 		// Start前にStopを呼んでもパニックしないことを確認する
 		p.Stop()
 	})
 }
+
+func TestProjectorStartWaitsForEventStore(t *testing.T) {
+	t.Parallel()
+
+	// newEventStore は/healthが最初のunhealthy回だけ503を返すEvent Storeを模擬する。
+	// 戻り値のpollsは/healthの成功前と成功後に受けたポーリングの回数を返す。
+	newEventStore := func(t *testing.T, unhealthy int32) (healthCalls *atomic.Int32, pollsBeforeHealthy, pollsAfterHealthy *atomic.Int32) {
+		t.Helper()
+		healthCalls, pollsBeforeHealthy, pollsAfterHealthy = &atomic.Int32{}, &atomic.Int32{}, &atomic.Int32{}
+		var healthy atomic.Bool
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health" {
+				if healthCalls.Add(1) <= unhealthy {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				healthy.Store(true)
+				w.Write([]byte(`{"status":"ok"}`))
+				return
+			}
+			if healthy.Load() {
+				pollsAfterHealthy.Add(1)
+			} else {
+				pollsBeforeHealthy.Add(1)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("[]"))
+		}))
+		t.Cleanup(ts.Close)
+
+		p, _, _ := setupTestProjector(t)
+		p.client = httpclient.New(ts.URL)
+		p.interval = 10 * time.Millisecond
+		p.startupAttempts = 3
+		p.startupBackoff = time.Millisecond
+		p.Start(context.Background())
+		t.Cleanup(p.Stop)
+		return healthCalls, pollsBeforeHealthy, pollsAfterHealthy
+	}
+
+	waitPoll := func(t *testing.T, polls ...*atomic.Int32) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			for _, p := range polls {
+				if p.Load() > 0 {
+					return
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatal("ポーリングが開始されませんでした")
+	}
+
+	t.Run("正常系_Event Storeの起動を待ってからポーリングを開始する", func(t *testing.T) {
+		t.Parallel()
+
+		healthCalls, before, after := newEventStore(t, 2)
+		waitPoll(t, before, after)
+
+		if got := healthCalls.Load(); got != 3 {
+			t.Errorf("/healthの試行回数 = %d, want 3", got)
+		}
+		if got := before.Load(); got != 0 {
+			t.Errorf("Event Storeの起動前にポーリングしました: %d回", got)
+		}
+	})
+
+	t.Run("正常系_接続できないまま上限に達してもポーリングを継続する", func(t *testing.T) {
+		t.Parallel()
+
+		healthCalls, before, after := newEventStore(t, 100)
+		waitPoll(t, before, after)
+
+		if got := healthCalls.Load(); got != 3 {
+			t.Errorf("/healthの試行回数 = %d, want 3", got)
+		}
+	})
+}
+
+func TestProjectorApplyBatches(t *testing.T) {
+	t.Parallel()
+
+	// setupFileProjector はトランザクション外からの参照を検証するため、ファイルのSQLiteでProjectorを作成する
+	setupFileProjector := func(t *testing.T) *Projector {
+		t.Helper()
+		sqlDB, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "media-query.db"))
+		if err != nil {
+			t.Fatalf("SQLiteの接続に失敗: %v", err)
+		}
+		t.Cleanup(func() { sqlDB.Close() })
+		if err := initSchema(sqlDB); err != nil {
+			t.Fatalf("Read Modelスキーマの初期化に失敗: %v", err)
+		}
+		return NewProjector(sqlDB, "http://localhost:9999")
+	}
+	uploaded := func(t *testing.T, aggregateID string) eventStoreResponse {
+		t.Helper()
+		return eventStoreResponse{
+			ID:            aggregateID + "-uploaded",
+			AggregateID:   aggregateID,
+			AggregateType: string(event.AggregateTypeMedia),
+			EventType:     string(event.TypeMediaUploaded),
+			Data: makeEventJSON(t, event.MediaUploadedData{
+				UserID: "user-123", Filename: aggregateID + ".jpg", ContentType: "image/jpeg", Size: 1024, StoragePath: "/data/media/" + aggregateID + ".jpg",
+			}),
+			Version:   1,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		}
+	}
+	exists := func(t *testing.T, p *Projector, aggregateID string) bool {
+		t.Helper()
+		_, err := p.queries.GetMediaByID(context.Background(), aggregateID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("GetMediaByIDが失敗: %v", err)
+		}
+		return err == nil
+	}
+
+	t.Run("正常系_バッチは1つのトランザクションでまとめて反映される", func(t *testing.T) {
+		t.Parallel()
+
+		p := setupFileProjector(t)
+		events := []eventStoreResponse{uploaded(t, "media-batch-1"), uploaded(t, "media-batch-2")}
+
+		var visibleDuringBatch bool
+		applied := p.applyBatches(context.Background(), events, func(q *mediadb.Queries, ev eventStoreResponse) error {
+			if ev.AggregateID == "media-batch-2" {
+				// コミット前のため、トランザクション外からは先に反映したイベントが見えない
+				visibleDuringBatch = exists(t, p, "media-batch-1")
+			}
+			return p.processEvent(context.Background(), q, ev)
+		})
+
+		if visibleDuringBatch {
+			t.Error("バッチのコミット前に反映結果がトランザクション外から見えました")
+		}
+		if len(applied) != 2 {
+			t.Errorf("反映したイベント数 = %d, want 2", len(applied))
+		}
+		for _, id := range []string{"media-batch-1", "media-batch-2"} {
+			if !exists(t, p, id) {
+				t.Errorf("%s が反映されていません", id)
+			}
+		}
+	})
+
+	t.Run("正常系_反映できないイベントがあってもバッチ内の他のイベントは反映される", func(t *testing.T) {
+		t.Parallel()
+
+		p := setupFileProjector(t)
+		poison := uploaded(t, "media-poison")
+		poison.Data = "{invalid json"
+		events := []eventStoreResponse{uploaded(t, "media-good-1"), poison, uploaded(t, "media-good-2")}
+
+		var attempts int
+		applied := p.applyBatches(context.Background(), events, func(q *mediadb.Queries, ev eventStoreResponse) error {
+			attempts++
+			return p.processEvent(context.Background(), q, ev)
+		})
+
+		// バッチで2件（poisonで中断）、1件ずつの反映で3件を試行する
+		if attempts != 5 {
+			t.Errorf("反映の試行回数 = %d, want 5", attempts)
+		}
+		if len(applied) != 2 || applied[0].ID != "media-good-1-uploaded" || applied[1].ID != "media-good-2-uploaded" {
+			t.Errorf("反映したイベント = %+v, want media-good-1, media-good-2", applied)
+		}
+		for _, id := range []string{"media-good-1", "media-good-2"} {
+			if !exists(t, p, id) {
+				t.Errorf("%s が反映されていません", id)
+			}
+		}
+		if exists(t, p, "media-poison") {
+			t.Error("反映できないイベントが反映されています")
+		}
+	})
+
+	t.Run("正常系_バッチサイズを超えるイベントは複数のバッチに分けて反映される", func(t *testing.T) {
+		t.Parallel()
+
+		p := setupFileProjector(t)
+		events := make([]eventStoreResponse, 0, projectorBatchSize+1)
+		for i := range projectorBatchSize + 1 {
+			events = append(events, uploaded(t, fmt.Sprintf("media-many-%d", i)))
+		}
+
+		applied := p.applyBatches(context.Background(), events, func(q *mediadb.Queries, ev eventStoreResponse) error {
+			return p.processEvent(context.Background(), q, ev)
+		})
+
+		if len(applied) != len(events) {
+			t.Errorf("反映したイベント数 = %d, want %d", len(applied), len(events))
+		}
+		if !exists(t, p, fmt.Sprintf("media-many-%d", projectorBatchSize)) {
+			t.Error("最後のバッチのイベントが反映されていません")
+		}
+	})
+}
@@ -0,0 +1,288 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	mediadb "github.com/nao1215/micro/internal/media/query/db"
+	"github.com/nao1215/micro/pkg/httpclient"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+const (
+	// regenerateBatchSize はRead Modelから再生成の対象を1回に取得する件数。
+	regenerateBatchSize = 100
+	// defaultRegenerateInterval はmedia-commandへサムネイルの再生成を依頼する間隔の既定値。
+	// 再生成は画像のデコードを伴うため、media-commandに負荷が集中しないように1件ずつ間隔を空ける。
+	defaultRegenerateInterval = 500 * time.Millisecond
+	// maxRegenerateJobs はポーリング用に保持する再生成ジョブの最大件数。超えた場合は古い完了済みジョブから破棄する。
+	maxRegenerateJobs = 20
+	// maxRegenerateFailures は1つのジョブで記録する失敗の最大件数。超えた分は件数だけを数える。
+	maxRegenerateFailures = 100
+)
+
+// サムネイル再生成ジョブの状態。
+const (
+	regenerateStatusRunning   = "running"
+	regenerateStatusCompleted = "completed"
+	regenerateStatusFailed    = "failed"
+)
+
+// errRegenerateRunning は再生成ジョブの実行中に新しいジョブを開始しようとした場合のエラー。
+var errRegenerateRunning = errors.New("サムネイルの再生成ジョブは実行中です")
+
+// errRegenerateSkipped はmedia-commandがメディアの状態を理由に再生成を拒否したことを表す。
+var errRegenerateSkipped = errors.New("再生成の対象外です")
+
+// regenerateJob はサムネイル再生成ジョブの状態。ポーリング用のレスポンスとしてそのまま返す。
+type regenerateJob struct {
+	// ID はジョブの一意識別子。
+	ID string `json:"id"`
+	// Status はジョブの状態（running, completed, failed）。
+	Status string `json:"status"`
+	// Succeeded は再生成したメディアの件数。
+	Succeeded int `json:"succeeded"`
+	// Skipped は削除済み・元画像なしなどでmedia-commandが再生成を拒否（409）したメディアの件数。
+	Skipped int `json:"skipped"`
+	// Failed は再生成に失敗したメディアの件数。
+	Failed int `json:"failed"`
+	// Failures は再生成に失敗したメディア（最大maxRegenerateFailures件）。
+	Failures []reprocessFailure `json:"failures"`
+	// StartedAt はジョブの開始日時。
+	StartedAt time.Time `json:"started_at"`
+	// CompletedAt はジョブの終了日時。実行中はnull。
+	CompletedAt *time.Time `json:"completed_at"`
+	// Error はジョブ自体が失敗した理由。
+	Error string `json:"error,omitempty"`
+}
+
+// regenerator はサムネイル生成済みの全画像について、media-commandへサムネイルの再生成を依頼するジョブを管理する。
+// サムネイルの形式やサイズの既定値を変更した後に、既存のサムネイルを作り直すために管理者が実行する。
+type regenerator struct {
+	// queries は再生成の対象を取得するRead Modelのクエリ。
+	queries *mediadb.Queries
+	// client はmedia-commandとの通信用HTTPクライアント。
+	client *httpclient.Client
+	// interval は再生成を依頼する間隔。ゼロの場合は間隔を空けない。
+	interval time.Duration
+	// running はジョブの実行中にtrueとなる。同時に1つのジョブだけを実行するために使用する。
+	running atomic.Bool
+	// mu はjobsへの並行アクセスを保護するミューテックス。
+	mu sync.Mutex
+	// jobs はジョブIDごとのジョブの状態。
+	jobs map[string]*regenerateJob
+}
+
+// newRegenerator は新しいregeneratorを生成する。
+// mediaCommandURL はmedia-commandのベースURL（例: "http://localhost:8081"）。
+// optsはmedia-commandとの通信用HTTPクライアントに渡す（リクエストの署名など）。
+func newRegenerator(queries *mediadb.Queries, mediaCommandURL string, opts ...httpclient.Option) *regenerator {
+	return &regenerator{
+		queries:  queries,
+		client:   httpclient.New(mediaCommandURL, opts...),
+		interval: defaultRegenerateInterval,
+		jobs:     make(map[string]*regenerateJob),
+	}
+}
+
+// start は再生成ジョブをバックグラウンドで開始し、開始時点のジョブの状態を返す。
+// 実行中のジョブがある場合はerrRegenerateRunningを返す。
+func (r *regenerator) start() (regenerateJob, error) {
+	if !r.running.CompareAndSwap(false, true) {
+		return regenerateJob{}, errRegenerateRunning
+	}
+
+	job := &regenerateJob{
+		ID:        uuid.New().String(),
+		Status:    regenerateStatusRunning,
+		Failures:  []reprocessFailure{},
+		StartedAt: time.Now().UTC(),
+	}
+	r.mu.Lock()
+	r.pruneLocked()
+	r.jobs[job.ID] = job
+	r.mu.Unlock()
+
+	go func() {
+		defer r.running.Store(false)
+		r.run(job)
+	}()
+	return r.snapshot(job), nil
+}
+
+// run はRead Modelのprocessedの画像をID順に取得し、1件ずつmedia-commandへ再生成を依頼してジョブの状態を更新する。
+// ジョブの実行中に投影されたメディアも、IDが未処理の範囲にあれば対象に含む。
+// リクエストの終了後も継続するため、リクエストのコンテキストは使用しない。
+func (r *regenerator) run(job *regenerateJob) {
+	ctx := context.Background()
+	err := func() error {
+		lastID := ""
+		requested := false
+		for {
+			ids, err := r.queries.ListProcessedImageIDs(ctx, mediadb.ListProcessedImageIDsParams{
+				ID:    lastID,
+				Limit: regenerateBatchSize,
+			})
+			if err != nil {
+				return fmt.Errorf("再生成の対象の取得に失敗: %w", err)
+			}
+			for _, id := range ids {
+				if requested {
+					if err := r.wait(ctx); err != nil {
+						return err
+					}
+				}
+				requested = true
+				r.record(job, id, r.trigger(ctx, id))
+			}
+			if len(ids) < regenerateBatchSize {
+				return nil
+			}
+			lastID = ids[len(ids)-1]
+		}
+	}()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	job.CompletedAt = &now
+	if err != nil {
+		job.Status = regenerateStatusFailed
+		job.Error = err.Error()
+		log.Printf("[Regenerate] サムネイルの再生成ジョブに失敗しました: id=%s, error=%v", job.ID, err)
+		return
+	}
+	job.Status = regenerateStatusCompleted
+	log.Printf("[Regenerate] サムネイルの再生成ジョブが完了しました: id=%s, 成功=%d件, スキップ=%d件, 失敗=%d件",
+		job.ID, job.Succeeded, job.Skipped, job.Failed)
+}
+
+// trigger はmedia-commandの /api/v1/media/{id}/thumbnail/regenerate を呼び出してサムネイルの再生成を依頼する。
+// Read ModelのIDはaggregate ID（"media-{uuid}"形式）のため、プレフィックスを除去して渡す。
+// media-commandが再生成を拒否した場合（409）はerrRegenerateSkippedを返す。
+func (r *regenerator) trigger(ctx context.Context, mediaID string) error {
+	path := fmt.Sprintf("/api/v1/media/%s/thumbnail/regenerate", strings.TrimPrefix(mediaID, "media-"))
+	status, err := r.client.DoJSON(ctx, http.MethodPost, path, nil, nil)
+	if status == http.StatusConflict {
+		return errRegenerateSkipped
+	}
+	return err
+}
+
+// record は1件の再生成の結果をジョブの状態に反映する。
+func (r *regenerator) record(job *regenerateJob, mediaID string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case err == nil:
+		job.Succeeded++
+	case errors.Is(err, errRegenerateSkipped):
+		job.Skipped++
+	default:
+		log.Printf("[Regenerate] サムネイルの再生成の依頼に失敗: media_id=%s, error=%v", mediaID, err)
+		job.Failed++
+		if len(job.Failures) < maxRegenerateFailures {
+			job.Failures = append(job.Failures, reprocessFailure{MediaID: mediaID, Error: err.Error()})
+		}
+	}
+}
+
+// wait は次の依頼までinterval待機する。コンテキストがキャンセルされた場合はエラーを返す。
+func (r *regenerator) wait(ctx context.Context) error {
+	if r.interval <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(r.interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// get はジョブの現在の状態を返す。
+func (r *regenerator) get(id string) (regenerateJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return regenerateJob{}, false
+	}
+	return snapshotRegenerateJob(job), true
+}
+
+// snapshot はジョブの状態のコピーを返す。
+func (r *regenerator) snapshot(job *regenerateJob) regenerateJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return snapshotRegenerateJob(job)
+}
+
+// snapshotRegenerateJob はジョブの状態のコピーを返す。失敗の一覧も実行中のジョブと共有しないように複製する。
+// 呼び出し側でmuを保持すること。
+func snapshotRegenerateJob(job *regenerateJob) regenerateJob {
+	snap := *job
+	snap.Failures = append([]reprocessFailure{}, job.Failures...)
+	return snap
+}
+
+// pruneLocked は保持するジョブがmaxRegenerateJobs件未満になるまで、古い完了済みジョブを破棄する。
+// 呼び出し側でmuを保持すること。
+func (r *regenerator) pruneLocked() {
+	if len(r.jobs) < maxRegenerateJobs {
+		return
+	}
+	finished := make([]*regenerateJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		if job.Status != regenerateStatusRunning {
+			finished = append(finished, job)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].StartedAt.Before(finished[j].StartedAt) })
+	for _, job := range finished {
+		if len(r.jobs) < maxRegenerateJobs {
+			return
+		}
+		delete(r.jobs, job.ID)
+	}
+}
+
+// handleStartRegenerateThumbnails はサムネイル生成済みの全画像のサムネイルを再生成するジョブを開始するハンドラを返す。
+// 再生成はバックグラウンドで行い、ジョブの状態を202で返す。
+// 完了はGET /api/v1/admin/media/regenerate-thumbnails/:id をポーリングして確認する。実行中のジョブがある場合は409を返す。
+func (s *Server) handleStartRegenerateThumbnails() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, err := s.regenerator.start()
+		if err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		log.Printf("[Regenerate] サムネイルの再生成ジョブを開始しました: id=%s, user_id=%s", job.ID, middleware.GetUserID(c))
+		c.JSON(http.StatusAccepted, job)
+	}
+}
+
+// handleGetRegenerateThumbnails はサムネイル再生成ジョブの状態を返すハンドラを返す。
+func (s *Server) handleGetRegenerateThumbnails() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, ok := s.regenerator.get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "サムネイルの再生成ジョブが見つかりません"})
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
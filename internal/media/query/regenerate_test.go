@@ -0,0 +1,187 @@
+package query
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newRegenerateMock はサムネイル再生成の依頼を記録するmedia-commandのモックを起動する。
+// statusesに指定したパスへの依頼にはそのステータスコードを返し、それ以外には200を返す。
+func newRegenerateMock(t *testing.T, statuses map[string]int) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		if status, ok := statuses[r.URL.Path]; ok {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"message":"ok"}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), paths...)
+	}
+}
+
+// waitRegenerateJob は再生成ジョブが終了するまでポーリングし、終了時の状態を返す。
+func waitRegenerateJob(t *testing.T, s *Server, id string) regenerateJob {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/media/regenerate-thumbnails/"+id, nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "admin-1", "admin@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var job regenerateJob
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		if job.Status != regenerateStatusRunning {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("再生成ジョブが終了しません")
+	return regenerateJob{}
+}
+
+func TestHandleRegenerateThumbnails(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_サムネイル生成済みの画像ごとに再生成を依頼し結果を集計する", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		ts, paths := newRegenerateMock(t, map[string]int{
+			"/api/v1/media/img-2/thumbnail/regenerate": http.StatusConflict,
+			"/api/v1/media/img-3/thumbnail/regenerate": http.StatusInternalServerError,
+		})
+		s.regenerator = newRegenerator(s.queries, ts.URL)
+		s.regenerator.interval = 0
+
+		insertTestMedia(t, db, "media-img-1", "user-123", "a.jpg", "image/jpeg", 1024, "/data/media/img-1/a.jpg", "processed")
+		insertTestMedia(t, db, "media-img-2", "user-123", "b.png", "image/png", 1024, "/data/media/img-2/b.png", "processed")
+		insertTestMedia(t, db, "media-img-3", "user-456", "c.jpg", "image/jpeg", 1024, "/data/media/img-3/c.jpg", "processed")
+		insertTestMedia(t, db, "media-video", "user-123", "d.mp4", "video/mp4", 1024, "/data/media/video/d.mp4", "processed")
+		insertTestMedia(t, db, "media-uploaded", "user-123", "e.jpg", "image/jpeg", 1024, "/data/media/uploaded/e.jpg", "uploaded")
+		insertTestMedia(t, db, "media-deleted", "user-123", "f.jpg", "image/jpeg", 1024, "/data/media/deleted/f.jpg", "deleted")
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/media/regenerate-thumbnails", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "admin-1", "admin@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		var started regenerateJob
+		if err := json.Unmarshal(w.Body.Bytes(), &started); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+
+		job := waitRegenerateJob(t, s, started.ID)
+		if job.Status != regenerateStatusCompleted {
+			t.Fatalf("期待する状態 %s, 実際の状態 %s（error=%s）", regenerateStatusCompleted, job.Status, job.Error)
+		}
+		if job.Succeeded != 1 || job.Skipped != 1 || job.Failed != 1 {
+			t.Errorf("期待する集計 成功1・スキップ1・失敗1, 実際の集計 成功%d・スキップ%d・失敗%d", job.Succeeded, job.Skipped, job.Failed)
+		}
+		if len(job.Failures) != 1 || job.Failures[0].MediaID != "media-img-3" {
+			t.Errorf("失敗したメディアが記録されていません: %+v", job.Failures)
+		}
+		if job.CompletedAt == nil {
+			t.Error("終了日時が記録されていません")
+		}
+
+		got := paths()
+		sort.Strings(got)
+		want := []string{
+			"/api/v1/media/img-1/thumbnail/regenerate",
+			"/api/v1/media/img-2/thumbnail/regenerate",
+			"/api/v1/media/img-3/thumbnail/regenerate",
+		}
+		if len(got) != len(want) {
+			t.Fatalf("期待する依頼 %v, 実際の依頼 %v", want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("期待する依頼 %v, 実際の依頼 %v", want, got)
+				break
+			}
+		}
+	})
+
+	t.Run("異常系_実行中のジョブがある場合は409を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		s.regenerator = newRegenerator(s.queries, "http://127.0.0.1:0")
+		s.regenerator.running.Store(true)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/media/regenerate-thumbnails", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "admin-1", "admin@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("異常系_管理者以外は403を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		s.regenerator = newRegenerator(s.queries, "http://127.0.0.1:0")
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/media/regenerate-thumbnails", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusForbidden, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("異常系_存在しないジョブは404を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		s.regenerator = newRegenerator(s.queries, "http://127.0.0.1:0")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/media/regenerate-thumbnails/unknown", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "admin-1", "admin@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusNotFound, w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	mediadb "github.com/nao1215/micro/internal/media/query/db"
+	"github.com/nao1215/micro/pkg/httpclient"
+	listquery "github.com/nao1215/micro/pkg/query"
+)
+
+const (
+	// defaultReprocessOlderThan はuploaded状態のまま放置されたとみなすまでの既定の経過時間。
+	defaultReprocessOlderThan = 10 * time.Minute
+	// defaultReprocessLimit は1回のジョブで再処理を依頼するメディアの既定の最大件数。
+	defaultReprocessLimit = 100
+	// maxReprocessLimit は1回のジョブで再処理を依頼できるメディアの最大件数。
+	maxReprocessLimit = 1000
+	// defaultReprocessInterval はmedia-commandへ再処理を依頼する間隔の既定値。
+	// 障害復旧直後にmedia-commandへ依頼が集中しないように1件ずつ間隔を空ける。
+	defaultReprocessInterval = 500 * time.Millisecond
+)
+
+// reprocessQuerySpec は未処理メディアの再処理が受け付ける件数のクエリパラメータ。
+var reprocessQuerySpec = listquery.Spec{
+	DefaultLimit: defaultReprocessLimit,
+	MaxLimit:     maxReprocessLimit,
+}
+
+// reprocessor はuploaded状態のまま処理されていないメディアの再処理をmedia-commandへ依頼する。
+// Sagaは過去のMediaUploadedイベントを遡って処理しないため、処理基盤の障害後に管理者が手動で実行する。
+type reprocessor struct {
+	// client はmedia-commandとの通信用HTTPクライアント。
+	client *httpclient.Client
+	// interval は再処理を依頼する間隔。ゼロの場合は間隔を空けない。
+	interval time.Duration
+	// running はジョブの実行中にtrueとなる。同時に1つのジョブだけを実行するために使用する。
+	running atomic.Bool
+	// mu はtriggeredへの並行アクセスを保護するミューテックス。
+	mu sync.Mutex
+	// triggered はメディアIDごとの最後に再処理を依頼した日時。
+	triggered map[string]time.Time
+}
+
+// newReprocessor は新しいreprocessorを生成する。
+// mediaCommandURL はmedia-commandのベースURL（例: "http://localhost:8081"）。
+// optsはmedia-commandとの通信用HTTPクライアントに渡す（リクエストの署名など）。
+func newReprocessor(mediaCommandURL string, opts ...httpclient.Option) *reprocessor {
+	return &reprocessor{
+		client:    httpclient.New(mediaCommandURL, opts...),
+		interval:  defaultReprocessInterval,
+		triggered: make(map[string]time.Time),
+	}
+}
+
+// recentlyTriggered は指定日時以降に再処理を依頼済みかどうかを返す。
+func (r *reprocessor) recentlyTriggered(mediaID string, since time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.triggered[mediaID]
+	return ok && at.After(since)
+}
+
+// lastTriggered は最後に再処理を依頼した日時を返す。依頼したことがない場合はfalseを返す。
+func (r *reprocessor) lastTriggered(mediaID string) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.triggered[mediaID]
+	return at, ok
+}
+
+// markTriggered は再処理を依頼した日時を記録する。
+func (r *reprocessor) markTriggered(mediaID string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.triggered[mediaID] = at
+}
+
+// trigger はmedia-commandの /api/v1/media/{id}/process を呼び出して再処理を依頼する。
+// Read ModelのIDはaggregate ID（"media-{uuid}"形式）のため、プレフィックスを除去して渡す。
+func (r *reprocessor) trigger(ctx context.Context, m mediadb.MediaReadModel) error {
+	reqBody := map[string]string{
+		"storage_path": m.StoragePath,
+		"content_type": m.ContentType,
+	}
+	path := fmt.Sprintf("/api/v1/media/%s/process", strings.TrimPrefix(m.ID, "media-"))
+	return r.client.PostJSON(ctx, path, reqBody, nil)
+}
+
+// wait は次の依頼までinterval待機する。コンテキストがキャンセルされた場合はエラーを返す。
+func (r *reprocessor) wait(ctx context.Context) error {
+	if r.interval <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(r.interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reprocessFailure は再処理の依頼に失敗したメディアの情報。
+type reprocessFailure struct {
+	// MediaID は依頼に失敗したメディアのID。
+	MediaID string `json:"media_id"`
+	// Error は失敗の理由。
+	Error string `json:"error"`
+}
+
+// reprocessResponse は再処理ジョブの実行結果のレスポンス。
+type reprocessResponse struct {
+	// Triggered は再処理を依頼したメディアのID。
+	Triggered []string `json:"triggered"`
+	// Skipped は直近に依頼済みのため今回は依頼しなかったメディアのID。
+	Skipped []string `json:"skipped"`
+	// Failed は再処理の依頼に失敗したメディア。
+	Failed []reprocessFailure `json:"failed"`
+	// OlderThan は放置されたとみなした経過時間。
+	OlderThan string `json:"older_than"`
+}
+
+// handleReprocessPending はuploaded状態のまま一定時間経過したメディアの再処理を依頼するハンドラ。
+// クエリパラメータ older_than で経過時間（既定10m）、limit で最大件数（既定100、最大1000）を指定する。
+// 同時に実行できるジョブは1つだけで、実行中に呼び出された場合は409を返す。
+// older_than 以内に依頼済みのメディアは再度依頼しないため、繰り返し呼び出しても依頼は重複しない。
+// media-commandへの依頼は1件ずつ間隔を空けて行う。
+func (s *Server) handleReprocessPending() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		olderThan := defaultReprocessOlderThan
+		if v := c.Query("older_than"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "older_thanは正の期間（例: 10m）で指定してください"})
+				return
+			}
+			olderThan = d
+		}
+
+		page, ok := listquery.ParseRequest(c, reprocessQuerySpec)
+		if !ok {
+			return
+		}
+		limit := int64(page.Limit)
+
+		if !s.reprocessor.running.CompareAndSwap(false, true) {
+			c.JSON(http.StatusConflict, gin.H{"error": "再処理ジョブは実行中です"})
+			return
+		}
+		defer s.reprocessor.running.Store(false)
+
+		ctx := c.Request.Context()
+		cutoff := time.Now().UTC().Add(-olderThan)
+		models, err := s.queries.ListStaleUploadedMedia(ctx, mediadb.ListStaleUploadedMediaParams{
+			UploadedAt: cutoff,
+			Limit:      limit,
+		})
+		if err != nil {
+			log.Printf("未処理メディアの取得エラー: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "未処理メディアの取得に失敗しました"})
+			return
+		}
+
+		resp := reprocessResponse{
+			Triggered: []string{},
+			Skipped:   []string{},
+			Failed:    []reprocessFailure{},
+			OlderThan: olderThan.String(),
+		}
+		for _, m := range models {
+			if s.reprocessor.recentlyTriggered(m.ID, cutoff) {
+				resp.Skipped = append(resp.Skipped, m.ID)
+				continue
+			}
+			if len(resp.Triggered)+len(resp.Failed) > 0 {
+				if err := s.reprocessor.wait(ctx); err != nil {
+					break
+				}
+			}
+
+			if err := s.reprocessor.trigger(ctx, m); err != nil {
+				log.Printf("メディア再処理の依頼に失敗: media_id=%s, error=%v", m.ID, err)
+				resp.Failed = append(resp.Failed, reprocessFailure{MediaID: m.ID, Error: err.Error()})
+				continue
+			}
+			s.reprocessor.markTriggered(m.ID, time.Now().UTC())
+			resp.Triggered = append(resp.Triggered, m.ID)
+		}
+
+		log.Printf("未処理メディアの再処理を依頼しました: 依頼=%d件, スキップ=%d件, 失敗=%d件",
+			len(resp.Triggered), len(resp.Skipped), len(resp.Failed))
+		c.JSON(http.StatusOK, resp)
+	}
+}
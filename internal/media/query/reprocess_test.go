@@ -0,0 +1,275 @@
+package query
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// insertStaleMedia はアップロード日時を指定してRead Modelにテスト用のメディアレコードを挿入する。
+func insertStaleMedia(t *testing.T, db *sql.DB, id, status string, uploadedAt time.Time) {
+	t.Helper()
+	_, err := db.Exec(
+		`INSERT INTO media_read_models (id, user_id, filename, filename_normalized, content_type, size, storage_path, status, last_event_version, uploaded_at, updated_at)
+		 VALUES (?, 'user-123', 'photo.jpg', 'photo.jpg', 'image/jpeg', 1024, ?, ?, 1, ?, datetime('now'))`,
+		id, "/data/media/"+id+"/photo.jpg", status, uploadedAt.UTC(),
+	)
+	if err != nil {
+		t.Fatalf("テスト用メディアレコードの挿入に失敗: %v", err)
+	}
+}
+
+// processCall はmedia-commandのモックが受け取った再処理依頼。
+type processCall struct {
+	path        string
+	storagePath string
+	contentType string
+}
+
+// newMediaCommandMock は再処理依頼を記録するmedia-commandのモックを起動する。
+// failPathに一致するパスへの依頼には500を返す。
+func newMediaCommandMock(t *testing.T, failPath string) (*httptest.Server, func() []processCall) {
+	t.Helper()
+
+	var (
+		mu    sync.Mutex
+		calls []processCall
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]string
+		_ = json.Unmarshal(body, &req)
+
+		mu.Lock()
+		calls = append(calls, processCall{path: r.URL.Path, storagePath: req["storage_path"], contentType: req["content_type"]})
+		mu.Unlock()
+
+		if r.URL.Path == failPath {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"message":"ok"}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts, func() []processCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]processCall(nil), calls...)
+	}
+}
+
+// postReprocessPending は再処理ジョブのエンドポイントをユーザーadmin-1として呼び出す。
+func postReprocessPending(t *testing.T, s *Server, query string) (int, reprocessResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/reprocess-pending"+query, nil)
+	req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "admin-1", "admin@example.com"))
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	var resp reprocessResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestHandleReprocessPending(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_放置されたuploadedのメディアごとに再処理を依頼する", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		ts, calls := newMediaCommandMock(t, "")
+		s.reprocessor = newReprocessor(ts.URL)
+		s.reprocessor.interval = 0
+
+		old := time.Now().Add(-time.Hour)
+		insertStaleMedia(t, db, "media-stuck-1", "uploaded", old)
+		insertStaleMedia(t, db, "media-stuck-2", "uploaded", old.Add(time.Minute))
+		insertStaleMedia(t, db, "media-recent", "uploaded", time.Now())
+		insertStaleMedia(t, db, "media-done", "processed", old)
+		insertStaleMedia(t, db, "media-failed", "failed", old)
+
+		code, resp := postReprocessPending(t, s, "")
+		if code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		if len(resp.Triggered) != 2 || resp.Triggered[0] != "media-stuck-1" || resp.Triggered[1] != "media-stuck-2" {
+			t.Errorf("期待するtriggered [media-stuck-1 media-stuck-2], 実際 %v", resp.Triggered)
+		}
+
+		got := calls()
+		if len(got) != 2 {
+			t.Fatalf("期待する依頼回数 2, 実際 %d", len(got))
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i].path < got[j].path })
+		for i, id := range []string{"stuck-1", "stuck-2"} {
+			if want := "/api/v1/media/" + id + "/process"; got[i].path != want {
+				t.Errorf("期待するパス %q, 実際 %q", want, got[i].path)
+			}
+			if want := "/data/media/media-" + id + "/photo.jpg"; got[i].storagePath != want {
+				t.Errorf("期待するstorage_path %q, 実際 %q", want, got[i].storagePath)
+			}
+			if got[i].contentType != "image/jpeg" {
+				t.Errorf("期待するcontent_type image/jpeg, 実際 %q", got[i].contentType)
+			}
+		}
+	})
+
+	t.Run("正常系_依頼済みのメディアは繰り返し呼び出しても再依頼しない", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		ts, calls := newMediaCommandMock(t, "")
+		s.reprocessor = newReprocessor(ts.URL)
+		s.reprocessor.interval = 0
+
+		insertStaleMedia(t, db, "media-stuck-1", "uploaded", time.Now().Add(-time.Hour))
+
+		if code, _ := postReprocessPending(t, s, ""); code != http.StatusOK {
+			t.Fatalf("1回目: 期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		code, resp := postReprocessPending(t, s, "")
+		if code != http.StatusOK {
+			t.Fatalf("2回目: 期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		if len(resp.Triggered) != 0 || len(resp.Skipped) != 1 {
+			t.Errorf("2回目: 期待するtriggered 0件・skipped 1件, 実際 triggered=%v skipped=%v", resp.Triggered, resp.Skipped)
+		}
+		if n := len(calls()); n != 1 {
+			t.Errorf("期待する依頼回数 1, 実際 %d", n)
+		}
+	})
+
+	t.Run("正常系_依頼に失敗したメディアはfailedに含め後続の依頼を続ける", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		ts, calls := newMediaCommandMock(t, "/api/v1/media/stuck-1/process")
+		s.reprocessor = newReprocessor(ts.URL)
+		s.reprocessor.interval = 0
+
+		old := time.Now().Add(-time.Hour)
+		insertStaleMedia(t, db, "media-stuck-1", "uploaded", old)
+		insertStaleMedia(t, db, "media-stuck-2", "uploaded", old.Add(time.Minute))
+
+		code, resp := postReprocessPending(t, s, "")
+		if code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		if len(resp.Failed) != 1 || resp.Failed[0].MediaID != "media-stuck-1" {
+			t.Errorf("期待するfailed [media-stuck-1], 実際 %v", resp.Failed)
+		}
+		if len(resp.Triggered) != 1 || resp.Triggered[0] != "media-stuck-2" {
+			t.Errorf("期待するtriggered [media-stuck-2], 実際 %v", resp.Triggered)
+		}
+		if n := len(calls()); n != 2 {
+			t.Errorf("期待する依頼回数 2, 実際 %d", n)
+		}
+
+		// 失敗したメディアは次回のジョブで再度依頼される
+		_, resp = postReprocessPending(t, s, "")
+		if len(resp.Failed) != 1 || len(resp.Skipped) != 1 {
+			t.Errorf("2回目: 期待するfailed 1件・skipped 1件, 実際 failed=%v skipped=%v", resp.Failed, resp.Skipped)
+		}
+	})
+
+	t.Run("正常系_older_thanとlimitで対象を絞り込める", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		ts, _ := newMediaCommandMock(t, "")
+		s.reprocessor = newReprocessor(ts.URL)
+		s.reprocessor.interval = 0
+
+		insertStaleMedia(t, db, "media-3h", "uploaded", time.Now().Add(-3*time.Hour))
+		insertStaleMedia(t, db, "media-2h", "uploaded", time.Now().Add(-2*time.Hour))
+		insertStaleMedia(t, db, "media-30m", "uploaded", time.Now().Add(-30*time.Minute))
+
+		code, resp := postReprocessPending(t, s, "?older_than=1h&limit=1")
+		if code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		if len(resp.Triggered) != 1 || resp.Triggered[0] != "media-3h" {
+			t.Errorf("期待するtriggered [media-3h], 実際 %v", resp.Triggered)
+		}
+		if resp.OlderThan != "1h0m0s" {
+			t.Errorf("期待するolder_than 1h0m0s, 実際 %q", resp.OlderThan)
+		}
+	})
+
+	t.Run("異常系_ジョブの実行中は409を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		s.reprocessor = newReprocessor("http://127.0.0.1:0")
+		s.reprocessor.running.Store(true)
+
+		if code, _ := postReprocessPending(t, s, ""); code != http.StatusConflict {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusConflict, code)
+		}
+	})
+
+	t.Run("異常系_不正なパラメータは400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		s.reprocessor = newReprocessor("http://127.0.0.1:0")
+
+		for _, q := range []string{"?older_than=abc", "?older_than=-1m", "?limit=0", "?limit=1001", "?limit=x"} {
+			if code, _ := postReprocessPending(t, s, q); code != http.StatusBadRequest {
+				t.Errorf("%s: 期待するステータスコード %d, 実際のステータスコード %d", q, http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("異常系_管理者以外は403を返し再処理を依頼しない", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		ts, calls := newMediaCommandMock(t, "")
+		s.reprocessor = newReprocessor(ts.URL)
+		s.reprocessor.interval = 0
+		insertStaleMedia(t, db, "media-stuck-1", "uploaded", time.Now().Add(-time.Hour))
+
+		if code, _ := postReprocessPending(t, s, ""); code != http.StatusForbidden {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusForbidden, code)
+		}
+		if got := calls(); len(got) != 0 {
+			t.Errorf("再処理を依頼しないこと: %v", got)
+		}
+	})
+}
+
+func TestReprocessorWaitRateLimit(t *testing.T) {
+	t.Parallel()
+
+	r := newReprocessor("http://127.0.0.1:0")
+	r.interval = 20 * time.Millisecond
+
+	start := time.Now()
+	if err := r.wait(t.Context()); err != nil {
+		t.Fatalf("wait()でエラーが発生: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < r.interval {
+		t.Errorf("期待する待機時間 %v 以上, 実際 %v", r.interval, elapsed)
+	}
+}
@@ -3,15 +3,21 @@ package query
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "modernc.org/sqlite"
 	mediadb "github.com/nao1215/micro/internal/media/query/db"
+	"github.com/nao1215/micro/pkg/httpclient"
 	"github.com/nao1215/micro/pkg/middleware"
+	listquery "github.com/nao1215/micro/pkg/query"
 )
 
 // Server はメディアクエリサービスのHTTPサーバー。
@@ -27,6 +33,16 @@ type Server struct {
 	db *sql.DB
 	// projector はEvent Storeからイベントをポーリングし、Read Modelを更新するバックグラウンドプロセス。
 	projector *Projector
+	// reprocessor は処理されずに残ったメディアの再処理をmedia-commandへ依頼する。
+	reprocessor *reprocessor
+	// regenerator はサムネイル生成済みの画像のサムネイル再生成をmedia-commandへ依頼するジョブを管理する。
+	regenerator *regenerator
+	// adminUserIDs は管理者として扱うユーザーIDの集合（環境変数ADMIN_USER_IDS）。
+	// 管理者は削除済みメディアの監査のためにinclude_deleted=trueを指定できる。
+	adminUserIDs map[string]struct{}
+	// eventStoreClient はEvent Storeサービスへの通信クライアント。
+	// 管理者によるステータス変更のイベントを追記するために使用する。
+	eventStoreClient *httpclient.Client
 }
 
 // NewServer は新しいメディアクエリサーバーを生成する。
@@ -43,23 +59,40 @@ func NewServer(port string) (*Server, error) {
 
 	queries := mediadb.New(sqlDB)
 
+	// 検索用の正規化カラム追加前に投影されたレコードを検索対象にする
+	backfilled, err := backfillNormalizedFilenames(context.Background(), queries)
+	if err != nil {
+		return nil, fmt.Errorf("正規化ファイル名の補完に失敗: %w", err)
+	}
+	if backfilled > 0 {
+		log.Printf("正規化ファイル名を補完しました: %d件", backfilled)
+	}
+
 	eventstoreURL := os.Getenv("EVENTSTORE_URL")
 	if eventstoreURL == "" {
 		eventstoreURL = "http://localhost:8084"
 	}
 
-	projector := NewProjector(queries, eventstoreURL)
+	projector := NewProjector(sqlDB, eventstoreURL)
+
+	mediaCommandURL := os.Getenv("MEDIA_COMMAND_URL")
+	if mediaCommandURL == "" {
+		mediaCommandURL = "http://localhost:8081"
+	}
 
 	router := gin.New()
-	router.Use(middleware.Recovery())
-	router.Use(gin.Logger())
+	router.Use(middleware.DefaultChain(middleware.ChainConfig{})...)
 
 	s := &Server{
-		router:    router,
-		port:      port,
-		queries:   queries,
-		db:        sqlDB,
-		projector: projector,
+		router:           router,
+		port:             port,
+		queries:          queries,
+		db:               sqlDB,
+		projector:        projector,
+		reprocessor:      newReprocessor(mediaCommandURL, httpclient.WithSigningKey(os.Getenv("INTERNAL_SIGNING_KEY"))),
+		regenerator:      newRegenerator(queries, mediaCommandURL, httpclient.WithSigningKey(os.Getenv("INTERNAL_SIGNING_KEY"))),
+		adminUserIDs:     parseAdminUserIDs(os.Getenv("ADMIN_USER_IDS")),
+		eventStoreClient: httpclient.New(eventstoreURL),
 	}
 	s.setupRoutes()
 
@@ -95,7 +128,11 @@ func (s *Server) setupRoutes() {
 	}
 
 	api := s.router.Group("/api/v1")
-	api.Use(middleware.JWTAuth(jwtSecret))
+	api.Use(middleware.JWTAuth(jwtSecret,
+		middleware.WithPreviousSecrets(middleware.SplitSecrets(os.Getenv("JWT_SECRET_PREVIOUS"))...),
+		middleware.WithIssuer(middleware.Issuer),
+		middleware.WithAudience(middleware.Audience),
+	))
 	{
 		media := api.Group("/media")
 		{
@@ -103,6 +140,10 @@ func (s *Server) setupRoutes() {
 			media.GET("", s.handleList())
 			// メディア詳細取得
 			media.GET("/:id", s.handleGetByID())
+			// レスポンシブ画像セット取得（<img srcset>用）
+			media.GET("/:id/srcset", s.handleSrcset())
+			// 処理状態取得（アップロード後のポーリング用）
+			media.GET("/:id/status", s.handleGetStatus())
 			// メディア検索
 			media.GET("/search", s.handleSearch())
 		}
@@ -113,15 +154,30 @@ func (s *Server) setupRoutes() {
 			// Read Modelの完全再構築
 			internal.POST("/rebuild", s.handleRebuild())
 		}
+
+		// 管理者向けジョブ
+		admin := api.Group("/admin")
+		{
+			// uploaded状態のまま放置されたメディアの再処理依頼（管理者のみ）
+			admin.POST("/reprocess-pending", s.requireAdmin(), s.handleReprocessPending())
+			// 処理が長時間完了していないメディアの一覧（管理者のみ）
+			admin.GET("/stuck-media", s.requireAdmin(), s.handleListStuckMedia())
+			// メディアの一括ステータス変更（管理者のみ）
+			admin.POST("/media/bulk-status", s.requireAdmin(), s.handleBulkStatus())
+			// サムネイル生成済みの全画像のサムネイル再生成ジョブ（管理者のみ）
+			admin.POST("/media/regenerate-thumbnails", s.requireAdmin(), s.handleStartRegenerateThumbnails())
+			admin.GET("/media/regenerate-thumbnails/:id", s.requireAdmin(), s.handleGetRegenerateThumbnails())
+		}
 	}
 
 	// ヘルスチェック
-	s.router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "media-query"})
-	})
+	s.router.GET("/health", middleware.HealthCheck("media-query", s.db))
 }
 
 // mediaResponse はメディア情報のJSONレスポンス構造。
+// 値を持たない場合があるフィールド（thumbnail_path, width, height, duration_seconds）はomitemptyにせず、
+// 値がない場合もキーを省略せずnullを出力する（MessagePackでも同様）。
+// nullは「値がない」ことだけを表し、処理が未完了かどうかはstatusで判別する。
 type mediaResponse struct {
 	// ID はメディアの一意識別子。
 	ID string `json:"id"`
@@ -135,15 +191,15 @@ type mediaResponse struct {
 	Size int64 `json:"size"`
 	// StoragePath はファイルの保存パス。
 	StoragePath string `json:"storage_path"`
-	// ThumbnailPath はサムネイル画像の保存パス。処理完了前はnull。
+	// ThumbnailPath はサムネイル画像の保存パス。処理完了前（status=uploaded）や、サムネイルを生成しなかった場合はnull。
 	ThumbnailPath *string `json:"thumbnail_path"`
-	// Width は画像/動画の幅（ピクセル）。処理完了前はnull。
+	// Width は画像/動画の幅（ピクセル）。処理完了前や、処理で幅を取得できなかった場合はnull。
 	Width *int64 `json:"width"`
-	// Height は画像/動画の高さ（ピクセル）。処理完了前はnull。
+	// Height は画像/動画の高さ（ピクセル）。処理完了前や、処理で高さを取得できなかった場合はnull。
 	Height *int64 `json:"height"`
-	// DurationSeconds は動画の長さ（秒）。画像の場合はnull。
+	// DurationSeconds は動画の長さ（秒）。処理完了前や画像の場合はnull。
 	DurationSeconds *float64 `json:"duration_seconds"`
-	// Status はメディアの状態（uploaded, processed, failed, deleted）。
+	// Status はメディアの状態（uploaded, processed, failed, deleted, flagged）。
 	Status string `json:"status"`
 	// UploadedAt はアップロード日時。
 	UploadedAt string `json:"uploaded_at"`
@@ -152,6 +208,7 @@ type mediaResponse struct {
 }
 
 // toMediaResponse はRead Modelのレコードを外部レスポンス形式に変換する。
+// Read ModelのNULLはnilに変換し、レスポンスではnullとして出力する。
 func toMediaResponse(m mediadb.MediaReadModel) mediaResponse {
 	resp := mediaResponse{
 		ID:          m.ID,
@@ -190,8 +247,65 @@ func toMediaResponses(models []mediadb.MediaReadModel) []mediaResponse {
 	return responses
 }
 
+// mediaListSorts はメディア一覧のsortに指定できる並び順。先頭をsort省略時の既定とする。
+var mediaListSorts = []string{
+	"uploaded_at_desc", "uploaded_at_asc",
+	"size_desc", "size_asc",
+	"filename_desc", "filename_asc",
+}
+
+// mediaListStatuses はメディア一覧のstatusに指定できる状態。
+// flaggedは確認が終わるまで一覧から除外するため指定できない。deletedは管理者のみ指定できる。
+var mediaListStatuses = []string{"uploaded", "processed", "failed", "deleted"}
+
+// defaultMediaListStatuses はstatus省略時に一覧に含める状態（削除済み・確認待ちを除く）。
+var defaultMediaListStatuses = []string{"uploaded", "processed", "failed"}
+
+// parseMediaListStatuses はカンマ区切りのstatusを検証し、重複を除いた状態の一覧を返す。
+func parseMediaListStatuses(raw string) ([]string, error) {
+	var statuses []string
+	for status := range strings.SplitSeq(raw, ",") {
+		status = strings.TrimSpace(status)
+		if status == "" || slices.Contains(statuses, status) {
+			continue
+		}
+		if !slices.Contains(mediaListStatuses, status) {
+			return nil, fmt.Errorf("statusは%sのいずれかをカンマ区切りで指定してください: %q", strings.Join(mediaListStatuses, ", "), status)
+		}
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		return nil, errors.New("statusを指定してください")
+	}
+	return statuses, nil
+}
+
+// mediaListCursorSorts はカーソルベースのページングに対応する並び順。
+// カーソルはアップロード日時とIDの組のため、アップロード日時の並び順だけで使用できる。
+var mediaListCursorSorts = []string{"uploaded_at_desc", "uploaded_at_asc"}
+
+const (
+	// defaultMediaListLimit はcursorだけを指定してlimitを省略した場合の1ページあたりの件数。
+	defaultMediaListLimit = 100
+	// maxMediaListLimit はメディア一覧の1ページあたりの最大件数。
+	maxMediaListLimit = 1000
+)
+
+// mediaListQuerySpec はメディア一覧が受け付ける並び順とページングのクエリパラメータ。
+// limitの既定値を設けず、limitとcursorのどちらも省略した場合は従来どおり全件を返す。
+var mediaListQuerySpec = listquery.Spec{
+	MaxLimit: maxMediaListLimit,
+	Cursor:   true,
+	Sorts:    mediaListSorts,
+}
+
 // handleList は認証済みユーザーのメディア一覧を返すハンドラ。
 // X-User-IDヘッダーまたはJWTクレームからユーザーIDを取得する。
+// statusを指定した場合はその状態（カンマ区切りで複数可）のメディアだけを返す。
+// 省略時は削除済みを除き、管理者がinclude_deleted=trueを指定した場合は削除済みメディアも含めて返す。
+// sortで並び順（uploaded_at・size・filenameの昇順/降順）を指定でき、省略時はアップロード日時の新しい順に返す。
+// limitまたはcursorを指定した場合はアップロード日時とIDの組によるカーソルでページングし、
+// 次ページのcursorをnext_cursorに返す（最終ページではnull）。カーソルはアップロード日時の並び順でのみ使用できる。
 func (s *Server) handleList() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
@@ -200,22 +314,88 @@ func (s *Server) handleList() gin.HandlerFunc {
 			return
 		}
 
-		models, err := s.queries.ListMediaByUserID(c.Request.Context(), userID)
+		page, ok := listquery.ParseRequest(c, mediaListQuerySpec)
+		if !ok {
+			return
+		}
+
+		statuses := defaultMediaListStatuses
+		if raw, ok := c.GetQuery("status"); ok {
+			var err error
+			statuses, err = parseMediaListStatuses(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if slices.Contains(statuses, "deleted") && !s.isAdmin(c) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "削除済みメディアは管理者のみ参照できます"})
+				return
+			}
+		} else if s.includeDeleted(c) {
+			statuses = append(slices.Clone(statuses), "deleted")
+		}
+
+		var (
+			models     []mediadb.MediaReadModel
+			nextCursor *string
+			err        error
+		)
+		if page.Limit == 0 && page.Cursor == "" {
+			models, err = s.queries.ListMediaByUserIDAndStatuses(c.Request.Context(), mediadb.ListMediaByUserIDAndStatusesParams{
+				UserID:   userID,
+				Statuses: statuses,
+				Sort:     page.Sort,
+			})
+		} else {
+			if !slices.Contains(mediaListCursorSorts, page.Sort) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit・cursorはsortが%sの場合のみ指定できます", strings.Join(mediaListCursorSorts, ", "))})
+				return
+			}
+			params := mediadb.ListMediaPageByUserIDAndStatusesParams{
+				UserID:   userID,
+				Statuses: statuses,
+				Sort:     page.Sort,
+			}
+			if page.Cursor != "" {
+				cursor, err := decodeMediaListCursor(page.Cursor)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				params.CursorUploadedAt = sql.NullTime{Time: cursor.UploadedAt, Valid: true}
+				params.CursorID = cursor.ID
+			}
+			limit := page.Limit
+			if limit == 0 {
+				limit = defaultMediaListLimit
+			}
+			// 次のページの有無を判定するため1件多く取得する
+			params.Limit = int64(limit + 1)
+			models, err = s.queries.ListMediaPageByUserIDAndStatuses(c.Request.Context(), params)
+			if len(models) > limit {
+				models = models[:limit]
+				next := encodeMediaListCursor(models[limit-1])
+				nextCursor = &next
+			}
+		}
 		if err != nil {
 			log.Printf("メディア一覧取得エラー: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "メディア一覧の取得に失敗しました"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"media": toMediaResponses(models),
-			"count": len(models),
-		})
+		media := toMediaResponses(models)
+		middleware.RespondList(c, http.StatusOK, gin.H{
+			"media":       media,
+			"count":       len(media),
+			"next_cursor": nextCursor,
+		}, middleware.Envelope{Data: media, Count: len(media), NextCursor: nextCursor})
 	}
 }
 
 // handleGetByID は指定されたIDのメディア詳細を返すハンドラ。
 // パスパラメータ :id からメディアIDを取得する。
+// 削除済みメディアは404を返すが、管理者がinclude_deleted=trueを指定した場合はステータスとともに返す。
 func (s *Server) handleGetByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		mediaID := c.Param("id")
@@ -234,13 +414,37 @@ func (s *Server) handleGetByID() gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "メディア詳細の取得に失敗しました"})
 			return
 		}
+		if model.Status == "deleted" && !s.includeDeleted(c) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "メディアが見つかりません"})
+			return
+		}
+
+		middleware.Respond(c, http.StatusOK, toMediaResponse(model))
+	}
+}
 
-		c.JSON(http.StatusOK, toMediaResponse(model))
+// mediaSearchContentTypes はメディア検索のcontent_typeに指定できるメディアの種類。
+// 指定した種類で始まるContent-Type（例: imageはimage/jpeg、image/png）のメディアに絞り込む。
+var mediaSearchContentTypes = []string{"image", "video"}
+
+// parseUploadedAtBound はメディア検索のfrom・to（RFC3339）をuploaded_atと比較できるUTCの日時に変換する。
+// 空の場合は絞り込まないことを表す無効な値を返す。
+func parseUploadedAtBound(name, v string) (sql.NullTime, error) {
+	if v == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("%sはRFC3339形式（例: 2024-01-01T00:00:00Z）で指定してください: %q", name, v)
 	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
 }
 
 // handleSearch はファイル名によるメディア検索を処理するハンドラ。
 // クエリパラメータ q でファイル名のパターンを指定する（部分一致検索）。
+// クエリと検索用カラムの双方をnormalizeForSearchで正規化して比較するため、
+// 全角・半角、英字の大文字小文字、ひらがな・カタカナの違いを区別しない。
+// content_type（image・video）でメディアの種類を、from・to（RFC3339、両端を含む）でアップロード日時の範囲を絞り込める。
 func (s *Server) handleSearch() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		q := c.Query("q")
@@ -249,20 +453,48 @@ func (s *Server) handleSearch() gin.HandlerFunc {
 			return
 		}
 
-		// LIKE句による部分一致検索
-		pattern := fmt.Sprintf("%%%s%%", q)
-		models, err := s.queries.SearchMedia(c.Request.Context(), pattern)
+		contentType := "%"
+		if v := c.Query("content_type"); v != "" {
+			if !slices.Contains(mediaSearchContentTypes, v) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("content_typeは%sのいずれかで指定してください: %q", strings.Join(mediaSearchContentTypes, ", "), v)})
+				return
+			}
+			contentType = v + "/%"
+		}
+		from, err := parseUploadedAtBound("from", c.Query("from"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		to, err := parseUploadedAtBound("to", c.Query("to"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if from.Valid && to.Valid && from.Time.After(to.Time) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "fromはto以前の日時で指定してください"})
+			return
+		}
+
+		// 正規化済みの検索用カラムに対するLIKE句による部分一致検索
+		models, err := s.queries.SearchMedia(c.Request.Context(), mediadb.SearchMediaParams{
+			FilenameNormalized: fmt.Sprintf("%%%s%%", normalizeForSearch(q)),
+			ContentType:        contentType,
+			UploadedFrom:       from,
+			UploadedTo:         to,
+		})
 		if err != nil {
 			log.Printf("メディア検索エラー: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "メディアの検索に失敗しました"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"media": toMediaResponses(models),
-			"count": len(models),
+		media := toMediaResponses(models)
+		middleware.RespondList(c, http.StatusOK, gin.H{
+			"media": media,
+			"count": len(media),
 			"query": q,
-		})
+		}, middleware.Envelope{Data: media, Count: len(media)})
 	}
 }
 
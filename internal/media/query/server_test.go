@@ -2,13 +2,18 @@ package query
 
 import (
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
 	_ "modernc.org/sqlite"
 	mediadb "github.com/nao1215/micro/internal/media/query/db"
 	"github.com/nao1215/micro/pkg/middleware"
@@ -51,8 +56,18 @@ func setupTestQueryServer(t *testing.T) (*Server, *sql.DB) {
 		{
 			media.GET("", s.handleList())
 			media.GET("/:id", s.handleGetByID())
+			media.GET("/:id/srcset", s.handleSrcset())
+			media.GET("/:id/status", s.handleGetStatus())
 			media.GET("/search", s.handleSearch())
 		}
+		admin := api.Group("/admin")
+		{
+			admin.POST("/reprocess-pending", s.requireAdmin(), s.handleReprocessPending())
+			admin.GET("/stuck-media", s.requireAdmin(), s.handleListStuckMedia())
+			admin.POST("/media/bulk-status", s.requireAdmin(), s.handleBulkStatus())
+			admin.POST("/media/regenerate-thumbnails", s.requireAdmin(), s.handleStartRegenerateThumbnails())
+			admin.GET("/media/regenerate-thumbnails/:id", s.requireAdmin(), s.handleGetRegenerateThumbnails())
+		}
 	}
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "media-query"})
@@ -76,12 +91,13 @@ func generateTestToken(t *testing.T, userID, email string) string {
 }
 
 // insertTestMedia はRead Modelにテスト用のメディアレコードを挿入する。
+// Projectorと同様に検索用の正規化ファイル名も設定する。
 func insertTestMedia(t *testing.T, db *sql.DB, id, userID, filename, contentType string, size int64, storagePath, status string) {
 	t.Helper()
 	_, err := db.Exec(
-		`INSERT INTO media_read_models (id, user_id, filename, content_type, size, storage_path, status, last_event_version, uploaded_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?, datetime('now'))`,
-		id, userID, filename, contentType, size, storagePath, status, time.Now().UTC(),
+		`INSERT INTO media_read_models (id, user_id, filename, filename_normalized, content_type, size, storage_path, status, last_event_version, uploaded_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, ?, datetime('now'))`,
+		id, userID, filename, normalizeForSearch(filename), contentType, size, storagePath, status, time.Now().UTC(),
 	)
 	if err != nil {
 		t.Fatalf("テスト用メディアレコードの挿入に失敗: %v", err)
@@ -186,6 +202,295 @@ func TestHandleListMedia(t *testing.T) {
 	})
 }
 
+// TestHandleListMedia_StatusAndSort はメディア一覧のstatusによる絞り込みとsortによる並び替えを検証する。
+func TestHandleListMedia_StatusAndSort(t *testing.T) {
+	t.Parallel()
+
+	// setupListTestServer は状態・サイズ・ファイル名・アップロード日時の異なるメディアを持つテスト用サーバーを作成する。
+	setupListTestServer := func(t *testing.T) *Server {
+		t.Helper()
+
+		s, db := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("user-123")
+		base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i, m := range []struct {
+			id, filename, status string
+			size                 int64
+		}{
+			{id: "media-uploaded", filename: "c.jpg", status: "uploaded", size: 300},
+			{id: "media-processed", filename: "a.jpg", status: "processed", size: 100},
+			{id: "media-failed", filename: "d.jpg", status: "failed", size: 400},
+			{id: "media-deleted", filename: "b.jpg", status: "deleted", size: 200},
+			{id: "media-flagged", filename: "e.jpg", status: "flagged", size: 500},
+		} {
+			insertTestMedia(t, db, m.id, "user-123", m.filename, "image/jpeg", m.size, "/data/media/"+m.id, m.status)
+			if _, err := db.Exec(`UPDATE media_read_models SET uploaded_at = ? WHERE id = ?`, base.Add(time.Duration(i)*time.Hour), m.id); err != nil {
+				t.Fatalf("アップロード日時の更新に失敗: %v", err)
+			}
+		}
+		return s
+	}
+
+	listIDs := func(t *testing.T, s *Server, userID, query string) (int, []string) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, userID, "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			return w.Code, nil
+		}
+
+		var resp struct {
+			Media []mediaResponse `json:"media"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		ids := make([]string, 0, len(resp.Media))
+		for _, m := range resp.Media {
+			ids = append(ids, m.ID)
+		}
+		return w.Code, ids
+	}
+
+	tests := []struct {
+		name       string
+		userID     string
+		query      string
+		wantStatus int
+		wantIDs    []string
+	}{
+		{
+			name:       "正常系_status省略時は削除済みと確認待ちを除きアップロード日時の新しい順に返す",
+			userID:     "user-123",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"media-failed", "media-processed", "media-uploaded"},
+		},
+		{
+			name:       "正常系_statusで絞り込む",
+			userID:     "user-123",
+			query:      "?status=processed",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"media-processed"},
+		},
+		{
+			name:       "正常系_statusはカンマ区切りで複数指定できる",
+			userID:     "user-123",
+			query:      "?status=uploaded,%20failed,uploaded",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"media-failed", "media-uploaded"},
+		},
+		{
+			name:       "正常系_管理者はstatusにdeletedを指定できる",
+			userID:     "user-123",
+			query:      "?status=deleted,processed&sort=filename_asc",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"media-processed", "media-deleted"},
+		},
+		{
+			name:       "正常系_アップロード日時の古い順に並べる",
+			userID:     "user-123",
+			query:      "?sort=uploaded_at_asc",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"media-uploaded", "media-processed", "media-failed"},
+		},
+		{
+			name:       "正常系_サイズの大きい順に並べる",
+			userID:     "user-123",
+			query:      "?sort=size_desc",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"media-failed", "media-uploaded", "media-processed"},
+		},
+		{
+			name:       "正常系_ファイル名の降順に並べる",
+			userID:     "user-123",
+			query:      "?status=processed,uploaded,failed&sort=filename_desc",
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"media-failed", "media-uploaded", "media-processed"},
+		},
+		{
+			name:       "異常系_不正なsortは400を返す",
+			userID:     "user-123",
+			query:      "?sort=uploaded_at",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "異常系_不正なstatusは400を返す",
+			userID:     "user-123",
+			query:      "?status=processed,flagged",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "異常系_空のstatusは400を返す",
+			userID:     "user-123",
+			query:      "?status=,",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "異常系_一般ユーザーがstatusにdeletedを指定すると403を返す",
+			userID:     "user-456",
+			query:      "?status=deleted",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := setupListTestServer(t)
+			code, ids := listIDs(t, s, tt.userID, tt.query)
+			if code != tt.wantStatus {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", tt.wantStatus, code)
+			}
+			if tt.wantStatus == http.StatusOK && !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("期待するメディア %v, 実際のメディア %v", tt.wantIDs, ids)
+			}
+		})
+	}
+}
+
+// TestHandleListMedia_Cursor はメディア一覧のcursorによるページングを検証する。
+func TestHandleListMedia_Cursor(t *testing.T) {
+	t.Parallel()
+
+	// setupCursorTestServer はアップロード日時が同じメディアを含む5件のメディアを持つテスト用サーバーを作成する。
+	setupCursorTestServer := func(t *testing.T) *Server {
+		t.Helper()
+
+		s, db := setupTestQueryServer(t)
+		base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		insertStaleMedia(t, db, "media-1", "processed", base)
+		insertStaleMedia(t, db, "media-2", "processed", base.Add(time.Hour))
+		insertStaleMedia(t, db, "media-3", "uploaded", base.Add(time.Hour))
+		insertStaleMedia(t, db, "media-4", "processed", base.Add(2*time.Hour))
+		insertStaleMedia(t, db, "media-5", "deleted", base.Add(3*time.Hour))
+		insertStaleMedia(t, db, "media-6", "failed", base.Add(4*time.Hour))
+		return s
+	}
+
+	type listPage struct {
+		Media      []mediaResponse `json:"media"`
+		Count      int             `json:"count"`
+		NextCursor *string         `json:"next_cursor"`
+	}
+
+	getPage := func(t *testing.T, s *Server, query string) (int, listPage) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "user-123", "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		var page listPage
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+		}
+		return w.Code, page
+	}
+
+	// collect はnext_cursorがnullになるまでページを辿り、取得したメディアIDを順に返す。
+	collect := func(t *testing.T, s *Server, query string) []string {
+		t.Helper()
+
+		var ids []string
+		cursor := ""
+		for range 10 {
+			q := query
+			if cursor != "" {
+				q += "&cursor=" + url.QueryEscape(cursor)
+			}
+			code, page := getPage(t, s, q)
+			if code != http.StatusOK {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+			}
+			if page.Count != len(page.Media) {
+				t.Errorf("countが件数と一致しません: count=%d, 件数=%d", page.Count, len(page.Media))
+			}
+			for _, m := range page.Media {
+				ids = append(ids, m.ID)
+			}
+			if page.NextCursor == nil {
+				return ids
+			}
+			cursor = *page.NextCursor
+		}
+		t.Fatal("next_cursorがnullになりません")
+		return nil
+	}
+
+	t.Run("正常系_アップロード日時の新しい順に全件を重複なく辿れる", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupCursorTestServer(t)
+		want := []string{"media-6", "media-4", "media-2", "media-3", "media-1"}
+		if got := collect(t, s, "?limit=2"); !slices.Equal(got, want) {
+			t.Errorf("期待するメディア %v, 実際のメディア %v", want, got)
+		}
+	})
+
+	t.Run("正常系_アップロード日時の古い順に全件を重複なく辿れる", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupCursorTestServer(t)
+		want := []string{"media-1", "media-2", "media-3", "media-4", "media-6"}
+		if got := collect(t, s, "?sort=uploaded_at_asc&limit=2"); !slices.Equal(got, want) {
+			t.Errorf("期待するメディア %v, 実際のメディア %v", want, got)
+		}
+	})
+
+	t.Run("正常系_件数がlimitちょうどの場合はnext_cursorがnullになる", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupCursorTestServer(t)
+		code, page := getPage(t, s, "?limit=5")
+		if code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		if len(page.Media) != 5 || page.NextCursor != nil {
+			t.Errorf("全件とnullのnext_cursorを返すこと: 件数=%d, next_cursor=%v", len(page.Media), page.NextCursor)
+		}
+	})
+
+	t.Run("正常系_limitとcursorを省略した場合は全件を返しnext_cursorはnull", func(t *testing.T) {
+		t.Parallel()
+
+		s := setupCursorTestServer(t)
+		code, page := getPage(t, s, "?sort=size_asc")
+		if code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		if len(page.Media) != 5 || page.NextCursor != nil {
+			t.Errorf("全件とnullのnext_cursorを返すこと: 件数=%d, next_cursor=%v", len(page.Media), page.NextCursor)
+		}
+	})
+
+	errorTests := []struct {
+		name  string
+		query string
+	}{
+		{name: "異常系_base64でないcursorは400を返す", query: "?cursor=%21%21%21"},
+		{name: "異常系_JSONでないcursorは400を返す", query: "?cursor=" + base64.RawURLEncoding.EncodeToString([]byte("not-json"))},
+		{name: "異常系_IDのないcursorは400を返す", query: "?cursor=" + base64.RawURLEncoding.EncodeToString([]byte(`{"uploaded_at":"2026-01-01T00:00:00Z"}`))},
+		{name: "異常系_アップロード日時以外のsortでlimitを指定すると400を返す", query: "?sort=size_desc&limit=2"},
+		{name: "異常系_不正なlimitは400を返す", query: "?limit=0"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := setupCursorTestServer(t)
+			if code, _ := getPage(t, s, tt.query); code != http.StatusBadRequest {
+				t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusBadRequest, code)
+			}
+		})
+	}
+}
+
 func TestHandleGetMedia(t *testing.T) {
 	t.Parallel()
 
@@ -247,6 +552,37 @@ func TestHandleGetMedia(t *testing.T) {
 		}
 	})
 
+	t.Run("正常系_AcceptにMessagePackを指定した場合はMessagePackで返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		insertTestMedia(t, db, "media-msgpack-1", "user-123", "packed.jpg", "image/jpeg", 2048, "/data/media/media-msgpack-1/packed.jpg", "processed")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media/media-msgpack-1", nil)
+		token := generateTestToken(t, "user-123", "test@example.com")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/msgpack")
+
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/msgpack") {
+			t.Errorf("期待するContent-Type application/msgpack, 実際のContent-Type %q", got)
+		}
+
+		var resp mediaResponse
+		var mh codec.MsgpackHandle
+		if err := codec.NewDecoderBytes(w.Body.Bytes(), &mh).Decode(&resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+		if resp.ID != "media-msgpack-1" || resp.Filename != "packed.jpg" || resp.Size != 2048 {
+			t.Errorf("期待するメディア media-msgpack-1/packed.jpg/2048, 実際のメディア %s/%s/%d", resp.ID, resp.Filename, resp.Size)
+		}
+	})
+
 	t.Run("異常系_存在しないIDの場合404を返す", func(t *testing.T) {
 		t.Parallel()
 
@@ -314,6 +650,62 @@ func TestHandleSearchMedia(t *testing.T) {
 		}
 	})
 
+	t.Run("正常系_日本語のファイル名を表記の違いを吸収して検索できる", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+
+		insertTestMedia(t, db, "ja-1", "user-123", "サンセット_海.jpg", "image/jpeg", 1024, "/data/media/ja-1/サンセット_海.jpg", "uploaded")
+		insertTestMedia(t, db, "ja-2", "user-123", "ｻﾝｾｯﾄ_山.png", "image/png", 2048, "/data/media/ja-2/ｻﾝｾｯﾄ_山.png", "processed")
+		insertTestMedia(t, db, "ja-3", "user-123", "Ｓｕｎｓｅｔ.jpg", "image/jpeg", 512, "/data/media/ja-3/Ｓｕｎｓｅｔ.jpg", "uploaded")
+		insertTestMedia(t, db, "ja-4", "user-123", "さくら.jpg", "image/jpeg", 256, "/data/media/ja-4/さくら.jpg", "uploaded")
+
+		tests := []struct {
+			name    string
+			q       string
+			wantIDs []string
+		}{
+			{name: "半角カタカナで全角カタカナがヒットする", q: "ｻﾝｾｯﾄ", wantIDs: []string{"ja-1", "ja-2"}},
+			{name: "全角カタカナで半角カタカナがヒットする", q: "サンセット", wantIDs: []string{"ja-1", "ja-2"}},
+			{name: "ひらがなでカタカナがヒットする", q: "さんせっと", wantIDs: []string{"ja-1", "ja-2"}},
+			{name: "カタカナでひらがながヒットする", q: "サクラ", wantIDs: []string{"ja-4"}},
+			{name: "半角小文字で全角大文字がヒットする", q: "sunset", wantIDs: []string{"ja-3"}},
+		}
+
+		token := generateTestToken(t, "user-123", "test@example.com")
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/media/search?q="+url.QueryEscape(tt.q), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", tt.name, http.StatusOK, w.Code, w.Body.String())
+			}
+
+			var resp struct {
+				Media []mediaResponse `json:"media"`
+				Query string          `json:"query"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("%s: レスポンスのデシリアライズに失敗: %v", tt.name, err)
+			}
+
+			got := make([]string, 0, len(resp.Media))
+			for _, m := range resp.Media {
+				got = append(got, m.ID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("%s: 期待するID %v, 実際のID %v", tt.name, tt.wantIDs, got)
+			}
+			if resp.Query != tt.q {
+				t.Errorf("%s: queryは入力のまま返すべき: got %q, want %q", tt.name, resp.Query, tt.q)
+			}
+		}
+	})
+
 	t.Run("正常系_ヒットしない検索の場合空の結果を返す", func(t *testing.T) {
 		t.Parallel()
 
@@ -341,6 +733,95 @@ func TestHandleSearchMedia(t *testing.T) {
 		}
 	})
 
+	t.Run("正常系_メディアの種類とアップロード日時の範囲で絞り込める", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+
+		insertTestMedia(t, db, "filter-1", "user-123", "trip_2024_01.jpg", "image/jpeg", 1024, "/data/media/filter-1/a.jpg", "uploaded")
+		insertTestMedia(t, db, "filter-2", "user-123", "trip_2024_02.mp4", "video/mp4", 2048, "/data/media/filter-2/b.mp4", "processed")
+		insertTestMedia(t, db, "filter-3", "user-123", "trip_2024_03.png", "image/png", 512, "/data/media/filter-3/c.png", "uploaded")
+		for id, uploadedAt := range map[string]time.Time{
+			"filter-1": time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+			"filter-2": time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC),
+			"filter-3": time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+		} {
+			if _, err := db.Exec(`UPDATE media_read_models SET uploaded_at = ? WHERE id = ?`, uploadedAt, id); err != nil {
+				t.Fatalf("アップロード日時の更新に失敗: %v", err)
+			}
+		}
+
+		tests := []struct {
+			name    string
+			query   string
+			wantIDs []string
+		}{
+			{name: "content_type=imageで画像のみ", query: "&content_type=image", wantIDs: []string{"filter-1", "filter-3"}},
+			{name: "content_type=videoで動画のみ", query: "&content_type=video", wantIDs: []string{"filter-2"}},
+			{name: "fromとtoの範囲（両端を含む）", query: "&from=2024-02-10T00:00:00Z&to=2024-03-10T00:00:00Z", wantIDs: []string{"filter-2", "filter-3"}},
+			{name: "タイムゾーン付きのto", query: "&to=" + url.QueryEscape("2024-02-10T09:00:00+09:00"), wantIDs: []string{"filter-1", "filter-2"}},
+			{name: "種類と期間の組み合わせ", query: "&content_type=image&from=2024-02-01T00:00:00Z", wantIDs: []string{"filter-3"}},
+		}
+
+		token := generateTestToken(t, "user-123", "test@example.com")
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/media/search?q=trip"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", tt.name, http.StatusOK, w.Code, w.Body.String())
+			}
+
+			var resp struct {
+				Media []mediaResponse `json:"media"`
+				Count int             `json:"count"`
+				Query string          `json:"query"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("%s: レスポンスのデシリアライズに失敗: %v", tt.name, err)
+			}
+
+			got := make([]string, 0, len(resp.Media))
+			for _, m := range resp.Media {
+				got = append(got, m.ID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("%s: 期待するID %v, 実際のID %v", tt.name, tt.wantIDs, got)
+			}
+			if resp.Count != len(tt.wantIDs) || resp.Query != "trip" {
+				t.Errorf("%s: count・queryが不正: count=%d, query=%q", tt.name, resp.Count, resp.Query)
+			}
+		}
+	})
+
+	t.Run("異常系_不正な絞り込み条件の場合400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := setupTestQueryServer(t)
+		token := generateTestToken(t, "user-123", "test@example.com")
+
+		for _, query := range []string{
+			"content_type=audio",
+			"from=2024-01-01",
+			"to=not-a-date",
+			"from=2024-03-01T00:00:00Z&to=2024-02-01T00:00:00Z",
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/media/search?q=trip&"+query, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: 期待するステータスコード %d, 実際のステータスコード %d, body: %s", query, http.StatusBadRequest, w.Code, w.Body.String())
+			}
+		}
+	})
+
 	t.Run("異常系_検索クエリが指定されていない場合400を返す", func(t *testing.T) {
 		t.Parallel()
 
@@ -497,6 +978,98 @@ func TestToMediaResponse(t *testing.T) {
 	})
 }
 
+// TestMediaResponseNullFields は値のないフィールドをキーを省略せずnullとして出力するAPI契約を検証する。
+func TestMediaResponseNullFields(t *testing.T) {
+	t.Parallel()
+
+	nullableKeys := []string{"thumbnail_path", "width", "height", "duration_seconds"}
+	now := time.Now().UTC()
+	unprocessed := mediadb.MediaReadModel{
+		ID:          "media-uploaded",
+		UserID:      "user-123",
+		Filename:    "clip.mp4",
+		ContentType: "video/mp4",
+		Size:        1024,
+		StoragePath: "/data/media/media-uploaded/clip.mp4",
+		Status:      "uploaded",
+		UploadedAt:  now,
+		UpdatedAt:   now,
+	}
+	processedImage := unprocessed
+	processedImage.ID = "media-processed"
+	processedImage.ContentType = "image/png"
+	processedImage.ThumbnailPath = sql.NullString{String: "/data/media/media-processed/thumbnail.jpg", Valid: true}
+	processedImage.Width = sql.NullInt64{Int64: 800, Valid: true}
+	processedImage.Height = sql.NullInt64{Int64: 600, Valid: true}
+	processedImage.Status = "processed"
+
+	// encoders はレスポンスの形式ごとに、レスポンスをエンコードして汎用のmapにデコードする。
+	encoders := map[string]func(t *testing.T, resp mediaResponse) map[string]any{
+		"JSON": func(t *testing.T, resp mediaResponse) map[string]any {
+			t.Helper()
+			b, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatalf("JSONへの変換に失敗: %v", err)
+			}
+			var m map[string]any
+			if err := json.Unmarshal(b, &m); err != nil {
+				t.Fatalf("JSONのデコードに失敗: %v", err)
+			}
+			return m
+		},
+		"MessagePack": func(t *testing.T, resp mediaResponse) map[string]any {
+			t.Helper()
+			var mh codec.MsgpackHandle
+			mh.RawToString = true
+			var b []byte
+			if err := codec.NewEncoderBytes(&b, &mh).Encode(resp); err != nil {
+				t.Fatalf("MessagePackへの変換に失敗: %v", err)
+			}
+			var m map[string]any
+			if err := codec.NewDecoderBytes(b, &mh).Decode(&m); err != nil {
+				t.Fatalf("MessagePackのデコードに失敗: %v", err)
+			}
+			return m
+		},
+	}
+
+	for format, encode := range encoders {
+		t.Run("正常系_"+format+"_処理完了前のメディアは値のないフィールドをnullとして出力する", func(t *testing.T) {
+			t.Parallel()
+
+			m := encode(t, toMediaResponse(unprocessed))
+			for _, key := range nullableKeys {
+				v, ok := m[key]
+				if !ok {
+					t.Errorf("%sのキーが省略されています", key)
+					continue
+				}
+				if v != nil {
+					t.Errorf("%sはnullであるべき、実際は %v", key, v)
+				}
+			}
+			if m["status"] != "uploaded" {
+				t.Errorf("期待するstatus uploaded, 実際のstatus %v", m["status"])
+			}
+		})
+
+		t.Run("正常系_"+format+"_処理済みの画像は値のあるフィールドだけ値を持ち、動画の長さはnull", func(t *testing.T) {
+			t.Parallel()
+
+			m := encode(t, toMediaResponse(processedImage))
+			for _, key := range []string{"thumbnail_path", "width", "height"} {
+				if m[key] == nil {
+					t.Errorf("%sに値がありません", key)
+				}
+			}
+			v, ok := m["duration_seconds"]
+			if !ok || v != nil {
+				t.Errorf("duration_secondsはキーがありnullであるべき、実際は %v (キーあり=%v)", v, ok)
+			}
+		})
+	}
+}
+
 func TestToMediaResponses(t *testing.T) {
 	t.Parallel()
 
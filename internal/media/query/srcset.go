@@ -0,0 +1,99 @@
+package query
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// generatedThumbnailSizes はmedia-commandが生成するサムネイルの一辺の長さ（ピクセル）の一覧。
+// media-commandの既定のthumbnailSizeと一致させる。サムネイルは常に正方形で出力される。
+var generatedThumbnailSizes = []int64{200}
+
+// srcsetCandidate は<img srcset>の候補1件分の情報。
+type srcsetCandidate struct {
+	// URL は画像の取得先URL。
+	URL string `json:"url"`
+	// Width は画像の幅（ピクセル）。
+	Width int64 `json:"width"`
+	// Height は画像の高さ（ピクセル）。
+	Height int64 `json:"height"`
+	// Descriptor はsrcset属性の幅記述子（例: 200w）。
+	Descriptor string `json:"descriptor"`
+}
+
+// srcsetResponse はレスポンシブ画像セットのJSONレスポンス構造。
+type srcsetResponse struct {
+	// MediaID はメディアの一意識別子。
+	MediaID string `json:"media_id"`
+	// Candidates は幅の昇順に並べた画像候補の一覧。
+	Candidates []srcsetCandidate `json:"candidates"`
+	// Srcset は<img srcset>にそのまま設定できる文字列。
+	Srcset string `json:"srcset"`
+}
+
+// buildSrcset は生成済みのサムネイルサイズからレスポンシブ画像セットを組み立てる。
+func buildSrcset(mediaID string, sizes []int64) srcsetResponse {
+	candidates := make([]srcsetCandidate, 0, len(sizes))
+	entries := make([]string, 0, len(sizes))
+	for _, size := range sizes {
+		candidate := srcsetCandidate{
+			URL:        fmt.Sprintf("/api/v1/media/%s/thumbnail", mediaID),
+			Width:      size,
+			Height:     size,
+			Descriptor: fmt.Sprintf("%dw", size),
+		}
+		candidates = append(candidates, candidate)
+		entries = append(entries, candidate.URL+" "+candidate.Descriptor)
+	}
+
+	return srcsetResponse{
+		MediaID:    mediaID,
+		Candidates: candidates,
+		Srcset:     strings.Join(entries, ", "),
+	}
+}
+
+// handleSrcset は指定されたメディアのレスポンシブ画像セットを返すハンドラ。
+// メディアの所有者のみが取得でき、サムネイル生成前のメディアには409を返す。
+func (s *Server) handleSrcset() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+
+		mediaID := c.Param("id")
+		model, err := s.queries.GetMediaByID(c.Request.Context(), mediaID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "メディアが見つかりません"})
+				return
+			}
+			log.Printf("メディア詳細取得エラー: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "メディア詳細の取得に失敗しました"})
+			return
+		}
+
+		if model.Status == "deleted" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "メディアが見つかりません"})
+			return
+		}
+		if model.UserID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "このメディアへのアクセス権がありません"})
+			return
+		}
+		if !model.ThumbnailPath.Valid {
+			c.JSON(http.StatusConflict, gin.H{"error": "サムネイルが生成されていません"})
+			return
+		}
+
+		c.JSON(http.StatusOK, buildSrcset(model.ID, generatedThumbnailSizes))
+	}
+}
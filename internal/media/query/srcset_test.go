@@ -0,0 +1,75 @@
+package query
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleSrcset(t *testing.T) {
+	t.Parallel()
+
+	s, db := setupTestQueryServer(t)
+
+	insertTestMedia(t, db, "media-srcset-1", "user-123", "sunset.jpg", "image/jpeg", 4096, "/data/media/media-srcset-1/sunset.jpg", "processed")
+	if _, err := db.Exec(
+		`UPDATE media_read_models SET thumbnail_path = ?, width = ?, height = ? WHERE id = ?`,
+		"/data/media/media-srcset-1/thumbnail.jpg", 1920, 1080, "media-srcset-1",
+	); err != nil {
+		t.Fatalf("テストデータの更新に失敗: %v", err)
+	}
+	insertTestMedia(t, db, "media-srcset-2", "user-123", "pending.jpg", "image/jpeg", 2048, "/data/media/media-srcset-2/pending.jpg", "uploaded")
+	insertTestMedia(t, db, "media-srcset-3", "user-123", "deleted.jpg", "image/jpeg", 2048, "/data/media/media-srcset-3/deleted.jpg", "deleted")
+
+	tests := []struct {
+		name       string
+		mediaID    string
+		userID     string
+		wantStatus int
+	}{
+		{name: "正常系_所有者は生成済みサイズを取得できる", mediaID: "media-srcset-1", userID: "user-123", wantStatus: http.StatusOK},
+		{name: "異常系_所有者以外は403を返す", mediaID: "media-srcset-1", userID: "user-999", wantStatus: http.StatusForbidden},
+		{name: "異常系_サムネイル生成前は409を返す", mediaID: "media-srcset-2", userID: "user-123", wantStatus: http.StatusConflict},
+		{name: "異常系_削除済みメディアは404を返す", mediaID: "media-srcset-3", userID: "user-123", wantStatus: http.StatusNotFound},
+		{name: "異常系_存在しないメディアは404を返す", mediaID: "missing", userID: "user-123", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/media/"+tt.mediaID+"/srcset", nil)
+			req.Header.Set("Authorization", "Bearer "+generateTestToken(t, tt.userID, "test@example.com"))
+
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp srcsetResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+			if resp.MediaID != tt.mediaID {
+				t.Errorf("期待するMediaID %q, 実際のMediaID %q", tt.mediaID, resp.MediaID)
+			}
+			if len(resp.Candidates) != len(generatedThumbnailSizes) {
+				t.Fatalf("期待する候補数 %d, 実際の候補数 %d", len(generatedThumbnailSizes), len(resp.Candidates))
+			}
+			for i, size := range generatedThumbnailSizes {
+				got := resp.Candidates[i]
+				if got.Width != size || got.Height != size {
+					t.Errorf("候補%d: 期待するサイズ %dx%d, 実際のサイズ %dx%d", i, size, size, got.Width, got.Height)
+				}
+			}
+			wantSrcset := "/api/v1/media/media-srcset-1/thumbnail 200w"
+			if resp.Srcset != wantSrcset {
+				t.Errorf("期待するsrcset %q, 実際のsrcset %q", wantSrcset, resp.Srcset)
+			}
+		})
+	}
+}
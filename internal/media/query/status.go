@@ -0,0 +1,59 @@
+package query
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// mediaStatusResponse はメディアの処理状態のJSONレスポンス構造。
+// アップロード後に処理の完了をポーリングするクライアント向けに、詳細より軽量な項目だけを返す。
+type mediaStatusResponse struct {
+	// Status はメディアの状態（uploaded, processed, failed, flagged）。
+	Status string `json:"status"`
+	// UpdatedAt はRead Model更新日時。
+	UpdatedAt string `json:"updated_at"`
+}
+
+// handleGetStatus は指定されたメディアの処理状態を返すハンドラ。
+// メディアの所有者のみが取得でき、削除済みのメディアには404を返す。
+// ポーリングで常に最新の状態を取得できるよう、レスポンスをキャッシュさせない。
+func (s *Server) handleGetStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+
+		mediaID := c.Param("id")
+		row, err := s.queries.GetMediaStatus(c.Request.Context(), mediaID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "メディアが見つかりません"})
+				return
+			}
+			log.Printf("メディア状態取得エラー: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "メディア状態の取得に失敗しました"})
+			return
+		}
+
+		if row.Status == "deleted" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "メディアが見つかりません"})
+			return
+		}
+		if row.UserID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "このメディアへのアクセス権がありません"})
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		middleware.Respond(c, http.StatusOK, mediaStatusResponse{
+			Status:    row.Status,
+			UpdatedAt: row.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+}
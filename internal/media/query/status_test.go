@@ -0,0 +1,120 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nao1215/micro/pkg/event"
+)
+
+func TestHandleGetStatus(t *testing.T) {
+	t.Parallel()
+
+	// getStatus はメディアの処理状態を取得する。
+	getStatus := func(t *testing.T, s *Server, mediaID, userID string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/media/"+mediaID+"/status", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(t, userID, "test@example.com"))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("正常系_イベントの適用に合わせてuploadedからprocessedに遷移する", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := setupTestQueryServer(t)
+		p := NewProjector(s.db, "http://localhost:9999")
+		ctx := context.Background()
+
+		// assertStatus は処理状態がwantであることを検証する。
+		assertStatus := func(t *testing.T, want string) {
+			t.Helper()
+			w := getStatus(t, s, "media-status-1", "user-123")
+			if w.Code != http.StatusOK {
+				t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d, body: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("期待するCache-Control %q, 実際のCache-Control %q", "no-store", got)
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+			if len(resp) != 2 {
+				t.Errorf("期待するキー status, updated_at, 実際のレスポンス %v", resp)
+			}
+			if resp["status"] != want {
+				t.Errorf("期待するstatus %q, 実際のstatus %v", want, resp["status"])
+			}
+			updatedAt, _ := resp["updated_at"].(string)
+			if _, err := time.Parse(time.RFC3339, updatedAt); err != nil {
+				t.Errorf("updated_atがRFC3339形式ではない: %q", updatedAt)
+			}
+		}
+
+		if err := p.processEvent(ctx, p.queries, eventStoreResponse{
+			ID:            "event-status-1",
+			AggregateID:   "media-status-1",
+			AggregateType: string(event.AggregateTypeMedia),
+			EventType:     string(event.TypeMediaUploaded),
+			Data: makeEventJSON(t, event.MediaUploadedData{
+				UserID:      "user-123",
+				Filename:    "sunset.jpg",
+				ContentType: "image/jpeg",
+				Size:        1024,
+				StoragePath: "/data/media/media-status-1/sunset.jpg",
+			}),
+			Version:   1,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		}); err != nil {
+			t.Fatalf("MediaUploadedの処理に失敗: %v", err)
+		}
+		assertStatus(t, "uploaded")
+
+		if err := p.processEvent(ctx, p.queries, eventStoreResponse{
+			ID:            "event-status-2",
+			AggregateID:   "media-status-1",
+			AggregateType: string(event.AggregateTypeMedia),
+			EventType:     string(event.TypeMediaProcessed),
+			Data: makeEventJSON(t, event.MediaProcessedData{
+				ThumbnailPath: "/data/media/media-status-1/thumbnail.jpg",
+				Width:         1920,
+				Height:        1080,
+			}),
+			Version:   2,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		}); err != nil {
+			t.Fatalf("MediaProcessedの処理に失敗: %v", err)
+		}
+		assertStatus(t, "processed")
+	})
+
+	t.Run("異常系_取得できない場合はエラーを返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		insertTestMedia(t, db, "media-status-owned", "user-123", "a.jpg", "image/jpeg", 1024, "/data/media/media-status-owned/a.jpg", "uploaded")
+		insertTestMedia(t, db, "media-status-deleted", "user-123", "b.jpg", "image/jpeg", 1024, "/data/media/media-status-deleted/b.jpg", "deleted")
+
+		tests := []struct {
+			name       string
+			mediaID    string
+			userID     string
+			wantStatus int
+		}{
+			{name: "所有者以外は403を返す", mediaID: "media-status-owned", userID: "user-999", wantStatus: http.StatusForbidden},
+			{name: "削除済みメディアは404を返す", mediaID: "media-status-deleted", userID: "user-123", wantStatus: http.StatusNotFound},
+			{name: "存在しないメディアは404を返す", mediaID: "missing", userID: "user-123", wantStatus: http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			if w := getStatus(t, s, tt.mediaID, tt.userID); w.Code != tt.wantStatus {
+				t.Errorf("%s: 期待するステータスコード %d, 実際のステータスコード %d", tt.name, tt.wantStatus, w.Code)
+			}
+		}
+	})
+}
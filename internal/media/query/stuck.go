@@ -0,0 +1,69 @@
+package query
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultStuckThresholdMinutes は処理が滞っているとみなすまでの既定の経過時間（分）。
+const defaultStuckThresholdMinutes = 30
+
+// stuckMediaResponse は処理が滞っているメディアのJSONレスポンス構造。
+type stuckMediaResponse struct {
+	mediaResponse
+	// ElapsedMinutes はアップロードからの経過時間（分）。
+	ElapsedMinutes int64 `json:"elapsed_minutes"`
+	// ReprocessRequestedAt は再処理ジョブで最後に再処理を依頼した日時。依頼していない場合はnull。
+	ReprocessRequestedAt *string `json:"reprocess_requested_at"`
+}
+
+// handleListStuckMedia はuploaded状態のまま一定時間以上経過したメディアの一覧を返すハンドラ。
+// クエリパラメータ threshold_minutes で経過時間（分、既定30）を指定する。
+// Sagaの停止などで処理パイプラインが詰まっていないかを運用者が確認するために使用し、
+// 検出されたメディアは POST /api/v1/admin/reprocess-pending で再処理を依頼できる。
+func (s *Server) handleListStuckMedia() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		threshold := int64(defaultStuckThresholdMinutes)
+		if v := c.Query("threshold_minutes"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "threshold_minutesは正の整数で指定してください"})
+				return
+			}
+			threshold = n
+		}
+
+		now := time.Now().UTC()
+		models, err := s.queries.ListStuckMedia(c.Request.Context(), now.Add(-time.Duration(threshold)*time.Minute))
+		if err != nil {
+			log.Printf("処理が滞っているメディアの取得エラー: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "処理が滞っているメディアの取得に失敗しました"})
+			return
+		}
+
+		responses := make([]stuckMediaResponse, 0, len(models))
+		for _, m := range models {
+			resp := stuckMediaResponse{
+				mediaResponse:  toMediaResponse(m),
+				ElapsedMinutes: int64(now.Sub(m.UploadedAt) / time.Minute),
+			}
+			if s.reprocessor != nil {
+				if at, ok := s.reprocessor.lastTriggered(m.ID); ok {
+					requestedAt := at.Format(time.RFC3339)
+					resp.ReprocessRequestedAt = &requestedAt
+				}
+			}
+			responses = append(responses, resp)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"media":             responses,
+			"count":             len(responses),
+			"threshold_minutes": threshold,
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package query
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stuckMediaListResponse は処理が滞っているメディア一覧のレスポンス。
+type stuckMediaListResponse struct {
+	Media            []stuckMediaResponse `json:"media"`
+	Count            int                  `json:"count"`
+	ThresholdMinutes int64                `json:"threshold_minutes"`
+}
+
+// getStuckMedia は処理が滞っているメディア一覧のエンドポイントをユーザーadmin-1として呼び出す。
+func getStuckMedia(t *testing.T, s *Server, query string) (int, stuckMediaListResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/stuck-media"+query, nil)
+	req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "admin-1", "admin@example.com"))
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	var resp stuckMediaListResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestHandleListStuckMedia(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常系_既定の閾値を超えたuploadedのメディアを古い順に返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		insertStaleMedia(t, db, "media-2h", "uploaded", time.Now().Add(-2*time.Hour))
+		insertStaleMedia(t, db, "media-1h", "uploaded", time.Now().Add(-time.Hour))
+		insertStaleMedia(t, db, "media-10m", "uploaded", time.Now().Add(-10*time.Minute))
+		insertStaleMedia(t, db, "media-done", "processed", time.Now().Add(-2*time.Hour))
+
+		code, resp := getStuckMedia(t, s, "")
+		if code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		if resp.ThresholdMinutes != defaultStuckThresholdMinutes {
+			t.Errorf("期待するthreshold_minutes %d, 実際 %d", defaultStuckThresholdMinutes, resp.ThresholdMinutes)
+		}
+		if resp.Count != 2 || len(resp.Media) != 2 {
+			t.Fatalf("期待する件数 2, 実際 count=%d len=%d", resp.Count, len(resp.Media))
+		}
+		if resp.Media[0].ID != "media-2h" || resp.Media[1].ID != "media-1h" {
+			t.Errorf("期待する順序 [media-2h media-1h], 実際 [%s %s]", resp.Media[0].ID, resp.Media[1].ID)
+		}
+		if resp.Media[0].ElapsedMinutes < 119 {
+			t.Errorf("期待するelapsed_minutes 119以上, 実際 %d", resp.Media[0].ElapsedMinutes)
+		}
+		if resp.Media[0].ReprocessRequestedAt != nil {
+			t.Errorf("再処理未依頼のreprocess_requested_atはnullであるべき, 実際 %v", *resp.Media[0].ReprocessRequestedAt)
+		}
+	})
+
+	t.Run("正常系_threshold_minutesで閾値を指定できる", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		insertStaleMedia(t, db, "media-1h", "uploaded", time.Now().Add(-time.Hour))
+		insertStaleMedia(t, db, "media-10m", "uploaded", time.Now().Add(-10*time.Minute))
+
+		code, resp := getStuckMedia(t, s, "?threshold_minutes=5")
+		if code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+		if resp.Count != 2 {
+			t.Errorf("期待する件数 2, 実際 %d", resp.Count)
+		}
+
+		_, resp = getStuckMedia(t, s, "?threshold_minutes=120")
+		if resp.Count != 0 || resp.Media == nil {
+			t.Errorf("期待する件数 0（空配列）, 実際 count=%d media=%v", resp.Count, resp.Media)
+		}
+	})
+
+	t.Run("正常系_再処理を依頼済みのメディアには依頼日時を含める", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		ts, _ := newMediaCommandMock(t, "")
+		s.reprocessor = newReprocessor(ts.URL)
+		s.reprocessor.interval = 0
+
+		insertStaleMedia(t, db, "media-stuck", "uploaded", time.Now().Add(-time.Hour))
+
+		if code, _ := postReprocessPending(t, s, ""); code != http.StatusOK {
+			t.Fatalf("再処理ジョブ: 期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, code)
+		}
+
+		_, resp := getStuckMedia(t, s, "")
+		if len(resp.Media) != 1 || resp.Media[0].ReprocessRequestedAt == nil {
+			t.Fatalf("再処理依頼済みのメディアにreprocess_requested_atが含まれていない: %+v", resp.Media)
+		}
+	})
+
+	t.Run("異常系_threshold_minutesが不正な場合は400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := setupTestQueryServer(t)
+		s.adminUserIDs = parseAdminUserIDs("admin-1")
+		for _, q := range []string{"?threshold_minutes=0", "?threshold_minutes=-1", "?threshold_minutes=abc"} {
+			if code, _ := getStuckMedia(t, s, q); code != http.StatusBadRequest {
+				t.Errorf("%s: 期待するステータスコード %d, 実際のステータスコード %d", q, http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("異常系_管理者以外は403を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := setupTestQueryServer(t)
+		insertStaleMedia(t, db, "media-2h", "uploaded", time.Now().Add(-2*time.Hour))
+
+		if code, _ := getStuckMedia(t, s, ""); code != http.StatusForbidden {
+			t.Errorf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusForbidden, code)
+		}
+	})
+}
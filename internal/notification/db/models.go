@@ -5,14 +5,34 @@
 package notificationdb
 
 import (
+	"database/sql"
 	"time"
 )
 
 type Notification struct {
+	ID           string
+	UserID       string
+	Title        string
+	Message      string
+	IsRead       int64
+	CreatedAt    time.Time
+	SnoozedUntil sql.NullTime
+}
+
+type NotificationDelivery struct {
+	ID             string
+	NotificationID string
+	Channel        string
+	Status         string
+	Error          sql.NullString
+	AttemptedAt    time.Time
+}
+
+type PushSubscription struct {
 	ID        string
 	UserID    string
-	Title     string
-	Message   string
-	IsRead    int64
+	Endpoint  string
+	P256dh    string
+	Auth      string
 	CreatedAt time.Time
 }
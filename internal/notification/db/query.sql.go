@@ -7,8 +7,29 @@ package notificationdb
 
 import (
 	"context"
+	"database/sql"
 )
 
+const countNotificationsByReadStatus = `-- name: CountNotificationsByReadStatus :one
+SELECT
+    COUNT(*) AS total,
+    CAST(COALESCE(SUM(CASE WHEN is_read = 0 THEN 1 ELSE 0 END), 0) AS INTEGER) AS unread
+FROM notifications
+WHERE user_id = ?
+`
+
+type CountNotificationsByReadStatusRow struct {
+	Total  int64
+	Unread int64
+}
+
+func (q *Queries) CountNotificationsByReadStatus(ctx context.Context, userID string) (CountNotificationsByReadStatusRow, error) {
+	row := q.db.QueryRowContext(ctx, countNotificationsByReadStatus, userID)
+	var i CountNotificationsByReadStatusRow
+	err := row.Scan(&i.Total, &i.Unread)
+	return i, err
+}
+
 const createNotification = `-- name: CreateNotification :exec
 INSERT INTO notifications (id, user_id, title, message, created_at)
 VALUES (?, ?, ?, ?, datetime('now'))
@@ -31,8 +52,42 @@ func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotification
 	return err
 }
 
+const createNotificationDelivery = `-- name: CreateNotificationDelivery :exec
+INSERT INTO notification_deliveries (id, notification_id, channel, status, error, attempted_at)
+VALUES (?, ?, ?, ?, ?, datetime('now'))
+`
+
+type CreateNotificationDeliveryParams struct {
+	ID             string
+	NotificationID string
+	Channel        string
+	Status         string
+	Error          sql.NullString
+}
+
+func (q *Queries) CreateNotificationDelivery(ctx context.Context, arg CreateNotificationDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, createNotificationDelivery,
+		arg.ID,
+		arg.NotificationID,
+		arg.Channel,
+		arg.Status,
+		arg.Error,
+	)
+	return err
+}
+
+const deletePushSubscription = `-- name: DeletePushSubscription :exec
+DELETE FROM push_subscriptions
+WHERE id = ?
+`
+
+func (q *Queries) DeletePushSubscription(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deletePushSubscription, id)
+	return err
+}
+
 const getNotificationByID = `-- name: GetNotificationByID :one
-SELECT id, user_id, title, message, is_read, created_at
+SELECT id, user_id, title, message, is_read, created_at, snoozed_until
 FROM notifications
 WHERE id = ?
 `
@@ -47,12 +102,50 @@ func (q *Queries) GetNotificationByID(ctx context.Context, id string) (Notificat
 		&i.Message,
 		&i.IsRead,
 		&i.CreatedAt,
+		&i.SnoozedUntil,
 	)
 	return i, err
 }
 
+const listDeliveriesByNotificationID = `-- name: ListDeliveriesByNotificationID :many
+SELECT id, notification_id, channel, status, error, attempted_at
+FROM notification_deliveries
+WHERE notification_id = ?
+ORDER BY attempted_at ASC, rowid ASC
+`
+
+func (q *Queries) ListDeliveriesByNotificationID(ctx context.Context, notificationID string) ([]NotificationDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listDeliveriesByNotificationID, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationDelivery
+	for rows.Next() {
+		var i NotificationDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.NotificationID,
+			&i.Channel,
+			&i.Status,
+			&i.Error,
+			&i.AttemptedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listNotificationsByUserID = `-- name: ListNotificationsByUserID :many
-SELECT id, user_id, title, message, is_read, created_at
+SELECT id, user_id, title, message, is_read, created_at, snoozed_until
 FROM notifications
 WHERE user_id = ?
 ORDER BY created_at DESC
@@ -74,6 +167,88 @@ func (q *Queries) ListNotificationsByUserID(ctx context.Context, userID string)
 			&i.Message,
 			&i.IsRead,
 			&i.CreatedAt,
+			&i.SnoozedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotificationsByUserIDWithLimit = `-- name: ListNotificationsByUserIDWithLimit :many
+SELECT id, user_id, title, message, is_read, created_at, snoozed_until
+FROM notifications
+WHERE user_id = ?
+ORDER BY created_at DESC
+LIMIT ?
+`
+
+type ListNotificationsByUserIDWithLimitParams struct {
+	UserID string
+	Limit  int64
+}
+
+func (q *Queries) ListNotificationsByUserIDWithLimit(ctx context.Context, arg ListNotificationsByUserIDWithLimitParams) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, listNotificationsByUserIDWithLimit, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Message,
+			&i.IsRead,
+			&i.CreatedAt,
+			&i.SnoozedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPushSubscriptionsByUserID = `-- name: ListPushSubscriptionsByUserID :many
+SELECT id, user_id, endpoint, p256dh, auth, created_at
+FROM push_subscriptions
+WHERE user_id = ?
+ORDER BY created_at ASC, rowid ASC
+`
+
+func (q *Queries) ListPushSubscriptionsByUserID(ctx context.Context, userID string) ([]PushSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, listPushSubscriptionsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PushSubscription
+	for rows.Next() {
+		var i PushSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Endpoint,
+			&i.P256dh,
+			&i.Auth,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -89,14 +264,20 @@ func (q *Queries) ListNotificationsByUserID(ctx context.Context, userID string)
 }
 
 const listUnreadNotifications = `-- name: ListUnreadNotifications :many
-SELECT id, user_id, title, message, is_read, created_at
+SELECT id, user_id, title, message, is_read, created_at, snoozed_until
 FROM notifications
 WHERE user_id = ? AND is_read = 0
+  AND (snoozed_until IS NULL OR snoozed_until <= ?)
 ORDER BY created_at DESC
 `
 
-func (q *Queries) ListUnreadNotifications(ctx context.Context, userID string) ([]Notification, error) {
-	rows, err := q.db.QueryContext(ctx, listUnreadNotifications, userID)
+type ListUnreadNotificationsParams struct {
+	UserID string
+	Now    sql.NullTime
+}
+
+func (q *Queries) ListUnreadNotifications(ctx context.Context, arg ListUnreadNotificationsParams) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, listUnreadNotifications, arg.UserID, arg.Now)
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +292,7 @@ func (q *Queries) ListUnreadNotifications(ctx context.Context, userID string) ([
 			&i.Message,
 			&i.IsRead,
 			&i.CreatedAt,
+			&i.SnoozedUntil,
 		); err != nil {
 			return nil, err
 		}
@@ -146,3 +328,68 @@ func (q *Queries) MarkAsRead(ctx context.Context, id string) error {
 	_, err := q.db.ExecContext(ctx, markAsRead, id)
 	return err
 }
+
+const snoozeNotification = `-- name: SnoozeNotification :exec
+UPDATE notifications
+SET snoozed_until = ?, is_read = 0
+WHERE id = ?
+`
+
+type SnoozeNotificationParams struct {
+	SnoozedUntil sql.NullTime
+	ID           string
+}
+
+func (q *Queries) SnoozeNotification(ctx context.Context, arg SnoozeNotificationParams) error {
+	_, err := q.db.ExecContext(ctx, snoozeNotification, arg.SnoozedUntil, arg.ID)
+	return err
+}
+
+const unsnoozeNotification = `-- name: UnsnoozeNotification :exec
+UPDATE notifications
+SET snoozed_until = NULL
+WHERE id = ?
+`
+
+func (q *Queries) UnsnoozeNotification(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, unsnoozeNotification, id)
+	return err
+}
+
+const upsertPushSubscription = `-- name: UpsertPushSubscription :one
+INSERT INTO push_subscriptions (id, user_id, endpoint, p256dh, auth, created_at)
+VALUES (?, ?, ?, ?, ?, datetime('now'))
+ON CONFLICT(endpoint) DO UPDATE SET
+    user_id = excluded.user_id,
+    p256dh = excluded.p256dh,
+    auth = excluded.auth
+RETURNING id, user_id, endpoint, p256dh, auth, created_at
+`
+
+type UpsertPushSubscriptionParams struct {
+	ID       string
+	UserID   string
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+func (q *Queries) UpsertPushSubscription(ctx context.Context, arg UpsertPushSubscriptionParams) (PushSubscription, error) {
+	row := q.db.QueryRowContext(ctx, upsertPushSubscription,
+		arg.ID,
+		arg.UserID,
+		arg.Endpoint,
+		arg.P256dh,
+		arg.Auth,
+	)
+	var i PushSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Endpoint,
+		&i.P256dh,
+		&i.Auth,
+		&i.CreatedAt,
+	)
+	return i, err
+}
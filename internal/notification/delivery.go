@@ -0,0 +1,147 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	notificationdb "github.com/nao1215/micro/internal/notification/db"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// deliveryChannel は通知の配信チャネル（in_app, email, webhook, web_push）。
+type deliveryChannel string
+
+// channelInApp はアプリ内通知（notificationsテーブルへの保存）の配信チャネル。
+const channelInApp deliveryChannel = "in_app"
+
+const (
+	// deliveryStatusSucceeded は配信に成功した試行の状態。
+	deliveryStatusSucceeded = "succeeded"
+	// deliveryStatusFailed は配信に失敗した試行の状態。
+	deliveryStatusFailed = "failed"
+)
+
+// errDeliverySkipped は通知先ユーザーがチャネルの宛先を登録していないなど、配信を試行しなかった場合にdelivererが返すエラー。
+// 配信の試行ではないため、notification_deliveriesテーブルには記録しない。
+var errDeliverySkipped = errors.New("配信対象がないため配信をスキップしました")
+
+// deliverer は通知をアプリ外のチャネルへ配信する。
+// メールやWebhookの配信機能はこのインターフェースを実装してServer.deliverersに登録する。
+// 配信の試行ごとに結果がnotification_deliveriesテーブルに記録される。
+type deliverer interface {
+	// channel は配信チャネルを返す。
+	channel() deliveryChannel
+	// deliver は通知を配信する。配信に失敗した場合はエラーを返す。
+	deliver(ctx context.Context, n notificationdb.Notification) error
+}
+
+// deliver は登録された全チャネルへ通知を配信し、チャネルごとの試行結果を記録する。
+// 一部のチャネルで失敗しても他のチャネルへの配信は継続する。
+func (s *Server) deliver(ctx context.Context, n notificationdb.Notification) {
+	for _, d := range s.deliverers {
+		err := d.deliver(ctx, n)
+		if errors.Is(err, errDeliverySkipped) {
+			continue
+		}
+		if err != nil {
+			log.Printf("通知の配信に失敗: notification_id=%s, channel=%s, error=%v", n.ID, d.channel(), err)
+		}
+		s.recordDelivery(ctx, n.ID, d.channel(), err)
+	}
+}
+
+// recordDelivery は配信の試行結果を記録する。deliverErrがnilの場合は成功として記録する。
+// 記録に失敗しても通知の送信自体は失敗扱いにしない。
+func (s *Server) recordDelivery(ctx context.Context, notificationID string, channel deliveryChannel, deliverErr error) {
+	params := notificationdb.CreateNotificationDeliveryParams{
+		ID:             uuid.New().String(),
+		NotificationID: notificationID,
+		Channel:        string(channel),
+		Status:         deliveryStatusSucceeded,
+	}
+	if deliverErr != nil {
+		params.Status = deliveryStatusFailed
+		params.Error = sql.NullString{String: deliverErr.Error(), Valid: true}
+	}
+	if err := s.queries.CreateNotificationDelivery(ctx, params); err != nil {
+		log.Printf("配信状況の記録に失敗: notification_id=%s, channel=%s, error=%v", notificationID, channel, err)
+	}
+}
+
+// deliveryResponse は配信試行のJSONレスポンス構造。
+type deliveryResponse struct {
+	// Channel は配信チャネル（in_app, email, webhook, web_push）。
+	Channel string `json:"channel"`
+	// Status は配信結果（succeeded, failed）。
+	Status string `json:"status"`
+	// AttemptedAt は配信を試行した日時（RFC3339形式）。
+	AttemptedAt string `json:"attempted_at"`
+	// Error は配信失敗時のエラー内容。成功時はnull。
+	Error *string `json:"error"`
+}
+
+// toDeliveryResponses はDB行のスライスをJSONレスポンスのスライスに変換する。
+func toDeliveryResponses(deliveries []notificationdb.NotificationDelivery) []deliveryResponse {
+	responses := make([]deliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		resp := deliveryResponse{
+			Channel:     d.Channel,
+			Status:      d.Status,
+			AttemptedAt: d.AttemptedAt.Format(time.RFC3339),
+		}
+		if d.Error.Valid {
+			resp.Error = &d.Error.String
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// handleListDeliveries は指定された通知のチャネルごとの配信状況を返すハンドラ。
+// 通知の所有者のみが取得できる。
+func (s *Server) handleListDeliveries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+
+		notificationID := c.Param("id")
+		if notificationID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "通知IDが必要です"})
+			return
+		}
+
+		// 通知の存在確認と所有者チェック
+		n, err := s.queries.GetNotificationByID(c.Request.Context(), notificationID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "通知が見つかりません"})
+			log.Printf("通知取得エラー: %v", err)
+			return
+		}
+
+		if n.UserID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "この通知を操作する権限がありません"})
+			return
+		}
+
+		deliveries, err := s.queries.ListDeliveriesByNotificationID(c.Request.Context(), notificationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "配信状況の取得に失敗しました"})
+			log.Printf("配信状況取得エラー: %v", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"notification_id": notificationID,
+			"deliveries":      toDeliveryResponses(deliveries),
+		})
+	}
+}
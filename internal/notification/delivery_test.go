@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	notificationdb "github.com/nao1215/micro/internal/notification/db"
+)
+
+// fakeDeliverer はテスト用の配信チャネル。errを返して配信失敗を再現する。
+type fakeDeliverer struct {
+	ch  deliveryChannel
+	err error
+	// delivered は配信を試行した通知のID。
+	delivered []string
+}
+
+func (f *fakeDeliverer) channel() deliveryChannel { return f.ch }
+
+func (f *fakeDeliverer) deliver(_ context.Context, n notificationdb.Notification) error {
+	f.delivered = append(f.delivered, n.ID)
+	return f.err
+}
+
+// TestHandleListDeliveries は配信状況取得ハンドラのテスト。
+func TestHandleListDeliveries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("チャネルごとの配信結果を返す", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+		email := &fakeDeliverer{ch: "email"}
+		webhook := &fakeDeliverer{ch: "webhook", err: errors.New("接続がタイムアウトしました")}
+		s.deliverers = []deliverer{email, webhook}
+
+		body := map[string]string{
+			"user_id": "user-1",
+			"title":   "アップロード完了",
+			"message": "メディアのアップロードが完了しました",
+		}
+		w := doRequest(router, http.MethodPost, "/api/v1/internal/send", "system", body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("通知の送信に失敗: status=%d, body=%s", w.Code, w.Body.String())
+		}
+		notificationID, _ := parseJSON(t, w)["id"].(string)
+
+		if len(email.delivered) != 1 || email.delivered[0] != notificationID {
+			t.Errorf("メールの配信対象: got %v, want [%s]", email.delivered, notificationID)
+		}
+
+		w = doRequest(router, http.MethodGet, "/api/v1/notifications/"+notificationID+"/deliveries", "user-1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		result := parseJSON(t, w)
+		if result["notification_id"] != notificationID {
+			t.Errorf("notification_id: got %v, want %s", result["notification_id"], notificationID)
+		}
+		deliveries, _ := result["deliveries"].([]any)
+		if len(deliveries) != 3 {
+			t.Fatalf("配信レコードの数: got %d, want 3", len(deliveries))
+		}
+
+		want := []struct {
+			channel string
+			status  string
+			hasErr  bool
+		}{
+			{channel: "in_app", status: "succeeded"},
+			{channel: "email", status: "succeeded"},
+			{channel: "webhook", status: "failed", hasErr: true},
+		}
+		for i, w := range want {
+			d, _ := deliveries[i].(map[string]any)
+			if d["channel"] != w.channel || d["status"] != w.status {
+				t.Errorf("deliveries[%d]: got channel=%v status=%v, want channel=%s status=%s", i, d["channel"], d["status"], w.channel, w.status)
+			}
+			if (d["error"] != nil) != w.hasErr {
+				t.Errorf("deliveries[%d].error: got %v", i, d["error"])
+			}
+			if d["attempted_at"] == "" {
+				t.Errorf("deliveries[%d].attempted_atが空です", i)
+			}
+		}
+		if d, _ := deliveries[2].(map[string]any); d["error"] != "接続がタイムアウトしました" {
+			t.Errorf("webhookのerror: got %v", d["error"])
+		}
+	})
+
+	t.Run("他ユーザーの通知の配信状況は取得できない", func(t *testing.T) {
+		t.Parallel()
+		_, router := setupTestServer(t)
+
+		body := map[string]string{"user_id": "user-1", "title": "t", "message": "m"}
+		w := doRequest(router, http.MethodPost, "/api/v1/internal/send", "system", body)
+		notificationID, _ := parseJSON(t, w)["id"].(string)
+
+		w = doRequest(router, http.MethodGet, "/api/v1/notifications/"+notificationID+"/deliveries", "user-2", nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusForbidden)
+		}
+	})
+
+	t.Run("存在しない通知はNotFound", func(t *testing.T) {
+		t.Parallel()
+		_, router := setupTestServer(t)
+
+		w := doRequest(router, http.MethodGet, "/api/v1/notifications/missing/deliveries", "user-1", nil)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("ユーザーIDがない場合はUnauthorized", func(t *testing.T) {
+		t.Parallel()
+		_, router := setupTestServer(t)
+
+		w := doRequest(router, http.MethodGet, "/api/v1/notifications/any/deliveries", "", nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+}
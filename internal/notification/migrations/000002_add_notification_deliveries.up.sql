@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id TEXT PRIMARY KEY,
+    notification_id TEXT NOT NULL REFERENCES notifications(id),
+    channel TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT,
+    attempted_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_notification_id
+    ON notification_deliveries(notification_id);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_failed
+    ON notification_deliveries(channel, attempted_at) WHERE status = 'failed';
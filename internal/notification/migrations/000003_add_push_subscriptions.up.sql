@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    endpoint TEXT NOT NULL,
+    p256dh TEXT NOT NULL,
+    auth TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_push_subscriptions_endpoint
+    ON push_subscriptions(endpoint);
+
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user_id
+    ON push_subscriptions(user_id);
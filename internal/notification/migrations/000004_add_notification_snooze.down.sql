@@ -0,0 +1 @@
+ALTER TABLE notifications DROP COLUMN snoozed_until;
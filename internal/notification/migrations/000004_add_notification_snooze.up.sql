@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN snoozed_until DATETIME;
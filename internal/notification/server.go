@@ -30,6 +30,10 @@ type Server struct {
 	db *sql.DB
 	// eventStoreClient はEvent Storeサービスへの通信クライアント。
 	eventStoreClient *httpclient.Client
+	// deliverers はアプリ内通知に加えて通知を配信する外部チャネル（メール・Webhook等）。
+	deliverers []deliverer
+	// vapid はWeb Pushの送信元を証明するVAPIDの鍵。nilの場合はWeb Pushを無効とする。
+	vapid *vapidKeys
 }
 
 // NewServer は新しい通知サーバーを生成する。
@@ -49,9 +53,13 @@ func NewServer(port string) (*Server, error) {
 		eventStoreURL = "http://localhost:8084"
 	}
 
+	vapid, err := parseVAPIDKeys(os.Getenv("VAPID_PRIVATE_KEY"), os.Getenv("VAPID_SUBJECT"))
+	if err != nil {
+		return nil, err
+	}
+
 	router := gin.New()
-	router.Use(middleware.Recovery())
-	router.Use(gin.Logger())
+	router.Use(middleware.DefaultChain(middleware.ChainConfig{})...)
 
 	s := &Server{
 		router:           router,
@@ -59,6 +67,10 @@ func NewServer(port string) (*Server, error) {
 		queries:          notificationdb.New(sqlDB),
 		db:               sqlDB,
 		eventStoreClient: httpclient.New(eventStoreURL),
+		vapid:            vapid,
+	}
+	if vapid != nil {
+		s.deliverers = append(s.deliverers, newWebPushDeliverer(s.queries, vapid))
 	}
 	s.setupRoutes()
 
@@ -78,7 +90,11 @@ func (s *Server) setupRoutes() {
 	}
 
 	api := s.router.Group("/api/v1")
-	api.Use(middleware.JWTAuth(jwtSecret))
+	api.Use(middleware.JWTAuth(jwtSecret,
+		middleware.WithPreviousSecrets(middleware.SplitSecrets(os.Getenv("JWT_SECRET_PREVIOUS"))...),
+		middleware.WithIssuer(middleware.Issuer),
+		middleware.WithAudience(middleware.Audience),
+	))
 	{
 		notifications := api.Group("/notifications")
 		{
@@ -86,10 +102,22 @@ func (s *Server) setupRoutes() {
 			notifications.GET("", s.handleList())
 			// 未読通知一覧取得
 			notifications.GET("/unread", s.handleListUnread())
+			// 既読状態ごとの件数と通知一覧の先頭ページを取得
+			notifications.GET("/summary", s.handleSummary())
+			// 通知のチャネルごとの配信状況取得
+			notifications.GET("/:id/deliveries", s.handleListDeliveries())
 			// 通知を既読にする
 			notifications.PUT("/:id/read", s.handleMarkAsRead())
 			// 全通知を既読にする
 			notifications.PUT("/read-all", s.handleMarkAllAsRead())
+			// 通知を指定した日時までスヌーズする（body: {"until": "<RFC3339>"}）
+			notifications.POST("/:id/snooze", s.handleSnooze())
+			// 通知のスヌーズを解除する
+			notifications.DELETE("/:id/snooze", s.handleUnsnooze())
+			// ブラウザのWeb Pushサブスクリプションを登録する
+			notifications.POST("/push-subscription", s.handleRegisterPushSubscription())
+			// サブスクリプション作成に使用するVAPIDの公開鍵を取得する
+			notifications.GET("/push-subscription/vapid-public-key", s.handleGetVAPIDPublicKey())
 		}
 
 		// 通知送信（内部API - Sagaから呼び出される）
@@ -100,9 +128,7 @@ func (s *Server) setupRoutes() {
 	}
 
 	// ヘルスチェック
-	s.router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "notification"})
-	})
+	s.router.GET("/health", middleware.HealthCheck("notification", s.db))
 }
 
 // notificationResponse は通知のJSONレスポンス構造。
@@ -119,11 +145,13 @@ type notificationResponse struct {
 	IsRead bool `json:"is_read"`
 	// CreatedAt は通知の作成日時（RFC3339形式）。
 	CreatedAt string `json:"created_at"`
+	// SnoozedUntil はスヌーズの期限（RFC3339形式）。スヌーズしていない場合は省略する。
+	SnoozedUntil *string `json:"snoozed_until,omitempty"`
 }
 
 // toNotificationResponse はDB行をJSONレスポンスに変換する。
 func toNotificationResponse(n notificationdb.Notification) notificationResponse {
-	return notificationResponse{
+	resp := notificationResponse{
 		ID:        n.ID,
 		UserID:    n.UserID,
 		Title:     n.Title,
@@ -131,6 +159,11 @@ func toNotificationResponse(n notificationdb.Notification) notificationResponse
 		IsRead:    n.IsRead != 0,
 		CreatedAt: n.CreatedAt.Format(time.RFC3339),
 	}
+	if n.SnoozedUntil.Valid {
+		t := n.SnoozedUntil.Time.UTC().Format(time.RFC3339)
+		resp.SnoozedUntil = &t
+	}
+	return resp
 }
 
 // toNotificationResponses はDB行のスライスをJSONレスポンスのスライスに変換する。
@@ -158,11 +191,13 @@ func (s *Server) handleList() gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, toNotificationResponses(notifications))
+		responses := toNotificationResponses(notifications)
+		middleware.RespondList(c, http.StatusOK, responses, middleware.Envelope{Data: responses, Count: len(responses)})
 	}
 }
 
 // handleListUnread は認証済みユーザーの未読通知一覧を返すハンドラ。
+// スヌーズ中の通知は期限が来るまで含めない。
 func (s *Server) handleListUnread() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
@@ -171,19 +206,39 @@ func (s *Server) handleListUnread() gin.HandlerFunc {
 			return
 		}
 
-		notifications, err := s.queries.ListUnreadNotifications(c.Request.Context(), userID)
+		notifications, err := s.queries.ListUnreadNotifications(c.Request.Context(), notificationdb.ListUnreadNotificationsParams{
+			UserID: userID,
+			Now:    sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "未読通知一覧の取得に失敗しました"})
 			log.Printf("未読通知一覧取得エラー: %v", err)
 			return
 		}
 
-		c.JSON(http.StatusOK, toNotificationResponses(notifications))
+		responses := toNotificationResponses(notifications)
+		middleware.RespondList(c, http.StatusOK, responses, middleware.Envelope{Data: responses, Count: len(responses)})
 	}
 }
 
-// handleMarkAsRead は指定された通知を既読にするハンドラ。
-func (s *Server) handleMarkAsRead() gin.HandlerFunc {
+// summaryPageSize は通知サマリーに含める通知の件数（先頭ページのサイズ）。
+const summaryPageSize = 20
+
+// notificationSummaryResponse は通知サマリーのJSONレスポンス構造。
+type notificationSummaryResponse struct {
+	// Total は通知の総件数。
+	Total int64 `json:"total"`
+	// Unread は未読通知の件数。
+	Unread int64 `json:"unread"`
+	// Read は既読通知の件数。
+	Read int64 `json:"read"`
+	// Notifications は新しい順の通知一覧の先頭ページ（最大summaryPageSize件）。
+	Notifications []notificationResponse `json:"notifications"`
+}
+
+// handleSummary は認証済みユーザーの既読状態ごとの通知件数と、通知一覧の先頭ページを返すハンドラ。
+// 受信箱のヘッダー表示に必要な情報を1回のリクエストで返す。
+func (s *Server) handleSummary() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := middleware.GetUserID(c)
 		if userID == "" {
@@ -191,22 +246,43 @@ func (s *Server) handleMarkAsRead() gin.HandlerFunc {
 			return
 		}
 
-		notificationID := c.Param("id")
-		if notificationID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "通知IDが必要です"})
+		counts, err := s.queries.CountNotificationsByReadStatus(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "通知件数の取得に失敗しました"})
+			log.Printf("通知件数取得エラー: %v", err)
 			return
 		}
 
-		// 通知の存在確認と所有者チェック
-		n, err := s.queries.GetNotificationByID(c.Request.Context(), notificationID)
+		notifications, err := s.queries.ListNotificationsByUserIDWithLimit(c.Request.Context(), notificationdb.ListNotificationsByUserIDWithLimitParams{
+			UserID: userID,
+			Limit:  summaryPageSize,
+		})
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "通知が見つかりません"})
-			log.Printf("通知取得エラー: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "通知一覧の取得に失敗しました"})
+			log.Printf("通知一覧取得エラー: %v", err)
 			return
 		}
 
-		if n.UserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "この通知を操作する権限がありません"})
+		c.JSON(http.StatusOK, notificationSummaryResponse{
+			Total:         counts.Total,
+			Unread:        counts.Unread,
+			Read:          counts.Total - counts.Unread,
+			Notifications: toNotificationResponses(notifications),
+		})
+	}
+}
+
+// handleMarkAsRead は指定された通知を既読にするハンドラ。
+func (s *Server) handleMarkAsRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+
+		notificationID := c.Param("id")
+		if _, ok := s.ownedNotification(c, notificationID, userID); !ok {
 			return
 		}
 
@@ -220,6 +296,116 @@ func (s *Server) handleMarkAsRead() gin.HandlerFunc {
 	}
 }
 
+// ownedNotification は通知の存在確認と所有者チェックを行い、userIDが所有する通知を返す。
+// 通知IDが空の場合は400、存在しない場合は404、他のユーザーの通知の場合は403のレスポンスを書き込み、falseを返す。
+func (s *Server) ownedNotification(c *gin.Context, notificationID, userID string) (notificationdb.Notification, bool) {
+	if notificationID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "通知IDが必要です"})
+		return notificationdb.Notification{}, false
+	}
+
+	n, err := s.queries.GetNotificationByID(c.Request.Context(), notificationID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知が見つかりません"})
+		log.Printf("通知取得エラー: %v", err)
+		return notificationdb.Notification{}, false
+	}
+
+	if n.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "この通知を操作する権限がありません"})
+		return notificationdb.Notification{}, false
+	}
+	return n, true
+}
+
+// snoozeRequest は通知のスヌーズリクエストのJSON構造。
+type snoozeRequest struct {
+	// Until はスヌーズの期限（RFC3339形式）。
+	Until string `json:"until" binding:"required"`
+}
+
+// handleSnooze は指定された通知を期限までスヌーズするハンドラ。
+// スヌーズ中の通知は未読一覧に表示せず、期限が来ると再び未読として表示する。
+// 既読の通知もスヌーズすると未読に戻す。過去の日時を指定した場合はスヌーズを即時解除する。
+func (s *Server) handleSnooze() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+
+		var req snoozeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+			return
+		}
+		until, err := time.Parse(time.RFC3339, req.Until)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("untilはRFC3339形式で指定してください: %q", req.Until)})
+			return
+		}
+
+		notificationID := c.Param("id")
+		n, ok := s.ownedNotification(c, notificationID, userID)
+		if !ok {
+			return
+		}
+
+		// 期限は秒単位で記録する（RFC3339で返す値と一致させる）
+		until = until.UTC().Truncate(time.Second)
+		if !until.After(time.Now()) {
+			if err := s.queries.UnsnoozeNotification(c.Request.Context(), notificationID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "通知のスヌーズ解除に失敗しました"})
+				log.Printf("通知スヌーズ解除エラー: %v", err)
+				return
+			}
+			n.SnoozedUntil = sql.NullTime{}
+			c.JSON(http.StatusOK, toNotificationResponse(n))
+			return
+		}
+
+		snoozedUntil := sql.NullTime{Time: until, Valid: true}
+		if err := s.queries.SnoozeNotification(c.Request.Context(), notificationdb.SnoozeNotificationParams{
+			SnoozedUntil: snoozedUntil,
+			ID:           notificationID,
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "通知のスヌーズに失敗しました"})
+			log.Printf("通知スヌーズエラー: %v", err)
+			return
+		}
+		n.SnoozedUntil = snoozedUntil
+		n.IsRead = 0
+		c.JSON(http.StatusOK, toNotificationResponse(n))
+	}
+}
+
+// handleUnsnooze は指定された通知のスヌーズを解除するハンドラ。
+// スヌーズしていない通知に対しても成功を返す。
+func (s *Server) handleUnsnooze() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+
+		notificationID := c.Param("id")
+		n, ok := s.ownedNotification(c, notificationID, userID)
+		if !ok {
+			return
+		}
+
+		if err := s.queries.UnsnoozeNotification(c.Request.Context(), notificationID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "通知のスヌーズ解除に失敗しました"})
+			log.Printf("通知スヌーズ解除エラー: %v", err)
+			return
+		}
+		n.SnoozedUntil = sql.NullTime{}
+		c.JSON(http.StatusOK, toNotificationResponse(n))
+	}
+}
+
 // handleMarkAllAsRead は認証済みユーザーの全通知を既読にするハンドラ。
 func (s *Server) handleMarkAllAsRead() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -261,7 +447,8 @@ type appendEventRequest struct {
 	Data json.RawMessage `json:"data"`
 }
 
-// handleSend は通知を作成しNotificationSentイベントを発行するハンドラ。
+// handleSend は通知を作成し、登録された外部チャネルへ配信してNotificationSentイベントを発行するハンドラ。
+// チャネルごとの配信結果はnotification_deliveriesテーブルに記録する。
 // 内部API（Sagaオーケストレーターから呼び出される）。
 func (s *Server) handleSend() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -285,6 +472,16 @@ func (s *Server) handleSend() gin.HandlerFunc {
 			return
 		}
 
+		// アプリ内通知の保存を配信成功として記録し、外部チャネルへ配信する
+		s.recordDelivery(c.Request.Context(), notificationID, channelInApp, nil)
+		s.deliver(c.Request.Context(), notificationdb.Notification{
+			ID:        notificationID,
+			UserID:    req.UserID,
+			Title:     req.Title,
+			Message:   req.Message,
+			CreatedAt: time.Now().UTC(),
+		})
+
 		// NotificationSentイベントをEvent Storeに送信
 		eventData := event.NotificationSentData{
 			UserID:  req.UserID,
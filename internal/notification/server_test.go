@@ -7,12 +7,16 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "modernc.org/sqlite"
 	notificationdb "github.com/nao1215/micro/internal/notification/db"
+	"github.com/nao1215/micro/pkg/eventstore/eventstoretest"
 	"github.com/nao1215/micro/pkg/httpclient"
+	"github.com/nao1215/micro/pkg/middleware"
 )
 
 func init() {
@@ -35,12 +39,7 @@ func setupTestServer(t *testing.T) (*Server, *gin.Engine) {
 	}
 
 	// Event Storeのモックサーバーを作成する
-	eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		fmt.Fprint(w, `{"id":"mock-event-id"}`)
-	}))
-	t.Cleanup(func() { eventStore.Close() })
+	eventStore := eventstoretest.New(t)
 
 	router := gin.New()
 	s := &Server{
@@ -65,8 +64,14 @@ func setupTestServer(t *testing.T) (*Server, *gin.Engine) {
 		{
 			notifications.GET("", s.handleList())
 			notifications.GET("/unread", s.handleListUnread())
+			notifications.GET("/summary", s.handleSummary())
+			notifications.GET("/:id/deliveries", s.handleListDeliveries())
 			notifications.PUT("/:id/read", s.handleMarkAsRead())
 			notifications.PUT("/read-all", s.handleMarkAllAsRead())
+			notifications.POST("/:id/snooze", s.handleSnooze())
+			notifications.DELETE("/:id/snooze", s.handleUnsnooze())
+			notifications.POST("/push-subscription", s.handleRegisterPushSubscription())
+			notifications.GET("/push-subscription/vapid-public-key", s.handleGetVAPIDPublicKey())
 		}
 
 		internal := api.Group("/internal")
@@ -248,6 +253,62 @@ func TestHandleListNotifications(t *testing.T) {
 	})
 }
 
+// TestHandleListNotifications_Envelope は通知一覧の従来の形式（配列）とエンベロープ形式のレスポンスを検証する。
+func TestHandleListNotifications_Envelope(t *testing.T) {
+	t.Parallel()
+
+	s, router := setupTestServer(t)
+	createTestNotification(t, s, "notif-1", "user-1", "タイトル1", "メッセージ1")
+	createTestNotification(t, s, "notif-2", "user-1", "タイトル2", "メッセージ2")
+
+	t.Run("指定しない場合は従来どおり配列で返す", func(t *testing.T) {
+		t.Parallel()
+
+		w := doRequest(router, http.MethodGet, "/api/v1/notifications", "user-1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+		if got := parseJSONArray(t, w); len(got) != 2 {
+			t.Errorf("配列の長さ: got %d, want 2", len(got))
+		}
+	})
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		accept string
+	}{
+		{name: "envelope=trueを指定するとエンベロープ形式で返す", path: "/api/v1/notifications?envelope=true"},
+		{name: "Acceptでエンベロープ形式を要求できる", path: "/api/v1/notifications", accept: middleware.EnvelopeMediaType},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-User-ID", "user-1")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+			}
+
+			var got struct {
+				Data  []notificationResponse `json:"data"`
+				Count int                    `json:"count"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("JSONのデコードに失敗: %v, body=%s", err, w.Body.String())
+			}
+			if got.Count != 2 || len(got.Data) != 2 {
+				t.Errorf("エンベロープ: got count=%d, data=%d件, want 2件", got.Count, len(got.Data))
+			}
+		})
+	}
+}
+
 // TestHandleListUnread は未読通知一覧取得ハンドラのテスト。
 func TestHandleListUnread(t *testing.T) {
 	t.Parallel()
@@ -312,6 +373,108 @@ func TestHandleListUnread(t *testing.T) {
 	})
 }
 
+// TestHandleSummary は既読状態ごとの件数と通知一覧の先頭ページを返すハンドラのテスト。
+func TestHandleSummary(t *testing.T) {
+	t.Parallel()
+
+	t.Run("件数が投入したデータと一致し、他ユーザーの通知は含まれない", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestNotification(t, s, "notif-1", "user-1", "未読1", "メッセージ1")
+		createTestNotification(t, s, "notif-2", "user-1", "未読2", "メッセージ2")
+		createTestNotification(t, s, "notif-3", "user-1", "既読1", "メッセージ3")
+		createTestNotification(t, s, "notif-4", "user-1", "既読2", "メッセージ4")
+		createTestNotification(t, s, "notif-5", "user-1", "既読3", "メッセージ5")
+		createTestNotification(t, s, "notif-other", "user-2", "他ユーザー", "メッセージ")
+		for _, id := range []string{"notif-3", "notif-4", "notif-5"} {
+			if err := s.queries.MarkAsRead(t.Context(), id); err != nil {
+				t.Fatalf("既読処理に失敗: %v", err)
+			}
+		}
+
+		w := doRequest(router, http.MethodGet, "/api/v1/notifications/summary", "user-1", nil)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+
+		var resp notificationSummaryResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("JSONのデコードに失敗: %v, body=%s", err, w.Body.String())
+		}
+		if resp.Total != 5 || resp.Unread != 2 || resp.Read != 3 {
+			t.Errorf("件数: got total=%d unread=%d read=%d, want total=5 unread=2 read=3", resp.Total, resp.Unread, resp.Read)
+		}
+		if len(resp.Notifications) != 5 {
+			t.Fatalf("通知の件数: got %d, want 5", len(resp.Notifications))
+		}
+		for _, n := range resp.Notifications {
+			if n.UserID != "user-1" {
+				t.Errorf("他ユーザーの通知が含まれている: %+v", n)
+			}
+		}
+	})
+
+	t.Run("通知一覧は先頭ページのみを返し、件数は全件を数える", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		total := summaryPageSize + 5
+		for i := range total {
+			createTestNotification(t, s, fmt.Sprintf("notif-%02d", i), "user-1", "タイトル", "メッセージ")
+		}
+
+		w := doRequest(router, http.MethodGet, "/api/v1/notifications/summary", "user-1", nil)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+
+		var resp notificationSummaryResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("JSONのデコードに失敗: %v, body=%s", err, w.Body.String())
+		}
+		if resp.Total != int64(total) || resp.Unread != int64(total) || resp.Read != 0 {
+			t.Errorf("件数: got total=%d unread=%d read=%d, want total=%d unread=%d read=0", resp.Total, resp.Unread, resp.Read, total, total)
+		}
+		if len(resp.Notifications) != summaryPageSize {
+			t.Errorf("通知の件数: got %d, want %d", len(resp.Notifications), summaryPageSize)
+		}
+	})
+
+	t.Run("通知がない場合は件数0と空配列を返す", func(t *testing.T) {
+		t.Parallel()
+		_, router := setupTestServer(t)
+
+		w := doRequest(router, http.MethodGet, "/api/v1/notifications/summary", "user-1", nil)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+
+		result := parseJSON(t, w)
+		if result["total"] != float64(0) || result["unread"] != float64(0) || result["read"] != float64(0) {
+			t.Errorf("件数: got %v", result)
+		}
+		notifications, ok := result["notifications"].([]any)
+		if !ok || len(notifications) != 0 {
+			t.Errorf("notificationsが空配列ではない: %v", result["notifications"])
+		}
+	})
+
+	t.Run("ユーザーIDが未設定の場合はUnauthorized", func(t *testing.T) {
+		t.Parallel()
+		_, router := setupTestServer(t)
+
+		w := doRequest(router, http.MethodGet, "/api/v1/notifications/summary", "", nil)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+}
+
 // TestHandleMarkRead は通知を既読にするハンドラのテスト。
 func TestHandleMarkRead(t *testing.T) {
 	t.Parallel()
@@ -377,6 +540,140 @@ func TestHandleMarkRead(t *testing.T) {
 	})
 }
 
+// TestHandleSnooze は通知のスヌーズとスヌーズ解除のハンドラのテスト。
+func TestHandleSnooze(t *testing.T) {
+	t.Parallel()
+
+	unreadIDs := func(t *testing.T, router *gin.Engine, userID string) []string {
+		t.Helper()
+		w := doRequest(router, http.MethodGet, "/api/v1/notifications/unread", userID, nil)
+		var ids []string
+		for _, n := range parseJSONArray(t, w) {
+			ids = append(ids, n["id"].(string))
+		}
+		return ids
+	}
+
+	t.Run("スヌーズ中の通知は未読一覧に含めず期限が来ると再び未読として表示する", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestNotification(t, s, "notif-1", "user-1", "スヌーズする通知", "メッセージ")
+		createTestNotification(t, s, "notif-2", "user-1", "通常の通知", "メッセージ")
+
+		until := time.Now().Add(time.Hour).UTC().Truncate(time.Second).Format(time.RFC3339)
+		w := doRequest(router, http.MethodPost, "/api/v1/notifications/notif-1/snooze", "user-1", map[string]string{"until": until})
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+		}
+		if got := parseJSON(t, w)["snoozed_until"]; got != until {
+			t.Errorf("snoozed_until: got %v, want %s", got, until)
+		}
+		if got := unreadIDs(t, router, "user-1"); !slices.Equal(got, []string{"notif-2"}) {
+			t.Errorf("スヌーズ中の未読通知: got %v, want [notif-2]", got)
+		}
+
+		// 期限の到来を模擬するため、期限を過去の日時に書き換える
+		if err := s.queries.SnoozeNotification(t.Context(), notificationdb.SnoozeNotificationParams{
+			SnoozedUntil: sql.NullTime{Time: time.Now().Add(-time.Second).UTC(), Valid: true},
+			ID:           "notif-1",
+		}); err != nil {
+			t.Fatalf("スヌーズの期限の更新に失敗: %v", err)
+		}
+		if got := unreadIDs(t, router, "user-1"); len(got) != 2 {
+			t.Errorf("期限到来後の未読通知: got %v, want 2件", got)
+		}
+	})
+
+	t.Run("既読の通知をスヌーズすると期限の到来後に未読として表示する", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestNotification(t, s, "notif-1", "user-1", "テスト", "メッセージ")
+		doRequest(router, http.MethodPut, "/api/v1/notifications/notif-1/read", "user-1", nil)
+
+		until := time.Now().Add(time.Hour).Format(time.RFC3339)
+		w := doRequest(router, http.MethodPost, "/api/v1/notifications/notif-1/snooze", "user-1", map[string]string{"until": until})
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+		}
+		if got := parseJSON(t, w)["is_read"]; got != false {
+			t.Errorf("is_read: got %v, want false", got)
+		}
+		if got := unreadIDs(t, router, "user-1"); len(got) != 0 {
+			t.Errorf("スヌーズ中の未読通知: got %v, want なし", got)
+		}
+	})
+
+	t.Run("過去の日時を指定するとスヌーズを即時解除する", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestNotification(t, s, "notif-1", "user-1", "テスト", "メッセージ")
+		doRequest(router, http.MethodPost, "/api/v1/notifications/notif-1/snooze", "user-1",
+			map[string]string{"until": time.Now().Add(time.Hour).Format(time.RFC3339)})
+
+		w := doRequest(router, http.MethodPost, "/api/v1/notifications/notif-1/snooze", "user-1",
+			map[string]string{"until": time.Now().Add(-time.Hour).Format(time.RFC3339)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+		}
+		if _, ok := parseJSON(t, w)["snoozed_until"]; ok {
+			t.Error("snoozed_untilが含まれています")
+		}
+		if got := unreadIDs(t, router, "user-1"); !slices.Equal(got, []string{"notif-1"}) {
+			t.Errorf("未読通知: got %v, want [notif-1]", got)
+		}
+	})
+
+	t.Run("スヌーズを解除すると未読一覧に再び表示する", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestNotification(t, s, "notif-1", "user-1", "テスト", "メッセージ")
+		doRequest(router, http.MethodPost, "/api/v1/notifications/notif-1/snooze", "user-1",
+			map[string]string{"until": time.Now().Add(time.Hour).Format(time.RFC3339)})
+
+		w := doRequest(router, http.MethodDelete, "/api/v1/notifications/notif-1/snooze", "user-1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body=%s", w.Code, http.StatusOK, w.Body.String())
+		}
+		if got := unreadIDs(t, router, "user-1"); !slices.Equal(got, []string{"notif-1"}) {
+			t.Errorf("未読通知: got %v, want [notif-1]", got)
+		}
+	})
+
+	t.Run("エラー時のステータスコード", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+
+		createTestNotification(t, s, "notif-1", "user-1", "ユーザー1の通知", "メッセージ")
+		future := map[string]string{"until": time.Now().Add(time.Hour).Format(time.RFC3339)}
+
+		tests := []struct {
+			name   string
+			method string
+			path   string
+			userID string
+			body   any
+			want   int
+		}{
+			{name: "他ユーザーの通知のスヌーズはForbidden", method: http.MethodPost, path: "/api/v1/notifications/notif-1/snooze", userID: "user-2", body: future, want: http.StatusForbidden},
+			{name: "他ユーザーの通知のスヌーズ解除はForbidden", method: http.MethodDelete, path: "/api/v1/notifications/notif-1/snooze", userID: "user-2", want: http.StatusForbidden},
+			{name: "存在しない通知のスヌーズはNotFound", method: http.MethodPost, path: "/api/v1/notifications/nonexistent/snooze", userID: "user-1", body: future, want: http.StatusNotFound},
+			{name: "存在しない通知のスヌーズ解除はNotFound", method: http.MethodDelete, path: "/api/v1/notifications/nonexistent/snooze", userID: "user-1", want: http.StatusNotFound},
+			{name: "untilがない場合はBadRequest", method: http.MethodPost, path: "/api/v1/notifications/notif-1/snooze", userID: "user-1", body: map[string]string{}, want: http.StatusBadRequest},
+			{name: "untilがRFC3339形式でない場合はBadRequest", method: http.MethodPost, path: "/api/v1/notifications/notif-1/snooze", userID: "user-1", body: map[string]string{"until": "2026-01-01 00:00:00"}, want: http.StatusBadRequest},
+			{name: "ユーザーIDが未設定の場合はUnauthorized", method: http.MethodPost, path: "/api/v1/notifications/notif-1/snooze", body: future, want: http.StatusUnauthorized},
+		}
+		for _, tt := range tests {
+			if w := doRequest(router, tt.method, tt.path, tt.userID, tt.body); w.Code != tt.want {
+				t.Errorf("%s: ステータスコード: got %d, want %d", tt.name, w.Code, tt.want)
+			}
+		}
+	})
+}
+
 // TestHandleMarkAllRead は全通知を既読にするハンドラのテスト。
 func TestHandleMarkAllRead(t *testing.T) {
 	t.Parallel()
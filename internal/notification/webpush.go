@@ -0,0 +1,409 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	notificationdb "github.com/nao1215/micro/internal/notification/db"
+	"github.com/nao1215/micro/pkg/middleware"
+)
+
+// channelWebPush はブラウザへのWeb Push配信チャネル。
+const channelWebPush deliveryChannel = "web_push"
+
+const (
+	// pushRecordSize はaes128gcmで暗号化するレコードのサイズ（RFC 8188）。ペイロードは1レコードに収める。
+	pushRecordSize = 4096
+	// maxPushPayloadSize は暗号化前のペイロードの最大バイト数。
+	// プッシュサービスが受け付ける4096バイトから、ヘッダー（86バイト）・認証タグ（16バイト）・区切り（1バイト）を除いた大きさ。
+	maxPushPayloadSize = 4096 - 86 - 16 - 1
+	// pushTTL はプッシュサービスがブラウザに届けるまでメッセージを保持する秒数。
+	pushTTL = 24 * time.Hour
+	// vapidTokenTTL はVAPIDのJWTの有効期間。プッシュサービスは24時間以内の有効期限を要求する。
+	vapidTokenTTL = 12 * time.Hour
+	// pushTimeout はプッシュサービスへのリクエストのタイムアウト。
+	pushTimeout = 10 * time.Second
+	// maxPushEndpointLength はサブスクリプションのエンドポイントURLの最大長。
+	maxPushEndpointLength = 2048
+	// pushAuthSecretLength はブラウザの認証シークレットのバイト数。
+	pushAuthSecretLength = 16
+)
+
+// vapidKeys はプッシュサービスに送信元を証明するVAPID（RFC 8292）の鍵と連絡先。
+type vapidKeys struct {
+	// privateKey はVAPIDのJWTに署名するP-256秘密鍵。
+	privateKey *ecdsa.PrivateKey
+	// publicKey はBase64URL形式の非圧縮公開鍵。ブラウザのサブスクリプション登録（applicationServerKey）に使用する。
+	publicKey string
+	// subject はプッシュサービスが運用者に連絡するためのmailto:またはhttps:のURL。
+	subject string
+}
+
+// parseVAPIDKeys は環境変数VAPID_PRIVATE_KEY（Base64URL形式のP-256秘密鍵）とVAPID_SUBJECTからVAPIDの鍵を生成する。
+// 公開鍵は秘密鍵から導出する。両方とも空の場合はWeb Pushを無効とし、nilを返す。
+func parseVAPIDKeys(privateKey, subject string) (*vapidKeys, error) {
+	privateKey = strings.TrimSpace(privateKey)
+	subject = strings.TrimSpace(subject)
+	if privateKey == "" && subject == "" {
+		return nil, nil
+	}
+	if privateKey == "" || subject == "" {
+		return nil, errors.New("Web Pushを有効にするにはVAPID_PRIVATE_KEYとVAPID_SUBJECTの両方を指定してください")
+	}
+	if !strings.HasPrefix(subject, "mailto:") && !strings.HasPrefix(subject, "https://") {
+		return nil, fmt.Errorf("VAPID_SUBJECTはmailto:またはhttps://で始まるURLで指定してください: %q", subject)
+	}
+
+	raw, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("VAPID_PRIVATE_KEYはBase64URL形式で指定してください: %w", err)
+	}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	if err != nil {
+		return nil, fmt.Errorf("VAPID_PRIVATE_KEYがP-256の秘密鍵として不正です: %w", err)
+	}
+	publicKey, err := key.PublicKey.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("VAPIDの公開鍵の導出に失敗: %w", err)
+	}
+	return &vapidKeys{
+		privateKey: key,
+		publicKey:  base64.RawURLEncoding.EncodeToString(publicKey),
+		subject:    subject,
+	}, nil
+}
+
+// authorization はendpointのプッシュサービスに送信するAuthorizationヘッダーの値を返す。
+func (v *vapidKeys) authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("エンドポイントのURLが不正です: %w", err)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidTokenTTL).Unix(),
+		"sub": v.subject,
+	}).SignedString(v.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("VAPIDのJWTの署名に失敗: %w", err)
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", token, v.publicKey), nil
+}
+
+// decodeBase64URL はパディングの有無にかかわらずBase64URL形式の文字列をデコードする。
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// encryptPushPayload はブラウザの公開鍵と認証シークレットでペイロードを暗号化する（RFC 8291、aes128gcm）。
+// 戻り値はヘッダー（salt・レコードサイズ・送信側の公開鍵）と暗号文を連結したリクエストボディ。
+func encryptPushPayload(p256dh, auth string, payload []byte) ([]byte, error) {
+	if len(payload) > maxPushPayloadSize {
+		return nil, fmt.Errorf("ペイロードが大きすぎます（%dバイト、最大%dバイト）", len(payload), maxPushPayloadSize)
+	}
+	uaPublicBytes, err := decodeBase64URL(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("p256dhのデコードに失敗: %w", err)
+	}
+	authSecret, err := decodeBase64URL(auth)
+	if err != nil {
+		return nil, fmt.Errorf("authのデコードに失敗: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("p256dhがP-256の公開鍵として不正です: %w", err)
+	}
+
+	// メッセージごとに送信側の鍵ペアとsaltを生成する
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("鍵ペアの生成に失敗: %w", err)
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+	sharedSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("共有鍵の導出に失敗: %w", err)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("saltの生成に失敗: %w", err)
+	}
+
+	keyInfo := "WebPush: info\x00" + string(uaPublicBytes) + string(asPublicBytes)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, fmt.Errorf("鍵の導出に失敗: %w", err)
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, fmt.Errorf("鍵の導出に失敗: %w", err)
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, fmt.Errorf("nonceの導出に失敗: %w", err)
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, fmt.Errorf("暗号器の生成に失敗: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("暗号器の生成に失敗: %w", err)
+	}
+
+	header := make([]byte, 0, len(salt)+4+1+len(asPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(asPublicBytes)))
+	header = append(header, asPublicBytes...)
+
+	// 最後のレコードであることを示す区切り（0x02）をペイロードの末尾に付ける
+	plaintext := append(append(make([]byte, 0, len(payload)+1), payload...), 0x02)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// pushPayload はWeb Pushでブラウザに届けるJSONペイロード。
+// Service Workerのpushイベントで受け取り、通知の表示に使用する。
+type pushPayload struct {
+	// NotificationID は通知の一意識別子。
+	NotificationID string `json:"notification_id"`
+	// Title は通知のタイトル。
+	Title string `json:"title"`
+	// Message は通知メッセージ。
+	Message string `json:"message"`
+}
+
+// errPushSubscriptionGone はプッシュサービスがサブスクリプションの期限切れ・解除（404/410）を返した場合のエラー。
+var errPushSubscriptionGone = errors.New("サブスクリプションは期限切れまたは解除済みです")
+
+// webPushDeliverer は通知先ユーザーが登録した全てのブラウザへWeb Pushで通知を配信するdeliverer。
+type webPushDeliverer struct {
+	// queries はサブスクリプションの取得・削除に使用するクエリ実行オブジェクト。
+	queries *notificationdb.Queries
+	// vapid はプッシュサービスに送信元を証明するVAPIDの鍵。
+	vapid *vapidKeys
+	// client はプッシュサービスへのHTTPクライアント。
+	client *http.Client
+}
+
+// newWebPushDeliverer は新しいwebPushDelivererを生成する。
+func newWebPushDeliverer(queries *notificationdb.Queries, vapid *vapidKeys) *webPushDeliverer {
+	return &webPushDeliverer{
+		queries: queries,
+		vapid:   vapid,
+		client:  &http.Client{Timeout: pushTimeout},
+	}
+}
+
+// channel は配信チャネルを返す。
+func (d *webPushDeliverer) channel() deliveryChannel {
+	return channelWebPush
+}
+
+// deliver は通知先ユーザーの全サブスクリプションへ通知をプッシュ配信する。
+// サブスクリプションが登録されていない場合はerrDeliverySkippedを返す。
+// 期限切れ（404/410）のサブスクリプションは削除し、残りのいずれかへの配信に失敗した場合はエラーを返す。
+func (d *webPushDeliverer) deliver(ctx context.Context, n notificationdb.Notification) error {
+	subscriptions, err := d.queries.ListPushSubscriptionsByUserID(ctx, n.UserID)
+	if err != nil {
+		return fmt.Errorf("サブスクリプションの取得に失敗: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return errDeliverySkipped
+	}
+
+	payload, err := json.Marshal(pushPayload{NotificationID: n.ID, Title: n.Title, Message: n.Message})
+	if err != nil {
+		return fmt.Errorf("ペイロードのシリアライズに失敗: %w", err)
+	}
+
+	var (
+		errs    []error
+		expired int
+	)
+	for _, sub := range subscriptions {
+		err := d.push(ctx, sub, payload)
+		if errors.Is(err, errPushSubscriptionGone) {
+			expired++
+			if err := d.queries.DeletePushSubscription(ctx, sub.ID); err != nil {
+				log.Printf("期限切れのサブスクリプションの削除に失敗: subscription_id=%s, error=%v", sub.ID, err)
+			}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscription_id=%s: %w", sub.ID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if expired == len(subscriptions) {
+		return fmt.Errorf("有効なサブスクリプションがありません（期限切れの%d件を削除しました）", expired)
+	}
+	return nil
+}
+
+// push は1つのサブスクリプションへ暗号化したペイロードを送信する。
+func (d *webPushDeliverer) push(ctx context.Context, sub notificationdb.PushSubscription, payload []byte) error {
+	body, err := encryptPushPayload(sub.P256dh, sub.Auth, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := d.vapid.authorization(sub.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("リクエストの作成に失敗: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprintf("%d", int(pushTTL.Seconds())))
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("プッシュサービスへの送信に失敗: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errPushSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("プッシュサービスがエラーを返しました: status=%d", resp.StatusCode)
+	}
+	return nil
+}
+
+// pushSubscriptionRequest はサブスクリプション登録のリクエストボディ。
+// ブラウザのPushSubscription.toJSON()の形式をそのまま受け付ける。
+type pushSubscriptionRequest struct {
+	// Endpoint はプッシュサービスのエンドポイントURL。
+	Endpoint string `json:"endpoint" binding:"required"`
+	// Keys はペイロードの暗号化に使用するブラウザの鍵。
+	Keys struct {
+		// P256dh はブラウザのP-256公開鍵（Base64URL形式）。
+		P256dh string `json:"p256dh" binding:"required"`
+		// Auth はブラウザの認証シークレット（Base64URL形式）。
+		Auth string `json:"auth" binding:"required"`
+	} `json:"keys"`
+}
+
+// validate はサブスクリプションのエンドポイントと鍵を検証する。
+// プッシュサービス以外への送信を避けるため、エンドポイントはhttpsのURLに限る。
+func (r pushSubscriptionRequest) validate() error {
+	if len(r.Endpoint) > maxPushEndpointLength {
+		return fmt.Errorf("endpointが長すぎます（最大%d文字）", maxPushEndpointLength)
+	}
+	u, err := url.Parse(r.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("endpointはhttpsのURLで指定してください")
+	}
+	p256dh, err := decodeBase64URL(r.Keys.P256dh)
+	if err != nil {
+		return errors.New("keys.p256dhはBase64URL形式で指定してください")
+	}
+	if _, err := ecdh.P256().NewPublicKey(p256dh); err != nil {
+		return errors.New("keys.p256dhがP-256の公開鍵として不正です")
+	}
+	auth, err := decodeBase64URL(r.Keys.Auth)
+	if err != nil || len(auth) != pushAuthSecretLength {
+		return fmt.Errorf("keys.authは%dバイトのBase64URL形式で指定してください", pushAuthSecretLength)
+	}
+	return nil
+}
+
+// pushSubscriptionResponse はサブスクリプション登録のJSONレスポンス構造。
+type pushSubscriptionResponse struct {
+	// ID はサブスクリプションの一意識別子。
+	ID string `json:"id"`
+	// Endpoint はプッシュサービスのエンドポイントURL。
+	Endpoint string `json:"endpoint"`
+	// CreatedAt は登録日時（RFC3339形式）。
+	CreatedAt string `json:"created_at"`
+}
+
+// handleRegisterPushSubscription はブラウザのWeb Pushサブスクリプションを登録するハンドラ。
+// 同じエンドポイントが登録済みの場合は、鍵と登録ユーザーを更新する。
+// Web Push（VAPID）が設定されていない場合は503を返す。
+func (s *Server) handleRegisterPushSubscription() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := middleware.GetUserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが取得できません"})
+			return
+		}
+		if s.vapid == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Web Pushが設定されていません"})
+			return
+		}
+
+		var req pushSubscriptionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+			return
+		}
+		if err := req.validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		sub, err := s.queries.UpsertPushSubscription(c.Request.Context(), notificationdb.UpsertPushSubscriptionParams{
+			ID:       uuid.New().String(),
+			UserID:   userID,
+			Endpoint: req.Endpoint,
+			P256dh:   req.Keys.P256dh,
+			Auth:     req.Keys.Auth,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "サブスクリプションの登録に失敗しました"})
+			log.Printf("サブスクリプション登録エラー: %v", err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, pushSubscriptionResponse{
+			ID:        sub.ID,
+			Endpoint:  sub.Endpoint,
+			CreatedAt: sub.CreatedAt.Format(time.RFC3339),
+		})
+	}
+}
+
+// handleGetVAPIDPublicKey はブラウザがサブスクリプションを作成する際に指定するVAPIDの公開鍵を返すハンドラ。
+// Web Push（VAPID）が設定されていない場合は503を返す。
+func (s *Server) handleGetVAPIDPublicKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.vapid == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Web Pushが設定されていません"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"public_key": s.vapid.publicKey})
+	}
+}
@@ -0,0 +1,411 @@
+package notification
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newTestVAPIDKeys はテスト用のVAPIDの鍵を生成する。
+func newTestVAPIDKeys(t *testing.T) *vapidKeys {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("VAPIDの鍵の生成に失敗: %v", err)
+	}
+	raw, err := key.Bytes()
+	if err != nil {
+		t.Fatalf("VAPIDの秘密鍵の変換に失敗: %v", err)
+	}
+	vapid, err := parseVAPIDKeys(base64.RawURLEncoding.EncodeToString(raw), "mailto:admin@example.com")
+	if err != nil {
+		t.Fatalf("VAPIDの鍵の解析に失敗: %v", err)
+	}
+	return vapid
+}
+
+// testBrowser はプッシュ通知を受け取るブラウザの鍵を模擬する。
+type testBrowser struct {
+	privateKey *ecdh.PrivateKey
+	auth       []byte
+}
+
+// newTestBrowser はテスト用のブラウザの鍵を生成する。
+func newTestBrowser(t *testing.T) *testBrowser {
+	t.Helper()
+
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ブラウザの鍵の生成に失敗: %v", err)
+	}
+	auth := make([]byte, pushAuthSecretLength)
+	if _, err := rand.Read(auth); err != nil {
+		t.Fatalf("認証シークレットの生成に失敗: %v", err)
+	}
+	return &testBrowser{privateKey: key, auth: auth}
+}
+
+// subscription はブラウザのPushSubscription.toJSON()相当のリクエストボディを返す。
+func (b *testBrowser) subscription(endpoint string) map[string]any {
+	return map[string]any{
+		"endpoint": endpoint,
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(b.privateKey.PublicKey().Bytes()),
+			"auth":   base64.RawURLEncoding.EncodeToString(b.auth),
+		},
+	}
+}
+
+// decrypt はaes128gcmで暗号化されたリクエストボディをブラウザの鍵で復号する（RFC 8291）。
+func (b *testBrowser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+
+	if len(body) < 21 {
+		t.Fatalf("ボディが短すぎます: %d", len(body))
+	}
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != pushRecordSize {
+		t.Errorf("レコードサイズ: got %d, want %d", rs, pushRecordSize)
+	}
+	idLen := int(body[20])
+	asPublicBytes := body[21 : 21+idLen]
+	ciphertext := body[21+idLen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		t.Fatalf("送信側の公開鍵の解析に失敗: %v", err)
+	}
+	shared, err := b.privateKey.ECDH(asPublic)
+	if err != nil {
+		t.Fatalf("共有鍵の導出に失敗: %v", err)
+	}
+	keyInfo := "WebPush: info\x00" + string(b.privateKey.PublicKey().Bytes()) + string(asPublicBytes)
+	ikm, _ := hkdf.Key(sha256.New, shared, b.auth, keyInfo, 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("ペイロードの復号に失敗: %v", err)
+	}
+	if len(plaintext) == 0 || plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatalf("最後のレコードの区切りがありません")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+// pushRequest はモックのプッシュサービスが受け取ったリクエスト。
+type pushRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newTestPushService はstatusを返すモックのプッシュサービス（HTTPS）を起動する。
+func newTestPushService(t *testing.T, status int) (*httptest.Server, func() []pushRequest) {
+	t.Helper()
+
+	var (
+		mu       sync.Mutex
+		received []pushRequest
+	)
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, pushRequest{header: r.Header.Clone(), body: body})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, func() []pushRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]pushRequest(nil), received...)
+	}
+}
+
+// enableTestWebPush はテスト用サーバーのWeb Pushを有効にし、モックのプッシュサービスに送信するよう設定する。
+func enableTestWebPush(t *testing.T, s *Server, push *httptest.Server) {
+	t.Helper()
+
+	s.vapid = newTestVAPIDKeys(t)
+	d := newWebPushDeliverer(s.queries, s.vapid)
+	d.client = push.Client()
+	s.deliverers = []deliverer{d}
+}
+
+// deliveryStatuses は通知のチャネルごとの配信結果を返す。
+func deliveryStatuses(t *testing.T, s *Server, notificationID string) map[string]string {
+	t.Helper()
+
+	deliveries, err := s.queries.ListDeliveriesByNotificationID(t.Context(), notificationID)
+	if err != nil {
+		t.Fatalf("配信状況の取得に失敗: %v", err)
+	}
+	statuses := make(map[string]string, len(deliveries))
+	for _, d := range deliveries {
+		statuses[d.Channel] = d.Status
+	}
+	return statuses
+}
+
+// TestWebPush はWeb Pushのサブスクリプション登録と配信を検証する。
+func TestWebPush(t *testing.T) {
+	t.Parallel()
+
+	sendBody := map[string]string{
+		"user_id": "user-1",
+		"title":   "アップロード完了",
+		"message": "メディアのアップロードが完了しました",
+	}
+
+	t.Run("登録したサブスクリプションへ暗号化した通知を配信する", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+		push, received := newTestPushService(t, http.StatusCreated)
+		enableTestWebPush(t, s, push)
+		browser := newTestBrowser(t)
+
+		w := doRequest(router, http.MethodPost, "/api/v1/notifications/push-subscription", "user-1", browser.subscription(push.URL+"/push/abc"))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("サブスクリプションの登録: status=%d, body=%s", w.Code, w.Body.String())
+		}
+
+		w = doRequest(router, http.MethodPost, "/api/v1/internal/send", "system", sendBody)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("通知の送信に失敗: status=%d, body=%s", w.Code, w.Body.String())
+		}
+		notificationID, _ := parseJSON(t, w)["id"].(string)
+
+		requests := received()
+		if len(requests) != 1 {
+			t.Fatalf("プッシュサービスへの送信回数: got %d, want 1", len(requests))
+		}
+		req := requests[0]
+		if got := req.header.Get("Content-Encoding"); got != "aes128gcm" {
+			t.Errorf("Content-Encoding: got %q, want %q", got, "aes128gcm")
+		}
+		if req.header.Get("TTL") == "" {
+			t.Error("TTLヘッダーがありません")
+		}
+
+		// VAPIDのJWTがVAPIDの公開鍵で検証でき、audがプッシュサービスのオリジンであること
+		authorization := req.header.Get("Authorization")
+		token, publicKey, ok := strings.Cut(strings.TrimPrefix(authorization, "vapid t="), ", k=")
+		if !ok || publicKey != s.vapid.publicKey {
+			t.Fatalf("Authorizationヘッダーが不正です: %q", authorization)
+		}
+		claims := jwt.MapClaims{}
+		if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+			return &s.vapid.privateKey.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(push.URL)); err != nil {
+			t.Errorf("VAPIDのJWTの検証に失敗: %v", err)
+		}
+
+		var payload pushPayload
+		if err := json.Unmarshal(browser.decrypt(t, req.body), &payload); err != nil {
+			t.Fatalf("ペイロードのデシリアライズに失敗: %v", err)
+		}
+		if payload.NotificationID != notificationID || payload.Title != sendBody["title"] || payload.Message != sendBody["message"] {
+			t.Errorf("ペイロード: got %+v", payload)
+		}
+
+		if got := deliveryStatuses(t, s, notificationID)["web_push"]; got != deliveryStatusSucceeded {
+			t.Errorf("web_pushの配信結果: got %q, want %q", got, deliveryStatusSucceeded)
+		}
+	})
+
+	t.Run("期限切れ（410）のサブスクリプションを削除して失敗を記録する", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+		push, received := newTestPushService(t, http.StatusGone)
+		enableTestWebPush(t, s, push)
+
+		w := doRequest(router, http.MethodPost, "/api/v1/notifications/push-subscription", "user-1", newTestBrowser(t).subscription(push.URL+"/push/expired"))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("サブスクリプションの登録: status=%d, body=%s", w.Code, w.Body.String())
+		}
+
+		w = doRequest(router, http.MethodPost, "/api/v1/internal/send", "system", sendBody)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("通知の送信に失敗: status=%d, body=%s", w.Code, w.Body.String())
+		}
+		notificationID, _ := parseJSON(t, w)["id"].(string)
+
+		if len(received()) != 1 {
+			t.Fatalf("プッシュサービスへの送信回数: got %d, want 1", len(received()))
+		}
+		subscriptions, err := s.queries.ListPushSubscriptionsByUserID(t.Context(), "user-1")
+		if err != nil {
+			t.Fatalf("サブスクリプションの取得に失敗: %v", err)
+		}
+		if len(subscriptions) != 0 {
+			t.Errorf("期限切れのサブスクリプションが削除されていません: %d件", len(subscriptions))
+		}
+		if got := deliveryStatuses(t, s, notificationID)["web_push"]; got != deliveryStatusFailed {
+			t.Errorf("web_pushの配信結果: got %q, want %q", got, deliveryStatusFailed)
+		}
+	})
+
+	t.Run("サブスクリプションがない場合は配信を記録しない", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+		push, received := newTestPushService(t, http.StatusCreated)
+		enableTestWebPush(t, s, push)
+
+		w := doRequest(router, http.MethodPost, "/api/v1/internal/send", "system", sendBody)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("通知の送信に失敗: status=%d, body=%s", w.Code, w.Body.String())
+		}
+		notificationID, _ := parseJSON(t, w)["id"].(string)
+
+		if len(received()) != 0 {
+			t.Errorf("プッシュサービスへの送信回数: got %d, want 0", len(received()))
+		}
+		if _, ok := deliveryStatuses(t, s, notificationID)["web_push"]; ok {
+			t.Error("サブスクリプションがないのにweb_pushの配信が記録されています")
+		}
+	})
+
+	t.Run("同じエンドポイントの再登録は鍵を更新する", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+		push, _ := newTestPushService(t, http.StatusCreated)
+		enableTestWebPush(t, s, push)
+
+		endpoint := push.URL + "/push/same"
+		first := doRequest(router, http.MethodPost, "/api/v1/notifications/push-subscription", "user-1", newTestBrowser(t).subscription(endpoint))
+		second := doRequest(router, http.MethodPost, "/api/v1/notifications/push-subscription", "user-1", newTestBrowser(t).subscription(endpoint))
+		if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+			t.Fatalf("サブスクリプションの登録: status=%d, %d", first.Code, second.Code)
+		}
+		if parseJSON(t, first)["id"] != parseJSON(t, second)["id"] {
+			t.Error("再登録でサブスクリプションIDが変わりました")
+		}
+		subscriptions, err := s.queries.ListPushSubscriptionsByUserID(t.Context(), "user-1")
+		if err != nil {
+			t.Fatalf("サブスクリプションの取得に失敗: %v", err)
+		}
+		if len(subscriptions) != 1 {
+			t.Errorf("サブスクリプション数: got %d, want 1", len(subscriptions))
+		}
+	})
+
+	t.Run("不正なサブスクリプションは400を返す", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+		s.vapid = newTestVAPIDKeys(t)
+		browser := newTestBrowser(t)
+
+		httpEndpoint := browser.subscription("http://push.example.com/abc")
+		badKey := browser.subscription("https://push.example.com/abc")
+		badKey["keys"] = map[string]string{"p256dh": "invalid", "auth": "invalid"}
+
+		for name, body := range map[string]map[string]any{"httpのエンドポイント": httpEndpoint, "不正な鍵": badKey} {
+			w := doRequest(router, http.MethodPost, "/api/v1/notifications/push-subscription", "user-1", body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: status=%d, want %d", name, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("Web Pushが設定されていない場合は503を返す", func(t *testing.T) {
+		t.Parallel()
+		_, router := setupTestServer(t)
+
+		w := doRequest(router, http.MethodPost, "/api/v1/notifications/push-subscription", "user-1", newTestBrowser(t).subscription("https://push.example.com/abc"))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("サブスクリプションの登録: status=%d, want %d", w.Code, http.StatusServiceUnavailable)
+		}
+		w = doRequest(router, http.MethodGet, "/api/v1/notifications/push-subscription/vapid-public-key", "user-1", nil)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("VAPIDの公開鍵の取得: status=%d, want %d", w.Code, http.StatusServiceUnavailable)
+		}
+	})
+
+	t.Run("VAPIDの公開鍵を返す", func(t *testing.T) {
+		t.Parallel()
+		s, router := setupTestServer(t)
+		s.vapid = newTestVAPIDKeys(t)
+
+		w := doRequest(router, http.MethodGet, "/api/v1/notifications/push-subscription/vapid-public-key", "user-1", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status=%d, want %d", w.Code, http.StatusOK)
+		}
+		if got := parseJSON(t, w)["public_key"]; got != s.vapid.publicKey {
+			t.Errorf("public_key: got %v, want %s", got, s.vapid.publicKey)
+		}
+	})
+}
+
+// TestParseVAPIDKeys はVAPIDの鍵の設定の解析を検証する。
+func TestParseVAPIDKeys(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("鍵の生成に失敗: %v", err)
+	}
+	raw, err := key.Bytes()
+	if err != nil {
+		t.Fatalf("秘密鍵の変換に失敗: %v", err)
+	}
+	privateKey := base64.RawURLEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name       string
+		privateKey string
+		subject    string
+		wantNil    bool
+		wantErr    bool
+	}{
+		{name: "未設定の場合は無効", wantNil: true},
+		{name: "秘密鍵と連絡先を指定した場合は有効", privateKey: privateKey, subject: "mailto:admin@example.com"},
+		{name: "連絡先がない場合はエラー", privateKey: privateKey, wantErr: true},
+		{name: "連絡先がURLでない場合はエラー", privateKey: privateKey, subject: "admin@example.com", wantErr: true},
+		{name: "秘密鍵が不正な場合はエラー", privateKey: "invalid", subject: "mailto:admin@example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseVAPIDKeys(tt.privateKey, tt.subject)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVAPIDKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("parseVAPIDKeys() = %v, wantNil %v", got, tt.wantNil)
+			}
+			if got == nil {
+				return
+			}
+			publicKey, err := key.PublicKey.Bytes()
+			if err != nil {
+				t.Fatalf("公開鍵の変換に失敗: %v", err)
+			}
+			if got.publicKey != base64.RawURLEncoding.EncodeToString(publicKey) {
+				t.Errorf("公開鍵が秘密鍵から導出されていません: %s", got.publicKey)
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ type Saga struct {
 	StartedAt   time.Time
 	UpdatedAt   time.Time
 	CompletedAt sql.NullTime
+	Attempts    int64
 }
 
 type SagaStep struct {
@@ -36,4 +37,5 @@ type SagaStep struct {
 	CompletedAt sql.NullTime
 	RetryCount  int64
 	LastError   string
+	Kind        string
 }
@@ -44,8 +44,8 @@ func (q *Queries) CreateSaga(ctx context.Context, arg CreateSagaParams) error {
 }
 
 const createSagaStep = `-- name: CreateSagaStep :exec
-INSERT INTO saga_steps (id, saga_id, step_name, status, result, started_at)
-VALUES (?, ?, ?, ?, '{}', datetime('now'))
+INSERT INTO saga_steps (id, saga_id, step_name, status, result, started_at, kind)
+VALUES (?, ?, ?, ?, '{}', datetime('now'), ?)
 `
 
 type CreateSagaStepParams struct {
@@ -53,6 +53,7 @@ type CreateSagaStepParams struct {
 	SagaID   string
 	StepName string
 	Status   string
+	Kind     string
 }
 
 func (q *Queries) CreateSagaStep(ctx context.Context, arg CreateSagaStepParams) error {
@@ -61,10 +62,22 @@ func (q *Queries) CreateSagaStep(ctx context.Context, arg CreateSagaStepParams)
 		arg.SagaID,
 		arg.StepName,
 		arg.Status,
+		arg.Kind,
 	)
 	return err
 }
 
+const deadLetterSaga = `-- name: DeadLetterSaga :exec
+UPDATE sagas
+SET status = 'dead_letter', updated_at = datetime('now'), completed_at = datetime('now')
+WHERE id = ?
+`
+
+func (q *Queries) DeadLetterSaga(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deadLetterSaga, id)
+	return err
+}
+
 const failSaga = `-- name: FailSaga :exec
 UPDATE sagas
 SET status = 'failed', updated_at = datetime('now'), completed_at = datetime('now')
@@ -88,7 +101,7 @@ func (q *Queries) GetProjectorOffset(ctx context.Context) (time.Time, error) {
 }
 
 const getSagaByID = `-- name: GetSagaByID :one
-SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at
+SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at, attempts
 FROM sagas
 WHERE id = ?
 `
@@ -105,14 +118,26 @@ func (q *Queries) GetSagaByID(ctx context.Context, id string) (Saga, error) {
 		&i.StartedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.Attempts,
 	)
 	return i, err
 }
 
+const incrementSagaAttempts = `-- name: IncrementSagaAttempts :exec
+UPDATE sagas
+SET attempts = attempts + 1, updated_at = datetime('now')
+WHERE id = ?
+`
+
+func (q *Queries) IncrementSagaAttempts(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, incrementSagaAttempts, id)
+	return err
+}
+
 const listActiveSagas = `-- name: ListActiveSagas :many
-SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at
+SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at, attempts
 FROM sagas
-WHERE status IN ('started', 'in_progress', 'compensating')
+WHERE status IN ('started', 'in_progress', 'compensating', 'partially_compensated')
 ORDER BY started_at ASC
 `
 
@@ -134,6 +159,7 @@ func (q *Queries) ListActiveSagas(ctx context.Context) ([]Saga, error) {
 			&i.StartedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.Attempts,
 		); err != nil {
 			return nil, err
 		}
@@ -149,7 +175,7 @@ func (q *Queries) ListActiveSagas(ctx context.Context) ([]Saga, error) {
 }
 
 const listSagaSteps = `-- name: ListSagaSteps :many
-SELECT id, saga_id, step_name, status, result, started_at, completed_at, retry_count, last_error
+SELECT id, saga_id, step_name, status, result, started_at, completed_at, retry_count, last_error, kind
 FROM saga_steps
 WHERE saga_id = ?
 ORDER BY started_at ASC
@@ -174,6 +200,55 @@ func (q *Queries) ListSagaSteps(ctx context.Context, sagaID string) ([]SagaStep,
 			&i.CompletedAt,
 			&i.RetryCount,
 			&i.LastError,
+			&i.Kind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSagaStepsByStatus = `-- name: ListSagaStepsByStatus :many
+SELECT id, saga_id, step_name, status, result, started_at, completed_at, retry_count, last_error, kind
+FROM saga_steps
+WHERE status = ?
+ORDER BY started_at ASC, id ASC
+LIMIT ? OFFSET ?
+`
+
+type ListSagaStepsByStatusParams struct {
+	Status string
+	Limit  int64
+	Offset int64
+}
+
+func (q *Queries) ListSagaStepsByStatus(ctx context.Context, arg ListSagaStepsByStatusParams) ([]SagaStep, error) {
+	rows, err := q.db.QueryContext(ctx, listSagaStepsByStatus, arg.Status, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SagaStep
+	for rows.Next() {
+		var i SagaStep
+		if err := rows.Scan(
+			&i.ID,
+			&i.SagaID,
+			&i.StepName,
+			&i.Status,
+			&i.Result,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.RetryCount,
+			&i.LastError,
+			&i.Kind,
 		); err != nil {
 			return nil, err
 		}
@@ -189,9 +264,9 @@ func (q *Queries) ListSagaSteps(ctx context.Context, sagaID string) ([]SagaStep,
 }
 
 const listStuckSagas = `-- name: ListStuckSagas :many
-SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at
+SELECT id, saga_type, current_step, status, payload, started_at, updated_at, completed_at, attempts
 FROM sagas
-WHERE status IN ('in_progress', 'compensating')
+WHERE status IN ('in_progress', 'compensating', 'partially_compensated')
   AND updated_at < ?
 ORDER BY updated_at ASC
 `
@@ -214,6 +289,7 @@ func (q *Queries) ListStuckSagas(ctx context.Context, updatedAt time.Time) ([]Sa
 			&i.StartedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.Attempts,
 		); err != nil {
 			return nil, err
 		}
@@ -228,6 +304,43 @@ func (q *Queries) ListStuckSagas(ctx context.Context, updatedAt time.Time) ([]Sa
 	return items, nil
 }
 
+const updateSagaPayload = `-- name: UpdateSagaPayload :exec
+UPDATE sagas
+SET payload = ?, updated_at = datetime('now')
+WHERE id = ?
+`
+
+type UpdateSagaPayloadParams struct {
+	Payload string
+	ID      string
+}
+
+func (q *Queries) UpdateSagaPayload(ctx context.Context, arg UpdateSagaPayloadParams) error {
+	_, err := q.db.ExecContext(ctx, updateSagaPayload, arg.Payload, arg.ID)
+	return err
+}
+
+const updateSagaStatus = `-- name: UpdateSagaStatus :exec
+UPDATE sagas
+SET current_step = ?, status = ?, updated_at = datetime('now')
+WHERE id = ?
+`
+
+type UpdateSagaStatusParams struct {
+	CurrentStep string
+	Status      string
+	ID          string
+}
+
+func (q *Queries) UpdateSagaStatus(ctx context.Context, arg UpdateSagaStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateSagaStatus,
+		arg.CurrentStep,
+		arg.Status,
+		arg.ID,
+	)
+	return err
+}
+
 const updateSagaStep = `-- name: UpdateSagaStep :exec
 UPDATE sagas
 SET current_step = ?, status = ?, payload = ?, updated_at = datetime('now')
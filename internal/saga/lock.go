@@ -0,0 +1,48 @@
+package saga
+
+import "sync"
+
+// aggregateLocks はaggregate_id単位のインメモリのロック。
+// 同じaggregateに対する処理だけを直列化し、別のaggregateの処理は並行して進められる。
+type aggregateLocks struct {
+	// mu はlocksを保護するミューテックス。
+	mu sync.Mutex
+	// locks はaggregate_idごとのロック。使用中のものだけを保持し、使い終わったら削除する。
+	locks map[string]*aggregateLock
+}
+
+// aggregateLock は1つのaggregateに対するロックと、その待機者数。
+type aggregateLock struct {
+	mu sync.Mutex
+	// refs はロックを保持または待機しているgoroutineの数。
+	refs int
+}
+
+// newAggregateLocks は新しいaggregate_id単位のロックを生成する。
+func newAggregateLocks() *aggregateLocks {
+	return &aggregateLocks{locks: make(map[string]*aggregateLock)}
+}
+
+// lock はaggregateIDのロックを取得し、解放する関数を返す。
+func (l *aggregateLocks) lock(aggregateID string) (unlock func()) {
+	l.mu.Lock()
+	al, ok := l.locks[aggregateID]
+	if !ok {
+		al = &aggregateLock{}
+		l.locks[aggregateID] = al
+	}
+	al.refs++
+	l.mu.Unlock()
+
+	al.mu.Lock()
+	return func() {
+		al.mu.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		al.refs--
+		if al.refs == 0 {
+			delete(l.locks, aggregateID)
+		}
+	}
+}
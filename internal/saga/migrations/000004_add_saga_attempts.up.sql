@@ -0,0 +1 @@
+ALTER TABLE sagas ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
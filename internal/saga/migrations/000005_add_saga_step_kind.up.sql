@@ -0,0 +1,4 @@
+ALTER TABLE saga_steps ADD COLUMN kind TEXT NOT NULL DEFAULT 'action';
+
+-- 既存の補償ステップを補償ステップとして記録し直す
+UPDATE saga_steps SET kind = 'compensation' WHERE step_name IN ('compensate_upload', 'compensate_upload_retry');
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_saga_steps_status_started_at;
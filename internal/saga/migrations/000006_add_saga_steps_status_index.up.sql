@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_saga_steps_status_started_at
+    ON saga_steps(status, started_at);
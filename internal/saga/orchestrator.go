@@ -3,8 +3,13 @@ package saga
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +25,21 @@ const (
 	stuckSagaThreshold = 5 * time.Minute
 	// stuckSagaCheckInterval はスタックSagaのチェック間隔。
 	stuckSagaCheckInterval = 1 * time.Minute
+	// defaultMaxSagaAttempts はスタック検出によるSagaの再試行回数の既定の上限。
+	defaultMaxSagaAttempts = 5
+	// startupAttempts は起動時にEvent Storeへの接続を試行する最大回数。
+	startupAttempts = 10
+	// startupBackoff は起動時の接続失敗後に待機する初回の時間。失敗ごとに2倍に広げる。
+	startupBackoff = 500 * time.Millisecond
+	// pollPageLimit はポーリング時にEvent Storeから1回に取得するイベント数（Event Storeが受け付ける最大件数）。
+	pollPageLimit = 1000
+)
+
+const (
+	// stepKindAction は順方向のステップを表すsaga_steps.kind。
+	stepKindAction = "action"
+	// stepKindCompensation は補償ステップを表すsaga_steps.kind。
+	stepKindCompensation = "compensation"
 )
 
 // Orchestrator はSagaの実行を管理するオーケストレータ。
@@ -38,8 +58,22 @@ type Orchestrator struct {
 	notificationClient *httpclient.Client
 	// lastPolledAt は最後にEvent Storeをポーリングした日時。
 	lastPolledAt time.Time
+	// maxAttempts はスタック検出によるSagaの再試行回数の上限。
+	// 上限に達したSagaは再試行せずdead_letterに移す。
+	maxAttempts int
+	// retryBackoff はステップのリトライ時の待機時間の基準値。リトライごとに2倍にする。
+	retryBackoff time.Duration
+	// handlers はSagaが関心を持つイベントタイプと、受信時に実行するアクションの対応表。
+	// Event Storeからはこの対応表のイベントタイプのみを購読する。
+	handlers map[event.Type]eventHandler
+	// sagaStartLocks はSaga開始をaggregate_id単位で直列化するロック。
+	// プッシュ通知とポーリングが同じイベントをほぼ同時に渡しても、Sagaを二重に作らないようにする。
+	sagaStartLocks *aggregateLocks
 }
 
+// eventHandler はイベント受信時に実行するSagaアクション。
+type eventHandler func(ctx context.Context, aggregateID, data string)
+
 // NewOrchestrator は新しいSagaオーケストレータを生成する。
 func NewOrchestrator(
 	queries *sagadb.Queries,
@@ -48,25 +82,49 @@ func NewOrchestrator(
 	albumClient *httpclient.Client,
 	notificationClient *httpclient.Client,
 ) *Orchestrator {
-	return &Orchestrator{
+	o := &Orchestrator{
 		queries:            queries,
 		eventStoreClient:   eventStoreClient,
 		mediaCommandClient: mediaCommandClient,
 		albumClient:        albumClient,
 		notificationClient: notificationClient,
 		lastPolledAt:       time.Now().UTC().Add(-1 * time.Hour),
+		maxAttempts:        defaultMaxSagaAttempts,
+		retryBackoff:       1 * time.Second,
+		sagaStartLocks:     newAggregateLocks(),
+	}
+	o.handlers = map[event.Type]eventHandler{
+		event.TypeMediaUploaded:         o.startMediaUploadSaga,
+		event.TypeMediaProcessed:        func(ctx context.Context, aggregateID, _ string) { o.advanceSagaOnProcessed(ctx, aggregateID) },
+		event.TypeMediaProcessingFailed: o.compensateOnProcessingFailed,
+		event.TypeMediaAddedToAlbum:     func(ctx context.Context, aggregateID, _ string) { o.advanceSagaOnAlbumAdded(ctx, aggregateID) },
+	}
+	return o
+}
+
+// subscribedEventTypes はSagaが購読するイベントタイプを名前順で返す。
+// handlersから導出するため、Sagaにイベントの処理を追加すると購読対象にも自動で反映される。
+// 撤回されたイベントを判定するため、EventRetractedも常に購読する。
+func (o *Orchestrator) subscribedEventTypes() []string {
+	types := make([]string, 0, len(o.handlers)+1)
+	types = append(types, string(event.TypeEventRetracted))
+	for eventType := range o.handlers {
+		types = append(types, string(eventType))
 	}
+	slices.Sort(types)
+	return types
 }
 
 // eventStoreEvent はEvent StoreのAPIレスポンスに対応する構造体。
 type eventStoreEvent struct {
-	ID            string `json:"id"`
-	AggregateID   string `json:"aggregate_id"`
-	AggregateType string `json:"aggregate_type"`
-	EventType     string `json:"event_type"`
-	Data          string `json:"data"`
-	Version       int64  `json:"version"`
-	CreatedAt     string `json:"created_at"`
+	ID            string         `json:"id"`
+	AggregateID   string         `json:"aggregate_id"`
+	AggregateType string         `json:"aggregate_type"`
+	EventType     string         `json:"event_type"`
+	Data          string         `json:"data"`
+	Version       int64          `json:"version"`
+	CreatedAt     string         `json:"created_at"`
+	Metadata      event.Metadata `json:"metadata"`
 }
 
 // Start はイベントポーリングループを開始する。
@@ -80,6 +138,12 @@ func (o *Orchestrator) Start() {
 	// スタックSaga検出をバックグラウンドで開始
 	go o.startStuckSagaDetector()
 
+	// コンテナの起動順序によってはEvent Storeがまだ立ち上がっていないため、接続を確認してからポーリングを始める。
+	// 接続できなくてもポーリングは継続し、Event Storeの復旧を待つ
+	if err := o.eventStoreClient.WaitForHealthy(context.Background(), startupAttempts, startupBackoff); err != nil {
+		log.Printf("[Saga] Event Storeに接続できないままポーリングを開始します: %v", err)
+	}
+
 	ticker := time.NewTicker(3 * time.Second)
 	defer ticker.Stop()
 
@@ -102,82 +166,201 @@ func (o *Orchestrator) loadOffset() {
 	log.Printf("[Saga] 永続化オフセットを復元しました: %s", offset.Format(time.RFC3339))
 }
 
-// poll はEvent Storeから新しいイベントを取得し、Sagaを進行させる。
+// poll はEvent Storeから購読対象の新しいイベントを取得し、Sagaを進行させる。
+// 無関係なイベントを転送しないよう、Event Storeのタイプフィルタで購読対象のみを取得する。
+// 長時間停止した後でも一度に大量のイベントを取得しないよう、同じsinceのままpollPageLimit件ずつ
+// offsetを進めて取得・処理し、全ページを処理し終えてから次回ポーリングの起点を進める。
 func (o *Orchestrator) poll() {
+	params := url.Values{}
+	params.Set("since", o.lastPolledAt.Format(time.RFC3339))
+	params.Set("types", strings.Join(o.subscribedEventTypes(), ","))
+	params.Set("limit", strconv.Itoa(pollPageLimit))
+
+	var lastEvent *eventStoreEvent
+	for offset := 0; ; offset += pollPageLimit {
+		params.Set("offset", strconv.Itoa(offset))
+		events, err := o.pollPage("/api/v1/events/since?" + params.Encode())
+		if err != nil {
+			log.Printf("[Saga] イベントポーリングエラー: %v", err)
+			return
+		}
+		if len(events) > 0 {
+			lastEvent = &events[len(events)-1]
+		}
+		if len(events) < pollPageLimit {
+			break
+		}
+	}
+	if lastEvent == nil {
+		return
+	}
+
+	// 最後のイベントの作成日時を記録して、次回ポーリングの起点にする
+	if t, err := time.Parse(time.RFC3339, lastEvent.CreatedAt); err == nil {
+		o.lastPolledAt = t
+
+		// オフセットを永続化する
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := o.queries.UpsertProjectorOffset(ctx, t); err != nil {
+			log.Printf("[Saga] オフセット永続化エラー: %v", err)
+		}
+	}
+}
+
+// pollPage はEvent Storeから購読対象のイベントを1ページ取得してSagaへ渡し、取得したイベントを返す。
+func (o *Orchestrator) pollPage(path string) ([]eventStoreEvent, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	sinceParam := o.lastPolledAt.Format(time.RFC3339)
-	path := fmt.Sprintf("/api/v1/events/since?since=%s", sinceParam)
-
 	var events []eventStoreEvent
 	if err := o.eventStoreClient.GetJSON(ctx, path, &events); err != nil {
-		log.Printf("[Saga] イベントポーリングエラー: %v", err)
-		return
+		return nil, err
 	}
+	o.dispatchEvents(ctx, events)
+	return events, nil
+}
 
+// dispatchEvents はポーリングで取得したイベントを順にSagaへ渡す。
+// 同じバッチ内で撤回されたイベントとEventRetractedイベント自体は処理しない。
+// 処理済みのイベントが後から撤回された場合、Sagaの実行済みステップは自動では取り消せないためログに記録する。
+func (o *Orchestrator) dispatchEvents(ctx context.Context, events []eventStoreEvent) {
+	retractions := event.NewRetractionSet()
+	batchIDs := make(map[string]struct{}, len(events))
 	for i := range events {
-		o.HandleEvent(ctx, events[i].EventType, events[i].AggregateID, events[i].Data)
+		batchIDs[events[i].ID] = struct{}{}
+		if _, err := retractions.Observe(event.Type(events[i].EventType), []byte(events[i].Data)); err != nil {
+			log.Printf("[Saga] 撤回イベントの読み取りエラー: event_id=%s, error=%v", events[i].ID, err)
+		}
 	}
 
-	if len(events) > 0 {
-		// 最後のイベントの作成日時を記録して、次回ポーリングの起点にする
-		lastEvent := events[len(events)-1]
-		if t, err := time.Parse(time.RFC3339, lastEvent.CreatedAt); err == nil {
-			o.lastPolledAt = t
-
-			// オフセットを永続化する
-			if err := o.queries.UpsertProjectorOffset(ctx, t); err != nil {
-				log.Printf("[Saga] オフセット永続化エラー: %v", err)
+	for i := range events {
+		ev := &events[i]
+		if retractions.Contains(ev.ID) {
+			log.Printf("[Saga] 撤回されたイベントをスキップします: event_id=%s, type=%s", ev.ID, ev.EventType)
+			continue
+		}
+		if event.Type(ev.EventType) == event.TypeEventRetracted {
+			var data event.EventRetractedData
+			if err := json.Unmarshal([]byte(ev.Data), &data); err == nil {
+				if _, ok := batchIDs[data.RetractedEventID]; !ok {
+					log.Printf("[Saga] 処理済みのイベントが撤回されました。必要に応じて手動で補償してください: retracted_event_id=%s, aggregate_id=%s", data.RetractedEventID, ev.AggregateID)
+				}
 			}
+			continue
 		}
+		// Sagaが呼び出すサービスが記録するイベントを同じ一連の処理として追跡できるよう、
+		// イベントのCorrelation IDと、このイベントを原因とするCausation IDを伝播する
+		evCtx := httpclient.WithCausationID(httpclient.WithCorrelationID(ctx, ev.Metadata.CorrelationID), ev.ID)
+		o.HandleEvent(evCtx, ev.EventType, ev.AggregateID, ev.Data)
 	}
 }
 
 // HandleEvent はイベントを受信し、対応するSagaアクションを実行する。
 // ポーリングと手動通知の両方から呼び出される。
+// 購読対象外のイベントは無視する。未登録のイベントタイプはタイプミスの可能性があるため警告を出力する。
 func (o *Orchestrator) HandleEvent(ctx context.Context, eventType, aggregateID, data string) {
-	switch event.Type(eventType) {
-	case event.TypeMediaUploaded:
-		o.startMediaUploadSaga(ctx, aggregateID, data)
-	case event.TypeMediaProcessed:
-		o.advanceSagaOnProcessed(ctx, aggregateID)
-	case event.TypeMediaProcessingFailed:
-		o.compensateOnProcessingFailed(ctx, aggregateID, data)
-	case event.TypeMediaAddedToAlbum:
-		o.advanceSagaOnAlbumAdded(ctx, aggregateID)
+	if !event.IsValidType(event.Type(eventType)) {
+		log.Printf("[Saga] 警告: 未登録のイベントタイプを無視します: type=%s, aggregate_id=%s", eventType, aggregateID)
+		return
+	}
+	if handler, ok := o.handlers[event.Type(eventType)]; ok {
+		handler(ctx, aggregateID, data)
+	}
+}
+
+// sagaPayload はSagaのペイロードのJSON構造。
+type sagaPayload struct {
+	// MediaAggregateID は対象メディアのaggregate_id。
+	MediaAggregateID string `json:"media_aggregate_id"`
+	// UploadData はMediaUploadedイベントのデータ（JSON文字列）。
+	UploadData string `json:"upload_data"`
+	// StepResults は完了したステップの実行結果をステップ名ごとに保持する。
+	// 後続ステップは元のアップロードデータを再解析せず、ここから前段の出力を読み取る。
+	StepResults map[string]json.RawMessage `json:"step_results,omitempty"`
+}
+
+// processMediaResult はprocess_mediaステップの実行結果。
+type processMediaResult struct {
+	// MediaID は処理対象のメディアID。
+	MediaID string `json:"media_id"`
+	// UserID はアップロードしたユーザーのID。
+	UserID string `json:"user_id"`
+	// Filename は元のファイル名。
+	Filename string `json:"filename"`
+}
+
+// addToAlbumResult はadd_to_albumステップの実行結果。
+type addToAlbumResult struct {
+	// MediaID はアルバムに追加したメディアID。
+	MediaID string `json:"media_id"`
+	// AlbumID はメディアを追加したアルバムのID。補償時に同じアルバムから取り除くために使う。
+	// 本フィールドの導入前に完了したステップでは空になる。
+	AlbumID string `json:"album_id,omitempty"`
+}
+
+// defaultAlbumResponse はalbumサービスのデフォルトアルバム取得APIのレスポンスのうち、Sagaが使う項目。
+type defaultAlbumResponse struct {
+	// ID はユーザーのデフォルトアルバムのID。
+	ID string `json:"id"`
+}
+
+// parseSagaPayload はSagaのペイロード文字列を解析する。
+func parseSagaPayload(raw string) (*sagaPayload, error) {
+	var payload sagaPayload
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		return nil, fmt.Errorf("ペイロードの解析に失敗: %w", err)
 	}
+	return &payload, nil
+}
+
+// decodeStepResult はペイロードに蓄積された指定ステップの実行結果を型Tにデシリアライズする。
+func decodeStepResult[T any](payload *sagaPayload, stepName string) (*T, error) {
+	raw, ok := payload.StepResults[stepName]
+	if !ok {
+		return nil, fmt.Errorf("ステップ %s の実行結果がありません", stepName)
+	}
+	var result T
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("ステップ %s の実行結果の解析に失敗: %w", stepName, err)
+	}
+	return &result, nil
+}
+
+// processMediaResultOf はprocess_mediaステップの実行結果を取得する。
+// ステップ結果を持たない（本機能の導入前に開始された）Sagaでは、アップロードデータから組み立てる。
+func processMediaResultOf(payload *sagaPayload) (*processMediaResult, error) {
+	if result, err := decodeStepResult[processMediaResult](payload, "process_media"); err == nil {
+		return result, nil
+	}
+
+	var uploadData event.MediaUploadedData
+	if err := json.Unmarshal([]byte(payload.UploadData), &uploadData); err != nil {
+		return nil, fmt.Errorf("アップロードデータの解析に失敗: %w", err)
+	}
+	return &processMediaResult{
+		MediaID:  extractMediaID(payload.MediaAggregateID),
+		UserID:   uploadData.UserID,
+		Filename: uploadData.Filename,
+	}, nil
 }
 
 // startMediaUploadSaga はメディアアップロードSagaを新規開始する。
 // Step1: Sagaレコード作成 → Step2: サムネイル生成依頼
+// 同じaggregateのアクティブなSagaが既にある場合は開始しない。既存Sagaの確認と作成は
+// aggregate_id単位のロック内で行い、プッシュ通知とポーリングから同時に届いてもSagaを1つだけ作る。
 func (o *Orchestrator) startMediaUploadSaga(ctx context.Context, aggregateID, data string) {
-	sagaID := uuid.New().String()
-
-	// Sagaの初期ペイロードにメディアIDとアップロードデータを保存
-	payload, _ := json.Marshal(map[string]string{
-		"media_aggregate_id": aggregateID,
-		"upload_data":        data,
-	})
-
-	if err := o.queries.CreateSaga(ctx, sagadb.CreateSagaParams{
-		ID:          sagaID,
-		SagaType:    "media_upload",
-		CurrentStep: "process_media",
-		Payload:     string(payload),
-	}); err != nil {
-		log.Printf("[Saga] Saga作成エラー: %v", err)
+	sagaID, ok := o.createMediaUploadSaga(ctx, aggregateID, data)
+	if !ok {
 		return
 	}
 
-	log.Printf("[Saga] メディアアップロードSaga開始: saga_id=%s, aggregate_id=%s", sagaID, aggregateID)
-
 	// Step: サムネイル生成を依頼
-	o.executeStep(ctx, sagaID, "process_media", func() error {
+	o.executeStep(ctx, sagaID, "process_media", func() (any, error) {
 		// イベントデータからstorage_pathを取得する
 		var uploadData event.MediaUploadedData
 		if err := json.Unmarshal([]byte(data), &uploadData); err != nil {
-			return fmt.Errorf("アップロードデータのパースに失敗: %w", err)
+			return nil, fmt.Errorf("アップロードデータのパースに失敗: %w", err)
 		}
 
 		// media-commandの /api/v1/media/{id}/process を呼び出す
@@ -186,10 +369,52 @@ func (o *Orchestrator) startMediaUploadSaga(ctx context.Context, aggregateID, da
 			"storage_path": uploadData.StoragePath,
 			"content_type": uploadData.ContentType,
 		}
-		return o.mediaCommandClient.PostJSON(ctx, fmt.Sprintf("/api/v1/media/%s/process", mediaID), reqBody, nil)
+		if err := o.mediaCommandClient.PostJSON(ctx, fmt.Sprintf("/api/v1/media/%s/process", mediaID), reqBody, nil); err != nil {
+			return nil, err
+		}
+
+		// 後続ステップが必要とする情報を結果として残す
+		return processMediaResult{
+			MediaID:  mediaID,
+			UserID:   uploadData.UserID,
+			Filename: uploadData.Filename,
+		}, nil
 	})
 }
 
+// createMediaUploadSaga はaggregateのアクティブなSagaがなければメディアアップロードSagaのレコードを作成し、
+// 作成したSagaのIDを返す。既存のSagaがある場合や作成に失敗した場合はfalseを返す。
+func (o *Orchestrator) createMediaUploadSaga(ctx context.Context, aggregateID, data string) (string, bool) {
+	unlock := o.sagaStartLocks.lock(aggregateID)
+	defer unlock()
+
+	if existing := o.findActiveSagaByAggregateID(ctx, aggregateID); existing != nil {
+		log.Printf("[Saga] アクティブなSagaが既にあるため開始しません: saga_id=%s, aggregate_id=%s", existing.ID, aggregateID)
+		return "", false
+	}
+
+	sagaID := uuid.New().String()
+
+	// Sagaの初期ペイロードにメディアIDとアップロードデータを保存
+	payload, _ := json.Marshal(sagaPayload{
+		MediaAggregateID: aggregateID,
+		UploadData:       data,
+	})
+
+	if err := o.queries.CreateSaga(ctx, sagadb.CreateSagaParams{
+		ID:          sagaID,
+		SagaType:    "media_upload",
+		CurrentStep: "process_media",
+		Payload:     string(payload),
+	}); err != nil {
+		log.Printf("[Saga] Saga作成エラー: %v", err)
+		return "", false
+	}
+
+	log.Printf("[Saga] メディアアップロードSaga開始: saga_id=%s, aggregate_id=%s", sagaID, aggregateID)
+	return sagaID, true
+}
+
 // advanceSagaOnProcessed はMediaProcessedイベント受信時にSagaを進行させる。
 // サムネイル生成成功 → デフォルトアルバムに追加依頼
 func (o *Orchestrator) advanceSagaOnProcessed(ctx context.Context, aggregateID string) {
@@ -210,25 +435,36 @@ func (o *Orchestrator) advanceSagaOnProcessed(ctx context.Context, aggregateID s
 	}
 
 	// Step: デフォルトアルバムにメディアを追加
-	o.executeStep(ctx, saga.ID, "add_to_album", func() error {
-		var payloadMap map[string]string
-		if err := json.Unmarshal([]byte(saga.Payload), &payloadMap); err != nil {
-			return fmt.Errorf("ペイロードの解析に失敗: %w", err)
+	o.executeStep(ctx, saga.ID, "add_to_album", func() (any, error) {
+		payload, err := parseSagaPayload(saga.Payload)
+		if err != nil {
+			return nil, err
 		}
 
 		// アルバムサービスにメディア追加を依頼
-		// ユーザーIDはアップロードデータから取得
-		var uploadData event.MediaUploadedData
-		if err := json.Unmarshal([]byte(payloadMap["upload_data"]), &uploadData); err != nil {
-			return fmt.Errorf("アップロードデータの解析に失敗: %w", err)
+		// メディアIDとユーザーIDはprocess_mediaステップの結果から取得
+		processed, err := processMediaResultOf(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		// アップロードしたユーザーとしてデフォルトアルバムを解決し、そのアルバムに追加する
+		userCtx := httpclient.WithUserID(ctx, processed.UserID)
+		var album defaultAlbumResponse
+		if err := o.albumClient.GetJSON(userCtx, "/api/v1/internal/albums/default", &album); err != nil {
+			return nil, fmt.Errorf("デフォルトアルバムの取得に失敗: %w", err)
+		}
+		if album.ID == "" {
+			return nil, errors.New("デフォルトアルバムのIDが空です")
 		}
 
-		mediaID := extractMediaID(payloadMap["media_aggregate_id"])
 		addReq := map[string]string{
-			"media_id": mediaID,
-			"user_id":  uploadData.UserID,
+			"media_id": processed.MediaID,
+		}
+		if err := o.albumClient.PostJSON(userCtx, fmt.Sprintf("/api/v1/internal/albums/%s/media", album.ID), addReq, nil); err != nil {
+			return nil, err
 		}
-		return o.albumClient.PostJSON(ctx, "/api/v1/albums/default/media", addReq, nil)
+		return addToAlbumResult{MediaID: processed.MediaID, AlbumID: album.ID}, nil
 	})
 }
 
@@ -259,23 +495,23 @@ func (o *Orchestrator) advanceSagaOnAlbumAdded(ctx context.Context, aggregateID
 		}
 
 		// Step: 完了通知を送信
-		o.executeStep(ctx, saga.ID, "send_notification", func() error {
-			var payloadMap map[string]string
-			if err := json.Unmarshal([]byte(saga.Payload), &payloadMap); err != nil {
-				return fmt.Errorf("ペイロードの解析に失敗: %w", err)
+		o.executeStep(ctx, saga.ID, "send_notification", func() (any, error) {
+			payload, err := parseSagaPayload(saga.Payload)
+			if err != nil {
+				return nil, err
 			}
 
-			var uploadData event.MediaUploadedData
-			if err := json.Unmarshal([]byte(payloadMap["upload_data"]), &uploadData); err != nil {
-				return fmt.Errorf("アップロードデータの解析に失敗: %w", err)
+			processed, err := processMediaResultOf(payload)
+			if err != nil {
+				return nil, err
 			}
 
 			notifReq := map[string]string{
-				"user_id": uploadData.UserID,
+				"user_id": processed.UserID,
 				"title":   "アップロード完了",
-				"message": fmt.Sprintf("メディア「%s」のアップロードと処理が完了しました。", uploadData.Filename),
+				"message": fmt.Sprintf("メディア「%s」のアップロードと処理が完了しました。", processed.Filename),
 			}
-			return o.notificationClient.PostJSON(ctx, "/api/v1/internal/send", notifReq, nil)
+			return nil, o.notificationClient.PostJSON(ctx, "/api/v1/internal/send", notifReq, nil)
 		})
 
 		// Saga完了
@@ -288,7 +524,7 @@ func (o *Orchestrator) advanceSagaOnAlbumAdded(ctx context.Context, aggregateID
 }
 
 // compensateOnProcessingFailed はメディア処理失敗時に補償アクションを実行する。
-// サムネイル生成失敗 → アップロード済みファイルの無効化（補償）→ Saga失敗
+// サムネイル生成失敗 → 完了済みステップの補償 → Saga失敗
 func (o *Orchestrator) compensateOnProcessingFailed(ctx context.Context, aggregateID, data string) {
 	saga := o.findActiveSagaByAggregateID(ctx, aggregateID)
 	if saga == nil {
@@ -296,26 +532,133 @@ func (o *Orchestrator) compensateOnProcessingFailed(ctx context.Context, aggrega
 	}
 
 	log.Printf("[Saga] 補償アクション開始: saga_id=%s, reason=メディア処理失敗", saga.ID)
+	if _, err := o.runCompensation(ctx, saga, "サムネイル生成に失敗したため、アップロードを無効化"); err != nil {
+		log.Printf("[Saga] 補償が完了しませんでした（スタック検出または手動retryで未完了分を再試行します）: saga_id=%s, error=%v", saga.ID, err)
+	}
+}
 
-	// Sagaを補償中状態に更新
-	if err := o.queries.UpdateSagaStep(ctx, sagadb.UpdateSagaStepParams{
-		CurrentStep: "compensate_upload",
-		Status:      "compensating",
-		Payload:     saga.Payload,
-		ID:          saga.ID,
-	}); err != nil {
-		log.Printf("[Saga] Saga更新エラー: %v", err)
+// compensationStep はSagaの補償ステップ。
+type compensationStep struct {
+	// name はsaga_stepsに記録するステップ名。
+	name string
+	// action は補償処理。既に補償済みの対象に対して再実行しても安全（冪等）であること。
+	action func() (any, error)
+}
+
+// compensationPlan はSagaで実行すべき補償ステップを、順方向のステップの逆順で返す。
+// 補償に成功済みのステップは含めない。
+func (o *Orchestrator) compensationPlan(ctx context.Context, saga *sagadb.Saga, steps []sagadb.SagaStep, reason string) ([]compensationStep, error) {
+	payload, err := parseSagaPayload(saga.Payload)
+	if err != nil {
+		return nil, err
 	}
 
-	// 補償アクション: アップロード済みメディアの無効化
-	o.executeStep(ctx, saga.ID, "compensate_upload", func() error {
-		mediaID := extractMediaID(aggregateID)
-		compensateReq := map[string]string{
-			"saga_id": saga.ID,
-			"reason":  "サムネイル生成に失敗したため、アップロードを無効化",
-		}
-		return o.mediaCommandClient.PostJSON(ctx, fmt.Sprintf("/api/v1/media/%s/compensate", mediaID), compensateReq, nil)
+	var plan []compensationStep
+	// アルバムに追加済みであれば、アルバムから取り除く
+	if hasCompletedStep(steps, stepKindAction, "add_to_album") {
+		plan = append(plan, compensationStep{
+			name: "compensate_album",
+			action: func() (any, error) {
+				processed, err := processMediaResultOf(payload)
+				if err != nil {
+					return nil, err
+				}
+				// 追加先のアルバムIDを記録していない場合は、ユーザーのデフォルトアルバムから取り除く
+				albumID := "default"
+				if added, err := decodeStepResult[addToAlbumResult](payload, "add_to_album"); err == nil && added.AlbumID != "" {
+					albumID = added.AlbumID
+				}
+				userCtx := httpclient.WithUserID(ctx, processed.UserID)
+				return nil, o.albumClient.DeleteJSON(userCtx, fmt.Sprintf("/api/v1/internal/albums/%s/media/%s", albumID, processed.MediaID), nil)
+			},
+		})
+	}
+	// アップロード済みメディアを無効化する
+	if payload.MediaAggregateID != "" {
+		plan = append(plan, compensationStep{
+			name: "compensate_upload",
+			action: func() (any, error) {
+				mediaID := extractMediaID(payload.MediaAggregateID)
+				compensateReq := map[string]string{
+					"saga_id": saga.ID,
+					"reason":  reason,
+				}
+				// media-commandはアップロードしたユーザーかを照合するため、ユーザーIDを伝播する
+				userCtx := ctx
+				var uploaded event.MediaUploadedData
+				if err := json.Unmarshal([]byte(payload.UploadData), &uploaded); err == nil {
+					userCtx = httpclient.WithUserID(ctx, uploaded.UserID)
+				}
+				return nil, o.mediaCommandClient.PostJSON(userCtx, fmt.Sprintf("/api/v1/media/%s/compensate", mediaID), compensateReq, nil)
+			},
+		})
+	}
+
+	return slices.DeleteFunc(plan, func(step compensationStep) bool {
+		return hasCompletedStep(steps, stepKindCompensation, step.name)
+	}), nil
+}
+
+// hasCompletedStep は指定した種類と名前のステップが成功済みかを返す。
+func hasCompletedStep(steps []sagadb.SagaStep, kind, name string) bool {
+	return slices.ContainsFunc(steps, func(step sagadb.SagaStep) bool {
+		return step.Kind == kind && step.StepName == name && step.Status == "completed"
+	})
+}
+
+// runCompensation はSagaの未完了の補償ステップを実行し、実行後も未完了のまま残った補償ステップ名を返す。
+// 各補償ステップの成否はsaga_stepsに個別に記録する。
+// 全ての補償が完了したSagaは失敗として記録する。一部の補償だけが成功した場合はpartially_compensated、
+// 1つも成功していない場合はcompensatingとし、次回の再試行では未完了の補償ステップだけを実行する。
+func (o *Orchestrator) runCompensation(ctx context.Context, saga *sagadb.Saga, reason string) ([]string, error) {
+	steps, err := o.queries.ListSagaSteps(ctx, saga.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ステップ履歴の取得に失敗: %w", err)
+	}
+	plan, err := o.compensationPlan(ctx, saga, steps, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	compensated := slices.ContainsFunc(steps, func(step sagadb.SagaStep) bool {
+		return step.Kind == stepKindCompensation && step.Status == "completed"
 	})
+	status := func() string {
+		if compensated {
+			return "partially_compensated"
+		}
+		return "compensating"
+	}
+
+	var pending []string
+	var lastErr error
+	for _, step := range plan {
+		if err := o.queries.UpdateSagaStatus(ctx, sagadb.UpdateSagaStatusParams{
+			CurrentStep: step.name,
+			Status:      status(),
+			ID:          saga.ID,
+		}); err != nil {
+			log.Printf("[Saga] Saga更新エラー: %v", err)
+		}
+		// 補償ステップ同士は独立しているため、失敗しても残りの補償ステップを実行する
+		if err := o.runStep(ctx, saga.ID, step.name, stepKindCompensation, step.action); err != nil {
+			pending = append(pending, step.name)
+			lastErr = err
+			continue
+		}
+		compensated = true
+	}
+
+	if len(pending) > 0 {
+		if err := o.queries.UpdateSagaStatus(ctx, sagadb.UpdateSagaStatusParams{
+			CurrentStep: pending[0],
+			Status:      status(),
+			ID:          saga.ID,
+		}); err != nil {
+			log.Printf("[Saga] Saga更新エラー: %v", err)
+		}
+		return pending, fmt.Errorf("未完了の補償ステップがあります（%s）: %w", strings.Join(pending, ", "), lastErr)
+	}
 
 	// Saga失敗として記録
 	if err := o.queries.FailSaga(ctx, saga.ID); err != nil {
@@ -323,11 +666,18 @@ func (o *Orchestrator) compensateOnProcessingFailed(ctx context.Context, aggrega
 	} else {
 		log.Printf("[Saga] メディアアップロードSaga失敗（補償完了）: saga_id=%s", saga.ID)
 	}
+	return nil, nil
 }
 
 // executeStep はSagaのステップをリトライ付きで実行し、結果をDBに記録する。
-// 最大maxRetries回まで指数バックオフでリトライする。
-func (o *Orchestrator) executeStep(ctx context.Context, sagaID, stepName string, action func() error) {
+// 最大maxRetries回まで指数バックオフでリトライし、全リトライに失敗した場合は最後のエラーを返す。
+// actionが返した結果はステップ履歴に保存し、Sagaのペイロードにも統合して後続ステップから参照できるようにする。
+func (o *Orchestrator) executeStep(ctx context.Context, sagaID, stepName string, action func() (any, error)) error {
+	return o.runStep(ctx, sagaID, stepName, stepKindAction, action)
+}
+
+// runStep は種類kindのステップをリトライ付きで実行し、結果をDBに記録する。
+func (o *Orchestrator) runStep(ctx context.Context, sagaID, stepName, kind string, action func() (any, error)) error {
 	stepID := uuid.New().String()
 
 	// ステップ開始を記録
@@ -336,6 +686,7 @@ func (o *Orchestrator) executeStep(ctx context.Context, sagaID, stepName string,
 		SagaID:   sagaID,
 		StepName: stepName,
 		Status:   "executing",
+		Kind:     kind,
 	}); err != nil {
 		log.Printf("[Saga] ステップ記録エラー: %v", err)
 	}
@@ -344,17 +695,28 @@ func (o *Orchestrator) executeStep(ctx context.Context, sagaID, stepName string,
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		// 2回目以降は指数バックオフで待機
 		if attempt > 0 {
-			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+			backoff := o.retryBackoff << uint(attempt-1)
 			log.Printf("[Saga] ステップ %s リトライ %d/%d（%v後）: saga_id=%s", stepName, attempt, maxRetries, backoff, sagaID)
 			time.Sleep(backoff)
 		}
 
-		lastErr = action()
+		var result any
+		result, lastErr = action()
 		if lastErr == nil {
 			// 成功
+			resultJSON := []byte("{}")
+			if result != nil {
+				b, err := json.Marshal(result)
+				if err != nil {
+					log.Printf("[Saga] ステップ結果のシリアライズエラー: step=%s, error=%v, saga_id=%s", stepName, err, sagaID)
+				} else {
+					resultJSON = b
+					o.mergeStepResult(ctx, sagaID, stepName, b)
+				}
+			}
 			_ = o.queries.UpdateSagaStepStatus(ctx, sagadb.UpdateSagaStepStatusParams{
 				Status: "completed",
-				Result: "{}",
+				Result: string(resultJSON),
 				ID:     stepID,
 			})
 			if attempt > 0 {
@@ -366,7 +728,7 @@ func (o *Orchestrator) executeStep(ctx context.Context, sagaID, stepName string,
 					ID:         stepID,
 				})
 			}
-			return
+			return nil
 		}
 
 		// リトライ情報をDBに記録
@@ -386,6 +748,52 @@ func (o *Orchestrator) executeStep(ctx context.Context, sagaID, stepName string,
 		Result: string(resultJSON),
 		ID:     stepID,
 	})
+	return lastErr
+}
+
+// mergeStepResult はステップの実行結果をSagaのペイロードのstep_resultsに統合する。
+// ペイロードの他のキーはそのまま保持する。
+func (o *Orchestrator) mergeStepResult(ctx context.Context, sagaID, stepName string, result json.RawMessage) {
+	saga, err := o.queries.GetSagaByID(ctx, sagaID)
+	if err != nil {
+		log.Printf("[Saga] ステップ結果統合のためのSaga取得エラー: saga_id=%s, error=%v", sagaID, err)
+		return
+	}
+
+	payload := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(saga.Payload), &payload); err != nil {
+		log.Printf("[Saga] ペイロード解析エラー: saga_id=%s, error=%v", sagaID, err)
+		return
+	}
+
+	stepResults := map[string]json.RawMessage{}
+	if raw, ok := payload["step_results"]; ok {
+		if err := json.Unmarshal(raw, &stepResults); err != nil {
+			log.Printf("[Saga] ステップ結果の解析エラー: saga_id=%s, error=%v", sagaID, err)
+			return
+		}
+	}
+	stepResults[stepName] = result
+
+	merged, err := json.Marshal(stepResults)
+	if err != nil {
+		log.Printf("[Saga] ステップ結果のシリアライズエラー: saga_id=%s, error=%v", sagaID, err)
+		return
+	}
+	payload["step_results"] = merged
+
+	updated, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("[Saga] ペイロードのシリアライズエラー: saga_id=%s, error=%v", sagaID, err)
+		return
+	}
+
+	if err := o.queries.UpdateSagaPayload(ctx, sagadb.UpdateSagaPayloadParams{
+		Payload: string(updated),
+		ID:      sagaID,
+	}); err != nil {
+		log.Printf("[Saga] ペイロード更新エラー: saga_id=%s, error=%v", sagaID, err)
+	}
 }
 
 // startStuckSagaDetector はスタックしたSagaを定期的に検出して処理するバックグラウンドループ。
@@ -401,6 +809,8 @@ func (o *Orchestrator) startStuckSagaDetector() {
 }
 
 // checkStuckSagas はスタックしたSagaを検出し、適切な処理を行う。
+// 検出のたびにSagaの試行回数を加算し、上限（maxAttempts）に達したSagaは再試行せずdead_letterに移す。
+// 再補償に失敗したSagaは補償中のまま残し、次回の検出で未完了の補償ステップだけを再試行する。
 func (o *Orchestrator) checkStuckSagas() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -416,29 +826,24 @@ func (o *Orchestrator) checkStuckSagas() {
 		log.Printf("[Saga] スタックSaga検出: saga_id=%s, status=%s, current_step=%s, updated_at=%s",
 			saga.ID, saga.Status, saga.CurrentStep, saga.UpdatedAt.Format(time.RFC3339))
 
+		if saga.Attempts >= int64(o.maxAttempts) {
+			log.Printf("[Saga] 試行回数の上限に達したためdead_letterに移します: saga_id=%s, attempts=%d/%d", saga.ID, saga.Attempts, o.maxAttempts)
+			if err := o.queries.DeadLetterSaga(ctx, saga.ID); err != nil {
+				log.Printf("[Saga] dead_letter記録エラー: saga_id=%s, error=%v", saga.ID, err)
+			}
+			continue
+		}
+		if err := o.queries.IncrementSagaAttempts(ctx, saga.ID); err != nil {
+			log.Printf("[Saga] 試行回数の記録エラー: saga_id=%s, error=%v", saga.ID, err)
+			continue
+		}
+
 		switch saga.Status {
-		case "compensating":
-			// 補償中のSagaは再補償を試行
+		case "compensating", "partially_compensated":
+			// 補償中のSagaは未完了の補償ステップだけを再試行
 			log.Printf("[Saga] 補償中のスタックSagaを再補償します: saga_id=%s", saga.ID)
-			var payloadMap map[string]string
-			if err := json.Unmarshal([]byte(saga.Payload), &payloadMap); err != nil {
-				log.Printf("[Saga] ペイロード解析エラー: saga_id=%s, error=%v", saga.ID, err)
-				continue
-			}
-			aggregateID := payloadMap["media_aggregate_id"]
-			if aggregateID != "" {
-				o.executeStep(ctx, saga.ID, "compensate_upload_retry", func() error {
-					mediaID := extractMediaID(aggregateID)
-					compensateReq := map[string]string{
-						"saga_id": saga.ID,
-						"reason":  "スタック検出による再補償",
-					}
-					return o.mediaCommandClient.PostJSON(ctx, fmt.Sprintf("/api/v1/media/%s/compensate", mediaID), compensateReq, nil)
-				})
-			}
-			// 再補償後に失敗としてマーク
-			if err := o.queries.FailSaga(ctx, saga.ID); err != nil {
-				log.Printf("[Saga] Saga失敗記録エラー: %v", err)
+			if _, err := o.runCompensation(ctx, &saga, "スタック検出による再補償"); err != nil {
+				log.Printf("[Saga] 再補償に失敗しました（次回の検出で再試行します）: saga_id=%s, attempts=%d/%d, error=%v", saga.ID, saga.Attempts+1, o.maxAttempts, err)
 			}
 		case "in_progress":
 			// 進行中のスタックSagaは失敗としてマーク
@@ -459,11 +864,11 @@ func (o *Orchestrator) findActiveSagaByAggregateID(ctx context.Context, aggregat
 	}
 
 	for _, saga := range sagas {
-		var payloadMap map[string]string
-		if err := json.Unmarshal([]byte(saga.Payload), &payloadMap); err != nil {
+		payload, err := parseSagaPayload(saga.Payload)
+		if err != nil {
 			continue
 		}
-		if payloadMap["media_aggregate_id"] == aggregateID {
+		if payload.MediaAggregateID == aggregateID {
 			return &saga
 		}
 	}
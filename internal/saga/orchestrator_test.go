@@ -0,0 +1,709 @@
+package saga
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sagadb "github.com/nao1215/micro/internal/saga/db"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// TestExecuteStepResult はステップの実行結果がペイロードに統合され、後続ステップから参照できることを検証する。
+func TestExecuteStepResult(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ステップ1が返した値をステップ2がペイロードから読み取れる", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		seedSaga(t, s, "saga-result", "media_upload", "process_media", "started",
+			`{"media_aggregate_id":"media-1","upload_data":"{}"}`)
+
+		type step1Result struct {
+			Value string `json:"value"`
+		}
+
+		ctx := context.Background()
+		s.orchestrator.executeStep(ctx, "saga-result", "step1", func() (any, error) {
+			return step1Result{Value: "from-step1"}, nil
+		})
+
+		var consumed string
+		s.orchestrator.executeStep(ctx, "saga-result", "step2", func() (any, error) {
+			saga, err := s.queries.GetSagaByID(ctx, "saga-result")
+			if err != nil {
+				return nil, err
+			}
+			payload, err := parseSagaPayload(saga.Payload)
+			if err != nil {
+				return nil, err
+			}
+			prev, err := decodeStepResult[step1Result](payload, "step1")
+			if err != nil {
+				return nil, err
+			}
+			consumed = prev.Value
+			return nil, nil
+		})
+
+		if consumed != "from-step1" {
+			t.Errorf("ステップ2が読み取った値: got %q, want %q", consumed, "from-step1")
+		}
+
+		saga, err := s.queries.GetSagaByID(ctx, "saga-result")
+		if err != nil {
+			t.Fatalf("Saga取得に失敗: %v", err)
+		}
+		payload, err := parseSagaPayload(saga.Payload)
+		if err != nil {
+			t.Fatalf("ペイロード解析に失敗: %v", err)
+		}
+		if payload.MediaAggregateID != "media-1" {
+			t.Errorf("media_aggregate_idが保持されていない: got %q", payload.MediaAggregateID)
+		}
+		if _, ok := payload.StepResults["step2"]; ok {
+			t.Error("結果を返さないステップがstep_resultsに統合されている")
+		}
+
+		steps, err := s.queries.ListSagaSteps(ctx, "saga-result")
+		if err != nil {
+			t.Fatalf("ステップ取得に失敗: %v", err)
+		}
+		if len(steps) != 2 {
+			t.Fatalf("ステップ数: got %d, want 2", len(steps))
+		}
+		results := map[string]string{}
+		for _, step := range steps {
+			results[step.StepName] = step.Result
+		}
+		if results["step1"] != `{"value":"from-step1"}` {
+			t.Errorf("step1のResult: got %q, want %q", results["step1"], `{"value":"from-step1"}`)
+		}
+		if results["step2"] != "{}" {
+			t.Errorf("step2のResult: got %q, want %q", results["step2"], "{}")
+		}
+	})
+
+	t.Run("存在しないステップの結果を読み取るとエラーになる", func(t *testing.T) {
+		t.Parallel()
+
+		payload := &sagaPayload{}
+		if _, err := decodeStepResult[processMediaResult](payload, "process_media"); err == nil {
+			t.Error("エラーが返されるべきだが、nilが返った")
+		}
+	})
+}
+
+// TestMediaUploadSagaStepResults はprocess_mediaの結果をadd_to_albumステップが利用することを検証する。
+func TestMediaUploadSagaStepResults(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+
+	mediaCommand := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer mediaCommand.Close()
+
+	// ユーザーごとにデフォルトアルバムを持つalbumサービスのモック
+	var (
+		mu        sync.Mutex
+		albumReq  map[string]string
+		albumPath string
+		albumUser string
+	)
+	album := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/internal/albums/default" {
+			_, _ = w.Write([]byte(`{"id":"default-` + r.Header.Get("X-User-ID") + `"}`))
+			return
+		}
+		albumPath = r.Method + " " + r.URL.Path
+		albumUser = r.Header.Get("X-User-ID")
+		_ = json.NewDecoder(r.Body).Decode(&albumReq)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer album.Close()
+
+	s.orchestrator = NewOrchestrator(
+		s.queries,
+		httpclient.New("http://localhost:19001"),
+		httpclient.New(mediaCommand.URL),
+		httpclient.New(album.URL),
+		httpclient.New("http://localhost:19004"),
+	)
+
+	ctx := context.Background()
+	s.orchestrator.HandleEvent(ctx, "MediaUploaded", "media-abc",
+		`{"user_id":"u-1","filename":"photo.jpg","content_type":"image/png","storage_path":"/data/media/abc/photo.jpg"}`)
+
+	saga := s.orchestrator.findActiveSagaByAggregateID(ctx, "media-abc")
+	if saga == nil {
+		t.Fatal("アクティブなSagaが見つからない")
+	}
+	payload, err := parseSagaPayload(saga.Payload)
+	if err != nil {
+		t.Fatalf("ペイロード解析に失敗: %v", err)
+	}
+	processed, err := decodeStepResult[processMediaResult](payload, "process_media")
+	if err != nil {
+		t.Fatalf("process_mediaの結果取得に失敗: %v", err)
+	}
+	if processed.UserID != "u-1" || processed.Filename != "photo.jpg" || processed.MediaID != "media-abc" {
+		t.Errorf("process_mediaの結果が不正: %+v", processed)
+	}
+
+	s.orchestrator.HandleEvent(ctx, "MediaProcessed", "media-abc", "")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if albumPath != "POST /api/v1/internal/albums/default-u-1/media" {
+		t.Errorf("アルバム追加リクエスト: got %q, want %q", albumPath, "POST /api/v1/internal/albums/default-u-1/media")
+	}
+	if albumUser != "u-1" {
+		t.Errorf("アルバム追加リクエストのX-User-ID: got %q, want %q", albumUser, "u-1")
+	}
+	if albumReq["media_id"] != "media-abc" {
+		t.Errorf("アルバム追加リクエストのmedia_id: got %q, want %q", albumReq["media_id"], "media-abc")
+	}
+
+	stored, err := s.queries.GetSagaByID(ctx, saga.ID)
+	if err != nil {
+		t.Fatalf("Saga取得に失敗: %v", err)
+	}
+	payload, err = parseSagaPayload(stored.Payload)
+	if err != nil {
+		t.Fatalf("ペイロード解析に失敗: %v", err)
+	}
+	added, err := decodeStepResult[addToAlbumResult](payload, "add_to_album")
+	if err != nil {
+		t.Fatalf("add_to_albumの結果取得に失敗: %v", err)
+	}
+	if added.AlbumID != "default-u-1" {
+		t.Errorf("add_to_albumの結果のalbum_id: got %q, want %q", added.AlbumID, "default-u-1")
+	}
+}
+
+// backdateSaga はSagaの最終更新日時をスタック判定の閾値より前に戻す。
+func backdateSaga(t *testing.T, s *Server, id string) {
+	t.Helper()
+
+	if _, err := s.db.Exec(`UPDATE sagas SET updated_at = datetime('now', '-10 minutes') WHERE id = ?`, id); err != nil {
+		t.Fatalf("Sagaの更新日時の変更に失敗: %v", err)
+	}
+}
+
+// TestCheckStuckSagasAttemptBudget はスタック検出による再試行が試行回数の上限で打ち切られることを検証する。
+func TestCheckStuckSagasAttemptBudget(t *testing.T) {
+	t.Parallel()
+
+	// newCompensateServer は補償リクエストの受信回数を数えるmedia-commandのモックを起動する。
+	newCompensateServer := func(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+		t.Helper()
+
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		t.Cleanup(srv.Close)
+		return srv, &calls
+	}
+
+	t.Run("再補償が失敗し続けるSagaは上限到達後にdead_letterに移され再試行されない", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		mediaCommand, calls := newCompensateServer(t, http.StatusInternalServerError)
+		s.orchestrator = NewOrchestrator(
+			s.queries,
+			httpclient.New("http://localhost:19001"),
+			httpclient.New(mediaCommand.URL),
+			httpclient.New("http://localhost:19003"),
+			httpclient.New("http://localhost:19004"),
+		)
+		s.orchestrator.maxAttempts = 2
+		s.orchestrator.retryBackoff = time.Millisecond
+
+		seedSaga(t, s, "saga-budget", "media_upload", "compensate_upload", "compensating",
+			`{"media_aggregate_id":"media-1","upload_data":"{}"}`)
+
+		ctx := context.Background()
+		callsPerAttempt := int32(maxRetries + 1)
+
+		// 上限までは補償中のまま再試行される
+		for attempt := 1; attempt <= 2; attempt++ {
+			backdateSaga(t, s, "saga-budget")
+			s.orchestrator.checkStuckSagas()
+
+			saga, err := s.queries.GetSagaByID(ctx, "saga-budget")
+			if err != nil {
+				t.Fatalf("Saga取得に失敗: %v", err)
+			}
+			if saga.Status != "compensating" {
+				t.Fatalf("%d回目の検出後のstatus: got %q, want %q", attempt, saga.Status, "compensating")
+			}
+			if saga.Attempts != int64(attempt) {
+				t.Errorf("%d回目の検出後のattempts: got %d, want %d", attempt, saga.Attempts, attempt)
+			}
+			if got := calls.Load(); got != callsPerAttempt*int32(attempt) {
+				t.Errorf("%d回目の検出後の補償リクエスト数: got %d, want %d", attempt, got, callsPerAttempt*int32(attempt))
+			}
+		}
+
+		// 上限到達後は補償せずdead_letterに移す
+		backdateSaga(t, s, "saga-budget")
+		s.orchestrator.checkStuckSagas()
+
+		saga, err := s.queries.GetSagaByID(ctx, "saga-budget")
+		if err != nil {
+			t.Fatalf("Saga取得に失敗: %v", err)
+		}
+		if saga.Status != "dead_letter" {
+			t.Fatalf("上限到達後のstatus: got %q, want %q", saga.Status, "dead_letter")
+		}
+		if !saga.CompletedAt.Valid {
+			t.Error("dead_letterのSagaにcompleted_atが設定されていない")
+		}
+		if got := calls.Load(); got != callsPerAttempt*2 {
+			t.Errorf("上限到達後に補償リクエストが送信された: got %d, want %d", got, callsPerAttempt*2)
+		}
+
+		// dead_letterのSagaは以降の検出対象にならない
+		backdateSaga(t, s, "saga-budget")
+		s.orchestrator.checkStuckSagas()
+		if got := calls.Load(); got != callsPerAttempt*2 {
+			t.Errorf("dead_letterのSagaが再試行された: got %d, want %d", got, callsPerAttempt*2)
+		}
+		active := s.orchestrator.findActiveSagaByAggregateID(ctx, "media-1")
+		if active != nil {
+			t.Errorf("dead_letterのSagaがアクティブとして扱われている: %+v", active)
+		}
+	})
+
+	t.Run("再補償に成功したSagaは失敗として記録される", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		mediaCommand, calls := newCompensateServer(t, http.StatusOK)
+		s.orchestrator = NewOrchestrator(
+			s.queries,
+			httpclient.New("http://localhost:19001"),
+			httpclient.New(mediaCommand.URL),
+			httpclient.New("http://localhost:19003"),
+			httpclient.New("http://localhost:19004"),
+		)
+
+		seedSaga(t, s, "saga-recovered", "media_upload", "compensate_upload", "compensating",
+			`{"media_aggregate_id":"media-2","upload_data":"{}"}`)
+		backdateSaga(t, s, "saga-recovered")
+		s.orchestrator.checkStuckSagas()
+
+		saga, err := s.queries.GetSagaByID(context.Background(), "saga-recovered")
+		if err != nil {
+			t.Fatalf("Saga取得に失敗: %v", err)
+		}
+		if saga.Status != "failed" {
+			t.Errorf("status: got %q, want %q", saga.Status, "failed")
+		}
+		if saga.Attempts != 1 {
+			t.Errorf("attempts: got %d, want 1", saga.Attempts)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("補償リクエスト数: got %d, want 1", got)
+		}
+	})
+}
+
+// TestPartialCompensation は一部の補償ステップだけが成功したSagaが、未完了の補償ステップだけを再試行することを検証する。
+func TestPartialCompensation(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+
+	var (
+		albumFailing atomic.Bool
+		albumCalls   atomic.Int32
+		albumPath    atomic.Value
+		mediaCalls   atomic.Int32
+		mediaUserID  atomic.Value
+	)
+	albumFailing.Store(true)
+	album := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		albumCalls.Add(1)
+		albumPath.Store(r.Method + " " + r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if albumFailing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer album.Close()
+	mediaCommand := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaCalls.Add(1)
+		mediaUserID.Store(r.Header.Get("X-User-ID"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer mediaCommand.Close()
+
+	s.orchestrator = NewOrchestrator(
+		s.queries,
+		httpclient.New("http://localhost:19001"),
+		httpclient.New(mediaCommand.URL),
+		httpclient.New(album.URL),
+		httpclient.New("http://localhost:19004"),
+	)
+	s.orchestrator.retryBackoff = time.Millisecond
+
+	seedSaga(t, s, "saga-partial", "media_upload", "send_notification", "in_progress",
+		`{"media_aggregate_id":"media-p","upload_data":"{\"user_id\":\"u-1\"}","step_results":{"process_media":{"media_id":"media-p","user_id":"u-1","filename":"a.jpg"},"add_to_album":{"media_id":"media-p","album_id":"album-u-1"}}}`)
+	seedSagaStep(t, s, "step-process", "saga-partial", "process_media", "completed")
+	seedSagaStep(t, s, "step-album", "saga-partial", "add_to_album", "completed")
+
+	ctx := context.Background()
+	saga, err := s.queries.GetSagaByID(ctx, "saga-partial")
+	if err != nil {
+		t.Fatalf("Saga取得に失敗: %v", err)
+	}
+
+	// アルバムからの削除だけが失敗する
+	pending, err := s.orchestrator.runCompensation(ctx, &saga, "テスト")
+	if err == nil {
+		t.Fatal("未完了の補償ステップが残った場合はエラーが返るべき")
+	}
+	if len(pending) != 1 || pending[0] != "compensate_album" {
+		t.Errorf("未完了の補償ステップ: got %v, want [compensate_album]", pending)
+	}
+	saga, err = s.queries.GetSagaByID(ctx, "saga-partial")
+	if err != nil {
+		t.Fatalf("Saga取得に失敗: %v", err)
+	}
+	if saga.Status != "partially_compensated" {
+		t.Errorf("status: got %q, want %q", saga.Status, "partially_compensated")
+	}
+	if saga.CurrentStep != "compensate_album" {
+		t.Errorf("current_step: got %q, want %q", saga.CurrentStep, "compensate_album")
+	}
+	if got := albumCalls.Load(); got != int32(maxRetries+1) {
+		t.Errorf("アルバム削除リクエスト数: got %d, want %d", got, maxRetries+1)
+	}
+	if got := albumPath.Load(); got != "DELETE /api/v1/internal/albums/album-u-1/media/media-p" {
+		t.Errorf("アルバム削除リクエスト: got %v", got)
+	}
+	if got := mediaCalls.Load(); got != 1 {
+		t.Errorf("アップロード無効化リクエスト数: got %d, want 1", got)
+	}
+	// media-commandが所有者を照合できるよう、アップロードしたユーザーを伝播する
+	if got := mediaUserID.Load(); got != "u-1" {
+		t.Errorf("アップロード無効化リクエストのX-User-ID: got %v, want u-1", got)
+	}
+
+	// 補償ステップは成否ごとに記録される
+	steps, err := s.queries.ListSagaSteps(ctx, "saga-partial")
+	if err != nil {
+		t.Fatalf("ステップ取得に失敗: %v", err)
+	}
+	compensations := map[string]string{}
+	for _, step := range steps {
+		if step.Kind == stepKindCompensation {
+			compensations[step.StepName] = step.Status
+		}
+	}
+	if compensations["compensate_album"] != "failed" || compensations["compensate_upload"] != "completed" {
+		t.Errorf("補償ステップの記録: got %v", compensations)
+	}
+
+	// スタック検出による再試行では未完了のアルバム削除だけを実行する
+	albumFailing.Store(false)
+	backdateSaga(t, s, "saga-partial")
+	s.orchestrator.checkStuckSagas()
+
+	saga, err = s.queries.GetSagaByID(ctx, "saga-partial")
+	if err != nil {
+		t.Fatalf("Saga取得に失敗: %v", err)
+	}
+	if saga.Status != "failed" {
+		t.Errorf("再試行後のstatus: got %q, want %q", saga.Status, "failed")
+	}
+	if got := albumCalls.Load(); got != int32(maxRetries+2) {
+		t.Errorf("再試行後のアルバム削除リクエスト数: got %d, want %d", got, maxRetries+2)
+	}
+	if got := mediaCalls.Load(); got != 1 {
+		t.Errorf("補償済みのアップロード無効化が再実行された: got %d, want 1", got)
+	}
+}
+
+// TestPollSubscribedEventTypes はpollがSagaの関心を持つイベントタイプのみをEvent Storeに要求することを検証する。
+func TestPollSubscribedEventTypes(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+
+	var (
+		mu    sync.Mutex
+		query url.Values
+	)
+	eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		query = r.URL.Query()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`[{"id":"ev-1","aggregate_id":"media-poll","aggregate_type":"Media","event_type":"MediaUploaded","data":"{\"user_id\":\"u-1\"}","version":1,"created_at":"2026-01-01T00:00:00Z"}]`))
+	}))
+	defer eventStore.Close()
+
+	mediaCommand := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer mediaCommand.Close()
+
+	s.orchestrator = NewOrchestrator(
+		s.queries,
+		httpclient.New(eventStore.URL),
+		httpclient.New(mediaCommand.URL),
+		httpclient.New("http://localhost:19003"),
+		httpclient.New("http://localhost:19004"),
+	)
+	s.orchestrator.poll()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := "EventRetracted,MediaAddedToAlbum,MediaProcessed,MediaProcessingFailed,MediaUploaded"
+	if got := query.Get("types"); got != want {
+		t.Errorf("typesクエリパラメータ: got %q, want %q", got, want)
+	}
+	if query.Get("since") == "" {
+		t.Error("sinceクエリパラメータが指定されていない")
+	}
+	if got := query.Get("limit"); got != strconv.Itoa(pollPageLimit) {
+		t.Errorf("limitクエリパラメータ: got %q, want %d", got, pollPageLimit)
+	}
+
+	// 取得したイベントでSagaが開始される
+	if saga := s.orchestrator.findActiveSagaByAggregateID(context.Background(), "media-poll"); saga == nil {
+		t.Error("取得したMediaUploadedイベントでSagaが開始されていない")
+	}
+	if got := s.orchestrator.lastPolledAt.Format(time.RFC3339); got != "2026-01-01T00:00:00Z" {
+		t.Errorf("lastPolledAt: got %s, want 2026-01-01T00:00:00Z", got)
+	}
+}
+
+// TestPollSkipsRetractedEvents は同じバッチ内で撤回されたイベントでSagaが開始されないことを検証する。
+func TestPollSkipsRetractedEvents(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+
+	eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`[` +
+			`{"id":"ev-1","aggregate_id":"media-retracted","aggregate_type":"Media","event_type":"MediaUploaded","data":"{\"user_id\":\"u-1\"}","version":1,"created_at":"2026-01-01T00:00:00Z"},` +
+			`{"id":"ev-2","aggregate_id":"media-retracted","aggregate_type":"Media","event_type":"EventRetracted","data":"{\"retracted_event_id\":\"ev-1\",\"reason\":\"誤記録\"}","version":2,"created_at":"2026-01-01T00:00:01Z"},` +
+			`{"id":"ev-3","aggregate_id":"media-kept","aggregate_type":"Media","event_type":"MediaUploaded","data":"{\"user_id\":\"u-2\"}","version":1,"created_at":"2026-01-01T00:00:02Z"}` +
+			`]`))
+	}))
+	defer eventStore.Close()
+
+	mediaCommand := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer mediaCommand.Close()
+
+	s.orchestrator = NewOrchestrator(
+		s.queries,
+		httpclient.New(eventStore.URL),
+		httpclient.New(mediaCommand.URL),
+		httpclient.New("http://localhost:19003"),
+		httpclient.New("http://localhost:19004"),
+	)
+	s.orchestrator.poll()
+
+	ctx := context.Background()
+	if saga := s.orchestrator.findActiveSagaByAggregateID(ctx, "media-retracted"); saga != nil {
+		t.Error("撤回されたMediaUploadedイベントでSagaが開始されている")
+	}
+	if saga := s.orchestrator.findActiveSagaByAggregateID(ctx, "media-kept"); saga == nil {
+		t.Error("撤回されていないMediaUploadedイベントでSagaが開始されていない")
+	}
+	if got := s.orchestrator.lastPolledAt.Format(time.RFC3339); got != "2026-01-01T00:00:02Z" {
+		t.Errorf("lastPolledAt: got %s, want 2026-01-01T00:00:02Z", got)
+	}
+}
+
+// TestPollPropagatesEventMetadata はSagaが呼び出すサービスへ、イベントのCorrelation IDと
+// そのイベントを原因とするCausation IDを伝播することを検証する。
+func TestPollPropagatesEventMetadata(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+
+	eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`[` +
+			`{"id":"ev-uploaded","aggregate_id":"media-traced","aggregate_type":"Media","event_type":"MediaUploaded","data":"{\"user_id\":\"u-1\"}","version":1,"created_at":"2026-01-01T00:00:00Z","metadata":{"correlation_id":"correlation-1"}}` +
+			`]`))
+	}))
+	defer eventStore.Close()
+
+	var (
+		mu             sync.Mutex
+		gotCorrelation string
+		gotCausation   string
+	)
+	mediaCommand := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		gotCorrelation = r.Header.Get(httpclient.CorrelationIDHeader)
+		gotCausation = r.Header.Get(httpclient.CausationIDHeader)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer mediaCommand.Close()
+
+	s.orchestrator = NewOrchestrator(
+		s.queries,
+		httpclient.New(eventStore.URL),
+		httpclient.New(mediaCommand.URL),
+		httpclient.New("http://localhost:19003"),
+		httpclient.New("http://localhost:19004"),
+	)
+	s.orchestrator.poll()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if gotCorrelation != "correlation-1" {
+		t.Errorf("%s: got %q, want %q", httpclient.CorrelationIDHeader, gotCorrelation, "correlation-1")
+	}
+	if gotCausation != "ev-uploaded" {
+		t.Errorf("%s: got %q, want %q", httpclient.CausationIDHeader, gotCausation, "ev-uploaded")
+	}
+}
+
+// slowCreateSagaDB はSagaの作成だけを遅延させるDB接続。
+type slowCreateSagaDB struct {
+	*sql.DB
+}
+
+// ExecContext はSagaの作成の場合に少し待ってからクエリを実行する。
+func (db slowCreateSagaDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if strings.Contains(query, "INSERT INTO sagas") {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+// TestStartMediaUploadSagaConcurrent はプッシュ通知とポーリングから同じMediaUploadedイベントが
+// 同時に届いても、Sagaが1つだけ作られることを検証する。
+func TestStartMediaUploadSagaConcurrent(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+
+	const data = `{"user_id":"u-1","filename":"photo.jpg","content_type":"image/png","storage_path":"/data/media/race/photo.jpg"}`
+	eventStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		ev, _ := json.Marshal([]eventStoreEvent{{
+			ID:            "ev-race",
+			AggregateID:   "media-race",
+			AggregateType: "Media",
+			EventType:     "MediaUploaded",
+			Data:          data,
+			Version:       1,
+			CreatedAt:     "2026-01-01T00:00:00Z",
+		}})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(ev)
+	}))
+	defer eventStore.Close()
+
+	var processCalls atomic.Int32
+	mediaCommand := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		processCalls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer mediaCommand.Close()
+
+	// 既存Sagaの確認から作成までの間に他の経路が割り込めるよう、Sagaの作成を遅くする
+	s.orchestrator = NewOrchestrator(
+		sagadb.New(slowCreateSagaDB{DB: s.db}),
+		httpclient.New(eventStore.URL),
+		httpclient.New(mediaCommand.URL),
+		httpclient.New("http://localhost:19003"),
+		httpclient.New("http://localhost:19004"),
+	)
+
+	notifyBody, _ := json.Marshal(eventNotifyRequest{EventType: "MediaUploaded", AggregateID: "media-race", Data: data})
+	const notifiers = 8
+
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+	)
+	for range notifiers {
+		wg.Go(func() {
+			<-start
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events/notify", bytes.NewReader(notifyBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+			}
+		})
+	}
+	for range 2 {
+		wg.Go(func() {
+			<-start
+			s.orchestrator.poll()
+		})
+	}
+	close(start)
+	wg.Wait()
+
+	sagas, err := s.queries.ListActiveSagas(context.Background())
+	if err != nil {
+		t.Fatalf("アクティブSaga取得に失敗: %v", err)
+	}
+	var count int
+	for _, saga := range sagas {
+		payload, err := parseSagaPayload(saga.Payload)
+		if err == nil && payload.MediaAggregateID == "media-race" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("作成されたSagaの数: got %d, want 1", count)
+	}
+	if got := processCalls.Load(); got != 1 {
+		t.Errorf("サムネイル生成の依頼回数: got %d, want 1", got)
+	}
+}
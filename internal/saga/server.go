@@ -1,16 +1,23 @@
 package saga
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	_ "modernc.org/sqlite"
 	sagadb "github.com/nao1215/micro/internal/saga/db"
+	"github.com/nao1215/micro/pkg/event"
 	"github.com/nao1215/micro/pkg/httpclient"
 	"github.com/nao1215/micro/pkg/middleware"
+	"github.com/nao1215/micro/pkg/query"
 )
 
 // Server はSagaオーケストレータサービスのHTTPサーバー。
@@ -57,18 +64,26 @@ func NewServer(port string) (*Server, error) {
 
 	queries := sagadb.New(sqlDB)
 
+	// INTERNAL_SIGNING_KEYが設定されている場合は、各サービスへのリクエストに署名して改ざんと再送を防ぐ
+	signingKey := httpclient.WithSigningKey(os.Getenv("INTERNAL_SIGNING_KEY"))
 	orch := NewOrchestrator(
 		queries,
-		httpclient.New(eventstoreURL),
-		httpclient.New(mediaCommandURL),
-		httpclient.New(albumURL),
-		httpclient.New(notificationURL),
+		httpclient.New(eventstoreURL, signingKey),
+		httpclient.New(mediaCommandURL, signingKey),
+		httpclient.New(albumURL, signingKey),
+		httpclient.New(notificationURL, signingKey),
 	)
+	if v := os.Getenv("SAGA_MAX_ATTEMPTS"); v != "" {
+		maxAttempts, err := strconv.Atoi(v)
+		if err != nil || maxAttempts < 1 {
+			return nil, fmt.Errorf("SAGA_MAX_ATTEMPTSは1以上の整数で指定してください: %q", v)
+		}
+		orch.maxAttempts = maxAttempts
+	}
 	go orch.Start()
 
 	router := gin.New()
-	router.Use(middleware.Recovery())
-	router.Use(gin.Logger())
+	router.Use(middleware.DefaultChain(middleware.ChainConfig{})...)
 
 	s := &Server{
 		router:       router,
@@ -96,8 +111,14 @@ func (s *Server) setupRoutes() {
 		{
 			// アクティブなSaga一覧取得
 			sagas.GET("", s.handleListActive())
+			// 複数のSaga詳細を一括取得（body: {"ids": [...]}、最大100件。存在しないIDは読み飛ばす）
+			sagas.POST("/batch", s.handleBatchGet())
+			// 全Sagaを横断して指定した状態のステップを一覧取得（?status=executing&limit=&offset=）
+			sagas.GET("/steps", s.handleListStepsByStatus())
 			// Saga詳細取得（ステップ履歴含む）
 			sagas.GET("/:id", s.handleGetByID())
+			// 未完了の補償ステップを手動で再試行
+			sagas.POST("/:id/compensate", s.handleRetryCompensation())
 		}
 
 		// イベント受信（イベントポーリングの代替として手動通知も受け付ける）
@@ -108,9 +129,7 @@ func (s *Server) setupRoutes() {
 	}
 
 	// ヘルスチェック
-	s.router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "saga"})
-	})
+	s.router.GET("/health", middleware.HealthCheck("saga", s.db))
 }
 
 // sagaResponse はSagaのJSONレスポンス構造。
@@ -123,6 +142,7 @@ type sagaResponse struct {
 	StartedAt   string             `json:"started_at"`
 	UpdatedAt   string             `json:"updated_at"`
 	CompletedAt *string            `json:"completed_at,omitempty"`
+	Attempts    int64              `json:"attempts"`
 	Steps       []sagaStepResponse `json:"steps,omitempty"`
 }
 
@@ -130,6 +150,7 @@ type sagaResponse struct {
 type sagaStepResponse struct {
 	ID          string  `json:"id"`
 	StepName    string  `json:"step_name"`
+	Kind        string  `json:"kind"`
 	Status      string  `json:"status"`
 	Result      string  `json:"result"`
 	StartedAt   *string `json:"started_at,omitempty"`
@@ -157,6 +178,7 @@ func (s *Server) handleListActive() gin.HandlerFunc {
 				Payload:     saga.Payload,
 				StartedAt:   saga.StartedAt.Format("2006-01-02T15:04:05Z"),
 				UpdatedAt:   saga.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+				Attempts:    saga.Attempts,
 			}
 			if saga.CompletedAt.Valid {
 				t := saga.CompletedAt.Time.Format("2006-01-02T15:04:05Z")
@@ -165,62 +187,255 @@ func (s *Server) handleListActive() gin.HandlerFunc {
 			responses = append(responses, resp)
 		}
 
-		c.JSON(http.StatusOK, responses)
+		middleware.RespondList(c, http.StatusOK, responses, middleware.Envelope{Data: responses, Count: len(responses)})
 	}
 }
 
 // handleGetByID はSaga詳細（ステップ履歴含む）を返すハンドラ。
 func (s *Server) handleGetByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		sagaID := c.Param("id")
-
-		saga, err := s.queries.GetSagaByID(c.Request.Context(), sagaID)
+		saga, err := s.queries.GetSagaByID(c.Request.Context(), c.Param("id"))
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Sagaが見つかりません"})
 			return
 		}
 
-		steps, err := s.queries.ListSagaSteps(c.Request.Context(), sagaID)
+		resp, err := s.sagaDetail(c.Request.Context(), saga)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Sagaステップの取得に失敗しました"})
 			return
 		}
 
-		resp := sagaResponse{
-			ID:          saga.ID,
-			SagaType:    saga.SagaType,
-			CurrentStep: saga.CurrentStep,
-			Status:      saga.Status,
-			Payload:     saga.Payload,
-			StartedAt:   saga.StartedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt:   saga.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// sagaDetail はSagaとそのステップ履歴をレスポンス構造に変換する。
+func (s *Server) sagaDetail(ctx context.Context, saga sagadb.Saga) (sagaResponse, error) {
+	steps, err := s.queries.ListSagaSteps(ctx, saga.ID)
+	if err != nil {
+		return sagaResponse{}, err
+	}
+
+	resp := sagaResponse{
+		ID:          saga.ID,
+		SagaType:    saga.SagaType,
+		CurrentStep: saga.CurrentStep,
+		Status:      saga.Status,
+		Payload:     saga.Payload,
+		StartedAt:   saga.StartedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:   saga.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		Attempts:    saga.Attempts,
+	}
+	if saga.CompletedAt.Valid {
+		t := saga.CompletedAt.Time.Format("2006-01-02T15:04:05Z")
+		resp.CompletedAt = &t
+	}
+
+	resp.Steps = make([]sagaStepResponse, 0, len(steps))
+	for _, step := range steps {
+		resp.Steps = append(resp.Steps, toSagaStepResponse(step))
+	}
+	return resp, nil
+}
+
+// toSagaStepResponse はSagaステップをレスポンス構造に変換する。
+func toSagaStepResponse(step sagadb.SagaStep) sagaStepResponse {
+	sr := sagaStepResponse{
+		ID:         step.ID,
+		StepName:   step.StepName,
+		Kind:       step.Kind,
+		Status:     step.Status,
+		Result:     step.Result,
+		RetryCount: step.RetryCount,
+		LastError:  step.LastError,
+	}
+	if step.StartedAt.Valid {
+		t := step.StartedAt.Time.Format("2006-01-02T15:04:05Z")
+		sr.StartedAt = &t
+	}
+	if step.CompletedAt.Valid {
+		t := step.CompletedAt.Time.Format("2006-01-02T15:04:05Z")
+		sr.CompletedAt = &t
+	}
+	return sr
+}
+
+// sagaStepStatuses はSagaステップが取りうる状態。
+var sagaStepStatuses = []string{"pending", "executing", "completed", "failed", "compensating", "compensated"}
+
+// sagaStepsQuerySpec は状態別のステップ一覧が受け付けるページングのクエリパラメータ（limit、offset）。
+var sagaStepsQuerySpec = query.Spec{
+	DefaultLimit: 100,
+	MaxLimit:     1000,
+	Offset:       true,
+}
+
+// sagaStepWithSagaResponse は所属するSagaのIDを含むSagaステップのJSONレスポンス構造。
+type sagaStepWithSagaResponse struct {
+	sagaStepResponse
+	// SagaID は所属するSagaのID。
+	SagaID string `json:"saga_id"`
+}
+
+// sagaStepsPageResponse は状態別のステップ一覧のJSONレスポンス構造。
+type sagaStepsPageResponse struct {
+	// Steps は開始日時の昇順に並べたページ内のステップ。
+	Steps []sagaStepWithSagaResponse `json:"steps"`
+	// NextOffset は次ページのoffset。最終ページの場合はnull。
+	NextOffset *int `json:"next_offset"`
+}
+
+// handleListStepsByStatus は全Sagaを横断して、指定した状態のステップ一覧を返すハンドラ。
+// 実行中（executing）のまま止まっているステップの発見など、運用時の調査に使う。
+// statusは必須で、開始日時の古い順にlimit/offsetでページングする（既定100件、最大1000件）。
+func (s *Server) handleListStepsByStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.Query("status")
+		if status == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "statusを指定してください"})
+			return
 		}
-		if saga.CompletedAt.Valid {
-			t := saga.CompletedAt.Time.Format("2006-01-02T15:04:05Z")
-			resp.CompletedAt = &t
+		if !slices.Contains(sagaStepStatuses, status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("statusは%vのいずれかを指定してください", sagaStepStatuses)})
+			return
+		}
+		page, ok := query.ParseRequest(c, sagaStepsQuerySpec)
+		if !ok {
+			return
+		}
+
+		// 次のページの有無を判定するため1件多く取得する
+		steps, err := s.queries.ListSagaStepsByStatus(c.Request.Context(), sagadb.ListSagaStepsByStatusParams{
+			Status: status,
+			Limit:  int64(page.Limit) + 1,
+			Offset: int64(page.Offset),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Sagaステップの取得に失敗しました"})
+			log.Printf("状態別のSagaステップ取得エラー: status=%s, error=%v", status, err)
+			return
 		}
 
-		resp.Steps = make([]sagaStepResponse, 0, len(steps))
+		resp := sagaStepsPageResponse{Steps: make([]sagaStepWithSagaResponse, 0, min(len(steps), page.Limit))}
+		if len(steps) > page.Limit {
+			steps = steps[:page.Limit]
+			next := page.Offset + page.Limit
+			resp.NextOffset = &next
+		}
 		for _, step := range steps {
-			sr := sagaStepResponse{
-				ID:         step.ID,
-				StepName:   step.StepName,
-				Status:     step.Status,
-				Result:     step.Result,
-				RetryCount: step.RetryCount,
-				LastError:  step.LastError,
+			resp.Steps = append(resp.Steps, sagaStepWithSagaResponse{
+				sagaStepResponse: toSagaStepResponse(step),
+				SagaID:           step.SagaID,
+			})
+		}
+		middleware.RespondList(c, http.StatusOK, resp, middleware.Envelope{
+			Data:       resp.Steps,
+			Count:      len(resp.Steps),
+			NextOffset: resp.NextOffset,
+		})
+	}
+}
+
+// maxBatchSagaIDs は一括取得で1回に指定できるSaga IDの最大件数。
+const maxBatchSagaIDs = 100
+
+// batchGetRequest はSaga詳細の一括取得リクエストの構造。
+type batchGetRequest struct {
+	// IDs は取得するSaga IDの一覧。
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// handleBatchGet は複数のSaga詳細（ステップ履歴含む）をまとめて返すハンドラ。
+// 監視画面などで複数のSagaを表示する際の往復回数を減らす。
+// 存在しないIDは読み飛ばし、重複したIDは1件として扱う。結果は指定順に並べる。
+func (s *Server) handleBatchGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req batchGetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+			return
+		}
+		if len(req.IDs) > maxBatchSagaIDs {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("一度に取得できるSagaは%d件までです", maxBatchSagaIDs)})
+			return
+		}
+
+		ctx := c.Request.Context()
+		seen := make(map[string]struct{}, len(req.IDs))
+		responses := make([]sagaResponse, 0, len(req.IDs))
+		for _, id := range req.IDs {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+
+			saga, err := s.queries.GetSagaByID(ctx, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
 			}
-			if step.StartedAt.Valid {
-				t := step.StartedAt.Time.Format("2006-01-02T15:04:05Z")
-				sr.StartedAt = &t
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Sagaの取得に失敗しました"})
+				return
 			}
-			if step.CompletedAt.Valid {
-				t := step.CompletedAt.Time.Format("2006-01-02T15:04:05Z")
-				sr.CompletedAt = &t
+			resp, err := s.sagaDetail(ctx, saga)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Sagaステップの取得に失敗しました"})
+				return
 			}
-			resp.Steps = append(resp.Steps, sr)
+			responses = append(responses, resp)
+		}
+
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+// compensationRetryableStatuses は補償の手動retryを受け付けるSagaの状態。
+var compensationRetryableStatuses = []string{"compensating", "partially_compensated", "dead_letter"}
+
+// retryCompensationResponse は補償の手動retryのJSONレスポンス構造。
+type retryCompensationResponse struct {
+	// Status はretry後のSagaの状態。
+	Status string `json:"status"`
+	// PendingSteps はretry後も未完了のまま残った補償ステップ名。
+	PendingSteps []string `json:"pending_steps"`
+}
+
+// handleRetryCompensation は未完了の補償ステップだけを再実行するハンドラ。
+// 補償に成功済みのステップは再実行しない。未完了の補償ステップが残った場合は502を返す。
+func (s *Server) handleRetryCompensation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		saga, err := s.queries.GetSagaByID(ctx, c.Param("id"))
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Sagaが見つかりません"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Sagaの取得に失敗しました"})
+			return
+		}
+		if !slices.Contains(compensationRetryableStatuses, saga.Status) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("状態が%sのSagaは補償を再試行できません", saga.Status)})
+			return
 		}
 
+		pending, compErr := s.orchestrator.runCompensation(ctx, &saga, "手動retryによる再補償")
+
+		updated, err := s.queries.GetSagaByID(ctx, saga.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Sagaの取得に失敗しました"})
+			return
+		}
+		resp := retryCompensationResponse{Status: updated.Status, PendingSteps: pending}
+		if resp.PendingSteps == nil {
+			resp.PendingSteps = []string{}
+		}
+		if compErr != nil {
+			log.Printf("[Saga] 手動retryで補償が完了しませんでした: saga_id=%s, error=%v", saga.ID, compErr)
+			c.JSON(http.StatusBadGateway, resp)
+			return
+		}
 		c.JSON(http.StatusOK, resp)
 	}
 }
@@ -241,6 +456,11 @@ func (s *Server) handleEventNotify() gin.HandlerFunc {
 			return
 		}
 
+		if !event.IsValidType(event.Type(req.EventType)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("未登録のイベントタイプです: %s", req.EventType)})
+			return
+		}
+
 		s.orchestrator.HandleEvent(c.Request.Context(), req.EventType, req.AggregateID, req.Data)
 
 		c.JSON(http.StatusOK, gin.H{"status": "accepted"})
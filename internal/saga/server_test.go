@@ -5,8 +5,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -28,6 +31,8 @@ func newTestServer(t *testing.T) *Server {
 	if err != nil {
 		t.Fatalf("インメモリDB接続に失敗: %v", err)
 	}
+	// インメモリDBは接続ごとに別のDBになるため、接続を1本に制限する
+	sqlDB.SetMaxOpenConns(1)
 	t.Cleanup(func() { sqlDB.Close() })
 
 	if err := initSchema(sqlDB); err != nil {
@@ -95,6 +100,7 @@ func seedSagaStep(t *testing.T, s *Server, id, sagaID, stepName, status string)
 		SagaID:   sagaID,
 		StepName: stepName,
 		Status:   status,
+		Kind:     stepKindAction,
 	}); err != nil {
 		t.Fatalf("テスト用Sagaステップ挿入に失敗: %v", err)
 	}
@@ -296,6 +302,265 @@ func TestHandleGetSagaDetail(t *testing.T) {
 	})
 }
 
+// TestHandleBatchGetSagas はSaga詳細の一括取得ハンドラを検証する。
+func TestHandleBatchGetSagas(t *testing.T) {
+	t.Parallel()
+
+	postBatch := func(t *testing.T, s *Server, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/sagas/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("複数のSagaをステップ付きで返し存在しないIDは読み飛ばす", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		seedSaga(t, s, "saga-a", "media_upload", "add_to_album", "in_progress", `{}`)
+		seedSagaStep(t, s, "step-a1", "saga-a", "process_media", "completed")
+		seedSagaStep(t, s, "step-a2", "saga-a", "add_to_album", "executing")
+		seedSaga(t, s, "saga-b", "media_upload", "send_notification", "completed", `{}`)
+		seedSagaStep(t, s, "step-b1", "saga-b", "process_media", "completed")
+
+		w := postBatch(t, s, `{"ids":["saga-b","unknown","saga-a","saga-b"]}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+
+		var result []sagaResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+		if len(result) != 2 {
+			t.Fatalf("Saga数: got %d, want 2", len(result))
+		}
+		if result[0].ID != "saga-b" || result[1].ID != "saga-a" {
+			t.Errorf("Saga IDの順序: got [%s %s], want [saga-b saga-a]", result[0].ID, result[1].ID)
+		}
+		if len(result[0].Steps) != 1 || result[0].Steps[0].StepName != "process_media" {
+			t.Errorf("saga-bのステップ: got %+v", result[0].Steps)
+		}
+		if len(result[1].Steps) != 2 || result[1].Steps[1].StepName != "add_to_album" {
+			t.Errorf("saga-aのステップ: got %+v", result[1].Steps)
+		}
+	})
+
+	t.Run("上限を超えるIDは400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		ids := make([]string, maxBatchSagaIDs+1)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("saga-%d", i)
+		}
+		body, err := json.Marshal(batchGetRequest{IDs: ids})
+		if err != nil {
+			t.Fatalf("リクエストの生成に失敗: %v", err)
+		}
+
+		w := postBatch(t, s, string(body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("idsが無い場合は400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		w := postBatch(t, s, `{}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+// TestHandleListStepsByStatus は全Sagaを横断した状態別のステップ一覧取得ハンドラを検証する。
+func TestHandleListStepsByStatus(t *testing.T) {
+	t.Parallel()
+
+	getSteps := func(t *testing.T, s *Server, rawQuery string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/sagas/steps?"+rawQuery, nil))
+		return w
+	}
+
+	seed := func(t *testing.T) *Server {
+		t.Helper()
+		s := newTestServer(t)
+		seedSaga(t, s, "saga-a", "media_upload", "add_to_album", "in_progress", `{}`)
+		seedSagaStep(t, s, "step-a1", "saga-a", "process_media", "completed")
+		seedSagaStep(t, s, "step-a2", "saga-a", "add_to_album", "executing")
+		seedSaga(t, s, "saga-b", "media_upload", "process_media", "in_progress", `{}`)
+		seedSagaStep(t, s, "step-b1", "saga-b", "process_media", "executing")
+		seedSaga(t, s, "saga-c", "media_upload", "compensate_upload", "compensating", `{}`)
+		seedSagaStep(t, s, "step-c1", "saga-c", "process_media", "failed")
+		seedSagaStep(t, s, "step-c2", "saga-c", "compensate_upload", "executing")
+		return s
+	}
+
+	t.Run("指定した状態のステップだけを所属するSagaのIDとともに返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := seed(t)
+		w := getSteps(t, s, "status=executing")
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		var result sagaStepsPageResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+		want := [][2]string{{"step-a2", "saga-a"}, {"step-b1", "saga-b"}, {"step-c2", "saga-c"}}
+		if len(result.Steps) != len(want) {
+			t.Fatalf("ステップ数: got %d, want %d (%+v)", len(result.Steps), len(want), result.Steps)
+		}
+		for i, step := range result.Steps {
+			if step.ID != want[i][0] || step.SagaID != want[i][1] || step.Status != "executing" {
+				t.Errorf("steps[%d]: got id=%s saga_id=%s status=%s, want id=%s saga_id=%s status=executing",
+					i, step.ID, step.SagaID, step.Status, want[i][0], want[i][1])
+			}
+		}
+		if result.NextOffset != nil {
+			t.Errorf("next_offset: got %d, want null", *result.NextOffset)
+		}
+
+		w = getSteps(t, s, "status=failed")
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+		if len(result.Steps) != 1 || result.Steps[0].ID != "step-c1" {
+			t.Errorf("failedのステップ: got %+v, want [step-c1]", result.Steps)
+		}
+	})
+
+	t.Run("limitとoffsetでページングする", func(t *testing.T) {
+		t.Parallel()
+
+		s := seed(t)
+		var first sagaStepsPageResponse
+		if err := json.Unmarshal(getSteps(t, s, "status=executing&limit=2").Body.Bytes(), &first); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+		if len(first.Steps) != 2 || first.NextOffset == nil || *first.NextOffset != 2 {
+			t.Fatalf("1ページ目: got %+v, next_offset %v, want 2件とnext_offset 2", first.Steps, first.NextOffset)
+		}
+
+		var second sagaStepsPageResponse
+		if err := json.Unmarshal(getSteps(t, s, "status=executing&limit=2&offset=2").Body.Bytes(), &second); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+		if len(second.Steps) != 1 || second.Steps[0].ID != "step-c2" || second.NextOffset != nil {
+			t.Errorf("2ページ目: got %+v, next_offset %v, want [step-c2]とnull", second.Steps, second.NextOffset)
+		}
+	})
+
+	t.Run("一致するステップがない場合は空の一覧を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := seed(t)
+		w := getSteps(t, s, "status=compensated")
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d", w.Code, http.StatusOK)
+		}
+		if got := strings.TrimSpace(w.Body.String()); got != `{"steps":[],"next_offset":null}` {
+			t.Errorf("レスポンス: got %s", got)
+		}
+	})
+
+	t.Run("不正なクエリパラメータは400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		for _, rawQuery := range []string{"", "status=stalled", "status=executing&limit=0", "status=executing&limit=1001", "status=executing&offset=-1"} {
+			if w := getSteps(t, s, rawQuery); w.Code != http.StatusBadRequest {
+				t.Errorf("%q のステータスコード: got %d, want %d", rawQuery, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
+
+// TestHandleRetryCompensation は補償の手動retryハンドラのテスト。
+func TestHandleRetryCompensation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("未完了の補償ステップだけを再実行する", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		mediaCommand := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer mediaCommand.Close()
+
+		s := newTestServer(t)
+		s.orchestrator.mediaCommandClient = httpclient.New(mediaCommand.URL)
+		seedSaga(t, s, "saga-dead", "media_upload", "compensate_upload", "dead_letter",
+			`{"media_aggregate_id":"media-1","upload_data":"{}"}`)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/sagas/saga-dead/compensate", nil)
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード: got %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		var resp retryCompensationResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("レスポンスのパースに失敗: %v", err)
+		}
+		if resp.Status != "failed" || len(resp.PendingSteps) != 0 {
+			t.Errorf("レスポンス: got %+v", resp)
+		}
+
+		// 補償済みのSagaは再試行できない
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/sagas/saga-dead/compensate", nil)
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusConflict {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusConflict)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("補償リクエスト数: got %d, want 1", got)
+		}
+	})
+
+	t.Run("補償中でないSagaの場合は409を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		seedSaga(t, s, "saga-running", "media_upload", "add_to_album", "in_progress", `{}`)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/sagas/saga-running/compensate", nil)
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusConflict)
+		}
+	})
+
+	t.Run("存在しないSaga IDの場合は404を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/sagas/nonexistent/compensate", nil)
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
+
 // TestHandleEventNotify はイベント通知ハンドラのテスト。
 func TestHandleEventNotify(t *testing.T) {
 	t.Parallel()
@@ -366,6 +631,27 @@ func TestHandleEventNotify(t *testing.T) {
 		}
 	})
 
+	t.Run("未登録のイベントタイプの場合は400を返す", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestServer(t)
+
+		body := eventNotifyRequest{
+			EventType:   "MediaUplaoded",
+			AggregateID: "media-abc",
+		}
+		jsonBody, _ := json.Marshal(body)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/events/notify", bytes.NewReader(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		s.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ステータスコード: got %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
 	t.Run("Dataフィールドが空でも受け付ける", func(t *testing.T) {
 		t.Parallel()
 
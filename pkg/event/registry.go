@@ -0,0 +1,35 @@
+package event
+
+import "slices"
+
+// allTypes は定義済みの全イベントタイプ。
+// 新しいイベントタイプを定義した場合は必ずここにも登録する（登録漏れはテストで検出される）。
+var allTypes = []Type{
+	TypeMediaUploaded,
+	TypeMediaProcessed,
+	TypeMediaProcessingFailed,
+	TypeMediaDeleted,
+	TypeMediaUploadCompensated,
+	TypeMediaFlagged,
+	TypeMediaMarkedFailed,
+	TypeMediaRestored,
+	TypeAlbumCreated,
+	TypeAlbumDeleted,
+	TypeMediaAddedToAlbum,
+	TypeMediaRemovedFromAlbum,
+	TypeNotificationSent,
+	TypeEventRetracted,
+	TypeAggregateEventLimitExceeded,
+}
+
+// AllTypes は定義済みの全イベントタイプを返す。
+// 返り値は複製のため、呼び出し元で変更してもレジストリには影響しない。
+func AllTypes() []Type {
+	return slices.Clone(allTypes)
+}
+
+// IsValidType は指定されたイベントタイプがレジストリに登録されているかを返す。
+// タイプミスや未定義のイベントタイプを検出するために使用する。
+func IsValidType(t Type) bool {
+	return slices.Contains(allTypes, t)
+}
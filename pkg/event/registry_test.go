@@ -0,0 +1,106 @@
+package event
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"testing"
+)
+
+// declaredTypes はtypes.goでType型として宣言されている定数名を返す。
+func declaredTypes(t *testing.T) []string {
+	t.Helper()
+
+	f, err := parser.ParseFile(token.NewFileSet(), "types.go", nil, 0)
+	if err != nil {
+		t.Fatalf("types.goの解析に失敗: %v", err)
+	}
+
+	var names []string
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			if ident, ok := vs.Type.(*ast.Ident); !ok || ident.Name != "Type" {
+				continue
+			}
+			for _, name := range vs.Names {
+				names = append(names, name.Name)
+			}
+		}
+	}
+	return names
+}
+
+func TestAllTypes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("types.goで宣言された全イベントタイプが登録されている", func(t *testing.T) {
+		t.Parallel()
+
+		declared := declaredTypes(t)
+		if len(declared) == 0 {
+			t.Fatal("types.goからイベントタイプの宣言を取得できませんでした")
+		}
+		if got := len(AllTypes()); got != len(declared) {
+			t.Errorf("登録数 %d と宣言数 %d が一致しません（宣言: %v）", got, len(declared), declared)
+		}
+		for _, name := range declared {
+			// 定数名はType+値の命名規則に従う
+			if !IsValidType(Type(name[len("Type"):])) {
+				t.Errorf("%s がレジストリに登録されていません", name)
+			}
+		}
+	})
+
+	t.Run("重複して登録されていない", func(t *testing.T) {
+		t.Parallel()
+
+		types := AllTypes()
+		slices.Sort(types)
+		if compacted := slices.Compact(slices.Clone(types)); len(compacted) != len(types) {
+			t.Errorf("重複したイベントタイプが登録されています: %v", types)
+		}
+	})
+
+	t.Run("返り値を変更してもレジストリに影響しない", func(t *testing.T) {
+		t.Parallel()
+
+		types := AllTypes()
+		types[0] = "Broken"
+		if IsValidType("Broken") {
+			t.Error("返り値の変更がレジストリに反映されました")
+		}
+	})
+}
+
+func TestIsValidType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		typ  Type
+		want bool
+	}{
+		{name: "登録済みのタイプ", typ: TypeMediaUploaded, want: true},
+		{name: "メタイベント", typ: TypeEventRetracted, want: true},
+		{name: "タイプミス", typ: "MediaUplaoded", want: false},
+		{name: "大文字小文字の違い", typ: "mediauploaded", want: false},
+		{name: "空文字", typ: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := IsValidType(tt.typ); got != tt.want {
+				t.Errorf("IsValidType(%q) = %v, want %v", tt.typ, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RetractionSet は撤回されたイベントIDの集合。
+// EventRetractedイベントを読み込ませることで撤回対象のIDを記録し、
+// 状態再構築時に撤回されたイベントを読み飛ばす判定に使用する。
+type RetractionSet map[string]struct{}
+
+// NewRetractionSet は空のRetractionSetを生成する。
+func NewRetractionSet() RetractionSet {
+	return make(RetractionSet)
+}
+
+// Observe はイベントがEventRetractedであれば撤回対象のイベントIDを記録し、trueを返す。
+// それ以外のイベントの場合は何もせずfalseを返す。
+func (r RetractionSet) Observe(eventType Type, data []byte) (bool, error) {
+	if eventType != TypeEventRetracted {
+		return false, nil
+	}
+
+	var retracted EventRetractedData
+	if err := json.Unmarshal(data, &retracted); err != nil {
+		return true, fmt.Errorf("EventRetractedDataのデシリアライズに失敗: %w", err)
+	}
+	if retracted.RetractedEventID == "" {
+		return true, fmt.Errorf("撤回対象のイベントIDが空です")
+	}
+	r[retracted.RetractedEventID] = struct{}{}
+	return true, nil
+}
+
+// Contains は指定したイベントが撤回済みであればtrueを返す。
+func (r RetractionSet) Contains(eventID string) bool {
+	_, ok := r[eventID]
+	return ok
+}
+
+// SkipRetracted は状態再構築に使用するイベントを返す。
+// 撤回されたイベントとEventRetractedイベント自体を除き、残りのイベントの順序は維持する。
+// 撤回の事実はEvent Storeに残るため、監査には元のイベント列を参照する。
+func SkipRetracted(events []Event) ([]Event, error) {
+	retractions := NewRetractionSet()
+	for i := range events {
+		if _, err := retractions.Observe(events[i].EventType, events[i].Data); err != nil {
+			return nil, fmt.Errorf("イベント %s の撤回情報の読み取りに失敗: %w", events[i].ID, err)
+		}
+	}
+
+	applicable := make([]Event, 0, len(events))
+	for _, e := range events {
+		if e.EventType == TypeEventRetracted || retractions.Contains(e.ID) {
+			continue
+		}
+		applicable = append(applicable, e)
+	}
+	return applicable, nil
+}
@@ -0,0 +1,84 @@
+package event
+
+import (
+	"testing"
+)
+
+// TestSkipRetracted は撤回されたイベントと撤回イベント自体が除外されることを検証する。
+func TestSkipRetracted(t *testing.T) {
+	t.Parallel()
+
+	newEvent := func(t *testing.T, eventType Type, version int64, data any) Event {
+		t.Helper()
+		e, err := New("media-1", AggregateTypeMedia, eventType, version, data)
+		if err != nil {
+			t.Fatalf("イベント生成に失敗: %v", err)
+		}
+		return *e
+	}
+
+	t.Run("正常系_撤回されたイベントを除き順序を維持する", func(t *testing.T) {
+		t.Parallel()
+
+		uploaded := newEvent(t, TypeMediaUploaded, 1, MediaUploadedData{Filename: "a.jpg"})
+		wrong := newEvent(t, TypeMediaProcessingFailed, 2, MediaProcessingFailedData{Reason: "誤記録"})
+		retraction := newEvent(t, TypeEventRetracted, 3, EventRetractedData{RetractedEventID: wrong.ID, Reason: "誤って追記"})
+		processed := newEvent(t, TypeMediaProcessed, 4, MediaProcessedData{Width: 10, Height: 10})
+
+		got, err := SkipRetracted([]Event{uploaded, wrong, retraction, processed})
+		if err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+		if len(got) != 2 || got[0].ID != uploaded.ID || got[1].ID != processed.ID {
+			t.Errorf("期待するイベント [%s %s], 実際のイベント %v", uploaded.ID, processed.ID, got)
+		}
+	})
+
+	t.Run("正常系_撤回がなければ全イベントを返す", func(t *testing.T) {
+		t.Parallel()
+
+		events := []Event{
+			newEvent(t, TypeMediaUploaded, 1, MediaUploadedData{Filename: "a.jpg"}),
+			newEvent(t, TypeMediaDeleted, 2, MediaDeletedData{UserID: "user-1"}),
+		}
+		got, err := SkipRetracted(events)
+		if err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+		if len(got) != len(events) {
+			t.Errorf("期待するイベント数 %d, 実際のイベント数 %d", len(events), len(got))
+		}
+	})
+
+	t.Run("異常系_撤回対象のIDが空の場合はエラーを返す", func(t *testing.T) {
+		t.Parallel()
+
+		events := []Event{newEvent(t, TypeEventRetracted, 1, EventRetractedData{Reason: "ID未指定"})}
+		if _, err := SkipRetracted(events); err == nil {
+			t.Error("エラーが返されるべきです")
+		}
+	})
+}
+
+// TestRetractionSetObserve はEventRetracted以外のイベントが撤回対象として記録されないことを検証する。
+func TestRetractionSetObserve(t *testing.T) {
+	t.Parallel()
+
+	retractions := NewRetractionSet()
+
+	isRetraction, err := retractions.Observe(TypeMediaUploaded, []byte(`{"retracted_event_id":"ev-1"}`))
+	if err != nil || isRetraction {
+		t.Fatalf("MediaUploadedは撤回イベントとして扱われるべきではありません: isRetraction=%v, err=%v", isRetraction, err)
+	}
+	if retractions.Contains("ev-1") {
+		t.Error("EventRetracted以外のイベントから撤回対象が記録されています")
+	}
+
+	isRetraction, err = retractions.Observe(TypeEventRetracted, []byte(`{"retracted_event_id":"ev-1","reason":"誤記録"}`))
+	if err != nil || !isRetraction {
+		t.Fatalf("EventRetractedが撤回イベントとして扱われていません: isRetraction=%v, err=%v", isRetraction, err)
+	}
+	if !retractions.Contains("ev-1") {
+		t.Error("撤回対象のイベントIDが記録されていません")
+	}
+}
@@ -0,0 +1,214 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Kind はJSON Schemaのtypeに相当する、イベントデータのフィールドの値の種類。
+type Kind string
+
+const (
+	// KindString は文字列を表す。
+	KindString Kind = "string"
+	// KindNumber は数値を表す。
+	KindNumber Kind = "number"
+	// KindBoolean は真偽値を表す。
+	KindBoolean Kind = "boolean"
+	// KindObject はオブジェクトを表す。
+	KindObject Kind = "object"
+	// KindArray は配列を表す。
+	KindArray Kind = "array"
+)
+
+// Schema はイベントのdataが満たすべきJSON Schema（required・propertiesのtypeのみのサブセット）。
+// Propertiesに定義していないフィールドは検証せずに許容するため、フィールドの追加は後方互換となる。
+type Schema struct {
+	// Required は必須のフィールド名。
+	Required []string
+	// Properties はフィールド名ごとの値の種類。
+	Properties map[string]Kind
+}
+
+// schemas はイベントタイプごとのdataのスキーマ。
+// 各フィールドは*Data構造体のJSONタグに合わせ、omitemptyのフィールドは必須としない。
+// 登録されていないイベントタイプのdataは検証しない。
+var schemas = map[Type]Schema{
+	TypeMediaUploaded: {
+		Required:   []string{"user_id", "filename", "content_type", "size", "storage_path"},
+		Properties: map[string]Kind{"user_id": KindString, "filename": KindString, "content_type": KindString, "size": KindNumber, "storage_path": KindString},
+	},
+	TypeMediaProcessed: {
+		Required:   []string{"thumbnail_path", "width", "height"},
+		Properties: map[string]Kind{"thumbnail_path": KindString, "thumbnail_format": KindString, "width": KindNumber, "height": KindNumber, "duration_seconds": KindNumber},
+	},
+	TypeMediaProcessingFailed: {
+		Required:   []string{"reason"},
+		Properties: map[string]Kind{"reason": KindString},
+	},
+	TypeMediaDeleted: {
+		Required:   []string{"user_id"},
+		Properties: map[string]Kind{"user_id": KindString},
+	},
+	TypeMediaUploadCompensated: {
+		Required:   []string{"reason", "saga_id"},
+		Properties: map[string]Kind{"reason": KindString, "saga_id": KindString},
+	},
+	TypeMediaFlagged: {
+		Required:   []string{"reason"},
+		Properties: map[string]Kind{"reason": KindString},
+	},
+	TypeMediaMarkedFailed: {
+		Required:   []string{"admin_user_id"},
+		Properties: map[string]Kind{"admin_user_id": KindString},
+	},
+	TypeMediaRestored: {
+		Required:   []string{"admin_user_id"},
+		Properties: map[string]Kind{"admin_user_id": KindString},
+	},
+	TypeAlbumCreated: {
+		Required:   []string{"user_id", "name", "description"},
+		Properties: map[string]Kind{"user_id": KindString, "name": KindString, "description": KindString},
+	},
+	TypeAlbumDeleted: {
+		Required:   []string{"user_id"},
+		Properties: map[string]Kind{"user_id": KindString},
+	},
+	TypeMediaAddedToAlbum: {
+		Required:   []string{"media_id"},
+		Properties: map[string]Kind{"media_id": KindString},
+	},
+	TypeMediaRemovedFromAlbum: {
+		Required:   []string{"media_id"},
+		Properties: map[string]Kind{"media_id": KindString},
+	},
+	TypeNotificationSent: {
+		Required:   []string{"user_id", "title", "message"},
+		Properties: map[string]Kind{"user_id": KindString, "title": KindString, "message": KindString},
+	},
+	TypeEventRetracted: {
+		Required:   []string{"retracted_event_id", "reason"},
+		Properties: map[string]Kind{"retracted_event_id": KindString, "reason": KindString},
+	},
+	TypeAggregateEventLimitExceeded: {
+		Required:   []string{"aggregate_id", "aggregate_type", "event_count", "limit"},
+		Properties: map[string]Kind{"aggregate_id": KindString, "aggregate_type": KindString, "event_count": KindNumber, "limit": KindNumber},
+	},
+}
+
+// SchemaFor は指定されたイベントタイプのdataのスキーマを返す。
+// スキーマが登録されていない場合はfalseを返す。
+func SchemaFor(t Type) (Schema, bool) {
+	s, ok := schemas[t]
+	return s, ok
+}
+
+// JSONSchemaDialect はJSONSchemaが準拠するJSON Schemaのバージョン。
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema はイベントのdataのスキーマをJSON Schema形式で表した値。
+// クライアントやドキュメント生成ツールにイベントのデータ構造を公開するために使用する。
+type JSONSchema struct {
+	// Schema は準拠するJSON Schemaのバージョン（JSONSchemaDialect）。
+	Schema string `json:"$schema"`
+	// Title はイベントタイプ。
+	Title string `json:"title"`
+	// Type はdataの値の種類。常にobject。
+	Type Kind `json:"type"`
+	// Required は必須のフィールド名。
+	Required []string `json:"required"`
+	// Properties はフィールド名ごとの定義。
+	Properties map[string]JSONSchemaProperty `json:"properties"`
+	// AdditionalProperties は定義していないフィールドを許容するか。常にtrue。
+	AdditionalProperties bool `json:"additionalProperties"`
+}
+
+// JSONSchemaProperty はJSONSchemaのフィールドの定義。
+type JSONSchemaProperty struct {
+	// Type はフィールドの値の種類。
+	Type Kind `json:"type"`
+}
+
+// JSONSchemaFor は指定されたイベントタイプのdataのスキーマをJSON Schema形式で返す。
+// スキーマが登録されていない場合はfalseを返す。
+func JSONSchemaFor(t Type) (JSONSchema, bool) {
+	s, ok := schemas[t]
+	if !ok {
+		return JSONSchema{}, false
+	}
+	properties := make(map[string]JSONSchemaProperty, len(s.Properties))
+	for name, kind := range s.Properties {
+		properties[name] = JSONSchemaProperty{Type: kind}
+	}
+	return JSONSchema{
+		Schema:               JSONSchemaDialect,
+		Title:                string(t),
+		Type:                 KindObject,
+		Required:             slices.Clone(s.Required),
+		Properties:           properties,
+		AdditionalProperties: true,
+	}, true
+}
+
+// ValidateData はdataが指定されたイベントタイプのスキーマを満たすかを検証する。
+// スキーマが登録されていないイベントタイプの場合は検証せずにnilを返す。
+func ValidateData(t Type, data []byte) error {
+	s, ok := schemas[t]
+	if !ok {
+		return nil
+	}
+	return s.Validate(data)
+}
+
+// Validate はdataがスキーマを満たすかを検証する。
+// dataはJSONオブジェクトでなければならない。
+func (s Schema) Validate(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return fmt.Errorf("dataはJSONオブジェクトで指定してください")
+	}
+	for _, name := range s.Required {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("dataの必須フィールド%sがありません", name)
+		}
+	}
+
+	// エラーメッセージを安定させるため、フィールド名の順に検証する
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if got := kindOf(raw); got != s.Properties[name] {
+			return fmt.Errorf("dataのフィールド%sは%sで指定してください（実際は%s）", name, s.Properties[name], got)
+		}
+	}
+	return nil
+}
+
+// kindOf はJSONの値の種類を先頭の文字から判定する。nullはどの種類にも一致しない"null"を返す。
+func kindOf(raw json.RawMessage) Kind {
+	if len(raw) == 0 {
+		return ""
+	}
+	switch raw[0] {
+	case '"':
+		return KindString
+	case '{':
+		return KindObject
+	case '[':
+		return KindArray
+	case 't', 'f':
+		return KindBoolean
+	case 'n':
+		return "null"
+	default:
+		return KindNumber
+	}
+}
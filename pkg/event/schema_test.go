@@ -0,0 +1,211 @@
+package event
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestValidateData はイベントタイプごとのスキーマによるdataの検証を検証する。
+func TestValidateData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		eventType Type
+		data      string
+		wantErr   string
+	}{
+		{
+			name:      "必須フィールドがそろったMediaUploadedを受け付けること",
+			eventType: TypeMediaUploaded,
+			data:      `{"user_id":"u-1","filename":"a.jpg","content_type":"image/jpeg","size":10,"storage_path":"/data/a.jpg"}`,
+		},
+		{
+			name:      "スキーマにないフィールドは許容すること",
+			eventType: TypeMediaDeleted,
+			data:      `{"user_id":"u-1","extra":true}`,
+		},
+		{
+			name:      "omitemptyのフィールドは省略できること",
+			eventType: TypeMediaProcessed,
+			data:      `{"thumbnail_path":"/t.jpg","width":10,"height":20}`,
+		},
+		{
+			name:      "filenameのないMediaUploadedを拒否すること",
+			eventType: TypeMediaUploaded,
+			data:      `{"user_id":"u-1","content_type":"image/jpeg","size":10,"storage_path":"/data/a.jpg"}`,
+			wantErr:   "必須フィールドfilename",
+		},
+		{
+			name:      "値の型が異なるフィールドを拒否すること",
+			eventType: TypeMediaProcessed,
+			data:      `{"thumbnail_path":"/t.jpg","width":"10","height":20}`,
+			wantErr:   "フィールドwidthはnumber",
+		},
+		{
+			name:      "nullの値を拒否すること",
+			eventType: TypeMediaAddedToAlbum,
+			data:      `{"media_id":null}`,
+			wantErr:   "フィールドmedia_idはstring",
+		},
+		{
+			name:      "JSONオブジェクト以外のdataを拒否すること",
+			eventType: TypeMediaDeleted,
+			data:      `["u-1"]`,
+			wantErr:   "JSONオブジェクト",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateData(tt.eventType, []byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateData() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateData() error = %v, want %q を含むエラー", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSchemasMatchDataTypes は全イベントタイプにスキーマが登録され、
+// スキーマが*Data構造体の定義（JSONタグ・フィールドの型）と一致することを検証する。
+// 構造体のフィールドを変更してスキーマの更新を忘れた場合、このテストが失敗する。
+func TestSchemasMatchDataTypes(t *testing.T) {
+	t.Parallel()
+
+	samples := map[Type]any{
+		TypeMediaUploaded:               MediaUploadedData{},
+		TypeMediaProcessed:              MediaProcessedData{},
+		TypeMediaProcessingFailed:       MediaProcessingFailedData{},
+		TypeMediaDeleted:                MediaDeletedData{},
+		TypeMediaUploadCompensated:      MediaUploadCompensatedData{},
+		TypeMediaFlagged:                MediaFlaggedData{},
+		TypeMediaMarkedFailed:           MediaMarkedFailedData{},
+		TypeMediaRestored:               MediaRestoredData{},
+		TypeAlbumCreated:                AlbumCreatedData{},
+		TypeAlbumDeleted:                AlbumDeletedData{},
+		TypeMediaAddedToAlbum:           MediaAddedToAlbumData{},
+		TypeMediaRemovedFromAlbum:       MediaRemovedFromAlbumData{},
+		TypeNotificationSent:            NotificationSentData{},
+		TypeEventRetracted:              EventRetractedData{},
+		TypeAggregateEventLimitExceeded: AggregateEventLimitExceededData{},
+	}
+
+	for _, typ := range AllTypes() {
+		schema, ok := SchemaFor(typ)
+		if !ok {
+			t.Errorf("%s のスキーマが登録されていません", typ)
+			continue
+		}
+		sample, ok := samples[typ]
+		if !ok {
+			t.Errorf("%s のサンプルデータがありません", typ)
+			continue
+		}
+
+		wantRequired, wantProperties := schemaOf(t, reflect.TypeOf(sample))
+		if got := slices.Sorted(slices.Values(schema.Required)); !slices.Equal(got, wantRequired) {
+			t.Errorf("%s の必須フィールド = %v, want %v", typ, got, wantRequired)
+		}
+		if !maps.Equal(schema.Properties, wantProperties) {
+			t.Errorf("%s のフィールド定義 = %v, want %v", typ, schema.Properties, wantProperties)
+		}
+
+		data, err := json.Marshal(sample)
+		if err != nil {
+			t.Fatalf("%s のJSON変換に失敗: %v", typ, err)
+		}
+		if err := ValidateData(typ, data); err != nil {
+			t.Errorf("%s のデータがスキーマを満たしません: %v", typ, err)
+		}
+	}
+}
+
+// schemaOf は構造体のJSONタグとフィールドの型から、必須フィールド（omitemptyでないフィールド、昇順）と
+// フィールドごとの値の種類を求める。
+func schemaOf(t *testing.T, typ reflect.Type) ([]string, map[string]Kind) {
+	t.Helper()
+
+	var required []string
+	properties := make(map[string]Kind)
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			t.Fatalf("%s.%s にJSONタグがありません", typ.Name(), field.Name)
+		}
+		switch field.Type.Kind() {
+		case reflect.String:
+			properties[name] = KindString
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+			properties[name] = KindNumber
+		case reflect.Bool:
+			properties[name] = KindBoolean
+		case reflect.Slice:
+			properties[name] = KindArray
+		case reflect.Struct, reflect.Map:
+			properties[name] = KindObject
+		default:
+			t.Fatalf("%s.%s の型 %s はスキーマで表現できません", typ.Name(), field.Name, field.Type)
+		}
+		if !slices.Contains(strings.Split(opts, ","), "omitempty") {
+			required = append(required, name)
+		}
+	}
+	slices.Sort(required)
+	return required, properties
+}
+
+// TestJSONSchemaFor はJSON Schema形式への変換を検証する。
+func TestJSONSchemaFor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("登録済みのイベントタイプをJSON Schema形式で返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		got, ok := JSONSchemaFor(TypeMediaAddedToAlbum)
+		if !ok {
+			t.Fatal("JSONSchemaFor() = false, want true")
+		}
+		want := JSONSchema{
+			Schema:               JSONSchemaDialect,
+			Title:                "MediaAddedToAlbum",
+			Type:                 KindObject,
+			Required:             []string{"media_id"},
+			Properties:           map[string]JSONSchemaProperty{"media_id": {Type: KindString}},
+			AdditionalProperties: true,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("JSONSchemaFor() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("返り値を変更してもレジストリに影響しないこと", func(t *testing.T) {
+		t.Parallel()
+
+		got, _ := JSONSchemaFor(TypeMediaDeleted)
+		got.Required[0] = "changed"
+		if s, _ := SchemaFor(TypeMediaDeleted); s.Required[0] != "user_id" {
+			t.Errorf("Required[0] = %s, want user_id", s.Required[0])
+		}
+	})
+
+	t.Run("未登録のイベントタイプはfalseを返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		if _, ok := JSONSchemaFor(Type("Unknown")); ok {
+			t.Error("JSONSchemaFor() = true, want false")
+		}
+	})
+}
@@ -15,6 +15,8 @@ const (
 	AggregateTypeAlbum AggregateType = "Album"
 	// AggregateTypeUser はユーザーエンティティを表す。
 	AggregateTypeUser AggregateType = "User"
+	// AggregateTypeSystem はEvent Store自身が発行する運用向けイベントの対象を表す。
+	AggregateTypeSystem AggregateType = "System"
 )
 
 // Type はイベントの種類を表す。
@@ -31,6 +33,13 @@ const (
 	TypeMediaDeleted Type = "MediaDeleted"
 	// TypeMediaUploadCompensated はメディアアップロードの補償アクションが実行されたことを表す。
 	TypeMediaUploadCompensated Type = "MediaUploadCompensated"
+	// TypeMediaFlagged はコンテンツ審査でメディアが要確認と判定されたことを表す。
+	TypeMediaFlagged Type = "MediaFlagged"
+	// TypeMediaMarkedFailed は管理者がメディアをfailedに変更したことを表す。
+	// Sagaの補償対象となるMediaProcessingFailedとは区別する。
+	TypeMediaMarkedFailed Type = "MediaMarkedFailed"
+	// TypeMediaRestored は管理者がfailedまたはflaggedのメディアをuploadedに戻したことを表す。
+	TypeMediaRestored Type = "MediaRestored"
 
 	// TypeAlbumCreated はアルバムが作成されたことを表す。
 	TypeAlbumCreated Type = "AlbumCreated"
@@ -43,6 +52,14 @@ const (
 
 	// TypeNotificationSent は通知が送信されたことを表す。
 	TypeNotificationSent Type = "NotificationSent"
+
+	// TypeEventRetracted は誤って追記されたイベントが撤回されたことを表すメタイベント。
+	// 撤回対象のイベントと同じAggregateに追記し、元のイベントは削除せずに残す。
+	TypeEventRetracted Type = "EventRetracted"
+
+	// TypeAggregateEventLimitExceeded は1つのAggregateのイベント数が上限を超えたことを表す警告イベント。
+	// 無制限にイベントが増え続けるAggregateを運用者が検知するため、Event StoreがSystem Aggregateに追記する。
+	TypeAggregateEventLimitExceeded Type = "AggregateEventLimitExceeded"
 )
 
 // Event はEvent Sourcingにおける不変のイベントレコードを表す。
@@ -62,6 +79,18 @@ type Event struct {
 	Version int64 `json:"version"`
 	// CreatedAt はイベントが作成された日時。
 	CreatedAt time.Time `json:"created_at"`
+	// Metadata は分散トレーシングのための追跡情報。
+	Metadata Metadata `json:"metadata"`
+}
+
+// Metadata はイベントに付与する分散トレーシングのための追跡情報。
+// MediaUploadedから始まる一連のイベント（MediaProcessed→MediaAddedToAlbum→NotificationSent）は
+// 同じCorrelationIDを持ち、CausationIDをたどると各イベントの直接の原因がわかる。
+type Metadata struct {
+	// CorrelationID は一連の処理で共通の識別子。
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// CausationID はこのイベントの直接の原因となったイベントのID。起点のイベントでは空。
+	CausationID string `json:"causation_id,omitempty"`
 }
 
 // MediaUploadedData はMediaUploadedイベントのデータ。
@@ -82,6 +111,8 @@ type MediaUploadedData struct {
 type MediaProcessedData struct {
 	// ThumbnailPath はサムネイル画像の保存パス。
 	ThumbnailPath string `json:"thumbnail_path"`
+	// ThumbnailFormat はサムネイル画像の形式（jpeg または png）。動画の場合は空。
+	ThumbnailFormat string `json:"thumbnail_format,omitempty"`
 	// Width は画像/動画の幅（ピクセル）。
 	Width int `json:"width"`
 	// Height は画像/動画の高さ（ピクセル）。
@@ -110,6 +141,24 @@ type MediaUploadCompensatedData struct {
 	SagaID string `json:"saga_id"`
 }
 
+// MediaFlaggedData はMediaFlaggedイベントのデータ。
+type MediaFlaggedData struct {
+	// Reason は要確認と判定された理由。
+	Reason string `json:"reason"`
+}
+
+// MediaMarkedFailedData はMediaMarkedFailedイベントのデータ。
+type MediaMarkedFailedData struct {
+	// AdminUserID は変更を実行した管理者のユーザーID。
+	AdminUserID string `json:"admin_user_id"`
+}
+
+// MediaRestoredData はMediaRestoredイベントのデータ。
+type MediaRestoredData struct {
+	// AdminUserID は変更を実行した管理者のユーザーID。
+	AdminUserID string `json:"admin_user_id"`
+}
+
 // AlbumCreatedData はAlbumCreatedイベントのデータ。
 type AlbumCreatedData struct {
 	// UserID はアルバムを作成したユーザーのID。
@@ -147,3 +196,23 @@ type NotificationSentData struct {
 	// Message は通知メッセージ。
 	Message string `json:"message"`
 }
+
+// EventRetractedData はEventRetractedイベントのデータ。
+type EventRetractedData struct {
+	// RetractedEventID は撤回対象のイベントID。
+	RetractedEventID string `json:"retracted_event_id"`
+	// Reason は撤回の理由。
+	Reason string `json:"reason"`
+}
+
+// AggregateEventLimitExceededData はAggregateEventLimitExceededイベントのデータ。
+type AggregateEventLimitExceededData struct {
+	// AggregateID はイベント数が上限を超えたAggregateの識別子。
+	AggregateID string `json:"aggregate_id"`
+	// AggregateType はイベント数が上限を超えたAggregateの種類。
+	AggregateType string `json:"aggregate_type"`
+	// EventCount は上限を超えた時点のイベント数。
+	EventCount int64 `json:"event_count"`
+	// Limit はAggregateあたりのイベント数の上限。
+	Limit int64 `json:"limit"`
+}
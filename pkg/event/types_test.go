@@ -30,6 +30,11 @@ func TestAggregateTypeConstants(t *testing.T) {
 			got:  AggregateTypeUser,
 			want: "User",
 		},
+		{
+			name: "AggregateTypeSystemの値が正しいこと",
+			got:  AggregateTypeSystem,
+			want: "System",
+		},
 	}
 
 	for _, tt := range tests {
@@ -76,6 +81,11 @@ func TestTypeConstants(t *testing.T) {
 			got:  TypeMediaUploadCompensated,
 			want: "MediaUploadCompensated",
 		},
+		{
+			name: "TypeMediaFlaggedの値が正しいこと",
+			got:  TypeMediaFlagged,
+			want: "MediaFlagged",
+		},
 		{
 			name: "TypeAlbumCreatedの値が正しいこと",
 			got:  TypeAlbumCreated,
@@ -101,6 +111,16 @@ func TestTypeConstants(t *testing.T) {
 			got:  TypeNotificationSent,
 			want: "NotificationSent",
 		},
+		{
+			name: "TypeEventRetractedの値が正しいこと",
+			got:  TypeEventRetracted,
+			want: "EventRetracted",
+		},
+		{
+			name: "TypeAggregateEventLimitExceededの値が正しいこと",
+			got:  TypeAggregateEventLimitExceeded,
+			want: "AggregateEventLimitExceeded",
+		},
 	}
 
 	for _, tt := range tests {
@@ -412,6 +432,29 @@ func TestMediaProcessingFailedDataJSON(t *testing.T) {
 	}
 }
 
+// TestMediaFlaggedDataJSON はMediaFlaggedDataのJSONシリアライズを検証する。
+func TestMediaFlaggedDataJSON(t *testing.T) {
+	t.Parallel()
+
+	data := MediaFlaggedData{
+		Reason: "不適切なコンテンツの可能性があります",
+	}
+
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("json.Marshal()でエラーが発生: %v", err)
+	}
+
+	var decoded MediaFlaggedData
+	if err := json.Unmarshal(jsonBytes, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal()でエラーが発生: %v", err)
+	}
+
+	if decoded.Reason != data.Reason {
+		t.Errorf("Reason = %q, want %q", decoded.Reason, data.Reason)
+	}
+}
+
 // TestAlbumDeletedDataJSON はAlbumDeletedDataのJSONシリアライズを検証する。
 func TestAlbumDeletedDataJSON(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,9 @@
+// Package eventstoretest は各サービスのテストで使うEvent Storeのモックサーバーを提供する。
+//
+// Serverはイベント追記API（POST /api/v1/events）を模倣し、必須項目とイベントタイプの検証、
+// Aggregateごとのバージョン採番、expected_versionによる競合検出を実際のEvent Storeと同じ形式の
+// レスポンスで返す。受信したイベントはEventsで取得でき、AssertEventTypesで受信順を検証できる。
+// Aggregateのイベント取得API（GET /api/v1/events/aggregate/:id）も模倣し、Seedで登録した既存のイベントと
+// 追記したイベントを実際のEvent Storeと同じくlimit（既定100件）とoffsetでページングして返す。
+// レスポンス形式が実際のEvent Storeと一致することはinternal/eventstoreのテストで保証する。
+package eventstoretest
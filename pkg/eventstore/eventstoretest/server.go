@@ -0,0 +1,371 @@
+package eventstoretest
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nao1215/micro/pkg/event"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// AppendPath はEvent Storeのイベント追記APIのパス。
+const AppendPath = "/api/v1/events"
+
+// AggregatePathPrefix はEvent StoreのAggregateのイベント取得APIのパスの接頭辞。後ろにAggregateIDを続ける。
+const AggregatePathPrefix = "/api/v1/events/aggregate/"
+
+const (
+	// defaultPageLimit はAggregateのイベント取得APIでlimitを省略した場合の件数（実際のEvent Storeと同じ）。
+	defaultPageLimit = 100
+	// maxPageLimit はAggregateのイベント取得APIのlimitの最大値（実際のEvent Storeと同じ）。
+	maxPageLimit = 1000
+)
+
+// Event はモックが受信して追記したイベント。
+type Event struct {
+	// ID はモックが採番したイベントID。
+	ID string
+	// AggregateID は対象エンティティの識別子。
+	AggregateID string
+	// AggregateType は対象エンティティの種類。
+	AggregateType string
+	// EventType はイベントの種類。
+	EventType string
+	// Data はイベント固有のデータ（JSON形式）。
+	Data json.RawMessage
+	// Version はモックが採番したバージョン。
+	Version int64
+	// ExpectedVersion はリクエストで指定されたexpected_version。省略された場合はnil。
+	ExpectedVersion *int64
+	// UserID はリクエストのX-User-IDヘッダー。
+	UserID string
+	// Metadata はイベントに記録した追跡情報。実際のEvent Storeと同様にリクエストボディのmetadataを優先し、
+	// 省略された項目はX-Correlation-ID・X-Causation-IDヘッダーの値とする。Correlation IDがどちらにもない場合は生成する。
+	Metadata event.Metadata
+	// CreatedAt はモックが追記した日時。
+	CreatedAt time.Time
+}
+
+// DecodeData はイベントのDataを指定された型にデシリアライズする。失敗した場合はテストを中断する。
+func DecodeData[T any](t testing.TB, ev Event) T {
+	t.Helper()
+
+	var data T
+	if err := json.Unmarshal(ev.Data, &data); err != nil {
+		t.Fatalf("%sイベントのデータのデシリアライズに失敗: %v", ev.EventType, err)
+	}
+	return data
+}
+
+// appendRequest はイベント追記リクエストのJSON構造。
+type appendRequest struct {
+	AggregateID     string          `json:"aggregate_id"`
+	AggregateType   string          `json:"aggregate_type"`
+	EventType       string          `json:"event_type"`
+	Data            json.RawMessage `json:"data"`
+	ExpectedVersion *int64          `json:"expected_version"`
+	Metadata        *event.Metadata `json:"metadata"`
+}
+
+// eventResponse はイベント追記成功時・取得時のJSONレスポンス構造。実際のEvent Storeと同じ形式。
+type eventResponse struct {
+	ID            string         `json:"id"`
+	AggregateID   string         `json:"aggregate_id"`
+	AggregateType string         `json:"aggregate_type"`
+	EventType     string         `json:"event_type"`
+	Data          string         `json:"data"`
+	Version       int64          `json:"version"`
+	CreatedAt     string         `json:"created_at"`
+	Metadata      event.Metadata `json:"metadata"`
+}
+
+// versionConflictResponse はexpected_versionが最新バージョンと一致しない場合のJSONレスポンス構造。実際のEvent Storeと同じ形式。
+type versionConflictResponse struct {
+	Error           string `json:"error"`
+	ExpectedVersion int64  `json:"expected_version"`
+	CurrentVersion  int64  `json:"current_version"`
+}
+
+// Server はEvent Storeのイベント追記APIを模倣するテスト用サーバー。
+type Server struct {
+	// URL はモックサーバーのベースURL（例: "http://127.0.0.1:12345"）。
+	URL string
+	// down がtrueの間は全リクエストに503を返す。
+	down atomic.Bool
+	// mu はversionsとreceivedへの並行アクセスを保護するミューテックス。
+	mu sync.Mutex
+	// versions はAggregateIDごとの最新バージョン。
+	versions map[string]int64
+	// received は追記したイベント（受信順）。
+	received []Event
+	// seeded はSeedで登録した既存のイベント（登録順）。
+	seeded []Event
+}
+
+// New はモックサーバーを起動する。サーバーはテスト終了時に停止する。
+func New(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{versions: make(map[string]int64)}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	s.URL = ts.URL
+	return s
+}
+
+// SetDown はEvent Storeの停止状態を切り替える。停止中は全リクエストに503を返し、イベントを記録しない。
+func (s *Server) SetDown(down bool) {
+	s.down.Store(down)
+}
+
+// SetVersion はAggregateの最新バージョンを設定する。
+// 既存のイベントがあるAggregateへの追記やexpected_versionの競合を再現するために使用する。
+func (s *Server) SetVersion(aggregateID string, version int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[aggregateID] = version
+}
+
+// Seed はEvent Storeに追記済みのイベントを登録し、登録したイベントを返す。
+// 登録したイベントはAggregateのイベント取得APIで返すが、EventsやAssertEventTypesには含めない。
+// バージョンはAggregateの最新バージョン+1とする。dataのシリアライズに失敗した場合はテストを中断する。
+func (s *Server) Seed(t testing.TB, aggregateID string, aggregateType event.AggregateType, eventType event.Type, data any) Event {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ev, err := event.New(aggregateID, aggregateType, eventType, s.versions[aggregateID]+1, data)
+	if err != nil {
+		t.Fatalf("%sイベントの生成に失敗: %v", eventType, err)
+	}
+	seeded := Event{
+		ID:            ev.ID,
+		AggregateID:   ev.AggregateID,
+		AggregateType: string(ev.AggregateType),
+		EventType:     string(ev.EventType),
+		Data:          ev.Data,
+		Version:       ev.Version,
+		Metadata:      event.Metadata{CorrelationID: uuid.New().String()},
+		CreatedAt:     ev.CreatedAt,
+	}
+	s.versions[aggregateID] = ev.Version
+	s.seeded = append(s.seeded, seeded)
+	return seeded
+}
+
+// Events は追記したイベントのコピーを受信順に返す。
+func (s *Server) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.received)
+}
+
+// EventTypes は追記したイベントのイベントタイプを受信順に返す。
+func (s *Server) EventTypes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	types := make([]string, 0, len(s.received))
+	for _, ev := range s.received {
+		types = append(types, ev.EventType)
+	}
+	return types
+}
+
+// AssertEventTypes は追記したイベントのイベントタイプが受信順にwantと一致することを検証する。
+func (s *Server) AssertEventTypes(t testing.TB, want ...string) {
+	t.Helper()
+
+	if got := s.EventTypes(); !slices.Equal(got, want) {
+		t.Errorf("Event Storeが受信したイベントタイプ: got %v, want %v", got, want)
+	}
+}
+
+// WaitForEvents は追記したイベントがn件以上になるまで待ち、イベントを受信順に返す。
+// 非同期に配送されるイベントの検証に使用する。一定時間内にn件に達しなければテストを中断する。
+func (s *Server) WaitForEvents(t testing.TB, n int) []Event {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		events := s.Events()
+		if len(events) >= n {
+			return events
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Event Storeが%d件のイベントを受信するのを待機中にタイムアウトしました（受信: %d件）", n, len(events))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// ServeHTTP はイベント追記リクエストとAggregateのイベント取得リクエストを処理する。
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.down.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Event Storeは停止中です"})
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == AppendPath:
+		s.serveAppend(w, r)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, AggregatePathPrefix) && !strings.Contains(strings.TrimPrefix(r.URL.Path, AggregatePathPrefix), "/"):
+		s.serveAggregate(w, r, strings.TrimPrefix(r.URL.Path, AggregatePathPrefix))
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("モックが対応していないAPIです: %s %s", r.Method, r.URL.Path)})
+	}
+}
+
+// serveAggregate はAggregateのイベント取得リクエストを処理する。
+// 実際のEvent Storeと同様に、Seedで登録したイベントと追記したイベントをバージョンの昇順の配列で返し、
+// イベントがない場合は空配列を返す。limit（既定100、最大1000）とoffsetによるページングも模倣し、
+// 全件数をX-Total-Countヘッダーで返す。撤回されたイベントの除外は模倣しない。
+func (s *Server) serveAggregate(w http.ResponseWriter, r *http.Request, aggregateID string) {
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	var events []Event
+	for _, ev := range slices.Concat(s.seeded, s.received) {
+		if ev.AggregateID == aggregateID {
+			events = append(events, ev)
+		}
+	}
+	s.mu.Unlock()
+	slices.SortFunc(events, func(a, b Event) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	total := len(events)
+	events = events[min(offset, total):min(offset+limit, total)]
+	responses := make([]eventResponse, 0, len(events))
+	for _, ev := range events {
+		responses = append(responses, toEventResponse(ev))
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeJSON(w, http.StatusOK, responses)
+}
+
+// parsePage は実際のEvent Storeと同じ規則でクエリパラメータのlimitとoffsetを解析する。
+func parsePage(r *http.Request) (limit, offset int, err error) {
+	limit = defaultPageLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxPageLimit {
+			return 0, 0, fmt.Errorf("limitは1から%dまでの整数で指定してください", maxPageLimit)
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, errors.New("offsetは0以上の整数で指定してください")
+		}
+	}
+	return limit, offset, nil
+}
+
+// serveAppend はイベント追記リクエストを処理する。
+// 実際のEvent Storeと同様に、必須項目の欠落・未登録のイベントタイプ・負のexpected_versionは400、
+// expected_versionが最新バージョンと一致しない場合は409を返し、成功時は最新バージョン+1で追記して201を返す。
+func (s *Server) serveAppend(w http.ResponseWriter, r *http.Request) {
+
+	var req appendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("リクエストが不正です: %v", err)})
+		return
+	}
+	if req.AggregateID == "" || req.AggregateType == "" || req.EventType == "" || len(req.Data) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストが不正です: aggregate_id, aggregate_type, event_type, dataは必須です"})
+		return
+	}
+	if req.ExpectedVersion != nil && *req.ExpectedVersion < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expected_versionは0以上で指定してください"})
+		return
+	}
+	if !event.IsValidType(event.Type(req.EventType)) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("未登録のイベントタイプです: %s", req.EventType)})
+		return
+	}
+
+	s.mu.Lock()
+	latest := s.versions[req.AggregateID]
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != latest {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusConflict, versionConflictResponse{
+			Error:           fmt.Sprintf("Aggregateのバージョンが期待と一致しません（期待: %d, 最新: %d）。最新の状態を取得し直してください", *req.ExpectedVersion, latest),
+			ExpectedVersion: *req.ExpectedVersion,
+			CurrentVersion:  latest,
+		})
+		return
+	}
+	ev, err := event.New(req.AggregateID, event.AggregateType(req.AggregateType), event.Type(req.EventType), latest+1, req.Data)
+	if err != nil {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "イベント生成に失敗しました"})
+		return
+	}
+	ev.Metadata = event.Metadata{
+		CorrelationID: r.Header.Get(httpclient.CorrelationIDHeader),
+		CausationID:   r.Header.Get(httpclient.CausationIDHeader),
+	}
+	if req.Metadata != nil {
+		if req.Metadata.CorrelationID != "" {
+			ev.Metadata.CorrelationID = req.Metadata.CorrelationID
+		}
+		if req.Metadata.CausationID != "" {
+			ev.Metadata.CausationID = req.Metadata.CausationID
+		}
+	}
+	if ev.Metadata.CorrelationID == "" {
+		ev.Metadata.CorrelationID = uuid.New().String()
+	}
+	s.versions[req.AggregateID] = ev.Version
+	received := Event{
+		ID:              ev.ID,
+		AggregateID:     ev.AggregateID,
+		AggregateType:   string(ev.AggregateType),
+		EventType:       string(ev.EventType),
+		Data:            ev.Data,
+		Version:         ev.Version,
+		ExpectedVersion: req.ExpectedVersion,
+		UserID:          r.Header.Get("X-User-ID"),
+		Metadata:        ev.Metadata,
+		CreatedAt:       ev.CreatedAt,
+	}
+	s.received = append(s.received, received)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, toEventResponse(received))
+}
+
+// toEventResponse はイベントを実際のEvent Storeと同じ形式のレスポンスに変換する。
+func toEventResponse(ev Event) eventResponse {
+	return eventResponse{
+		ID:            ev.ID,
+		AggregateID:   ev.AggregateID,
+		AggregateType: ev.AggregateType,
+		EventType:     ev.EventType,
+		Data:          string(ev.Data),
+		Version:       ev.Version,
+		CreatedAt:     ev.CreatedAt.Format(time.RFC3339),
+		Metadata:      ev.Metadata,
+	}
+}
+
+// writeJSON はステータスコードとJSONレスポンスを書き込む。
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
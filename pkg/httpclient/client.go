@@ -17,46 +17,137 @@ type Client struct {
 	httpClient *http.Client
 	// baseURL は接続先サービスのベースURL。
 	baseURL string
+	// signingKey はリクエストの署名に使用する鍵。nilの場合は署名しない。
+	signingKey []byte
+	// maxRetries は一時的な失敗を再試行する最大回数。0の場合は再試行しない。
+	maxRetries int
+	// retryBaseDelay は最初の再試行までの待機時間。
+	retryBaseDelay time.Duration
 }
 
+// Option はClientの動作を設定するオプション。
+type Option func(*Client)
+
 // New は新しいサービス間通信用HTTPクライアントを生成する。
 // baseURLには接続先サービスのベースURL（例: "http://eventstore:8084"）を指定する。
-func New(baseURL string) *Client {
-	return &Client{
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 		baseURL: baseURL,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // PostJSON は指定パスにJSONボディでPOSTリクエストを送信する。
 // レスポンスボディをresultにデシリアライズする。
 func (c *Client) PostJSON(ctx context.Context, path string, body any, result any) error {
-	return c.doJSON(ctx, http.MethodPost, path, body, result)
+	_, err := c.DoJSON(ctx, http.MethodPost, path, body, result)
+	return err
 }
 
 // GetJSON は指定パスにGETリクエストを送信する。
 // レスポンスボディをresultにデシリアライズする。
 func (c *Client) GetJSON(ctx context.Context, path string, result any) error {
-	return c.doJSON(ctx, http.MethodGet, path, nil, result)
+	_, err := c.DoJSON(ctx, http.MethodGet, path, nil, result)
+	return err
 }
 
-// doJSON はJSON形式のHTTPリクエストを実行する共通処理。
-func (c *Client) doJSON(ctx context.Context, method, path string, body any, result any) error {
-	var bodyReader io.Reader
+// DeleteJSON は指定パスにDELETEリクエストを送信する。
+// レスポンスボディをresultにデシリアライズする。
+func (c *Client) DeleteJSON(ctx context.Context, path string, result any) error {
+	_, err := c.DoJSON(ctx, http.MethodDelete, path, nil, result)
+	return err
+}
+
+// PutJSON は指定パスにJSONボディでPUTリクエストを送信する。
+// レスポンスボディをresultにデシリアライズする。
+func (c *Client) PutJSON(ctx context.Context, path string, body any, result any) error {
+	_, err := c.DoJSON(ctx, http.MethodPut, path, body, result)
+	return err
+}
+
+// PatchJSON は指定パスにJSONボディでPATCHリクエストを送信する。
+// レスポンスボディをresultにデシリアライズする。
+func (c *Client) PatchJSON(ctx context.Context, path string, body any, result any) error {
+	_, err := c.DoJSON(ctx, http.MethodPatch, path, body, result)
+	return err
+}
+
+// maxHealthBackoff はWaitForHealthyで広げる待機時間の上限。
+const maxHealthBackoff = 30 * time.Second
+
+// WaitForHealthy は接続先サービスの /health が成功するまで、最大maxAttempts回リトライする。
+// 失敗するごとに待機時間をbackoffから2倍に広げ、maxHealthBackoffで頭打ちにする（指数バックオフ）。
+// 起動順序によって接続先サービスがまだ立ち上がっていない場合に、起動直後の処理を待たせるために使用する。
+// 上限に達した場合は最後のエラーを返し、ctxがキャンセルされた場合はctx.Err()を返す。
+func (c *Client) WaitForHealthy(ctx context.Context, maxAttempts int, backoff time.Duration) error {
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		lastErr = c.GetJSON(ctx, "/health", nil)
+		if lastErr == nil {
+			return nil
+		}
+		if attempt == maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxHealthBackoff)
+	}
+	return fmt.Errorf("%d回試行しても接続できません: %w", maxAttempts, lastErr)
+}
+
+// DoJSON は指定パスにmethodでJSON形式のHTTPリクエストを送信し、レスポンスのステータスコードを返す。
+// bodyがnilでない場合はJSONにシリアライズしてボディとし、2xxのレスポンスボディをresultにデシリアライズする。
+// 2xx以外のレスポンスはエラーを返すが、ステータスコードもあわせて返すため、
+// 呼び出し側は404と500を区別して補償の要否を判断するといった制御ができる。
+// レスポンスを受け取れなかった場合（送信エラーなど）のステータスコードは0。
+// WithRetryを指定した場合は一時的な失敗のリクエストを再試行し、最後の試行のステータスコードを返す。
+func (c *Client) DoJSON(ctx context.Context, method, path string, body any, result any) (int, error) {
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
-			return fmt.Errorf("リクエストボディのシリアライズに失敗: %w", err)
+			return 0, fmt.Errorf("リクエストボディのシリアライズに失敗: %w", err)
 		}
+	}
+
+	var status int
+	err := c.withRetry(ctx, func() (bool, error) {
+		var (
+			retryable bool
+			err       error
+		)
+		status, retryable, err = c.send(ctx, method, path, jsonBody, result)
+		return retryable, err
+	})
+	return status, err
+}
+
+// send はHTTPリクエストを1回送信し、レスポンスボディをresultにデシリアライズする。
+// レスポンスのステータスコード（レスポンスを受け取れなかった場合は0）を返し、
+// 失敗した場合は、再試行で成功する可能性があるか（送信エラーまたは5xx）をあわせて返す。
+func (c *Client) send(ctx context.Context, method, path string, jsonBody []byte, result any) (int, bool, error) {
+	// 再試行のたびにボディを読み直すため、リクエストは試行ごとに作成する
+	var bodyReader io.Reader
+	if jsonBody != nil {
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
 	url := c.baseURL + path
 	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
-		return fmt.Errorf("HTTPリクエストの作成に失敗: %w", err)
+		return 0, false, fmt.Errorf("HTTPリクエストの作成に失敗: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -64,24 +155,38 @@ func (c *Client) doJSON(ctx context.Context, method, path string, body any, resu
 	if userID, ok := ctx.Value(contextKeyUserID).(string); ok {
 		req.Header.Set("X-User-ID", userID)
 	}
+	// コンテキストから分散トレーシングの追跡情報を伝播する
+	if correlationID := CorrelationID(ctx); correlationID != "" {
+		req.Header.Set(CorrelationIDHeader, correlationID)
+	}
+	if causationID := CausationID(ctx); causationID != "" {
+		req.Header.Set(CausationIDHeader, causationID)
+	}
+
+	// 再試行が受信側で再送として拒否されないよう、署名（ノンス）も試行ごとに作成する
+	if c.signingKey != nil {
+		if err := signRequest(req, c.signingKey, jsonBody, time.Now()); err != nil {
+			return 0, false, err
+		}
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("HTTPリクエストの送信に失敗: %w", err)
+		return 0, true, fmt.Errorf("HTTPリクエストの送信に失敗: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTPエラー: status=%d, body=%s", resp.StatusCode, string(respBody))
+		return resp.StatusCode, resp.StatusCode >= http.StatusInternalServerError, fmt.Errorf("HTTPエラー: status=%d, body=%s", resp.StatusCode, string(respBody))
 	}
 
 	if result != nil {
 		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("レスポンスボディのデシリアライズに失敗: %w", err)
+			return resp.StatusCode, false, fmt.Errorf("レスポンスボディのデシリアライズに失敗: %w", err)
 		}
 	}
-	return nil
+	return resp.StatusCode, false, nil
 }
 
 // contextKey はコンテキストキーの型。
@@ -95,3 +200,47 @@ const contextKeyUserID contextKey = "user_id"
 func WithUserID(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, contextKeyUserID, userID)
 }
+
+const (
+	// CorrelationIDHeader は一連の処理で共通の識別子（イベントのmetadata.correlation_id）を伝播するヘッダー。
+	CorrelationIDHeader = "X-Correlation-ID"
+	// CausationIDHeader は後続のイベントの直接の原因となったイベントのID（metadata.causation_id）を伝播するヘッダー。
+	CausationIDHeader = "X-Causation-ID"
+)
+
+const (
+	// contextKeyCorrelationID はコンテキストにCorrelation IDを格納するためのキー。
+	contextKeyCorrelationID contextKey = "correlation_id"
+	// contextKeyCausationID はコンテキストにCausation IDを格納するためのキー。
+	contextKeyCausationID contextKey = "causation_id"
+)
+
+// WithCorrelationID はコンテキストにCorrelation IDを設定する。
+// コンテキストを渡したリクエストにはX-Correlation-IDヘッダーとして伝播する。idが空の場合はctxをそのまま返す。
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKeyCorrelationID, id)
+}
+
+// CorrelationID はコンテキストに設定されたCorrelation IDを返す。設定されていない場合は空文字を返す。
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKeyCorrelationID).(string)
+	return id
+}
+
+// WithCausationID はコンテキストにCausation IDを設定する。
+// コンテキストを渡したリクエストにはX-Causation-IDヘッダーとして伝播する。idが空の場合はctxをそのまま返す。
+func WithCausationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKeyCausationID, id)
+}
+
+// CausationID はコンテキストに設定されたCausation IDを返す。設定されていない場合は空文字を返す。
+func CausationID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKeyCausationID).(string)
+	return id
+}
@@ -3,10 +3,14 @@ package httpclient
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // testRequest はテストサーバーが受け取ったリクエスト情報を保持する構造体。
@@ -317,6 +321,501 @@ func TestGetJSON(t *testing.T) {
 	})
 }
 
+// TestDeleteJSON はDeleteJSONメソッドを検証する。
+func TestDeleteJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常にDELETEリクエストを送信できること", func(t *testing.T) {
+		t.Parallel()
+
+		var received testRequest
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received.Method = r.Method
+			received.Path = r.URL.Path
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(testPayload{Name: "deleted", Value: 1})
+		}))
+		defer ts.Close()
+
+		client := New(ts.URL)
+		var result testPayload
+
+		if err := client.DeleteJSON(context.Background(), "/api/albums/1/media/2", &result); err != nil {
+			t.Fatalf("DeleteJSON()でエラーが発生: %v", err)
+		}
+		if received.Method != http.MethodDelete {
+			t.Errorf("Method = %q, want %q", received.Method, http.MethodDelete)
+		}
+		if received.Path != "/api/albums/1/media/2" {
+			t.Errorf("Path = %q, want %q", received.Path, "/api/albums/1/media/2")
+		}
+		if result.Name != "deleted" {
+			t.Errorf("result.Name = %q, want %q", result.Name, "deleted")
+		}
+	})
+
+	t.Run("サーバーがエラーを返した場合にエラーが返ること", func(t *testing.T) {
+		t.Parallel()
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		if err := New(ts.URL).DeleteJSON(context.Background(), "/api/test", nil); err == nil {
+			t.Fatal("DeleteJSON()がエラーを返すべきだが、nilが返った")
+		}
+	})
+
+	t.Run("DeleteJSONでもユーザーIDが伝播されること", func(t *testing.T) {
+		t.Parallel()
+
+		var received testRequest
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received.Headers = r.Header.Clone()
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer ts.Close()
+
+		ctx := WithUserID(context.Background(), "user-delete")
+		if err := New(ts.URL).DeleteJSON(ctx, "/api/test", nil); err != nil {
+			t.Fatalf("DeleteJSON()でエラーが発生: %v", err)
+		}
+		if got := received.Headers.Get("X-User-ID"); got != "user-delete" {
+			t.Errorf("X-User-ID = %q, want %q", got, "user-delete")
+		}
+	})
+}
+
+// TestDoJSON はDoJSONメソッドがレスポンスのステータスコードを返すことを検証する。
+func TestDoJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("成功した場合にステータスコードとレスポンスボディを返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		var received testRequest
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received.Method = r.Method
+			received.Body, _ = io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(testPayload{Name: "created", Value: 1})
+		}))
+		defer ts.Close()
+
+		var result testPayload
+		status, err := New(ts.URL).DoJSON(context.Background(), http.MethodPost, "/api/test", testPayload{Name: "req"}, &result)
+		if err != nil {
+			t.Fatalf("DoJSON()でエラーが発生: %v", err)
+		}
+		if status != http.StatusCreated {
+			t.Errorf("status = %d, want %d", status, http.StatusCreated)
+		}
+		if received.Method != http.MethodPost || string(received.Body) != `{"name":"req","value":0}` {
+			t.Errorf("リクエスト = %s %s, want POST {\"name\":\"req\",\"value\":0}", received.Method, received.Body)
+		}
+		if result.Name != "created" {
+			t.Errorf("result.Name = %q, want %q", result.Name, "created")
+		}
+	})
+
+	t.Run("2xx以外の場合にエラーとステータスコードを返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		for _, want := range []int{http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError} {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(want)
+			}))
+
+			status, err := New(ts.URL).DoJSON(context.Background(), http.MethodGet, "/api/test", nil, nil)
+			ts.Close()
+			if err == nil {
+				t.Errorf("status=%d: DoJSON()がエラーを返すべきだが、nilが返った", want)
+			}
+			if status != want {
+				t.Errorf("status = %d, want %d", status, want)
+			}
+		}
+	})
+
+	t.Run("レスポンスを受け取れなかった場合はステータスコード0を返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		ts := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		url := ts.URL
+		ts.Close()
+
+		status, err := New(url).DoJSON(context.Background(), http.MethodGet, "/api/test", nil, nil)
+		if err == nil {
+			t.Fatal("DoJSON()がエラーを返すべきだが、nilが返った")
+		}
+		if status != 0 {
+			t.Errorf("status = %d, want 0", status)
+		}
+	})
+
+	t.Run("再試行した場合は最後の試行のステータスコードを返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if calls.Add(1) < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer ts.Close()
+
+		status, err := New(ts.URL, WithRetry(2, time.Millisecond)).DoJSON(context.Background(), http.MethodDelete, "/api/test", nil, nil)
+		if err != nil {
+			t.Fatalf("DoJSON()でエラーが発生: %v", err)
+		}
+		if status != http.StatusAccepted {
+			t.Errorf("status = %d, want %d", status, http.StatusAccepted)
+		}
+	})
+}
+
+// TestPutJSON はPutJSONメソッドを検証する。
+func TestPutJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常にPUTリクエストを送信してレスポンスを取得できること", func(t *testing.T) {
+		t.Parallel()
+
+		var received testRequest
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received.Method = r.Method
+			received.Path = r.URL.Path
+			received.Headers = r.Header.Clone()
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("リクエストボディの読み取りに失敗: %v", err)
+			}
+			received.Body = body
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(testPayload{Name: "updated", Value: 2})
+		}))
+		defer ts.Close()
+
+		ctx := WithUserID(context.Background(), "user-1")
+		var result testPayload
+		if err := New(ts.URL).PutJSON(ctx, "/api/albums/1", testPayload{Name: "album", Value: 1}, &result); err != nil {
+			t.Fatalf("PutJSON()でエラーが発生: %v", err)
+		}
+		if received.Method != http.MethodPut {
+			t.Errorf("Method = %q, want %q", received.Method, http.MethodPut)
+		}
+		if received.Path != "/api/albums/1" {
+			t.Errorf("Path = %q, want %q", received.Path, "/api/albums/1")
+		}
+		if got := received.Headers.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want %q", got, "application/json")
+		}
+		if got := received.Headers.Get("X-User-ID"); got != "user-1" {
+			t.Errorf("X-User-ID = %q, want %q", got, "user-1")
+		}
+		var sent testPayload
+		if err := json.Unmarshal(received.Body, &sent); err != nil {
+			t.Fatalf("送信されたボディのデシリアライズに失敗: %v", err)
+		}
+		if sent.Name != "album" || sent.Value != 1 {
+			t.Errorf("送信されたボディ = %+v, want {Name:album Value:1}", sent)
+		}
+		if result.Name != "updated" || result.Value != 2 {
+			t.Errorf("result = %+v, want {Name:updated Value:2}", result)
+		}
+	})
+
+	t.Run("サーバーがエラーを返した場合にエラーが返ること", func(t *testing.T) {
+		t.Parallel()
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+		}))
+		defer ts.Close()
+
+		err := New(ts.URL).PutJSON(context.Background(), "/api/test", testPayload{}, nil)
+		if err == nil {
+			t.Fatal("PutJSON()がエラーを返すべきだが、nilが返った")
+		}
+		if !strings.Contains(err.Error(), "status=404") {
+			t.Errorf("エラーメッセージにステータスコードが含まれません: %v", err)
+		}
+	})
+}
+
+// TestPatchJSON はPatchJSONメソッドを検証する。
+func TestPatchJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("正常にPATCHリクエストを送信してレスポンスを取得できること", func(t *testing.T) {
+		t.Parallel()
+
+		var received testRequest
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received.Method = r.Method
+			received.Path = r.URL.Path
+			received.Headers = r.Header.Clone()
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("リクエストボディの読み取りに失敗: %v", err)
+			}
+			received.Body = body
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(testPayload{Name: "updated", Value: 2})
+		}))
+		defer ts.Close()
+
+		ctx := WithUserID(context.Background(), "user-1")
+		var result testPayload
+		if err := New(ts.URL).PatchJSON(ctx, "/api/albums/1", testPayload{Name: "album", Value: 1}, &result); err != nil {
+			t.Fatalf("PatchJSON()でエラーが発生: %v", err)
+		}
+		if received.Method != http.MethodPatch {
+			t.Errorf("Method = %q, want %q", received.Method, http.MethodPatch)
+		}
+		if received.Path != "/api/albums/1" {
+			t.Errorf("Path = %q, want %q", received.Path, "/api/albums/1")
+		}
+		if got := received.Headers.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want %q", got, "application/json")
+		}
+		if got := received.Headers.Get("X-User-ID"); got != "user-1" {
+			t.Errorf("X-User-ID = %q, want %q", got, "user-1")
+		}
+		var sent testPayload
+		if err := json.Unmarshal(received.Body, &sent); err != nil {
+			t.Fatalf("送信されたボディのデシリアライズに失敗: %v", err)
+		}
+		if sent.Name != "album" || sent.Value != 1 {
+			t.Errorf("送信されたボディ = %+v, want {Name:album Value:1}", sent)
+		}
+		if result.Name != "updated" || result.Value != 2 {
+			t.Errorf("result = %+v, want {Name:updated Value:2}", result)
+		}
+	})
+
+	t.Run("サーバーがエラーを返した場合にエラーが返ること", func(t *testing.T) {
+		t.Parallel()
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+		}))
+		defer ts.Close()
+
+		err := New(ts.URL).PatchJSON(context.Background(), "/api/test", testPayload{}, nil)
+		if err == nil {
+			t.Fatal("PatchJSON()がエラーを返すべきだが、nilが返った")
+		}
+		if !strings.Contains(err.Error(), "status=404") {
+			t.Errorf("エラーメッセージにステータスコードが含まれません: %v", err)
+		}
+	})
+}
+
+// TestWaitForHealthy はWaitForHealthyのリトライを検証する。
+func TestWaitForHealthy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("起動が遅れたサービスにリトライで接続できること", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/health" {
+				t.Errorf("Path = %q, want %q", r.URL.Path, "/health")
+			}
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"status":"ok"}`))
+		}))
+		defer ts.Close()
+
+		if err := New(ts.URL).WaitForHealthy(context.Background(), 5, time.Millisecond); err != nil {
+			t.Fatalf("WaitForHealthy()でエラーが発生: %v", err)
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("試行回数 = %d, want 3", got)
+		}
+	})
+
+	t.Run("上限回数まで失敗した場合にエラーが返ること", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+
+		if err := New(ts.URL).WaitForHealthy(context.Background(), 3, time.Millisecond); err == nil {
+			t.Fatal("WaitForHealthy()がエラーを返すべきだが、nilが返った")
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("試行回数 = %d, want 3", got)
+		}
+	})
+
+	t.Run("コンテキストがキャンセルされた場合に待機を中断すること", func(t *testing.T) {
+		t.Parallel()
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := New(ts.URL).WaitForHealthy(ctx, 3, time.Hour)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("エラー = %v, want context.Canceled", err)
+		}
+	})
+}
+
+// TestWithRetry はWithRetryによる一時的な失敗の再試行を検証する。
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	t.Run("5xxを指数バックオフで再試行して成功すること", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		var bodies []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"message":"ok"}`))
+		}))
+		defer ts.Close()
+
+		var result map[string]string
+		client := New(ts.URL, WithRetry(3, time.Millisecond))
+		if err := client.PostJSON(context.Background(), "/api", map[string]string{"key": "value"}, &result); err != nil {
+			t.Fatalf("PostJSON()でエラーが発生: %v", err)
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("試行回数 = %d, want 3", got)
+		}
+		if result["message"] != "ok" {
+			t.Errorf("レスポンス = %v, want message=ok", result)
+		}
+		// 再試行でも同じボディを送ること
+		for i, body := range bodies {
+			if body != `{"key":"value"}` {
+				t.Errorf("%d回目のボディ = %s, want {\"key\":\"value\"}", i+1, body)
+			}
+		}
+	})
+
+	t.Run("上限回数まで再試行して最後のエラーを返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+
+		err := New(ts.URL, WithRetry(2, time.Millisecond)).GetJSON(context.Background(), "/api", nil)
+		if err == nil || !strings.Contains(err.Error(), "status=503") {
+			t.Fatalf("エラー = %v, want status=503 を含むエラー", err)
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("試行回数 = %d, want 3（初回 + 再試行2回）", got)
+		}
+	})
+
+	t.Run("4xxは再試行せずにすぐ返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusConflict)
+		}))
+		defer ts.Close()
+
+		err := New(ts.URL, WithRetry(3, time.Millisecond)).GetJSON(context.Background(), "/api", nil)
+		if err == nil || !strings.Contains(err.Error(), "status=409") {
+			t.Fatalf("エラー = %v, want status=409 を含むエラー", err)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("試行回数 = %d, want 1", got)
+		}
+	})
+
+	t.Run("接続エラーを再試行すること", func(t *testing.T) {
+		t.Parallel()
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+		url := ts.URL
+		ts.Close()
+
+		start := time.Now()
+		err := New(url, WithRetry(2, 20*time.Millisecond)).GetJSON(context.Background(), "/api", nil)
+		if err == nil || !strings.Contains(err.Error(), "送信に失敗") {
+			t.Fatalf("エラー = %v, want 送信エラー", err)
+		}
+		// 20ms + 40ms の待機を経て上限に達すること
+		if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+			t.Errorf("経過時間 = %v, want 60ms以上（指数バックオフで待機）", elapsed)
+		}
+	})
+
+	t.Run("コンテキストがキャンセルされた場合は再試行を中断すること", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ctx, cancel := context.WithCancel(context.Background())
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			cancel()
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		err := New(ts.URL, WithRetry(3, time.Hour)).GetJSON(ctx, "/api", nil)
+		if err == nil {
+			t.Fatal("GetJSON()がエラーを返すべきだが、nilが返った")
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("試行回数 = %d, want 1", got)
+		}
+	})
+
+	t.Run("WithRetryを指定しない場合は再試行しないこと", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		if err := New(ts.URL).GetJSON(context.Background(), "/api", nil); err == nil {
+			t.Fatal("GetJSON()がエラーを返すべきだが、nilが返った")
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("試行回数 = %d, want 1", got)
+		}
+	})
+}
+
 // TestWithUserID はWithUserID関数を検証する。
 func TestWithUserID(t *testing.T) {
 	t.Parallel()
@@ -448,3 +947,55 @@ func TestPostJSON_SerializationError(t *testing.T) {
 		t.Fatal("PostJSON()がエラーを返すべきだが、nilが返った")
 	}
 }
+
+// TestWithCorrelationID はCorrelation IDとCausation IDのヘッダーへの伝播を検証する。
+func TestWithCorrelationID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		wantCorrelation string
+		wantCausation   string
+	}{
+		{
+			name:            "コンテキストに設定した追跡情報をヘッダーとして伝播できること",
+			ctx:             WithCausationID(WithCorrelationID(context.Background(), "correlation-1"), "event-1"),
+			wantCorrelation: "correlation-1",
+			wantCausation:   "event-1",
+		},
+		{
+			name: "設定されていない場合はヘッダーを付与しないこと",
+			ctx:  context.Background(),
+		},
+		{
+			name: "空のIDは設定しないこと",
+			ctx:  WithCausationID(WithCorrelationID(context.Background(), ""), ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotCorrelation, gotCausation string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotCorrelation = r.Header.Get(CorrelationIDHeader)
+				gotCausation = r.Header.Get(CausationIDHeader)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(testPayload{Name: "ok", Value: 1})
+			}))
+			defer ts.Close()
+
+			if err := New(ts.URL).PostJSON(tt.ctx, "/api/v1/events", testPayload{Name: "test"}, nil); err != nil {
+				t.Fatalf("PostJSON()でエラーが発生: %v", err)
+			}
+			if gotCorrelation != tt.wantCorrelation {
+				t.Errorf("%s = %q, want %q", CorrelationIDHeader, gotCorrelation, tt.wantCorrelation)
+			}
+			if gotCausation != tt.wantCausation {
+				t.Errorf("%s = %q, want %q", CausationIDHeader, gotCausation, tt.wantCausation)
+			}
+		})
+	}
+}
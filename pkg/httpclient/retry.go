@@ -0,0 +1,42 @@
+package httpclient
+
+import (
+	"context"
+	"time"
+)
+
+// maxRetryDelay はWithRetryで広げる待機時間の上限。
+const maxRetryDelay = 30 * time.Second
+
+// WithRetry は一時的な失敗（接続エラー・タイムアウトなどの送信エラーと5xx）のリクエストを、
+// 最大maxRetries回まで再試行するオプション。
+// 待機時間はbaseDelayから再試行ごとに2倍に広げ、maxRetryDelayで頭打ちにする（指数バックオフ）。
+// 4xxは再試行せずにすぐ返し、ctxがキャンセルされた場合やタイムアウトした場合は再試行を中断する。
+// メソッドによらず再試行するため、受信側で重複を検出できない非冪等なリクエストには使用しないこと。
+// maxRetriesが0以下の場合は再試行しない。
+func WithRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = max(maxRetries, 0)
+		c.retryBaseDelay = baseDelay
+	}
+}
+
+// withRetry はsendを実行し、失敗した場合はWithRetryの設定に従って再試行する。
+// sendは失敗が一時的なもの（5xxまたは送信エラー）かをretryableとして返す。
+// 再試行の上限に達した場合は最後のエラーを返し、ctxがキャンセルされた場合は再試行せずに中断する。
+func (c *Client) withRetry(ctx context.Context, send func() (retryable bool, err error)) error {
+	delay := c.retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		retryable, err := send()
+		if err == nil || !retryable || attempt >= c.maxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
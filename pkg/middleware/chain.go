@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// ChainConfig はDefaultChainが組み立てるミドルウェアの構成。
+// ゼロ値は全サービス共通の標準構成（Recovery・Logger・Correlation・DecompressRequest）を表す。
+type ChainConfig struct {
+	// AllowedOrigins はCORSを許可するオリジン。空の場合はCORSを適用しない（外部に公開するgatewayのみ指定する）。
+	AllowedOrigins []string
+	// MaxDecompressedBytes は展開後のリクエストボディの上限。0の場合はDefaultMaxDecompressedBytesを使用する。
+	MaxDecompressedBytes int64
+	// DisableLogger がtrueの場合はリクエストログを出力しない（テストなど）。
+	DisableLogger bool
+	// DisableDecompress がtrueの場合は圧縮されたリクエストボディを展開しない。
+	DisableDecompress bool
+	// Extra は標準のミドルウェアの後に、指定した順で追加するサービス固有のミドルウェア。
+	Extra []gin.HandlerFunc
+}
+
+// Chain はmwsを指定した順に並べたミドルウェアの列を返す。nilの要素は取り除くため、
+// 条件付きのミドルウェアを `Chain(a, maybeNil, b)` のように並べたまま除外できる。
+// 戻り値は `router.Use(middleware.Chain(...)...)` のように展開して適用する。
+func Chain(mws ...gin.HandlerFunc) gin.HandlersChain {
+	chain := make(gin.HandlersChain, 0, len(mws))
+	for _, mw := range mws {
+		if mw != nil {
+			chain = append(chain, mw)
+		}
+	}
+	return chain
+}
+
+// DefaultChain は全サービスで推奨するミドルウェアを、正しい順序で並べた列を返す。
+// 順序は Recovery → Logger → CORS → Correlation → DecompressRequest → cfg.Extra とする。
+// Recoveryは後続のミドルウェアのパニックも捕捉できるよう常に先頭に置き、除外できない。
+// Loggerは後続のミドルウェアが中断したリクエスト（413や401など）もログに残せるようRecoveryの直後に置く。
+// CORSはプリフライトを展開より先に応答し、展開に失敗した413や415のレスポンスもブラウザから読めるようDecompressRequestの前に置く。
+func DefaultChain(cfg ChainConfig) gin.HandlersChain {
+	mws := []gin.HandlerFunc{Recovery()}
+	if !cfg.DisableLogger {
+		mws = append(mws, gin.Logger())
+	}
+	if len(cfg.AllowedOrigins) > 0 {
+		mws = append(mws, CORS(cfg.AllowedOrigins))
+	}
+	mws = append(mws, Correlation())
+	if !cfg.DisableDecompress {
+		maxBytes := cfg.MaxDecompressedBytes
+		if maxBytes <= 0 {
+			maxBytes = DefaultMaxDecompressedBytes
+		}
+		mws = append(mws, DecompressRequest(maxBytes))
+	}
+	return Chain(append(mws, cfg.Extra...)...)
+}
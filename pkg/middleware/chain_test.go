@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestChain はミドルウェアの列の組み立てを検証する。
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	record := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			order = append(order, name)
+			c.Next()
+		}
+	}
+
+	router := gin.New()
+	router.Use(Chain(record("first"), nil, record("second"), record("third"))...)
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got, want := strings.Join(order, ","), "first,second,third"; got != want {
+		t.Errorf("実行順 = %s, want %s", got, want)
+	}
+}
+
+// TestDefaultChain は推奨ミドルウェアの構成と適用順序を検証する。
+func TestDefaultChain(t *testing.T) {
+	t.Parallel()
+
+	const origin = "http://localhost:3000"
+
+	t.Run("構成に応じてミドルウェアを追加・除外すること", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name string
+			cfg  ChainConfig
+			want int
+		}{
+			{name: "ゼロ値はRecovery・Logger・Correlation・DecompressRequestの4つ", cfg: ChainConfig{}, want: 4},
+			{name: "オリジンを指定するとCORSを追加する", cfg: ChainConfig{AllowedOrigins: []string{origin}}, want: 5},
+			{name: "LoggerとDecompressRequestを除外してもRecoveryとCorrelationは残る", cfg: ChainConfig{DisableLogger: true, DisableDecompress: true}, want: 2},
+			{name: "Extraは末尾に追加しnilは取り除く", cfg: ChainConfig{DisableLogger: true, Extra: []gin.HandlerFunc{nil, func(c *gin.Context) {}}}, want: 4},
+		}
+		for _, tt := range tests {
+			if got := len(DefaultChain(tt.cfg)); got != tt.want {
+				t.Errorf("%s: ミドルウェアの数 = %d, want %d", tt.name, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("Extraのパニックを先頭のRecoveryが捕捉すること", func(t *testing.T) {
+		t.Parallel()
+
+		router := gin.New()
+		router.Use(DefaultChain(ChainConfig{
+			DisableLogger: true,
+			Extra: []gin.HandlerFunc{func(c *gin.Context) {
+				panic("サービス固有のミドルウェアでパニック")
+			}},
+		})...)
+		router.GET("/", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("ステータスコード = %d, want %d", w.Code, http.StatusInternalServerError)
+		}
+	})
+
+	t.Run("展開に失敗したレスポンスにもCORSヘッダーを付けること", func(t *testing.T) {
+		t.Parallel()
+
+		router := gin.New()
+		router.Use(DefaultChain(ChainConfig{DisableLogger: true, AllowedOrigins: []string{origin}})...)
+		router.POST("/", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("data"))
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Content-Encoding", "br")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("ステータスコード = %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != origin {
+			t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, origin)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// maxTraceIDLength はX-Correlation-IDとX-Causation-IDとして受け付ける値の最大長。
+// 長すぎる値や制御文字を含む値は、ログやイベントのメタデータに記録しないよう破棄する。
+const maxTraceIDLength = 128
+
+// Correlation はX-Correlation-IDとX-Causation-IDヘッダーをリクエストのコンテキストに設定するGinミドルウェアを返す。
+// X-Correlation-IDがない場合は新しいIDを生成し、レスポンスのX-Correlation-IDヘッダーでも返す。
+// ハンドラがc.Request.Context()をhttpclientに渡すと、後続のサービス間リクエストに同じヘッダーが伝播し、
+// Event Storeはその値をイベントのmetadataに記録する。
+func Correlation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		correlationID := c.GetHeader(httpclient.CorrelationIDHeader)
+		if !validTraceID(correlationID) {
+			correlationID = uuid.New().String()
+		}
+		ctx := httpclient.WithCorrelationID(c.Request.Context(), correlationID)
+		if causationID := c.GetHeader(httpclient.CausationIDHeader); validTraceID(causationID) {
+			ctx = httpclient.WithCausationID(ctx, causationID)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(httpclient.CorrelationIDHeader, correlationID)
+		c.Next()
+	}
+}
+
+// validTraceID は追跡用のIDとして記録できる値かどうかを判定する。空、長すぎる、または表示可能なASCII以外を含む値は無効とする。
+func validTraceID(id string) bool {
+	if id == "" || len(id) > maxTraceIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// TestCorrelation はX-Correlation-IDとX-Causation-IDのコンテキストへの設定を検証する。
+func TestCorrelation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		correlationID   string
+		causationID     string
+		wantCorrelation string
+		wantCausation   string
+		wantGenerated   bool
+	}{
+		{
+			name:            "受信したヘッダーをコンテキストに設定すること",
+			correlationID:   "correlation-1",
+			causationID:     "event-1",
+			wantCorrelation: "correlation-1",
+			wantCausation:   "event-1",
+		},
+		{
+			name:          "X-Correlation-IDがない場合は新しいIDを生成すること",
+			wantGenerated: true,
+		},
+		{
+			name:          "長すぎるX-Correlation-IDは破棄して新しいIDを生成すること",
+			correlationID: strings.Repeat("a", maxTraceIDLength+1),
+			wantGenerated: true,
+		},
+		{
+			name:            "制御文字を含むX-Causation-IDは破棄すること",
+			correlationID:   "correlation-1",
+			causationID:     "event-1\tinjected",
+			wantCorrelation: "correlation-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotCorrelation, gotCausation string
+			router := gin.New()
+			router.Use(Correlation())
+			router.GET("/", func(c *gin.Context) {
+				gotCorrelation = httpclient.CorrelationID(c.Request.Context())
+				gotCausation = httpclient.CausationID(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.correlationID != "" {
+				req.Header.Set(httpclient.CorrelationIDHeader, tt.correlationID)
+			}
+			if tt.causationID != "" {
+				req.Header.Set(httpclient.CausationIDHeader, tt.causationID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if tt.wantGenerated {
+				if gotCorrelation == "" || gotCorrelation == tt.correlationID {
+					t.Errorf("Correlation ID = %q, want 新しく生成したID", gotCorrelation)
+				}
+			} else if gotCorrelation != tt.wantCorrelation {
+				t.Errorf("Correlation ID = %q, want %q", gotCorrelation, tt.wantCorrelation)
+			}
+			if gotCausation != tt.wantCausation {
+				t.Errorf("Causation ID = %q, want %q", gotCausation, tt.wantCausation)
+			}
+			if got := w.Header().Get(httpclient.CorrelationIDHeader); got != gotCorrelation {
+				t.Errorf("レスポンスの%s = %q, want %q", httpclient.CorrelationIDHeader, got, gotCorrelation)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/httpclient"
 )
 
 // CORS は指定されたオリジンからのクロスオリジンリクエストを許可するGinミドルウェアを返す。
@@ -19,7 +20,9 @@ func CORS(allowedOrigins []string) gin.HandlerFunc {
 		if _, ok := originsSet[origin]; ok {
 			c.Header("Access-Control-Allow-Origin", origin)
 			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, "+httpclient.CorrelationIDHeader)
+			// トークンの有効期限の通知とリクエストのCorrelation IDをフロントエンドから参照できるようにする
+			c.Header("Access-Control-Expose-Headers", HeaderTokenExpiring+", "+HeaderTokenExpiresAt+", "+httpclient.CorrelationIDHeader)
 			c.Header("Access-Control-Max-Age", "86400")
 		}
 
@@ -36,12 +36,15 @@ func TestCORS(t *testing.T) {
 		if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, PUT, DELETE, OPTIONS" {
 			t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, "GET, POST, PUT, DELETE, OPTIONS")
 		}
-		if got := w.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
-			t.Errorf("Access-Control-Allow-Headers = %q, want %q", got, "Authorization, Content-Type")
+		if got := w.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type, X-Correlation-ID" {
+			t.Errorf("Access-Control-Allow-Headers = %q, want %q", got, "Authorization, Content-Type, X-Correlation-ID")
 		}
 		if got := w.Header().Get("Access-Control-Max-Age"); got != "86400" {
 			t.Errorf("Access-Control-Max-Age = %q, want %q", got, "86400")
 		}
+		if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Token-Expiring, X-Token-Expires-At, X-Correlation-ID" {
+			t.Errorf("Access-Control-Expose-Headers = %q, want %q", got, "X-Token-Expiring, X-Token-Expires-At, X-Correlation-ID")
+		}
 	})
 
 	t.Run("許可リストの2番目のオリジンでも正しくCORSヘッダーが設定されること", func(t *testing.T) {
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxDecompressedBytes は展開後のリクエストボディの既定の上限（64MB）。
+// media-commandのアップロード上限（50MB）にマルチパートのオーバーヘッドを加えても収まる大きさとする。
+const DefaultMaxDecompressedBytes int64 = 64 << 20
+
+// DecompressRequest はContent-Encoding: gzipのリクエストボディを展開するGinミドルウェアを返す。
+// 低速な回線のクライアントが大きなJSONを圧縮して送信できるようにする。
+// 展開後のボディはContent-Encodingを除いた通常のリクエストとして後続のハンドラに渡すため、
+// ハンドラはボディのバインドを変更せずに済む。
+// 圧縮率の高いデータ（zip bomb）によるメモリ枯渇を防ぐため、展開後のサイズがmaxBytesを超える場合は413を返す。
+// gzipとして不正なボディは400、gzip以外のContent-Encodingは415を返す。
+func DecompressRequest(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+		switch encoding {
+		case "", "identity":
+			c.Next()
+			return
+		case "gzip", "x-gzip":
+		default:
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+				"error": fmt.Sprintf("サポートしていないContent-Encodingです: %s（gzipのみ）", encoding),
+			})
+			return
+		}
+
+		zr, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "gzipの展開に失敗しました"})
+			return
+		}
+		defer zr.Close()
+
+		// 上限を1バイトでも超えたことを検出できるよう、maxBytes+1バイトまで展開する。
+		body, err := io.ReadAll(io.LimitReader(zr, maxBytes+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "gzipの展開に失敗しました"})
+			return
+		}
+		if int64(len(body)) > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("展開後のリクエストボディが上限（%dバイト）を超えています", maxBytes),
+			})
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Next()
+	}
+}
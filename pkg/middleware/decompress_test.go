@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipBytes はテスト用にデータをgzipで圧縮する。
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzipの圧縮に失敗: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzipの圧縮に失敗: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestDecompressRequest はDecompressRequestミドルウェアを検証する。
+func TestDecompressRequest(t *testing.T) {
+	t.Parallel()
+
+	newRouter := func(maxBytes int64) *gin.Engine {
+		router := gin.New()
+		router.Use(DecompressRequest(maxBytes))
+		router.POST("/echo", func(c *gin.Context) {
+			var req struct {
+				Name string `json:"name"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"name": req.Name, "encoding": c.GetHeader("Content-Encoding")})
+		})
+		return router
+	}
+
+	tests := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		maxBytes   int64
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "gzipで圧縮したJSONが展開されてバインドされること",
+			encoding:   "gzip",
+			body:       gzipBytes(t, []byte(`{"name":"圧縮"}`)),
+			maxBytes:   1024,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"encoding":"","name":"圧縮"}`,
+		},
+		{
+			name:       "Content-Encodingがない場合はそのまま渡されること",
+			body:       []byte(`{"name":"plain"}`),
+			maxBytes:   1024,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"encoding":"","name":"plain"}`,
+		},
+		{
+			name:       "展開後のサイズが上限を超える場合413が返ること",
+			encoding:   "gzip",
+			body:       gzipBytes(t, bytes.Repeat([]byte("0"), 1<<20)),
+			maxBytes:   1024,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "gzipとして不正なボディの場合400が返ること",
+			encoding:   "gzip",
+			body:       []byte(`{"name":"not gzip"}`),
+			maxBytes:   1024,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "gzip以外のContent-Encodingの場合415が返ること",
+			encoding:   "br",
+			body:       []byte(`{"name":"brotli"}`),
+			maxBytes:   1024,
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+			newRouter(tt.maxBytes).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("ステータスコード = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -1,5 +1,9 @@
 // Package middleware はGinベースのHTTP APIで使用する共通ミドルウェアを提供する。
 //
 // JWT認証トークンの検証、リクエストログ、パニックリカバリ、
-// CORS設定など、全サービスで共通して使用するミドルウェアを含む。
+// CORS設定、特定パスでのミドルウェアのスキップ、データベースの接続を確認するヘルスチェックなど、
+// 全サービスで共通して使用するミドルウェアを含む。
+//
+// 各サービスはDefaultChainが返す推奨ミドルウェアの列を `router.Use(middleware.DefaultChain(cfg)...)` で適用し、
+// 全サービスで同じ順序（Recovery → Logger → CORS → Correlation → DecompressRequest → サービス固有のミドルウェア）を保つ。
 package middleware
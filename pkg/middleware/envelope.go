@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// EnvelopeQueryParam は一覧系APIでエンベロープ形式のレスポンスを要求するクエリパラメータ（envelope=true）。
+	EnvelopeQueryParam = "envelope"
+	// EnvelopeMediaType は一覧系APIでエンベロープ形式のレスポンスを要求するAcceptヘッダーのメディアタイプ。
+	EnvelopeMediaType = "application/vnd.micro.envelope+json"
+)
+
+// Envelope は一覧系APIの共通のレスポンス形式。
+// 一覧の要素をdataに、件数をcountに格納し、ページングする一覧は全件数や次ページの位置を付与する。
+type Envelope struct {
+	// Data は一覧の要素。
+	Data any `json:"data"`
+	// Count はdataの要素数。
+	Count int `json:"count"`
+	// Total は条件に一致する全件数。ページングしない一覧では省略する。
+	Total *int64 `json:"total,omitempty"`
+	// NextOffset は次ページのoffset。offsetでページングしない一覧や最終ページでは省略する。
+	NextOffset *int `json:"next_offset,omitempty"`
+	// NextCursor は次ページのcursor。cursorでページングしない一覧や最終ページでは省略する。
+	NextCursor *string `json:"next_cursor,omitempty"`
+}
+
+// WantsEnvelope はクライアントが一覧をエンベロープ形式で要求したかを返す。
+// クエリパラメータenvelopeに真の値（true、1など）を指定するか、
+// AcceptヘッダーにEnvelopeMediaTypeを含めた場合にエンベロープ形式とする。
+func WantsEnvelope(c *gin.Context) bool {
+	if v := c.Query(EnvelopeQueryParam); v != "" {
+		want, err := strconv.ParseBool(v)
+		return err == nil && want
+	}
+	for accept := range strings.SplitSeq(c.GetHeader("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accept); err == nil && mediaType == EnvelopeMediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// RespondList は一覧系APIのレスポンスをRespondと同様にAcceptヘッダーに応じた形式で返す。
+// クライアントがエンベロープ形式を要求した場合（WantsEnvelope）はenvelopeを、
+// それ以外は既存のクライアントを壊さないよう従来の形式legacyを返す。
+func RespondList(c *gin.Context, status int, legacy any, envelope Envelope) {
+	if WantsEnvelope(c) {
+		Respond(c, status, envelope)
+		return
+	}
+	Respond(c, status, legacy)
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRespondList は一覧のレスポンス形式（従来の形式・エンベロープ形式）の切り替えを検証する。
+func TestRespondList(t *testing.T) {
+	t.Parallel()
+
+	items := []string{"a", "b"}
+	next := 2
+	total := int64(5)
+	router := gin.New()
+	router.GET("/items", func(c *gin.Context) {
+		RespondList(c, http.StatusOK, items, Envelope{Data: items, Count: len(items), Total: &total, NextOffset: &next})
+	})
+
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		want   string
+	}{
+		{name: "指定しない場合は従来の形式で返すこと", want: `["a","b"]`},
+		{name: "envelope=trueを指定した場合はエンベロープ形式で返すこと", query: "?envelope=true", want: `{"data":["a","b"],"count":2,"total":5,"next_offset":2}`},
+		{name: "envelope=1を指定した場合はエンベロープ形式で返すこと", query: "?envelope=1", want: `{"data":["a","b"],"count":2,"total":5,"next_offset":2}`},
+		{name: "envelope=falseを指定した場合は従来の形式で返すこと", query: "?envelope=false", want: `["a","b"]`},
+		{name: "不正なenvelopeは従来の形式で返すこと", query: "?envelope=yes", want: `["a","b"]`},
+		{name: "Acceptでエンベロープ形式を要求できること", accept: "application/json;q=0.5, " + EnvelopeMediaType, want: `{"data":["a","b"],"count":2,"total":5,"next_offset":2}`},
+		{name: "クエリパラメータをAcceptより優先すること", query: "?envelope=false", accept: EnvelopeMediaType, want: `["a","b"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/items"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("ステータスコード = %d, want %d", w.Code, http.StatusOK)
+			}
+			var got, want any
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("レスポンスのデシリアライズに失敗: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("期待値のデシリアライズに失敗: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("レスポンス = %s, want %s", w.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+// TestEnvelopeOmitsPagination はページングしない一覧のエンベロープでtotalとnext_offsetを省略することを検証する。
+func TestEnvelopeOmitsPagination(t *testing.T) {
+	t.Parallel()
+
+	got, err := json.Marshal(Envelope{Data: []string{}, Count: 0})
+	if err != nil {
+		t.Fatalf("JSON変換に失敗: %v", err)
+	}
+	if want := `{"data":[],"count":0}`; string(got) != want {
+		t.Errorf("Envelope = %s, want %s", got, want)
+	}
+}
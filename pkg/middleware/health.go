@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthPingTimeout はヘルスチェックでデータベースの応答を待つ時間。
+// readiness probeのタイムアウトより短くし、応答しないデータベースでヘルスチェック自体が詰まらないようにする。
+const healthPingTimeout = 2 * time.Second
+
+// HealthCheck はサービスのヘルスチェックを処理するハンドラを返す。
+// dbにPingを送り、応答があれば200と{"status":"ok"}、応答がなければ503と
+// {"status":"degraded","db":"unreachable"}を返す。Kubernetesのreadiness probeで
+// データベースに接続できないインスタンスをトラフィックの振り分け先から外せるようにする。
+func HealthCheck(service string, db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthPingTimeout)
+		defer cancel()
+
+		if err := db.PingContext(ctx); err != nil {
+			log.Printf("ヘルスチェック: データベースに接続できません: service=%s, error=%v", service, err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "degraded", "db": "unreachable", "service": service})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": service})
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "modernc.org/sqlite"
+)
+
+// TestHealthCheck はデータベースの接続状態に応じたヘルスチェックの応答を検証する。
+func TestHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		closeDB    bool
+		wantStatus int
+		wantBody   map[string]string
+	}{
+		{
+			name:       "データベースに接続できる場合は200を返すこと",
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]string{"status": "ok", "service": "album"},
+		},
+		{
+			name:       "データベースに接続できない場合は503を返すこと",
+			closeDB:    true,
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   map[string]string{"status": "degraded", "db": "unreachable", "service": "album"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := sql.Open("sqlite", ":memory:")
+			if err != nil {
+				t.Fatalf("インメモリSQLiteの接続に失敗: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			if tt.closeDB {
+				db.Close()
+			}
+
+			router := gin.New()
+			router.GET("/health", HealthCheck("album", db))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("ステータスコード = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var got map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("レスポンスのJSONデコードに失敗: %v", err)
+			}
+			if len(got) != len(tt.wantBody) {
+				t.Errorf("レスポンス = %v, want %v", got, tt.wantBody)
+			}
+			for k, v := range tt.wantBody {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+const (
+	// defaultSignatureWindow は署名の日時と受信日時の差として許容する既定の範囲。
+	defaultSignatureWindow = 5 * time.Minute
+	// maxSignedBodyBytes は署名を検証するために読み込むボディの上限（1MB）。内部APIのJSONボディはこれより十分小さい。
+	maxSignedBodyBytes = 1 << 20
+)
+
+// contextKeySignatureVerified はサービス間リクエストの署名を検証済みであることをGinコンテキストに記録するためのキー。
+const contextKeySignatureVerified = "internal_signature_verified"
+
+// InternalSignatureOption はVerifyInternalSignatureの検証内容を設定するオプション。
+type InternalSignatureOption func(*internalSignatureConfig)
+
+// internalSignatureConfig はVerifyInternalSignatureの検証設定。
+type internalSignatureConfig struct {
+	// window は署名の日時と受信日時の差として許容する範囲。
+	window time.Duration
+}
+
+// WithSignatureWindow は署名の日時と受信日時の差として許容する範囲を設定する。
+// 送信元と受信側の時計のずれを吸収できる範囲で、できるだけ短くする。
+func WithSignatureWindow(window time.Duration) InternalSignatureOption {
+	return func(cfg *internalSignatureConfig) {
+		cfg.window = window
+	}
+}
+
+// nonceCache は検証済みのノンスを有効期限まで記録し、同じノンスの再利用を検出する。
+type nonceCache struct {
+	// mu はseenへの並行アクセスを保護するミューテックス。
+	mu sync.Mutex
+	// seen はノンスごとの記録の有効期限。
+	seen map[string]time.Time
+}
+
+// add はノンスをexpiresまで記録する。既に記録済みの場合はfalseを返す。
+// 有効期限を過ぎた記録は追加のたびに削除する。
+func (n *nonceCache) add(nonce string, now, expires time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for k, exp := range n.seen {
+		if now.After(exp) {
+			delete(n.seen, k)
+		}
+	}
+	if _, ok := n.seen[nonce]; ok {
+		return false
+	}
+	n.seen[nonce] = expires
+	return true
+}
+
+// VerifyInternalSignature はhttpclient.WithSigningKeyで署名されたサービス間リクエストを検証するGinミドルウェアを返す。
+// 署名がない・一致しない（メソッド・パス・ボディの改ざん）、署名の日時が許容範囲（既定5分）を外れている、
+// または同じノンスのリクエストを既に受け付けている（キャプチャしたリクエストの再送）場合は401を返す。
+// ノンスは許容範囲の間だけインスタンスのメモリに記録する。
+// keyが空の場合は検証せずに通過させる（環境変数INTERNAL_SIGNING_KEYを設定するまでの移行用）。
+// 検証せずに通過させたリクエストはInternalSignatureVerifiedがfalseを返す。
+func VerifyInternalSignature(key string, opts ...InternalSignatureOption) gin.HandlerFunc {
+	if key == "" {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	cfg := &internalSignatureConfig{window: defaultSignatureWindow}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	nonces := &nonceCache{seen: make(map[string]time.Time)}
+
+	return func(c *gin.Context) {
+		signature := c.GetHeader(httpclient.SignatureHeader)
+		nonce := c.GetHeader(httpclient.NonceHeader)
+		timestamp, err := strconv.ParseInt(c.GetHeader(httpclient.TimestampHeader), 10, 64)
+		if signature == "" || nonce == "" || err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "サービス間リクエストの署名が必要です"})
+			return
+		}
+
+		now := time.Now()
+		signedAt := time.Unix(timestamp, 0)
+		if signedAt.Before(now.Add(-cfg.window)) || signedAt.After(now.Add(cfg.window)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "サービス間リクエストの署名の有効期限が切れています"})
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSignedBodyBytes+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "リクエストボディの読み込みに失敗しました"})
+			return
+		}
+		if len(body) > maxSignedBodyBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "リクエストボディが大きすぎます"})
+			return
+		}
+		// 後続のハンドラがボディを読めるよう、読み込んだ内容で差し替える
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		want := httpclient.Sign([]byte(key), c.Request.Method, c.Request.URL.RequestURI(), timestamp, nonce, body)
+		if !hmac.Equal([]byte(signature), []byte(want)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "サービス間リクエストの署名が一致しません"})
+			return
+		}
+
+		// 許容範囲を過ぎたリクエストは日時の検証で拒否されるため、ノンスはその時点まで記録すれば十分
+		if !nonces.add(nonce, now, signedAt.Add(cfg.window)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "再送されたサービス間リクエストです"})
+			return
+		}
+		c.Set(contextKeySignatureVerified, true)
+
+		c.Next()
+	}
+}
+
+// InternalSignatureVerified はVerifyInternalSignatureがリクエストの署名を検証済みかを返す。
+// 鍵が空で検証を省略した場合はfalseを返すため、X-User-IDヘッダーのような送信元が指定する値を信頼してよいかの判定に使う。
+func InternalSignatureVerified(c *gin.Context) bool {
+	return c.GetBool(contextKeySignatureVerified)
+}
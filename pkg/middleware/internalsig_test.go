@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nao1215/micro/pkg/httpclient"
+)
+
+// TestVerifyInternalSignature はサービス間リクエストの署名の検証を検証する。
+func TestVerifyInternalSignature(t *testing.T) {
+	t.Parallel()
+
+	const key = "internal-signing-key"
+
+	// newSignedRouter は署名を検証して受信したボディを返すルーターを作成する。
+	newSignedRouter := func(key string) *gin.Engine {
+		router := gin.New()
+		router.POST("/api/v1/internal/albums/:id/media", VerifyInternalSignature(key), func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			c.Header("X-Signature-Verified", strconv.FormatBool(InternalSignatureVerified(c)))
+			c.Data(http.StatusOK, "application/json", body)
+		})
+		return router
+	}
+
+	// signedRequest はtimestampとnonceでbodyに署名したリクエストを作成する。
+	signedRequest := func(body string, timestamp int64, nonce string) *http.Request {
+		const path = "/api/v1/internal/albums/album-1/media"
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(httpclient.TimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(httpclient.NonceHeader, nonce)
+		req.Header.Set(httpclient.SignatureHeader, httpclient.Sign([]byte(key), http.MethodPost, path, timestamp, nonce, []byte(body)))
+		return req
+	}
+
+	t.Run("httpclientで署名したリクエストを受け付けること", func(t *testing.T) {
+		t.Parallel()
+
+		ts := httptest.NewServer(newSignedRouter(key))
+		t.Cleanup(ts.Close)
+
+		client := httpclient.New(ts.URL, httpclient.WithSigningKey(key))
+		var got map[string]string
+		if err := client.PostJSON(context.Background(), "/api/v1/internal/albums/album-1/media", map[string]string{"media_id": "media-1"}, &got); err != nil {
+			t.Fatalf("署名したリクエストが拒否された: %v", err)
+		}
+		if got["media_id"] != "media-1" {
+			t.Errorf("後続のハンドラが受け取ったボディ = %v, want media_id=media-1", got)
+		}
+	})
+
+	t.Run("署名のないリクエストは401を返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		ts := httptest.NewServer(newSignedRouter(key))
+		t.Cleanup(ts.Close)
+
+		err := httpclient.New(ts.URL).PostJSON(context.Background(), "/api/v1/internal/albums/album-1/media", map[string]string{"media_id": "media-1"}, nil)
+		if err == nil || !strings.Contains(err.Error(), "status=401") {
+			t.Errorf("エラー = %v, want status=401", err)
+		}
+	})
+
+	t.Run("別の鍵で署名したリクエストは401を返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		ts := httptest.NewServer(newSignedRouter(key))
+		t.Cleanup(ts.Close)
+
+		client := httpclient.New(ts.URL, httpclient.WithSigningKey("another-key"))
+		err := client.PostJSON(context.Background(), "/api/v1/internal/albums/album-1/media", map[string]string{"media_id": "media-1"}, nil)
+		if err == nil || !strings.Contains(err.Error(), "status=401") {
+			t.Errorf("エラー = %v, want status=401", err)
+		}
+	})
+
+	t.Run("署名後にボディを改ざんしたリクエストは401を返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		req := signedRequest(`{"media_id":"media-1"}`, time.Now().Unix(), "nonce-tampered")
+		req.Body = io.NopCloser(strings.NewReader(`{"media_id":"media-2"}`))
+		w := httptest.NewRecorder()
+		newSignedRouter(key).ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("ステータスコード = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("日時が許容範囲を外れたリクエストは401を返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		router := newSignedRouter(key)
+		for _, signedAt := range []time.Time{
+			time.Now().Add(-defaultSignatureWindow - time.Minute),
+			time.Now().Add(defaultSignatureWindow + time.Minute),
+		} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, signedRequest(`{"media_id":"media-1"}`, signedAt.Unix(), "nonce-stale-"+signedAt.String()))
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("%v: ステータスコード = %d, want %d", signedAt, w.Code, http.StatusUnauthorized)
+			}
+		}
+	})
+
+	t.Run("受け付け済みのリクエストを再送した場合は401を返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		router := newSignedRouter(key)
+		timestamp := time.Now().Unix()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, signedRequest(`{"media_id":"media-1"}`, timestamp, "nonce-replay"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("初回のステータスコード = %d, want %d", w.Code, http.StatusOK)
+		}
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, signedRequest(`{"media_id":"media-1"}`, timestamp, "nonce-replay"))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("再送のステータスコード = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("鍵が空の場合は検証しないこと", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/internal/albums/album-1/media", strings.NewReader(`{}`))
+		w := httptest.NewRecorder()
+		newSignedRouter("").ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("ステータスコード = %d, want %d", w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get("X-Signature-Verified"); got != "false" {
+			t.Errorf("InternalSignatureVerified = %s, want false", got)
+		}
+	})
+
+	t.Run("署名を検証したリクエストはInternalSignatureVerifiedがtrueを返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		w := httptest.NewRecorder()
+		newSignedRouter(key).ServeHTTP(w, signedRequest(`{}`, time.Now().Unix(), "nonce-verified"))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード = %d, want %d", w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get("X-Signature-Verified"); got != "true" {
+			t.Errorf("InternalSignatureVerified = %s, want true", got)
+		}
+	})
+}
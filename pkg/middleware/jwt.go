@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -8,10 +10,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // JWTClaims はJWTトークンのクレーム（ペイロード）を表す。
 // ユーザーID等の情報をサービス間で伝播するために使用する。
+// RegisteredClaimsのID（jti）はトークンごとに一意で、トークンの失効に使用する。
 type JWTClaims struct {
 	jwt.RegisteredClaims
 	// UserID は認証済みユーザーの一意識別子。
@@ -23,6 +27,16 @@ type JWTClaims struct {
 // headerKeyUserID はサービス間でユーザーIDを伝播するためのHTTPヘッダーキー。
 const headerKeyUserID = "X-User-ID"
 
+// contextKeyClaims は検証済みのJWTClaimsをGinコンテキストに格納するためのキー。
+const contextKeyClaims = "jwt_claims"
+
+const (
+	// Issuer はGenerateJWTが発行するトークンのissuer（iss）クレーム。
+	Issuer = "mediahub-gateway"
+	// Audience はGenerateJWTが発行するトークンのaudience（aud）クレーム。
+	Audience = "mediahub-api"
+)
+
 // GenerateJWT はユーザー情報からJWTトークンを生成する。
 // gatewayサービスがOAuth2認証後に呼び出す。
 func GenerateJWT(secret, userID, email string) (string, error) {
@@ -30,7 +44,9 @@ func GenerateJWT(secret, userID, email string) (string, error) {
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "mediahub-gateway",
+			Issuer:    Issuer,
+			Audience:  jwt.ClaimStrings{Audience},
+			ID:        uuid.New().String(),
 		},
 		UserID: userID,
 		Email:  email,
@@ -44,9 +60,101 @@ func GenerateJWT(secret, userID, email string) (string, error) {
 	return signed, nil
 }
 
+// JWTAuthOption はJWTAuthの検証内容を設定するオプション。
+type JWTAuthOption func(*jwtAuthConfig)
+
+// jwtAuthConfig はJWTAuthの検証設定。
+type jwtAuthConfig struct {
+	// issuer は期待するissuer。空の場合は検証しない。
+	issuer string
+	// audience は期待するaudience。空の場合は検証しない。
+	audience string
+	// previousSecrets はローテーション前の秘密鍵。検証にのみ使用し、署名には使用しない。
+	previousSecrets []string
+	// revocations は失効済みトークンの確認先。nilの場合は失効を確認しない。
+	revocations RevocationChecker
+}
+
+// RevocationChecker はトークンが失効済みかを確認する。
+type RevocationChecker interface {
+	// IsRevoked はトークンID（jti）が失効済みの場合にtrueを返す。
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// WithIssuer はトークンのissuer（iss）クレームが指定値と一致することを検証する。
+func WithIssuer(issuer string) JWTAuthOption {
+	return func(cfg *jwtAuthConfig) {
+		cfg.issuer = issuer
+	}
+}
+
+// WithAudience はトークンのaudience（aud）クレームに指定値が含まれることを検証する。
+func WithAudience(audience string) JWTAuthOption {
+	return func(cfg *jwtAuthConfig) {
+		cfg.audience = audience
+	}
+}
+
+// WithPreviousSecrets はローテーション前の秘密鍵で署名されたトークンも検証に通す。
+// 秘密鍵を切り替えた直後も発行済みトークンが有効期限まで使えるよう、新しい秘密鍵と併用する。
+// 空文字列は無視する。
+func WithPreviousSecrets(secrets ...string) JWTAuthOption {
+	return func(cfg *jwtAuthConfig) {
+		for _, secret := range secrets {
+			if secret != "" {
+				cfg.previousSecrets = append(cfg.previousSecrets, secret)
+			}
+		}
+	}
+}
+
+// WithRevocationChecker はトークンID（jti）が失効済みのトークンを拒否する。
+// jtiを持たない（jtiの導入前に発行された）トークンは失効を確認しない。
+func WithRevocationChecker(checker RevocationChecker) JWTAuthOption {
+	return func(cfg *jwtAuthConfig) {
+		cfg.revocations = checker
+	}
+}
+
+// SplitSecrets はカンマ区切りの秘密鍵の一覧（環境変数JWT_SECRET_PREVIOUSの値）を分割する。
+// 前後の空白を除き、空の要素は含めない。
+func SplitSecrets(v string) []string {
+	var secrets []string
+	for _, secret := range strings.Split(v, ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
 // JWTAuth はJWTトークンを検証するGinミドルウェアを返す。
-// 検証に成功した場合、コンテキストに "user_id" と "email" を設定する。
-func JWTAuth(secret string) gin.HandlerFunc {
+// 検証に成功した場合、コンテキストに "user_id" と "email"、検証済みのクレームを設定する。
+// オプションを省略した場合は署名と有効期限のみを検証し、issuerとaudienceは検証しない。
+// WithPreviousSecretsを指定した場合、secretの次にローテーション前の秘密鍵を順に試す。
+func JWTAuth(secret string, opts ...JWTAuthOption) gin.HandlerFunc {
+	cfg := &jwtAuthConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var verificationKey any = []byte(secret)
+	if len(cfg.previousSecrets) > 0 {
+		keys := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{[]byte(secret)}}
+		for _, previous := range cfg.previousSecrets {
+			keys.Keys = append(keys.Keys, []byte(previous))
+		}
+		verificationKey = keys
+	}
+
+	var parserOpts []jwt.ParserOption
+	if cfg.issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(cfg.issuer))
+	}
+	if cfg.audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(cfg.audience))
+	}
+
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -66,8 +174,22 @@ func JWTAuth(secret string) gin.HandlerFunc {
 
 		claims := &JWTClaims{}
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(_ *jwt.Token) (any, error) {
-			return []byte(secret), nil
-		})
+			return verificationKey, nil
+		}, parserOpts...)
+		// クレームが欠落している場合も不一致として扱う
+		missing := errors.Is(err, jwt.ErrTokenRequiredClaimMissing)
+		if errors.Is(err, jwt.ErrTokenInvalidIssuer) || (missing && cfg.issuer != "" && claims.Issuer == "") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "トークンの発行者が不正です",
+			})
+			return
+		}
+		if errors.Is(err, jwt.ErrTokenInvalidAudience) || (missing && cfg.audience != "" && len(claims.Audience) == 0) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "トークンの利用対象が不正です",
+			})
+			return
+		}
 		if err != nil || !token.Valid {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "トークンが無効です",
@@ -75,8 +197,25 @@ func JWTAuth(secret string) gin.HandlerFunc {
 			return
 		}
 
+		if cfg.revocations != nil && claims.ID != "" {
+			revoked, err := cfg.revocations.IsRevoked(c.Request.Context(), claims.ID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"error": "トークンの失効状態を確認できませんでした",
+				})
+				return
+			}
+			if revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"error": "トークンは失効しています",
+				})
+				return
+			}
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
+		c.Set(contextKeyClaims, claims)
 		c.Header(headerKeyUserID, claims.UserID)
 		c.Next()
 	}
@@ -91,3 +230,13 @@ func GetUserID(c *gin.Context) string {
 	}
 	return ""
 }
+
+// GetClaims はGinコンテキストから検証済みのJWTClaimsを取得する。
+// JWTAuthミドルウェアが事前に適用されていない場合はnilを返す。
+func GetClaims(c *gin.Context) *JWTClaims {
+	claims, _ := c.Get(contextKeyClaims)
+	if cl, ok := claims.(*JWTClaims); ok {
+		return cl
+	}
+	return nil
+}
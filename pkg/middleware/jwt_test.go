@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -54,6 +55,9 @@ func TestGenerateJWT(t *testing.T) {
 		if claims.Issuer != "mediahub-gateway" {
 			t.Errorf("Issuer = %q, want %q", claims.Issuer, "mediahub-gateway")
 		}
+		if len(claims.Audience) != 1 || claims.Audience[0] != Audience {
+			t.Errorf("Audience = %v, want [%s]", claims.Audience, Audience)
+		}
 	})
 
 	t.Run("トークンの有効期限が24時間後であること", func(t *testing.T) {
@@ -366,6 +370,367 @@ func TestJWTAuth(t *testing.T) {
 	})
 }
 
+// signTestToken は指定したissuerとaudienceを持つテスト用トークンを署名する。
+func signTestToken(t *testing.T, issuer string, audience []string) string {
+	t.Helper()
+
+	claims := JWTClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    issuer,
+			Audience:  audience,
+		},
+		UserID: "user-claims",
+		Email:  "claims@example.com",
+	}
+
+	tokenStr, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("トークンの署名に失敗: %v", err)
+	}
+	return tokenStr
+}
+
+// TestJWTAuthIssuerAudience はJWTAuthのissuer/audience検証を検証する。
+func TestJWTAuthIssuerAudience(t *testing.T) {
+	t.Parallel()
+
+	strict := []JWTAuthOption{WithIssuer(Issuer), WithAudience(Audience)}
+
+	tests := []struct {
+		name      string
+		opts      []JWTAuthOption
+		issuer    string
+		audience  []string
+		wantCode  int
+		wantError string
+	}{
+		{
+			name:     "issuerとaudienceが一致する場合は成功すること",
+			opts:     strict,
+			issuer:   Issuer,
+			audience: []string{Audience},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "audienceが複数の場合も期待値を含めば成功すること",
+			opts:     strict,
+			issuer:   Issuer,
+			audience: []string{"other-api", Audience},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "issuerが異なる場合は発行者不正の401が返ること",
+			opts:      strict,
+			issuer:    "other-system",
+			audience:  []string{Audience},
+			wantCode:  http.StatusUnauthorized,
+			wantError: "トークンの発行者が不正です",
+		},
+		{
+			name:      "issuerが無い場合は発行者不正の401が返ること",
+			opts:      strict,
+			audience:  []string{Audience},
+			wantCode:  http.StatusUnauthorized,
+			wantError: "トークンの発行者が不正です",
+		},
+		{
+			name:      "audienceが異なる場合は利用対象不正の401が返ること",
+			opts:      strict,
+			issuer:    Issuer,
+			audience:  []string{"other-api"},
+			wantCode:  http.StatusUnauthorized,
+			wantError: "トークンの利用対象が不正です",
+		},
+		{
+			name:      "audienceが無い場合は利用対象不正の401が返ること",
+			opts:      strict,
+			issuer:    Issuer,
+			wantCode:  http.StatusUnauthorized,
+			wantError: "トークンの利用対象が不正です",
+		},
+		{
+			name:     "オプション省略時はissuerとaudienceを検証しないこと",
+			issuer:   "other-system",
+			audience: []string{"other-api"},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.Use(JWTAuth(testSecret, tt.opts...))
+			router.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+signTestToken(t, tt.issuer, tt.audience))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("ステータスコード = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantError == "" {
+				return
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("レスポンスボディのパースに失敗: %v", err)
+			}
+			if body["error"] != tt.wantError {
+				t.Errorf("error = %q, want %q", body["error"], tt.wantError)
+			}
+		})
+	}
+
+	t.Run("GenerateJWTのトークンは検証オプション付きで受け入れられること", func(t *testing.T) {
+		t.Parallel()
+
+		tokenStr, err := GenerateJWT(testSecret, "user-gen", "gen@example.com")
+		if err != nil {
+			t.Fatalf("GenerateJWT()でエラーが発生: %v", err)
+		}
+
+		router := gin.New()
+		router.Use(JWTAuth(testSecret, strict...))
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenStr)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("ステータスコード = %d, want %d", w.Code, http.StatusOK)
+		}
+	})
+}
+
+// TestJWTAuthSecretRotation はローテーション前の秘密鍵で署名されたトークンの検証を検証する。
+func TestJWTAuthSecretRotation(t *testing.T) {
+	t.Parallel()
+
+	const (
+		currentSecret  = "current-secret"
+		previousSecret = "previous-secret"
+		olderSecret    = "older-secret"
+	)
+
+	tests := []struct {
+		name       string
+		signSecret string
+		opts       []JWTAuthOption
+		wantCode   int
+	}{
+		{
+			name:       "現在の秘密鍵で署名したトークンは受け入れられること",
+			signSecret: currentSecret,
+			opts:       []JWTAuthOption{WithPreviousSecrets(previousSecret)},
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "ローテーション前の秘密鍵で署名したトークンも受け入れられること",
+			signSecret: previousSecret,
+			opts:       []JWTAuthOption{WithPreviousSecrets(previousSecret)},
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "複数世代前の秘密鍵も指定すれば受け入れられること",
+			signSecret: olderSecret,
+			opts:       []JWTAuthOption{WithPreviousSecrets(previousSecret, olderSecret)},
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "指定していない秘密鍵で署名したトークンは拒否されること",
+			signSecret: olderSecret,
+			opts:       []JWTAuthOption{WithPreviousSecrets(previousSecret)},
+			wantCode:   http.StatusUnauthorized,
+		},
+		{
+			name:       "オプション省略時はローテーション前の秘密鍵を受け入れないこと",
+			signSecret: previousSecret,
+			wantCode:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tokenStr, err := GenerateJWT(tt.signSecret, "user-rotation", "rotation@example.com")
+			if err != nil {
+				t.Fatalf("GenerateJWT()でエラーが発生: %v", err)
+			}
+
+			router := gin.New()
+			router.Use(JWTAuth(currentSecret, append(tt.opts, WithIssuer(Issuer), WithAudience(Audience))...))
+			router.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"user_id": GetUserID(c)})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tokenStr)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("ステータスコード = %d, want %d, body: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("新しいトークンは現在の秘密鍵で署名されること", func(t *testing.T) {
+		t.Parallel()
+
+		tokenStr, err := GenerateJWT(currentSecret, "user-new", "new@example.com")
+		if err != nil {
+			t.Fatalf("GenerateJWT()でエラーが発生: %v", err)
+		}
+
+		// 現在の秘密鍵のみで検証でき、ローテーション前の秘密鍵では検証できない
+		if _, err := jwt.ParseWithClaims(tokenStr, &JWTClaims{}, func(_ *jwt.Token) (any, error) {
+			return []byte(currentSecret), nil
+		}); err != nil {
+			t.Errorf("現在の秘密鍵で検証できない: %v", err)
+		}
+		if _, err := jwt.ParseWithClaims(tokenStr, &JWTClaims{}, func(_ *jwt.Token) (any, error) {
+			return []byte(previousSecret), nil
+		}); err == nil {
+			t.Error("ローテーション前の秘密鍵で検証できてしまう")
+		}
+	})
+}
+
+// TestSplitSecrets はSplitSecrets関数を検証する。
+func TestSplitSecrets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "", want: nil},
+		{input: "old-1", want: []string{"old-1"}},
+		{input: " old-1 , old-2 ,,", want: []string{"old-1", "old-2"}},
+	}
+
+	for _, tt := range tests {
+		got := SplitSecrets(tt.input)
+		if len(got) != len(tt.want) {
+			t.Errorf("SplitSecrets(%q) = %q, want %q", tt.input, got, tt.want)
+			continue
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("SplitSecrets(%q) = %q, want %q", tt.input, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// fakeRevocations はテスト用の失効済みトークン一覧。
+type fakeRevocations struct {
+	revoked map[string]bool
+	err     error
+}
+
+// IsRevoked はjtiが失効済みかを返す。
+func (f *fakeRevocations) IsRevoked(_ context.Context, jti string) (bool, error) {
+	return f.revoked[jti], f.err
+}
+
+// TestJWTAuthRevocation はWithRevocationCheckerによる失効済みトークンの拒否を検証する。
+func TestJWTAuthRevocation(t *testing.T) {
+	t.Parallel()
+
+	// jtiOf はトークンのjtiを取得する。
+	jtiOf := func(t *testing.T, tokenStr string) string {
+		t.Helper()
+		claims := &JWTClaims{}
+		if _, err := jwt.ParseWithClaims(tokenStr, claims, func(_ *jwt.Token) (any, error) {
+			return []byte(testSecret), nil
+		}); err != nil {
+			t.Fatalf("トークンのパースに失敗: %v", err)
+		}
+		return claims.ID
+	}
+
+	tokenStr, err := GenerateJWT(testSecret, "user-revoke", "revoke@example.com")
+	if err != nil {
+		t.Fatalf("GenerateJWT()でエラーが発生: %v", err)
+	}
+	otherToken, err := GenerateJWT(testSecret, "user-revoke", "revoke@example.com")
+	if err != nil {
+		t.Fatalf("GenerateJWT()でエラーが発生: %v", err)
+	}
+	if jtiOf(t, tokenStr) == "" || jtiOf(t, tokenStr) == jtiOf(t, otherToken) {
+		t.Fatal("トークンごとに一意のjtiが設定されていない")
+	}
+
+	tests := []struct {
+		name     string
+		checker  *fakeRevocations
+		token    string
+		wantCode int
+	}{
+		{
+			name:     "失効済みのトークンは401を返すこと",
+			checker:  &fakeRevocations{revoked: map[string]bool{jtiOf(t, tokenStr): true}},
+			token:    tokenStr,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "失効していないトークンは受け入れられること",
+			checker:  &fakeRevocations{revoked: map[string]bool{jtiOf(t, tokenStr): true}},
+			token:    otherToken,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "失効状態を確認できない場合は500を返すこと",
+			checker:  &fakeRevocations{err: context.DeadlineExceeded},
+			token:    tokenStr,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotJTI string
+			router := gin.New()
+			router.Use(JWTAuth(testSecret, WithRevocationChecker(tt.checker)))
+			router.GET("/test", func(c *gin.Context) {
+				gotJTI = GetClaims(c).ID
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("ステータスコード = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusOK && gotJTI != jtiOf(t, tt.token) {
+				t.Errorf("GetClaims().ID = %q, want %q", gotJTI, jtiOf(t, tt.token))
+			}
+		})
+	}
+}
+
 // TestGetUserID はGetUserID関数を検証する。
 func TestGetUserID(t *testing.T) {
 	t.Parallel()
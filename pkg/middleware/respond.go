@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+)
+
+// Respond はリクエストのAcceptヘッダーに応じた形式でobjをレスポンスとして返す。
+// JSONとMessagePack（application/msgpack、application/x-msgpack）に対応し、帯域やパース速度を重視するクライアントはMessagePackを選べる。
+// Acceptが無い場合や対応していない形式のみを指定した場合は、既存のクライアントを壊さないようJSONで返す。
+// 形式がAcceptで変わることをキャッシュに伝えるため、Vary: Acceptを付与する。
+func Respond(c *gin.Context, status int, obj any) {
+	c.Header("Vary", "Accept")
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEMSGPACK2, binding.MIMEMSGPACK) {
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		c.Render(status, render.MsgPack{Data: obj})
+	default:
+		c.JSON(status, obj)
+	}
+}
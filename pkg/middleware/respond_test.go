@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+)
+
+// TestRespond はAcceptヘッダーによるレスポンス形式の切り替えを検証する。
+func TestRespond(t *testing.T) {
+	t.Parallel()
+
+	type payload struct {
+		ID   string `json:"id"`
+		Size int64  `json:"size"`
+	}
+
+	router := gin.New()
+	router.GET("/media", func(c *gin.Context) {
+		Respond(c, http.StatusOK, payload{ID: "media-1", Size: 1024})
+	})
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+	}{
+		{name: "Acceptが無い場合はJSONで返すこと", accept: "", wantContentType: "application/json"},
+		{name: "JSONを指定した場合はJSONで返すこと", accept: "application/json", wantContentType: "application/json"},
+		{name: "application/msgpackを指定した場合はMessagePackで返すこと", accept: "application/msgpack", wantContentType: "application/msgpack"},
+		{name: "application/x-msgpackを指定した場合はMessagePackで返すこと", accept: "application/x-msgpack", wantContentType: "application/msgpack"},
+		{name: "ワイルドカードの場合はJSONで返すこと", accept: "*/*", wantContentType: "application/json"},
+		{name: "未対応の形式のみを指定した場合はJSONにフォールバックすること", accept: "application/xml", wantContentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/media", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("ステータスコード = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.wantContentType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want %q", got, "Accept")
+			}
+
+			var got payload
+			if tt.wantContentType == "application/msgpack" {
+				var mh codec.MsgpackHandle
+				if err := codec.NewDecoderBytes(w.Body.Bytes(), &mh).Decode(&got); err != nil {
+					t.Fatalf("MessagePackのデコードに失敗: %v", err)
+				}
+			} else if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("JSONのデコードに失敗: %v", err)
+			}
+			if got.ID != "media-1" || got.Size != 1024 {
+				t.Errorf("レスポンス = %+v, want {ID:media-1 Size:1024}", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Skip は指定したパスへのリクエストでミドルウェアmwを実行せずに後続のハンドラへ進むラッパーを返す。
+// ヘルスチェックやメトリクスなど、監視ツールからアクセスされるパスを認証やレート制限の対象外にするために使用する。
+// パスはクエリ文字列を含まないリクエストパスと完全一致で比較する。
+func Skip(paths []string, mw gin.HandlerFunc) gin.HandlerFunc {
+	skip := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		skip[p] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := skip[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+		mw(c)
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSkip はSkipラッパーによるミドルウェアのバイパスを検証する。
+func TestSkip(t *testing.T) {
+	t.Parallel()
+
+	// 常にリクエストを拒否するミドルウェア
+	deny := func(c *gin.Context) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "拒否されました"})
+	}
+
+	router := gin.New()
+	router.Use(Skip([]string{"/health", "/metrics"}, deny))
+	for _, path := range []string{"/health", "/metrics", "/health/detail", "/api/v1/media"} {
+		router.GET(path, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		wantCode int
+	}{
+		{name: "スキップ対象のパスはミドルウェアをバイパスすること", target: "/health", wantCode: http.StatusOK},
+		{name: "複数指定したパスもバイパスすること", target: "/metrics", wantCode: http.StatusOK},
+		{name: "クエリ文字列付きでもバイパスすること", target: "/health?verbose=1", wantCode: http.StatusOK},
+		{name: "前方一致するだけのパスにはミドルウェアを適用すること", target: "/health/detail", wantCode: http.StatusUnauthorized},
+		{name: "スキップ対象外のパスにはミドルウェアを適用すること", target: "/api/v1/media", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("ステータスコード = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+
+	t.Run("JWTAuthと組み合わせてヘルスチェックを認証外にできること", func(t *testing.T) {
+		t.Parallel()
+
+		r := gin.New()
+		r.Use(Skip([]string{"/health"}, JWTAuth(testSecret)))
+		r.GET("/health", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+		r.GET("/protected", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		for target, want := range map[string]int{"/health": http.StatusOK, "/protected": http.StatusUnauthorized} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != want {
+				t.Errorf("%s: ステータスコード = %d, want %d", target, w.Code, want)
+			}
+		}
+	})
+}
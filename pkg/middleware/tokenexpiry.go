@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderTokenExpiring はトークンの有効期限が近いことをクライアントに知らせるレスポンスヘッダーキー。
+	HeaderTokenExpiring = "X-Token-Expiring"
+	// HeaderTokenExpiresAt はトークンの有効期限（RFC3339形式）を知らせるレスポンスヘッダーキー。
+	HeaderTokenExpiresAt = "X-Token-Expires-At"
+)
+
+// TokenExpiryNotice はトークンの残り有効時間がthreshold未満の場合に、
+// レスポンスへX-Token-Expiring: trueとX-Token-Expires-Atヘッダーを付けるGinミドルウェアを返す。
+// クライアントは期限切れの401を受ける前にトークンを更新できる。
+// JWTAuthの後段に適用する。クレームがない、または有効期限を持たないトークンにはヘッダーを付けない。
+func TokenExpiryNotice(threshold time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims != nil && claims.ExpiresAt != nil {
+			expiresAt := claims.ExpiresAt.Time
+			if time.Until(expiresAt) < threshold {
+				c.Header(HeaderTokenExpiring, "true")
+				c.Header(HeaderTokenExpiresAt, expiresAt.UTC().Format(time.RFC3339))
+			}
+		}
+		c.Next()
+	}
+}
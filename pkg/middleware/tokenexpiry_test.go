@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestTokenExpiryNotice はトークンの有効期限が近い場合のヘッダー付与を検証する。
+func TestTokenExpiryNotice(t *testing.T) {
+	t.Parallel()
+
+	// signToken は指定した有効期限を持つテスト用トークンを署名する。expiresAtがnilの場合は有効期限を持たない。
+	signToken := func(t *testing.T, expiresAt *jwt.NumericDate) string {
+		t.Helper()
+		claims := JWTClaims{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: expiresAt},
+			UserID:           "user-expiry",
+		}
+		tokenStr, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+		if err != nil {
+			t.Fatalf("トークンの署名に失敗: %v", err)
+		}
+		return tokenStr
+	}
+
+	router := gin.New()
+	router.Use(JWTAuth(testSecret), TokenExpiryNotice(5*time.Minute))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	soon := time.Now().Add(2 * time.Minute).Truncate(time.Second)
+	tests := []struct {
+		name          string
+		expiresAt     *jwt.NumericDate
+		wantExpiring  string
+		wantExpiresAt string
+	}{
+		{
+			name:          "残り有効時間が閾値未満の場合はヘッダーを付けること",
+			expiresAt:     jwt.NewNumericDate(soon),
+			wantExpiring:  "true",
+			wantExpiresAt: soon.UTC().Format(time.RFC3339),
+		},
+		{
+			name:      "残り有効時間が閾値以上の場合はヘッダーを付けないこと",
+			expiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		{
+			name:      "有効期限を持たないトークンにはヘッダーを付けないこと",
+			expiresAt: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+signToken(t, tt.expiresAt))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("ステータスコード = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get(HeaderTokenExpiring); got != tt.wantExpiring {
+				t.Errorf("%s = %q, want %q", HeaderTokenExpiring, got, tt.wantExpiring)
+			}
+			if got := w.Header().Get(HeaderTokenExpiresAt); got != tt.wantExpiresAt {
+				t.Errorf("%s = %q, want %q", HeaderTokenExpiresAt, got, tt.wantExpiresAt)
+			}
+		})
+	}
+
+	t.Run("JWTAuthの前段に適用してクレームがない場合はヘッダーを付けないこと", func(t *testing.T) {
+		t.Parallel()
+
+		r := gin.New()
+		r.Use(TokenExpiryNotice(5 * time.Minute))
+		r.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+		if got := w.Header().Get(HeaderTokenExpiring); got != "" {
+			t.Errorf("%s = %q, want empty", HeaderTokenExpiring, got)
+		}
+	})
+}
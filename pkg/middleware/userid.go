@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PropagatedUserID はサービス間通信でX-User-IDヘッダーに伝播されたユーザーIDをGinコンテキストに設定するミドルウェアを返す。
+// 設定したユーザーIDはJWTAuthと同様にGetUserIDで取得できる。ヘッダーがない場合は401を返す。
+// ヘッダーの値は検証しないため、Docker内部ネットワークからのみ到達できる内部APIにだけ適用すること。
+func PropagatedUserID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetHeader(headerKeyUserID)
+		if userID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "X-User-IDヘッダーが必要です",
+			})
+			return
+		}
+
+		c.Set("user_id", userID)
+		c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPropagatedUserID はX-User-IDヘッダーからのユーザーIDの取り出しを検証する。
+func TestPropagatedUserID(t *testing.T) {
+	t.Parallel()
+
+	router := gin.New()
+	router.Use(PropagatedUserID())
+	router.GET("/internal", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": GetUserID(c)})
+	})
+
+	t.Run("ヘッダーのユーザーIDがコンテキストに設定されること", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/internal", nil)
+		req.Header.Set("X-User-ID", "user-internal")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ステータスコード = %d, want %d", w.Code, http.StatusOK)
+		}
+		if want := `{"user_id":"user-internal"}`; w.Body.String() != want {
+			t.Errorf("body = %s, want %s", w.Body.String(), want)
+		}
+	})
+
+	t.Run("ヘッダーがない場合は401を返すこと", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/internal", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("ステータスコード = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+}
@@ -0,0 +1,6 @@
+// Package query は一覧系APIのページング・並び替え・絞り込みのクエリパラメータを解析する。
+//
+// limit、cursor / offset、sort、order、filter[field]=value の標準パラメータを
+// エンドポイントごとに許可した範囲（Spec）で検証し、Paramsに変換する。
+// ハンドラはParamsをsqlcのパラメータに変換して使用し、不正な入力には共通の形式で400を返す。
+package query
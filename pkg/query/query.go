@@ -0,0 +1,186 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Order は並び順（昇順・降順）。
+type Order string
+
+const (
+	// Asc は昇順。
+	Asc Order = "asc"
+	// Desc は降順。
+	Desc Order = "desc"
+)
+
+// filterPrefix と filterSuffix は絞り込み条件のクエリパラメータ名（filter[field]）の前後の文字列。
+const (
+	filterPrefix = "filter["
+	filterSuffix = "]"
+)
+
+// Spec はエンドポイントが受け付けるクエリパラメータの範囲。
+// 許可していないパラメータ（sort、filterのフィールド、cursor、offset）が指定された場合は不正な入力として扱う。
+type Spec struct {
+	// DefaultLimit はlimit省略時の件数。
+	DefaultLimit int
+	// MaxLimit はlimitの最大値。
+	MaxLimit int
+	// ClampLimit はMaxLimitを超えるlimitをエラーにせずMaxLimitに丸めるか。
+	ClampLimit bool
+	// Cursor はcursorによるページングを許可するか。
+	Cursor bool
+	// Offset はoffsetによるページングを許可するか。cursorとの同時指定はできない。
+	Offset bool
+	// Sorts は並び替えに使用できるフィールド。先頭をsort省略時の既定とする。空の場合はsortを受け付けない。
+	Sorts []string
+	// DefaultOrder はorder省略時の並び順。空の場合は昇順とする。
+	DefaultOrder Order
+	// Filters は絞り込みに使用できるフィールド。
+	Filters []string
+}
+
+// Params は検証済みのクエリパラメータ。
+type Params struct {
+	// Limit は1ページあたりの件数。
+	Limit int
+	// Cursor は前ページのnext_cursor。先頭ページの場合は空文字。
+	Cursor string
+	// Offset は読み飛ばす件数。
+	Offset int
+	// Sort は並び替えのフィールド。Spec.Sortsが空の場合は空文字。
+	Sort string
+	// Order は並び順。
+	Order Order
+	// Filters はフィールドごとの絞り込み条件。指定されなかったフィールドは含まない。
+	Filters map[string]string
+}
+
+// Filter は絞り込み条件を返す。指定されていない場合はokがfalseになる。
+func (p Params) Filter(field string) (value string, ok bool) {
+	value, ok = p.Filters[field]
+	return value, ok
+}
+
+// Parse はクエリパラメータvaluesをspecに従って検証し、Paramsに変換する。
+// 空の値は省略と同じに扱い、標準以外のパラメータは複数指定も含めて無視する。標準のパラメータの複数指定はエラーとする。
+// 不正な入力の場合はクライアントにそのまま返せるエラーメッセージを持つエラーを返す。
+func Parse(values url.Values, spec Spec) (Params, error) {
+	p := Params{
+		Limit:   spec.DefaultLimit,
+		Order:   spec.DefaultOrder,
+		Filters: map[string]string{},
+	}
+	if p.Order == "" {
+		p.Order = Asc
+	}
+	if len(spec.Sorts) > 0 {
+		p.Sort = spec.Sorts[0]
+	}
+
+	if values.Get("cursor") != "" && values.Get("offset") != "" {
+		return Params{}, errors.New("cursorとoffsetは同時に指定できません")
+	}
+	// 複数の不正な入力がある場合も毎回同じエラーを返すよう、パラメータ名の順に検証する
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		// 標準以外のパラメータは各ハンドラが解釈するため、複数指定も含めて検証しない
+		if !isStandardKey(key) {
+			continue
+		}
+		vs := values[key]
+		if len(vs) > 1 {
+			return Params{}, fmt.Errorf("%sは1回だけ指定してください", key)
+		}
+		v := vs[0]
+		// 既存のクライアントとの互換性のため、空の値は省略と同じに扱う
+		if v == "" {
+			continue
+		}
+		switch {
+		case key == "limit":
+			n, err := strconv.Atoi(v)
+			if spec.ClampLimit {
+				if err != nil || n < 1 {
+					return Params{}, errors.New("limitは1以上の整数で指定してください")
+				}
+				n = min(n, spec.MaxLimit)
+			} else if err != nil || n < 1 || n > spec.MaxLimit {
+				return Params{}, fmt.Errorf("limitは1から%dまでの整数で指定してください", spec.MaxLimit)
+			}
+			p.Limit = n
+		case key == "cursor":
+			if !spec.Cursor {
+				return Params{}, errors.New("cursorは指定できません")
+			}
+			p.Cursor = v
+		case key == "offset":
+			if !spec.Offset {
+				return Params{}, errors.New("offsetは指定できません")
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return Params{}, errors.New("offsetは0以上の整数で指定してください")
+			}
+			p.Offset = n
+		case key == "sort":
+			if !slices.Contains(spec.Sorts, v) {
+				return Params{}, unsupportedError("sort", v, spec.Sorts)
+			}
+			p.Sort = v
+		case key == "order":
+			switch Order(strings.ToLower(v)) {
+			case Asc:
+				p.Order = Asc
+			case Desc:
+				p.Order = Desc
+			default:
+				return Params{}, fmt.Errorf("orderはascまたはdescで指定してください: %q", v)
+			}
+		case strings.HasPrefix(key, filterPrefix) && strings.HasSuffix(key, filterSuffix):
+			field := strings.TrimSuffix(strings.TrimPrefix(key, filterPrefix), filterSuffix)
+			if !slices.Contains(spec.Filters, field) {
+				return Params{}, unsupportedError("filter", field, spec.Filters)
+			}
+			p.Filters[field] = v
+		}
+	}
+	return p, nil
+}
+
+// isStandardKey はkeyがこのパッケージで解釈する標準のパラメータ（limit、cursor、offset、sort、order、filter[...]）かを返す。
+func isStandardKey(key string) bool {
+	switch key {
+	case "limit", "cursor", "offset", "sort", "order":
+		return true
+	}
+	return strings.HasPrefix(key, filterPrefix) && strings.HasSuffix(key, filterSuffix)
+}
+
+// ParseRequest はリクエストのクエリパラメータをspecに従って解析する。
+// 不正な入力の場合は400を返してハンドラを打ち切り、okにfalseを返す。
+func ParseRequest(c *gin.Context, spec Spec) (p Params, ok bool) {
+	p, err := Parse(c.Request.URL.Query(), spec)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return Params{}, false
+	}
+	return p, true
+}
+
+// unsupportedError はnameに許可されていない値vが指定された場合のエラーを返す。
+func unsupportedError(name, v string, allowed []string) error {
+	if len(allowed) == 0 {
+		return fmt.Errorf("%sは指定できません: %q", name, v)
+	}
+	return fmt.Errorf("%sは%sのいずれかで指定してください: %q", name, strings.Join(allowed, ", "), v)
+}
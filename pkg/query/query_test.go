@@ -0,0 +1,175 @@
+package query
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testSpec はテストで使用する、すべての標準パラメータを許可したSpec。
+var testSpec = Spec{
+	DefaultLimit: 20,
+	MaxLimit:     100,
+	Cursor:       true,
+	Offset:       true,
+	Sorts:        []string{"created_at", "name"},
+	DefaultOrder: Desc,
+	Filters:      []string{"status", "content_type"},
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   string
+		spec    Spec
+		want    Params
+		wantErr string
+	}{
+		{
+			name:  "正常系_省略時は既定値",
+			query: "",
+			spec:  testSpec,
+			want:  Params{Limit: 20, Sort: "created_at", Order: Desc, Filters: map[string]string{}},
+		},
+		{
+			name:  "正常系_すべてのパラメータを指定",
+			query: "limit=50&cursor=abc&sort=name&order=ASC&filter[status]=processed&filter[content_type]=image/png",
+			spec:  testSpec,
+			want: Params{
+				Limit:   50,
+				Cursor:  "abc",
+				Sort:    "name",
+				Order:   Asc,
+				Filters: map[string]string{"status": "processed", "content_type": "image/png"},
+			},
+		},
+		{
+			name:  "正常系_offsetによるページング",
+			query: "offset=40&limit=100",
+			spec:  testSpec,
+			want:  Params{Limit: 100, Offset: 40, Sort: "created_at", Order: Desc, Filters: map[string]string{}},
+		},
+		{
+			name:  "正常系_空の値と標準以外のパラメータは無視する",
+			query: "limit=&order=&filter[status]=&type=Media",
+			spec:  testSpec,
+			want:  Params{Limit: 20, Sort: "created_at", Order: Desc, Filters: map[string]string{}},
+		},
+		{
+			name:  "正常系_標準以外のパラメータは複数指定しても無視する",
+			query: "type=a&type=b&limit=5",
+			spec:  testSpec,
+			want:  Params{Limit: 5, Sort: "created_at", Order: Desc, Filters: map[string]string{}},
+		},
+		{
+			name:  "正常系_sortを許可しない場合はSortが空でOrderは昇順",
+			query: "limit=1",
+			spec:  Spec{DefaultLimit: 10, MaxLimit: 10},
+			want:  Params{Limit: 1, Order: Asc, Filters: map[string]string{}},
+		},
+		{
+			name:  "正常系_ClampLimitの場合は最大値を超えるlimitを最大値に丸める",
+			query: "limit=500",
+			spec:  Spec{DefaultLimit: 10, MaxLimit: 100, ClampLimit: true},
+			want:  Params{Limit: 100, Order: Asc, Filters: map[string]string{}},
+		},
+		{name: "異常系_limitが0", query: "limit=0", spec: testSpec, wantErr: "limitは1から100までの整数で指定してください"},
+		{name: "異常系_ClampLimitの場合も負のlimitはエラー", query: "limit=-1", spec: Spec{MaxLimit: 100, ClampLimit: true}, wantErr: "limitは1以上の整数で指定してください"},
+		{name: "異常系_ClampLimitの場合も数値でないlimitはエラー", query: "limit=abc", spec: Spec{MaxLimit: 100, ClampLimit: true}, wantErr: "limitは1以上の整数で指定してください"},
+		{name: "異常系_limitが最大値を超える", query: "limit=101", spec: testSpec, wantErr: "limitは1から100までの整数で指定してください"},
+		{name: "異常系_limitが数値でない", query: "limit=abc", spec: testSpec, wantErr: "limitは1から100までの整数で指定してください"},
+		{name: "異常系_offsetが負", query: "offset=-1", spec: testSpec, wantErr: "offsetは0以上の整数で指定してください"},
+		{name: "異常系_cursorとoffsetの同時指定", query: "cursor=abc&offset=10", spec: testSpec, wantErr: "cursorとoffsetは同時に指定できません"},
+		{name: "異常系_cursorを許可しない", query: "cursor=abc", spec: Spec{MaxLimit: 10}, wantErr: "cursorは指定できません"},
+		{name: "異常系_offsetを許可しない", query: "offset=1", spec: Spec{MaxLimit: 10}, wantErr: "offsetは指定できません"},
+		{name: "異常系_許可していないsort", query: "sort=size", spec: testSpec, wantErr: `sortはcreated_at, nameのいずれかで指定してください: "size"`},
+		{name: "異常系_sortを許可しない", query: "sort=name", spec: Spec{MaxLimit: 10}, wantErr: `sortは指定できません: "name"`},
+		{name: "異常系_不正なorder", query: "order=up", spec: testSpec, wantErr: `orderはascまたはdescで指定してください: "up"`},
+		{name: "異常系_許可していないfilter", query: "filter[user_id]=u1", spec: testSpec, wantErr: `filterはstatus, content_typeのいずれかで指定してください: "user_id"`},
+		{name: "異常系_同じパラメータの複数指定", query: "limit=1&limit=2", spec: testSpec, wantErr: "limitは1回だけ指定してください"},
+		{name: "異常系_同じfilterの複数指定", query: "filter[status]=a&filter[status]=b", spec: testSpec, wantErr: "filter[status]は1回だけ指定してください"},
+		{name: "異常系_複数の不正な入力はパラメータ名の順に検証する", query: "sort=size&limit=0", spec: testSpec, wantErr: "limitは1から100までの整数で指定してください"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("クエリの解析に失敗: %v", err)
+			}
+			got, err := Parse(values, tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("期待するエラー %q, 実際のエラー %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("期待する値 %+v, 実際の値 %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	serve := func(t *testing.T, rawQuery string) (*httptest.ResponseRecorder, Params) {
+		t.Helper()
+		var got Params
+		router := gin.New()
+		router.GET("/items", func(c *gin.Context) {
+			p, ok := ParseRequest(c, testSpec)
+			if !ok {
+				return
+			}
+			got = p
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?"+rawQuery, nil))
+		return w, got
+	}
+
+	t.Run("正常系_検証済みのパラメータを返す", func(t *testing.T) {
+		t.Parallel()
+
+		w, got := serve(t, "limit=5&filter[status]=processed")
+		if w.Code != http.StatusOK {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusOK, w.Code)
+		}
+		if got.Limit != 5 {
+			t.Errorf("期待するlimit 5, 実際のlimit %d", got.Limit)
+		}
+		if v, ok := got.Filter("status"); !ok || v != "processed" {
+			t.Errorf("期待するfilter[status] processed, 実際の値 %q (指定あり=%v)", v, ok)
+		}
+		if _, ok := got.Filter("content_type"); ok {
+			t.Error("指定していないfilterが含まれています")
+		}
+	})
+
+	t.Run("異常系_不正な入力は400とエラーメッセージを返す", func(t *testing.T) {
+		t.Parallel()
+
+		w, _ := serve(t, "order=up")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("期待するステータスコード %d, 実際のステータスコード %d", http.StatusBadRequest, w.Code)
+		}
+		want := `{"error":"orderはascまたはdescで指定してください: \"up\""}`
+		if w.Body.String() != want {
+			t.Errorf("期待するレスポンス %s, 実際のレスポンス %s", want, w.Body.String())
+		}
+	})
+}